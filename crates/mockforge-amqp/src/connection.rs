@@ -176,6 +176,71 @@ fn extract_string_arg(table: &[u8], key: &str) -> Option<String> {
     None
 }
 
+/// Read the `headers` property of a published message into strings, so
+/// header exchanges can route on it and tests can verify it. Strings,
+/// integers, and booleans are kept; other values are skipped. Parsing
+/// stops at the first unknown tag, keeping the headers read so far.
+fn parse_header_table(table: &[u8]) -> HashMap<String, String> {
+    let mut headers = HashMap::new();
+    if table.len() < 4 {
+        return headers;
+    }
+    let body_len = u32::from_be_bytes([table[0], table[1], table[2], table[3]]) as usize;
+    let Some(body) = table.get(4..4 + body_len) else {
+        return headers;
+    };
+
+    let mut i = 0usize;
+    while i < body.len() {
+        let Some(&name_len) = body.get(i) else { break };
+        let Some(name_bytes) = body.get(i + 1..i + 1 + name_len as usize) else {
+            break;
+        };
+        i += 1 + name_len as usize;
+        let Some(&tag) = body.get(i) else { break };
+        i += 1;
+
+        let width = match tag {
+            b't' | b'b' | b'B' => 1,
+            b's' | b'u' => 2,
+            b'I' | b'i' | b'f' => 4,
+            b'l' | b'L' | b'd' | b'T' => 8,
+            b'V' => 0,
+            b'S' | b'x' | b'F' | b'A' => match body.get(i..i + 4) {
+                Some(b) => 4 + u32::from_be_bytes([b[0], b[1], b[2], b[3]]) as usize,
+                None => break,
+            },
+            _ => break,
+        };
+        let Some(b) = body.get(i..i + width) else {
+            break;
+        };
+        i += width;
+
+        let value = match tag {
+            b'S' => Some(String::from_utf8_lossy(&b[4..]).into_owned()),
+            b't' => Some((b[0] != 0).to_string()),
+            b'b' => Some((b[0] as i8).to_string()),
+            b'B' => Some(b[0].to_string()),
+            b's' => Some(i16::from_be_bytes([b[0], b[1]]).to_string()),
+            b'u' => Some(u16::from_be_bytes([b[0], b[1]]).to_string()),
+            b'I' => Some(i32::from_be_bytes([b[0], b[1], b[2], b[3]]).to_string()),
+            b'i' => Some(u32::from_be_bytes([b[0], b[1], b[2], b[3]]).to_string()),
+            b'l' => Some(
+                i64::from_be_bytes([b[0], b[1], b[2], b[3], b[4], b[5], b[6], b[7]]).to_string(),
+            ),
+            b'L' => Some(
+                u64::from_be_bytes([b[0], b[1], b[2], b[3], b[4], b[5], b[6], b[7]]).to_string(),
+            ),
+            _ => None,
+        };
+        if let Some(value) = value {
+            headers.insert(String::from_utf8_lossy(name_bytes).into_owned(), value);
+        }
+    }
+    headers
+}
+
 /// Connection state machine
 #[derive(Debug, Clone, PartialEq)]
 pub enum ConnectionState {
@@ -1873,7 +1938,7 @@ impl AmqpConnection {
 
                 // Route pending messages
                 for (exchange, routing_key, message) in pending_publishes {
-                    self.route_message(&exchange, &routing_key, message).await;
+                    self.publish_message(&exchange, &routing_key, message).await;
                 }
 
                 // Process pending acks
@@ -2005,9 +2070,8 @@ impl AmqpConnection {
                 payload[offset + 2],
                 payload[offset + 3],
             ]) as usize;
-            offset += 4;
-            // Parse headers (simplified - just skip for now)
-            offset += table_len;
+            props.headers = parse_header_table(&payload[offset..]);
+            offset += 4 + table_len;
         }
 
         // Delivery-mode (bit 12)
@@ -2146,7 +2210,8 @@ impl AmqpConnection {
                     }
                 } else {
                     // Route immediately
-                    self.route_message(&state.exchange, &state.routing_key, message.clone()).await;
+                    self.publish_message(&state.exchange, &state.routing_key, message.clone())
+                        .await;
 
                     // Send publisher confirm if enabled
                     if let Some(channel_state) = self.channels.get_mut(&ch) {
@@ -2164,6 +2229,12 @@ impl AmqpConnection {
         Ok(true)
     }
 
+    /// Record a client publish and route it to the bound queues
+    async fn publish_message(&mut self, exchange_name: &str, routing_key: &str, message: Message) {
+        self.exchanges.write().await.record_publish(exchange_name, routing_key, &message);
+        self.route_message(exchange_name, routing_key, message).await;
+    }
+
     /// Route a message through exchanges to queues
     async fn route_message(&mut self, exchange_name: &str, routing_key: &str, message: Message) {
         let target_queues = {
@@ -2371,11 +2442,15 @@ impl AmqpConnection {
             props_data.extend_from_slice(ce.as_bytes());
         }
 
-        // Headers (bit 13) - skip for now
+        // Headers (bit 13), as long strings
         if !message.properties.headers.is_empty() {
             flags |= 0x2000;
-            // Empty table for now
-            props_data.extend_from_slice(&0u32.to_be_bytes());
+            let mut table = Vec::new();
+            for (name, value) in &message.properties.headers {
+                self.add_field_table_string(&mut table, name, value);
+            }
+            props_data.extend_from_slice(&(table.len() as u32).to_be_bytes());
+            props_data.extend_from_slice(&table);
         }
 
         // Delivery mode
@@ -2507,6 +2582,43 @@ mod tests {
         assert_ne!(tag1, tag2);
     }
 
+    #[test]
+    fn test_parse_header_table() {
+        let mut body = Vec::new();
+        body.extend_from_slice(b"\x08x-tenant");
+        body.push(b'S');
+        body.extend_from_slice(&4u32.to_be_bytes());
+        body.extend_from_slice(b"acme");
+        body.extend_from_slice(b"\x05flags");
+        body.push(b'F');
+        body.extend_from_slice(&0u32.to_be_bytes());
+        body.extend_from_slice(b"\x07attempt");
+        body.push(b'I');
+        body.extend_from_slice(&3i32.to_be_bytes());
+        body.extend_from_slice(b"\x06urgent");
+        body.push(b't');
+        body.push(1);
+        let mut table = (body.len() as u32).to_be_bytes().to_vec();
+        table.extend_from_slice(&body);
+
+        let headers = parse_header_table(&table);
+        assert_eq!(headers.len(), 3);
+        assert_eq!(headers["x-tenant"], "acme");
+        assert_eq!(headers["attempt"], "3");
+        assert_eq!(headers["urgent"], "true");
+
+        // An unknown tag keeps the headers read before it
+        let mut body = b"\x08x-tenant".to_vec();
+        body.push(b'S');
+        body.extend_from_slice(&4u32.to_be_bytes());
+        body.extend_from_slice(b"acme\x03badZ\x01");
+        let mut table = (body.len() as u32).to_be_bytes().to_vec();
+        table.extend_from_slice(&body);
+        let headers = parse_header_table(&table);
+        assert_eq!(headers.len(), 1);
+        assert_eq!(headers["x-tenant"], "acme");
+    }
+
     #[test]
     fn test_unacked_message() {
         let msg = UnackedMessage {
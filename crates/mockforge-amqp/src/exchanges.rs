@@ -1,7 +1,8 @@
 use crate::bindings::Binding;
 use crate::messages::Message;
 use serde::{Deserialize, Serialize};
-use std::collections::HashMap;
+use std::collections::{HashMap, VecDeque};
+use std::time::{SystemTime, UNIX_EPOCH};
 
 /// Exchange types supported by AMQP
 #[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
@@ -137,6 +138,20 @@ impl Exchange {
 #[derive(Debug)]
 pub struct ExchangeManager {
     exchanges: HashMap<String, Exchange>,
+    published: VecDeque<PublishedMessage>,
+}
+
+/// Number of client-published messages kept for inspection
+const PUBLISHED_LOG_CAPACITY: usize = 1000;
+
+/// A message a client published, kept so tests can verify publishers
+#[derive(Debug, Clone)]
+pub struct PublishedMessage {
+    pub exchange: String,
+    pub routing_key: String,
+    pub message: Message,
+    /// Unix timestamp in milliseconds
+    pub timestamp: u64,
 }
 
 impl Default for ExchangeManager {
@@ -149,7 +164,30 @@ impl ExchangeManager {
     pub fn new() -> Self {
         Self {
             exchanges: HashMap::new(),
+            published: VecDeque::new(),
+        }
+    }
+
+    /// Record a message a client published, dropping the oldest once the
+    /// log is full
+    pub fn record_publish(&mut self, exchange: &str, routing_key: &str, message: &Message) {
+        if self.published.len() == PUBLISHED_LOG_CAPACITY {
+            self.published.pop_front();
         }
+        self.published.push_back(PublishedMessage {
+            exchange: exchange.to_string(),
+            routing_key: routing_key.to_string(),
+            message: message.clone(),
+            timestamp: SystemTime::now()
+                .duration_since(UNIX_EPOCH)
+                .unwrap_or_default()
+                .as_millis() as u64,
+        });
+    }
+
+    /// Messages clients published, oldest first
+    pub fn published_messages(&self) -> Vec<PublishedMessage> {
+        self.published.iter().cloned().collect()
     }
 
     pub fn declare_exchange(
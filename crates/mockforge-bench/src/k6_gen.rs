@@ -292,6 +292,21 @@ impl K6ScriptGenerator {
         result
     }
 
+    /// Make `name` unique among `used` by appending `_2`, `_3`, ...
+    ///
+    /// Examples:
+    /// - "plans_list" (first use) -> "plans_list"
+    /// - "plans_list" (second use) -> "plans_list_2"
+    fn unique_name(name: String, used: &mut HashSet<String>) -> String {
+        let mut candidate = name.clone();
+        let mut suffix = 2;
+        while !used.insert(candidate.clone()) {
+            candidate = format!("{}_{}", name, suffix);
+            suffix += 1;
+        }
+        candidate
+    }
+
     /// Build the typed template data for rendering.
     fn build_template_data(&self) -> Result<K6ScriptTemplateData> {
         let stages = self
@@ -305,19 +320,31 @@ impl K6ScriptGenerator {
         // Track all placeholders used across all operations
         let mut all_placeholders: HashSet<DynamicPlaceholder> = HashSet::new();
 
+        // Distinct operations can sanitize to the same identifier (e.g.
+        // `plans.list` and `plans_list`, or one operationId in two merged
+        // specs); declaring the same `const` twice is a syntax error.
+        let mut used_names: HashSet<String> = HashSet::new();
+        let mut used_metric_names: HashSet<String> = HashSet::new();
+
         let operations = self
             .templates
             .iter()
             .enumerate()
             .map(|(idx, template)| {
                 let display_name = template.operation.display_name();
-                let sanitized_name = Self::sanitize_js_identifier(&display_name);
+                let sanitized_name = Self::unique_name(
+                    Self::sanitize_js_identifier(&display_name),
+                    &mut used_names,
+                );
                 // metric_name must satisfy k6's 128-char limit AND leave room
                 // for suffixes like `_latency` / `_errors` / `_stepN_*`.
                 // Long deeply-nested operationIds (e.g. Microsoft Graph) exceed
                 // this; sanitize_k6_metric_name truncates with a hash suffix
                 // for uniqueness. (See issue #79 — Srikanth's microsoft-graph.yaml run.)
-                let metric_name = Self::sanitize_k6_metric_name(&display_name);
+                let metric_name = Self::unique_name(
+                    Self::sanitize_k6_metric_name(&display_name),
+                    &mut used_metric_names,
+                );
                 // k6 uses 'del' instead of 'delete' for HTTP DELETE method
                 let k6_method = match template.operation.method.to_lowercase().as_str() {
                     "delete" => "del".to_string(),
@@ -464,6 +491,7 @@ impl K6ScriptGenerator {
     /// Checks for:
     /// - Invalid metric names (contains dots or special characters)
     /// - Invalid JavaScript variable names
+    /// - Top-level `const` declarations repeating a name
     /// - Missing required k6 imports
     ///
     /// Returns a list of validation errors, empty if all checks pass.
@@ -485,9 +513,28 @@ impl K6ScriptGenerator {
         // k6 metric names must only contain ASCII letters, numbers, or underscores
         // and start with a letter or underscore
         let lines: Vec<&str> = script.lines().collect();
+        let mut top_level_consts: HashSet<&str> = HashSet::new();
         for (line_num, line) in lines.iter().enumerate() {
             let trimmed = line.trim();
 
+            // Redeclaring a top-level const is a SyntaxError; nested blocks
+            // (indented) may legitimately shadow names like `res`
+            if let Some(rest) = line.strip_prefix("const ") {
+                let name = rest
+                    .split(|c: char| c.is_whitespace() || c == '=')
+                    .next()
+                    .unwrap_or("");
+                let is_identifier =
+                    name.starts_with(|c: char| c.is_ascii_alphabetic() || c == '_' || c == '$');
+                if is_identifier && !top_level_consts.insert(name) {
+                    errors.push(format!(
+                        "Line {}: Duplicate declaration of '{}'. Each operation needs a unique variable name.",
+                        line_num + 1,
+                        name
+                    ));
+                }
+            }
+
             // Check for Trend/Rate constructors with invalid metric names
             if trimmed.contains("new Trend(") || trimmed.contains("new Rate(") {
                 // Extract the metric name from the string literal
@@ -862,6 +909,61 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_script_generation_with_colliding_names() {
+        use crate::spec_parser::ApiOperation;
+        use openapiv3::Operation;
+
+        // Both operationIds sanitize to `plans_list`
+        let templates = [("/plans", "plans.list"), ("/v2/plans", "plans_list")]
+            .iter()
+            .map(|(path, operation_id)| RequestTemplate {
+                operation: ApiOperation {
+                    method: "get".to_string(),
+                    path: path.to_string(),
+                    operation: Operation::default(),
+                    operation_id: Some(operation_id.to_string()),
+                },
+                path_params: HashMap::new(),
+                query_params: HashMap::new(),
+                headers: HashMap::new(),
+                body: None,
+            })
+            .collect();
+
+        let config = K6Config {
+            target_url: "https://api.example.com".to_string(),
+            base_path: None,
+            scenario: LoadScenario::Constant,
+            duration_secs: 30,
+            max_vus: 5,
+            threshold_percentile: "p(95)".to_string(),
+            threshold_ms: 500,
+            max_error_rate: 0.05,
+            auth_header: None,
+            custom_headers: HashMap::new(),
+            skip_tls_verify: false,
+            security_testing_enabled: false,
+            chunked_request_bodies: false,
+            target_rps: None,
+            no_keep_alive: false,
+            geo_source_ips: Vec::new(),
+            geo_source_headers: Vec::new(),
+        };
+
+        let script = K6ScriptGenerator::new(config, templates)
+            .generate()
+            .expect("Should generate script");
+
+        assert!(script.contains("const plans_list_latency = new Trend('plans_list_latency');"));
+        assert!(
+            script.contains("const plans_list_2_latency = new Trend('plans_list_2_latency');")
+        );
+        assert!(script.contains("plans_list_2_latency.add"));
+        let errors = K6ScriptGenerator::validate_script(&script);
+        assert!(errors.is_empty(), "Colliding names should be made unique: {errors:#?}");
+    }
+
     /// Issue #79 (round 5) regression: `--rps` with the default `ramp-up`
     /// scenario produced 0 requests because the script took
     /// `preAllocatedVUs` from the *last* stage's target — and ramp-up's last
@@ -1214,6 +1316,27 @@ export default function() {}
         assert!(!errors.is_empty(), "Script missing imports should have validation errors");
     }
 
+    #[test]
+    fn test_validate_script_duplicate_declaration() {
+        let invalid_script = r#"
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { Rate, Trend } from 'k6/metrics';
+const plans_list_latency = new Trend('plans_list_latency');
+const plans_list_latency = new Trend('plans_list_latency');
+export default function() {
+    const res = http.get('https://example.com/a');
+}
+export function teardown() {
+    const res = http.get('https://example.com/b');
+}
+"#;
+
+        let errors = K6ScriptGenerator::validate_script(invalid_script);
+        assert_eq!(errors.len(), 1, "Only the top-level redeclaration is an error: {errors:?}");
+        assert!(errors[0].contains("Duplicate declaration of 'plans_list_latency'"));
+    }
+
     #[test]
     fn test_validate_script_metric_name_validation() {
         // Test that validate_script correctly identifies invalid metric names
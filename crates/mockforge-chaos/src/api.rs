@@ -446,14 +446,8 @@ async fn start_scenario(
     State(state): State<ChaosApiState>,
     Path(name): Path<String>,
 ) -> Result<Json<StatusResponse>, ChaosApiError> {
-    let scenario = match name.as_str() {
-        "network_degradation" => PredefinedScenarios::network_degradation(),
-        "service_instability" => PredefinedScenarios::service_instability(),
-        "cascading_failure" => PredefinedScenarios::cascading_failure(),
-        "peak_traffic" => PredefinedScenarios::peak_traffic(),
-        "slow_backend" => PredefinedScenarios::slow_backend(),
-        _ => return Err(ChaosApiError::NotFound(format!("Scenario '{}' not found", name))),
-    };
+    let scenario = PredefinedScenarios::by_name(&name)
+        .ok_or_else(|| ChaosApiError::NotFound(format!("Scenario '{}' not found", name)))?;
 
     state.scenario_engine.start_scenario(scenario.clone());
 
@@ -777,13 +771,9 @@ async fn start_recording(
     Json(req): Json<StartRecordingRequest>,
 ) -> Result<Json<StatusResponse>, ChaosApiError> {
     // Get the scenario based on name
-    let scenario = match req.scenario_name.as_str() {
-        "network_degradation" => PredefinedScenarios::network_degradation(),
-        "service_instability" => PredefinedScenarios::service_instability(),
-        "cascading_failure" => PredefinedScenarios::cascading_failure(),
-        "peak_traffic" => PredefinedScenarios::peak_traffic(),
-        "slow_backend" => PredefinedScenarios::slow_backend(),
-        _ => {
+    let scenario = match PredefinedScenarios::by_name(&req.scenario_name) {
+        Some(scenario) => scenario,
+        None => {
             // Check if it's an active scenario
             let active_scenarios = state.scenario_engine.get_active_scenarios();
             active_scenarios
@@ -87,7 +87,7 @@ pub struct ChaosConfig {
 }
 
 /// Latency injection configuration
-#[derive(Debug, Clone, Serialize, Deserialize)]
+#[derive(Debug, Clone, Serialize, Deserialize, PartialEq)]
 pub struct LatencyConfig {
     /// Enable latency injection
     pub enabled: bool,
@@ -188,7 +188,7 @@ impl Default for FaultInjectionConfig {
 }
 
 /// Rate limiting configuration
-#[derive(Debug, Clone, Serialize, Deserialize)]
+#[derive(Debug, Clone, Serialize, Deserialize, PartialEq)]
 pub struct RateLimitConfig {
     /// Enable rate limiting
     pub enabled: bool,
@@ -0,0 +1,318 @@
+//! Per-request faults on the connection a response is written to.
+//!
+//! `FaultTcpListener` wraps a listener of TCP streams and pairs every
+//! accepted connection with a [`ConnectionControl`]. A handler holding the
+//! control can swap the response it is about to return for a
+//! connection-level fault, which takes effect on the next write hyper makes
+//! to the socket — the handler's own response. On HTTP/2 connections that
+//! write may belong to another stream, so only HTTP/1 requests should inject
+//! faults.
+//!
+//! ```text
+//! handler ──▶ ConnectionControl::inject ──▶ hyper writes the response
+//!                                            ├─▶ Reset → RST, nothing written
+//!                                            ├─▶ Close → FIN, nothing written
+//!                                            └─▶ Raw   → bytes verbatim, then FIN
+//! ```
+
+use axum::body::Bytes;
+use axum::extract::connect_info::Connected;
+use axum::serve::{IncomingStream, Listener};
+use std::{
+    io,
+    net::SocketAddr,
+    pin::Pin,
+    sync::{Arc, Mutex},
+    task::{ready, Context, Poll},
+    time::Duration,
+};
+use tokio::io::{AsyncRead, AsyncWrite, ReadBuf};
+use tokio::net::TcpStream;
+use tracing::warn;
+
+/// A fault written to a connection in place of a response
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum ConnectionFault {
+    /// Drop the connection with an RST before writing anything
+    Reset,
+    /// Close the connection with a FIN before writing anything
+    Close,
+    /// Write these bytes in place of the response, then close the connection
+    Raw(Bytes),
+}
+
+impl ConnectionFault {
+    /// Label recorded in the chaos fault metrics
+    fn metric_label(&self) -> &'static str {
+        match self {
+            Self::Reset => "tcp_reset",
+            Self::Close => "tcp_close",
+            Self::Raw(_) => "raw_response",
+        }
+    }
+}
+
+/// Handle for injecting a fault into the connection a request arrived on
+#[derive(Debug, Clone, Default)]
+pub struct ConnectionControl {
+    pending: Arc<Mutex<Option<ConnectionFault>>>,
+}
+
+impl ConnectionControl {
+    /// Replace the next response written to the connection with `fault`
+    pub fn inject(&self, fault: ConnectionFault) {
+        *self.pending.lock().unwrap_or_else(|e| e.into_inner()) = Some(fault);
+    }
+
+    fn take(&self) -> Option<ConnectionFault> {
+        self.pending.lock().unwrap_or_else(|e| e.into_inner()).take()
+    }
+}
+
+/// Progress of an injected fault
+enum FaultState {
+    /// No fault injected; writes go to the socket
+    Passthrough,
+    /// Writing `bytes[written..]` in place of the response
+    Raw { bytes: Bytes, written: usize },
+    /// Closing the write side after a raw response
+    Closing,
+    /// The fault is complete; writes fail so hyper drops the connection
+    Done,
+}
+
+/// TCP stream whose responses can be replaced through its
+/// [`ConnectionControl`]
+pub struct FaultStream {
+    inner: TcpStream,
+    control: ConnectionControl,
+    state: FaultState,
+}
+
+impl FaultStream {
+    /// Wrap an accepted TCP stream
+    pub fn new(inner: TcpStream) -> Self {
+        Self {
+            inner,
+            control: ConnectionControl::default(),
+            state: FaultState::Passthrough,
+        }
+    }
+
+    /// The handle for injecting faults into this connection
+    pub fn control(&self) -> &ConnectionControl {
+        &self.control
+    }
+
+    fn start(&mut self, fault: ConnectionFault) {
+        crate::metrics::CHAOS_METRICS.record_fault(fault.metric_label(), "_connection");
+        self.state = match fault {
+            ConnectionFault::Reset => {
+                // As in the chaos listener, SO_LINGER=0 makes dropping the
+                // stream send an RST rather than a FIN
+                #[allow(deprecated)]
+                if let Err(e) = self.inner.set_linger(Some(Duration::ZERO)) {
+                    warn!("[chaos] set_linger(0) failed: {} — falling back to FIN", e);
+                }
+                FaultState::Done
+            }
+            ConnectionFault::Close => FaultState::Done,
+            ConnectionFault::Raw(bytes) => FaultState::Raw { bytes, written: 0 },
+        };
+    }
+}
+
+fn fault_injected() -> io::Error {
+    io::Error::new(io::ErrorKind::ConnectionAborted, "response replaced by a connection fault")
+}
+
+impl AsyncRead for FaultStream {
+    fn poll_read(
+        self: Pin<&mut Self>,
+        cx: &mut Context<'_>,
+        buf: &mut ReadBuf<'_>,
+    ) -> Poll<io::Result<()>> {
+        Pin::new(&mut self.get_mut().inner).poll_read(cx, buf)
+    }
+}
+
+impl AsyncWrite for FaultStream {
+    fn poll_write(
+        self: Pin<&mut Self>,
+        cx: &mut Context<'_>,
+        buf: &[u8],
+    ) -> Poll<io::Result<usize>> {
+        let this = self.get_mut();
+        loop {
+            match &mut this.state {
+                FaultState::Passthrough => match this.control.take() {
+                    Some(fault) => this.start(fault),
+                    None => return Pin::new(&mut this.inner).poll_write(cx, buf),
+                },
+                FaultState::Raw { bytes, written } => {
+                    while *written < bytes.len() {
+                        let n =
+                            ready!(Pin::new(&mut this.inner).poll_write(cx, &bytes[*written..]))?;
+                        if n == 0 {
+                            return Poll::Ready(Err(io::ErrorKind::WriteZero.into()));
+                        }
+                        *written += n;
+                    }
+                    this.state = FaultState::Closing;
+                }
+                FaultState::Closing => {
+                    ready!(Pin::new(&mut this.inner).poll_shutdown(cx))?;
+                    this.state = FaultState::Done;
+                }
+                FaultState::Done => return Poll::Ready(Err(fault_injected())),
+            }
+        }
+    }
+
+    fn poll_flush(self: Pin<&mut Self>, cx: &mut Context<'_>) -> Poll<io::Result<()>> {
+        let this = self.get_mut();
+        match this.state {
+            FaultState::Done => Poll::Ready(Err(fault_injected())),
+            _ => Pin::new(&mut this.inner).poll_flush(cx),
+        }
+    }
+
+    fn poll_shutdown(self: Pin<&mut Self>, cx: &mut Context<'_>) -> Poll<io::Result<()>> {
+        let this = self.get_mut();
+        match this.state {
+            // A shutdown would send a FIN ahead of an injected RST
+            FaultState::Done => Poll::Ready(Ok(())),
+            _ => Pin::new(&mut this.inner).poll_shutdown(cx),
+        }
+    }
+}
+
+/// Listener wrapper that pairs every accepted TCP connection with a
+/// [`ConnectionControl`]
+pub struct FaultTcpListener<L> {
+    inner: L,
+}
+
+impl<L> FaultTcpListener<L> {
+    /// Wrap a listener, such as a `TcpListener` or a [`ChaosTcpListener`]
+    ///
+    /// [`ChaosTcpListener`]: crate::ChaosTcpListener
+    pub fn new(inner: L) -> Self {
+        Self { inner }
+    }
+}
+
+impl<L> Listener for FaultTcpListener<L>
+where
+    L: Listener<Io = TcpStream>,
+{
+    type Io = FaultStream;
+    type Addr = L::Addr;
+
+    async fn accept(&mut self) -> (Self::Io, Self::Addr) {
+        let (stream, addr) = self.inner.accept().await;
+        (FaultStream::new(stream), addr)
+    }
+
+    fn local_addr(&self) -> io::Result<Self::Addr> {
+        self.inner.local_addr()
+    }
+}
+
+/// Connect info for [`FaultTcpListener`]: the peer address and the
+/// connection's [`ConnectionControl`]
+#[derive(Debug, Clone)]
+pub struct FaultConnectInfo {
+    /// Address of the client
+    pub addr: SocketAddr,
+    /// Handle for injecting faults into the connection
+    pub control: ConnectionControl,
+}
+
+impl<L> Connected<IncomingStream<'_, FaultTcpListener<L>>> for FaultConnectInfo
+where
+    L: Listener<Io = TcpStream, Addr = SocketAddr>,
+{
+    fn connect_info(stream: IncomingStream<'_, FaultTcpListener<L>>) -> Self {
+        Self {
+            addr: *stream.remote_addr(),
+            control: stream.io().control().clone(),
+        }
+    }
+}
+
+/// For accept loops that build the connect info themselves rather than
+/// going through `axum::serve`
+impl Connected<FaultConnectInfo> for FaultConnectInfo {
+    fn connect_info(info: FaultConnectInfo) -> Self {
+        info
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use axum::extract::ConnectInfo;
+    use tokio::io::{AsyncReadExt, AsyncWriteExt};
+    use tokio::net::TcpListener;
+
+    /// Serve a handler that injects `fault` and return the server address
+    async fn serve_fault(fault: Option<ConnectionFault>) -> SocketAddr {
+        let listener = TcpListener::bind("127.0.0.1:0").await.unwrap();
+        let addr = listener.local_addr().unwrap();
+        let app = axum::Router::new().route(
+            "/",
+            axum::routing::get(move |ConnectInfo(info): ConnectInfo<FaultConnectInfo>| {
+                let fault = fault.clone();
+                async move {
+                    if let Some(fault) = fault {
+                        info.control.inject(fault);
+                    }
+                    "ok"
+                }
+            }),
+        );
+        let make_svc = app.into_make_service_with_connect_info::<FaultConnectInfo>();
+        tokio::spawn(async move {
+            axum::serve(FaultTcpListener::new(listener), make_svc).await.unwrap();
+        });
+        addr
+    }
+
+    /// Send a GET and read until the server closes the connection
+    async fn get(addr: SocketAddr) -> io::Result<Vec<u8>> {
+        let mut stream = TcpStream::connect(addr).await?;
+        stream
+            .write_all(b"GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+            .await?;
+        let mut response = Vec::new();
+        stream.read_to_end(&mut response).await?;
+        Ok(response)
+    }
+
+    #[tokio::test]
+    async fn passes_responses_through_without_a_fault() {
+        let response = get(serve_fault(None).await).await.unwrap();
+        assert!(response.starts_with(b"HTTP/1.1 200 OK"));
+        assert!(response.ends_with(b"ok"));
+    }
+
+    #[tokio::test]
+    async fn raw_fault_writes_bytes_verbatim() {
+        let raw = Bytes::from_static(b"HTTP/1.1 299 Whatever\r\nX-B: 1\r\n\r\nhi");
+        let response = get(serve_fault(Some(ConnectionFault::Raw(raw.clone()))).await).await;
+        assert_eq!(response.unwrap(), raw.to_vec());
+    }
+
+    #[tokio::test]
+    async fn close_fault_writes_nothing() {
+        let response = get(serve_fault(Some(ConnectionFault::Close)).await).await;
+        assert!(response.unwrap().is_empty());
+    }
+
+    #[tokio::test]
+    async fn reset_fault_resets_the_connection() {
+        let err = get(serve_fault(Some(ConnectionFault::Reset)).await).await.unwrap_err();
+        assert_eq!(err.kind(), io::ErrorKind::ConnectionReset);
+    }
+}
@@ -18,6 +18,7 @@ pub mod chaos_mesh;
 #[cfg(feature = "enterprise")]
 pub mod collaboration;
 pub mod config;
+pub mod connection_fault;
 #[cfg(feature = "enterprise")]
 pub mod dashboard;
 #[cfg(feature = "enterprise")]
@@ -118,6 +119,9 @@ pub use config::{
     ErrorPattern, FaultInjectionConfig, LatencyConfig, NetworkProfile, RateLimitConfig,
     TrafficShapingConfig,
 };
+pub use connection_fault::{
+    ConnectionControl, ConnectionFault, FaultConnectInfo, FaultStream, FaultTcpListener,
+};
 #[cfg(feature = "enterprise")]
 pub use dashboard::{DashboardManager, DashboardQuery, DashboardStats, DashboardUpdate};
 #[cfg(feature = "enterprise")]
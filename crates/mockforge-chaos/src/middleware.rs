@@ -202,12 +202,26 @@ pub async fn chaos_middleware(
     chaos_middleware_core(chaos, req, next).await
 }
 
+/// Whether the path belongs to MockForge's own APIs rather than mocked traffic
+fn is_admin_path(path: &str) -> bool {
+    ["/__mockforge", "/api/chaos", "/api/verification"].iter().any(|prefix| {
+        path.strip_prefix(prefix)
+            .is_some_and(|rest| rest.is_empty() || rest.starts_with('/'))
+    })
+}
+
 /// Core chaos middleware logic
 async fn chaos_middleware_core(
     chaos: Arc<ChaosMiddleware>,
     req: Request<Body>,
     next: Next,
 ) -> Response {
+    // The admin and verification APIs stay reachable while chaos slows or
+    // fails traffic, so tests can inspect the server and turn chaos off
+    if is_admin_path(req.uri().path()) {
+        return next.run(req).await;
+    }
+
     // Read config at start of request (supports hot-reload)
     let config = chaos.config.read().await;
 
@@ -217,6 +231,11 @@ async fn chaos_middleware_core(
         return next.run(req).await;
     }
 
+    // Latency and rate limits changed through the API since the injectors
+    // were cached apply from this request on
+    let latency = config.latency.clone().unwrap_or_default();
+    let rate_limit = config.rate_limit.clone().unwrap_or_default();
+
     let path = req.uri().path().to_string();
 
     // Extract client IP from request extensions (set by ConnectInfo if available) or headers
@@ -238,6 +257,13 @@ async fn chaos_middleware_core(
     // Release config lock early (we'll read specific configs as needed)
     drop(config);
 
+    if chaos.latency_injector.read().await.config() != &latency {
+        *chaos.latency_injector.write().await = LatencyInjector::new(latency);
+    }
+    if chaos.rate_limiter.read().await.config() != &rate_limit {
+        *chaos.rate_limiter.write().await = RateLimiter::new(rate_limit);
+    }
+
     // Check circuit breaker
     {
         let circuit_breaker = chaos.circuit_breaker.read().await;
@@ -725,6 +751,56 @@ mod tests {
         assert_eq!(samples[0].latency_ms, 50, "Recorded latency should match injected delay");
     }
 
+    #[tokio::test]
+    async fn test_runtime_updates_apply_to_traffic_but_not_admin_paths() {
+        use axum::{middleware::from_fn_with_state, routing::get, Router};
+        use std::time::{Duration, Instant};
+        use tower::ServiceExt;
+
+        let config = Arc::new(RwLock::new(ChaosConfig::default()));
+        let middleware =
+            Arc::new(ChaosMiddleware::new(config.clone(), Arc::new(LatencyMetricsTracker::new())));
+        middleware.init_from_config().await;
+        let app = Router::new()
+            .route("/orders", get(|| async { "ok" }))
+            .route("/__mockforge/api/health", get(|| async { "ok" }))
+            .layer(from_fn_with_state(middleware, chaos_middleware));
+        let call = |path: &'static str| {
+            let app = app.clone();
+            async move {
+                let started = Instant::now();
+                let response = app
+                    .oneshot(Request::builder().uri(path).body(Body::empty()).unwrap())
+                    .await
+                    .unwrap();
+                (response.status(), started.elapsed())
+            }
+        };
+
+        {
+            let mut config = config.write().await;
+            config.enabled = true;
+            config.latency = Some(LatencyConfig {
+                enabled: true,
+                fixed_delay_ms: Some(100),
+                ..Default::default()
+            });
+            config.fault_injection = Some(crate::config::FaultInjectionConfig {
+                enabled: true,
+                http_errors: vec![503],
+                http_error_probability: 1.0,
+                ..Default::default()
+            });
+        }
+
+        let (status, elapsed) = call("/orders").await;
+        assert_eq!(status, StatusCode::SERVICE_UNAVAILABLE);
+        assert!(elapsed >= Duration::from_millis(100), "latency set at runtime should apply");
+        let (status, elapsed) = call("/__mockforge/api/health").await;
+        assert_eq!(status, StatusCode::OK);
+        assert!(elapsed < Duration::from_millis(100), "admin paths should not be delayed");
+    }
+
     /// Issue #79 item 6: pre-fix the prometheus counter sat at zero forever
     /// because nothing called `record_fault`. This is a coarse smoke check
     /// that the counter mechanism itself works — the actual call sites are
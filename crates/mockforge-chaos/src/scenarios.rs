@@ -91,6 +91,18 @@ impl ChaosScenario {
 pub struct PredefinedScenarios;
 
 impl PredefinedScenarios {
+    /// Look up a predefined scenario by name, e.g. `slow_backend`
+    pub fn by_name(name: &str) -> Option<ChaosScenario> {
+        match name {
+            "network_degradation" => Some(Self::network_degradation()),
+            "service_instability" => Some(Self::service_instability()),
+            "cascading_failure" => Some(Self::cascading_failure()),
+            "peak_traffic" => Some(Self::peak_traffic()),
+            "slow_backend" => Some(Self::slow_backend()),
+            _ => None,
+        }
+    }
+
     /// Network degradation scenario (high latency, packet loss)
     pub fn network_degradation() -> ChaosScenario {
         ChaosScenario::new(
@@ -312,6 +324,13 @@ mod tests {
         assert!(scenario.chaos_config.latency.is_some());
     }
 
+    #[test]
+    fn test_scenario_by_name() {
+        let scenario = PredefinedScenarios::by_name("slow_backend").unwrap();
+        assert_eq!(scenario.name, "slow_backend");
+        assert!(PredefinedScenarios::by_name("latency-spike").is_none());
+    }
+
     #[test]
     fn test_scenario_engine() {
         let engine = ScenarioEngine::new();
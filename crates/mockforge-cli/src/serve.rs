@@ -1017,7 +1017,13 @@ pub async fn handle_serve(
     // Initialize the global request logger early, BEFORE any server tasks are spawned.
     // This ensures HTTP request logs are captured from the very first request,
     // not just after the admin UI router happens to initialize.
-    mockforge_core::init_global_logger(1000);
+    // MOCKFORGE_REQUEST_JOURNAL_LIMIT bounds it for long-running shared servers.
+    let journal_limit = std::env::var("MOCKFORGE_REQUEST_JOURNAL_LIMIT")
+        .ok()
+        .and_then(|v| v.parse::<usize>().ok())
+        .filter(|n| *n > 0)
+        .unwrap_or(1000);
+    mockforge_core::init_global_logger(journal_limit);
 
     println!("📡 HTTP server on port {}", config.http.port);
     println!("🔌 WebSocket server on port {}", config.websocket.port);
@@ -1496,6 +1502,47 @@ pub async fn handle_serve(
     #[cfg(not(feature = "kafka"))]
     let kafka_broker_for_http = None::<Arc<dyn Any + Send + Sync>>;
 
+    // The FTP server's registry backs the admin API's file and upload
+    // endpoints, so files seeded there are the ones clients download and
+    // client uploads show up in the upload log.
+    #[cfg(feature = "ftp")]
+    let ftp_server = if config.ftp.enabled {
+        let mut server = mockforge_ftp::FtpServer::new(config.ftp.clone());
+        if let Some(fixtures_dir) = &config.ftp.fixtures_dir {
+            if fixtures_dir.exists() {
+                match server.load_fixtures_dir(fixtures_dir).await {
+                    Ok(count) => {
+                        println!("   Loaded {} FTP fixtures from {:?}", count, fixtures_dir)
+                    }
+                    Err(e) => eprintln!(
+                        "⚠️  Warning: Failed to load FTP fixtures from {:?}: {}",
+                        fixtures_dir, e
+                    ),
+                }
+            } else {
+                println!("   No FTP fixtures directory found at {:?}", fixtures_dir);
+            }
+        }
+        Some(server)
+    } else {
+        None
+    };
+    #[cfg(feature = "ftp")]
+    let ftp_registry_for_http = ftp_server
+        .as_ref()
+        .map(|server| server.spec_registry() as Arc<dyn Any + Send + Sync>);
+    #[cfg(not(feature = "ftp"))]
+    let ftp_registry_for_http = None::<Arc<dyn Any + Send + Sync>>;
+
+    // Scripts registered through the admin API run on both the `/ws`
+    // endpoint of the HTTP port and the standalone WebSocket listener.
+    #[cfg(feature = "ws")]
+    let ws_scripts = Arc::new(mockforge_ws::WsScriptRegistry::new());
+    #[cfg(feature = "ws")]
+    let ws_scripts_for_http = Some(ws_scripts.clone() as Arc<dyn Any + Send + Sync>);
+    #[cfg(not(feature = "ws"))]
+    let ws_scripts_for_http = None::<Arc<dyn Any + Send + Sync>>;
+
     // Create health manager for Kubernetes-native health checks
     use mockforge_http::HealthManager;
     use std::sync::Arc;
@@ -1903,6 +1950,8 @@ pub async fn handle_serve(
         mqtt_broker_for_http,
         amqp_broker_for_http,
         kafka_broker_for_http,
+        ftp_registry_for_http,
+        ws_scripts_for_http,
         traffic_shaper,                        // traffic_shaper
         traffic_shaping_enabled,               // traffic_shaping_enabled
         Some(health_manager_for_router),       // health_manager
@@ -2019,7 +2068,7 @@ pub async fn handle_serve(
     // MOCKFORGE_WS_PORT=0 to skip it.
     #[cfg(feature = "ws")]
     {
-        http_app = http_app.merge(mockforge_ws::router());
+        http_app = http_app.merge(mockforge_ws::router_with_scripts(ws_scripts.clone()));
         println!("✅ WebSocket endpoint available at /ws (HTTP upgrade)");
     }
 
@@ -2030,6 +2079,15 @@ pub async fn handle_serve(
     {
         match mockforge_graphql::create_router(None).await {
             Ok(gql_router) => {
+                // GraphQL stubs registered through the management API answer
+                // ahead of the generated schema
+                let gql_router = match mockforge_http::management::server_state() {
+                    Some(state) => gql_router.layer(axum::middleware::from_fn_with_state(
+                        state,
+                        mockforge_http::management::dynamic_mock_override,
+                    )),
+                    None => gql_router,
+                };
                 http_app = http_app.merge(gql_router);
                 println!(
                     "✅ GraphQL endpoint available at /graphql (POST queries, GET playground)"
@@ -2248,8 +2306,10 @@ pub async fn handle_serve(
     // Store chaos_api_state for passing to admin server (Phase 3)
     let chaos_api_state_for_admin = chaos_api_state.clone();
 
-    // Integrate chaos middleware if chaos is enabled
-    if chaos_config.enabled {
+    // Integrate chaos middleware even when chaos starts disabled, so chaos
+    // turned on through the admin API applies to traffic; while disabled it
+    // passes requests straight through
+    {
         use axum::middleware::from_fn;
         use mockforge_chaos::middleware::{chaos_middleware_with_state, ChaosMiddleware};
         use std::sync::{Arc, OnceLock};
@@ -2302,7 +2362,9 @@ pub async fn handle_serve(
                     }
                 })
             }));
-        println!("✅ Chaos middleware integrated - latency recording enabled");
+        if chaos_config.enabled {
+            println!("✅ Chaos middleware integrated - latency recording enabled");
+        }
     }
 
     // Layer the HTTP metrics middleware as the outermost wrapper so every
@@ -2596,6 +2658,7 @@ pub async fn handle_serve(
             let ws_port = config.websocket.port;
             let ws_host = config.websocket.host.clone();
             let ws_shutdown = shutdown_token.clone();
+            let ws_scripts = ws_scripts.clone();
             // The WS handler reads MOCKFORGE_WS_REPLAY_FILE at connection time
             // to decide between replay mode and echo mode. Config is the
             // source of truth — whether it was set by --ws-replay-file, a
@@ -2609,7 +2672,11 @@ pub async fn handle_serve(
             tokio::spawn(async move {
                 println!("🔌 WebSocket server listening on ws://{}:{}", ws_host, ws_port);
                 tokio::select! {
-                    result = mockforge_ws::start_with_latency_and_host(ws_port, &ws_host, None) => {
+                    result = mockforge_ws::start_with_scripts_and_host(
+                        ws_port,
+                        &ws_host,
+                        ws_scripts,
+                    ) => {
                         result.map_err(|e| format!("WebSocket server error: {}", e))
                     }
                     _ = ws_shutdown.cancelled() => {
@@ -2634,12 +2701,20 @@ pub async fn handle_serve(
         {
             let grpc_port = config.grpc.port;
             let grpc_enabled = config.grpc.enabled;
+            let mut grpc_config = mockforge_grpc::DynamicGrpcConfig {
+                enable_reflection: config.grpc.reflection,
+                overrides: config.grpc.overrides.clone(),
+                ..Default::default()
+            };
+            if let Some(proto_dir) = &config.grpc.proto_dir {
+                grpc_config.proto_dir = proto_dir.clone();
+            }
             let grpc_shutdown = shutdown_token.clone();
             if grpc_enabled && grpc_port != 0 {
                 tokio::spawn(async move {
                     println!("⚡ gRPC server listening on localhost:{}", grpc_port);
                     tokio::select! {
-                        result = mockforge_grpc::start(grpc_port) => {
+                        result = mockforge_grpc::start_with_config(grpc_port, None, grpc_config) => {
                             result.map_err(|e| format!("gRPC server error: {}", e))
                         }
                         _ = grpc_shutdown.cancelled() => {
@@ -2708,13 +2783,59 @@ pub async fn handle_serve(
             }
         };
 
-        Some(tokio::spawn(async move {
-            println!("📧 SMTP server listening on {}:{}", smtp_config.host, smtp_config.port);
+        // The IMAP and POP3 readers serve the mailboxes the SMTP server fills
+        if let Some(imap_port) = smtp_config.imap_port {
+            let server =
+                mockforge_smtp::ImapServer::new(&smtp_config.host, imap_port, smtp_reg.clone());
+            let imap_shutdown = shutdown_token.clone();
+            let host = smtp_config.host.clone();
+            tokio::spawn(async move {
+                tokio::select! {
+                    result = async {
+                        let listener = server.bind().await?;
+                        let port = listener.local_addr()?.port();
+                        println!("📬 IMAP server listening on {}:{}", host, port);
+                        server.serve(listener).await
+                    } => {
+                        if let Err(e) = result {
+                            tracing::error!("IMAP server error: {}", e);
+                        }
+                    }
+                    _ = imap_shutdown.cancelled() => {}
+                }
+            });
+        }
+        if let Some(pop3_port) = smtp_config.pop3_port {
+            let server =
+                mockforge_smtp::Pop3Server::new(&smtp_config.host, pop3_port, smtp_reg.clone());
+            let pop3_shutdown = shutdown_token.clone();
+            let host = smtp_config.host.clone();
+            tokio::spawn(async move {
+                tokio::select! {
+                    result = async {
+                        let listener = server.bind().await?;
+                        let port = listener.local_addr()?.port();
+                        println!("📬 POP3 server listening on {}:{}", host, port);
+                        server.serve(listener).await
+                    } => {
+                        if let Err(e) = result {
+                            tracing::error!("POP3 server error: {}", e);
+                        }
+                    }
+                    _ = pop3_shutdown.cancelled() => {}
+                }
+            });
+        }
 
+        Some(tokio::spawn(async move {
             tokio::select! {
                 result = async {
                     let server = mockforge_smtp::SmtpServer::new(server_config, smtp_reg)?;
-                    server.start().await
+                    // Bound first so port 0 reports the port the OS picked
+                    let listener = server.bind().await?;
+                    let port = listener.local_addr()?.port();
+                    println!("📧 SMTP server listening on {}:{}", smtp_config.host, port);
+                    server.serve(listener).await
                 } => {
                     result.map_err(|e| format!("SMTP server error: {}", e))
                 }
@@ -2993,13 +3114,18 @@ pub async fn handle_serve(
             }
 
             let registry_arc = Arc::new(registry);
-
-            println!("🔌 TCP server listening on {}:{}", server_config.host, server_config.port);
+            let host = server_config.host.clone();
 
             match TcpServer::new(server_config, registry_arc) {
                 Ok(server) => {
                     tokio::select! {
-                        result = server.start() => {
+                        result = async {
+                            // Bound first so port 0 reports the port the OS picked
+                            let listener = server.bind().await?;
+                            let port = listener.local_addr()?.port();
+                            println!("🔌 TCP server listening on {}:{}", host, port);
+                            server.serve(listener).await
+                        } => {
                             result.map_err(|e| format!("TCP server error: {}", e))
                         }
                         _ = tcp_shutdown.cancelled() => {
@@ -3017,6 +3143,34 @@ pub async fn handle_serve(
     #[cfg(not(feature = "tcp"))]
     let _tcp_handle: Option<tokio::task::JoinHandle<Result<(), String>>> = None;
 
+    // Start FTP server (if enabled)
+    #[cfg(feature = "ftp")]
+    let _ftp_handle = if let Some(mut server) = ftp_server {
+        let ftp_shutdown = shutdown_token.clone();
+        let host = config.ftp.host.clone();
+        Some(tokio::spawn(async move {
+            tokio::select! {
+                result = async {
+                    // libunftp binds by address, so port 0 is resolved up
+                    // front to report the port clients should use
+                    let port = server.reserve_port()?;
+                    println!("📁 FTP server listening on {}:{}", host, port);
+                    server.start().await
+                } => {
+                    result.map_err(|e| format!("FTP server error: {}", e))
+                }
+                _ = ftp_shutdown.cancelled() => {
+                    println!("🛑 Shutting down FTP server...");
+                    Ok(())
+                }
+            }
+        }))
+    } else {
+        None
+    };
+    #[cfg(not(feature = "ftp"))]
+    let _ftp_handle: Option<tokio::task::JoinHandle<Result<(), String>>> = None;
+
     // Create latency injector if latency is enabled (for hot-reload support)
     use mockforge_foundation::latency::{FaultConfig, LatencyInjector};
     use tokio::sync::RwLock;
@@ -563,6 +563,10 @@ pub fn apply_env_overrides(mut config: ServerConfig) -> ServerConfig {
         config.grpc.enabled = enabled == "1" || enabled.eq_ignore_ascii_case("true");
     }
 
+    if let Ok(reflection) = std::env::var("MOCKFORGE_GRPC_REFLECTION") {
+        config.grpc.reflection = reflection == "1" || reflection.eq_ignore_ascii_case("true");
+    }
+
     // MQTT broker overrides
     if let Ok(port) = std::env::var("MOCKFORGE_MQTT_PORT") {
         if let Ok(port_num) = port.parse() {
@@ -220,6 +220,9 @@ pub struct GrpcConfig {
     pub proto_dir: Option<String>,
     /// TLS configuration
     pub tls: Option<TlsConfig>,
+    /// Serve the gRPC server reflection service so tools like grpcurl can
+    /// discover the mocked services
+    pub reflection: bool,
     /// Per-method response overrides. First matching rule wins; rules with no
     /// `match` block are catch-all rules.
     #[serde(default, skip_serializing_if = "Vec::is_empty")]
@@ -234,6 +237,7 @@ impl Default for GrpcConfig {
             host: "0.0.0.0".to_string(),
             proto_dir: None,
             tls: None,
+            reflection: false,
             overrides: Vec::new(),
         }
     }
@@ -254,8 +258,9 @@ pub struct GrpcOverride {
     pub service: String,
     /// Method name (case-sensitive, matches proto definition).
     pub method: String,
-    /// Optional request-field-equality match. Keys are top-level field names of
-    /// the request message; values are stringified expected values. When
+    /// Optional request-field-equality match. Keys are field names of the
+    /// request message, dotted to address nested messages (e.g. `customer.id`);
+    /// values are stringified expected values. When
     /// omitted, the rule matches every call to the named method.
     #[serde(default, skip_serializing_if = "HashMap::is_empty")]
     pub r#match: HashMap<String, String>,
@@ -267,6 +272,8 @@ pub struct GrpcOverride {
 /// gRPC status code (in which case the call returns an error with `message`),
 /// or `body` is set to a JSON object that's serialized into the response
 /// message. Setting both is allowed but `status` wins when non-OK.
+/// Server-streaming and bidirectional methods can instead script their
+/// response stream with `stream`.
 #[derive(Debug, Clone, Default, Serialize, Deserialize)]
 #[cfg_attr(feature = "schema", derive(schemars::JsonSchema))]
 #[serde(default)]
@@ -282,6 +289,38 @@ pub struct GrpcOverrideResponse {
     /// message type from the proto. Ignored when `status` is non-OK.
     #[serde(default, skip_serializing_if = "Option::is_none")]
     pub body: Option<serde_json::Value>,
+    /// Messages a server-streaming or bidirectional method sends, in order.
+    /// When non-empty, `status`, `message`, and `body` are ignored for
+    /// those methods.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub stream: Vec<GrpcStreamStep>,
+}
+
+/// One step of a scripted gRPC response stream: a message, or a status
+/// that ends the stream. A stream without a status step ends with `OK`
+/// after its last message.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+#[cfg_attr(feature = "schema", derive(schemars::JsonSchema))]
+#[serde(default)]
+pub struct GrpcStreamStep {
+    /// Message to send as a JSON object, like `GrpcOverrideResponse::body`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub body: Option<serde_json::Value>,
+    /// gRPC status code name ending the stream, e.g. `UNAVAILABLE`. Steps
+    /// after it are never sent.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub status: Option<String>,
+    /// Error message sent with `status`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub message: Option<String>,
+    /// Milliseconds to wait before the step
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub delay_ms: Option<u64>,
+    /// For bidirectional methods, hold the step until the client has sent
+    /// this many messages in total. If the client closes its side first,
+    /// the stream ends with `OK` without the remaining steps.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub after_requests: Option<usize>,
 }
 
 /// GraphQL server configuration
@@ -402,6 +441,12 @@ pub struct SmtpConfig {
     pub tls_cert_path: Option<std::path::PathBuf>,
     /// Path to TLS private key file
     pub tls_key_path: Option<std::path::PathBuf>,
+    /// Port of the IMAP reader over the captured mail (0 picks a free port;
+    /// unset disables it)
+    pub imap_port: Option<u16>,
+    /// Port of the POP3 reader over the captured mail (0 picks a free port;
+    /// unset disables it)
+    pub pop3_port: Option<u16>,
 }
 
 impl Default for SmtpConfig {
@@ -419,6 +464,8 @@ impl Default for SmtpConfig {
             enable_starttls: false,
             tls_cert_path: None,
             tls_key_path: None,
+            imap_port: None,
+            pop3_port: None,
         }
     }
 }
@@ -420,6 +420,55 @@ pub fn get_global_routes() -> Vec<GlobalRouteInfo> {
     store.clone()
 }
 
+// ── Global gRPC Service Store ───────────────────────────────────────
+
+/// gRPC service information stored in the global gRPC service store
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct GlobalGrpcServiceInfo {
+    /// Fully qualified service name, e.g. `shop.v1.Orders`
+    pub name: String,
+    /// The service's methods
+    pub methods: Vec<GlobalGrpcMethodInfo>,
+}
+
+/// A method of a service in the global gRPC service store
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct GlobalGrpcMethodInfo {
+    /// Method name
+    pub name: String,
+    /// Fully qualified request message type
+    pub input_type: String,
+    /// Fully qualified response message type
+    pub output_type: String,
+    /// Whether the client streams requests
+    pub client_streaming: bool,
+    /// Whether the server streams responses
+    pub server_streaming: bool,
+}
+
+/// Global gRPC service store that the gRPC server populates and the
+/// management API reads
+static GLOBAL_GRPC_SERVICE_STORE: once_cell::sync::OnceCell<
+    std::sync::RwLock<Vec<GlobalGrpcServiceInfo>>,
+> = once_cell::sync::OnceCell::new();
+
+fn grpc_service_store() -> &'static std::sync::RwLock<Vec<GlobalGrpcServiceInfo>> {
+    GLOBAL_GRPC_SERVICE_STORE.get_or_init(|| std::sync::RwLock::new(Vec::new()))
+}
+
+/// Store services in the global gRPC service store (called by the gRPC
+/// server after service discovery)
+pub fn set_global_grpc_services(services: Vec<GlobalGrpcServiceInfo>) {
+    let mut store = grpc_service_store().write().expect("gRPC service store poisoned");
+    *store = services;
+}
+
+/// Get services from the global gRPC service store
+pub fn get_global_grpc_services() -> Vec<GlobalGrpcServiceInfo> {
+    let store = grpc_service_store().read().expect("gRPC service store poisoned");
+    store.clone()
+}
+
 /// Log a request to the global logger (convenience function)
 pub async fn log_request_global(entry: RequestLogEntry) {
     if let Some(logger) = get_global_logger() {
@@ -13,11 +13,12 @@ use crate::time_travel::VirtualClock;
 use crate::Config;
 use chrono::{Duration as ChronoDuration, Utc};
 use once_cell::sync::{Lazy, OnceCell};
-use rand::{rng, Rng};
+use rand::rngs::StdRng;
+use rand::{rng, Rng, RngCore, SeedableRng};
 use regex::Regex;
 use serde_json::Value;
 use std::collections::HashMap;
-use std::sync::Arc;
+use std::sync::{Arc, Mutex};
 
 // Pre-compiled regex patterns for templating
 static RANDINT_RE: Lazy<Regex> = Lazy::new(|| {
@@ -53,6 +54,40 @@ static SECURE_RE: Lazy<Regex> = Lazy::new(|| {
         .expect("SECURE_RE regex pattern is valid")
 });
 
+/// Seeded random source of generated values, so they repeat across runs.
+/// Seeded from `MOCKFORGE_RANDOM_SEED` or by [`set_random_seed`]; without a
+/// seed, values come from the thread RNG.
+static SEEDED_RNG: Lazy<Mutex<Option<StdRng>>> = Lazy::new(|| {
+    let seed = std::env::var("MOCKFORGE_RANDOM_SEED")
+        .ok()
+        .and_then(|s| s.trim().parse::<i64>().ok());
+    Mutex::new(seed.map(|seed| StdRng::seed_from_u64(seed as u64)))
+});
+
+/// Reseed the random source behind `{{uuid}}`, `{{rand.*}}`, and
+/// `{{faker.*}}` tokens, so the values generated from now on repeat across
+/// runs
+pub fn set_random_seed(seed: u64) {
+    *SEEDED_RNG.lock().unwrap_or_else(|e| e.into_inner()) = Some(StdRng::seed_from_u64(seed));
+}
+
+/// Run `f` with the seeded random source, or the thread RNG without a seed
+fn with_rng<T>(f: impl FnOnce(&mut dyn RngCore) -> T) -> T {
+    let mut seeded = SEEDED_RNG.lock().unwrap_or_else(|e| e.into_inner());
+    if let Some(seeded_rng) = seeded.as_mut() {
+        return f(seeded_rng);
+    }
+    drop(seeded);
+    f(&mut rng())
+}
+
+/// A random v4 UUID drawn from the templating random source
+fn random_uuid() -> String {
+    let mut bytes = [0u8; 16];
+    with_rng(|r| r.fill_bytes(&mut bytes));
+    uuid::Builder::from_random_bytes(bytes).into_uuid().to_string()
+}
+
 static DECRYPT_RE: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r#"\{\{\s*decrypt\s+(?:([^\s}]+)\s+)?\s*"([^"]+)"\s*\}\}"#)
         .expect("DECRYPT_RE regex pattern is valid")
@@ -275,7 +310,7 @@ pub fn expand_str_with_context(input: &str, context: &TemplatingContext) -> Stri
 
     // Basic replacements first (fast paths) - only if tokens are present
     if out.contains("{{uuid}}") {
-        out = out.replace("{{uuid}}", &uuid::Uuid::new_v4().to_string());
+        out = out.replace("{{uuid}}", &random_uuid());
     }
 
     // Only get current time if we need it (for {{now}} or time offsets)
@@ -284,7 +319,8 @@ pub fn expand_str_with_context(input: &str, context: &TemplatingContext) -> Stri
         if let Some(clock) = &context.virtual_clock {
             Some(clock.now())
         } else {
-            Some(Utc::now())
+            // The server-wide virtual clock, set through the time-travel API
+            Some(crate::time_travel::now())
         }
     } else {
         None
@@ -302,11 +338,11 @@ pub fn expand_str_with_context(input: &str, context: &TemplatingContext) -> Stri
 
     // Randoms - only process if tokens are present
     if out.contains("{{rand.int}}") {
-        let n: i64 = rng().random_range(0..=1_000_000);
+        let n: i64 = with_rng(|r| r.random_range(0..=1_000_000));
         out = out.replace("{{rand.int}}", &n.to_string());
     }
     if out.contains("{{rand.float}}") {
-        let n: f64 = rng().random();
+        let n: f64 = with_rng(|r| r.random());
         out = out.replace("{{rand.float}}", &format!("{:.6}", n));
     }
     if RANDINT_RE.is_match(&out) {
@@ -364,11 +400,11 @@ static FAKER_PROVIDER: OnceCell<Arc<dyn FakerProvider + Send + Sync>> = OnceCell
 pub trait FakerProvider {
     /// Generate a random UUID
     fn uuid(&self) -> String {
-        uuid::Uuid::new_v4().to_string()
+        random_uuid()
     }
     /// Generate a fake email address
     fn email(&self) -> String {
-        format!("user{}@example.com", rng().random_range(1000..=9999))
+        format!("user{}@example.com", with_rng(|r| r.random_range(1000..=9999)))
     }
     /// Generate a fake person name
     fn name(&self) -> String {
@@ -432,7 +468,7 @@ fn replace_randint_ranges(input: &str) -> String {
             let a: i64 = caps.get(1).map(|m| m.as_str().parse().unwrap_or(0)).unwrap_or(0);
             let b: i64 = caps.get(2).map(|m| m.as_str().parse().unwrap_or(100)).unwrap_or(100);
             let (lo, hi) = if a <= b { (a, b) } else { (b, a) };
-            let n: i64 = rng().random_range(lo..=hi);
+            let n: i64 = with_rng(|r| r.random_range(lo..=hi));
             s = RANDINT_RE.replace(&s, n.to_string()).to_string();
         } else {
             break;
@@ -712,18 +748,25 @@ fn replace_with_provider(input: &str, p: &dyn FakerProvider) -> String {
 fn replace_with_fallback(input: &str) -> String {
     let mut out = input.to_string();
     if out.contains("{{faker.uuid}}") {
-        out = out.replace("{{faker.uuid}}", &uuid::Uuid::new_v4().to_string());
+        out = out.replace("{{faker.uuid}}", &random_uuid());
     }
     if out.contains("{{faker.email}}") {
-        let user: String = (0..8).map(|_| (b'a' + (rng().random::<u8>() % 26)) as char).collect();
-        let dom: String = (0..6).map(|_| (b'a' + (rng().random::<u8>() % 26)) as char).collect();
+        let (user, dom): (String, String) = with_rng(|r| {
+            let mut letters =
+                |n| (0..n).map(|_| (b'a' + (r.random::<u8>() % 26)) as char).collect();
+            (letters(8), letters(6))
+        });
         out = out.replace("{{faker.email}}", &format!("{}@{}.example", user, dom));
     }
     if out.contains("{{faker.name}}") {
         let firsts = ["Alex", "Sam", "Taylor", "Jordan", "Casey", "Riley"];
         let lasts = ["Smith", "Lee", "Patel", "Garcia", "Kim", "Brown"];
-        let fi = rng().random::<u8>() as usize % firsts.len();
-        let li = rng().random::<u8>() as usize % lasts.len();
+        let (fi, li) = with_rng(|r| {
+            (
+                r.random::<u8>() as usize % firsts.len(),
+                r.random::<u8>() as usize % lasts.len(),
+            )
+        });
         out = out.replace("{{faker.name}}", &format!("{} {}", firsts[fi], lasts[li]));
     }
     out
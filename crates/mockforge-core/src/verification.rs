@@ -18,6 +18,7 @@
 //!         query_params: std::collections::HashMap::new(),
 //!         headers: std::collections::HashMap::new(),
 //!         body_pattern: None,
+//!         ..Default::default()
 //!     };
 //!
 //!     let logger = get_global_logger().unwrap();
@@ -27,6 +28,7 @@
 //! ```
 
 use crate::request_logger::RequestLogEntry;
+use chrono::{DateTime, Utc};
 use regex::Regex;
 use serde::{Deserialize, Serialize};
 use std::collections::HashMap;
@@ -52,9 +54,52 @@ pub struct VerificationRequest {
     #[serde(default)]
     pub headers: HashMap<String, String>,
 
+    /// Headers whose values must match these regular expressions.
+    /// Case-insensitive header names. If empty, not checked.
+    #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    pub header_patterns: HashMap<String, String>,
+
+    /// Headers that must not have been sent. Case-insensitive names.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub headers_absent: Vec<String>,
+
+    /// Headers that must have been sent, with any value. Case-insensitive names.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub headers_present: Vec<String>,
+
     /// Request body pattern to match. Supports exact match or regex.
     /// If None, body is not checked.
     pub body_pattern: Option<String>,
+
+    /// JSONPath expressions (e.g. `$.items[0].sku`) and the values the JSON
+    /// body must have there. If empty, not checked.
+    #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    pub body_json_path: HashMap<String, serde_json::Value>,
+
+    /// JSON Schema the JSON body must validate against.
+    /// If None, not checked.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub body_json_schema: Option<serde_json::Value>,
+
+    /// Only match requests logged at or after this time. If None, not checked.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub since: Option<DateTime<Utc>>,
+
+    /// Only match requests logged before this time. If None, not checked.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub until: Option<DateTime<Utc>>,
+
+    /// Journal mark from `POST /api/verification/journal/mark`; only
+    /// requests logged after the mark match. If None, not checked.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub since_mark: Option<String>,
+}
+
+/// Create a journal mark: an opaque token for the current server time, so
+/// verification can be scoped to later requests without relying on the
+/// client's clock
+pub fn journal_mark() -> String {
+    Utc::now().to_rfc3339_opts(chrono::SecondsFormat::Nanos, true)
 }
 
 /// Count assertion for verification.
@@ -131,6 +176,19 @@ pub fn matches_verification_pattern(
     entry: &RequestLogEntry,
     pattern: &VerificationRequest,
 ) -> bool {
+    // Check the time window
+    if pattern.since.is_some_and(|since| entry.timestamp < since)
+        || pattern.until.is_some_and(|until| entry.timestamp >= until)
+    {
+        return false;
+    }
+    if let Some(ref mark) = pattern.since_mark {
+        match DateTime::parse_from_rfc3339(mark) {
+            Ok(marked) if entry.timestamp >= marked => {}
+            _ => return false,
+        }
+    }
+
     // Check HTTP method (case-insensitive)
     if let Some(ref expected_method) = pattern.method {
         if entry.method.to_uppercase() != expected_method.to_uppercase() {
@@ -169,6 +227,34 @@ pub fn matches_verification_pattern(
         }
     }
 
+    // Check header value patterns (case-insensitive header names)
+    for (key, value_pattern) in &pattern.header_patterns {
+        let re = match Regex::new(value_pattern) {
+            Ok(re) => re,
+            Err(_) => return false,
+        };
+        let found = entry
+            .headers
+            .iter()
+            .any(|(k, v)| k.eq_ignore_ascii_case(key) && re.is_match(v));
+        if !found {
+            return false;
+        }
+    }
+
+    // Check header presence and absence. Sensitive headers are logged by
+    // name only, in the redacted_headers metadata.
+    let redacted = entry.metadata.get("redacted_headers").map(String::as_str).unwrap_or("");
+    let has_header = |name: &String| {
+        entry.headers.keys().any(|k| k.eq_ignore_ascii_case(name))
+            || redacted.split(',').any(|k| k.eq_ignore_ascii_case(name))
+    };
+    if pattern.headers_absent.iter().any(has_header)
+        || !pattern.headers_present.iter().all(has_header)
+    {
+        return false;
+    }
+
     // Check body pattern
     // Note: RequestLogEntry doesn't store request body directly.
     // This would need to be enhanced or we'd need to check metadata.
@@ -186,9 +272,54 @@ pub fn matches_verification_pattern(
         }
     }
 
+    // Structured body matchers need a recorded JSON body
+    if !pattern.body_json_path.is_empty() || pattern.body_json_schema.is_some() {
+        let body = match entry
+            .metadata
+            .get("request_body")
+            .and_then(|b| serde_json::from_str::<serde_json::Value>(b).ok())
+        {
+            Some(body) => body,
+            None => return false,
+        };
+        for (json_path, expected) in &pattern.body_json_path {
+            if json_path_value(&body, json_path) != Some(expected) {
+                return false;
+            }
+        }
+        if let Some(ref schema) = pattern.body_json_schema {
+            if !crate::validation::validate_json_schema(&body, schema).valid {
+                return false;
+            }
+        }
+    }
+
     true
 }
 
+/// Look up a simple JSONPath (`$.a.b`, `$.items[0]`) in a JSON value
+fn json_path_value<'a>(
+    json: &'a serde_json::Value,
+    json_path: &str,
+) -> Option<&'a serde_json::Value> {
+    let path = json_path.strip_prefix('$').unwrap_or(json_path);
+    let mut current = json;
+    for part in path.split('.').filter(|p| !p.is_empty()) {
+        let (field, indexes) = match part.find('[') {
+            Some(i) => (&part[..i], &part[i..]),
+            None => (part, ""),
+        };
+        if !field.is_empty() {
+            current = current.get(field)?;
+        }
+        for index in indexes.split('[').filter(|i| !i.is_empty()) {
+            let index: usize = index.strip_suffix(']')?.parse().ok()?;
+            current = current.get(index)?;
+        }
+    }
+    Some(current)
+}
+
 /// Match a path against a pattern (supports exact, wildcard, and regex)
 fn matches_path_pattern(path: &str, pattern: &str) -> bool {
     // Exact match
@@ -201,8 +332,9 @@ fn matches_path_pattern(path: &str, pattern: &str) -> bool {
         return true;
     }
 
-    // Try wildcard matching first (before regex, as wildcards are more specific)
-    if pattern.contains('*') {
+    // Try wildcard matching first (before regex, as wildcards are more specific).
+    // Anchored patterns are always regular expressions.
+    if pattern.contains('*') && !pattern.starts_with('^') {
         return matches_wildcard_pattern(path, pattern);
     }
 
@@ -428,6 +560,73 @@ mod tests {
         )
     }
 
+    #[test]
+    fn test_header_presence_matchers() {
+        let mut entry = create_test_entry("GET", "/upstream");
+        entry.headers.insert("X-Request-Id".to_string(), "req-123".to_string());
+
+        let mut pattern = VerificationRequest {
+            headers_absent: vec!["authorization".to_string()],
+            headers_present: vec!["x-request-id".to_string()],
+            ..Default::default()
+        };
+        pattern.header_patterns.insert("X-Request-ID".to_string(), "^req-\\d+$".to_string());
+        assert!(matches_verification_pattern(&entry, &pattern));
+
+        entry.headers.insert("Authorization".to_string(), "Bearer secret".to_string());
+        assert!(!matches_verification_pattern(&entry, &pattern));
+
+        entry.headers.remove("Authorization");
+        entry
+            .metadata
+            .insert("redacted_headers".to_string(), "authorization".to_string());
+        assert!(!matches_verification_pattern(&entry, &pattern));
+    }
+
+    #[test]
+    fn test_time_window() {
+        let entry = create_test_entry("GET", "/api/users");
+        let before = entry.timestamp - chrono::Duration::seconds(1);
+        let after = entry.timestamp + chrono::Duration::seconds(1);
+
+        let mut pattern = VerificationRequest {
+            since: Some(before),
+            until: Some(after),
+            ..Default::default()
+        };
+        assert!(matches_verification_pattern(&entry, &pattern));
+
+        pattern.until = Some(entry.timestamp);
+        assert!(!matches_verification_pattern(&entry, &pattern));
+
+        let pattern = VerificationRequest {
+            since_mark: Some(after.to_rfc3339()),
+            ..Default::default()
+        };
+        assert!(!matches_verification_pattern(&entry, &pattern));
+    }
+
+    #[test]
+    fn test_body_json_matchers() {
+        let mut entry = create_test_entry("POST", "/api/orders");
+        entry.metadata.insert(
+            "request_body".to_string(),
+            r#"{"items":[{"sku":"A1","qty":2}]}"#.to_string(),
+        );
+
+        let mut pattern = VerificationRequest::default();
+        pattern.body_json_path.insert("$.items[0].sku".to_string(), serde_json::json!("A1"));
+        pattern.body_json_schema = Some(serde_json::json!({"required": ["items"]}));
+        assert!(matches_verification_pattern(&entry, &pattern));
+
+        pattern.body_json_path.insert("$.items[0].qty".to_string(), serde_json::json!(3));
+        assert!(!matches_verification_pattern(&entry, &pattern));
+
+        // Requests without a recorded body never match structured matchers
+        let unrecorded = create_test_entry("POST", "/api/orders");
+        assert!(!matches_verification_pattern(&unrecorded, &pattern));
+    }
+
     #[tokio::test]
     async fn test_verify_exactly() {
         let logger = CentralizedRequestLogger::new(100);
@@ -441,6 +640,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
 
         let result = verify_requests(&logger, &pattern, VerificationCount::Exactly(3)).await;
@@ -460,6 +660,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
 
         let result = verify_at_least(&logger, &pattern, 2).await;
@@ -484,6 +685,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
 
         let result = verify_never(&logger, &pattern).await;
@@ -505,6 +707,7 @@ mod tests {
                 query_params: HashMap::new(),
                 headers: HashMap::new(),
                 body_pattern: None,
+                ..Default::default()
             },
             VerificationRequest {
                 method: Some("GET".to_string()),
@@ -512,6 +715,7 @@ mod tests {
                 query_params: HashMap::new(),
                 headers: HashMap::new(),
                 body_pattern: None,
+                ..Default::default()
             },
         ];
 
@@ -544,6 +748,7 @@ mod tests {
     fn test_matches_path_pattern_regex() {
         assert!(matches_path_pattern("/api/users/123", r"^/api/users/\d+$"));
         assert!(!matches_path_pattern("/api/users/abc", r"^/api/users/\d+$"));
+        assert!(matches_path_pattern("/api/users/1/posts", r"^/api/users/.*$"));
     }
 
     #[test]
@@ -555,6 +760,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
         assert!(matches_verification_pattern(&entry, &pattern));
 
@@ -564,6 +770,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
         assert!(!matches_verification_pattern(&entry, &pattern2));
     }
@@ -577,6 +784,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
         assert!(matches_verification_pattern(&entry, &pattern));
 
@@ -586,6 +794,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
         assert!(!matches_verification_pattern(&entry, &pattern2));
     }
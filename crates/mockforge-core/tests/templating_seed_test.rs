@@ -0,0 +1,18 @@
+//! The templating random source is global, so seeding it gets its own test
+//! binary rather than racing the unit tests that generate values.
+
+use mockforge_core::templating::{expand_str, set_random_seed};
+
+#[test]
+fn set_random_seed_repeats_generated_values() {
+    let template = "{{uuid}} {{rand.int}} {{randInt 1 1000}} {{faker.email}}";
+    set_random_seed(42);
+    let first = expand_str(template);
+    set_random_seed(42);
+    assert_eq!(expand_str(template), first);
+    set_random_seed(43);
+    assert_ne!(expand_str(template), first);
+
+    let uuid = first.split(' ').next().unwrap();
+    assert_eq!(uuid::Uuid::parse_str(uuid).unwrap().get_version_num(), 4);
+}
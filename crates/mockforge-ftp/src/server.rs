@@ -1,9 +1,11 @@
+use crate::fixtures::FtpFixture;
 use crate::spec_registry::FtpSpecRegistry;
 use crate::storage::MockForgeStorage;
 use crate::vfs::VirtualFileSystem;
 use anyhow::Result;
 use libunftp::ServerBuilder;
 use mockforge_core::config::FtpConfig;
+use std::path::Path;
 use std::sync::Arc;
 use tracing::info;
 
@@ -27,6 +29,50 @@ impl FtpServer {
         }
     }
 
+    /// Load the fixtures (`*.yaml` / `*.yml`) in `dir`. Their virtual files
+    /// are added to the file system and their upload rules decide which
+    /// uploads are accepted.
+    pub async fn load_fixtures_dir(&mut self, dir: &Path) -> Result<usize> {
+        let mut paths: Vec<_> = std::fs::read_dir(dir)?
+            .flatten()
+            .map(|entry| entry.path())
+            .filter(|path| {
+                matches!(path.extension().and_then(|e| e.to_str()), Some("yaml" | "yml"))
+            })
+            .collect();
+        paths.sort();
+
+        let mut fixtures = Vec::new();
+        for path in paths {
+            let content = std::fs::read_to_string(&path)?;
+            let fixture: FtpFixture = serde_yaml::from_str(&content)
+                .map_err(|e| anyhow::anyhow!("Invalid FTP fixture {}: {}", path.display(), e))?;
+            for virtual_file in &fixture.virtual_files {
+                let file = virtual_file.clone().to_file_fixture().to_virtual_file();
+                self.vfs.create_parent_directories_async(&file.path).await;
+                self.vfs.add_file_async(file.path.clone(), file).await?;
+            }
+            fixtures.push(fixture);
+        }
+
+        let count = fixtures.len();
+        let mut registry = FtpSpecRegistry::new().with_vfs(self.vfs.clone());
+        registry.fixtures = fixtures;
+        self.spec_registry = Arc::new(registry);
+        Ok(count)
+    }
+
+    /// Replace a configured port of 0 with a free one, so the address can be
+    /// reported before [`FtpServer::start`]. libunftp binds by address, so
+    /// the port is probed and released rather than handed over as a socket.
+    pub fn reserve_port(&mut self) -> std::io::Result<u16> {
+        if self.config.port == 0 {
+            let probe = std::net::TcpListener::bind((self.config.host.as_str(), 0))?;
+            self.config.port = probe.local_addr()?.port();
+        }
+        Ok(self.config.port)
+    }
+
     pub async fn start(&self) -> Result<()> {
         let addr = format!("{}:{}", self.config.host, self.config.port);
         info!("Starting FTP server on {}", addr);
@@ -156,6 +202,56 @@ mod tests {
         assert!(files.is_empty());
     }
 
+    #[tokio::test]
+    async fn test_load_fixtures_dir() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(
+            dir.path().join("files.yaml"),
+            r#"
+identifier: files
+name: Files
+description: null
+virtual_files:
+  - path: /pub/readme.txt
+    content:
+      type: static
+      content: hello
+    permissions: "644"
+    owner: mockforge
+    group: users
+upload_rules:
+  - path_pattern: "^/incoming/.*"
+    auto_accept: true
+    validation: null
+    storage:
+      type: memory
+"#,
+        )
+        .unwrap();
+        std::fs::write(dir.path().join("notes.txt"), "not a fixture").unwrap();
+
+        let mut server = FtpServer::new(FtpConfig::default());
+        assert_eq!(server.load_fixtures_dir(dir.path()).await.unwrap(), 1);
+
+        let file = server.vfs.get_file_async(Path::new("/pub/readme.txt")).await.unwrap();
+        assert_eq!(file.render_content().unwrap(), b"hello");
+        assert!(server.vfs.directory_exists_async(Path::new("/pub")).await);
+        assert!(server.spec_registry.find_upload_rule("/incoming/a.csv").is_some());
+        assert!(server.spec_registry.find_upload_rule("/pub/a.csv").is_none());
+    }
+
+    #[test]
+    fn test_reserve_port() {
+        let mut server = FtpServer::new(FtpConfig {
+            host: "127.0.0.1".to_string(),
+            port: 0,
+            ..Default::default()
+        });
+        let port = server.reserve_port().unwrap();
+        assert_ne!(port, 0);
+        assert_eq!(server.reserve_port().unwrap(), port);
+    }
+
     #[tokio::test]
     async fn test_handle_upload_memory_storage() {
         let config = FtpConfig {
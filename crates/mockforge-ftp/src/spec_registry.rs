@@ -10,7 +10,7 @@ use std::collections::HashMap;
 use std::sync::Arc;
 
 /// Tracked upload information
-#[derive(Debug, Clone)]
+#[derive(Debug, Clone, serde::Serialize)]
 pub struct UploadRecord {
     pub id: String,
     pub path: std::path::PathBuf,
@@ -96,6 +96,11 @@ impl FtpSpecRegistry {
     pub fn get_upload(&self, id: &str) -> Option<UploadRecord> {
         self.uploads.read().iter().find(|u| u.id == id).cloned()
     }
+
+    /// Forget every recorded upload. Stored files stay in the VFS.
+    pub fn clear_uploads(&self) {
+        self.uploads.write().clear();
+    }
 }
 
 impl Default for FtpSpecRegistry {
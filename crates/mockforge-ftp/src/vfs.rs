@@ -161,6 +161,16 @@ impl VirtualFileSystem {
         Ok(())
     }
 
+    /// Create every directory above `path`, so clients can change into them
+    pub async fn create_parent_directories_async(&self, path: &Path) {
+        let mut dirs = self.directories.write().await;
+        for ancestor in path.ancestors().skip(1) {
+            if !ancestor.as_os_str().is_empty() {
+                dirs.insert(ancestor.to_path_buf());
+            }
+        }
+    }
+
     /// Async version of is_directory_empty — use from async contexts.
     pub async fn is_directory_empty_async(&self, path: &Path) -> bool {
         let files = self.files.read().await;
@@ -233,6 +233,31 @@ pub async fn discover_services(
         service_reg_duration
     );
 
+    // Publish the services for the management API's gRPC service listing
+    mockforge_core::request_logger::set_global_grpc_services(
+        registry
+            .services
+            .values()
+            .map(|service| {
+                let service = service.service();
+                mockforge_core::request_logger::GlobalGrpcServiceInfo {
+                    name: service.name.clone(),
+                    methods: service
+                        .methods
+                        .iter()
+                        .map(|method| mockforge_core::request_logger::GlobalGrpcMethodInfo {
+                            name: method.name.clone(),
+                            input_type: method.input_type.clone(),
+                            output_type: method.output_type.clone(),
+                            client_streaming: method.client_streaming,
+                            server_streaming: method.server_streaming,
+                        })
+                        .collect(),
+                }
+            })
+            .collect(),
+    );
+
     let total_discovery_duration = discovery_start.elapsed();
     info!("Service discovery completed (total time: {:?})", total_discovery_duration);
     Ok(registry)
@@ -545,16 +570,11 @@ async fn start_grpc_only_server(
                     let path = req.uri().path().to_string();
                     match router::parse_grpc_path(&path) {
                         Some((service_name, method_name)) => {
-                            // Collect the request body (limit to 4MB)
-                            let body = axum::body::to_bytes(req.into_body(), 4 * 1024 * 1024)
-                                .await
-                                .unwrap_or_default();
-
                             match router::handle_dynamic_grpc_request(
                                 &registry,
                                 service_name,
                                 method_name,
-                                body,
+                                req.into_body(),
                             )
                             .await
                             {
@@ -92,9 +92,10 @@ impl ProtoParser {
             return Ok(());
         }
 
-        // Discover all proto files
+        // Discover all proto files and precompiled descriptor sets
         let proto_files = self.discover_proto_files(proto_path)?;
-        if proto_files.is_empty() {
+        let descriptor_sets = self.discover_descriptor_sets(proto_path)?;
+        if proto_files.is_empty() && descriptor_sets.is_empty() {
             warn!("No proto files found in directory: {}", proto_dir);
             return Ok(());
         }
@@ -120,6 +121,12 @@ impl ProtoParser {
             }
         }
 
+        for descriptor_set in &descriptor_sets {
+            if let Err(e) = self.load_descriptor_set(descriptor_set) {
+                error!("Failed to load descriptor set {}: {}", descriptor_set, e);
+            }
+        }
+
         // Extract services from the descriptor pool only if there are any services in the pool
         if self.pool.services().count() > 0 {
             self.extract_services()?;
@@ -156,6 +163,48 @@ impl ProtoParser {
         Ok(proto_files)
     }
 
+    /// Discover serialized FileDescriptorSets in a directory recursively, as
+    /// written by `protoc --descriptor_set_out` or `buf build`
+    #[allow(clippy::only_used_in_recursion)]
+    fn discover_descriptor_sets(
+        &self,
+        dir: &Path,
+    ) -> Result<Vec<String>, Box<dyn std::error::Error + Send + Sync>> {
+        let mut descriptor_sets = Vec::new();
+
+        if let Ok(entries) = fs::read_dir(dir) {
+            for entry in entries.flatten() {
+                let path = entry.path();
+
+                if path.is_dir() {
+                    descriptor_sets.extend(self.discover_descriptor_sets(&path)?);
+                } else if matches!(
+                    path.extension().and_then(|s| s.to_str()),
+                    Some("binpb" | "pb" | "desc")
+                ) {
+                    descriptor_sets.push(path.to_string_lossy().to_string());
+                }
+            }
+        }
+
+        descriptor_sets.sort();
+        Ok(descriptor_sets)
+    }
+
+    /// Load a serialized FileDescriptorSet into the pool. Files the pool
+    /// already holds are skipped.
+    fn load_descriptor_set(
+        &mut self,
+        path: &str,
+    ) -> Result<(), Box<dyn std::error::Error + Send + Sync>> {
+        let descriptor_bytes = fs::read(path)?;
+        self.pool
+            .decode_file_descriptor_set(&*descriptor_bytes)
+            .map_err(|e| format!("Failed to decode descriptor set: {}", e))?;
+        info!("Loaded descriptor set: {}", path);
+        Ok(())
+    }
+
     /// Parse a single proto file using protoc compilation
     async fn parse_proto_file(
         &mut self,
@@ -816,4 +865,47 @@ mod tests {
         assert!(method_names.contains(&"SayHelloClientStream"));
         assert!(method_names.contains(&"Chat"));
     }
+
+    #[tokio::test]
+    async fn test_parse_directory_descriptor_set() {
+        use prost::Message;
+        use prost_types::{
+            DescriptorProto, FileDescriptorProto, FileDescriptorSet, MethodDescriptorProto,
+            ServiceDescriptorProto,
+        };
+
+        let message = |name: &str| DescriptorProto {
+            name: Some(name.to_string()),
+            ..Default::default()
+        };
+        let file = FileDescriptorProto {
+            name: Some("shop/v1/orders.proto".to_string()),
+            package: Some("shop.v1".to_string()),
+            message_type: vec![message("GetOrderRequest"), message("Order")],
+            service: vec![ServiceDescriptorProto {
+                name: Some("Orders".to_string()),
+                method: vec![MethodDescriptorProto {
+                    name: Some("GetOrder".to_string()),
+                    input_type: Some(".shop.v1.GetOrderRequest".to_string()),
+                    output_type: Some(".shop.v1.Order".to_string()),
+                    ..Default::default()
+                }],
+                ..Default::default()
+            }],
+            syntax: Some("proto3".to_string()),
+            ..Default::default()
+        };
+        let temp_dir = TempDir::new().unwrap();
+        let descriptor_set = FileDescriptorSet { file: vec![file] };
+        fs::write(temp_dir.path().join("orders.binpb"), descriptor_set.encode_to_vec()).unwrap();
+
+        let mut parser = ProtoParser::new();
+        parser.parse_directory(temp_dir.path().to_str().unwrap()).await.unwrap();
+
+        // Descriptor sets are loaded without protoc
+        let service = parser.get_service("shop.v1.Orders").unwrap();
+        assert_eq!(service.methods.len(), 1);
+        assert_eq!(service.methods[0].input_type, "shop.v1.GetOrderRequest");
+        assert_eq!(service.methods[0].output_type, "shop.v1.Order");
+    }
 }
@@ -3,11 +3,17 @@
 //! Routes incoming gRPC requests to the appropriate dynamically-discovered service
 //! using the service registry and descriptor pool for response generation.
 
+use super::http_bridge::converters::ProtobufJsonConverter;
 use super::ServiceRegistry;
-use http::header::HeaderValue;
-use mockforge_core::config::{GrpcOverride, GrpcOverrideResponse};
+use axum::body::Bytes;
+use http::header::{HeaderMap, HeaderValue};
+use http_body::Frame;
+use mockforge_core::config::{GrpcOverride, GrpcOverrideResponse, GrpcStreamStep};
 use prost_reflect::prost::Message as _;
 use prost_reflect::{DynamicMessage, MessageDescriptor, Value};
+use std::convert::Infallible;
+use std::time::Duration;
+use tokio::sync::watch;
 use tonic::{Code, Status};
 use tracing::{debug, warn};
 
@@ -133,24 +139,31 @@ pub fn encode_grpc_body(message: &DynamicMessage) -> Vec<u8> {
 
 /// Create an HTTP response representing a gRPC error
 pub fn create_grpc_error_response(status: Status) -> axum::response::Response {
-    let code = status.code() as i32;
-    let message = status.message().to_string();
-
     let mut response = axum::response::Response::new(axum::body::Body::empty());
     *response.status_mut() = http::StatusCode::OK; // gRPC always returns HTTP 200
     response
         .headers_mut()
         .insert("content-type", HeaderValue::from_static("application/grpc"));
-    response.headers_mut().insert(
+    response.headers_mut().extend(grpc_status_headers(&status));
+    response
+}
+
+/// The `grpc-status` and `grpc-message` headers reporting a status
+fn grpc_status_headers(status: &Status) -> HeaderMap {
+    let code = status.code() as i32;
+    let message = status.message();
+
+    let mut headers = HeaderMap::new();
+    headers.insert(
         "grpc-status",
         HeaderValue::from_str(&code.to_string()).unwrap_or(HeaderValue::from_static("2")),
     );
     if !message.is_empty() {
-        if let Ok(val) = HeaderValue::from_str(&message) {
-            response.headers_mut().insert("grpc-message", val);
+        if let Ok(val) = HeaderValue::from_str(message) {
+            headers.insert("grpc-message", val);
         }
     }
-    response
+    headers
 }
 
 /// Handle a dynamic gRPC request using the service registry and descriptor pool.
@@ -160,7 +173,7 @@ pub async fn handle_dynamic_grpc_request(
     registry: &ServiceRegistry,
     service_name: &str,
     method_name: &str,
-    _body: axum::body::Bytes,
+    body: axum::body::Body,
 ) -> Result<axum::response::Response, Status> {
     debug!("Dynamic gRPC handler: {}/{}", service_name, method_name);
 
@@ -185,19 +198,93 @@ pub async fn handle_dynamic_grpc_request(
                 ))
             })?;
 
+    let started = std::time::Instant::now();
+
+    // A scripted bidirectional stream answers while the client is still
+    // sending, so its request body is read as it arrives instead of being
+    // collected first. Like client streams, only catch-all overrides apply.
+    if method.client_streaming && method.server_streaming {
+        let output_desc = registry.descriptor_pool().get_message_by_name(&method.output_type);
+        let steps =
+            find_matching_override(registry.overrides(), service_name, &method.name, None, None)
+                .filter(|rule| !rule.response.stream.is_empty())
+                .and_then(|rule| prepare_stream(&rule.response.stream, output_desc.as_ref()));
+        if let Some(steps) = steps {
+            debug!("Applying stream override for {}.{}", service_name, method.name);
+            let result = Ok(stream_grpc_response(steps, Some(count_request_messages(body))));
+            record_grpc_call(registry, service_name, method, &[], started, &result).await;
+            return result;
+        }
+    }
+
+    // Collect the request body (limit to 4MB)
+    let body = axum::body::to_bytes(body, 4 * 1024 * 1024).await.unwrap_or_default();
+
     // Determine streaming type and handle
-    match (method.client_streaming, method.server_streaming) {
-        (false, false) => handle_unary(registry, service_name, method, &_body).await,
-        (false, true) => handle_server_streaming(registry, service_name, method).await,
+    let result = match (method.client_streaming, method.server_streaming) {
+        (false, false) => handle_unary(registry, service_name, method, &body).await,
+        (false, true) => handle_server_streaming(registry, service_name, method, &body).await,
         (true, false) => {
             // Client streaming: aggregate incoming frames, respond with single message
-            handle_client_streaming(registry, service_name, method, &_body).await
+            handle_client_streaming(registry, service_name, method, &body).await
         }
         (true, true) => {
             // Bidirectional streaming: respond with multiple frames
-            handle_bidi_streaming(registry, service_name, method, &_body).await
+            handle_bidi_streaming(registry, service_name, method, &body).await
+        }
+    };
+    record_grpc_call(registry, service_name, method, &body, started, &result).await;
+    result
+}
+
+/// Record a call in the request journal for verification. For calls with a
+/// single request message whose type is in the descriptor pool, the message
+/// is recorded as JSON in the `request_message` metadata.
+async fn record_grpc_call(
+    registry: &ServiceRegistry,
+    service_name: &str,
+    method: &super::proto_parser::ProtoMethod,
+    body: &[u8],
+    started: std::time::Instant,
+    result: &Result<axum::response::Response, Status>,
+) {
+    let pool = registry.descriptor_pool();
+    let message = if method.client_streaming {
+        None
+    } else {
+        pool.get_message_by_name(&method.input_type).and_then(|desc| {
+            let decoded =
+                DynamicMessage::decode(desc.clone(), decode_grpc_body(body).ok()?).ok()?;
+            ProtobufJsonConverter::new(pool.clone()).protobuf_to_json(&desc, &decoded).ok()
+        })
+    };
+
+    let (code, error) = match result {
+        Ok(response) => {
+            let code = response
+                .headers()
+                .get("grpc-status")
+                .and_then(|v| v.to_str().ok())
+                .and_then(|v| v.parse().ok())
+                .unwrap_or(0);
+            (code, None)
         }
+        Err(status) => (status.code() as u16, Some(status.message().to_string())),
+    };
+    let mut entry = mockforge_core::create_grpc_log_entry(
+        service_name,
+        &method.name,
+        code,
+        started.elapsed().as_millis() as u64,
+        None,
+        body.len() as u64,
+        0,
+        error,
+    );
+    if let Some(message) = message {
+        entry.metadata.insert("request_message".to_string(), message.to_string());
     }
+    mockforge_core::log_request_global(entry).await;
 }
 
 /// Find the first override rule that matches a `service/method` request.
@@ -206,11 +293,12 @@ pub async fn handle_dynamic_grpc_request(
 /// - The override's `service` must exactly equal `service_name` (callers should
 ///   pass the same form the proto uses — fully-qualified or not — consistently).
 /// - The override's `method` must exactly equal `method_name`.
-/// - If the override has a non-empty `match` map, every key must correspond to
-///   a top-level field of the decoded request whose stringified value equals
-///   the match value. If `request_body` is None or the request can't be
-///   decoded against `input_desc`, match conditions are skipped (a catch-all
-///   override — empty match — still applies; one with `match` does not).
+/// - If the override has a non-empty `match` map, every key must name a field
+///   of the decoded request (dotted for nested messages) whose stringified
+///   value equals the match value. If `request_body` is None or the request
+///   can't be decoded against `input_desc`, match conditions are skipped (a
+///   catch-all override — empty match — still applies; one with `match` does
+///   not).
 pub(super) fn find_matching_override<'a>(
     overrides: &'a [GrpcOverride],
     service_name: &str,
@@ -235,12 +323,9 @@ pub(super) fn find_matching_override<'a>(
             continue;
         };
 
-        let all_match = rule.r#match.iter().all(|(field_name, expected)| {
-            let Some(field) = desc.get_field_by_name(field_name) else {
-                return false;
-            };
-            let value = decoded.get_field(&field);
-            stringify_value(value.as_ref()) == *expected
+        let all_match = rule.r#match.iter().all(|(field_path, expected)| {
+            request_field(&decoded, field_path)
+                .is_some_and(|value| stringify_value(&value) == *expected)
         });
         if all_match {
             return Some(rule);
@@ -249,6 +334,23 @@ pub(super) fn find_matching_override<'a>(
     None
 }
 
+/// Look up a field of a decoded request by its proto or JSON name. Dotted
+/// paths address fields of nested messages, e.g. `customer.id`.
+fn request_field(message: &DynamicMessage, path: &str) -> Option<Value> {
+    let (name, rest) = match path.split_once('.') {
+        Some((name, rest)) => (name, Some(rest)),
+        None => (path, None),
+    };
+    let desc = message.descriptor();
+    let field = desc.get_field_by_name(name).or_else(|| desc.get_field_by_json_name(name))?;
+    let value = message.get_field(&field).into_owned();
+    match (rest, value) {
+        (None, value) => Some(value),
+        (Some(rest), Value::Message(nested)) => request_field(&nested, rest),
+        _ => None,
+    }
+}
+
 /// Stringify a `prost_reflect::Value` for equality matching against the
 /// `match` map (which is string-typed in YAML).
 fn stringify_value(value: &Value) -> String {
@@ -337,6 +439,123 @@ fn apply_override(
     }
 }
 
+/// A step of an override's `stream`, with its message encoded
+struct StreamStep {
+    delay: Duration,
+    after_requests: usize,
+    /// The message frame to send, or the status that ends the stream
+    action: Result<Bytes, Status>,
+}
+
+/// Encode the messages of an override's `stream`. Returns None, so the
+/// caller falls back to default generation, if a message can't be converted
+/// into the output type.
+fn prepare_stream(
+    steps: &[GrpcStreamStep],
+    output_desc: Option<&MessageDescriptor>,
+) -> Option<Vec<StreamStep>> {
+    let mut prepared = Vec::with_capacity(steps.len());
+    for (index, step) in steps.iter().enumerate() {
+        let action = if let Some(name) = step.status.as_deref() {
+            Err(Status::new(parse_status_code(name), step.message.clone().unwrap_or_default()))
+        } else {
+            let Some(desc) = output_desc else {
+                warn!("Override stream has messages but output descriptor unavailable");
+                return None;
+            };
+            let body = step.body.clone().unwrap_or_else(|| serde_json::json!({}));
+            let converter = ProtobufJsonConverter::new(desc.parent_pool().clone());
+            match converter.json_to_protobuf(desc, &body) {
+                Ok(msg) => Ok(Bytes::from(encode_grpc_body(&msg))),
+                Err(e) => {
+                    warn!("Override stream message {} failed to convert: {}", index, e);
+                    return None;
+                }
+            }
+        };
+        prepared.push(StreamStep {
+            delay: Duration::from_millis(step.delay_ms.unwrap_or(0)),
+            after_requests: step.after_requests.unwrap_or(0),
+            action,
+        });
+    }
+    Some(prepared)
+}
+
+/// Build a response that streams the steps in order, each after its delay,
+/// and ends with the status in trailers. For bidirectional calls, `received`
+/// counts the client's messages so steps can wait for them.
+fn stream_grpc_response(
+    steps: Vec<StreamStep>,
+    mut received: Option<watch::Receiver<usize>>,
+) -> axum::response::Response {
+    let (tx, rx) = tokio::sync::mpsc::channel::<Result<Frame<Bytes>, Infallible>>(16);
+    tokio::spawn(async move {
+        let mut status = Status::new(Code::Ok, "");
+        for step in steps {
+            if let Some(received) = received.as_mut().filter(|_| step.after_requests > 0) {
+                let closed =
+                    received.wait_for(|count| *count >= step.after_requests).await.is_err();
+                if closed {
+                    // The client closed its side before sending enough
+                    break;
+                }
+            }
+            if !step.delay.is_zero() {
+                tokio::time::sleep(step.delay).await;
+            }
+            match step.action {
+                Ok(frame) => {
+                    if tx.send(Ok(Frame::data(frame))).await.is_err() {
+                        return; // The client went away
+                    }
+                }
+                Err(end) => {
+                    status = end;
+                    break;
+                }
+            }
+        }
+        let _ = tx.send(Ok(Frame::trailers(grpc_status_headers(&status)))).await;
+    });
+
+    let body = http_body_util::StreamBody::new(tokio_stream::wrappers::ReceiverStream::new(rx));
+    let mut response = axum::response::Response::new(axum::body::Body::new(body));
+    *response.status_mut() = http::StatusCode::OK;
+    response
+        .headers_mut()
+        .insert("content-type", HeaderValue::from_static("application/grpc"));
+    response
+}
+
+/// Count the messages of a streamed request body as they arrive. The count's
+/// sender is dropped once the client closes its side.
+fn count_request_messages(body: axum::body::Body) -> watch::Receiver<usize> {
+    use futures::StreamExt;
+
+    let (tx, rx) = watch::channel(0);
+    tokio::spawn(async move {
+        let mut chunks = body.into_data_stream();
+        let mut buffered = Vec::new();
+        let mut count = 0;
+        while let Some(Ok(chunk)) = chunks.next().await {
+            buffered.extend_from_slice(&chunk);
+            while buffered.len() >= 5 {
+                let length =
+                    u32::from_be_bytes([buffered[1], buffered[2], buffered[3], buffered[4]])
+                        as usize;
+                if buffered.len() < 5 + length {
+                    break; // Incomplete frame
+                }
+                buffered.drain(..5 + length);
+                count += 1;
+            }
+            tx.send_replace(count);
+        }
+    });
+    rx
+}
+
 /// Handle a unary gRPC call
 async fn handle_unary(
     registry: &ServiceRegistry,
@@ -391,13 +610,34 @@ async fn handle_server_streaming(
     registry: &ServiceRegistry,
     service_name: &str,
     method: &super::proto_parser::ProtoMethod,
+    request_body: &[u8],
 ) -> Result<axum::response::Response, Status> {
     let pool = registry.descriptor_pool();
+    let input_desc = pool.get_message_by_name(&method.input_type);
+    let output_desc = pool.get_message_by_name(&method.output_type);
+
+    if let Some(rule) = find_matching_override(
+        registry.overrides(),
+        service_name,
+        &method.name,
+        input_desc.as_ref(),
+        Some(request_body),
+    ) {
+        if rule.response.stream.is_empty() {
+            if let Some(resp) = apply_override(&rule.response, output_desc.as_ref())? {
+                return Ok(resp);
+            }
+        } else if let Some(steps) = prepare_stream(&rule.response.stream, output_desc.as_ref()) {
+            debug!("Applying stream override for {}.{}", service_name, method.name);
+            return Ok(stream_grpc_response(steps, None));
+        }
+    }
+
     let stream_count = 3; // Send 3 mock messages
 
     let mut all_frames = Vec::new();
 
-    if let Some(output_desc) = pool.get_message_by_name(&method.output_type) {
+    if let Some(output_desc) = output_desc {
         for _ in 0..stream_count {
             let mock_msg = generate_message_from_descriptor(&output_desc, 0);
             all_frames.extend_from_slice(&encode_grpc_body(&mock_msg));
@@ -434,8 +674,19 @@ async fn handle_client_streaming(
         service_name, method.name, frame_count
     );
 
+    // A client stream has no single request message to match, so only
+    // catch-all overrides apply
+    let output_desc = pool.get_message_by_name(&method.output_type);
+    if let Some(rule) =
+        find_matching_override(registry.overrides(), service_name, &method.name, None, None)
+    {
+        if let Some(resp) = apply_override(&rule.response, output_desc.as_ref())? {
+            return Ok(resp);
+        }
+    }
+
     // Generate a single aggregated response
-    let response_bytes = if let Some(output_desc) = pool.get_message_by_name(&method.output_type) {
+    let response_bytes = if let Some(output_desc) = output_desc {
         let mock_msg = generate_message_from_descriptor(&output_desc, 0);
         encode_grpc_body(&mock_msg)
     } else {
@@ -448,7 +699,9 @@ async fn handle_client_streaming(
 /// Handle a bidirectional-streaming gRPC call.
 ///
 /// Reads all incoming frames and returns a stream of response frames
-/// (one response per incoming frame, or a minimum of 3).
+/// (one response per incoming frame, or a minimum of 3). Overrides with a
+/// `stream` are handled before the request is read; see
+/// [`handle_dynamic_grpc_request`].
 async fn handle_bidi_streaming(
     registry: &ServiceRegistry,
     service_name: &str,
@@ -457,6 +710,16 @@ async fn handle_bidi_streaming(
 ) -> Result<axum::response::Response, Status> {
     let pool = registry.descriptor_pool();
 
+    // As for client streams, only catch-all overrides apply
+    let output_desc = pool.get_message_by_name(&method.output_type);
+    if let Some(rule) =
+        find_matching_override(registry.overrides(), service_name, &method.name, None, None)
+    {
+        if let Some(resp) = apply_override(&rule.response, output_desc.as_ref())? {
+            return Ok(resp);
+        }
+    }
+
     // Count incoming frames to determine how many responses to send
     let incoming_count = count_grpc_frames(body);
     let response_count = incoming_count.max(3); // At least 3 responses
@@ -468,7 +731,7 @@ async fn handle_bidi_streaming(
 
     let mut all_frames = Vec::new();
 
-    if let Some(output_desc) = pool.get_message_by_name(&method.output_type) {
+    if let Some(output_desc) = output_desc {
         for _ in 0..response_count {
             let mock_msg = generate_message_from_descriptor(&output_desc, 0);
             all_frames.extend_from_slice(&encode_grpc_body(&mock_msg));
@@ -560,6 +823,7 @@ mod tests {
                 status: status.map(|s| s.to_string()),
                 message: None,
                 body: None,
+                stream: vec![],
             },
         }
     }
@@ -608,6 +872,131 @@ mod tests {
         assert_eq!(m.response.status.as_deref(), Some("NOT_FOUND"));
     }
 
+    #[test]
+    fn test_find_override_matches_nested_fields() {
+        use prost_types::field_descriptor_proto::{Label, Type};
+        use prost_types::{
+            DescriptorProto, FieldDescriptorProto, FileDescriptorProto, FileDescriptorSet,
+        };
+
+        let field = |name: &str, json_name: &str, r#type: Type, type_name: Option<&str>| {
+            FieldDescriptorProto {
+                name: Some(name.to_string()),
+                json_name: Some(json_name.to_string()),
+                number: Some(1),
+                label: Some(Label::Optional as i32),
+                r#type: Some(r#type as i32),
+                type_name: type_name.map(str::to_string),
+                ..Default::default()
+            }
+        };
+        let file = FileDescriptorProto {
+            name: Some("shop.proto".to_string()),
+            package: Some("shop".to_string()),
+            message_type: vec![
+                DescriptorProto {
+                    name: Some("Customer".to_string()),
+                    field: vec![field("account_id", "accountId", Type::String, None)],
+                    ..Default::default()
+                },
+                DescriptorProto {
+                    name: Some("GetOrderRequest".to_string()),
+                    field: vec![field(
+                        "customer",
+                        "customer",
+                        Type::Message,
+                        Some(".shop.Customer"),
+                    )],
+                    ..Default::default()
+                },
+            ],
+            syntax: Some("proto3".to_string()),
+            ..Default::default()
+        };
+        let descriptor_set = FileDescriptorSet { file: vec![file] };
+        let encoded = prost::Message::encode_to_vec(&descriptor_set);
+        let pool = prost_reflect::DescriptorPool::decode(encoded.as_slice()).unwrap();
+        let desc = pool.get_message_by_name("shop.GetOrderRequest").unwrap();
+
+        let mut customer = DynamicMessage::new(pool.get_message_by_name("shop.Customer").unwrap());
+        customer.set_field_by_name("account_id", Value::String("acct-1".to_string()));
+        let mut request = DynamicMessage::new(desc.clone());
+        request.set_field_by_name("customer", Value::Message(customer));
+        let body = encode_grpc_body(&request);
+
+        for key in ["customer.account_id", "customer.accountId"] {
+            let rules = vec![override_rule(
+                "shop.Orders",
+                "GetOrder",
+                &[(key, "acct-1")],
+                None,
+            )];
+            assert!(
+                find_matching_override(&rules, "shop.Orders", "GetOrder", Some(&desc), Some(&body))
+                    .is_some(),
+                "{key} should match the nested field"
+            );
+        }
+        let rules = vec![override_rule(
+            "shop.Orders",
+            "GetOrder",
+            &[("customer.account_id", "x")],
+            None,
+        )];
+        assert!(find_matching_override(
+            &rules,
+            "shop.Orders",
+            "GetOrder",
+            Some(&desc),
+            Some(&body)
+        )
+        .is_none());
+    }
+
+    #[tokio::test]
+    async fn test_client_streaming_applies_catch_all_override() {
+        let mut registry = ServiceRegistry::new();
+        registry.set_overrides(vec![
+            override_rule("shop.Uploads", "Upload", &[("name", "x")], Some("NOT_FOUND")),
+            override_rule("shop.Uploads", "Upload", &[], Some("RESOURCE_EXHAUSTED")),
+        ]);
+        let method = super::super::proto_parser::ProtoMethod {
+            name: "Upload".to_string(),
+            input_type: "shop.Chunk".to_string(),
+            output_type: "shop.Summary".to_string(),
+            client_streaming: true,
+            server_streaming: false,
+        };
+
+        let response =
+            handle_client_streaming(&registry, "shop.Uploads", &method, &[]).await.unwrap();
+        assert_eq!(response.headers().get("grpc-status").unwrap(), "8");
+    }
+
+    #[tokio::test]
+    async fn test_stream_response_ends_with_status_trailers() {
+        use http_body_util::BodyExt;
+
+        let step = |action| StreamStep {
+            delay: Duration::from_millis(10),
+            after_requests: 0,
+            action,
+        };
+        let steps = vec![
+            step(Ok(Bytes::from_static(b"\0\0\0\0\x01a"))),
+            step(Err(Status::unavailable("connection reset"))),
+            step(Ok(Bytes::from_static(b"\0\0\0\0\x01b"))),
+        ];
+
+        let response = stream_grpc_response(steps, None);
+        assert!(response.headers().get("grpc-status").is_none());
+        let collected = response.into_body().collect().await.unwrap();
+        let trailers = collected.trailers().cloned().unwrap();
+        assert_eq!(trailers.get("grpc-status").unwrap(), "14");
+        assert_eq!(trailers.get("grpc-message").unwrap(), "connection reset");
+        assert_eq!(collected.to_bytes().as_ref(), b"\0\0\0\0\x01a");
+    }
+
     #[test]
     fn test_parse_status_code_recognizes_standard_names() {
         assert_eq!(parse_status_code("NOT_FOUND"), Code::NotFound);
@@ -0,0 +1,234 @@
+//! Overrides with a `stream` script the responses of server-streaming and
+//! bidirectional methods: messages sent with their delays, a status ending
+//! the stream mid-way, and, for bidi calls, messages held until the client
+//! has sent enough. A real `tonic` client calls a service loaded from a
+//! descriptor set.
+
+use mockforge_core::config::{GrpcOverride, GrpcOverrideResponse, GrpcStreamStep};
+use mockforge_grpc::dynamic::DynamicGrpcConfig;
+use prost_types::field_descriptor_proto::{Label, Type};
+use prost_types::{
+    DescriptorProto, FieldDescriptorProto, FileDescriptorProto, FileDescriptorSet,
+    MethodDescriptorProto, ServiceDescriptorProto,
+};
+use serde_json::json;
+use std::collections::HashMap;
+use std::time::{Duration, Instant};
+use tokio_stream::wrappers::ReceiverStream;
+
+#[derive(Clone, PartialEq, prost::Message)]
+struct WatchRequest {
+    #[prost(string, tag = "1")]
+    order_id: String,
+}
+
+#[derive(Clone, PartialEq, prost::Message)]
+struct OrderEvent {
+    #[prost(string, tag = "1")]
+    status: String,
+}
+
+/// A descriptor set declaring `shop.Orders` with a server-streaming `Watch`
+/// and a bidirectional `Chat`
+fn descriptor_set() -> Vec<u8> {
+    let message = |name: &str, field: &str| DescriptorProto {
+        name: Some(name.to_string()),
+        field: vec![FieldDescriptorProto {
+            name: Some(field.to_string()),
+            json_name: Some(field.to_string()),
+            number: Some(1),
+            label: Some(Label::Optional as i32),
+            r#type: Some(Type::String as i32),
+            ..Default::default()
+        }],
+        ..Default::default()
+    };
+    let method = |name: &str, input: &str, client_streaming: bool| MethodDescriptorProto {
+        name: Some(name.to_string()),
+        input_type: Some(format!(".shop.{input}")),
+        output_type: Some(".shop.OrderEvent".to_string()),
+        client_streaming: Some(client_streaming),
+        server_streaming: Some(true),
+        ..Default::default()
+    };
+    let file = FileDescriptorProto {
+        name: Some("shop.proto".to_string()),
+        package: Some("shop".to_string()),
+        message_type: vec![
+            message("WatchRequest", "order_id"),
+            message("OrderEvent", "status"),
+        ],
+        service: vec![ServiceDescriptorProto {
+            name: Some("Orders".to_string()),
+            method: vec![
+                method("Watch", "WatchRequest", false),
+                method("Chat", "OrderEvent", true),
+            ],
+            ..Default::default()
+        }],
+        syntax: Some("proto3".to_string()),
+        ..Default::default()
+    };
+    prost::Message::encode_to_vec(&FileDescriptorSet { file: vec![file] })
+}
+
+fn send(status: &str, delay_ms: u64, after_requests: usize) -> GrpcStreamStep {
+    GrpcStreamStep {
+        body: Some(json!({ "status": status })),
+        delay_ms: Some(delay_ms),
+        after_requests: Some(after_requests),
+        ..Default::default()
+    }
+}
+
+fn stream_override(
+    method: &str,
+    r#match: HashMap<String, String>,
+    stream: Vec<GrpcStreamStep>,
+) -> GrpcOverride {
+    GrpcOverride {
+        service: "shop.Orders".to_string(),
+        method: method.to_string(),
+        r#match,
+        response: GrpcOverrideResponse {
+            stream,
+            ..Default::default()
+        },
+    }
+}
+
+/// Start the server with the overrides and connect a client to it
+async fn spawn_orders(
+    overrides: Vec<GrpcOverride>,
+) -> (tonic::client::Grpc<tonic::transport::Channel>, tempfile::TempDir) {
+    let proto_dir = tempfile::tempdir().unwrap();
+    std::fs::write(proto_dir.path().join("shop.binpb"), descriptor_set()).unwrap();
+
+    let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+    let port = listener.local_addr().unwrap().port();
+    drop(listener);
+    let config = DynamicGrpcConfig {
+        proto_dir: proto_dir.path().to_string_lossy().into_owned(),
+        enable_reflection: false,
+        excluded_services: vec![],
+        http_bridge: None,
+        tls: None,
+        overrides,
+    };
+    tokio::spawn(async move {
+        mockforge_grpc::start_with_config(port, None, config).await.unwrap();
+    });
+
+    let endpoint = format!("http://127.0.0.1:{port}");
+    let deadline = Instant::now() + Duration::from_secs(5);
+    let channel = loop {
+        match tonic::transport::Endpoint::from_shared(endpoint.clone())
+            .unwrap()
+            .connect()
+            .await
+        {
+            Ok(channel) => break channel,
+            Err(_) if Instant::now() < deadline => {
+                tokio::time::sleep(Duration::from_millis(50)).await
+            }
+            Err(e) => panic!("gRPC server never started on {endpoint}: {e}"),
+        }
+    };
+    (tonic::client::Grpc::new(channel), proto_dir)
+}
+
+async fn next_event(
+    stream: &mut tonic::Streaming<OrderEvent>,
+) -> Result<Option<OrderEvent>, tonic::Status> {
+    tokio::time::timeout(Duration::from_secs(3), stream.message())
+        .await
+        .expect("stream should produce a message or end")
+}
+
+#[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+async fn server_stream_sends_scripted_messages_then_fails() {
+    let (mut client, _proto_dir) = spawn_orders(vec![stream_override(
+        "Watch",
+        HashMap::from([("order_id".to_string(), "42".to_string())]),
+        vec![
+            send("PACKED", 0, 0),
+            send("SHIPPED", 300, 0),
+            GrpcStreamStep {
+                status: Some("UNAVAILABLE".to_string()),
+                message: Some("connection reset".to_string()),
+                ..Default::default()
+            },
+        ],
+    )])
+    .await;
+
+    client.ready().await.unwrap();
+    let response = client
+        .server_streaming(
+            tonic::Request::new(WatchRequest {
+                order_id: "42".to_string(),
+            }),
+            http::uri::PathAndQuery::from_static("/shop.Orders/Watch"),
+            tonic_prost::ProstCodec::<WatchRequest, OrderEvent>::default(),
+        )
+        .await
+        .unwrap();
+    let mut stream = response.into_inner();
+
+    let started = Instant::now();
+    assert_eq!(next_event(&mut stream).await.unwrap().unwrap().status, "PACKED");
+    assert_eq!(next_event(&mut stream).await.unwrap().unwrap().status, "SHIPPED");
+    assert!(started.elapsed() >= Duration::from_millis(250), "SHIPPED should be delayed");
+    let status = next_event(&mut stream).await.unwrap_err();
+    assert_eq!(status.code(), tonic::Code::Unavailable);
+    assert_eq!(status.message(), "connection reset");
+}
+
+#[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+async fn bidi_stream_holds_messages_until_the_client_sends() {
+    let (mut client, _proto_dir) = spawn_orders(vec![stream_override(
+        "Chat",
+        HashMap::new(),
+        vec![send("ready", 0, 0), send("got two", 0, 2)],
+    )])
+    .await;
+
+    let (requests, rx) = tokio::sync::mpsc::channel(4);
+    client.ready().await.unwrap();
+    let response = client
+        .streaming(
+            tonic::Request::new(ReceiverStream::new(rx)),
+            http::uri::PathAndQuery::from_static("/shop.Orders/Chat"),
+            tonic_prost::ProstCodec::<OrderEvent, OrderEvent>::default(),
+        )
+        .await
+        .unwrap();
+    let mut stream = response.into_inner();
+
+    assert_eq!(next_event(&mut stream).await.unwrap().unwrap().status, "ready");
+    requests
+        .send(OrderEvent {
+            status: "one".to_string(),
+        })
+        .await
+        .unwrap();
+    assert!(
+        tokio::time::timeout(Duration::from_millis(200), stream.message())
+            .await
+            .is_err(),
+        "the second message should wait for the client's second"
+    );
+    requests
+        .send(OrderEvent {
+            status: "two".to_string(),
+        })
+        .await
+        .unwrap();
+    assert_eq!(next_event(&mut stream).await.unwrap().unwrap().status, "got two");
+
+    drop(requests);
+    assert!(
+        next_event(&mut stream).await.unwrap().is_none(),
+        "the stream should end with OK"
+    );
+}
@@ -292,6 +292,8 @@ impl HttpRouterBuilder {
             self.mqtt_broker,
             None, // amqp_broker — wired directly from serve.rs, not via this builder
             None, // kafka_broker — wired directly from serve.rs, not via this builder
+            None, // ftp_registry — wired directly from serve.rs, not via this builder
+            None, // ws_scripts — wired directly from serve.rs, not via this builder
             self.traffic_shaper,
             self.traffic_shaping_enabled,
             self.health_manager,
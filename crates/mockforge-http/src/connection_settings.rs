@@ -0,0 +1,425 @@
+//! Connection settings for the plain-HTTP listener.
+//!
+//! Lets clients be tested against restrictive upstreams. The settings are
+//! applied where hyper reads and keeps connections, not per request, so a
+//! client trickling its request head is cut off even though no handler ever
+//! runs.
+//!
+//! Configured through environment variables, all unset by default:
+//!
+//! - `MOCKFORGE_HTTP_HEADER_READ_TIMEOUT_MS`: close connections that have not
+//!   sent a complete request head this long after it was due, which also
+//!   bounds how long a kept-alive connection may wait for its next request
+//! - `MOCKFORGE_HTTP_MAX_HEADER_BYTES`: answer 431 to request heads larger
+//!   than this; hyper's read buffer cannot go below 8 KiB, so smaller values
+//!   are raised to it
+//! - `MOCKFORGE_HTTP_IDLE_TIMEOUT_MS`: close connections with no request in
+//!   flight and no traffic for this long
+//! - `MOCKFORGE_HTTP_MAX_CONNECTIONS`: connections open at once; further
+//!   connections wait in the listen backlog until one closes
+//! - `MOCKFORGE_HTTP_KEEPALIVE=0` (or `false|no|off`): close every connection
+//!   after one response
+
+use axum::body::{Body, Bytes};
+use axum::extract::Request;
+use axum::response::Response;
+use axum::serve::Listener;
+use hyper::body::{Body as _, Frame, Incoming, SizeHint};
+use hyper_util::rt::{TokioExecutor, TokioIo, TokioTimer};
+use hyper_util::server::conn::auto;
+use mockforge_chaos::{FaultConnectInfo, FaultStream};
+use std::convert::Infallible;
+use std::future::Future;
+use std::net::SocketAddr;
+use std::pin::Pin;
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::sync::Arc;
+use std::task::{Context, Poll};
+use std::time::Duration;
+use tokio::io::{AsyncRead, AsyncWrite, ReadBuf};
+use tokio::sync::Semaphore;
+use tokio::time::{Instant, Sleep};
+use tower::{Service, ServiceExt};
+use tracing::debug;
+
+/// Smallest read buffer hyper accepts, which bounds the largest request head
+const MIN_HEADER_BUFFER_BYTES: usize = 8 * 1024;
+
+/// How the HTTP listener treats client connections
+#[derive(Debug, Clone)]
+pub struct ConnectionSettings {
+    /// Time allowed for a complete request head
+    pub header_read_timeout: Option<Duration>,
+    /// Largest request head accepted
+    pub max_header_bytes: Option<usize>,
+    /// Time a connection may sit idle between requests
+    pub idle_timeout: Option<Duration>,
+    /// Connections open at once
+    pub max_connections: Option<usize>,
+    /// Serve more than one request per connection
+    pub keep_alive: bool,
+}
+
+impl Default for ConnectionSettings {
+    fn default() -> Self {
+        Self {
+            header_read_timeout: None,
+            max_header_bytes: None,
+            idle_timeout: None,
+            max_connections: None,
+            keep_alive: true,
+        }
+    }
+}
+
+impl ConnectionSettings {
+    /// Read the settings from the environment
+    pub fn from_env() -> Self {
+        let positive = |name: &str| {
+            std::env::var(name).ok().and_then(|v| v.parse::<u64>().ok()).filter(|v| *v > 0)
+        };
+        Self {
+            header_read_timeout: positive("MOCKFORGE_HTTP_HEADER_READ_TIMEOUT_MS")
+                .map(Duration::from_millis),
+            max_header_bytes: positive("MOCKFORGE_HTTP_MAX_HEADER_BYTES").map(|n| n as usize),
+            idle_timeout: positive("MOCKFORGE_HTTP_IDLE_TIMEOUT_MS").map(Duration::from_millis),
+            max_connections: positive("MOCKFORGE_HTTP_MAX_CONNECTIONS").map(|n| n as usize),
+            keep_alive: !std::env::var("MOCKFORGE_HTTP_KEEPALIVE")
+                .map(|v| matches!(v.to_ascii_lowercase().as_str(), "0" | "false" | "no" | "off"))
+                .unwrap_or(false),
+        }
+    }
+
+    /// The hyper connection builder applying the settings
+    fn builder(&self) -> auto::Builder<TokioExecutor> {
+        let mut builder = auto::Builder::new(TokioExecutor::new());
+        let mut http1 = builder.http1();
+        http1.keep_alive(self.keep_alive);
+        if let Some(timeout) = self.header_read_timeout {
+            http1.timer(TokioTimer::new()).header_read_timeout(timeout);
+        }
+        if let Some(max) = self.max_header_bytes {
+            http1.max_buf_size(max.max(MIN_HEADER_BUFFER_BYTES));
+        }
+        builder
+    }
+}
+
+/// Accept connections from `listener` and serve each with a service from
+/// `make_service`, applying `settings`. Runs until the task is dropped.
+pub async fn serve<L, M, S>(
+    mut listener: L,
+    mut make_service: M,
+    settings: ConnectionSettings,
+) -> std::io::Result<()>
+where
+    L: Listener<Io = FaultStream, Addr = SocketAddr>,
+    M: Service<FaultConnectInfo, Response = S, Error = Infallible>,
+    S: Service<Request, Response = Response, Error = Infallible> + Clone + Send + 'static,
+    S::Future: Send + 'static,
+{
+    let builder = settings.builder();
+    let connections = settings.max_connections.map(|n| Arc::new(Semaphore::new(n)));
+
+    loop {
+        // Without a free slot, connections stay in the listen backlog
+        let slot = match &connections {
+            Some(connections) => Some(
+                connections
+                    .clone()
+                    .acquire_owned()
+                    .await
+                    .map_err(|e| std::io::Error::other(e.to_string()))?,
+            ),
+            None => None,
+        };
+        let (stream, addr) = listener.accept().await;
+        let info = FaultConnectInfo {
+            addr,
+            control: stream.control().clone(),
+        };
+        let service = match make_service.ready().await {
+            Ok(ready) => match ready.call(info).await {
+                Ok(service) => service,
+                Err(never) => match never {},
+            },
+            Err(never) => match never {},
+        };
+
+        let in_flight = Arc::new(AtomicUsize::new(0));
+        let io =
+            TokioIo::new(IdleTimeoutStream::new(stream, settings.idle_timeout, in_flight.clone()));
+        let hyper_service = hyper::service::service_fn(move |req: hyper::Request<Incoming>| {
+            let busy = Busy::enter(&in_flight);
+            let service = service.clone();
+            async move {
+                let response = service.oneshot(req.map(Body::new)).await?;
+                Ok::<_, Infallible>(response.map(|body| BusyBody { body, _busy: busy }))
+            }
+        });
+        let builder = builder.clone();
+        tokio::spawn(async move {
+            if let Err(e) = builder.serve_connection_with_upgrades(io, hyper_service).await {
+                debug!("HTTP connection from {} ended with an error: {}", addr, e);
+            }
+            drop(slot);
+        });
+    }
+}
+
+/// Marks a connection busy while a request is handled and its response
+/// body written, so the idle timeout never cuts off a slow response
+struct Busy(Arc<AtomicUsize>);
+
+impl Busy {
+    fn enter(in_flight: &Arc<AtomicUsize>) -> Self {
+        in_flight.fetch_add(1, Ordering::AcqRel);
+        Self(in_flight.clone())
+    }
+}
+
+impl Drop for Busy {
+    fn drop(&mut self) {
+        self.0.fetch_sub(1, Ordering::AcqRel);
+    }
+}
+
+/// Response body that keeps its connection busy until hyper drops it
+struct BusyBody {
+    body: Body,
+    _busy: Busy,
+}
+
+impl hyper::body::Body for BusyBody {
+    type Data = Bytes;
+    type Error = axum::Error;
+
+    fn poll_frame(
+        self: Pin<&mut Self>,
+        cx: &mut Context<'_>,
+    ) -> Poll<Option<Result<Frame<Self::Data>, Self::Error>>> {
+        Pin::new(&mut self.get_mut().body).poll_frame(cx)
+    }
+
+    fn is_end_stream(&self) -> bool {
+        self.body.is_end_stream()
+    }
+
+    fn size_hint(&self) -> SizeHint {
+        self.body.size_hint()
+    }
+}
+
+/// Stream that reads end-of-file once its connection has had no request in
+/// flight and no traffic for the idle timeout, which makes hyper close it
+struct IdleTimeoutStream {
+    inner: FaultStream,
+    timeout: Option<Duration>,
+    deadline: Pin<Box<Sleep>>,
+    in_flight: Arc<AtomicUsize>,
+}
+
+impl IdleTimeoutStream {
+    fn new(inner: FaultStream, timeout: Option<Duration>, in_flight: Arc<AtomicUsize>) -> Self {
+        let deadline = Instant::now() + timeout.unwrap_or_default();
+        Self {
+            inner,
+            timeout,
+            deadline: Box::pin(tokio::time::sleep_until(deadline)),
+            in_flight,
+        }
+    }
+
+    fn touch(&mut self) {
+        if let Some(timeout) = self.timeout {
+            self.deadline.as_mut().reset(Instant::now() + timeout);
+        }
+    }
+}
+
+impl AsyncRead for IdleTimeoutStream {
+    fn poll_read(
+        self: Pin<&mut Self>,
+        cx: &mut Context<'_>,
+        buf: &mut ReadBuf<'_>,
+    ) -> Poll<std::io::Result<()>> {
+        let this = self.get_mut();
+        let filled = buf.filled().len();
+        match Pin::new(&mut this.inner).poll_read(cx, buf) {
+            Poll::Ready(result) => {
+                if buf.filled().len() > filled {
+                    this.touch();
+                }
+                Poll::Ready(result)
+            }
+            Poll::Pending if this.timeout.is_none() => Poll::Pending,
+            Poll::Pending if this.in_flight.load(Ordering::Acquire) > 0 => {
+                this.touch();
+                Poll::Pending
+            }
+            Poll::Pending => match this.deadline.as_mut().poll(cx) {
+                Poll::Ready(()) => Poll::Ready(Ok(())),
+                Poll::Pending => Poll::Pending,
+            },
+        }
+    }
+}
+
+impl AsyncWrite for IdleTimeoutStream {
+    fn poll_write(
+        self: Pin<&mut Self>,
+        cx: &mut Context<'_>,
+        buf: &[u8],
+    ) -> Poll<std::io::Result<usize>> {
+        let this = self.get_mut();
+        let written = Pin::new(&mut this.inner).poll_write(cx, buf);
+        if matches!(written, Poll::Ready(Ok(n)) if n > 0) {
+            this.touch();
+        }
+        written
+    }
+
+    fn poll_flush(self: Pin<&mut Self>, cx: &mut Context<'_>) -> Poll<std::io::Result<()>> {
+        Pin::new(&mut self.get_mut().inner).poll_flush(cx)
+    }
+
+    fn poll_shutdown(self: Pin<&mut Self>, cx: &mut Context<'_>) -> Poll<std::io::Result<()>> {
+        Pin::new(&mut self.get_mut().inner).poll_shutdown(cx)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use mockforge_chaos::FaultTcpListener;
+    use tokio::io::{AsyncReadExt, AsyncWriteExt};
+    use tokio::net::{TcpListener, TcpStream};
+
+    /// Serve a router with the settings and return its address
+    async fn serve_with(settings: ConnectionSettings) -> SocketAddr {
+        let listener = TcpListener::bind("127.0.0.1:0").await.unwrap();
+        let addr = listener.local_addr().unwrap();
+        let app = axum::Router::new().route("/", axum::routing::get(|| async { "ok" }));
+        let make_service = app.into_make_service_with_connect_info::<FaultConnectInfo>();
+        tokio::spawn(serve(FaultTcpListener::new(listener), make_service, settings));
+        addr
+    }
+
+    /// Read until the server closes the connection, or fail after `limit`
+    async fn read_until_closed(stream: &mut TcpStream, limit: Duration) -> Vec<u8> {
+        let mut response = Vec::new();
+        tokio::time::timeout(limit, stream.read_to_end(&mut response))
+            .await
+            .expect("server should close the connection")
+            .ok();
+        response
+    }
+
+    #[tokio::test]
+    async fn slowloris_clients_are_disconnected() {
+        let addr = serve_with(ConnectionSettings {
+            header_read_timeout: Some(Duration::from_millis(200)),
+            ..Default::default()
+        })
+        .await;
+
+        let mut stream = TcpStream::connect(addr).await.unwrap();
+        stream.write_all(b"GET / HTTP/1.1\r\nHost: test\r\n").await.unwrap();
+        // Keep trickling header bytes; the head is never completed
+        let trickle = async {
+            loop {
+                tokio::time::sleep(Duration::from_millis(50)).await;
+                if stream.write_all(b"X-Slow: 1\r\n").await.is_err() {
+                    return;
+                }
+            }
+        };
+        tokio::time::timeout(Duration::from_secs(2), trickle)
+            .await
+            .expect("server should stop reading the trickled head");
+
+        // Complete requests are still served
+        let mut stream = TcpStream::connect(addr).await.unwrap();
+        stream
+            .write_all(b"GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+            .await
+            .unwrap();
+        let response = read_until_closed(&mut stream, Duration::from_secs(2)).await;
+        assert!(response.starts_with(b"HTTP/1.1 200 OK"));
+    }
+
+    #[tokio::test]
+    async fn oversized_heads_are_rejected() {
+        let addr = serve_with(ConnectionSettings {
+            max_header_bytes: Some(1024),
+            ..Default::default()
+        })
+        .await;
+
+        let mut stream = TcpStream::connect(addr).await.unwrap();
+        let big = "a".repeat(MIN_HEADER_BUFFER_BYTES * 2);
+        let request = format!("GET / HTTP/1.1\r\nHost: test\r\nX-Big: {}\r\n\r\n", big);
+        let _ = stream.write_all(request.as_bytes()).await;
+        let response = read_until_closed(&mut stream, Duration::from_secs(2)).await;
+        assert!(response.starts_with(b"HTTP/1.1 431"));
+    }
+
+    #[tokio::test]
+    async fn idle_connections_are_closed() {
+        let addr = serve_with(ConnectionSettings {
+            idle_timeout: Some(Duration::from_millis(200)),
+            ..Default::default()
+        })
+        .await;
+
+        let mut stream = TcpStream::connect(addr).await.unwrap();
+        stream.write_all(b"GET / HTTP/1.1\r\nHost: test\r\n\r\n").await.unwrap();
+        let response = read_until_closed(&mut stream, Duration::from_secs(2)).await;
+        assert!(response.starts_with(b"HTTP/1.1 200 OK"));
+        assert!(response.ends_with(b"ok"));
+    }
+
+    #[tokio::test]
+    async fn disabled_keep_alive_closes_after_one_response() {
+        let addr = serve_with(ConnectionSettings {
+            keep_alive: false,
+            ..Default::default()
+        })
+        .await;
+
+        let mut stream = TcpStream::connect(addr).await.unwrap();
+        stream.write_all(b"GET / HTTP/1.1\r\nHost: test\r\n\r\n").await.unwrap();
+        let response = read_until_closed(&mut stream, Duration::from_secs(2)).await;
+        assert!(response.starts_with(b"HTTP/1.1 200 OK"));
+    }
+
+    #[tokio::test]
+    async fn connections_beyond_the_limit_wait() {
+        let addr = serve_with(ConnectionSettings {
+            max_connections: Some(1),
+            ..Default::default()
+        })
+        .await;
+
+        let mut first = TcpStream::connect(addr).await.unwrap();
+        first.write_all(b"GET / HTTP/1.1\r\nHost: test\r\n\r\n").await.unwrap();
+        let mut buf = [0u8; 15];
+        first.read_exact(&mut buf).await.unwrap();
+        assert_eq!(&buf, b"HTTP/1.1 200 OK");
+
+        // The second connection is only served once the first closes
+        let mut second = TcpStream::connect(addr).await.unwrap();
+        second
+            .write_all(b"GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+            .await
+            .unwrap();
+        let mut waiting = [0u8; 1];
+        let served =
+            tokio::time::timeout(Duration::from_millis(300), second.read(&mut waiting)).await;
+        assert!(served.is_err(), "second connection was served while the first was open");
+
+        drop(first);
+        let response = read_until_closed(&mut second, Duration::from_secs(2)).await;
+        assert!(response.starts_with(b"HTTP/1.1 200 OK"));
+    }
+}
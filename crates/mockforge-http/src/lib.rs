@@ -200,6 +200,8 @@
 pub mod ai_handler;
 pub mod auth;
 pub mod chain_handlers;
+/// Header timeouts, size limits, and connection caps for the HTTP listener
+pub mod connection_settings;
 /// Cross-protocol consistency engine integration for HTTP
 pub mod consistency;
 /// Contract diff retrieval API
@@ -960,6 +962,7 @@ pub async fn build_router_with_multi_tenant(
     // Create WebSocket state and connect it to management state
     use std::sync::Arc;
     let ws_state = WsManagementState::new();
+    management_ws::publish_server_events(ws_state.tx.clone());
     let ws_broadcast = Arc::new(ws_state.tx.clone());
     let management_state = management_state.with_ws_broadcast(ws_broadcast);
 
@@ -988,6 +991,7 @@ pub async fn build_router_with_multi_tenant(
     #[cfg(not(feature = "smtp"))]
     let _ = smtp_registry;
     let management_state_for_fallback = management_state.clone();
+    management::publish_server_state(management_state.clone());
     app = app.nest("/__mockforge/api", management_router(management_state));
     // Serve any request that the rest of the router doesn't handle as a dynamic
     // mock lookup. Lets the Node/Rust SDK register stubs via the management
@@ -995,8 +999,13 @@ pub async fn build_router_with_multi_tenant(
     // no mock matches.
     app = app.fallback_service(
         axum::routing::any(management::dynamic_mock_fallback)
-            .with_state(management_state_for_fallback),
+            .with_state(management_state_for_fallback.clone()),
     );
+    // Dynamic mocks take precedence over the spec's routes
+    app = app.layer(from_fn_with_state(
+        management_state_for_fallback,
+        management::dynamic_mock_override,
+    ));
 
     // Add verification API endpoint
     app = app.merge(verification_router());
@@ -1515,8 +1524,6 @@ pub async fn serve_router_with_tls_notify_chaos(
     bound_port_tx: Option<tokio::sync::oneshot::Sender<u16>>,
     chaos_config: Option<Arc<RwLock<mockforge_chaos::ChaosConfig>>>,
 ) -> Result<(), Box<dyn std::error::Error + Send + Sync>> {
-    use std::net::SocketAddr;
-
     let addr = mockforge_core::wildcard_socket_addr(port);
 
     if let Some(ref tls) = tls_config {
@@ -1549,45 +1556,49 @@ pub async fn serve_router_with_tls_notify_chaos(
     let odata_app = tower::ServiceBuilder::new()
         .layer(mockforge_core::odata_rewrite::ODataRewriteLayer)
         .service(app);
+    // Every connection carries a ConnectionControl so mocks can answer with
+    // a connection-level fault or a raw response. The layer mirrors the
+    // FaultConnectInfo onto `ConnectInfo<SocketAddr>` so existing handlers
+    // keep working.
+    let app_with_connect_info = tower::ServiceBuilder::new()
+        .layer(axum::middleware::from_fn(expose_fault_connect_info))
+        .service(odata_app);
+    let make_svc = axum::ServiceExt::<Request<Body>>::into_make_service_with_connect_info::<
+        mockforge_chaos::FaultConnectInfo,
+    >(app_with_connect_info);
+    // Bump the accept counter once per accepted connection so the
+    // dashboard sampler can derive CPS.
+    let counted = counting_listener::CountingMakeService::new(make_svc);
+    // Header timeouts, size limits, and connection caps are applied by the
+    // accept loop and hyper, ahead of any handler
+    let settings = connection_settings::ConnectionSettings::from_env();
     if let Some(cfg) = chaos_config {
         info!("HTTP listener wrapped with chaos TCP listener (RST/FIN injection enabled)");
         let chaos_listener = mockforge_chaos::ChaosTcpListener::new(listener, cfg);
-        // Layer that copies ConnectInfo<ChaosClientAddr> → ConnectInfo<SocketAddr>
-        // so existing handlers using `ConnectInfo<SocketAddr>` keep working.
-        let app_with_addr_compat = tower::ServiceBuilder::new()
-            .layer(axum::middleware::from_fn(copy_chaos_addr_to_socketaddr))
-            .service(odata_app);
-        let make_svc = axum::ServiceExt::<Request<Body>>::into_make_service_with_connect_info::<
-            mockforge_chaos::ChaosClientAddr,
-        >(app_with_addr_compat);
-        // Bump the accept counter on each connection that gets through chaos.
-        let counted = counting_listener::CountingMakeService::new(make_svc);
-        axum::serve(chaos_listener, counted).await?;
+        let listener = mockforge_chaos::FaultTcpListener::new(chaos_listener);
+        connection_settings::serve(listener, counted, settings).await?;
     } else {
-        let make_svc = axum::ServiceExt::<Request<Body>>::into_make_service_with_connect_info::<
-            SocketAddr,
-        >(odata_app);
-        // Bump the accept counter once per accepted connection so the
-        // dashboard sampler can derive CPS.
-        let counted = counting_listener::CountingMakeService::new(make_svc);
-        axum::serve(listener, counted).await?;
+        let listener = mockforge_chaos::FaultTcpListener::new(listener);
+        connection_settings::serve(listener, counted, settings).await?;
     }
     Ok(())
 }
 
-/// Mirror `ConnectInfo<ChaosClientAddr>` (set by the chaos listener) onto
-/// `ConnectInfo<SocketAddr>` so handlers extracting `ConnectInfo<SocketAddr>`
-/// keep working when chaos TCP wrapping is enabled.
-async fn copy_chaos_addr_to_socketaddr(
+/// Mirror `ConnectInfo<FaultConnectInfo>` (set by the fault listener) onto
+/// `ConnectInfo<SocketAddr>`, and expose the connection's
+/// [`mockforge_chaos::ConnectionControl`] as a request extension.
+async fn expose_fault_connect_info(
     mut req: Request<Body>,
     next: axum::middleware::Next,
 ) -> axum::response::Response {
     use axum::extract::ConnectInfo;
-    if let Some(ConnectInfo(chaos_addr)) =
-        req.extensions().get::<ConnectInfo<mockforge_chaos::ChaosClientAddr>>().copied()
+    if let Some(ConnectInfo(info)) = req
+        .extensions()
+        .get::<ConnectInfo<mockforge_chaos::FaultConnectInfo>>()
+        .cloned()
     {
-        let sock: std::net::SocketAddr = *chaos_addr;
-        req.extensions_mut().insert(ConnectInfo(sock));
+        req.extensions_mut().insert(ConnectInfo(info.addr));
+        req.extensions_mut().insert(info.control);
     }
     next.run(req).await
 }
@@ -1705,6 +1716,8 @@ pub async fn build_router_with_chains(
         None, // mqtt_broker
         None, // amqp_broker
         None, // kafka_broker
+        None, // ftp_registry
+        None, // ws_scripts
         None, // traffic_shaper
         false,
         None, // health_manager
@@ -1768,6 +1781,8 @@ pub async fn build_router_with_chains_and_multi_tenant(
     mqtt_broker: Option<Arc<dyn std::any::Any + Send + Sync>>,
     amqp_broker: Option<Arc<dyn std::any::Any + Send + Sync>>,
     kafka_broker: Option<Arc<dyn std::any::Any + Send + Sync>>,
+    ftp_registry: Option<Arc<dyn std::any::Any + Send + Sync>>,
+    ws_scripts: Option<Arc<dyn std::any::Any + Send + Sync>>,
     traffic_shaper: Option<mockforge_core::traffic_shaping::TrafficShaper>,
     traffic_shaping_enabled: bool,
     health_manager: Option<Arc<HealthManager>>,
@@ -2176,6 +2191,7 @@ pub async fn build_router_with_chains_and_multi_tenant(
     // Create WebSocket state and connect it to management state
     use std::sync::Arc;
     let ws_state = WsManagementState::new();
+    management_ws::publish_server_events(ws_state.tx.clone());
     let ws_broadcast = Arc::new(ws_state.tx.clone());
     let management_state = management_state.with_ws_broadcast(ws_broadcast);
 
@@ -2278,13 +2294,63 @@ pub async fn build_router_with_chains_and_multi_tenant(
         let _ = kafka_broker;
         management_state
     };
+    #[cfg(feature = "ftp")]
+    let management_state = {
+        if let Some(ftp_reg) = ftp_registry {
+            match ftp_reg.downcast::<mockforge_ftp::FtpSpecRegistry>() {
+                Ok(ftp_reg) => management_state.with_ftp_registry(ftp_reg),
+                Err(e) => {
+                    error!(
+                        "Invalid FTP registry type passed to HTTP management state: {:?}",
+                        e.type_id()
+                    );
+                    management_state
+                }
+            }
+        } else {
+            management_state
+        }
+    };
+    #[cfg(not(feature = "ftp"))]
+    let management_state = {
+        let _ = ftp_registry;
+        management_state
+    };
+    #[cfg(feature = "ws")]
+    let management_state = {
+        if let Some(ws_scripts) = ws_scripts {
+            match ws_scripts.downcast::<mockforge_ws::WsScriptRegistry>() {
+                Ok(ws_scripts) => management_state.with_ws_scripts(ws_scripts),
+                Err(e) => {
+                    error!(
+                        "Invalid WebSocket script registry passed to HTTP management state: {:?}",
+                        e.type_id()
+                    );
+                    management_state
+                }
+            }
+        } else {
+            management_state
+        }
+    };
+    #[cfg(not(feature = "ws"))]
+    let management_state = {
+        let _ = ws_scripts;
+        management_state
+    };
     let management_state_for_fallback = management_state.clone();
+    management::publish_server_state(management_state.clone());
     app = app.nest("/__mockforge/api", management_router(management_state));
-    // Dynamic-mock fallback; see identical block earlier in this file.
+    // Dynamic-mock fallback and override; see identical block earlier in
+    // this file.
     app = app.fallback_service(
         axum::routing::any(management::dynamic_mock_fallback)
-            .with_state(management_state_for_fallback),
+            .with_state(management_state_for_fallback.clone()),
     );
+    app = app.layer(from_fn_with_state(
+        management_state_for_fallback,
+        management::dynamic_mock_override,
+    ));
 
     // Add verification API endpoint
     app = app.merge(verification_router());
@@ -0,0 +1,132 @@
+use serde::{Deserialize, Serialize};
+use std::collections::HashMap;
+use std::time::Duration;
+
+/// An HTTP request a mock sends after responding, such as the webhook an
+/// async API sends once an accepted job completes. The URL, header values,
+/// and body strings may use `{{request.path.<name>}}`,
+/// `{{request.header.<name>}}`, and `{{request.body.<field>}}` placeholders.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct MockCallback {
+    /// HTTP method, POST when empty
+    #[serde(default)]
+    pub method: String,
+    /// Absolute http or https URL to call
+    pub url: String,
+    /// Request headers
+    #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    pub headers: HashMap<String, String>,
+    /// JSON request body
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub body: Option<serde_json::Value>,
+    /// How long to wait after responding before calling, in milliseconds
+    #[serde(default)]
+    pub delay_ms: u64,
+}
+
+/// The parts of the matched request that callback and transformer
+/// placeholders refer to
+#[derive(Debug, Default)]
+pub(crate) struct CallbackRequest {
+    /// Captured path parameters
+    pub path_params: HashMap<String, String>,
+    /// Request headers, by lowercase name
+    pub headers: HashMap<String, String>,
+    /// The request body, if it is JSON
+    pub body: Option<serde_json::Value>,
+}
+
+impl CallbackRequest {
+    /// The value a placeholder name refers to: a path parameter, a header,
+    /// or a field of the body reached by a dotted path (array items by index)
+    fn lookup(&self, name: &str) -> Option<String> {
+        if let Some(param) = name.strip_prefix("request.path.") {
+            return self.path_params.get(param).cloned();
+        }
+        if let Some(header) = name.strip_prefix("request.header.") {
+            return self.headers.get(&header.to_ascii_lowercase()).cloned();
+        }
+        let field = name.strip_prefix("request.body.")?;
+        let value = field.split('.').try_fold(self.body.as_ref()?, |value, key| match value {
+            serde_json::Value::Array(items) => key.parse::<usize>().ok().and_then(|i| items.get(i)),
+            _ => value.get(key),
+        })?;
+        Some(match value {
+            serde_json::Value::String(s) => s.clone(),
+            other => other.to_string(),
+        })
+    }
+
+    /// Replace the placeholders in a string; unknown ones are left as is
+    pub(crate) fn render(&self, template: &str) -> String {
+        let mut rendered = String::with_capacity(template.len());
+        let mut rest = template;
+        while let Some(start) = rest.find("{{") {
+            let Some(len) = rest[start..].find("}}").map(|end| end + 2) else {
+                break;
+            };
+            let placeholder = &rest[start..start + len];
+            rendered.push_str(&rest[..start]);
+            match self.lookup(placeholder[2..len - 2].trim()) {
+                Some(value) => rendered.push_str(&value),
+                None => rendered.push_str(placeholder),
+            }
+            rest = &rest[start + len..];
+        }
+        rendered.push_str(rest);
+        rendered
+    }
+
+    /// Replace the placeholders in every string of a JSON value
+    pub(crate) fn render_value(&self, value: &mut serde_json::Value) {
+        match value {
+            serde_json::Value::String(s) => *s = self.render(s),
+            serde_json::Value::Array(items) => items.iter_mut().for_each(|i| self.render_value(i)),
+            serde_json::Value::Object(fields) => {
+                fields.values_mut().for_each(|field| self.render_value(field))
+            }
+            _ => {}
+        }
+    }
+}
+
+/// Send callbacks in the background, each after its delay. Failures are
+/// logged; they never affect the response already served.
+pub(crate) fn send_callbacks(mock_id: &str, callbacks: &[MockCallback], request: &CallbackRequest) {
+    for callback in callbacks {
+        let method = if callback.method.is_empty() {
+            "POST".to_string()
+        } else {
+            callback.method.to_ascii_uppercase()
+        };
+        let Ok(method) = reqwest::Method::from_bytes(method.as_bytes()) else {
+            tracing::warn!("Mock {} callback has an invalid method {}", mock_id, callback.method);
+            continue;
+        };
+        let url = request.render(&callback.url);
+        let headers: Vec<(String, String)> =
+            callback.headers.iter().map(|(k, v)| (k.clone(), request.render(v))).collect();
+        let body = callback.body.clone().map(|mut body| {
+            request.render_value(&mut body);
+            body
+        });
+        let delay = Duration::from_millis(callback.delay_ms);
+        let mock_id = mock_id.to_string();
+
+        tokio::spawn(async move {
+            if !delay.is_zero() {
+                tokio::time::sleep(delay).await;
+            }
+            let mut builder = reqwest::Client::new().request(method, &url);
+            for (name, value) in &headers {
+                builder = builder.header(name.as_str(), value.as_str());
+            }
+            if let Some(body) = &body {
+                builder = builder.json(body);
+            }
+            if let Err(e) = builder.send().await {
+                tracing::warn!("Mock {} callback to {} failed: {}", mock_id, url, e);
+            }
+        });
+    }
+}
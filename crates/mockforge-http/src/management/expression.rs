@@ -0,0 +1,395 @@
+//! Boolean match expressions over a request, as the SDKs send them for
+//! `When` stubs, e.g.
+//! `request.body.amount > 1000 && request.header['X-Region'] == 'EU'`.
+//!
+//! Expressions compare request fields with literals using `==`, `!=`, `<`,
+//! `<=`, `>`, `>=` and `=~` (regular expression match), combined with `&&`,
+//! `||`, `!` and parentheses. The fields are `request.method`,
+//! `request.path`, `request.body` with `.field` and `[index]` access into
+//! the JSON body, and `request.header`, `request.query` and `request.cookie`
+//! indexed by name. Fields that are missing evaluate to `null`.
+
+use regex::Regex;
+use serde_json::Value;
+use std::collections::HashMap;
+
+use super::{json_values_equal, request_cookie};
+
+/// The parts of a request an expression can refer to
+pub(super) struct ExprRequest<'a> {
+    pub method: &'a str,
+    pub path: &'a str,
+    pub headers: &'a HashMap<String, String>,
+    pub query_params: &'a HashMap<String, String>,
+    pub body: Option<&'a [u8]>,
+}
+
+/// Evaluate `expression` against the request. Malformed expressions never
+/// match.
+pub(super) fn evaluate(expression: &str, request: &ExprRequest<'_>) -> bool {
+    let result = lex(expression).and_then(|tokens| {
+        // A body that is not JSON is available to expressions as a string
+        let body = match request.body {
+            Some(bytes) => serde_json::from_slice(bytes)
+                .unwrap_or_else(|_| Value::String(String::from_utf8_lossy(bytes).into_owned())),
+            None => Value::Null,
+        };
+        let mut parser = Parser {
+            tokens,
+            pos: 0,
+            request,
+            body,
+        };
+        let value = parser.parse_or()?;
+        match parser.peek() {
+            None => Ok(truthy(&value)),
+            Some(token) => Err(format!("unexpected {token:?}")),
+        }
+    });
+    result.unwrap_or_else(|err| {
+        tracing::warn!("Invalid match expression {:?}: {}", expression, err);
+        false
+    })
+}
+
+#[derive(Debug, Clone, PartialEq)]
+enum Token {
+    Ident(String),
+    Number(f64),
+    Str(String),
+    Op(&'static str),
+}
+
+/// Operators, longest first so `<=` is not read as `<`
+const OPERATORS: [&str; 15] = [
+    "&&", "||", "==", "!=", "<=", ">=", "=~", "<", ">", "!", "(", ")", "[", "]", ".",
+];
+
+fn lex(expression: &str) -> Result<Vec<Token>, String> {
+    let chars: Vec<char> = expression.chars().collect();
+    let mut tokens = Vec::new();
+    let mut i = 0;
+    while i < chars.len() {
+        let c = chars[i];
+        let start = i;
+        if c.is_whitespace() {
+            i += 1;
+        } else if c.is_alphabetic() || c == '_' {
+            while i < chars.len() && (chars[i].is_alphanumeric() || matches!(chars[i], '_' | '-')) {
+                i += 1;
+            }
+            tokens.push(Token::Ident(chars[start..i].iter().collect()));
+        } else if c.is_ascii_digit()
+            || (c == '-' && chars.get(i + 1).is_some_and(char::is_ascii_digit))
+        {
+            i += 1;
+            while i < chars.len() && (chars[i].is_ascii_digit() || chars[i] == '.') {
+                i += 1;
+            }
+            let text: String = chars[start..i].iter().collect();
+            let number = text.parse().map_err(|_| format!("invalid number {text:?}"))?;
+            tokens.push(Token::Number(number));
+        } else if c == '\'' || c == '"' {
+            i += 1;
+            let mut text = String::new();
+            while i < chars.len() && chars[i] != c {
+                if chars[i] == '\\' && i + 1 < chars.len() {
+                    i += 1;
+                }
+                text.push(chars[i]);
+                i += 1;
+            }
+            if i >= chars.len() {
+                return Err(format!("unterminated string at {start}"));
+            }
+            i += 1;
+            tokens.push(Token::Str(text));
+        } else {
+            let rest: String = chars[i..].iter().collect();
+            let op = OPERATORS
+                .iter()
+                .find(|op| rest.starts_with(**op))
+                .ok_or_else(|| format!("unexpected character {c:?} at {start}"))?;
+            i += op.len();
+            tokens.push(Token::Op(*op));
+        }
+    }
+    Ok(tokens)
+}
+
+/// Evaluates the expression while parsing it by recursive descent
+struct Parser<'r> {
+    tokens: Vec<Token>,
+    pos: usize,
+    request: &'r ExprRequest<'r>,
+    body: Value,
+}
+
+impl Parser<'_> {
+    fn peek(&self) -> Option<&Token> {
+        self.tokens.get(self.pos)
+    }
+
+    fn next(&mut self) -> Option<Token> {
+        let token = self.tokens.get(self.pos).cloned();
+        if token.is_some() {
+            self.pos += 1;
+        }
+        token
+    }
+
+    /// Consume the next token if it is the operator `op`
+    fn accept(&mut self, op: &str) -> bool {
+        if matches!(self.peek(), Some(Token::Op(next)) if *next == op) {
+            self.pos += 1;
+            true
+        } else {
+            false
+        }
+    }
+
+    fn expect(&mut self, op: &str) -> Result<(), String> {
+        if self.accept(op) {
+            Ok(())
+        } else {
+            Err(format!("expected {op}, got {:?}", self.peek()))
+        }
+    }
+
+    /// and ('||' and)*
+    fn parse_or(&mut self) -> Result<Value, String> {
+        let mut value = self.parse_and()?;
+        while self.accept("||") {
+            let rhs = self.parse_and()?;
+            value = Value::Bool(truthy(&value) || truthy(&rhs));
+        }
+        Ok(value)
+    }
+
+    /// not ('&&' not)*
+    fn parse_and(&mut self) -> Result<Value, String> {
+        let mut value = self.parse_not()?;
+        while self.accept("&&") {
+            let rhs = self.parse_not()?;
+            value = Value::Bool(truthy(&value) && truthy(&rhs));
+        }
+        Ok(value)
+    }
+
+    /// '!' not | comparison
+    fn parse_not(&mut self) -> Result<Value, String> {
+        if self.accept("!") {
+            let value = self.parse_not()?;
+            return Ok(Value::Bool(!truthy(&value)));
+        }
+        self.parse_comparison()
+    }
+
+    /// operand (op operand)?
+    fn parse_comparison(&mut self) -> Result<Value, String> {
+        let lhs = self.parse_operand()?;
+        for op in ["==", "!=", "<=", ">=", "=~", "<", ">"] {
+            if self.accept(op) {
+                let rhs = self.parse_operand()?;
+                return compare(op, &lhs, &rhs).map(Value::Bool);
+            }
+        }
+        Ok(lhs)
+    }
+
+    /// A literal, a request field, or a parenthesized expression
+    fn parse_operand(&mut self) -> Result<Value, String> {
+        if self.accept("(") {
+            let value = self.parse_or()?;
+            self.expect(")")?;
+            return Ok(value);
+        }
+        match self.next() {
+            Some(Token::Number(n)) => {
+                Ok(serde_json::Number::from_f64(n).map_or(Value::Null, Value::Number))
+            }
+            Some(Token::Str(text)) => Ok(Value::String(text)),
+            Some(Token::Ident(ident)) => match ident.as_str() {
+                "true" => Ok(Value::Bool(true)),
+                "false" => Ok(Value::Bool(false)),
+                "null" => Ok(Value::Null),
+                "request" => self.parse_field(),
+                _ => Err(format!("unknown identifier {ident:?}")),
+            },
+            other => Err(format!("expected a value, got {other:?}")),
+        }
+    }
+
+    /// The accessors following `request`
+    fn parse_field(&mut self) -> Result<Value, String> {
+        self.expect(".")?;
+        let root = match self.next() {
+            Some(Token::Ident(root)) => root,
+            other => return Err(format!("expected a request field, got {other:?}")),
+        };
+        let request = self.request;
+        match root.as_str() {
+            "method" => Ok(Value::String(request.method.to_string())),
+            "path" => Ok(Value::String(request.path.to_string())),
+            "header" | "query" | "cookie" => {
+                self.expect("[")?;
+                let name = match self.next() {
+                    Some(Token::Str(name)) => name,
+                    other => return Err(format!("expected a quoted name, got {other:?}")),
+                };
+                self.expect("]")?;
+                let value = match root.as_str() {
+                    "header" => request
+                        .headers
+                        .iter()
+                        .find(|(k, _)| k.eq_ignore_ascii_case(&name))
+                        .map(|(_, v)| v.clone()),
+                    "query" => request.query_params.get(&name).cloned(),
+                    _ => request_cookie(request.headers, &name),
+                };
+                Ok(value.map_or(Value::Null, Value::String))
+            }
+            "body" => {
+                let mut value = self.body.clone();
+                loop {
+                    if self.accept(".") {
+                        value = match self.next() {
+                            Some(Token::Ident(name)) => value.get(name.as_str()).cloned(),
+                            other => return Err(format!("expected a field name, got {other:?}")),
+                        }
+                        .unwrap_or(Value::Null);
+                    } else if self.accept("[") {
+                        value = match self.next() {
+                            Some(Token::Str(key)) => value.get(key.as_str()).cloned(),
+                            Some(Token::Number(n)) if n >= 0.0 && n.fract() == 0.0 => {
+                                value.get(n as usize).cloned()
+                            }
+                            other => {
+                                return Err(format!(
+                                    "expected an index or quoted key, got {other:?}"
+                                ))
+                            }
+                        }
+                        .unwrap_or(Value::Null);
+                        self.expect("]")?;
+                    } else {
+                        return Ok(value);
+                    }
+                }
+            }
+            _ => Err(format!("unknown request field {root:?}")),
+        }
+    }
+}
+
+/// Whether a value counts as true: anything but `false` and `null`
+fn truthy(value: &Value) -> bool {
+    match value {
+        Value::Bool(b) => *b,
+        Value::Null => false,
+        _ => true,
+    }
+}
+
+/// A number, or a string holding one, so query parameters and headers
+/// compare with numeric literals
+fn as_number(value: &Value) -> Option<f64> {
+    match value {
+        Value::Number(n) => n.as_f64(),
+        Value::String(s) => s.trim().parse().ok(),
+        _ => None,
+    }
+}
+
+fn compare(op: &str, lhs: &Value, rhs: &Value) -> Result<bool, String> {
+    let equal = || match (lhs, rhs) {
+        (Value::String(_), Value::Number(_)) | (Value::Number(_), Value::String(_)) => {
+            as_number(lhs).is_some() && as_number(lhs) == as_number(rhs)
+        }
+        _ => json_values_equal(lhs, rhs),
+    };
+    Ok(match op {
+        "==" => equal(),
+        "!=" => !equal(),
+        "=~" => {
+            let pattern = rhs.as_str().ok_or("=~ needs a string pattern")?;
+            let re = Regex::new(pattern).map_err(|err| err.to_string())?;
+            match lhs {
+                Value::String(text) => re.is_match(text),
+                Value::Null => false,
+                other => re.is_match(&other.to_string()),
+            }
+        }
+        _ => {
+            let ordering = match (as_number(lhs), as_number(rhs)) {
+                (Some(a), Some(b)) => a.partial_cmp(&b),
+                _ => match (lhs, rhs) {
+                    (Value::String(a), Value::String(b)) => Some(a.cmp(b)),
+                    _ => None,
+                },
+            };
+            ordering.is_some_and(|ordering| match op {
+                "<" => ordering.is_lt(),
+                "<=" => ordering.is_le(),
+                ">" => ordering.is_gt(),
+                _ => ordering.is_ge(),
+            })
+        }
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn eval(expression: &str, body: &str) -> bool {
+        let headers = HashMap::from([
+            ("X-Region".to_string(), "EU".to_string()),
+            ("Cookie".to_string(), "theme=dark; session=abc".to_string()),
+        ]);
+        let query_params = HashMap::from([("page".to_string(), "2".to_string())]);
+        let request = ExprRequest {
+            method: "POST",
+            path: "/payments",
+            headers: &headers,
+            query_params: &query_params,
+            body: Some(body.as_bytes()),
+        };
+        evaluate(expression, &request)
+    }
+
+    #[test]
+    fn test_evaluate_request_fields() {
+        let body = r#"{"amount": 1500, "items": [{"sku": "A1"}], "first name": "Ada"}"#;
+
+        assert!(eval("request.body.amount > 1000 && request.header['x-region'] == 'EU'", body));
+        assert!(!eval("request.body.amount <= 1000", body));
+        assert!(eval(
+            r#"request.query["page"] =~ '^[0-9]+$' && request.query['page'] == 2"#,
+            body
+        ));
+        assert!(eval("!(request.body.items[0].sku == null)", body));
+        assert!(eval("request.body.items[1].sku == null", body));
+        assert!(eval(r#"request.body["first name"] != -1.5"#, body));
+        assert!(eval("request.cookie['session'] == 'abc' || request.path == '/health'", body));
+        assert!(eval("request.method == \"GET\" || (request.path == '/payments')", body));
+        assert!(eval("request.body == 'plain'", "plain"));
+    }
+
+    #[test]
+    fn test_evaluate_malformed_expressions_do_not_match() {
+        for expression in [
+            "request.body.amount >",
+            "request.header == 'EU'",
+            "request.bdy.amount > 1",
+            "amount > 1000",
+            "(request.path == '/payments'",
+            "request.path == 'unterminated",
+            "request.path == '/payments' request.method == 'POST'",
+            "request.path # 1",
+            "request.path =~ '('",
+        ] {
+            assert!(!eval(expression, "{}"), "{expression} should not match");
+        }
+    }
+}
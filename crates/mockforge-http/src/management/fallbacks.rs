@@ -0,0 +1,240 @@
+use axum::{
+    extract::{Query, State},
+    http::{HeaderName, HeaderValue, StatusCode},
+    response::{IntoResponse, Json, Response},
+};
+use mockforge_openapi::{OpenApiRoute, OpenApiSpec};
+use serde::{Deserialize, Serialize};
+
+use super::mock_proxy::{MockProxy, ProxiedRequest};
+use super::{path_in_base, path_matches_pattern, ManagementState, MockConfig, MockResponse};
+
+/// What the server does with unmatched requests under a route prefix
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct MockFallback {
+    /// Route prefix the fallback applies to; `/` covers every route
+    pub prefix: String,
+    /// How unmatched requests are answered
+    #[serde(flatten)]
+    pub strategy: FallbackStrategy,
+}
+
+/// How a fallback answers unmatched requests
+#[derive(Debug, Clone, Serialize, Deserialize)]
+#[serde(tag = "strategy", rename_all = "snake_case")]
+pub enum FallbackStrategy {
+    /// 404 Not Found, even in shadow mode
+    NotFound,
+    /// A fixed response, e.g. the API's error envelope
+    Respond {
+        /// The response to serve
+        response: FallbackResponse,
+    },
+    /// Forward to a real backend through the mock proxy client
+    Proxy {
+        /// Base URL requests are forwarded to; the request path and query
+        /// are appended
+        upstream_url: String,
+        /// Save each upstream response as a mock for the request's method
+        /// and path, so later requests are served without the backend
+        #[serde(default)]
+        record: bool,
+    },
+    /// The response the loaded OpenAPI spec generates for the request's
+    /// operation, or 404 when the spec has no such operation
+    FromSpec,
+    /// The request itself, described as JSON
+    Echo,
+}
+
+/// Response served by a `respond` fallback
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct FallbackResponse {
+    /// Status code, 200 when omitted
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub status_code: Option<u16>,
+    /// Latency to inject in milliseconds
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub latency_ms: Option<u64>,
+    /// Body and headers
+    pub response: MockResponse,
+}
+
+/// The fallback for path: the one with the longest prefix the path is under
+pub(crate) fn find_fallback<'a>(
+    fallbacks: &'a [MockFallback],
+    path: &str,
+) -> Option<&'a MockFallback> {
+    fallbacks
+        .iter()
+        .filter(|f| path_in_base(path, &f.prefix))
+        .max_by_key(|f| f.prefix.trim_end_matches('/').len())
+}
+
+/// Answer an unmatched request with a fallback
+pub(crate) async fn fallback_response(
+    state: &ManagementState,
+    strategy: &FallbackStrategy,
+    request: ProxiedRequest<'_>,
+) -> Response {
+    match strategy {
+        FallbackStrategy::NotFound => StatusCode::NOT_FOUND.into_response(),
+        FallbackStrategy::Respond { response } => {
+            let mock = MockConfig {
+                status_code: response.status_code,
+                latency_ms: response.latency_ms,
+                response: response.response.clone(),
+                ..Default::default()
+            };
+            super::mock_response(&mock).await
+        }
+        FallbackStrategy::Proxy {
+            upstream_url,
+            record,
+        } => {
+            let mock = MockConfig {
+                id: "fallback".to_string(),
+                ..Default::default()
+            };
+            let proxy = MockProxy {
+                upstream_url: upstream_url.clone(),
+                record: *record,
+            };
+            super::mock_proxy::forward(state, &mock, &proxy, request).await
+        }
+        FallbackStrategy::FromSpec => match &state.spec {
+            Some(spec) => {
+                // Spec paths are relative to the server's base path
+                let path = state
+                    .base_path
+                    .as_deref()
+                    .and_then(|base| request.path.strip_prefix(base.trim_end_matches('/')))
+                    .filter(|path| path.starts_with('/'))
+                    .unwrap_or(request.path);
+                spec_response(spec, request.method, path)
+            }
+            None => StatusCode::NOT_FOUND.into_response(),
+        },
+        FallbackStrategy::Echo => echo_response(&request),
+    }
+}
+
+/// The response the spec generates for an operation: its preferred status,
+/// schema-generated body, and documented headers
+fn spec_response(spec: &std::sync::Arc<OpenApiSpec>, method: &str, path: &str) -> Response {
+    // Prefer the template with the most literal segments, so `/users/me`
+    // wins over `/users/{id}`
+    let template = spec
+        .spec
+        .paths
+        .paths
+        .keys()
+        .filter(|template| path_matches_pattern(template, path))
+        .max_by_key(|template| template.split('/').filter(|s| !s.starts_with('{')).count());
+    let operation = template.and_then(|template| {
+        let operation = spec.operations_for_path(template).remove(&method.to_uppercase())?;
+        Some((template, operation))
+    });
+    let Some((template, operation)) = operation else {
+        return StatusCode::NOT_FOUND.into_response();
+    };
+
+    let route = OpenApiRoute::from_operation(method, template.clone(), &operation, spec.clone());
+    let (status, body) = route.mock_response_with_status();
+    let mut response = Response::builder()
+        .status(StatusCode::from_u16(status).unwrap_or(StatusCode::OK))
+        .header(axum::http::header::CONTENT_TYPE, "application/json");
+    for (name, value) in route.mock_response_headers_for_status(status) {
+        if let (Ok(name), Ok(value)) =
+            (HeaderName::from_bytes(name.as_bytes()), HeaderValue::from_str(&value))
+        {
+            response = response.header(name, value);
+        }
+    }
+    response
+        .body(axum::body::Body::from(body.to_string()))
+        .unwrap_or_else(|_| StatusCode::INTERNAL_SERVER_ERROR.into_response())
+}
+
+/// The request as JSON: its method, path, query, headers, and body, which
+/// is inlined when it is JSON and a string otherwise
+fn echo_response(request: &ProxiedRequest<'_>) -> Response {
+    let body = if request.body.is_empty() {
+        serde_json::Value::Null
+    } else {
+        serde_json::from_slice(request.body).unwrap_or_else(|_| {
+            serde_json::Value::String(String::from_utf8_lossy(request.body).into_owned())
+        })
+    };
+    Json(serde_json::json!({
+        "method": request.method,
+        "path": request.path,
+        "query": request.query.unwrap_or_default(),
+        "headers": request.headers,
+        "body": body,
+    }))
+    .into_response()
+}
+
+/// List the configured fallbacks
+pub(crate) async fn list_fallbacks(
+    State(state): State<ManagementState>,
+) -> Json<Vec<MockFallback>> {
+    Json(state.fallbacks.read().await.clone())
+}
+
+/// Set the fallback for a prefix, replacing any fallback it had
+pub(crate) async fn set_fallback(
+    State(state): State<ManagementState>,
+    Json(fallback): Json<MockFallback>,
+) -> impl IntoResponse {
+    let invalid = if !fallback.prefix.starts_with('/') {
+        Some("fallback prefix must start with /")
+    } else {
+        match &fallback.strategy {
+            FallbackStrategy::Proxy { upstream_url, .. }
+                if !upstream_url.starts_with("http://")
+                    && !upstream_url.starts_with("https://") =>
+            {
+                Some("proxy fallback upstream_url must be an absolute http or https URL")
+            }
+            FallbackStrategy::FromSpec if state.spec.is_none() => {
+                Some("from_spec fallback requires an OpenAPI spec to be loaded")
+            }
+            _ => None,
+        }
+    };
+    if let Some(error) = invalid {
+        return (StatusCode::BAD_REQUEST, Json(serde_json::json!({ "error": error })))
+            .into_response();
+    }
+    let mut fallbacks = state.fallbacks.write().await;
+    let prefix = fallback.prefix.trim_end_matches('/');
+    fallbacks.retain(|f| f.prefix.trim_end_matches('/') != prefix);
+    fallbacks.push(fallback.clone());
+    Json(fallback).into_response()
+}
+
+/// Query of `DELETE /fallbacks`
+#[derive(Debug, Default, Deserialize)]
+pub(crate) struct ClearFallbacksQuery {
+    /// Only remove the fallback of this prefix
+    pub prefix: Option<String>,
+}
+
+/// Remove the fallback of a prefix, or every fallback when no prefix is
+/// given, restoring the default handling of unmatched requests
+pub(crate) async fn clear_fallbacks(
+    State(state): State<ManagementState>,
+    Query(query): Query<ClearFallbacksQuery>,
+) -> StatusCode {
+    let mut fallbacks = state.fallbacks.write().await;
+    match query.prefix {
+        Some(prefix) => {
+            let prefix = prefix.trim_end_matches('/');
+            fallbacks.retain(|f| f.prefix.trim_end_matches('/') != prefix);
+        }
+        None => fallbacks.clear(),
+    }
+    StatusCode::NO_CONTENT
+}
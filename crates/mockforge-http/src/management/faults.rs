@@ -0,0 +1,160 @@
+use axum::body::{Body, Bytes};
+use axum::http::StatusCode;
+use axum::response::{IntoResponse, Response};
+use axum::Json;
+use base64::Engine;
+use futures::stream;
+use mockforge_chaos::{ConnectionControl, ConnectionFault};
+use serde::{Deserialize, Serialize};
+
+use super::MockConfig;
+
+/// Rate a slow trickle writes the body at, in bytes per second
+const SLOW_TRICKLE_BYTES_PER_SECOND: u64 = 10;
+
+/// A network-level failure a mock simulates in place of a well-formed
+/// response
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(tag = "type", rename_all = "snake_case")]
+pub enum MockFault {
+    /// Reset the connection before writing anything
+    ConnectionReset,
+    /// Close the connection without writing a response
+    EmptyResponse,
+    /// Write the status and headers followed by an invalid chunked-encoding
+    /// frame
+    MalformedChunk,
+    /// Write the response a byte at a time
+    SlowTrickle,
+}
+
+/// Serve `fault` for a mock. Connection faults are injected through the
+/// request's `control`; without one, as on TLS and HTTP/2 connections, the
+/// response body fails instead and hyper aborts the connection.
+pub(crate) async fn fault_response(
+    mock: &MockConfig,
+    fault: MockFault,
+    control: Option<&ConnectionControl>,
+) -> Response {
+    if fault == MockFault::SlowTrickle {
+        let mut mock = mock.clone();
+        mock.response.throttle_bytes_per_second = Some(SLOW_TRICKLE_BYTES_PER_SECOND);
+        return super::mock_response(&mock).await;
+    }
+
+    super::sleep_latency(mock).await;
+    let connection_fault = match fault {
+        MockFault::ConnectionReset => ConnectionFault::Reset,
+        MockFault::EmptyResponse => ConnectionFault::Close,
+        _ => ConnectionFault::Raw(malformed_chunk(mock)),
+    };
+    match control {
+        Some(control) => {
+            control.inject(connection_fault);
+            // Never written: the fault replaces it on the wire
+            StatusCode::OK.into_response()
+        }
+        None => {
+            let failed = stream::once(async {
+                Err::<Bytes, _>(std::io::Error::new(
+                    std::io::ErrorKind::ConnectionAborted,
+                    "mock fault",
+                ))
+            });
+            Response::new(Body::from_stream(failed))
+        }
+    }
+}
+
+/// Write a mock's `raw_base64` response to the connection verbatim through
+/// the request's `control`. Without one there is no connection to write to,
+/// and the request fails with a 501.
+pub(crate) async fn raw_response(
+    mock: &MockConfig,
+    control: Option<&ConnectionControl>,
+) -> Response {
+    let encoded = mock.response.raw_base64.as_deref().unwrap_or_default();
+    let raw = match base64::engine::general_purpose::STANDARD.decode(encoded) {
+        Ok(raw) => raw,
+        Err(e) => {
+            tracing::warn!("Mock {} raw response could not be served: {}", mock.id, e);
+            let error = format!("invalid raw_base64: {}", e);
+            return (
+                StatusCode::INTERNAL_SERVER_ERROR,
+                Json(serde_json::json!({ "error": error })),
+            )
+                .into_response();
+        }
+    };
+    let Some(control) = control else {
+        let error = "raw responses can only be served on plain HTTP/1 connections";
+        return (StatusCode::NOT_IMPLEMENTED, Json(serde_json::json!({ "error": error })))
+            .into_response();
+    };
+
+    super::sleep_latency(mock).await;
+    control.inject(ConnectionFault::Raw(Bytes::from(raw)));
+    // Never written: the raw response replaces it on the wire
+    StatusCode::OK.into_response()
+}
+
+/// A chunked response whose first chunk has an invalid size line
+fn malformed_chunk(mock: &MockConfig) -> Bytes {
+    let status = mock
+        .status_code
+        .and_then(|c| StatusCode::from_u16(c).ok())
+        .unwrap_or(StatusCode::OK);
+    let mut raw =
+        format!("HTTP/1.1 {} {}\r\n", status.as_u16(), status.canonical_reason().unwrap_or(""));
+    let mut has_content_type = false;
+    for (name, value) in mock.response.headers.iter().flatten() {
+        if name.eq_ignore_ascii_case("content-length")
+            || name.eq_ignore_ascii_case("transfer-encoding")
+        {
+            continue;
+        }
+        has_content_type |= name.eq_ignore_ascii_case("content-type");
+        raw.push_str(&format!("{}: {}\r\n", name, value));
+    }
+    if !has_content_type {
+        raw.push_str("content-type: application/json\r\n");
+    }
+    raw.push_str("transfer-encoding: chunked\r\n\r\n");
+    raw.push_str("zz\r\n{\"partial\r\n");
+    Bytes::from(raw)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_mock_fault_wire_format() {
+        let fault: MockFault = serde_json::from_value(serde_json::json!({
+            "type": "connection_reset"
+        }))
+        .unwrap();
+        assert_eq!(fault, MockFault::ConnectionReset);
+        assert_eq!(
+            serde_json::to_value(MockFault::SlowTrickle).unwrap(),
+            serde_json::json!({ "type": "slow_trickle" })
+        );
+    }
+
+    #[test]
+    fn test_malformed_chunk_keeps_status_and_headers() {
+        let mock = MockConfig {
+            status_code: Some(503),
+            response: super::super::MockResponse {
+                headers: Some([("X-Trace".to_string(), "1".to_string())].into()),
+                ..Default::default()
+            },
+            ..Default::default()
+        };
+        let raw = malformed_chunk(&mock);
+        let raw = std::str::from_utf8(&raw).unwrap();
+        assert!(raw.starts_with("HTTP/1.1 503 Service Unavailable\r\n"));
+        assert!(raw.contains("X-Trace: 1\r\n"));
+        assert!(raw.contains("transfer-encoding: chunked\r\n\r\nzz\r\n"));
+    }
+}
@@ -187,6 +187,20 @@ pub(crate) async fn get_config(State(state): State<ManagementState>) -> Json<sup
     })
 }
 
+/// Request to reseed template generated values
+#[derive(Debug, Deserialize)]
+pub struct RandomSeedRequest {
+    /// The new seed
+    pub seed: i64,
+}
+
+/// Reseed the random source behind `{{uuid}}`, `{{rand.*}}`, and
+/// `{{faker.*}}` tokens, so the values generated from now on repeat
+pub(crate) async fn set_random_seed(Json(request): Json<RandomSeedRequest>) -> StatusCode {
+    mockforge_core::templating::set_random_seed(request.seed as u64);
+    StatusCode::NO_CONTENT
+}
+
 /// Serve the loaded OpenAPI spec as JSON
 pub(crate) async fn get_openapi_spec(
     State(state): State<ManagementState>,
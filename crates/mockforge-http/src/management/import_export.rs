@@ -47,8 +47,19 @@ pub(crate) async fn import_mocks(
     State(state): State<ManagementState>,
     Json(mocks): Json<Vec<MockConfig>>,
 ) -> impl IntoResponse {
+    if let Some((mock, e)) =
+        mocks.iter().find_map(|mock| mock.compile_path().err().map(|e| (mock, e)))
+    {
+        return (
+            StatusCode::BAD_REQUEST,
+            Json(serde_json::json!({
+                "error": format!("mock {} has an invalid path regex: {}", mock.id, e)
+            })),
+        )
+            .into_response();
+    }
     let mut current_mocks = state.mocks.write().await;
     current_mocks.clear();
     current_mocks.extend(mocks);
-    Json(serde_json::json!({ "status": "imported", "count": current_mocks.len() }))
+    Json(serde_json::json!({ "status": "imported", "count": current_mocks.len() })).into_response()
 }
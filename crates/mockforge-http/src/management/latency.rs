@@ -0,0 +1,119 @@
+use rand::Rng;
+use serde::{Deserialize, Serialize};
+use std::time::Duration;
+
+/// Simulated latency drawn from a distribution for each response. Values are
+/// in milliseconds; omitted parameters are zero.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+#[serde(tag = "distribution", rename_all = "lowercase")]
+pub enum MockLatency {
+    /// The same delay every time
+    Fixed {
+        /// The delay
+        #[serde(default)]
+        fixed_ms: f64,
+    },
+    /// A delay picked uniformly between a minimum and maximum
+    Uniform {
+        /// Shortest delay
+        #[serde(default)]
+        min_ms: f64,
+        /// Longest delay
+        #[serde(default)]
+        max_ms: f64,
+    },
+    /// A normally distributed delay, clamped at zero
+    Normal {
+        /// Mean delay
+        #[serde(default)]
+        mean_ms: f64,
+        /// Standard deviation of the delay
+        #[serde(default)]
+        std_dev_ms: f64,
+    },
+    /// A log-normally distributed delay, with the long right tail typical of
+    /// real services
+    #[serde(rename = "lognormal")]
+    LogNormal {
+        /// Mean of the delay itself, not of its logarithm
+        #[serde(default)]
+        mean_ms: f64,
+        /// Standard deviation of the delay itself
+        #[serde(default)]
+        std_dev_ms: f64,
+    },
+    /// Delays whose P50, P95, and P99 match a table
+    Percentiles {
+        /// Median delay
+        #[serde(default)]
+        p50_ms: f64,
+        /// 95th percentile delay
+        #[serde(default)]
+        p95_ms: f64,
+        /// 99th percentile delay
+        #[serde(default)]
+        p99_ms: f64,
+    },
+}
+
+impl MockLatency {
+    /// Draw one delay from the distribution
+    pub fn sample(&self) -> Duration {
+        let mut rng = rand::rng();
+        let ms = match *self {
+            Self::Fixed { fixed_ms } => fixed_ms,
+            Self::Uniform { min_ms, max_ms } => min_ms + rng.random::<f64>() * (max_ms - min_ms),
+            Self::Normal {
+                mean_ms,
+                std_dev_ms,
+            } => mean_ms + standard_normal(&mut rng) * std_dev_ms,
+            Self::LogNormal {
+                mean_ms,
+                std_dev_ms,
+            } => {
+                // Convert the delay's mean and deviation into the parameters
+                // of its logarithm
+                let sigma2 = (1.0 + (std_dev_ms * std_dev_ms) / (mean_ms * mean_ms)).ln();
+                let mu = mean_ms.ln() - sigma2 / 2.0;
+                (mu + standard_normal(&mut rng) * sigma2.sqrt()).exp()
+            }
+            Self::Percentiles {
+                p50_ms,
+                p95_ms,
+                p99_ms,
+            } => percentile(rng.random(), p50_ms, p95_ms, p99_ms),
+        };
+        if ms.is_finite() && ms > 0.0 {
+            Duration::from_secs_f64(ms / 1000.0)
+        } else {
+            Duration::ZERO
+        }
+    }
+}
+
+/// A standard normal sample, by the Box-Muller transform
+fn standard_normal(rng: &mut impl Rng) -> f64 {
+    let u1: f64 = 1.0 - rng.random::<f64>();
+    let u2: f64 = rng.random();
+    (-2.0 * u1.ln()).sqrt() * (2.0 * std::f64::consts::PI * u2).cos()
+}
+
+/// Interpolate the inverse CDF of a percentile table linearly through
+/// (0, 0), (0.5, P50), (0.95, P95), (0.99, P99), and (1, P99 plus the
+/// P95-P99 spread)
+fn percentile(q: f64, p50_ms: f64, p95_ms: f64, p99_ms: f64) -> f64 {
+    let points = [
+        (0.0, 0.0),
+        (0.5, p50_ms),
+        (0.95, p95_ms),
+        (0.99, p99_ms),
+        (1.0, p99_ms + (p99_ms - p95_ms)),
+    ];
+    for pair in points.windows(2) {
+        let ((q0, v0), (q1, v1)) = (pair[0], pair[1]);
+        if q <= q1 {
+            return v0 + (q - q0) / (q1 - q0) * (v1 - v0);
+        }
+    }
+    points[points.len() - 1].1
+}
@@ -0,0 +1,153 @@
+use axum::{
+    body::{Body, Bytes},
+    http::{HeaderName, HeaderValue, StatusCode},
+    response::{IntoResponse, Response},
+};
+use base64::Engine;
+use serde::{Deserialize, Serialize};
+use std::collections::HashMap;
+
+use super::{ManagementState, MockConfig, MockResponse};
+
+/// Headers that describe a single connection or the message framing, which
+/// are neither forwarded nor recorded
+const HOP_BY_HOP_HEADERS: &[&str] = &[
+    "connection",
+    "content-length",
+    "host",
+    "keep-alive",
+    "proxy-connection",
+    "te",
+    "trailer",
+    "transfer-encoding",
+    "upgrade",
+];
+
+/// Forwards a mock's requests to a real backend instead of serving the
+/// mock's response
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct MockProxy {
+    /// Base URL requests are forwarded to; the request path and query are
+    /// appended
+    pub upstream_url: String,
+    /// Save each upstream response as a new mock for the request's method
+    /// and path, so the next such request is served without the backend
+    #[serde(default)]
+    pub record: bool,
+}
+
+/// The request being forwarded
+pub(crate) struct ProxiedRequest<'a> {
+    pub method: &'a str,
+    pub path: &'a str,
+    pub query: Option<&'a str>,
+    pub headers: &'a HashMap<String, String>,
+    pub body: &'a Bytes,
+}
+
+/// Forward a request to the mock's upstream and relay the response, or
+/// answer 502 Bad Gateway when the upstream cannot be reached
+pub(crate) async fn forward(
+    state: &ManagementState,
+    mock: &MockConfig,
+    proxy: &MockProxy,
+    request: ProxiedRequest<'_>,
+) -> Response {
+    let mut url = format!("{}{}", proxy.upstream_url.trim_end_matches('/'), request.path);
+    if let Some(query) = request.query {
+        url.push('?');
+        url.push_str(query);
+    }
+    let Ok(method) = reqwest::Method::from_bytes(request.method.as_bytes()) else {
+        return bad_gateway(format!("cannot forward method {}", request.method));
+    };
+
+    let mut upstream = reqwest::Client::new().request(method, &url);
+    for (name, value) in request.headers {
+        if !is_hop_by_hop(name) {
+            upstream = upstream.header(name.as_str(), value.as_str());
+        }
+    }
+    let upstream = match upstream.body(request.body.clone()).send().await {
+        Ok(upstream) => upstream,
+        Err(e) => return bad_gateway(format!("failed to reach upstream {}: {}", url, e)),
+    };
+
+    let status = upstream.status().as_u16();
+    let headers: Vec<(String, String)> = upstream
+        .headers()
+        .iter()
+        .filter(|(name, _)| !is_hop_by_hop(name.as_str()))
+        .filter_map(|(name, value)| Some((name.to_string(), value.to_str().ok()?.to_string())))
+        .collect();
+    let body = match upstream.bytes().await {
+        Ok(body) => body,
+        Err(e) => return bad_gateway(format!("failed to read upstream response: {}", e)),
+    };
+
+    if proxy.record {
+        record(state, mock, &request, status, &headers, &body).await;
+    }
+
+    let mut response =
+        Response::builder().status(StatusCode::from_u16(status).unwrap_or(StatusCode::BAD_GATEWAY));
+    for (name, value) in &headers {
+        if let (Ok(name), Ok(value)) =
+            (HeaderName::from_bytes(name.as_bytes()), HeaderValue::from_str(value))
+        {
+            response = response.header(name, value);
+        }
+    }
+    response
+        .body(Body::from(body))
+        .unwrap_or_else(|_| StatusCode::BAD_GATEWAY.into_response())
+}
+
+/// Save an upstream response as a mock for the request's method and path,
+/// ranked above the proxying mock so it serves later requests
+async fn record(
+    state: &ManagementState,
+    proxying: &MockConfig,
+    request: &ProxiedRequest<'_>,
+    status: u16,
+    headers: &[(String, String)],
+    body: &Bytes,
+) {
+    let is_json = headers
+        .iter()
+        .any(|(name, value)| name.eq_ignore_ascii_case("content-type") && value.contains("json"));
+    let mut response = MockResponse {
+        headers: Some(headers.iter().cloned().collect()),
+        ..Default::default()
+    };
+    match serde_json::from_slice(body).ok().filter(|_| is_json) {
+        Some(json) => response.body = json,
+        None => response.body_base64 = Some(base64::engine::general_purpose::STANDARD.encode(body)),
+    }
+    let mock = MockConfig {
+        id: uuid::Uuid::new_v4().to_string(),
+        name: format!("Recorded {} {}", request.method, request.path),
+        method: request.method.to_string(),
+        path: request.path.to_string(),
+        response,
+        enabled: true,
+        status_code: Some(status),
+        priority: Some(proxying.priority.unwrap_or(0).saturating_add(1)),
+        ..Default::default()
+    };
+
+    tracing::info!("Recorded mock {} {} {} from {}", mock.method, mock.path, mock.id, proxying.id);
+    state.mocks.write().await.push(mock.clone());
+    if let Some(tx) = &state.ws_broadcast {
+        let _ = tx.send(crate::management_ws::MockEvent::mock_created(mock));
+    }
+}
+
+fn is_hop_by_hop(name: &str) -> bool {
+    HOP_BY_HOP_HEADERS.iter().any(|h| name.eq_ignore_ascii_case(h))
+}
+
+fn bad_gateway(error: String) -> Response {
+    tracing::warn!("Mock proxy failed: {}", error);
+    (StatusCode::BAD_GATEWAY, axum::Json(serde_json::json!({ "error": error }))).into_response()
+}
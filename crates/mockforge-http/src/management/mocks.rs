@@ -48,6 +48,10 @@ pub(crate) async fn create_mock(
     if mocks.iter().any(|m| m.id == mock.id) {
         return Err(StatusCode::CONFLICT);
     }
+    if let Err(e) = mock.compile_path() {
+        warn!("Rejecting mock {} with an invalid path regex: {}", mock.id, e);
+        return Err(StatusCode::BAD_REQUEST);
+    }
 
     info!("Creating mock: {} {} {}", mock.method, mock.path, mock.id);
 
@@ -71,6 +75,52 @@ pub(crate) async fn create_mock(
     Ok((StatusCode::CREATED, Json(mock)))
 }
 
+/// Create several mocks in one request, all or nothing
+pub(crate) async fn create_mocks_bulk(
+    State(state): State<ManagementState>,
+    Json(mut batch): Json<Vec<MockConfig>>,
+) -> Result<(StatusCode, Json<Vec<MockConfig>>), StatusCode> {
+    let mut mocks = state.mocks.write().await;
+
+    // Generate IDs and reject duplicates before anything is stored
+    let mut ids: std::collections::HashSet<String> = mocks.iter().map(|m| m.id.clone()).collect();
+    for mock in batch.iter_mut() {
+        if mock.id.is_empty() {
+            mock.id = uuid::Uuid::new_v4().to_string();
+        }
+        if !ids.insert(mock.id.clone()) {
+            return Err(StatusCode::CONFLICT);
+        }
+        if let Err(e) = mock.compile_path() {
+            warn!("Rejecting mock {} with an invalid path regex: {}", mock.id, e);
+            return Err(StatusCode::BAD_REQUEST);
+        }
+    }
+
+    info!("Creating {} mocks in bulk", batch.len());
+
+    for mock in &batch {
+        // Invoke lifecycle hooks
+        if let Some(hooks) = &state.lifecycle_hooks {
+            let event = mockforge_core::lifecycle::MockLifecycleEvent::Created {
+                id: mock.id.clone(),
+                name: mock.name.clone(),
+                config: serde_json::to_value(mock).unwrap_or_default(),
+            };
+            hooks.invoke_mock_created(&event).await;
+        }
+
+        mocks.push(mock.clone());
+
+        // Broadcast WebSocket event
+        if let Some(tx) = &state.ws_broadcast {
+            let _ = tx.send(crate::management_ws::MockEvent::mock_created(mock.clone()));
+        }
+    }
+
+    Ok((StatusCode::CREATED, Json(batch)))
+}
+
 /// Update an existing mock
 pub(crate) async fn update_mock(
     State(state): State<ManagementState>,
@@ -80,6 +130,10 @@ pub(crate) async fn update_mock(
     let mut mocks = state.mocks.write().await;
 
     let position = mocks.iter().position(|m| m.id == id).ok_or(StatusCode::NOT_FOUND)?;
+    if let Err(e) = updated_mock.compile_path() {
+        warn!("Rejecting mock {} with an invalid path regex: {}", id, e);
+        return Err(StatusCode::BAD_REQUEST);
+    }
 
     // Get old mock for comparison
     let old_mock = mocks[position].clone();
@@ -150,3 +204,31 @@ pub(crate) async fn delete_mock(
 
     Ok(StatusCode::NO_CONTENT)
 }
+
+/// Delete every mock in a group, leaving all others in place
+pub(crate) async fn delete_mock_group(
+    State(state): State<ManagementState>,
+    Path(group): Path<String>,
+) -> Json<serde_json::Value> {
+    let mut mocks = state.mocks.write().await;
+    let (deleted, kept): (Vec<MockConfig>, Vec<MockConfig>) =
+        mocks.drain(..).partition(|m| m.group.as_deref() == Some(group.as_str()));
+    *mocks = kept;
+    drop(mocks);
+
+    info!("Deleting {} mocks in group {}", deleted.len(), group);
+    for mock in &deleted {
+        if let Some(hooks) = &state.lifecycle_hooks {
+            let event = mockforge_core::lifecycle::MockLifecycleEvent::Deleted {
+                id: mock.id.clone(),
+                name: mock.name.clone(),
+            };
+            hooks.invoke_mock_deleted(&event).await;
+        }
+        if let Some(tx) = &state.ws_broadcast {
+            let _ = tx.send(crate::management_ws::MockEvent::mock_deleted(mock.id.clone()));
+        }
+    }
+
+    Json(serde_json::json!({ "group": group, "deleted": deleted.len() }))
+}
@@ -3,27 +3,46 @@
 /// Provides REST endpoints for controlling mocks, server configuration,
 /// and integration with developer tools (VS Code extension, CI/CD, etc.)
 mod ai_gen;
+mod callbacks;
 mod chaos_admin;
 mod conformance;
+mod expression;
+mod fallbacks;
+mod faults;
 mod health;
 mod import_export;
+mod latency;
 mod migration;
+mod mock_proxy;
 mod mocks;
 mod protocols;
 mod proxy;
+mod request_schema;
+mod response_body;
 mod rule_explanations;
+mod scenarios;
+mod sequence;
 mod traffic_to_openapi;
+mod transformers;
 
 // `ai_gen.rs` was split into four topic files under #656; the route
 // wiring below pulls handlers from each via these glob re-exports.
 pub use ai_gen::*;
+pub use callbacks::MockCallback;
 pub use chaos_admin::*;
 pub(crate) use conformance::{clear_conformance_violations, get_conformance_violations};
+pub use fallbacks::{FallbackResponse, FallbackStrategy, MockFallback};
+pub use faults::MockFault;
 pub use health::*;
 pub use import_export::*;
+pub use latency::MockLatency;
+pub use mock_proxy::MockProxy;
 pub use proxy::{BodyTransformRequest, ProxyRuleRequest, ProxyRuleResponse};
+pub use response_body::{ContentEncoding, MockChunk};
 pub use rule_explanations::*;
+pub use sequence::SequencedResponse;
 pub use traffic_to_openapi::*;
+pub use transformers::{ResponseSignature, ResponseTransformer};
 
 use axum::{
     body::Body,
@@ -36,6 +55,7 @@ use axum::{
 use mockforge_openapi::OpenApiSpec;
 use mockforge_proxy::config::ProxyConfig;
 use serde::{Deserialize, Serialize};
+use std::sync::atomic::{AtomicU64, Ordering};
 use std::sync::Arc;
 use tokio::sync::{broadcast, RwLock};
 
@@ -94,8 +114,19 @@ pub struct KafkaMessageEvent {
     pub timestamp: String,
 }
 
+/// How a mock's path is compared with request paths
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum PathMatch {
+    /// Literal path with `{param}` segments and `*` wildcards
+    #[default]
+    Template,
+    /// Regular expression; named groups are captured as path parameters
+    Regex,
+}
+
 /// Mock configuration representation
-#[derive(Debug, Clone, Serialize, Deserialize)]
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
 pub struct MockConfig {
     /// Unique identifier for the mock. Auto-generated by `create_mock` when
     /// omitted on input — clients can POST without an `id` and the server
@@ -111,6 +142,9 @@ pub struct MockConfig {
     pub method: String,
     /// API path pattern to match
     pub path: String,
+    /// How `path` is compared with request paths; a template when omitted
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub path_match: Option<PathMatch>,
     /// Response configuration
     pub response: MockResponse,
     /// Whether this mock is currently enabled
@@ -119,6 +153,10 @@ pub struct MockConfig {
     /// Optional latency to inject in milliseconds
     #[serde(skip_serializing_if = "Option::is_none")]
     pub latency_ms: Option<u64>,
+    /// Latency drawn from a distribution for each response, in place of
+    /// `latency_ms`
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub latency: Option<MockLatency>,
     /// Optional HTTP status code override
     #[serde(skip_serializing_if = "Option::is_none")]
     pub status_code: Option<u16>,
@@ -128,6 +166,9 @@ pub struct MockConfig {
     /// Priority for mock ordering (higher priority mocks are matched first)
     #[serde(skip_serializing_if = "Option::is_none")]
     pub priority: Option<i32>,
+    /// Group the mock belongs to, so related mocks can be removed together
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub group: Option<String>,
     /// Scenario name for stateful mocking
     #[serde(skip_serializing_if = "Option::is_none")]
     pub scenario: Option<String>,
@@ -137,6 +178,179 @@ pub struct MockConfig {
     /// New scenario state after this mock is matched
     #[serde(skip_serializing_if = "Option::is_none")]
     pub new_scenario_state: Option<String>,
+    /// Stop matching after this many requests
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub max_matches: Option<u64>,
+    /// Stop matching once this many milliseconds have passed since the mock
+    /// was created
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub expires_after_ms: Option<u64>,
+    /// Answer with 429 Too Many Requests once this many requests have
+    /// matched within a window
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub rate_limit: Option<MockRateLimit>,
+    /// Responses served in turn, one per match, in place of the mock's
+    /// response; the last repeats once the sequence is exhausted
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub response_sequence: Vec<SequencedResponse>,
+    /// Requests sent after the mock responds, e.g. webhooks
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub callbacks: Vec<MockCallback>,
+    /// Named response transformers applied, in order, to the response
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub transformers: Vec<String>,
+    /// Forward matching requests to a real backend instead of serving
+    /// `response`
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub proxy: Option<MockProxy>,
+    /// JSON Schema request bodies must conform to, enforced according to
+    /// `MOCKFORGE_REQUEST_VALIDATION`
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub request_schema: Option<serde_json::Value>,
+    /// Match counts and creation time, which are not part of the configuration
+    #[serde(skip)]
+    pub runtime: Arc<MockRuntime>,
+}
+
+impl MockConfig {
+    /// Whether the mock has expired, per `expires_after_ms`
+    fn is_expired(&self) -> bool {
+        self.expires_after_ms.is_some_and(|ms| {
+            self.runtime.created.elapsed() >= std::time::Duration::from_millis(ms)
+        })
+    }
+
+    /// Compile a regex `path` when the mock is registered, so requests do not
+    /// compile it. Fails when the path is not a valid regex.
+    pub(crate) fn compile_path(&self) -> Result<(), regex::Error> {
+        if self.path_match == Some(PathMatch::Regex) {
+            let re = regex::Regex::new(&self.path)?;
+            let _ = self.runtime.path_regex.set(Some(re));
+        }
+        Ok(())
+    }
+
+    /// The compiled regex `path`, for mocks stored without going through
+    /// [`Self::compile_path`] compiled on first use; `None` when invalid
+    fn path_regex(&self) -> Option<&regex::Regex> {
+        self.runtime
+            .path_regex
+            .get_or_init(|| match regex::Regex::new(&self.path) {
+                Ok(re) => Some(re),
+                Err(e) => {
+                    tracing::warn!("Mock {} has an invalid path regex: {}", self.id, e);
+                    None
+                }
+            })
+            .as_ref()
+    }
+
+    /// Whether the mock can still serve requests: it has not expired or used
+    /// up `max_matches`. [`Self::claim_match`] makes the final, atomic check.
+    fn is_available(&self) -> bool {
+        !self.is_expired()
+            && self
+                .max_matches
+                .is_none_or(|max| self.runtime.matches.load(Ordering::SeqCst) < max)
+    }
+
+    /// Count a request against the mock, unless it has expired or already
+    /// served `max_matches` requests, returning the request's 0-based match
+    /// number. Claiming atomically keeps concurrent requests from exceeding
+    /// the limit.
+    fn claim_match(&self) -> Option<u64> {
+        if self.is_expired() {
+            return None;
+        }
+        self.runtime
+            .matches
+            .fetch_update(Ordering::SeqCst, Ordering::SeqCst, |n| match self.max_matches {
+                Some(max) if n >= max => None,
+                _ => Some(n + 1),
+            })
+            .ok()
+    }
+}
+
+/// Runtime state of a mock. It is created with the mock, so updating a mock
+/// resets its match counts and expiry.
+#[derive(Debug)]
+pub struct MockRuntime {
+    /// When the mock was created
+    pub created: std::time::Instant,
+    /// How many requests the mock has served
+    pub matches: AtomicU64,
+    /// Requests counted against the mock's rate limit
+    rate_window: std::sync::Mutex<RateWindow>,
+    /// The compiled `path` of a regex mock
+    path_regex: std::sync::OnceLock<Option<regex::Regex>>,
+}
+
+impl Default for MockRuntime {
+    fn default() -> Self {
+        Self {
+            created: std::time::Instant::now(),
+            matches: AtomicU64::new(0),
+            rate_window: std::sync::Mutex::new(RateWindow {
+                started: std::time::Instant::now(),
+                count: 0,
+            }),
+            path_regex: std::sync::OnceLock::new(),
+        }
+    }
+}
+
+/// The current rate limit window of a mock
+#[derive(Debug)]
+struct RateWindow {
+    started: std::time::Instant,
+    count: u64,
+}
+
+/// Rate limit of a mock: `limit` requests per `window_ms` milliseconds
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct MockRateLimit {
+    /// Requests answered normally in each window
+    pub limit: u64,
+    /// Length of the window in milliseconds
+    pub window_ms: u64,
+}
+
+impl MockRateLimit {
+    /// Count a request in the current window. Once the limit is reached,
+    /// returns how long is left until the window resets instead.
+    fn admit(&self, runtime: &MockRuntime) -> Result<(), std::time::Duration> {
+        let window = std::time::Duration::from_millis(self.window_ms);
+        let mut current = runtime.rate_window.lock().unwrap_or_else(|e| e.into_inner());
+        if current.started.elapsed() >= window {
+            *current = RateWindow {
+                started: std::time::Instant::now(),
+                count: 0,
+            };
+        }
+        if current.count >= self.limit {
+            return Err(window.saturating_sub(current.started.elapsed()));
+        }
+        current.count += 1;
+        Ok(())
+    }
+}
+
+/// Response for a request over a mock's rate limit, with the headers
+/// clients use for backoff
+fn rate_limited_response(mock: &MockConfig, limit: u64, reset_in: std::time::Duration) -> Response {
+    let retry_after = reset_in.as_secs_f64().ceil().max(1.0) as u64;
+    let reset_at = chrono::Utc::now().timestamp().max(0) as u64 + retry_after;
+    Response::builder()
+        .status(StatusCode::TOO_MANY_REQUESTS)
+        .extension(MatchedMockId(mock.id.clone()))
+        .header(http::header::RETRY_AFTER, retry_after)
+        .header("x-ratelimit-limit", limit)
+        .header("x-ratelimit-remaining", 0u64)
+        .header("x-ratelimit-reset", reset_at)
+        .header(http::header::CONTENT_TYPE, "application/json")
+        .body(Body::from(r#"{"error":"rate limit exceeded"}"#))
+        .unwrap_or_else(|_| StatusCode::INTERNAL_SERVER_ERROR.into_response())
 }
 
 fn default_true() -> bool {
@@ -144,33 +358,84 @@ fn default_true() -> bool {
 }
 
 /// Mock response configuration
-#[derive(Debug, Clone, Serialize, Deserialize)]
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
 pub struct MockResponse {
     /// Response body as JSON
+    #[serde(default)]
     pub body: serde_json::Value,
     /// Optional custom response headers
     #[serde(skip_serializing_if = "Option::is_none")]
     pub headers: Option<std::collections::HashMap<String, String>>,
+    /// Binary body, base64-encoded, served verbatim in place of `body`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub body_base64: Option<String>,
+    /// File served as the body in place of `body`, read on every request
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub body_file: Option<String>,
+    /// `Set-Cookie` header values, each sent as its own header
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub set_cookies: Vec<String>,
+    /// Body written as a sequence of delayed chunks, in place of `body`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub chunks: Vec<MockChunk>,
+    /// Hold a chunked response open after the last chunk until the client
+    /// disconnects
+    #[serde(default)]
+    pub keep_open: bool,
+    /// Compression applied to the body, sent as `Content-Encoding`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub content_encoding: Option<ContentEncoding>,
+    /// Rate the body is written at, in bytes per second; full speed when
+    /// omitted
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub throttle_bytes_per_second: Option<u64>,
+    /// Complete HTTP/1.x response, base64-encoded, written to the connection
+    /// verbatim in place of the rendered response
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub raw_base64: Option<String>,
+    /// Network-level failure served in place of the response
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub fault: Option<MockFault>,
 }
 
 /// Request matching criteria for advanced request matching
 #[derive(Debug, Clone, Serialize, Deserialize, Default)]
 pub struct RequestMatchCriteria {
     /// Headers that must be present and match (case-insensitive header names)
-    #[serde(skip_serializing_if = "std::collections::HashMap::is_empty")]
+    #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
     pub headers: std::collections::HashMap<String, String>,
+    /// Headers that must not be sent (case-insensitive names)
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub headers_absent: Vec<String>,
+    /// Headers that must be sent, with any value (case-insensitive names)
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub headers_present: Vec<String>,
     /// Query parameters that must be present and match
-    #[serde(skip_serializing_if = "std::collections::HashMap::is_empty")]
+    #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
     pub query_params: std::collections::HashMap<String, String>,
+    /// Cookies that must be sent with exactly the given value
+    #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
+    pub cookies: std::collections::HashMap<String, String>,
     /// Request body pattern (supports exact match or regex)
     #[serde(skip_serializing_if = "Option::is_none")]
     pub body_pattern: Option<String>,
+    /// JSON the request body must equal
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub body_json: Option<serde_json::Value>,
     /// JSONPath expression for JSON body matching
     #[serde(skip_serializing_if = "Option::is_none")]
     pub json_path: Option<String>,
+    /// JSONPath expressions mapped to the value one of the selected nodes
+    /// must equal
+    #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
+    pub json_paths: std::collections::HashMap<String, serde_json::Value>,
     /// XPath expression for XML body matching
     #[serde(skip_serializing_if = "Option::is_none")]
     pub xpath: Option<String>,
+    /// XPath expressions mapped to the trimmed text the selected element must
+    /// have; an empty value only requires the element to exist
+    #[serde(default, skip_serializing_if = "std::collections::HashMap::is_empty")]
+    pub xpaths: std::collections::HashMap<String, String>,
     /// Custom matcher expression (e.g., "headers.content-type == \"application/json\"")
     #[serde(skip_serializing_if = "Option::is_none")]
     pub custom_matcher: Option<String>,
@@ -204,8 +469,8 @@ pub fn mock_matches_request(
         return false;
     }
 
-    // Check path pattern (supports wildcards and path parameters)
-    if !path_matches_pattern(&mock.path, path) {
+    // Check path pattern (supports wildcards, path parameters, and regexes)
+    if mock_path_params(mock, path).is_none() {
         return false;
     }
 
@@ -230,6 +495,14 @@ pub fn mock_matches_request(
             }
         }
 
+        // Check header presence and absence
+        let has_header = |name: &String| headers.keys().any(|k| k.eq_ignore_ascii_case(name));
+        if criteria.headers_absent.iter().any(has_header)
+            || !criteria.headers_present.iter().all(has_header)
+        {
+            return false;
+        }
+
         // Check query parameters
         for (key, expected_value) in &criteria.query_params {
             if let Some(actual_value) = query_params.get(key) {
@@ -241,6 +514,14 @@ pub fn mock_matches_request(
             }
         }
 
+        // Check cookies
+        if !criteria.cookies.is_empty() {
+            let cookies = request_cookies(headers);
+            if criteria.cookies.iter().any(|(name, value)| cookies.get(name) != Some(value)) {
+                return false;
+            }
+        }
+
         // Check body pattern
         if let Some(pattern) = &criteria.body_pattern {
             if let Some(body_bytes) = body {
@@ -272,6 +553,28 @@ pub fn mock_matches_request(
             }
         }
 
+        // Check JSON body equality and JSONPath values
+        if criteria.body_json.is_some() || !criteria.json_paths.is_empty() {
+            let Some(json_value) =
+                body.and_then(|b| serde_json::from_slice::<serde_json::Value>(b).ok())
+            else {
+                return false;
+            };
+            if let Some(expected) = &criteria.body_json {
+                if !json_values_equal(&json_value, expected) {
+                    return false;
+                }
+            }
+            for (json_path, expected) in &criteria.json_paths {
+                if !json_path_values(&json_value, json_path)
+                    .into_iter()
+                    .any(|actual| json_values_equal(actual, expected))
+                {
+                    return false;
+                }
+            }
+        }
+
         // Check XPath (supports a focused subset)
         if let Some(xpath) = &criteria.xpath {
             if let Some(body_bytes) = body {
@@ -287,6 +590,19 @@ pub fn mock_matches_request(
             }
         }
 
+        // Check XPath text matchers
+        if !criteria.xpaths.is_empty() {
+            let Some(body_str) = body.and_then(|b| std::str::from_utf8(b).ok()) else {
+                return false;
+            };
+            for (xpath, expected) in &criteria.xpaths {
+                let expected = (!expected.is_empty()).then_some(expected.as_str());
+                if !xml_xpath_matches(body_str, xpath, expected) {
+                    return false;
+                }
+            }
+        }
+
         // Check custom matcher
         if let Some(custom) = &criteria.custom_matcher {
             if !evaluate_custom_matcher(custom, method, path, headers, query_params, body) {
@@ -336,6 +652,84 @@ fn path_matches_pattern(pattern: &str, path: &str) -> bool {
     true
 }
 
+/// The cookies sent in the request's `Cookie` headers, by name
+fn request_cookies(
+    headers: &std::collections::HashMap<String, String>,
+) -> std::collections::HashMap<String, String> {
+    headers
+        .iter()
+        .filter(|(name, _)| name.eq_ignore_ascii_case("cookie"))
+        .flat_map(|(_, value)| value.split(';'))
+        .filter_map(|pair| {
+            let (name, value) = pair.split_once('=')?;
+            Some((name.trim().to_string(), value.trim().trim_matches('"').to_string()))
+        })
+        .collect()
+}
+
+/// Match a request path against the mock's path, returning the captured path
+/// parameters: the values of `{name}` segments, or a regex's named groups
+fn mock_path_params(
+    mock: &MockConfig,
+    path: &str,
+) -> Option<std::collections::HashMap<String, String>> {
+    match mock.path_match.unwrap_or_default() {
+        PathMatch::Regex => {
+            let re = mock.path_regex()?;
+            let captures = re.captures(path)?;
+            Some(
+                re.capture_names()
+                    .flatten()
+                    .filter_map(|name| {
+                        captures.name(name).map(|m| (name.to_string(), m.as_str().to_string()))
+                    })
+                    .collect(),
+            )
+        }
+        PathMatch::Template => {
+            if !path_matches_pattern(&mock.path, path) {
+                return None;
+            }
+            let pattern_parts = mock.path.split('/').filter(|s| !s.is_empty());
+            let path_parts = path.split('/').filter(|s| !s.is_empty());
+            Some(
+                pattern_parts
+                    .zip(path_parts)
+                    .filter_map(|(pattern_part, path_part)| {
+                        let name = pattern_part.strip_prefix('{')?.strip_suffix('}')?;
+                        Some((name.to_string(), path_part.to_string()))
+                    })
+                    .collect(),
+            )
+        }
+    }
+}
+
+/// Replace `{{request.path.<name>}}` placeholders in the strings of a
+/// response body with the captured path parameters
+fn expand_path_params(
+    value: &mut serde_json::Value,
+    params: &std::collections::HashMap<String, String>,
+) {
+    match value {
+        serde_json::Value::String(s) => {
+            for (name, param) in params {
+                let placeholder = format!("{{{{request.path.{}}}}}", name);
+                if s.contains(&placeholder) {
+                    *s = s.replace(&placeholder, param);
+                }
+            }
+        }
+        serde_json::Value::Array(items) => {
+            items.iter_mut().for_each(|item| expand_path_params(item, params))
+        }
+        serde_json::Value::Object(fields) => {
+            fields.values_mut().for_each(|field| expand_path_params(field, params))
+        }
+        _ => {}
+    }
+}
+
 /// Check if path matches a wildcard pattern
 fn matches_wildcard_pattern(pattern: &str, path: &str) -> bool {
     use regex::Regex;
@@ -358,9 +752,17 @@ fn matches_wildcard_pattern(pattern: &str, path: &str) -> bool {
 /// - `$.items[0].name` — array index access
 /// - `$.items[*]` — array wildcard (checks array is non-empty)
 fn json_path_exists(json: &serde_json::Value, json_path: &str) -> bool {
-    let path = if json_path == "$" {
-        return true;
-    } else if let Some(p) = json_path.strip_prefix("$.") {
+    !json_path_values(json, json_path).is_empty()
+}
+
+/// Select the nodes of a JSON value a JSONPath refers to, supporting the
+/// same subset as [`json_path_exists`]. A wildcard selects every element of
+/// the array, so `$.items[*].sku` selects the SKU of each item.
+fn json_path_values<'a>(
+    json: &'a serde_json::Value,
+    json_path: &str,
+) -> Vec<&'a serde_json::Value> {
+    let path = if let Some(p) = json_path.strip_prefix("$.") {
         p
     } else if let Some(p) = json_path.strip_prefix('$') {
         p.strip_prefix('.').unwrap_or(p)
@@ -368,42 +770,46 @@ fn json_path_exists(json: &serde_json::Value, json_path: &str) -> bool {
         json_path
     };
 
-    let mut current = json;
+    let mut current = vec![json];
     for segment in split_json_path_segments(path) {
-        match segment {
-            JsonPathSegment::Field(name) => {
-                if let Some(obj) = current.as_object() {
-                    if let Some(value) = obj.get(name) {
-                        current = value;
-                    } else {
-                        return false;
-                    }
-                } else {
-                    return false;
-                }
-            }
-            JsonPathSegment::Index(idx) => {
-                if let Some(arr) = current.as_array() {
-                    if let Some(value) = arr.get(idx) {
-                        current = value;
-                    } else {
-                        return false;
+        current = current
+            .into_iter()
+            .flat_map(|value| -> Vec<&'a serde_json::Value> {
+                match segment {
+                    JsonPathSegment::Field(name) => value.get(name).into_iter().collect(),
+                    JsonPathSegment::Index(idx) => value.get(idx).into_iter().collect(),
+                    JsonPathSegment::Wildcard => {
+                        value.as_array().map(|arr| arr.iter().collect()).unwrap_or_default()
                     }
-                } else {
-                    return false;
-                }
-            }
-            JsonPathSegment::Wildcard => {
-                if let Some(arr) = current.as_array() {
-                    return !arr.is_empty();
                 }
-                return false;
-            }
+            })
+            .collect();
+        if current.is_empty() {
+            break;
         }
     }
-    true
+    current
 }
 
+/// Compare two JSON values, treating numbers as equal when they have the
+/// same numeric value (so `1` matches `1.0`)
+fn json_values_equal(actual: &serde_json::Value, expected: &serde_json::Value) -> bool {
+    use serde_json::Value;
+
+    match (actual, expected) {
+        (Value::Number(a), Value::Number(b)) => a == b || a.as_f64() == b.as_f64(),
+        (Value::Array(a), Value::Array(b)) => {
+            a.len() == b.len() && a.iter().zip(b).all(|(x, y)| json_values_equal(x, y))
+        }
+        (Value::Object(a), Value::Object(b)) => {
+            a.len() == b.len()
+                && a.iter().all(|(k, v)| b.get(k).is_some_and(|w| json_values_equal(v, w)))
+        }
+        _ => actual == expected,
+    }
+}
+
+#[derive(Clone, Copy)]
 enum JsonPathSegment<'a> {
     Field(&'a str),
     Index(usize),
@@ -502,6 +908,13 @@ fn segment_matches(node: roxmltree::Node<'_, '_>, segment: &XPathSegment) -> boo
 /// - Descendant search: `//item` and `//parent/child`
 /// - Optional text predicate per segment: `item[text()="value"]`
 fn xml_xpath_exists(xml_body: &str, xpath: &str) -> bool {
+    xml_xpath_matches(xml_body, xpath, None)
+}
+
+/// Check if an XPath expression selects an element of an XML body whose
+/// trimmed text equals `expected_text`, or any element when it is `None`.
+/// Supports the same subset as [`xml_xpath_exists`].
+fn xml_xpath_matches(xml_body: &str, xpath: &str, expected_text: Option<&str>) -> bool {
     let doc = match roxmltree::Document::parse(xml_body) {
         Ok(doc) => doc,
         Err(err) => {
@@ -524,7 +937,7 @@ fn xml_xpath_exists(xml_body: &str, xpath: &str) -> bool {
         return false;
     };
 
-    let segments: Vec<XPathSegment> = path_str
+    let mut segments: Vec<XPathSegment> = path_str
         .split('/')
         .filter(|s| !s.trim().is_empty())
         .filter_map(parse_xpath_segment)
@@ -533,6 +946,9 @@ fn xml_xpath_exists(xml_body: &str, xpath: &str) -> bool {
     if segments.is_empty() {
         return false;
     }
+    if let (Some(expected), Some(last)) = (expected_text, segments.last_mut()) {
+        last.text_equals = Some(expected.to_string());
+    }
 
     if is_descendant {
         let first = &segments[0];
@@ -593,6 +1009,18 @@ fn evaluate_custom_matcher(
 
     let expr = expression.trim();
 
+    // Expressions over `request.` fields, as the SDKs send them
+    if expr.contains("request.") {
+        let request = expression::ExprRequest {
+            method,
+            path,
+            headers,
+            query_params,
+            body,
+        };
+        return expression::evaluate(expr, &request);
+    }
+
     // Handle equality expressions (field == "value")
     if expr.contains("==") {
         let parts: Vec<&str> = expr.split("==").map(|s| s.trim()).collect();
@@ -678,6 +1106,20 @@ fn evaluate_custom_matcher(
     }
 }
 
+/// Value of the named cookie sent in the request's `Cookie` headers
+fn request_cookie(
+    headers: &std::collections::HashMap<String, String>,
+    name: &str,
+) -> Option<String> {
+    headers
+        .iter()
+        .filter(|(k, _)| k.eq_ignore_ascii_case("cookie"))
+        .flat_map(|(_, v)| v.split(';'))
+        .filter_map(|pair| pair.trim().split_once('='))
+        .find(|(k, _)| *k == name)
+        .map(|(_, v)| v.to_string())
+}
+
 /// Server statistics
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct ServerStats {
@@ -727,6 +1169,13 @@ pub struct ManagementState {
     /// Optional SMTP registry for email mocking
     #[cfg(feature = "smtp")]
     pub smtp_registry: Option<Arc<mockforge_smtp::SmtpSpecRegistry>>,
+    /// Optional FTP registry, whose virtual file system and upload log the
+    /// FTP server serves
+    #[cfg(feature = "ftp")]
+    pub ftp_registry: Option<Arc<mockforge_ftp::FtpSpecRegistry>>,
+    /// Optional registry of the scripts the WebSocket server runs per path
+    #[cfg(feature = "ws")]
+    pub ws_scripts: Option<Arc<mockforge_ws::WsScriptRegistry>>,
     /// Optional MQTT session manager (the live listener state) for the admin
     /// API. Shared with the running listener so the admin reflects the clients
     /// and topics actually being served (issue #730).
@@ -744,6 +1193,13 @@ pub struct ManagementState {
     /// State machine manager for scenario state machines
     pub state_machine_manager:
         Arc<RwLock<mockforge_scenarios::state_machine::ScenarioStateMachineManager>>,
+    /// Current state of each scenario mocks have moved or the API has set;
+    /// scenarios missing here are in the started state
+    pub scenario_states: Arc<RwLock<std::collections::HashMap<String, String>>>,
+    /// How unmatched requests are answered, per route prefix
+    pub fallbacks: Arc<RwLock<Vec<MockFallback>>>,
+    /// Response transformers mocks can reference, by name
+    pub transformers: Arc<RwLock<std::collections::HashMap<String, ResponseTransformer>>>,
     /// Optional WebSocket broadcast channel for real-time updates
     pub ws_broadcast: Option<Arc<broadcast::Sender<crate::management_ws::MockEvent>>>,
     /// Lifecycle hook registry for extensibility
@@ -793,6 +1249,10 @@ impl ManagementState {
             proxy_config: None,
             #[cfg(feature = "smtp")]
             smtp_registry: None,
+            #[cfg(feature = "ftp")]
+            ftp_registry: None,
+            #[cfg(feature = "ws")]
+            ws_scripts: None,
             #[cfg(feature = "mqtt")]
             mqtt_sessions: None,
             #[cfg(feature = "kafka")]
@@ -808,6 +1268,9 @@ impl ManagementState {
             state_machine_manager: Arc::new(RwLock::new(
                 mockforge_scenarios::state_machine::ScenarioStateMachineManager::new(),
             )),
+            scenario_states: Arc::new(RwLock::new(std::collections::HashMap::new())),
+            fallbacks: Arc::new(RwLock::new(Vec::new())),
+            transformers: Arc::new(RwLock::new(std::collections::HashMap::new())),
             ws_broadcast: None,
             lifecycle_hooks: None,
             rule_explanations: Arc::new(RwLock::new(std::collections::HashMap::new())),
@@ -879,6 +1342,20 @@ impl ManagementState {
         self
     }
 
+    #[cfg(feature = "ftp")]
+    /// Add FTP registry to management state
+    pub fn with_ftp_registry(mut self, ftp_registry: Arc<mockforge_ftp::FtpSpecRegistry>) -> Self {
+        self.ftp_registry = Some(ftp_registry);
+        self
+    }
+
+    #[cfg(feature = "ws")]
+    /// Add WebSocket script registry to management state
+    pub fn with_ws_scripts(mut self, ws_scripts: Arc<mockforge_ws::WsScriptRegistry>) -> Self {
+        self.ws_scripts = Some(ws_scripts);
+        self
+    }
+
     #[cfg(feature = "mqtt")]
     /// Add the MQTT session manager (live listener state) to management state
     pub fn with_mqtt_sessions(
@@ -935,14 +1412,27 @@ pub fn management_router(state: ManagementState) -> Router {
         .route("/config", get(get_config))
         .route("/config/validate", post(validate_config))
         .route("/config/bulk", post(bulk_update_config))
+        .route("/config/random-seed", put(set_random_seed))
         .route("/mocks", get(mocks::list_mocks))
         .route("/mocks", post(mocks::create_mock))
+        .route("/mocks/bulk", post(mocks::create_mocks_bulk))
         .route("/mocks/{id}", get(mocks::get_mock))
         .route("/mocks/{id}", put(mocks::update_mock))
         .route("/mocks/{id}", delete(mocks::delete_mock))
+        .route("/mocks/groups/{group}", delete(mocks::delete_mock_group))
         .route("/export", get(export_mocks))
         .route("/import", post(import_mocks))
         .route("/spec", get(get_openapi_spec))
+        .route("/grpc/services", get(protocols::list_grpc_services))
+        .route("/scenario-states", get(scenarios::list_scenario_states))
+        .route("/scenario-states/reset", post(scenarios::reset_scenario_states))
+        .route("/scenario-states/{name}", put(scenarios::set_scenario_state))
+        .route("/fallbacks", get(fallbacks::list_fallbacks))
+        .route("/fallbacks", put(fallbacks::set_fallback))
+        .route("/fallbacks", delete(fallbacks::clear_fallbacks))
+        .route("/transformers", get(transformers::list_transformers))
+        .route("/transformers/{name}", put(transformers::set_transformer))
+        .route("/transformers/{name}", delete(transformers::delete_transformer))
         // Issue #79 round 12 — server-side spec violation feed for the
         // new TUI "Conformance" screen. Backed by the bounded ring
         // buffer in `mockforge_foundation::conformance_violations` that
@@ -962,12 +1452,35 @@ pub fn management_router(state: ManagementState) -> Router {
     #[cfg(not(feature = "smtp"))]
     let router = router;
 
+    #[cfg(feature = "ftp")]
+    let router = router
+        .route("/ftp/files", get(protocols::list_ftp_files))
+        .route("/ftp/files/{*path}", get(protocols::get_ftp_file))
+        .route("/ftp/files/{*path}", put(protocols::put_ftp_file))
+        .route("/ftp/files/{*path}", delete(protocols::delete_ftp_file))
+        .route("/ftp/dirs/{*path}", put(protocols::create_ftp_dir))
+        .route("/ftp/uploads", get(protocols::list_ftp_uploads))
+        .route("/ftp/uploads", delete(protocols::clear_ftp_uploads));
+
+    #[cfg(not(feature = "ftp"))]
+    let router = router;
+
+    #[cfg(feature = "ws")]
+    let router = router
+        .route("/ws/scripts", get(protocols::list_ws_scripts))
+        .route("/ws/scripts", post(protocols::register_ws_script));
+
+    #[cfg(not(feature = "ws"))]
+    let router = router;
+
     // MQTT routes
     #[cfg(feature = "mqtt")]
     let router = router
         .route("/mqtt/stats", get(protocols::get_mqtt_stats))
         .route("/mqtt/clients", get(protocols::get_mqtt_clients))
         .route("/mqtt/topics", get(protocols::get_mqtt_topics))
+        .route("/mqtt/subscriptions", get(protocols::get_mqtt_subscriptions))
+        .route("/mqtt/messages", get(protocols::get_mqtt_messages))
         .route("/mqtt/clients/{client_id}", delete(protocols::disconnect_mqtt_client))
         .route("/mqtt/messages/stream", get(protocols::mqtt_messages_stream))
         .route("/mqtt/publish", post(protocols::publish_mqtt_message_handler))
@@ -983,6 +1496,7 @@ pub fn management_router(state: ManagementState) -> Router {
         .route("/kafka/stats", get(protocols::get_kafka_stats))
         .route("/kafka/topics", get(protocols::get_kafka_topics))
         .route("/kafka/topics/{topic}", get(protocols::get_kafka_topic))
+        .route("/kafka/topics/{topic}/messages", get(protocols::get_kafka_topic_messages))
         .route("/kafka/groups", get(protocols::get_kafka_groups))
         .route("/kafka/groups/{group_id}", get(protocols::get_kafka_group))
         .route("/kafka/produce", post(protocols::produce_kafka_message))
@@ -1002,7 +1516,8 @@ pub fn management_router(state: ManagementState) -> Router {
         .route("/amqp/exchanges/{name}/bindings", post(protocols::add_amqp_binding))
         .route("/amqp/queues", get(protocols::get_amqp_queues))
         .route("/amqp/queues", post(protocols::declare_amqp_queue))
-        .route("/amqp/publish", post(protocols::publish_amqp_message));
+        .route("/amqp/publish", post(protocols::publish_amqp_message))
+        .route("/amqp/published", get(protocols::get_amqp_published));
 
     #[cfg(not(feature = "amqp"))]
     let router = router;
@@ -1094,6 +1609,24 @@ pub fn management_router_with_spec_import(state: ManagementState) -> Router {
         .merge(spec_import_router(SpecImportState::new()))
 }
 
+/// ID of the dynamic mock that served a response, attached as a response
+/// extension so the request logger can record which stub matched
+#[derive(Debug, Clone)]
+pub struct MatchedMockId(pub String);
+
+/// Marks a response for a request no route or dynamic mock matched, so the
+/// request logger can record it as unmatched
+#[derive(Debug, Clone, Copy)]
+pub struct UnmatchedRequest;
+
+/// Whether `MOCKFORGE_STRICT_STUBBING` is set, in which case unmatched
+/// requests always get a 404, even in shadow mode
+fn strict_stubbing_enabled() -> bool {
+    std::env::var("MOCKFORGE_STRICT_STUBBING")
+        .map(|v| v == "1" || v.eq_ignore_ascii_case("true"))
+        .unwrap_or(false)
+}
+
 /// Match an incoming request against mocks registered via the
 /// `POST /__mockforge/api/mocks` endpoint and return the first match's
 /// response (ordered by descending priority). Returns `None` if nothing
@@ -1106,6 +1639,7 @@ pub fn management_router_with_spec_import(state: ManagementState) -> Router {
 pub async fn serve_dynamic_mock(state: &ManagementState, req: Request<Body>) -> Option<Response> {
     let method = req.method().as_str().to_string();
     let path = req.uri().path().to_string();
+    let query = req.uri().query().map(str::to_string);
 
     let query_params: std::collections::HashMap<String, String> = req
         .uri()
@@ -1119,6 +1653,15 @@ pub async fn serve_dynamic_mock(state: &ManagementState, req: Request<Body>) ->
         .filter_map(|(k, v)| v.to_str().ok().map(|v| (k.as_str().to_string(), v.to_string())))
         .collect();
 
+    // Connection faults and raw responses rewrite the wire, so they are only
+    // injected on HTTP/1 connections where the next write is this request's
+    // response
+    let connection_control = req
+        .extensions()
+        .get::<mockforge_chaos::ConnectionControl>()
+        .filter(|_| req.version() <= http::Version::HTTP_11)
+        .cloned();
+
     let (_parts, body) = req.into_parts();
     let body_bytes = axum::body::to_bytes(body, 1024 * 1024).await.ok()?;
     let body_opt: Option<&[u8]> = if body_bytes.is_empty() {
@@ -1128,22 +1671,125 @@ pub async fn serve_dynamic_mock(state: &ManagementState, req: Request<Body>) ->
     };
 
     let mocks = state.mocks.read().await;
-
     let mut candidates: Vec<&MockConfig> = mocks
         .iter()
         .filter(|m| mock_matches_request(m, &method, &path, &headers, &query_params, body_opt))
         .collect();
-    if candidates.is_empty() {
-        return None;
-    }
     candidates.sort_by_key(|m| -(m.priority.unwrap_or(0)));
-    let mock = candidates.first()?;
+    // Scenario states are only locked when a candidate belongs to a
+    // scenario, and then held until the serving mock has moved its scenario
+    // on, so concurrent requests see each transition in turn
+    let mut scenario_states = if candidates.iter().any(|m| m.scenario.is_some()) {
+        Some(state.scenario_states.write().await)
+    } else {
+        None
+    };
+    // Skip mocks that have expired or used up their matches. The rate limit
+    // and request schema are checked first, so a 429 or 400 neither claims
+    // a match nor moves the scenario on.
+    let mut selected = None;
+    for candidate in candidates {
+        if !candidate.is_available() {
+            continue;
+        }
+        if let Some(states) = &scenario_states {
+            if !scenarios::scenario_state_allows(candidate, states) {
+                continue;
+            }
+        }
+        if let Some(rate_limit) = &candidate.rate_limit {
+            if let Err(reset_in) = rate_limit.admit(&candidate.runtime) {
+                return Some(rate_limited_response(candidate, rate_limit.limit, reset_in));
+            }
+        }
+        if let Some(mut rejection) =
+            request_schema::check_request_schema(candidate, &method, &path, &body_bytes)
+        {
+            rejection.extensions_mut().insert(MatchedMockId(candidate.id.clone()));
+            return Some(rejection);
+        }
+        // A concurrent request may have claimed the mock's last match
+        if let Some(match_number) = candidate.claim_match() {
+            selected = Some((candidate, match_number));
+            break;
+        }
+    }
+    // Clone the mock that serves the request so the lock is not held while
+    // it responds
+    let (mock, match_number) = selected?;
+    if let Some(states) = scenario_states.as_mut() {
+        scenarios::advance_scenario_state(mock, states);
+    }
+    let mut mock = mock.clone();
+    drop(scenario_states);
+    drop(mocks);
 
-    if let Some(ms) = mock.latency_ms {
-        if ms > 0 {
-            tokio::time::sleep(std::time::Duration::from_millis(ms)).await;
+    mock.apply_sequenced_response(match_number);
+
+    let path_params = mock_path_params(&mock, &path).unwrap_or_default();
+    if !path_params.is_empty() {
+        expand_path_params(&mut mock.response.body, &path_params);
+    }
+
+    let rewrites_connection = mock.proxy.is_none()
+        && (mock.response.raw_base64.is_some() || mock.response.fault.is_some());
+    let mut response = match &mock.proxy {
+        Some(proxy) => {
+            let request = mock_proxy::ProxiedRequest {
+                method: &method,
+                path: &path,
+                query: query.as_deref(),
+                headers: &headers,
+                body: &body_bytes,
+            };
+            mock_proxy::forward(state, &mock, proxy, request).await
+        }
+        None if mock.response.raw_base64.is_some() => {
+            faults::raw_response(&mock, connection_control.as_ref()).await
+        }
+        None => match mock.response.fault {
+            Some(fault) => faults::fault_response(&mock, fault, connection_control.as_ref()).await,
+            None => mock_response(&mock).await,
+        },
+    };
+    if !mock.callbacks.is_empty() || !mock.transformers.is_empty() {
+        let request = callbacks::CallbackRequest {
+            path_params,
+            headers,
+            body: serde_json::from_slice(&body_bytes).ok(),
+        };
+        // Raw responses and faults are never written, so there is nothing
+        // to transform
+        if !rewrites_connection {
+            response = transformers::apply_transformers(
+                state,
+                &mock.id,
+                &mock.transformers,
+                &request,
+                response,
+            )
+            .await;
         }
+        callbacks::send_callbacks(&mock.id, &mock.callbacks, &request);
     }
+    response.extensions_mut().insert(MatchedMockId(mock.id.clone()));
+    Some(response)
+}
+
+/// Wait out a mock's latency
+async fn sleep_latency(mock: &MockConfig) {
+    let latency = match &mock.latency {
+        Some(latency) => latency.sample(),
+        None => std::time::Duration::from_millis(mock.latency_ms.unwrap_or(0)),
+    };
+    if !latency.is_zero() {
+        tokio::time::sleep(latency).await;
+    }
+}
+
+/// Serve a mock's response: its status, headers, and body after its latency
+async fn mock_response(mock: &MockConfig) -> Response {
+    sleep_latency(mock).await;
 
     let status = mock
         .status_code
@@ -1174,15 +1820,34 @@ pub async fn serve_dynamic_mock(state: &ManagementState, req: Request<Body>) ->
         mock.response.body.clone()
     };
 
-    let body_bytes_out = serde_json::to_vec(&body_value).unwrap_or_default();
+    let body = match response_body::mock_body(&mock.response, body_value).await {
+        Ok(body) => body,
+        Err(e) => {
+            tracing::warn!("Mock {} body could not be served: {}", mock.id, e);
+            return (
+                StatusCode::INTERNAL_SERVER_ERROR,
+                axum::Json(serde_json::json!({ "error": e })),
+            )
+                .into_response();
+        }
+    };
+    let default_content_type = if mock.response.is_binary() {
+        "application/octet-stream"
+    } else {
+        "application/json"
+    };
     let mut response = Response::builder().status(status);
 
     let mut has_content_type = false;
+    let mut has_content_encoding = false;
     if let Some(h) = &mock.response.headers {
         for (k, v) in h {
             if k.eq_ignore_ascii_case("content-type") {
                 has_content_type = true;
             }
+            if k.eq_ignore_ascii_case("content-encoding") {
+                has_content_encoding = true;
+            }
             if let (Ok(name), Ok(value)) =
                 (HeaderName::from_bytes(k.as_bytes()), HeaderValue::from_str(v))
             {
@@ -1191,14 +1856,20 @@ pub async fn serve_dynamic_mock(state: &ManagementState, req: Request<Body>) ->
         }
     }
     if !has_content_type {
-        response = response.header("content-type", "application/json");
+        response = response.header("content-type", default_content_type);
+    }
+    if let Some(encoding) = mock.response.content_encoding.filter(|_| !has_content_encoding) {
+        response = response.header(axum::http::header::CONTENT_ENCODING, encoding.as_str());
+    }
+    for cookie in &mock.response.set_cookies {
+        if let Ok(value) = HeaderValue::from_str(cookie) {
+            response = response.header(axum::http::header::SET_COOKIE, value);
+        }
     }
 
-    Some(
-        response
-            .body(Body::from(body_bytes_out))
-            .unwrap_or_else(|_| StatusCode::INTERNAL_SERVER_ERROR.into_response()),
-    )
+    response
+        .body(body)
+        .unwrap_or_else(|_| StatusCode::INTERNAL_SERVER_ERROR.into_response())
 }
 
 /// Axum fallback handler for the main router: tries to serve a dynamic mock,
@@ -1220,9 +1891,24 @@ pub async fn dynamic_mock_fallback(
     let path = req.uri().path().to_string();
     let query = req.uri().query().unwrap_or_default().to_string();
 
+    // Buffer the body so proxy and echo fallbacks still have it once the
+    // dynamic mocks have seen the request
+    let (parts, body) = req.into_parts();
+    let headers: std::collections::HashMap<String, String> = parts
+        .headers
+        .iter()
+        .filter_map(|(k, v)| v.to_str().ok().map(|v| (k.as_str().to_string(), v.to_string())))
+        .collect();
+    let Ok(body) = axum::body::to_bytes(body, 1024 * 1024).await else {
+        return StatusCode::PAYLOAD_TOO_LARGE.into_response();
+    };
+    let req = Request::from_parts(parts, Body::from(body.clone()));
+
     match serve_dynamic_mock(&state, req).await {
         Some(resp) => resp,
         None => {
+            let fallback = fallbacks::find_fallback(&state.fallbacks.read().await, &path)
+                .map(|f| f.strategy.clone());
             // Issue #79 round 14 — shadow mode returns 200 for unknown
             // paths (instead of 404) while still recording them, so a
             // proxy replay flows through non-blocking.
@@ -1240,11 +1926,29 @@ pub async fn dynamic_mock_fallback(
                 Some(bp) => path_in_base(&path, bp),
                 None => true, // no base path configured — shadow applies to everything
             };
-            let shadow = shadow_enabled && in_base_path;
-            let status = if shadow {
-                StatusCode::OK
-            } else {
-                StatusCode::NOT_FOUND
+            let shadow =
+                shadow_enabled && in_base_path && !strict_stubbing_enabled() && fallback.is_none();
+            let mut response = match &fallback {
+                Some(strategy) => {
+                    let request = mock_proxy::ProxiedRequest {
+                        method: &method,
+                        path: &path,
+                        query: Some(query.as_str()).filter(|q| !q.is_empty()),
+                        headers: &headers,
+                        body: &body,
+                    };
+                    fallbacks::fallback_response(&state, strategy, request).await
+                }
+                // Minimal JSON stub so clients expecting a body don't
+                // choke. Shadow mode is for traffic-replay observability,
+                // not realistic response shapes.
+                None if shadow => (
+                    StatusCode::OK,
+                    [(http::header::CONTENT_TYPE, "application/json")],
+                    r#"{"shadow":true,"matched":false}"#,
+                )
+                    .into_response(),
+                None => StatusCode::NOT_FOUND.into_response(),
             };
             mockforge_foundation::unknown_paths::record(
                 mockforge_foundation::unknown_paths::UnknownPathRequest {
@@ -1253,26 +1957,77 @@ pub async fn dynamic_mock_fallback(
                     path,
                     client_ip: "unknown".to_string(),
                     query,
-                    status: status.as_u16(),
+                    status: response.status().as_u16(),
                 },
             );
-            if shadow {
-                // Minimal JSON stub so clients expecting a body don't
-                // choke. Shadow mode is for traffic-replay observability,
-                // not realistic response shapes.
-                (
-                    StatusCode::OK,
-                    [(http::header::CONTENT_TYPE, "application/json")],
-                    r#"{"shadow":true,"matched":false}"#,
-                )
-                    .into_response()
-            } else {
-                StatusCode::NOT_FOUND.into_response()
-            }
+            response.extensions_mut().insert(UnmatchedRequest);
+            response
         }
     }
 }
 
+/// Management state of the server's HTTP router, published so routers
+/// merged into it later can serve its dynamic mocks too
+static SERVER_STATE: std::sync::OnceLock<ManagementState> = std::sync::OnceLock::new();
+
+/// Publish the management state. The first router built in the process is
+/// the server's; later calls are ignored.
+pub fn publish_server_state(state: ManagementState) {
+    let _ = SERVER_STATE.set(state);
+}
+
+/// The server's management state, if its router has been built
+pub fn server_state() -> Option<ManagementState> {
+    SERVER_STATE.get().cloned()
+}
+
+/// Middleware serving dynamic mocks ahead of the router's own routes, so a
+/// mock registered for a path the OpenAPI spec also serves overrides the
+/// spec's response. Requests no mock matches continue to the router, whose
+/// fallback applies fallback strategies and 404s. MockForge's own APIs are
+/// never shadowed by a mock.
+pub async fn dynamic_mock_override(
+    State(state): State<ManagementState>,
+    req: Request<Body>,
+    next: axum::middleware::Next,
+) -> Response {
+    let path = req.uri().path();
+    let own_api = ["/__mockforge", "/api/verification", "/api/chaos"].iter().any(|prefix| {
+        path.strip_prefix(prefix)
+            .is_some_and(|rest| rest.is_empty() || rest.starts_with('/'))
+    });
+    // Only requests a mock could serve are buffered, so large uploads to
+    // the spec's routes are left alone
+    let candidate = !own_api
+        && state.mocks.read().await.iter().any(|mock| {
+            mock.enabled
+                && mock.method.eq_ignore_ascii_case(req.method().as_str())
+                && mock_path_params(mock, path).is_some()
+        });
+    if !candidate {
+        return next.run(req).await;
+    }
+
+    // Buffer the body so the router still has it when no mock matches
+    let (parts, body) = req.into_parts();
+    let Ok(body) = axum::body::to_bytes(body, 1024 * 1024).await else {
+        return StatusCode::PAYLOAD_TOO_LARGE.into_response();
+    };
+    let mut probe = Request::new(Body::from(body.clone()));
+    *probe.method_mut() = parts.method.clone();
+    *probe.uri_mut() = parts.uri.clone();
+    *probe.version_mut() = parts.version;
+    *probe.headers_mut() = parts.headers.clone();
+    if let Some(control) = parts.extensions.get::<mockforge_chaos::ConnectionControl>() {
+        probe.extensions_mut().insert(control.clone());
+    }
+
+    match serve_dynamic_mock(&state, probe).await {
+        Some(response) => response,
+        None => next.run(Request::from_parts(parts, Body::from(body))).await,
+    }
+}
+
 /// Round 20 — is `path` under `base_path` for shadow-mode purposes?
 ///
 /// Matches at the **segment** boundary, so `/api123/foo` does not match
@@ -1334,6 +2089,26 @@ mod tests {
         assert_eq!(s.base_path.as_deref(), None);
     }
 
+    #[tokio::test]
+    async fn test_list_grpc_services_sorted() {
+        use mockforge_core::request_logger::{set_global_grpc_services, GlobalGrpcServiceInfo};
+
+        set_global_grpc_services(vec![
+            GlobalGrpcServiceInfo {
+                name: "shop.v1.Orders".to_string(),
+                methods: Vec::new(),
+            },
+            GlobalGrpcServiceInfo {
+                name: "grpc.health.v1.Health".to_string(),
+                methods: Vec::new(),
+            },
+        ]);
+
+        let axum::Json(body) = protocols::list_grpc_services().await;
+        assert_eq!(body["services"][0]["name"], "grpc.health.v1.Health");
+        assert_eq!(body["services"][1]["name"], "shop.v1.Orders");
+    }
+
     #[tokio::test]
     async fn test_create_and_get_mock() {
         let state = ManagementState::new(None, None, 3000);
@@ -1345,7 +2120,7 @@ mod tests {
             path: "/test".to_string(),
             response: MockResponse {
                 body: serde_json::json!({"message": "test"}),
-                headers: None,
+                ..Default::default()
             },
             enabled: true,
             latency_ms: None,
@@ -1355,6 +2130,7 @@ mod tests {
             scenario: None,
             required_scenario_state: None,
             new_scenario_state: None,
+            ..Default::default()
         };
 
         // Create mock
@@ -1384,7 +2160,7 @@ mod tests {
                 path: "/test1".to_string(),
                 response: MockResponse {
                     body: serde_json::json!({}),
-                    headers: None,
+                    ..Default::default()
                 },
                 enabled: true,
                 latency_ms: None,
@@ -1394,6 +2170,7 @@ mod tests {
                 scenario: None,
                 required_scenario_state: None,
                 new_scenario_state: None,
+                ..Default::default()
             });
             mocks.push(MockConfig {
                 id: "2".to_string(),
@@ -1402,7 +2179,7 @@ mod tests {
                 path: "/test2".to_string(),
                 response: MockResponse {
                     body: serde_json::json!({}),
-                    headers: None,
+                    ..Default::default()
                 },
                 enabled: false,
                 latency_ms: None,
@@ -1412,6 +2189,7 @@ mod tests {
                 scenario: None,
                 required_scenario_state: None,
                 new_scenario_state: None,
+                ..Default::default()
             });
         }
 
@@ -1420,6 +2198,944 @@ mod tests {
         assert_eq!(mocks.iter().filter(|m| m.enabled).count(), 1);
     }
 
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_enforces_max_matches_and_expiry() {
+        let state = ManagementState::new(None, None, 3000);
+        {
+            let mut mocks = state.mocks.write().await;
+            for (id, max_matches, expires_after_ms) in [
+                ("once", Some(1), None),
+                ("expired", None, Some(0)),
+                ("live", None, Some(60_000)),
+            ] {
+                mocks.push(MockConfig {
+                    id: id.to_string(),
+                    method: "GET".to_string(),
+                    path: format!("/{id}"),
+                    enabled: true,
+                    max_matches,
+                    expires_after_ms,
+                    ..Default::default()
+                });
+            }
+        }
+        let serve = |path: &str| {
+            let req = Request::builder().uri(path).body(Body::empty()).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        assert!(serve("/once").await.is_some());
+        assert!(serve("/once").await.is_none(), "max_matches should disable the mock");
+        assert!(serve("/expired").await.is_none());
+        assert!(serve("/live").await.is_some());
+        assert!(serve("/live").await.is_some());
+
+        // Limits are not persisted with the mock's configuration
+        let json = serde_json::to_value(&state.mocks.read().await[0]).unwrap();
+        assert_eq!(json["max_matches"], 1);
+        assert!(json.get("runtime").is_none());
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_response_sequence() {
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "flaky".to_string(),
+            method: "GET".to_string(),
+            path: "/flaky".to_string(),
+            enabled: true,
+            response_sequence: vec![
+                SequencedResponse {
+                    status_code: Some(503),
+                    body: serde_json::json!({ "error": "unavailable" }),
+                    ..Default::default()
+                },
+                SequencedResponse {
+                    status_code: Some(200),
+                    body: serde_json::json!({ "ok": true }),
+                    headers: Some([("X-Attempt".to_string(), "2".to_string())].into()),
+                    ..Default::default()
+                },
+            ],
+            ..Default::default()
+        });
+        let serve = || {
+            let req = Request::builder().uri("/flaky").body(Body::empty()).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        let first = serve().await.unwrap();
+        assert_eq!(first.status(), StatusCode::SERVICE_UNAVAILABLE);
+        let body = axum::body::to_bytes(first.into_body(), 1024).await.unwrap();
+        assert_eq!(body.as_ref(), br#"{"error":"unavailable"}"#);
+        for _ in 0..2 {
+            let repeated = serve().await.unwrap();
+            assert_eq!(repeated.status(), StatusCode::OK);
+            assert_eq!(repeated.headers()["x-attempt"], "2");
+        }
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_sends_callbacks() {
+        let (tx, mut rx) = tokio::sync::mpsc::unbounded_channel();
+        let receiver = axum::Router::new().route(
+            "/hooks",
+            axum::routing::post(
+                move |headers: axum::http::HeaderMap,
+                      axum::Json(body): axum::Json<serde_json::Value>| {
+                    let tx = tx.clone();
+                    async move {
+                        let _ = tx.send((headers, body));
+                        StatusCode::NO_CONTENT
+                    }
+                },
+            ),
+        );
+        let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+        let receiver_addr = listener.local_addr().unwrap();
+        tokio::spawn(async move { axum::serve(listener, receiver).await });
+
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "jobs".to_string(),
+            method: "POST".to_string(),
+            path: "/jobs/{id}".to_string(),
+            enabled: true,
+            status_code: Some(202),
+            callbacks: vec![MockCallback {
+                url: format!("http://{}/hooks", receiver_addr),
+                headers: [("X-Job".to_string(), "{{request.path.id}}".to_string())].into(),
+                body: Some(serde_json::json!({ "status": "{{request.body.status}}" })),
+                delay_ms: 10,
+                ..Default::default()
+            }],
+            ..Default::default()
+        });
+
+        let req = Request::builder()
+            .method("POST")
+            .uri("/jobs/42")
+            .body(Body::from(r#"{"status":"done"}"#))
+            .unwrap();
+        let response = serve_dynamic_mock(&state, req).await.unwrap();
+        assert_eq!(response.status(), StatusCode::ACCEPTED);
+
+        let (headers, body) = tokio::time::timeout(std::time::Duration::from_secs(5), rx.recv())
+            .await
+            .unwrap()
+            .unwrap();
+        assert_eq!(headers["x-job"], "42");
+        assert_eq!(body, serde_json::json!({ "status": "done" }));
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_proxies_and_records() {
+        let upstream = axum::Router::new().route(
+            "/users/{id}",
+            axum::routing::get(|uri: axum::http::Uri| async move {
+                axum::Json(serde_json::json!({ "from": "upstream", "uri": uri.to_string() }))
+            }),
+        );
+        let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+        let upstream_addr = listener.local_addr().unwrap();
+        tokio::spawn(async move { axum::serve(listener, upstream).await });
+
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "users".to_string(),
+            method: "GET".to_string(),
+            path: "/users/{id}".to_string(),
+            enabled: true,
+            proxy: Some(MockProxy {
+                upstream_url: format!("http://{}/", upstream_addr),
+                record: true,
+            }),
+            ..Default::default()
+        });
+
+        let req = Request::builder().uri("/users/7?full=1").body(Body::empty()).unwrap();
+        let response = serve_dynamic_mock(&state, req).await.unwrap();
+        assert_eq!(response.status(), StatusCode::OK);
+        let body = axum::body::to_bytes(response.into_body(), 1024).await.unwrap();
+        let body: serde_json::Value = serde_json::from_slice(&body).unwrap();
+        assert_eq!(body, serde_json::json!({ "from": "upstream", "uri": "/users/7?full=1" }));
+
+        let mocks = state.mocks.read().await;
+        let recorded = mocks.iter().find(|m| m.id != "users").expect("recorded mock");
+        assert_eq!(recorded.path, "/users/7");
+        assert_eq!(recorded.priority, Some(1));
+        assert_eq!(recorded.response.body, body);
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_enforces_request_schema() {
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "orders".to_string(),
+            method: "POST".to_string(),
+            path: "/orders".to_string(),
+            enabled: true,
+            request_schema: Some(serde_json::json!({
+                "type": "object",
+                "required": ["sku"],
+                "properties": { "sku": { "type": "string" } }
+            })),
+            ..Default::default()
+        });
+        let serve = |body: &'static str| {
+            let req =
+                Request::builder().method("POST").uri("/orders").body(Body::from(body)).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        assert_eq!(serve(r#"{"sku":"A-1"}"#).await.unwrap().status(), StatusCode::OK);
+        let rejected = serve(r#"{"sku":1}"#).await.unwrap();
+        assert_eq!(rejected.status(), StatusCode::BAD_REQUEST);
+        let body = axum::body::to_bytes(rejected.into_body(), 4096).await.unwrap();
+        let body: serde_json::Value = serde_json::from_slice(&body).unwrap();
+        assert_eq!(body["error"], "request validation failed");
+        assert_eq!(serve("not json").await.unwrap().status(), StatusCode::BAD_REQUEST);
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_rejected_request_keeps_its_match() {
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "once".to_string(),
+            method: "POST".to_string(),
+            path: "/once".to_string(),
+            enabled: true,
+            max_matches: Some(1),
+            request_schema: Some(serde_json::json!({ "type": "object", "required": ["sku"] })),
+            ..Default::default()
+        });
+        let serve = |body: &'static str| {
+            let req =
+                Request::builder().method("POST").uri("/once").body(Body::from(body)).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        assert_eq!(serve("{}").await.unwrap().status(), StatusCode::BAD_REQUEST);
+        assert_eq!(serve(r#"{"sku":"A-1"}"#).await.unwrap().status(), StatusCode::OK);
+        assert!(serve(r#"{"sku":"A-1"}"#).await.is_none(), "the one match is used up");
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_rate_limit() {
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "limited".to_string(),
+            method: "GET".to_string(),
+            path: "/limited".to_string(),
+            enabled: true,
+            rate_limit: Some(MockRateLimit {
+                limit: 2,
+                window_ms: 60_000,
+            }),
+            ..Default::default()
+        });
+        let serve = || {
+            let req = Request::builder().uri("/limited").body(Body::empty()).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        assert_eq!(serve().await.unwrap().status(), StatusCode::OK);
+        assert_eq!(serve().await.unwrap().status(), StatusCode::OK);
+        let limited = serve().await.unwrap();
+        assert_eq!(limited.status(), StatusCode::TOO_MANY_REQUESTS);
+        assert_eq!(limited.headers()["retry-after"], "60");
+        assert_eq!(limited.headers()["x-ratelimit-limit"], "2");
+        assert_eq!(limited.headers()["x-ratelimit-remaining"], "0");
+        assert!(limited.extensions().get::<MatchedMockId>().is_some());
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_rate_limit_has_no_side_effects() {
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "limited".to_string(),
+            method: "POST".to_string(),
+            path: "/limited".to_string(),
+            enabled: true,
+            max_matches: Some(2),
+            scenario: Some("quota".to_string()),
+            new_scenario_state: Some("used".to_string()),
+            rate_limit: Some(MockRateLimit {
+                limit: 1,
+                window_ms: 60_000,
+            }),
+            ..Default::default()
+        });
+        let serve = || {
+            let req =
+                Request::builder().method("POST").uri("/limited").body(Body::empty()).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        assert_eq!(serve().await.unwrap().status(), StatusCode::OK);
+        state.scenario_states.write().await.clear();
+        assert_eq!(serve().await.unwrap().status(), StatusCode::TOO_MANY_REQUESTS);
+        let mock = &state.mocks.read().await[0];
+        assert_eq!(mock.runtime.matches.load(Ordering::SeqCst), 1);
+        assert!(state.scenario_states.read().await.is_empty());
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_captures_path_params() {
+        let state = ManagementState::new(None, None, 3000);
+        for mock in [
+            serde_json::json!({
+                "method": "GET",
+                "path": "/users/{id}",
+                "response": {"body": {"id": "{{request.path.id}}"}}
+            }),
+            serde_json::json!({
+                "method": "GET",
+                "path": r"^/files/(?P<name>[a-z]+)\.txt$",
+                "path_match": "regex",
+                "response": {"body": ["{{request.path.name}}"]}
+            }),
+        ] {
+            state.mocks.write().await.push(serde_json::from_value(mock).unwrap());
+        }
+        let serve = |path: &str| {
+            let req = Request::builder().uri(path).body(Body::empty()).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+        let body = |response: Response| async move {
+            axum::body::to_bytes(response.into_body(), 1024).await.unwrap()
+        };
+
+        let response = serve("/users/42").await.unwrap();
+        assert_eq!(body(response).await.as_ref(), br#"{"id":"42"}"#);
+        let response = serve("/files/report.txt").await.unwrap();
+        assert_eq!(body(response).await.as_ref(), br#"["report"]"#);
+        assert!(serve("/files/Report.txt").await.is_none());
+        assert!(serve("/files/report.txt/raw").await.is_none());
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_applies_transformers_in_order() {
+        let state = ManagementState::new(None, None, 3000);
+        for transformer in [
+            serde_json::json!({
+                "name": "tag",
+                "set_headers": {"X-Request-Id": "{{request.header.x-request-id}}"},
+                "body_patch": [{"op": "add", "path": "/tagged", "value": true}]
+            }),
+            serde_json::json!({"name": "created", "status_code": 201}),
+        ] {
+            let transformer: ResponseTransformer = serde_json::from_value(transformer).unwrap();
+            state.transformers.write().await.insert(transformer.name.clone(), transformer);
+        }
+        state.mocks.write().await.push(
+            serde_json::from_value(serde_json::json!({
+                "method": "POST",
+                "path": "/orders",
+                "response": {"body": {"id": 1}},
+                "transformers": ["tag", "undefined", "created"]
+            }))
+            .unwrap(),
+        );
+
+        let req = Request::builder()
+            .method("POST")
+            .uri("/orders")
+            .header("X-Request-Id", "req-7")
+            .body(Body::empty())
+            .unwrap();
+        let response = serve_dynamic_mock(&state, req).await.unwrap();
+        assert_eq!(response.status(), StatusCode::CREATED);
+        assert_eq!(response.headers()["x-request-id"], "req-7");
+        assert!(response.extensions().get::<MatchedMockId>().is_some());
+        let body = axum::body::to_bytes(response.into_body(), 1024).await.unwrap();
+        assert_eq!(body.as_ref(), br#"{"id":1,"tagged":true}"#);
+    }
+
+    #[tokio::test]
+    async fn test_create_mock_compiles_regex_path_once() {
+        let state = ManagementState::new(None, None, 3000);
+        let mock = |path: &str| MockConfig {
+            method: "GET".to_string(),
+            path: path.to_string(),
+            path_match: Some(PathMatch::Regex),
+            ..Default::default()
+        };
+
+        let result = mocks::create_mock(State(state.clone()), axum::Json(mock("^/files/("))).await;
+        assert_eq!(result.unwrap_err(), StatusCode::BAD_REQUEST);
+        assert!(state.mocks.read().await.is_empty());
+
+        let created = mock(r"^/files/(?P<name>\w+)$");
+        mocks::create_mock(State(state.clone()), axum::Json(created)).await.unwrap();
+        let stored = state.mocks.read().await[0].clone();
+        assert!(matches!(stored.runtime.path_regex.get(), Some(Some(_))));
+        let params = mock_path_params(&stored, "/files/report").unwrap();
+        assert_eq!(params["name"], "report");
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_scenario_states() {
+        let state = ManagementState::new(None, None, 3000);
+        {
+            let mut mocks = state.mocks.write().await;
+            for (id, required, new_state, status) in [
+                ("create", "Started", "created", 201),
+                ("pay", "created", "paid", 200),
+                ("refund", "paid", "refunded", 202),
+            ] {
+                mocks.push(MockConfig {
+                    id: id.to_string(),
+                    method: "POST".to_string(),
+                    path: "/orders".to_string(),
+                    enabled: true,
+                    status_code: Some(status),
+                    scenario: Some("checkout".to_string()),
+                    required_scenario_state: Some(required.to_string()),
+                    new_scenario_state: Some(new_state.to_string()),
+                    ..Default::default()
+                });
+            }
+        }
+        let serve = || {
+            let req = Request::builder().method("POST").uri("/orders").body(Body::empty()).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        assert_eq!(serve().await.unwrap().status(), StatusCode::CREATED);
+        assert_eq!(serve().await.unwrap().status(), StatusCode::OK);
+        assert_eq!(serve().await.unwrap().status(), StatusCode::ACCEPTED);
+        assert!(serve().await.is_none(), "no mock requires the refunded state");
+
+        let axum::Json(listed) = scenarios::list_scenario_states(State(state.clone())).await;
+        assert_eq!(
+            listed["scenarios"],
+            serde_json::json!([{"name": "checkout", "state": "refunded"}])
+        );
+
+        scenarios::reset_scenario_states(State(state.clone())).await;
+        assert_eq!(serve().await.unwrap().status(), StatusCode::CREATED);
+
+        let request = scenarios::SetScenarioStateRequest {
+            state: "paid".to_string(),
+        };
+        let path = axum::extract::Path("checkout".to_string());
+        scenarios::set_scenario_state(State(state.clone()), path, axum::Json(request)).await;
+        assert_eq!(serve().await.unwrap().status(), StatusCode::ACCEPTED);
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_rejected_request_keeps_scenario_state() {
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "create".to_string(),
+            method: "POST".to_string(),
+            path: "/orders".to_string(),
+            enabled: true,
+            scenario: Some("checkout".to_string()),
+            required_scenario_state: Some("Started".to_string()),
+            new_scenario_state: Some("created".to_string()),
+            request_schema: Some(serde_json::json!({ "type": "object", "required": ["sku"] })),
+            ..Default::default()
+        });
+        let serve = |body: &'static str| {
+            let req =
+                Request::builder().method("POST").uri("/orders").body(Body::from(body)).unwrap();
+            serve_dynamic_mock(&state, req)
+        };
+
+        assert_eq!(serve("{}").await.unwrap().status(), StatusCode::BAD_REQUEST);
+        assert!(state.scenario_states.read().await.is_empty());
+        assert_eq!(serve(r#"{"sku":"A-1"}"#).await.unwrap().status(), StatusCode::OK);
+        assert_eq!(state.scenario_states.read().await["checkout"], "created");
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_without_scenarios_skips_scenario_lock() {
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(MockConfig {
+            id: "plain".to_string(),
+            method: "GET".to_string(),
+            path: "/plain".to_string(),
+            enabled: true,
+            ..Default::default()
+        });
+
+        // Served while another task holds the scenario lock
+        let _held = state.scenario_states.write().await;
+        let req = Request::builder().uri("/plain").body(Body::empty()).unwrap();
+        let served = tokio::time::timeout(
+            std::time::Duration::from_secs(1),
+            serve_dynamic_mock(&state, req),
+        )
+        .await
+        .expect("scenario lock not taken");
+        assert_eq!(served.unwrap().status(), StatusCode::OK);
+    }
+
+    #[tokio::test]
+    async fn test_delete_mock_group_keeps_other_mocks() {
+        let state = ManagementState::new(None, None, 3000);
+        for (id, group) in [
+            ("shared", None),
+            ("cart", Some("checkout")),
+            ("pay", Some("checkout")),
+        ] {
+            state.mocks.write().await.push(MockConfig {
+                id: id.to_string(),
+                method: "GET".to_string(),
+                path: format!("/{}", id),
+                group: group.map(str::to_string),
+                ..Default::default()
+            });
+        }
+
+        let path = axum::extract::Path("checkout".to_string());
+        let axum::Json(deleted) = mocks::delete_mock_group(State(state.clone()), path).await;
+        assert_eq!(deleted["deleted"], 2);
+        let remaining: Vec<String> =
+            state.mocks.read().await.iter().map(|m| m.id.clone()).collect();
+        assert_eq!(remaining, vec!["shared".to_string()]);
+    }
+
+    #[tokio::test]
+    async fn test_create_mocks_bulk_is_all_or_nothing() {
+        let state = ManagementState::new(None, None, 3000);
+        let mock = |id: &str| MockConfig {
+            id: id.to_string(),
+            method: "GET".to_string(),
+            path: format!("/{}", id),
+            ..Default::default()
+        };
+
+        let batch = axum::Json(vec![mock("a"), mock("")]);
+        let (status, axum::Json(created)) =
+            mocks::create_mocks_bulk(State(state.clone()), batch).await.unwrap();
+        assert_eq!(status, StatusCode::CREATED);
+        assert_eq!(created.len(), 2);
+        assert_eq!(created[0].id, "a");
+        assert!(!created[1].id.is_empty(), "expected a generated ID");
+        let stored: Vec<String> = state.mocks.read().await.iter().map(|m| m.id.clone()).collect();
+        assert_eq!(stored, vec!["a".to_string(), created[1].id.clone()]);
+
+        // A duplicate within the batch stores none of it
+        let batch = axum::Json(vec![mock("b"), mock("b")]);
+        let result = mocks::create_mocks_bulk(State(state.clone()), batch).await;
+        assert_eq!(result.unwrap_err(), StatusCode::CONFLICT);
+        assert_eq!(state.mocks.read().await.len(), 2);
+
+        // So does a clash with an existing mock, even after valid entries
+        let batch = axum::Json(vec![mock("c"), mock("a")]);
+        let result = mocks::create_mocks_bulk(State(state.clone()), batch).await;
+        assert_eq!(result.unwrap_err(), StatusCode::CONFLICT);
+        assert_eq!(state.mocks.read().await.len(), 2);
+
+        let (status, axum::Json(created)) =
+            mocks::create_mocks_bulk(State(state.clone()), axum::Json(vec![]))
+                .await
+                .unwrap();
+        assert_eq!(status, StatusCode::CREATED);
+        assert!(created.is_empty());
+        assert_eq!(state.mocks.read().await.len(), 2);
+    }
+
+    #[tokio::test]
+    async fn test_dynamic_mock_fallback_uses_most_specific_prefix() {
+        let state = ManagementState::new(None, None, 3000);
+        for fallback in [
+            serde_json::json!({
+                "prefix": "/",
+                "strategy": "respond",
+                "response": {"status_code": 418, "response": {"body": {"error": "teapot"}}}
+            }),
+            serde_json::json!({"prefix": "/api/legacy", "strategy": "not_found"}),
+        ] {
+            let fallback: MockFallback = serde_json::from_value(fallback).unwrap();
+            fallbacks::set_fallback(State(state.clone()), axum::Json(fallback)).await;
+        }
+        let serve = |path: &str| {
+            let req = Request::builder().uri(path).body(Body::empty()).unwrap();
+            dynamic_mock_fallback(State(state.clone()), req)
+        };
+
+        let response = serve("/orders").await;
+        assert_eq!(response.status(), StatusCode::IM_A_TEAPOT);
+        assert!(response.extensions().get::<UnmatchedRequest>().is_some());
+        let body = axum::body::to_bytes(response.into_body(), 1024).await.unwrap();
+        assert_eq!(body.as_ref(), br#"{"error":"teapot"}"#);
+
+        assert_eq!(serve("/api/legacy/users").await.status(), StatusCode::NOT_FOUND);
+        assert_eq!(serve("/api/legacyx").await.status(), StatusCode::IM_A_TEAPOT);
+
+        fallbacks::clear_fallbacks(State(state.clone()), axum::extract::Query(Default::default()))
+            .await;
+        assert_eq!(serve("/orders").await.status(), StatusCode::NOT_FOUND);
+    }
+
+    #[tokio::test]
+    async fn test_dynamic_mock_fallback_proxies_generates_and_echoes() {
+        let upstream = axum::Router::new().route(
+            "/legacy/{id}",
+            axum::routing::post(|uri: axum::http::Uri, body: String| async move {
+                axum::Json(serde_json::json!({ "uri": uri.to_string(), "body": body }))
+            }),
+        );
+        let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+        let upstream_addr = listener.local_addr().unwrap();
+        tokio::spawn(async move { axum::serve(listener, upstream).await });
+
+        let spec = OpenApiSpec::from_json(serde_json::json!({
+            "openapi": "3.0.0",
+            "info": {"title": "Users", "version": "1"},
+            "paths": {"/users/{id}": {"get": {"responses": {
+                "404": {"description": "missing"},
+                "200": {"description": "user", "content": {"application/json": {
+                    "example": {"id": 1, "name": "Ada"}
+                }}}
+            }}}}
+        }))
+        .unwrap();
+        let state = ManagementState::new(Some(Arc::new(spec)), None, 3000);
+        for fallback in [
+            serde_json::json!({"prefix": "/", "strategy": "echo"}),
+            serde_json::json!({
+                "prefix": "/legacy",
+                "strategy": "proxy",
+                "upstream_url": format!("http://{}", upstream_addr)
+            }),
+            serde_json::json!({"prefix": "/users", "strategy": "from_spec"}),
+        ] {
+            let fallback: MockFallback = serde_json::from_value(fallback).unwrap();
+            let response = fallbacks::set_fallback(State(state.clone()), axum::Json(fallback))
+                .await
+                .into_response();
+            assert_eq!(response.status(), StatusCode::OK);
+        }
+        let serve = |method: &str, path: &str, body: &'static str| {
+            let req = Request::builder().method(method).uri(path).body(Body::from(body)).unwrap();
+            dynamic_mock_fallback(State(state.clone()), req)
+        };
+        let json = |response: Response| async move {
+            let body = axum::body::to_bytes(response.into_body(), 4096).await.unwrap();
+            serde_json::from_slice::<serde_json::Value>(&body).unwrap()
+        };
+
+        let response = serve("POST", "/legacy/7?v=1", "payload").await;
+        assert_eq!(response.status(), StatusCode::OK);
+        assert_eq!(
+            json(response).await,
+            serde_json::json!({ "uri": "/legacy/7?v=1", "body": "payload" })
+        );
+
+        let response = serve("GET", "/users/42", "").await;
+        assert_eq!(response.status(), StatusCode::OK);
+        assert_eq!(json(response).await["name"], "Ada");
+        assert_eq!(serve("DELETE", "/users/42", "").await.status(), StatusCode::NOT_FOUND);
+
+        let response = serve("PUT", "/orders?dry=1", r#"{"qty":2}"#).await;
+        let echoed = json(response).await;
+        assert_eq!(echoed["method"], "PUT");
+        assert_eq!(echoed["path"], "/orders");
+        assert_eq!(echoed["query"], "dry=1");
+        assert_eq!(echoed["body"], serde_json::json!({ "qty": 2 }));
+
+        // A recording proxy saves the upstream response as a mock, which
+        // serves the next such request without the upstream
+        let fallback: MockFallback = serde_json::from_value(serde_json::json!({
+            "prefix": "/legacy",
+            "strategy": "proxy",
+            "upstream_url": format!("http://{}", upstream_addr),
+            "record": true
+        }))
+        .unwrap();
+        fallbacks::set_fallback(State(state.clone()), axum::Json(fallback)).await;
+        assert_eq!(serve("POST", "/legacy/8", "recorded").await.status(), StatusCode::OK);
+        let recorded = state.mocks.read().await.clone();
+        assert_eq!(recorded.len(), 1);
+        assert_eq!((recorded[0].method.as_str(), recorded[0].path.as_str()), ("POST", "/legacy/8"));
+        let query = fallbacks::ClearFallbacksQuery {
+            prefix: Some("/legacy/".to_string()),
+        };
+        fallbacks::clear_fallbacks(State(state.clone()), axum::extract::Query(query)).await;
+        let response = serve("POST", "/legacy/8", "replayed").await;
+        assert_eq!(json(response).await["body"], "recorded");
+        // Clearing one prefix leaves the others in place
+        let response = serve("POST", "/legacy/9", "").await;
+        assert_eq!(json(response).await["path"], "/legacy/9");
+
+        // Fallbacks that cannot work are rejected when they are set
+        let specless = ManagementState::new(None, None, 3000);
+        for fallback in [
+            serde_json::json!({"prefix": "/", "strategy": "from_spec"}),
+            serde_json::json!({"prefix": "/", "strategy": "proxy", "upstream_url": "ftp://x"}),
+        ] {
+            let fallback: MockFallback = serde_json::from_value(fallback).unwrap();
+            let response = fallbacks::set_fallback(State(specless.clone()), axum::Json(fallback))
+                .await
+                .into_response();
+            assert_eq!(response.status(), StatusCode::BAD_REQUEST);
+        }
+    }
+
+    #[tokio::test]
+    async fn test_dynamic_mock_override_takes_precedence_over_routes() {
+        use tower::ServiceExt;
+
+        let state = ManagementState::new(None, None, 3000);
+        state.mocks.write().await.push(
+            serde_json::from_value(serde_json::json!({
+                "method": "GET",
+                "path": "/users/42",
+                "response": {"body": {"source": "mock"}}
+            }))
+            .unwrap(),
+        );
+        let app = Router::new()
+            .route("/users/{id}", get(|| async { "spec" }))
+            .route("/__mockforge/api/health", get(|| async { "admin" }))
+            .layer(axum::middleware::from_fn_with_state(state.clone(), dynamic_mock_override));
+        let serve = |path: &str| {
+            let req = Request::builder().uri(path).body(Body::empty()).unwrap();
+            let app = app.clone();
+            async move {
+                let response = app.oneshot(req).await.unwrap();
+                axum::body::to_bytes(response.into_body(), 1024).await.unwrap()
+            }
+        };
+
+        assert_eq!(serve("/users/42").await.as_ref(), br#"{"source":"mock"}"#);
+        assert_eq!(serve("/users/7").await.as_ref(), b"spec");
+        assert_eq!(serve("/__mockforge/api/health").await.as_ref(), b"admin");
+    }
+
+    #[tokio::test]
+    async fn test_mock_response_serves_binary_and_file_bodies_and_cookies() {
+        let file = tempfile::NamedTempFile::new().unwrap();
+        std::fs::write(file.path(), b"report,1\n").unwrap();
+        let body_of = |response: serde_json::Value| MockConfig {
+            response: serde_json::from_value(response).unwrap(),
+            ..Default::default()
+        };
+
+        let response =
+            mock_response(&body_of(serde_json::json!({"body_base64": "iVBORw0KGgo="}))).await;
+        assert_eq!(response.headers()["content-type"], "application/octet-stream");
+        let body = axum::body::to_bytes(response.into_body(), 1024).await.unwrap();
+        assert_eq!(body.as_ref(), b"\x89PNG\r\n\x1a\n");
+
+        let response = mock_response(&body_of(serde_json::json!({
+            "body_file": file.path(),
+            "headers": {"Content-Type": "text/csv"},
+            "set_cookies": ["session=abc; HttpOnly", "theme=dark"]
+        })))
+        .await;
+        assert_eq!(response.headers()["content-type"], "text/csv");
+        let cookies: Vec<_> = response.headers().get_all("set-cookie").iter().collect();
+        assert_eq!(cookies, ["session=abc; HttpOnly", "theme=dark"]);
+        let body = axum::body::to_bytes(response.into_body(), 1024).await.unwrap();
+        assert_eq!(body.as_ref(), b"report,1\n");
+
+        let missing = mock_response(&body_of(serde_json::json!({"body_file": "/no/such/file"})));
+        assert_eq!(missing.await.status(), StatusCode::INTERNAL_SERVER_ERROR);
+    }
+
+    #[tokio::test]
+    async fn test_mock_response_streams_chunks() {
+        use futures::StreamExt;
+
+        let mock = |keep_open: bool| MockConfig {
+            response: serde_json::from_value(serde_json::json!({
+                "chunks": [
+                    {"data_base64": "ZGF0YTogMQoK"},
+                    {"data_base64": "ZGF0YTogMgoK", "delay_ms": 100}
+                ],
+                "keep_open": keep_open
+            }))
+            .unwrap(),
+            ..Default::default()
+        };
+
+        let started = std::time::Instant::now();
+        let response = mock_response(&mock(false)).await;
+        assert_eq!(response.headers()["content-type"], "application/octet-stream");
+        let body = axum::body::to_bytes(response.into_body(), 1024).await.unwrap();
+        assert_eq!(body.as_ref(), b"data: 1\n\ndata: 2\n\n");
+        assert!(started.elapsed() >= std::time::Duration::from_millis(100));
+
+        let mut chunks = mock_response(&mock(true)).await.into_body().into_data_stream();
+        assert_eq!(chunks.next().await.unwrap().unwrap().as_ref(), b"data: 1\n\n");
+        assert_eq!(chunks.next().await.unwrap().unwrap().as_ref(), b"data: 2\n\n");
+        let held = tokio::time::timeout(std::time::Duration::from_millis(100), chunks.next());
+        assert!(held.await.is_err(), "keep_open should not end the body");
+    }
+
+    #[test]
+    fn test_mock_latency_samples_its_distribution() {
+        use std::time::Duration;
+
+        let latency =
+            |spec: serde_json::Value| -> MockLatency { serde_json::from_value(spec).unwrap() };
+
+        let fixed = latency(serde_json::json!({"distribution": "fixed", "fixed_ms": 25}));
+        assert_eq!(fixed.sample(), Duration::from_millis(25));
+
+        // Zero parameters are omitted by the SDKs
+        let uniform = latency(serde_json::json!({"distribution": "uniform", "max_ms": 10}));
+        let normal =
+            latency(serde_json::json!({"distribution": "normal", "mean_ms": 50, "std_dev_ms": 80}));
+        let lognormal = latency(
+            serde_json::json!({"distribution": "lognormal", "mean_ms": 50, "std_dev_ms": 20}),
+        );
+        let percentiles = latency(serde_json::json!({
+            "distribution": "percentiles", "p50_ms": 10, "p95_ms": 50, "p99_ms": 100
+        }));
+        for _ in 0..1000 {
+            assert!(uniform.sample() <= Duration::from_millis(10));
+            assert!(lognormal.sample() > Duration::ZERO);
+            assert!(percentiles.sample() <= Duration::from_millis(150));
+        }
+
+        let clamped = (0..1000).filter(|_| normal.sample().is_zero()).count();
+        assert!(clamped > 0, "negative draws should clamp to zero");
+
+        let mut samples: Vec<_> = (0..2000).map(|_| percentiles.sample()).collect();
+        samples.sort();
+        let median = samples[samples.len() / 2];
+        assert!(median > Duration::from_millis(7) && median < Duration::from_millis(13));
+    }
+
+    #[tokio::test]
+    async fn test_serve_dynamic_mock_rewrites_the_connection() {
+        use base64::Engine;
+        use tokio::io::{AsyncReadExt, AsyncWriteExt};
+
+        let state = ManagementState::new(None, None, 3000);
+        for (path, fault) in [
+            ("/reset", MockFault::ConnectionReset),
+            ("/empty", MockFault::EmptyResponse),
+            ("/malformed", MockFault::MalformedChunk),
+        ] {
+            state.mocks.write().await.push(MockConfig {
+                id: path.to_string(),
+                method: "GET".to_string(),
+                path: path.to_string(),
+                enabled: true,
+                response: MockResponse {
+                    fault: Some(fault),
+                    ..Default::default()
+                },
+                ..Default::default()
+            });
+        }
+        let raw = b"HTTP/1.1 299 Whatever\r\nX-B: 1\r\nX-A: 2\r\n\r\nhi";
+        state.mocks.write().await.push(MockConfig {
+            id: "raw".to_string(),
+            method: "GET".to_string(),
+            path: "/raw".to_string(),
+            enabled: true,
+            response: MockResponse {
+                raw_base64: Some(base64::engine::general_purpose::STANDARD.encode(raw)),
+                ..Default::default()
+            },
+            ..Default::default()
+        });
+        let app = axum::Router::new().fallback(
+            move |axum::extract::ConnectInfo(info): axum::extract::ConnectInfo<
+                mockforge_chaos::FaultConnectInfo,
+            >,
+                  mut req: Request<Body>| {
+                let state = state.clone();
+                async move {
+                    req.extensions_mut().insert(info.control);
+                    serve_dynamic_mock(&state, req).await.unwrap()
+                }
+            },
+        );
+        let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+        let addr = listener.local_addr().unwrap();
+        let make_svc =
+            app.into_make_service_with_connect_info::<mockforge_chaos::FaultConnectInfo>();
+        tokio::spawn(async move {
+            axum::serve(mockforge_chaos::FaultTcpListener::new(listener), make_svc).await
+        });
+
+        let get = |path: &'static str| async move {
+            let mut stream = tokio::net::TcpStream::connect(addr).await?;
+            let request = format!("GET {} HTTP/1.1\r\nHost: test\r\n\r\n", path);
+            stream.write_all(request.as_bytes()).await?;
+            let mut response = Vec::new();
+            stream.read_to_end(&mut response).await?;
+            Ok::<_, std::io::Error>(response)
+        };
+
+        let reset = get("/reset").await.unwrap_err();
+        assert_eq!(reset.kind(), std::io::ErrorKind::ConnectionReset);
+        assert!(get("/empty").await.unwrap().is_empty());
+        let malformed = String::from_utf8(get("/malformed").await.unwrap()).unwrap();
+        assert!(malformed.starts_with("HTTP/1.1 200 OK\r\n"), "got {:?}", malformed);
+        assert!(malformed.contains("transfer-encoding: chunked\r\n\r\nzz\r\n"));
+        assert_eq!(get("/raw").await.unwrap(), raw);
+    }
+
+    #[tokio::test]
+    async fn test_mock_response_throttles_body() {
+        let mock = MockConfig {
+            response: serde_json::from_value(serde_json::json!({
+                "body_base64": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+                "throttle_bytes_per_second": 100
+            }))
+            .unwrap(),
+            ..Default::default()
+        };
+
+        let started = std::time::Instant::now();
+        let response = mock_response(&mock).await;
+        let body = axum::body::to_bytes(response.into_body(), 1024).await.unwrap();
+        assert_eq!(body.len(), 42);
+        // 42 bytes in 10-byte slices a tenth of a second apart
+        let elapsed = started.elapsed();
+        assert!(elapsed >= std::time::Duration::from_millis(400), "took {:?}", elapsed);
+    }
+
+    #[tokio::test]
+    async fn test_mock_response_encodes_body() {
+        use std::io::Read;
+
+        let body = br#"{"report":"quarterly"}"#;
+        let encoded = |encoding: &str| async move {
+            let mock = MockConfig {
+                response: serde_json::from_value(serde_json::json!({
+                    "body": {"report": "quarterly"},
+                    "content_encoding": encoding
+                }))
+                .unwrap(),
+                ..Default::default()
+            };
+            let response = mock_response(&mock).await;
+            assert_eq!(response.headers()["content-encoding"], encoding);
+            assert_eq!(response.headers()["content-type"], "application/json");
+            axum::body::to_bytes(response.into_body(), 1024).await.unwrap()
+        };
+
+        let mut decoded = Vec::new();
+        flate2::read::GzDecoder::new(encoded("gzip").await.as_ref())
+            .read_to_end(&mut decoded)
+            .unwrap();
+        assert_eq!(decoded, body);
+
+        decoded.clear();
+        flate2::read::ZlibDecoder::new(encoded("deflate").await.as_ref())
+            .read_to_end(&mut decoded)
+            .unwrap();
+        assert_eq!(decoded, body);
+
+        decoded.clear();
+        brotli::Decompressor::new(encoded("br").await.as_ref(), 4096)
+            .read_to_end(&mut decoded)
+            .unwrap();
+        assert_eq!(decoded, body);
+    }
+
     #[test]
     fn test_mock_matches_request_with_xpath_absolute_path() {
         let mock = MockConfig {
@@ -1429,7 +3145,7 @@ mod tests {
             path: "/xml".to_string(),
             response: MockResponse {
                 body: serde_json::json!({"ok": true}),
-                headers: None,
+                ..Default::default()
             },
             enabled: true,
             latency_ms: None,
@@ -1442,6 +3158,7 @@ mod tests {
             scenario: None,
             required_scenario_state: None,
             new_scenario_state: None,
+            ..Default::default()
         };
 
         let body = br#"<root><order><id>123</id></order></root>"#;
@@ -1451,6 +3168,16 @@ mod tests {
         assert!(mock_matches_request(&mock, "POST", "/xml", &headers, &query, Some(body)));
     }
 
+    #[test]
+    fn test_xml_xpath_matches_text() {
+        let body =
+            r#"<s:Envelope xmlns:s="urn:s"><s:Body><Get><id>42</id></Get></s:Body></s:Envelope>"#;
+
+        assert!(xml_xpath_matches(body, "//Get/id", Some("42")));
+        assert!(!xml_xpath_matches(body, "//Get/id", Some("43")));
+        assert!(xml_xpath_matches(body, "/Envelope/Body/Get", None));
+    }
+
     #[test]
     fn test_mock_matches_request_with_xpath_text_predicate() {
         let mock = MockConfig {
@@ -1460,7 +3187,7 @@ mod tests {
             path: "/xml".to_string(),
             response: MockResponse {
                 body: serde_json::json!({"ok": true}),
-                headers: None,
+                ..Default::default()
             },
             enabled: true,
             latency_ms: None,
@@ -1473,6 +3200,7 @@ mod tests {
             scenario: None,
             required_scenario_state: None,
             new_scenario_state: None,
+            ..Default::default()
         };
 
         let body = br#"<root><order><id>123</id></order></root>"#;
@@ -1482,6 +3210,76 @@ mod tests {
         assert!(mock_matches_request(&mock, "POST", "/xml", &headers, &query, Some(body)));
     }
 
+    #[test]
+    fn test_mock_matches_request_with_body_json_and_json_paths() {
+        // Criteria as the SDKs send them, without headers or query params
+        let mock: MockConfig = serde_json::from_value(serde_json::json!({
+            "method": "POST",
+            "path": "/orders",
+            "response": {"body": {"ok": true}},
+            "request_match": {"body_json": {"type": "refund", "amount": 10}}
+        }))
+        .unwrap();
+        let headers = std::collections::HashMap::new();
+        let query = std::collections::HashMap::new();
+        let matches = |body: &[u8]| {
+            mock_matches_request(&mock, "POST", "/orders", &headers, &query, Some(body))
+        };
+
+        assert!(matches(br#"{"amount": 10.0, "type": "refund"}"#));
+        let body = br#"{"type":"refund","amount":10,"items":[{"sku":"B2"},{"sku":"A1"}]}"#;
+        assert!(!matches(body), "body_json requires the whole body to be equal");
+        assert!(!matches(b""));
+
+        let mock: MockConfig = serde_json::from_value(serde_json::json!({
+            "method": "POST",
+            "path": "/orders",
+            "response": {"body": {}},
+            "request_match": {"json_paths": {"$.type": "refund", "$.items[*].sku": "A1"}}
+        }))
+        .unwrap();
+        let matches = |body: &[u8]| {
+            mock_matches_request(&mock, "POST", "/orders", &headers, &query, Some(body))
+        };
+        assert!(matches(body));
+        assert!(!matches(br#"{"type": "refund", "items": [{"sku": "B2"}]}"#));
+        assert!(!matches(br#"{"type": "sale", "items": [{"sku": "A1"}]}"#));
+        assert!(!matches(b"not json"));
+    }
+
+    #[test]
+    fn test_mock_matches_request_with_cookies() {
+        let mock: MockConfig = serde_json::from_value(serde_json::json!({
+            "method": "GET",
+            "path": "/account",
+            "response": {"body": {}},
+            "request_match": {"cookies": {"session": "abc123"}}
+        }))
+        .unwrap();
+        let query = std::collections::HashMap::new();
+        let matches = |cookie: Option<&str>| {
+            let headers = cookie
+                .map(|c| std::collections::HashMap::from([("cookie".to_string(), c.to_string())]))
+                .unwrap_or_default();
+            mock_matches_request(&mock, "GET", "/account", &headers, &query, None)
+        };
+
+        assert!(matches(Some("theme=dark; session=abc123")));
+        assert!(matches(Some(r#"session="abc123""#)));
+        assert!(!matches(Some("session=other")));
+        assert!(!matches(Some("theme=dark")));
+        assert!(!matches(None));
+    }
+
+    #[test]
+    fn test_json_values_equal_compares_numbers_by_value() {
+        use serde_json::json;
+
+        assert!(json_values_equal(&json!({"a": [1, 2.0]}), &json!({"a": [1.0, 2]})));
+        assert!(!json_values_equal(&json!({"a": 1, "b": 2}), &json!({"a": 1})));
+        assert!(!json_values_equal(&json!("1"), &json!(1)));
+    }
+
     #[test]
     fn test_mock_matches_request_with_xpath_no_match() {
         let mock = MockConfig {
@@ -1491,7 +3289,7 @@ mod tests {
             path: "/xml".to_string(),
             response: MockResponse {
                 body: serde_json::json!({"ok": true}),
-                headers: None,
+                ..Default::default()
             },
             enabled: true,
             latency_ms: None,
@@ -1504,6 +3302,7 @@ mod tests {
             scenario: None,
             required_scenario_state: None,
             new_scenario_state: None,
+            ..Default::default()
         };
 
         let body = br#"<root><order><id>123</id></order></root>"#;
@@ -1,5 +1,6 @@
 #[cfg(any(
     feature = "smtp",
+    feature = "ftp",
     feature = "mqtt",
     feature = "kafka",
     feature = "amqp"
@@ -16,8 +17,10 @@ use axum::{
 };
 #[cfg(any(feature = "mqtt", feature = "kafka"))]
 use futures::stream::{self, Stream};
+#[cfg(any(feature = "mqtt", feature = "kafka", feature = "amqp", feature = "ws"))]
+use serde::Deserialize;
 #[cfg(any(feature = "mqtt", feature = "kafka", feature = "amqp"))]
-use serde::{Deserialize, Serialize};
+use serde::Serialize;
 #[cfg(any(feature = "mqtt", feature = "kafka"))]
 use std::convert::Infallible;
 #[cfg(any(feature = "mqtt", feature = "kafka"))]
@@ -31,6 +34,15 @@ use super::MessageEvent;
 #[cfg(feature = "mqtt")]
 use super::MqttMessageEvent;
 
+// ========== gRPC Handlers ==========
+
+/// List the services the gRPC server exposes, sorted by name
+pub(crate) async fn list_grpc_services() -> Json<serde_json::Value> {
+    let mut services = mockforge_core::request_logger::get_global_grpc_services();
+    services.sort_by(|a, b| a.name.cmp(&b.name));
+    Json(serde_json::json!({ "services": services }))
+}
+
 // ========== SMTP Handlers ==========
 
 #[cfg(feature = "smtp")]
@@ -187,6 +199,293 @@ pub(crate) async fn search_smtp_emails(
     }
 }
 
+// ========== FTP Handlers ==========
+
+#[cfg(feature = "ftp")]
+use mockforge_ftp::{FileContent, FileMetadata, VirtualFile};
+
+#[cfg(feature = "ftp")]
+fn ftp_not_available() -> axum::response::Response {
+    (
+        StatusCode::NOT_IMPLEMENTED,
+        Json(serde_json::json!({
+            "error": "FTP file management not available",
+            "message": "FTP server is not enabled or registry not available."
+        })),
+    )
+        .into_response()
+}
+
+/// The absolute virtual file system path for a `{*path}` capture
+#[cfg(feature = "ftp")]
+fn ftp_path(path: &str) -> std::path::PathBuf {
+    std::path::PathBuf::from(format!("/{}", path.trim_start_matches('/')))
+}
+
+/// List the files in the FTP server's virtual file system
+#[cfg(feature = "ftp")]
+pub(crate) async fn list_ftp_files(
+    State(state): State<ManagementState>,
+) -> axum::response::Response {
+    let Some(ref ftp_registry) = state.ftp_registry else {
+        return ftp_not_available();
+    };
+    let mut files = ftp_registry.vfs.list_files_async(std::path::Path::new("/")).await;
+    files.sort_by(|a, b| a.path.cmp(&b.path));
+    let files: Vec<_> = files
+        .iter()
+        .map(|file| {
+            // Template and generated files only know their size once rendered
+            let size = file
+                .render_content()
+                .map(|data| data.len() as u64)
+                .unwrap_or(file.metadata.size);
+            serde_json::json!({
+                "path": file.path,
+                "size": size,
+                "modified_at": file.modified_at,
+            })
+        })
+        .collect();
+    (StatusCode::OK, Json(serde_json::json!(files))).into_response()
+}
+
+/// Read a file from the FTP server's virtual file system
+#[cfg(feature = "ftp")]
+pub(crate) async fn get_ftp_file(
+    State(state): State<ManagementState>,
+    Path(path): Path<String>,
+) -> axum::response::Response {
+    let Some(ref ftp_registry) = state.ftp_registry else {
+        return ftp_not_available();
+    };
+    let path = ftp_path(&path);
+    match ftp_registry.vfs.get_file_async(&path).await.map(|file| file.render_content()) {
+        Some(Ok(data)) => (
+            StatusCode::OK,
+            [(axum::http::header::CONTENT_TYPE, "application/octet-stream")],
+            data,
+        )
+            .into_response(),
+        Some(Err(e)) => (
+            StatusCode::INTERNAL_SERVER_ERROR,
+            Json(serde_json::json!({
+                "error": "Failed to render file",
+                "message": e.to_string()
+            })),
+        )
+            .into_response(),
+        None => (
+            StatusCode::NOT_FOUND,
+            Json(serde_json::json!({
+                "error": "File not found",
+                "message": format!("No file at {}", path.display())
+            })),
+        )
+            .into_response(),
+    }
+}
+
+/// Create or replace a file in the FTP server's virtual file system. Its
+/// parent directories are created, so clients can change into them.
+#[cfg(feature = "ftp")]
+pub(crate) async fn put_ftp_file(
+    State(state): State<ManagementState>,
+    Path(path): Path<String>,
+    body: axum::body::Bytes,
+) -> axum::response::Response {
+    let Some(ref ftp_registry) = state.ftp_registry else {
+        return ftp_not_available();
+    };
+    let path = ftp_path(&path);
+    let size = body.len() as u64;
+    let file = VirtualFile::new(
+        path.clone(),
+        FileContent::Static(body.to_vec()),
+        FileMetadata {
+            size,
+            ..Default::default()
+        },
+    );
+    ftp_registry.vfs.create_parent_directories_async(&path).await;
+    match ftp_registry.vfs.add_file_async(path.clone(), file).await {
+        Ok(()) => (StatusCode::OK, Json(serde_json::json!({ "path": path, "size": size })))
+            .into_response(),
+        Err(e) => (
+            StatusCode::INTERNAL_SERVER_ERROR,
+            Json(serde_json::json!({
+                "error": "Failed to store file",
+                "message": e.to_string()
+            })),
+        )
+            .into_response(),
+    }
+}
+
+/// Delete a file from the FTP server's virtual file system
+#[cfg(feature = "ftp")]
+pub(crate) async fn delete_ftp_file(
+    State(state): State<ManagementState>,
+    Path(path): Path<String>,
+) -> axum::response::Response {
+    let Some(ref ftp_registry) = state.ftp_registry else {
+        return ftp_not_available();
+    };
+    let path = ftp_path(&path);
+    if ftp_registry.vfs.get_file_async(&path).await.is_none() {
+        return (
+            StatusCode::NOT_FOUND,
+            Json(serde_json::json!({
+                "error": "File not found",
+                "message": format!("No file at {}", path.display())
+            })),
+        )
+            .into_response();
+    }
+    match ftp_registry.vfs.remove_file_async(&path).await {
+        Ok(()) => (
+            StatusCode::OK,
+            Json(serde_json::json!({ "message": "File deleted successfully" })),
+        )
+            .into_response(),
+        Err(e) => (
+            StatusCode::INTERNAL_SERVER_ERROR,
+            Json(serde_json::json!({
+                "error": "Failed to delete file",
+                "message": e.to_string()
+            })),
+        )
+            .into_response(),
+    }
+}
+
+/// Create a directory, and its parents, in the FTP server's virtual file
+/// system
+#[cfg(feature = "ftp")]
+pub(crate) async fn create_ftp_dir(
+    State(state): State<ManagementState>,
+    Path(path): Path<String>,
+) -> axum::response::Response {
+    let Some(ref ftp_registry) = state.ftp_registry else {
+        return ftp_not_available();
+    };
+    let path = ftp_path(&path);
+    ftp_registry.vfs.create_parent_directories_async(&path).await;
+    match ftp_registry.vfs.create_directory_async(path.clone()).await {
+        Ok(()) => (StatusCode::OK, Json(serde_json::json!({ "path": path }))).into_response(),
+        Err(e) => (
+            StatusCode::INTERNAL_SERVER_ERROR,
+            Json(serde_json::json!({
+                "error": "Failed to create directory",
+                "message": e.to_string()
+            })),
+        )
+            .into_response(),
+    }
+}
+
+/// List the uploads FTP clients made, oldest first
+#[cfg(feature = "ftp")]
+pub(crate) async fn list_ftp_uploads(
+    State(state): State<ManagementState>,
+) -> axum::response::Response {
+    let Some(ref ftp_registry) = state.ftp_registry else {
+        return ftp_not_available();
+    };
+    (StatusCode::OK, Json(serde_json::json!(ftp_registry.get_uploads()))).into_response()
+}
+
+/// Forget the recorded FTP uploads. Uploaded files stay in the file system.
+#[cfg(feature = "ftp")]
+pub(crate) async fn clear_ftp_uploads(
+    State(state): State<ManagementState>,
+) -> axum::response::Response {
+    let Some(ref ftp_registry) = state.ftp_registry else {
+        return ftp_not_available();
+    };
+    ftp_registry.clear_uploads();
+    (
+        StatusCode::OK,
+        Json(serde_json::json!({ "message": "Uploads cleared successfully" })),
+    )
+        .into_response()
+}
+
+// ========== WebSocket Script Handlers ==========
+
+/// A script to run for the clients of a WebSocket path, in the replay
+/// file's entry format
+#[cfg(feature = "ws")]
+#[derive(Debug, Deserialize)]
+pub struct WsScriptRequest {
+    /// Path the script is served on, `/ws` or below it
+    pub path: String,
+    /// Replay entries, run in order for each client
+    pub entries: Vec<serde_json::Value>,
+}
+
+#[cfg(feature = "ws")]
+fn ws_scripts_not_available() -> axum::response::Response {
+    (
+        StatusCode::NOT_IMPLEMENTED,
+        Json(serde_json::json!({
+            "error": "WebSocket scripts not available",
+            "message": "WebSocket server is not enabled or script registry not available."
+        })),
+    )
+        .into_response()
+}
+
+/// List the paths with a registered WebSocket script
+#[cfg(feature = "ws")]
+pub(crate) async fn list_ws_scripts(
+    State(state): State<ManagementState>,
+) -> axum::response::Response {
+    let Some(ref ws_scripts) = state.ws_scripts else {
+        return ws_scripts_not_available();
+    };
+    let scripts: Vec<_> = ws_scripts
+        .list()
+        .into_iter()
+        .map(|(path, steps)| serde_json::json!({ "path": path, "steps": steps }))
+        .collect();
+    (StatusCode::OK, Json(serde_json::json!(scripts))).into_response()
+}
+
+/// Register the script of a WebSocket path. Each path runs one script, so a
+/// second registration for a path is rejected.
+#[cfg(feature = "ws")]
+pub(crate) async fn register_ws_script(
+    State(state): State<ManagementState>,
+    Json(request): Json<WsScriptRequest>,
+) -> axum::response::Response {
+    let Some(ref ws_scripts) = state.ws_scripts else {
+        return ws_scripts_not_available();
+    };
+    let steps = request.entries.len();
+    match ws_scripts.register(&request.path, request.entries) {
+        Ok(()) => (
+            StatusCode::CREATED,
+            Json(serde_json::json!({ "path": request.path, "steps": steps })),
+        )
+            .into_response(),
+        Err(e) => {
+            let status = match e {
+                mockforge_ws::ScriptError::AlreadyRegistered(_) => StatusCode::CONFLICT,
+                _ => StatusCode::BAD_REQUEST,
+            };
+            (
+                status,
+                Json(serde_json::json!({
+                    "error": "Failed to register WebSocket script",
+                    "message": e.to_string()
+                })),
+            )
+                .into_response()
+        }
+    }
+}
+
 // ========== MQTT Handlers ==========
 
 /// MQTT broker statistics
@@ -250,6 +549,61 @@ pub(crate) async fn get_mqtt_topics(State(state): State<ManagementState>) -> imp
     }
 }
 
+#[cfg(feature = "mqtt")]
+pub(crate) async fn get_mqtt_subscriptions(
+    State(state): State<ManagementState>,
+) -> impl IntoResponse {
+    if let Some(sessions) = &state.mqtt_sessions {
+        let subscriptions: Vec<serde_json::Value> = sessions
+            .subscriptions()
+            .await
+            .into_iter()
+            .map(|(client_id, topic_filter, qos)| {
+                serde_json::json!({
+                    "client_id": client_id,
+                    "topic_filter": topic_filter,
+                    "qos": qos
+                })
+            })
+            .collect();
+        Json(serde_json::json!({
+            "subscriptions": subscriptions
+        }))
+        .into_response()
+    } else {
+        (StatusCode::SERVICE_UNAVAILABLE, "MQTT broker not available").into_response()
+    }
+}
+
+#[cfg(feature = "mqtt")]
+pub(crate) async fn get_mqtt_messages(State(state): State<ManagementState>) -> impl IntoResponse {
+    use base64::Engine;
+
+    if let Some(sessions) = &state.mqtt_sessions {
+        let messages: Vec<serde_json::Value> = sessions
+            .published_messages()
+            .await
+            .into_iter()
+            .map(|message| {
+                serde_json::json!({
+                    "publisher_id": message.publisher_id,
+                    "topic": message.topic,
+                    "payload": base64::engine::general_purpose::STANDARD.encode(&message.payload),
+                    "qos": message.qos,
+                    "retain": message.retain,
+                    "timestamp": message.timestamp
+                })
+            })
+            .collect();
+        Json(serde_json::json!({
+            "messages": messages
+        }))
+        .into_response()
+    } else {
+        (StatusCode::SERVICE_UNAVAILABLE, "MQTT broker not available").into_response()
+    }
+}
+
 #[cfg(feature = "mqtt")]
 pub(crate) async fn disconnect_mqtt_client(
     State(state): State<ManagementState>,
@@ -741,6 +1095,61 @@ pub(crate) async fn get_kafka_topic(
     }
 }
 
+#[cfg(feature = "kafka")]
+/// List the records retained in a Kafka topic, across partitions
+pub(crate) async fn get_kafka_topic_messages(
+    State(state): State<ManagementState>,
+    Path(topic_name): Path<String>,
+) -> impl IntoResponse {
+    use base64::Engine;
+
+    if let Some(broker) = &state.kafka_broker {
+        let topics = broker.topics.read().await;
+        if let Some(topic) = topics.get(&topic_name) {
+            let mut messages: Vec<serde_json::Value> = Vec::new();
+            for (idx, partition) in topic.partitions.iter().enumerate() {
+                for message in &partition.messages {
+                    messages.push(serde_json::json!({
+                        "partition": idx as i32,
+                        "offset": message.offset,
+                        "timestamp": message.timestamp,
+                        "key": message.key.as_ref().map(|k| String::from_utf8_lossy(k).into_owned()),
+                        "value": base64::engine::general_purpose::STANDARD.encode(&message.value),
+                        "headers": message.headers.iter().map(|(k, v)| {
+                            (k.clone(), serde_json::Value::String(String::from_utf8_lossy(v).into_owned()))
+                        }).collect::<serde_json::Map<_, _>>(),
+                    }));
+                }
+            }
+            messages.sort_by_key(|m| m["timestamp"].as_i64().unwrap_or_default());
+
+            Json(serde_json::json!({
+                "topic": topic_name,
+                "messages": messages
+            }))
+            .into_response()
+        } else {
+            (
+                StatusCode::NOT_FOUND,
+                Json(serde_json::json!({
+                    "error": "Topic not found",
+                    "topic": topic_name
+                })),
+            )
+                .into_response()
+        }
+    } else {
+        (
+            StatusCode::SERVICE_UNAVAILABLE,
+            Json(serde_json::json!({
+                "error": "Kafka broker not available",
+                "message": "Kafka broker is not enabled or not available."
+            })),
+        )
+            .into_response()
+    }
+}
+
 #[cfg(feature = "kafka")]
 /// List Kafka consumer groups
 pub(crate) async fn get_kafka_groups(State(state): State<ManagementState>) -> impl IntoResponse {
@@ -1260,6 +1669,8 @@ mod amqp {
         pub routing_key: String,
         #[serde(default)]
         pub payload: String,
+        #[serde(default)]
+        pub headers: std::collections::HashMap<String, String>,
     }
 
     pub(crate) fn exchange_type_str(t: &ExchangeType) -> &'static str {
@@ -1282,9 +1693,16 @@ mod amqp {
     }
 
     /// Build a `Message` for an admin-initiated publish.
-    pub(crate) fn build_message(routing_key: String, payload: String) -> Message {
+    pub(crate) fn build_message(
+        routing_key: String,
+        payload: String,
+        headers: std::collections::HashMap<String, String>,
+    ) -> Message {
         Message {
-            properties: Default::default(),
+            properties: mockforge_amqp::messages::MessageProperties {
+                headers,
+                ..Default::default()
+            },
             body: payload.into_bytes(),
             routing_key,
         }
@@ -1468,7 +1886,7 @@ pub(crate) async fn publish_amqp_message(
     let Some(broker) = &state.amqp_broker else {
         return (StatusCode::SERVICE_UNAVAILABLE, "AMQP broker not available").into_response();
     };
-    let message = build_message(req.routing_key.clone(), req.payload.clone());
+    let message = build_message(req.routing_key.clone(), req.payload.clone(), req.headers.clone());
 
     // Resolve target queues exactly as the connection handler does: the
     // default ("") exchange routes straight to the queue named by the
@@ -1512,6 +1930,96 @@ pub(crate) async fn publish_amqp_message(
     .into_response()
 }
 
+#[cfg(feature = "amqp")]
+pub(crate) async fn get_amqp_published(State(state): State<ManagementState>) -> impl IntoResponse {
+    use base64::Engine;
+
+    let Some(broker) = &state.amqp_broker else {
+        return (StatusCode::SERVICE_UNAVAILABLE, "AMQP broker not available").into_response();
+    };
+    let messages: Vec<serde_json::Value> = broker
+        .exchanges
+        .read()
+        .await
+        .published_messages()
+        .into_iter()
+        .map(|published| {
+            serde_json::json!({
+                "exchange": published.exchange,
+                "routing_key": published.routing_key,
+                "headers": published.message.properties.headers,
+                "content_type": published.message.properties.content_type,
+                "payload": base64::engine::general_purpose::STANDARD.encode(&published.message.body),
+                "timestamp": published.timestamp,
+            })
+        })
+        .collect();
+    Json(serde_json::json!({ "messages": messages })).into_response()
+}
+
+#[cfg(all(test, feature = "mqtt"))]
+mod mqtt_tests {
+    use super::{get_mqtt_messages, get_mqtt_subscriptions};
+    use crate::management::ManagementState;
+    use axum::{extract::State, http::StatusCode, response::IntoResponse};
+    use mockforge_mqtt::{ProtocolQoS, SessionManager};
+    use std::sync::Arc;
+
+    async fn json_body(response: axum::response::Response) -> serde_json::Value {
+        let bytes = axum::body::to_bytes(response.into_body(), 1 << 20).await.unwrap();
+        serde_json::from_slice(&bytes).unwrap()
+    }
+
+    #[tokio::test]
+    async fn messages_and_subscriptions_need_the_broker() {
+        let state = ManagementState::new(None, None, 3000);
+        let response = get_mqtt_messages(State(state.clone())).await.into_response();
+        assert_eq!(response.status(), StatusCode::SERVICE_UNAVAILABLE);
+        let response = get_mqtt_subscriptions(State(state)).await.into_response();
+        assert_eq!(response.status(), StatusCode::SERVICE_UNAVAILABLE);
+    }
+
+    #[tokio::test]
+    async fn messages_report_the_publish_journal() {
+        let sessions = Arc::new(SessionManager::new(10, None));
+        sessions
+            .publish_raw(
+                "device-1",
+                "sensors/temp",
+                b"21.5".to_vec(),
+                ProtocolQoS::AtLeastOnce,
+                true,
+            )
+            .await;
+        let state = ManagementState::new(None, None, 3000).with_mqtt_sessions(sessions);
+
+        let body = json_body(get_mqtt_messages(State(state)).await.into_response()).await;
+        let message = &body["messages"][0];
+        assert_eq!(message["publisher_id"], "device-1");
+        assert_eq!(message["topic"], "sensors/temp");
+        assert_eq!(message["payload"], "MjEuNQ==");
+        assert_eq!(message["qos"], 1);
+        assert_eq!(message["retain"], true);
+    }
+
+    #[tokio::test]
+    async fn subscriptions_report_connected_clients() {
+        let sessions = Arc::new(SessionManager::new(10, None));
+        let (tx, _rx) = tokio::sync::mpsc::channel(10);
+        sessions.connect("sub".to_string(), true, 60, tx, None).await.unwrap();
+        sessions
+            .subscribe("sub", vec![("sensors/#".to_string(), ProtocolQoS::AtLeastOnce)])
+            .await;
+        let state = ManagementState::new(None, None, 3000).with_mqtt_sessions(sessions);
+
+        let body = json_body(get_mqtt_subscriptions(State(state)).await.into_response()).await;
+        assert_eq!(
+            body["subscriptions"],
+            serde_json::json!([{ "client_id": "sub", "topic_filter": "sensors/#", "qos": 1 }])
+        );
+    }
+}
+
 #[cfg(all(test, feature = "amqp"))]
 mod amqp_tests {
     use super::amqp::{build_message, exchange_type_str, parse_exchange_type};
@@ -1546,7 +2054,7 @@ mod amqp_tests {
             Binding::new("orders".into(), "q.orders".into(), "order.created".into()),
         ));
 
-        let msg = build_message("order.created".into(), "{\"id\":1}".into());
+        let msg = build_message("order.created".into(), "{\"id\":1}".into(), Default::default());
         let targets =
             exchanges.get_exchange("orders").unwrap().route_message(&msg, "order.created");
         assert_eq!(targets, vec!["q.orders".to_string()]);
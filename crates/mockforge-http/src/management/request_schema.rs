@@ -0,0 +1,79 @@
+use axum::{
+    http::StatusCode,
+    response::{IntoResponse, Response},
+};
+
+use super::MockConfig;
+
+/// What happens to requests whose body violates a mock's request schema,
+/// set like OpenAPI validation by `MOCKFORGE_REQUEST_VALIDATION`
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum SchemaValidation {
+    Off,
+    Warn,
+    Enforce,
+}
+
+impl SchemaValidation {
+    fn from_env() -> Self {
+        match std::env::var("MOCKFORGE_REQUEST_VALIDATION")
+            .unwrap_or_else(|_| "enforce".into())
+            .to_ascii_lowercase()
+            .as_str()
+        {
+            "off" | "disable" | "disabled" => Self::Off,
+            "warn" | "warning" => Self::Warn,
+            _ => Self::Enforce,
+        }
+    }
+}
+
+/// Validate a request body against the mock's request schema. Returns the
+/// 400 response to send instead of the mock's when validation is enforced
+/// and the body does not conform.
+pub(crate) fn check_request_schema(
+    mock: &MockConfig,
+    method: &str,
+    path: &str,
+    body: &[u8],
+) -> Option<Response> {
+    let schema = mock.request_schema.as_ref()?;
+    let mode = SchemaValidation::from_env();
+    if mode == SchemaValidation::Off {
+        return None;
+    }
+    let validator = match jsonschema::validator_for(schema) {
+        Ok(validator) => validator,
+        Err(e) => {
+            tracing::warn!("Mock {} has an invalid request_schema: {}", mock.id, e);
+            return None;
+        }
+    };
+
+    let errors: Vec<String> = match serde_json::from_slice::<serde_json::Value>(body) {
+        Ok(instance) => validator.iter_errors(&instance).map(|e| e.to_string()).collect(),
+        Err(e) => vec![format!("request body is not valid JSON: {}", e)],
+    };
+    if errors.is_empty() {
+        return None;
+    }
+    tracing::warn!(
+        "{} {} violates the request_schema of mock {}: {:?}",
+        method,
+        path,
+        mock.id,
+        errors
+    );
+    if mode == SchemaValidation::Warn {
+        return None;
+    }
+
+    let payload = serde_json::json!({
+        "error": "request validation failed",
+        "detail": errors,
+        "method": method,
+        "path": path,
+        "timestamp": chrono::Utc::now().to_rfc3339(),
+    });
+    Some((StatusCode::BAD_REQUEST, axum::Json(payload)).into_response())
+}
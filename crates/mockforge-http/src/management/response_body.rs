@@ -0,0 +1,156 @@
+use axum::body::{Body, Bytes};
+use base64::Engine;
+use futures::stream::{self, StreamExt};
+use serde::{Deserialize, Serialize};
+use std::convert::Infallible;
+use std::io::Write;
+use std::time::Duration;
+
+use super::MockResponse;
+
+/// One write of a streamed response body
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct MockChunk {
+    /// Chunk bytes, base64-encoded
+    pub data_base64: String,
+    /// How long to wait before writing the chunk, in milliseconds
+    #[serde(default)]
+    pub delay_ms: u64,
+}
+
+/// Compression applied to a mock's body, announced with `Content-Encoding`
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+pub enum ContentEncoding {
+    /// gzip
+    #[serde(rename = "gzip")]
+    Gzip,
+    /// zlib-wrapped deflate
+    #[serde(rename = "deflate")]
+    Deflate,
+    /// Brotli
+    #[serde(rename = "br")]
+    Brotli,
+}
+
+impl ContentEncoding {
+    /// The `Content-Encoding` header value
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::Gzip => "gzip",
+            Self::Deflate => "deflate",
+            Self::Brotli => "br",
+        }
+    }
+
+    /// Compress data
+    fn encode(self, data: &[u8]) -> std::io::Result<Vec<u8>> {
+        match self {
+            Self::Gzip => {
+                let mut encoder =
+                    flate2::write::GzEncoder::new(Vec::new(), flate2::Compression::default());
+                encoder.write_all(data)?;
+                encoder.finish()
+            }
+            Self::Deflate => {
+                let mut encoder =
+                    flate2::write::ZlibEncoder::new(Vec::new(), flate2::Compression::default());
+                encoder.write_all(data)?;
+                encoder.finish()
+            }
+            Self::Brotli => {
+                let mut encoder = brotli::CompressorWriter::new(Vec::new(), 4096, 5, 22);
+                encoder.write_all(data)?;
+                Ok(encoder.into_inner())
+            }
+        }
+    }
+}
+
+impl MockResponse {
+    /// Whether the body is bytes rather than the JSON `body`
+    pub(crate) fn is_binary(&self) -> bool {
+        self.body_base64.is_some() || self.body_file.is_some() || !self.chunks.is_empty()
+    }
+}
+
+/// Build the body of a mock response: its chunks, binary or file-backed
+/// bytes, or `json` when it has neither, compressed with its content encoding
+pub(crate) async fn mock_body(
+    response: &MockResponse,
+    json: serde_json::Value,
+) -> Result<Body, String> {
+    if !response.chunks.is_empty() {
+        if response.content_encoding.is_some() || response.throttle_bytes_per_second.is_some() {
+            return Err("chunked bodies cannot have a content_encoding or throttle".to_string());
+        }
+        return chunked_body(&response.chunks, response.keep_open);
+    }
+
+    let data = if let Some(encoded) = &response.body_base64 {
+        decode(encoded, "body_base64")?
+    } else if let Some(path) = &response.body_file {
+        tokio::fs::read(path)
+            .await
+            .map_err(|e| format!("failed to read body_file {}: {}", path, e))?
+    } else {
+        serde_json::to_vec(&json).unwrap_or_default()
+    };
+    let data = match response.content_encoding {
+        Some(encoding) => encoding
+            .encode(&data)
+            .map_err(|e| format!("failed to encode the body as {}: {}", encoding.as_str(), e))?,
+        None => data,
+    };
+    Ok(match response.throttle_bytes_per_second {
+        Some(bytes_per_second) if bytes_per_second > 0 => throttled_body(data, bytes_per_second),
+        _ => Body::from(data),
+    })
+}
+
+/// A body written at `bytes_per_second`, in slices of a tenth of a second's
+/// worth of bytes
+fn throttled_body(data: Vec<u8>, bytes_per_second: u64) -> Body {
+    let slice_len = (bytes_per_second / 10).max(1);
+    let interval = Duration::from_secs_f64(slice_len as f64 / bytes_per_second as f64);
+    let data = Bytes::from(data);
+    let slices: Vec<Bytes> = data.chunks(slice_len as usize).map(|s| data.slice_ref(s)).collect();
+
+    let writes = stream::iter(slices.into_iter().enumerate()).then(move |(i, slice)| async move {
+        if i > 0 {
+            tokio::time::sleep(interval).await;
+        }
+        Ok::<_, Infallible>(slice)
+    });
+    Body::from_stream(writes)
+}
+
+/// A body written chunk by chunk, each after its delay. With `keep_open`
+/// the body never ends, so the connection stays open until the client
+/// disconnects.
+fn chunked_body(chunks: &[MockChunk], keep_open: bool) -> Result<Body, String> {
+    let writes = chunks
+        .iter()
+        .map(|chunk| {
+            let data = decode(&chunk.data_base64, "chunk data_base64")?;
+            Ok((Bytes::from(data), Duration::from_millis(chunk.delay_ms)))
+        })
+        .collect::<Result<Vec<_>, String>>()?;
+
+    let writes = stream::iter(writes).then(|(data, delay)| async move {
+        if !delay.is_zero() {
+            tokio::time::sleep(delay).await;
+        }
+        Ok::<_, Infallible>(data)
+    });
+    Ok(if keep_open {
+        Body::from_stream(writes.chain(stream::pending()))
+    } else {
+        Body::from_stream(writes)
+    })
+}
+
+fn decode(encoded: &str, field: &str) -> Result<Vec<u8>, String> {
+    base64::engine::general_purpose::STANDARD
+        .decode(encoded)
+        .map_err(|e| format!("invalid {}: {}", field, e))
+}
@@ -0,0 +1,82 @@
+use axum::{
+    extract::{Path, State},
+    http::StatusCode,
+    response::{IntoResponse, Json},
+};
+use serde::Deserialize;
+use std::collections::{BTreeMap, HashMap};
+
+use super::{ManagementState, MockConfig};
+
+/// The state every scenario is in until a mock or the API moves it
+pub(crate) const SCENARIO_STARTED: &str = "Started";
+
+/// Request to force a scenario into a state
+#[derive(Debug, Deserialize)]
+pub struct SetScenarioStateRequest {
+    /// The state to move the scenario to
+    pub state: String,
+}
+
+/// Whether the mock's scenario is in the state the mock requires. Mocks
+/// without a scenario or required state are always active.
+pub(crate) fn scenario_state_allows(mock: &MockConfig, states: &HashMap<String, String>) -> bool {
+    match (&mock.scenario, &mock.required_scenario_state) {
+        (Some(scenario), Some(required)) => {
+            states.get(scenario).map(String::as_str).unwrap_or(SCENARIO_STARTED) == required
+        }
+        _ => true,
+    }
+}
+
+/// Move the mock's scenario to the mock's new state, if it sets one
+pub(crate) fn advance_scenario_state(mock: &MockConfig, states: &mut HashMap<String, String>) {
+    if let (Some(scenario), Some(new_state)) = (&mock.scenario, &mock.new_scenario_state) {
+        states.insert(scenario.clone(), new_state.clone());
+    }
+}
+
+/// List the current state of every scenario a mock or the API names,
+/// sorted by name
+pub(crate) async fn list_scenario_states(
+    State(state): State<ManagementState>,
+) -> Json<serde_json::Value> {
+    let mut scenarios: BTreeMap<String, String> = state
+        .mocks
+        .read()
+        .await
+        .iter()
+        .filter_map(|mock| mock.scenario.clone())
+        .map(|name| (name, SCENARIO_STARTED.to_string()))
+        .collect();
+    scenarios.extend(state.scenario_states.read().await.clone());
+
+    let scenarios: Vec<_> = scenarios
+        .into_iter()
+        .map(|(name, state)| serde_json::json!({ "name": name, "state": state }))
+        .collect();
+    Json(serde_json::json!({ "scenarios": scenarios }))
+}
+
+/// Force a scenario into a state
+pub(crate) async fn set_scenario_state(
+    State(state): State<ManagementState>,
+    Path(name): Path<String>,
+    Json(request): Json<SetScenarioStateRequest>,
+) -> impl IntoResponse {
+    if request.state.is_empty() {
+        return (
+            StatusCode::BAD_REQUEST,
+            Json(serde_json::json!({ "error": "state must not be empty" })),
+        )
+            .into_response();
+    }
+    state.scenario_states.write().await.insert(name.clone(), request.state.clone());
+    Json(serde_json::json!({ "name": name, "state": request.state })).into_response()
+}
+
+/// Move every scenario back to the started state
+pub(crate) async fn reset_scenario_states(State(state): State<ManagementState>) -> StatusCode {
+    state.scenario_states.write().await.clear();
+    StatusCode::NO_CONTENT
+}
@@ -0,0 +1,43 @@
+use serde::{Deserialize, Serialize};
+
+use super::{MockConfig, MockLatency};
+
+/// One response of a mock's response sequence
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct SequencedResponse {
+    /// Status code, the mock's when omitted
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub status_code: Option<u16>,
+    /// Response body as JSON
+    #[serde(default)]
+    pub body: serde_json::Value,
+    /// Response headers, replacing the mock's
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub headers: Option<std::collections::HashMap<String, String>>,
+    /// Latency to inject in milliseconds
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub latency_ms: Option<u64>,
+    /// Latency drawn from a distribution, in place of `latency_ms`
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub latency: Option<MockLatency>,
+}
+
+impl MockConfig {
+    /// Replace the mock's response with the sequenced response for its
+    /// `match_number`th match (0-based). Once the sequence is exhausted its
+    /// last response repeats.
+    pub(crate) fn apply_sequenced_response(&mut self, match_number: u64) {
+        let Some(last) = self.response_sequence.len().checked_sub(1) else {
+            return;
+        };
+        let index = usize::try_from(match_number).map_or(last, |n| n.min(last));
+        let sequenced = self.response_sequence[index].clone();
+        if sequenced.status_code.is_some() {
+            self.status_code = sequenced.status_code;
+        }
+        self.response.body = sequenced.body;
+        self.response.headers = sequenced.headers;
+        self.latency_ms = sequenced.latency_ms;
+        self.latency = sequenced.latency;
+    }
+}
@@ -0,0 +1,257 @@
+use axum::{
+    body::Body,
+    extract::{Path, State},
+    http::{response::Parts, HeaderName, HeaderValue, StatusCode},
+    response::{IntoResponse, Json, Response},
+};
+use hmac::{Hmac, Mac};
+use serde::{Deserialize, Serialize};
+use sha2::Sha256;
+use std::collections::HashMap;
+
+use super::callbacks::CallbackRequest;
+use super::ManagementState;
+
+/// Largest response body transformers rewrite
+const MAX_TRANSFORMED_BODY_BYTES: usize = 16 * 1024 * 1024;
+
+/// A named rewrite applied to the responses of the mocks that list it in
+/// their `transformers`. Header values and the strings in `body_patch` may
+/// use `{{request.path.<name>}}`, `{{request.header.<name>}}`, and
+/// `{{request.body.<field>}}` placeholders.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct ResponseTransformer {
+    /// Name mocks reference the transformer by
+    #[serde(default)]
+    pub name: String,
+    /// What the transformer is for
+    #[serde(default, skip_serializing_if = "String::is_empty")]
+    pub description: String,
+    /// Status code replacing the response's
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub status_code: Option<u16>,
+    /// Headers set on the response, replacing any of the same name
+    #[serde(default, skip_serializing_if = "HashMap::is_empty")]
+    pub set_headers: HashMap<String, String>,
+    /// Headers removed from the response
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub remove_headers: Vec<String>,
+    /// JSON Patch (RFC 6902) operations applied to a JSON body
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub body_patch: Option<serde_json::Value>,
+    /// Signature of the body as the transformer leaves it
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub sign: Option<ResponseSignature>,
+}
+
+/// An HMAC-SHA256 signature of the response body, sent hex-encoded
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct ResponseSignature {
+    /// Header the signature is sent in
+    pub header: String,
+    /// HMAC key
+    pub secret: String,
+}
+
+impl ResponseTransformer {
+    /// Check the transformer can be applied, short of the response it needs
+    fn validate(&self) -> Result<(), String> {
+        let valid_name = self.name.starts_with(|c: char| c.is_ascii_alphanumeric())
+            && self.name.chars().all(|c| c.is_ascii_alphanumeric() || "_.-".contains(c));
+        if !valid_name {
+            return Err("transformer name must be letters, digits, '.', '_', or '-'".to_string());
+        }
+        if let Some(code) = self.status_code {
+            StatusCode::from_u16(code).map_err(|e| format!("invalid status_code: {}", e))?;
+        }
+        let header_names = self
+            .set_headers
+            .keys()
+            .chain(&self.remove_headers)
+            .chain(self.sign.as_ref().map(|sign| &sign.header));
+        for name in header_names {
+            HeaderName::from_bytes(name.as_bytes())
+                .map_err(|_| format!("invalid header name {:?}", name))?;
+        }
+        if let Some(patch) = &self.body_patch {
+            serde_json::from_value::<json_patch::Patch>(patch.clone())
+                .map_err(|e| format!("invalid body_patch: {}", e))?;
+        }
+        Ok(())
+    }
+
+    /// Rewrite a response's head and body
+    fn apply(
+        &self,
+        parts: &mut Parts,
+        body: &mut Vec<u8>,
+        request: &CallbackRequest,
+    ) -> Result<(), String> {
+        if let Some(code) = self.status_code {
+            parts.status = StatusCode::from_u16(code).map_err(|e| e.to_string())?;
+        }
+        for name in &self.remove_headers {
+            parts.headers.remove(name.as_str());
+        }
+        for (name, value) in &self.set_headers {
+            let name = HeaderName::from_bytes(name.as_bytes()).map_err(|e| e.to_string())?;
+            let value = HeaderValue::from_str(&request.render(value))
+                .map_err(|_| format!("invalid value for header {}", name))?;
+            parts.headers.insert(name, value);
+        }
+        if let Some(patch) = &self.body_patch {
+            let mut patch = patch.clone();
+            request.render_value(&mut patch);
+            let patch: json_patch::Patch =
+                serde_json::from_value(patch).map_err(|e| format!("invalid body_patch: {}", e))?;
+            let mut document: serde_json::Value = serde_json::from_slice(body)
+                .map_err(|_| "body_patch needs a JSON response body".to_string())?;
+            json_patch::patch(&mut document, &patch).map_err(|e| e.to_string())?;
+            *body = serde_json::to_vec(&document).map_err(|e| e.to_string())?;
+        }
+        if let Some(sign) = &self.sign {
+            let mut mac = Hmac::<Sha256>::new_from_slice(sign.secret.as_bytes())
+                .map_err(|e| e.to_string())?;
+            mac.update(body);
+            let signature = hex::encode(mac.finalize().into_bytes());
+            let name = HeaderName::from_bytes(sign.header.as_bytes()).map_err(|e| e.to_string())?;
+            parts
+                .headers
+                .insert(name, HeaderValue::from_str(&signature).map_err(|e| e.to_string())?);
+        }
+        Ok(())
+    }
+}
+
+/// Apply a mock's transformers, in order, to its response. The body is
+/// buffered to do so. Names without a definition are skipped.
+pub(crate) async fn apply_transformers(
+    state: &ManagementState,
+    mock_id: &str,
+    names: &[String],
+    request: &CallbackRequest,
+    response: Response,
+) -> Response {
+    let transformers: Vec<ResponseTransformer> = {
+        let defined = state.transformers.read().await;
+        names.iter().filter_map(|name| defined.get(name).cloned()).collect()
+    };
+    if transformers.is_empty() {
+        return response;
+    }
+
+    let (mut parts, body) = response.into_parts();
+    let mut body = match axum::body::to_bytes(body, MAX_TRANSFORMED_BODY_BYTES).await {
+        Ok(body) => body.to_vec(),
+        Err(e) => return transform_failed(mock_id, "body", e.to_string()),
+    };
+    for transformer in &transformers {
+        if let Err(e) = transformer.apply(&mut parts, &mut body, request) {
+            return transform_failed(mock_id, &transformer.name, e);
+        }
+    }
+    parts.headers.remove(axum::http::header::CONTENT_LENGTH);
+    Response::from_parts(parts, Body::from(body))
+}
+
+fn transform_failed(mock_id: &str, transformer: &str, error: String) -> Response {
+    tracing::warn!("Mock {} transformer {} failed: {}", mock_id, transformer, error);
+    let error = format!("transformer {} failed: {}", transformer, error);
+    (StatusCode::INTERNAL_SERVER_ERROR, Json(serde_json::json!({ "error": error }))).into_response()
+}
+
+/// List the defined transformers, by name
+pub(crate) async fn list_transformers(
+    State(state): State<ManagementState>,
+) -> Json<serde_json::Value> {
+    let mut transformers: Vec<ResponseTransformer> =
+        state.transformers.read().await.values().cloned().collect();
+    transformers.sort_by(|a, b| a.name.cmp(&b.name));
+    Json(serde_json::json!({ "transformers": transformers }))
+}
+
+/// Define a transformer, replacing any of the same name
+pub(crate) async fn set_transformer(
+    State(state): State<ManagementState>,
+    Path(name): Path<String>,
+    Json(mut transformer): Json<ResponseTransformer>,
+) -> Response {
+    transformer.name = name;
+    if let Err(e) = transformer.validate() {
+        return (StatusCode::BAD_REQUEST, Json(serde_json::json!({ "error": e }))).into_response();
+    }
+    state
+        .transformers
+        .write()
+        .await
+        .insert(transformer.name.clone(), transformer.clone());
+    Json(transformer).into_response()
+}
+
+/// Remove a transformer. Mocks still listing it respond untransformed.
+pub(crate) async fn delete_transformer(
+    State(state): State<ManagementState>,
+    Path(name): Path<String>,
+) -> StatusCode {
+    match state.transformers.write().await.remove(&name) {
+        Some(_) => StatusCode::NO_CONTENT,
+        None => StatusCode::NOT_FOUND,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_transformer_rewrites_and_signs_the_response() {
+        let transformer: ResponseTransformer = serde_json::from_value(serde_json::json!({
+            "name": "sign-response",
+            "status_code": 201,
+            "set_headers": { "X-Request-Id": "{{request.header.x-request-id}}" },
+            "remove_headers": ["X-Internal"],
+            "body_patch": [{ "op": "add", "path": "/id", "value": "{{request.path.id}}" }],
+            "sign": { "header": "X-Signature", "secret": "key" }
+        }))
+        .unwrap();
+        transformer.validate().unwrap();
+
+        let request = CallbackRequest {
+            path_params: [("id".to_string(), "42".to_string())].into(),
+            headers: [("x-request-id".to_string(), "req-1".to_string())].into(),
+            body: None,
+        };
+        let (mut parts, _) = Response::builder()
+            .header("X-Internal", "secret")
+            .body(())
+            .unwrap()
+            .into_parts();
+        let mut body = br#"{"ok":true}"#.to_vec();
+        transformer.apply(&mut parts, &mut body, &request).unwrap();
+
+        assert_eq!(parts.status, StatusCode::CREATED);
+        assert_eq!(parts.headers["x-request-id"], "req-1");
+        assert!(!parts.headers.contains_key("x-internal"));
+        assert_eq!(body, br#"{"id":"42","ok":true}"#);
+        let mut mac = Hmac::<Sha256>::new_from_slice(b"key").unwrap();
+        mac.update(&body);
+        assert_eq!(parts.headers["x-signature"], hex::encode(mac.finalize().into_bytes()));
+    }
+
+    #[test]
+    fn test_transformer_validation() {
+        let named = |name: &str| ResponseTransformer {
+            name: name.to_string(),
+            ..Default::default()
+        };
+        assert!(named("inject-request-id").validate().is_ok());
+        assert!(named("bad/name").validate().is_err());
+        assert!(named("").validate().is_err());
+
+        let bad_patch = ResponseTransformer {
+            body_patch: Some(serde_json::json!([{ "op": "explode" }])),
+            ..named("patch")
+        };
+        assert!(bad_patch.validate().is_err());
+    }
+}
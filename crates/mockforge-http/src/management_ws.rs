@@ -252,6 +252,22 @@ impl Default for WsManagementState {
     }
 }
 
+/// Mock events of the server's management API, published so the admin
+/// server can audit mock changes made through it
+static SERVER_EVENTS: std::sync::OnceLock<broadcast::Sender<MockEvent>> =
+    std::sync::OnceLock::new();
+
+/// Publish the management API's event channel. The first router built in the
+/// process is the server's; later calls are ignored.
+pub fn publish_server_events(tx: broadcast::Sender<MockEvent>) {
+    let _ = SERVER_EVENTS.set(tx);
+}
+
+/// Subscribe to the server's mock events, if its router has been built
+pub fn subscribe_server_events() -> Option<broadcast::Receiver<MockEvent>> {
+    SERVER_EVENTS.get().map(broadcast::Sender::subscribe)
+}
+
 /// WebSocket upgrade handler
 async fn ws_handler(
     ws: WebSocketUpgrade,
@@ -342,7 +358,7 @@ mod tests {
             path: "/test".to_string(),
             response: MockResponse {
                 body: serde_json::json!({"message": "test"}),
-                headers: None,
+                ..Default::default()
             },
             enabled: true,
             latency_ms: None,
@@ -352,6 +368,7 @@ mod tests {
             scenario: None,
             required_scenario_state: None,
             new_scenario_state: None,
+            ..Default::default()
         };
 
         let event = MockEvent::mock_created(mock);
@@ -1,11 +1,13 @@
 //! HTTP request logging middleware
 
 use axum::{
-    extract::{ConnectInfo, MatchedPath, Request},
-    http::HeaderMap,
+    body::{Body, Bytes},
+    extract::{ConnectInfo, Request},
+    http::{HeaderMap, StatusCode},
     middleware::Next,
-    response::Response,
+    response::{IntoResponse, Response},
 };
+use base64::{engine::general_purpose, Engine as _};
 use mockforge_core::{
     create_http_log_entry_with_query, log_request_global,
     reality_continuum::response_trace::ResponseGenerationTrace,
@@ -16,19 +18,61 @@ use std::net::SocketAddr;
 use std::time::Instant;
 use tracing::info;
 
+use crate::management::{MatchedMockId, UnmatchedRequest};
+
+/// Largest request body recorded in the request log. Of larger bodies, the
+/// first this many bytes are recorded and the entry is flagged as truncated.
+const MAX_LOGGED_BODY_BYTES: usize = 64 * 1024;
+
+/// The part of a request body recorded in the request log
+struct LoggedBody {
+    /// Up to `MAX_LOGGED_BODY_BYTES` bytes from the start of the body
+    bytes: Bytes,
+    /// Whether the body went on past `bytes`
+    truncated: bool,
+}
+
+/// Read up to `limit` bytes of a body for the request log, with or without
+/// a Content-Length. Returns the body to forward: all of it when it fits,
+/// otherwise the bytes read followed by the rest, still streaming.
+async fn buffer_body(body: Body, limit: usize) -> Result<(Body, LoggedBody), axum::Error> {
+    use futures::StreamExt;
+
+    let mut stream = body.into_data_stream();
+    let mut buffered = Vec::new();
+    while let Some(chunk) = stream.next().await {
+        buffered.extend_from_slice(&chunk?);
+        if buffered.len() > limit {
+            let head = Bytes::from(buffered);
+            let logged = LoggedBody {
+                bytes: head.slice(..limit),
+                truncated: true,
+            };
+            let rest =
+                futures::stream::once(async move { Ok::<_, axum::Error>(head) }).chain(stream);
+            return Ok((Body::from_stream(rest), logged));
+        }
+    }
+    let bytes = Bytes::from(buffered);
+    let logged = LoggedBody {
+        bytes: bytes.clone(),
+        truncated: false,
+    };
+    Ok((Body::from(bytes), logged))
+}
+
 /// HTTP request logging middleware
 pub async fn log_http_requests(
     ConnectInfo(addr): ConnectInfo<SocketAddr>,
-    matched_path: Option<MatchedPath>,
     req: Request,
     next: Next,
 ) -> Response {
     let start_time = Instant::now();
     let method = req.method().to_string();
     let uri = req.uri().to_string();
-    let path = matched_path
-        .map(|mp| mp.as_str().to_string())
-        .unwrap_or_else(|| uri.split('?').next().unwrap_or(&uri).to_string());
+    // The journal records the path the client sent, not the route template
+    // that served it, so requests can be verified by their concrete path
+    let path = req.uri().path().to_string();
 
     // Extract query parameters from URI
     let query_params: HashMap<String, String> = req
@@ -39,6 +83,7 @@ pub async fn log_http_requests(
 
     // Extract headers (filter sensitive ones)
     let headers = extract_safe_headers(req.headers());
+    let redacted_headers = redacted_header_names(req.headers());
 
     // Extract user agent
     let user_agent = req
@@ -51,6 +96,30 @@ pub async fn log_http_requests(
     // Must be done before calling next.run() which consumes the request
     let reality_metadata = req.extensions().get::<RealityTraceMetadata>().cloned();
 
+    // Buffer bodies so verification can match and return them. gRPC streams
+    // are left alone: a bidirectional call may wait for a response before
+    // sending more, so reading ahead would stall it.
+    let is_grpc = req
+        .headers()
+        .get("content-type")
+        .and_then(|h| h.to_str().ok())
+        .is_some_and(|ct| ct.starts_with("application/grpc"));
+    let (req, request_body) = if is_grpc {
+        (req, None)
+    } else {
+        let (parts, body) = req.into_parts();
+        match buffer_body(body, MAX_LOGGED_BODY_BYTES).await {
+            Ok((body, logged)) => (Request::from_parts(parts, body), Some(logged)),
+            Err(e) => {
+                // The body could not be read in full, e.g. the client went
+                // away mid-upload. It is consumed, and forwarding an empty
+                // body instead would serve a request the client never sent.
+                tracing::warn!("Failed to read the body of {} {}: {}", method, uri, e);
+                return (StatusCode::BAD_REQUEST, "failed to read request body").into_response();
+            }
+        }
+    };
+
     // Call the next middleware/handler
     let response = next.run(req).await;
 
@@ -94,6 +163,31 @@ pub async fn log_http_requests(
     // Attach reality metadata if available
     log_entry.reality_metadata = reality_metadata;
 
+    if let Some(names) = redacted_headers {
+        log_entry.metadata.insert("redacted_headers".to_string(), names);
+    }
+    if let Some(body) = request_body.filter(|body| !body.bytes.is_empty()) {
+        // The text form is lossy for binary bodies; clients wanting the
+        // exact bytes decode the base64 form
+        let text = String::from_utf8_lossy(&body.bytes).into_owned();
+        log_entry.metadata.insert("request_body".to_string(), text);
+        log_entry.metadata.insert(
+            "request_body_base64".to_string(),
+            general_purpose::STANDARD.encode(&body.bytes),
+        );
+        if body.truncated {
+            log_entry
+                .metadata
+                .insert("request_body_truncated".to_string(), "true".to_string());
+        }
+    }
+    if let Some(MatchedMockId(id)) = response.extensions().get::<MatchedMockId>() {
+        log_entry.metadata.insert("stub_id".to_string(), id.clone());
+    }
+    if response.extensions().get::<UnmatchedRequest>().is_some() {
+        log_entry.metadata.insert("unmatched".to_string(), "true".to_string());
+    }
+
     // Extract response generation trace from response extensions (set by handler)
     if let Some(trace) = response.extensions().get::<ResponseGenerationTrace>() {
         // Serialize trace to JSON string and store in metadata
@@ -131,41 +225,129 @@ pub async fn log_http_requests(
     response
 }
 
+/// Headers carrying credentials, whose values are never logged
+const SENSITIVE_HEADERS: [&str; 6] = [
+    "authorization",
+    "proxy-authorization",
+    "cookie",
+    "set-cookie",
+    "x-api-key",
+    "x-auth-token",
+];
+
 /// Extract safe headers (exclude sensitive ones)
 fn extract_safe_headers(headers: &HeaderMap) -> HashMap<String, String> {
     let mut safe_headers = HashMap::new();
 
-    // List of safe headers to include
-    let safe_header_names = [
-        "accept",
-        "accept-encoding",
-        "accept-language",
-        "cache-control",
-        "content-type",
-        "content-length",
-        "user-agent",
-        "referer",
-        "host",
-        "x-forwarded-for",
-        "x-real-ip",
-    ];
-
-    for name in safe_header_names {
-        if let Some(value) = headers.get(name) {
-            if let Ok(value_str) = value.to_str() {
-                safe_headers.insert(name.to_string(), value_str.to_string());
-            }
+    for (name, value) in headers {
+        if SENSITIVE_HEADERS.contains(&name.as_str()) {
+            continue;
+        }
+        if let Ok(value_str) = value.to_str() {
+            safe_headers.insert(name.to_string(), value_str.to_string());
         }
     }
 
     safe_headers
 }
 
+/// Names of the sensitive headers sent, comma-separated, so verification can
+/// check whether credentials were sent without their values being logged
+fn redacted_header_names(headers: &HeaderMap) -> Option<String> {
+    let names: Vec<&str> = SENSITIVE_HEADERS
+        .into_iter()
+        .filter(|name| headers.contains_key(*name))
+        .collect();
+    (!names.is_empty()).then(|| names.join(","))
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
     use axum::http::HeaderValue;
 
+    #[tokio::test]
+    async fn test_unreadable_body_is_rejected_not_forwarded_empty() {
+        use axum::body::Bytes;
+        use tower::ServiceExt;
+
+        let app = axum::Router::new()
+            .route("/", axum::routing::post(|body: String| async move { body }))
+            .layer(axum::middleware::from_fn(log_http_requests));
+        let request = |body: Body| {
+            let mut req = Request::builder()
+                .method("POST")
+                .uri("/")
+                .header("content-length", "10")
+                .body(body)
+                .unwrap();
+            req.extensions_mut()
+                .insert(ConnectInfo(SocketAddr::from(([127, 0, 0, 1], 4000))));
+            req
+        };
+
+        let res = app.clone().oneshot(request(Body::from("0123456789"))).await.unwrap();
+        assert_eq!(res.status(), StatusCode::OK);
+        let body = axum::body::to_bytes(res.into_body(), 1024).await.unwrap();
+        assert_eq!(body.as_ref(), b"0123456789");
+
+        let broken = futures::stream::iter(vec![
+            Ok(Bytes::from_static(b"01234")),
+            Err(std::io::Error::new(std::io::ErrorKind::ConnectionReset, "client went away")),
+        ]);
+        let res = app.oneshot(request(Body::from_stream(broken))).await.unwrap();
+        assert_eq!(res.status(), StatusCode::BAD_REQUEST);
+    }
+
+    #[tokio::test]
+    async fn test_journals_the_requested_path_not_the_route_template() {
+        use tower::ServiceExt;
+
+        let logger = mockforge_core::request_logger::init_global_logger(1000);
+        let app = axum::Router::new()
+            .route("/journal-paths/{id}", axum::routing::get(|| async { "ok" }))
+            .layer(axum::middleware::from_fn(log_http_requests));
+        let mut req = Request::builder()
+            .uri("/journal-paths/42?view=full")
+            .body(Body::empty())
+            .unwrap();
+        req.extensions_mut()
+            .insert(ConnectInfo(SocketAddr::from(([127, 0, 0, 1], 4000))));
+
+        let res = app.oneshot(req).await.unwrap();
+        assert_eq!(res.status(), StatusCode::OK);
+        let logs = logger.get_recent_logs(None).await;
+        let entry = logs
+            .iter()
+            .find(|entry| entry.path.starts_with("/journal-paths/"))
+            .expect("request was journaled");
+        assert_eq!(entry.path, "/journal-paths/42");
+        assert_eq!(entry.query_params.get("view").map(String::as_str), Some("full"));
+    }
+
+    #[tokio::test]
+    async fn test_buffer_body_records_chunked_bodies_and_truncates_large_ones() {
+        let chunked = |chunks: Vec<&'static [u8]>| {
+            Body::from_stream(futures::stream::iter(
+                chunks.into_iter().map(Ok::<_, std::io::Error>),
+            ))
+        };
+
+        let (body, logged) =
+            buffer_body(chunked(vec![b"{\"sku\":", b"\"A-1\"}"]), 64).await.unwrap();
+        assert_eq!(logged.bytes.as_ref(), br#"{"sku":"A-1"}"#);
+        assert!(!logged.truncated);
+        let forwarded = axum::body::to_bytes(body, 1024).await.unwrap();
+        assert_eq!(forwarded.as_ref(), br#"{"sku":"A-1"}"#);
+
+        let (body, logged) =
+            buffer_body(chunked(vec![b"0123456789", b"abcdef", b"ghij"]), 12).await.unwrap();
+        assert_eq!(logged.bytes.as_ref(), b"0123456789ab");
+        assert!(logged.truncated);
+        let forwarded = axum::body::to_bytes(body, 1024).await.unwrap();
+        assert_eq!(forwarded.as_ref(), b"0123456789abcdefghij");
+    }
+
     #[test]
     fn test_extract_safe_headers_empty() {
         let headers = HeaderMap::new();
@@ -241,6 +423,21 @@ mod tests {
         assert_eq!(safe_headers.get("x-real-ip"), Some(&"192.168.1.2".to_string()));
     }
 
+    #[test]
+    fn test_extract_safe_headers_keeps_custom_headers_and_names_sensitive_ones() {
+        let mut headers = HeaderMap::new();
+        headers.insert("x-request-id", HeaderValue::from_static("req-123"));
+        headers.insert("authorization", HeaderValue::from_static("Bearer token"));
+        headers.insert("cookie", HeaderValue::from_static("session=abc123"));
+
+        let safe_headers = extract_safe_headers(&headers);
+
+        assert_eq!(safe_headers.len(), 1);
+        assert_eq!(safe_headers.get("x-request-id"), Some(&"req-123".to_string()));
+        assert_eq!(redacted_header_names(&headers).as_deref(), Some("authorization,cookie"));
+        assert_eq!(redacted_header_names(&HeaderMap::new()), None);
+    }
+
     #[test]
     fn test_extract_safe_headers_handles_invalid_utf8() {
         let mut headers = HeaderMap::new();
@@ -3,12 +3,17 @@
 //! Provides REST endpoints for programmatic request verification,
 //! allowing test code to verify that specific requests were made (or not made).
 
-use axum::{http::StatusCode, response::IntoResponse, routing::post, Json, Router};
+use axum::{
+    http::StatusCode,
+    response::IntoResponse,
+    routing::{delete, post},
+    Json, Router,
+};
 use mockforge_core::{
     request_logger::get_global_logger,
     verification::{
-        verify_at_least, verify_never, verify_requests, verify_sequence, VerificationCount,
-        VerificationRequest, VerificationResult,
+        journal_mark, verify_at_least, verify_never, verify_requests, verify_sequence,
+        VerificationCount, VerificationRequest, VerificationResult,
     },
 };
 use serde::{Deserialize, Serialize};
@@ -68,6 +73,33 @@ pub fn verification_router() -> Router {
         .route("/api/verification/sequence", post(handle_sequence))
         .route("/api/verification/never", post(handle_never))
         .route("/api/verification/at-least", post(handle_at_least))
+        .route("/api/verification/journal", delete(handle_reset_journal))
+        .route("/api/verification/journal/mark", post(handle_mark_journal))
+}
+
+/// Response for the journal mark endpoint
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct MarkResponse {
+    /// Token to pass as a pattern's `since_mark`
+    pub mark: String,
+}
+
+/// Mark the current point of the request journal
+async fn handle_mark_journal() -> impl IntoResponse {
+    Json(MarkResponse {
+        mark: journal_mark(),
+    })
+}
+
+/// Clear the request journal, leaving mocks untouched
+async fn handle_reset_journal() -> impl IntoResponse {
+    match get_global_logger() {
+        Some(logger) => {
+            logger.clear_logs().await;
+            StatusCode::NO_CONTENT
+        }
+        None => StatusCode::SERVICE_UNAVAILABLE,
+    }
 }
 
 /// Verify requests against a pattern and count assertion
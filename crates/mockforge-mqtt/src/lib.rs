@@ -61,7 +61,7 @@ pub use server::{
     start_mqtt_dual_server, start_mqtt_server, start_mqtt_server_with_metrics,
     start_mqtt_server_with_session_manager, start_mqtt_tls_server, MqttServer,
 };
-pub use session::SessionManager;
+pub use session::{PublishedMessage, SessionManager};
 pub use spec_registry::MqttSpecRegistry;
 pub use tls::{create_tls_acceptor, create_tls_acceptor_with_client_auth, TlsError};
 pub use topics::TopicTree;
@@ -3,7 +3,7 @@
 //! This module handles client session tracking, subscription management,
 //! and QoS message delivery state for the MQTT broker.
 
-use std::collections::HashMap;
+use std::collections::{HashMap, VecDeque};
 use std::sync::Arc;
 use std::time::{SystemTime, UNIX_EPOCH};
 
@@ -222,6 +222,26 @@ pub struct ActiveClient {
     pub will: Option<crate::protocol::Will>,
 }
 
+/// Number of published messages kept for inspection through the admin API
+const PUBLISHED_LOG_CAPACITY: usize = 1000;
+
+/// A message published to the broker, kept for test verification
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct PublishedMessage {
+    /// Client that published the message
+    pub publisher_id: String,
+    /// Topic the message was published to
+    pub topic: String,
+    /// Message payload
+    pub payload: Vec<u8>,
+    /// QoS level the message was published with
+    pub qos: u8,
+    /// Whether the message was retained
+    pub retain: bool,
+    /// Unix timestamp in milliseconds
+    pub timestamp: u64,
+}
+
 /// Session manager for tracking all client sessions
 pub struct SessionManager {
     /// Active connected clients
@@ -234,6 +254,8 @@ pub struct SessionManager {
     metrics: Option<Arc<MqttMetrics>>,
     /// Maximum number of connections
     max_connections: usize,
+    /// Most recent published messages, oldest first
+    published: RwLock<VecDeque<PublishedMessage>>,
 }
 
 impl SessionManager {
@@ -245,6 +267,7 @@ impl SessionManager {
             topics: RwLock::new(TopicTree::new()),
             metrics,
             max_connections,
+            published: RwLock::new(VecDeque::new()),
         }
     }
 
@@ -533,6 +556,24 @@ impl SessionManager {
             metrics.record_publish(publish.qos as u8);
         }
 
+        {
+            let mut published = self.published.write().await;
+            if published.len() == PUBLISHED_LOG_CAPACITY {
+                published.pop_front();
+            }
+            published.push_back(PublishedMessage {
+                publisher_id: publisher_id.to_string(),
+                topic: publish.topic.clone(),
+                payload: publish.payload.clone(),
+                qos: publish.qos as u8,
+                retain: publish.retain,
+                timestamp: SystemTime::now()
+                    .duration_since(UNIX_EPOCH)
+                    .unwrap_or_default()
+                    .as_millis() as u64,
+            });
+        }
+
         // Handle retained messages
         if publish.retain {
             let mut topics = self.topics.write().await;
@@ -719,6 +760,28 @@ impl SessionManager {
         self.publish(publisher_id, &packet).await;
     }
 
+    /// Most recent published messages, oldest first
+    pub async fn published_messages(&self) -> Vec<PublishedMessage> {
+        self.published.read().await.iter().cloned().collect()
+    }
+
+    /// Subscriptions of connected clients as (client id, topic filter, QoS)
+    pub async fn subscriptions(&self) -> Vec<(String, String, u8)> {
+        let active = self.active_clients.read().await;
+        let mut subscriptions: Vec<(String, String, u8)> = active
+            .iter()
+            .flat_map(|(client_id, client)| {
+                client
+                    .session
+                    .subscriptions
+                    .iter()
+                    .map(move |(filter, qos)| (client_id.clone(), filter.clone(), *qos as u8))
+            })
+            .collect();
+        subscriptions.sort();
+        subscriptions
+    }
+
     /// Get count of active connections
     pub async fn connection_count(&self) -> usize {
         let active = self.active_clients.read().await;
@@ -1133,4 +1196,81 @@ mod tests {
             other => panic!("expected a delivered Publish, got {other:?}"),
         }
     }
+
+    #[tokio::test]
+    async fn test_published_messages_are_journaled() {
+        let manager = SessionManager::new(10, None);
+        let publish = PublishPacket {
+            dup: false,
+            qos: QoS::AtLeastOnce,
+            retain: true,
+            topic: "sensors/temp".to_string(),
+            packet_id: Some(7),
+            payload: b"21.5".to_vec(),
+        };
+        manager.publish("device-1", &publish).await;
+        manager
+            .publish_raw("admin", "sensors/humidity", b"40".to_vec(), QoS::AtMostOnce, false)
+            .await;
+
+        let published = manager.published_messages().await;
+        assert_eq!(published.len(), 2);
+        assert_eq!(published[0].publisher_id, "device-1");
+        assert_eq!(published[0].topic, "sensors/temp");
+        assert_eq!(published[0].payload, b"21.5");
+        assert_eq!(published[0].qos, 1);
+        assert!(published[0].retain);
+        assert!(published[0].timestamp > 0);
+        assert_eq!(published[1].topic, "sensors/humidity");
+        assert!(!published[1].retain);
+    }
+
+    #[tokio::test]
+    async fn test_published_messages_drop_oldest_at_capacity() {
+        let manager = SessionManager::new(10, None);
+        for i in 0..PUBLISHED_LOG_CAPACITY + 2 {
+            manager
+                .publish_raw("admin", &format!("t/{i}"), Vec::new(), QoS::AtMostOnce, false)
+                .await;
+        }
+
+        let published = manager.published_messages().await;
+        assert_eq!(published.len(), PUBLISHED_LOG_CAPACITY);
+        assert_eq!(published[0].topic, "t/2");
+        assert_eq!(
+            published[PUBLISHED_LOG_CAPACITY - 1].topic,
+            format!("t/{}", PUBLISHED_LOG_CAPACITY + 1)
+        );
+    }
+
+    #[tokio::test]
+    async fn test_subscriptions_list_connected_clients_sorted() {
+        let manager = SessionManager::new(10, None);
+        let (tx1, _rx1) = mpsc::channel(10);
+        let (tx2, _rx2) = mpsc::channel(10);
+        manager.connect("b".to_string(), true, 60, tx1, None).await.unwrap();
+        manager.connect("a".to_string(), true, 60, tx2, None).await.unwrap();
+        manager
+            .subscribe(
+                "b",
+                vec![
+                    ("z/#".to_string(), QoS::ExactlyOnce),
+                    ("x/+".to_string(), QoS::AtMostOnce),
+                ],
+            )
+            .await;
+        manager.subscribe("a", vec![("y".to_string(), QoS::AtLeastOnce)]).await;
+
+        assert_eq!(
+            manager.subscriptions().await,
+            vec![
+                ("a".to_string(), "y".to_string(), 1),
+                ("b".to_string(), "x/+".to_string(), 0),
+                ("b".to_string(), "z/#".to_string(), 2),
+            ]
+        );
+
+        manager.disconnect("b", true).await;
+        assert_eq!(manager.subscriptions().await, vec![("a".to_string(), "y".to_string(), 1)]);
+    }
 }
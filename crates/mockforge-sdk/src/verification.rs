@@ -32,6 +32,7 @@ pub trait Verification {
     ///     query_params: std::collections::HashMap::new(),
     ///     headers: std::collections::HashMap::new(),
     ///     body_pattern: None,
+    ///     ..Default::default()
     /// };
     ///
     /// let result = server.verify(&pattern, VerificationCount::Exactly(3)).await?;
@@ -65,6 +66,7 @@ pub trait Verification {
     ///     query_params: std::collections::HashMap::new(),
     ///     headers: std::collections::HashMap::new(),
     ///     body_pattern: None,
+    ///     ..Default::default()
     /// };
     ///
     /// let result = server.verify_never(&pattern).await?;
@@ -97,6 +99,7 @@ pub trait Verification {
     ///     query_params: std::collections::HashMap::new(),
     ///     headers: std::collections::HashMap::new(),
     ///     body_pattern: None,
+    ///     ..Default::default()
     /// };
     ///
     /// let result = server.verify_at_least(&pattern, 2).await?;
@@ -131,6 +134,7 @@ pub trait Verification {
     ///         query_params: std::collections::HashMap::new(),
     ///         headers: std::collections::HashMap::new(),
     ///         body_pattern: None,
+    ///         ..Default::default()
     ///     },
     ///     VerificationRequest {
     ///         method: Some("GET".to_string()),
@@ -138,6 +142,7 @@ pub trait Verification {
     ///         query_params: std::collections::HashMap::new(),
     ///         headers: std::collections::HashMap::new(),
     ///         body_pattern: None,
+    ///         ..Default::default()
     ///     },
     /// ];
     ///
@@ -209,6 +214,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         }
     }
 
@@ -234,6 +240,7 @@ mod tests {
             query_params,
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
 
         assert_eq!(request.query_params.len(), 2);
@@ -253,6 +260,7 @@ mod tests {
             query_params: HashMap::new(),
             headers,
             body_pattern: None,
+            ..Default::default()
         };
 
         assert_eq!(request.headers.len(), 2);
@@ -267,6 +275,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: Some(r#"{"name":".*"}"#.to_string()),
+            ..Default::default()
         };
 
         assert_eq!(request.body_pattern, Some(r#"{"name":".*"}"#.to_string()));
@@ -396,6 +405,7 @@ mod tests {
             query_params,
             headers,
             body_pattern: Some(r#"{"name":"test"}"#.to_string()),
+            ..Default::default()
         };
 
         assert_eq!(request.method, Some("PUT".to_string()));
@@ -413,6 +423,7 @@ mod tests {
             query_params: HashMap::new(),
             headers: HashMap::new(),
             body_pattern: None,
+            ..Default::default()
         };
 
         assert!(request.method.is_none());
@@ -461,6 +472,7 @@ mod tests {
             query_params,
             headers,
             body_pattern: None,
+            ..Default::default()
         };
 
         assert_eq!(request.method, Some("GET".to_string()));
@@ -0,0 +1,891 @@
+//! IMAP reader over the captured mail.
+//!
+//! Serves each recipient's mailbox as an IMAP4rev1 INBOX, so code that polls
+//! a mailbox can be tested against the mail the SMTP server captured. It
+//! implements the subset polling clients use: LOGIN, SELECT and EXAMINE,
+//! FETCH, SEARCH, STORE, and EXPUNGE, with and without UID. Any password is
+//! accepted; the login name selects the mailbox, which is the recipient
+//! address (case-insensitive). TLS is not offered.
+
+use crate::mailboxes::{
+    contains_ignore_case, header_fields, header_value, message_data, split_message, MailboxEntry,
+    DELETED, SEEN,
+};
+use crate::server::read_command_line;
+use crate::SmtpSpecRegistry;
+use mockforge_core::Result;
+use std::sync::Arc;
+use tokio::io::{AsyncReadExt, AsyncWriteExt, BufReader};
+use tokio::net::{TcpListener, TcpStream};
+use tracing::{debug, info};
+
+/// System flags, in the case clients expect
+const SYSTEM_FLAGS: [&str; 5] = ["\\Seen", "\\Answered", "\\Flagged", "\\Deleted", "\\Draft"];
+
+/// Largest literal a client may send in a command
+const MAX_LITERAL_BYTES: usize = 64 * 1024;
+
+/// IMAP server reading the SMTP server's mailboxes
+pub struct ImapServer {
+    host: String,
+    port: u16,
+    registry: Arc<SmtpSpecRegistry>,
+}
+
+impl ImapServer {
+    /// Create an IMAP server for the mail captured in `registry`
+    pub fn new(host: impl Into<String>, port: u16, registry: Arc<SmtpSpecRegistry>) -> Self {
+        Self {
+            host: host.into(),
+            port,
+            registry,
+        }
+    }
+
+    /// Start the IMAP server
+    pub async fn start(&self) -> Result<()> {
+        let listener = self.bind().await?;
+        self.serve(listener).await
+    }
+
+    /// Bind the configured address. With port 0 the listener's
+    /// `local_addr` reports the port the OS picked.
+    pub async fn bind(&self) -> Result<TcpListener> {
+        Ok(TcpListener::bind((self.host.as_str(), self.port)).await?)
+    }
+
+    /// Accept IMAP sessions on a listener from [`ImapServer::bind`]
+    pub async fn serve(&self, listener: TcpListener) -> Result<()> {
+        info!("IMAP server listening on {}", listener.local_addr()?);
+        loop {
+            let (stream, peer_addr) = listener.accept().await?;
+            let registry = self.registry.clone();
+            tokio::spawn(async move {
+                if let Err(e) = handle_imap_session(stream, registry).await {
+                    debug!("IMAP session from {} ended: {}", peer_addr, e);
+                }
+            });
+        }
+    }
+}
+
+/// The state of one IMAP connection
+struct ImapSession {
+    registry: Arc<SmtpSpecRegistry>,
+    /// Logged-in user, whose mailbox is the INBOX
+    user: Option<String>,
+    selected: bool,
+    read_only: bool,
+    /// Message count last reported to the client
+    known: usize,
+    /// Response to the command being handled
+    out: Vec<u8>,
+}
+
+async fn handle_imap_session(stream: TcpStream, registry: Arc<SmtpSpecRegistry>) -> Result<()> {
+    let (read, mut write) = stream.into_split();
+    let mut reader = BufReader::new(read);
+    write.write_all(b"* OK [CAPABILITY IMAP4rev1] MockForge IMAP ready\r\n").await?;
+
+    let mut session = ImapSession {
+        registry,
+        user: None,
+        selected: false,
+        read_only: false,
+        known: 0,
+        out: Vec::new(),
+    };
+    loop {
+        let Some(line) = read_command(&mut reader, &mut write).await? else {
+            return Ok(());
+        };
+        let (tag, rest) = line.split_once(' ').unwrap_or((line.as_str(), ""));
+        let (command, args) = rest.split_once(' ').unwrap_or((rest, ""));
+        let mut command = command.to_ascii_uppercase();
+        let mut args = args;
+        let uid = command == "UID";
+        if uid {
+            let (uid_command, uid_args) = args.split_once(' ').unwrap_or((args, ""));
+            command = uid_command.to_ascii_uppercase();
+            args = uid_args;
+        }
+
+        let open = session.handle(tag, &command, args, uid);
+        write.write_all(&std::mem::take(&mut session.out)).await?;
+        if !open {
+            return Ok(());
+        }
+    }
+}
+
+/// Read a command line, inlining any literals as quoted strings
+async fn read_command<R, W>(reader: &mut R, write: &mut W) -> Result<Option<String>>
+where
+    R: tokio::io::AsyncBufRead + Unpin,
+    W: tokio::io::AsyncWrite + Unpin,
+{
+    let mut command = String::new();
+    loop {
+        let Some(line) = read_command_line(reader).await? else {
+            return Ok(None);
+        };
+        let literal_size = line
+            .strip_suffix('}')
+            .and_then(|head| head.rfind('{').map(|open| (open, &head[open + 1..])))
+            .and_then(|(open, spec)| {
+                let synchronizing = !spec.ends_with('+');
+                let size = spec.trim_end_matches('+').parse::<usize>().ok()?;
+                Some((open, size, synchronizing))
+            });
+        let Some((open, size, synchronizing)) = literal_size else {
+            command.push_str(&line);
+            return Ok(Some(command));
+        };
+        if size > MAX_LITERAL_BYTES {
+            return Ok(None);
+        }
+        if synchronizing {
+            write.write_all(b"+ Ready\r\n").await?;
+        }
+        let mut literal = vec![0; size];
+        reader.read_exact(&mut literal).await?;
+        command.push_str(&line[..open]);
+        command.push_str(&quote(&String::from_utf8_lossy(&literal)));
+    }
+}
+
+impl ImapSession {
+    fn line(&mut self, line: &str) {
+        self.out.extend_from_slice(line.as_bytes());
+        self.out.extend_from_slice(b"\r\n");
+    }
+
+    fn user(&self) -> &str {
+        self.user.as_deref().unwrap_or_default()
+    }
+
+    /// The logged-in user's mailbox entries, in sequence order
+    fn entries(&self) -> Vec<MailboxEntry> {
+        self.registry.mailboxes().with(self.user(), |mailbox| mailbox.entries.clone())
+    }
+
+    /// The message an entry refers to, as the client reads it
+    fn message(&self, entry: &MailboxEntry) -> Option<(Vec<u8>, chrono::DateTime<chrono::Utc>)> {
+        let email = self.registry.get_email_by_id(&entry.email_id).ok().flatten()?;
+        Some((message_data(&email), email.received_at))
+    }
+
+    /// Run one command and report whether the session continues
+    fn handle(&mut self, tag: &str, command: &str, args: &str, uid: bool) -> bool {
+        let fields = fields(args);
+        match command {
+            "CAPABILITY" => self.line("* CAPABILITY IMAP4rev1"),
+            "NOOP" | "CHECK" => {}
+            "LOGOUT" => {
+                self.line("* BYE MockForge IMAP closing");
+                self.line(&format!("{} OK LOGOUT completed", tag));
+                return false;
+            }
+            "LOGIN" => {
+                if fields.len() != 2 {
+                    self.line(&format!("{} BAD LOGIN expects a user name and password", tag));
+                    return true;
+                }
+                self.user = Some(fields[0].clone());
+                self.selected = false;
+            }
+            "LIST" | "LSUB" => {
+                if fields.len() == 2 && !fields[1].is_empty() {
+                    self.line(&format!("* {} (\\HasNoChildren) \"/\" INBOX", command));
+                }
+            }
+            _ => {
+                if self.user.is_none() {
+                    self.line(&format!("{} NO not authenticated", tag));
+                    return true;
+                }
+                if let Err(e) = self.handle_authenticated(command, &fields, uid) {
+                    self.line(&format!("{} {}", tag, e));
+                    return true;
+                }
+            }
+        }
+
+        self.report_exists();
+        self.line(&format!("{} OK {} completed", tag, command));
+        true
+    }
+
+    /// Run a command that needs a logged-in user. Errors are the tagged
+    /// response's status and text.
+    fn handle_authenticated(
+        &mut self,
+        command: &str,
+        fields: &[String],
+        uid: bool,
+    ) -> std::result::Result<(), String> {
+        match command {
+            "SELECT" | "EXAMINE" => {
+                if fields.len() != 1 || !fields[0].eq_ignore_ascii_case("INBOX") {
+                    return Err("NO no such mailbox".to_string());
+                }
+                let (count, uid_next) = self
+                    .registry
+                    .mailboxes()
+                    .with(self.user(), |mailbox| (mailbox.entries.len(), mailbox.uid_next));
+                self.selected = true;
+                self.read_only = command == "EXAMINE";
+                self.known = count;
+                self.line("* FLAGS (\\Seen \\Answered \\Flagged \\Deleted \\Draft)");
+                self.line(&format!("* {} EXISTS", count));
+                self.line("* 0 RECENT");
+                self.line("* OK [UIDVALIDITY 1] UIDs valid");
+                self.line(&format!("* OK [UIDNEXT {}] Predicted next UID", uid_next));
+                return Ok(());
+            }
+            "STATUS" => {
+                if fields.len() != 2 || !fields[0].eq_ignore_ascii_case("INBOX") {
+                    return Err("NO no such mailbox".to_string());
+                }
+                let (messages, uid_next, unseen) =
+                    self.registry.mailboxes().with(self.user(), |mailbox| {
+                        let unseen =
+                            mailbox.entries.iter().filter(|e| !e.flags.contains(SEEN)).count();
+                        (mailbox.entries.len(), mailbox.uid_next, unseen)
+                    });
+                let items: Vec<String> = self::fields(unparen(&fields[1]))
+                    .iter()
+                    .filter_map(|item| {
+                        let item = item.to_ascii_uppercase();
+                        let value = match item.as_str() {
+                            "MESSAGES" => messages,
+                            "RECENT" => 0,
+                            "UIDNEXT" => uid_next as usize,
+                            "UIDVALIDITY" => 1,
+                            "UNSEEN" => unseen,
+                            _ => return None,
+                        };
+                        Some(format!("{} {}", item, value))
+                    })
+                    .collect();
+                self.line(&format!("* STATUS INBOX ({})", items.join(" ")));
+                return Ok(());
+            }
+            _ => {}
+        }
+
+        if !self.selected {
+            return Err("BAD no mailbox selected".to_string());
+        }
+        let result = match command {
+            "FETCH" => self.fetch(fields, uid),
+            "STORE" => self.store(fields, uid),
+            "SEARCH" => self.search(fields, uid),
+            "EXPUNGE" => {
+                self.expunge(true);
+                Ok(())
+            }
+            "CLOSE" => {
+                self.expunge(false);
+                self.selected = false;
+                Ok(())
+            }
+            _ => Err(format!("unsupported command {}", command)),
+        };
+        result.map_err(|e| format!("BAD {}", e))
+    }
+
+    /// Tell the client about messages delivered since it last looked at the
+    /// selected mailbox
+    fn report_exists(&mut self) {
+        if !self.selected {
+            return;
+        }
+        let count = self.registry.mailboxes().with(self.user(), |mailbox| mailbox.entries.len());
+        if count != self.known {
+            self.known = count;
+            self.line(&format!("* {} EXISTS", count));
+        }
+    }
+
+    /// The entries addressed by a sequence set, with their sequence numbers
+    fn matching(
+        &self,
+        set: &str,
+        uid: bool,
+    ) -> std::result::Result<Vec<(usize, MailboxEntry)>, String> {
+        let entries = self.entries();
+        let max = if uid {
+            entries.last().map_or(0, |e| e.uid)
+        } else {
+            entries.len() as u32
+        };
+        let spans = sequence_set(set, max)?;
+        Ok(entries
+            .into_iter()
+            .enumerate()
+            .filter(|(i, entry)| {
+                let key = if uid { entry.uid } else { *i as u32 + 1 };
+                spans.iter().any(|(lo, hi)| (*lo..=*hi).contains(&key))
+            })
+            .map(|(i, entry)| (i + 1, entry))
+            .collect())
+    }
+
+    /// FETCH and UID FETCH
+    fn fetch(&mut self, fields: &[String], uid: bool) -> std::result::Result<(), String> {
+        if fields.len() < 2 {
+            return Err("FETCH expects a sequence set and items".to_string());
+        }
+        let mut items: Vec<String> = Vec::new();
+        if uid {
+            items.push("UID".to_string());
+        }
+        for item in self::fields(unparen(&fields[1..].join(" "))) {
+            match item.to_ascii_uppercase().as_str() {
+                "ALL" | "FULL" => items
+                    .extend(["FLAGS", "INTERNALDATE", "RFC822.SIZE", "ENVELOPE"].map(String::from)),
+                "FAST" => items.extend(["FLAGS", "INTERNALDATE", "RFC822.SIZE"].map(String::from)),
+                _ => items.push(item),
+            }
+        }
+
+        let mut seen_uids = Vec::new();
+        for (number, mut entry) in self.matching(&fields[0], uid)? {
+            let Some((data, received_at)) = self.message(&entry) else {
+                continue;
+            };
+            // Fetching a body implicitly sets \Seen, which the response reflects
+            let mut entry_items = items.clone();
+            if !self.read_only && !entry.flags.contains(SEEN) && sets_seen(&items) {
+                entry.flags.insert(SEEN.to_string());
+                seen_uids.push(entry.uid);
+                if !items.iter().any(|item| item.eq_ignore_ascii_case("FLAGS")) {
+                    entry_items.push("FLAGS".to_string());
+                }
+            }
+
+            let (header, body) = split_message(&data);
+            let mut parts: Vec<Vec<u8>> = Vec::new();
+            let mut done = std::collections::HashSet::new();
+            for item in &entry_items {
+                let upper = item.to_ascii_uppercase();
+                if !done.insert(upper.clone()) {
+                    continue;
+                }
+                let name = upper.split('[').next().unwrap_or_default();
+                let part = match name {
+                    "UID" => format!("UID {}", entry.uid).into_bytes(),
+                    "FLAGS" => format!("FLAGS {}", flag_list(&entry)).into_bytes(),
+                    "INTERNALDATE" => format!(
+                        "INTERNALDATE {}",
+                        quote(&received_at.format("%d-%b-%Y %H:%M:%S %z").to_string())
+                    )
+                    .into_bytes(),
+                    "RFC822.SIZE" => format!("RFC822.SIZE {}", data.len()).into_bytes(),
+                    "ENVELOPE" => format!("ENVELOPE {}", envelope(&data)).into_bytes(),
+                    "RFC822" => literal_item("RFC822", &data),
+                    "RFC822.HEADER" => literal_item("RFC822.HEADER", header),
+                    "RFC822.TEXT" => literal_item("RFC822.TEXT", body),
+                    "BODY" | "BODY.PEEK" => {
+                        let (Some(open), Some(close)) = (item.find('['), item.rfind(']')) else {
+                            return Err("BODYSTRUCTURE is not supported".to_string());
+                        };
+                        if close < open {
+                            return Err("BODYSTRUCTURE is not supported".to_string());
+                        }
+                        let section_name = &item[open + 1..close];
+                        let mut content = section(&data, section_name)?;
+                        let mut key = format!("BODY[{}]", section_name);
+                        let partial = &item[close + 1..];
+                        if !partial.is_empty() {
+                            let (start, length) = parse_partial(partial)
+                                .ok_or_else(|| format!("invalid partial {}", partial))?;
+                            let end = start.saturating_add(length).min(content.len());
+                            content = content[start.min(content.len())..end].to_vec();
+                            key.push_str(&format!("<{}>", start));
+                        }
+                        literal_item(&key, &content)
+                    }
+                    _ => return Err(format!("unsupported fetch item {}", item)),
+                };
+                parts.push(part);
+            }
+
+            self.out.extend_from_slice(format!("* {} FETCH (", number).as_bytes());
+            self.out.extend_from_slice(&parts.join(&b' '));
+            self.out.extend_from_slice(b")\r\n");
+        }
+
+        if !seen_uids.is_empty() {
+            self.registry.mailboxes().with(self.user(), |mailbox| {
+                for entry in &mut mailbox.entries {
+                    if seen_uids.contains(&entry.uid) {
+                        entry.flags.insert(SEEN.to_string());
+                    }
+                }
+            });
+        }
+        Ok(())
+    }
+
+    /// STORE and UID STORE
+    fn store(&mut self, fields: &[String], uid: bool) -> std::result::Result<(), String> {
+        if fields.len() < 3 {
+            return Err("STORE expects a sequence set, an operation, and flags".to_string());
+        }
+        let operation = fields[1].to_ascii_uppercase();
+        let silent = operation.ends_with(".SILENT");
+        let operation = operation.trim_end_matches(".SILENT");
+        if !matches!(operation, "FLAGS" | "+FLAGS" | "-FLAGS") {
+            return Err(format!("unknown STORE operation {}", fields[1]));
+        }
+        let flags: Vec<String> = self::fields(unparen(&fields[2..].join(" ")))
+            .iter()
+            .map(|f| canonical_flag(f))
+            .collect();
+        let matched = self.matching(&fields[0], uid)?;
+        if self.read_only {
+            return Err("mailbox is read-only".to_string());
+        }
+
+        let user = self.user().to_string();
+        let updated: Vec<(usize, MailboxEntry)> =
+            self.registry.mailboxes().with(&user, |mailbox| {
+                matched
+                    .iter()
+                    .filter_map(|(number, matched)| {
+                        let entry = mailbox.entries.iter_mut().find(|e| e.uid == matched.uid)?;
+                        match operation {
+                            "FLAGS" => entry.flags = flags.iter().cloned().collect(),
+                            "+FLAGS" => entry.flags.extend(flags.iter().cloned()),
+                            _ => entry.flags.retain(|flag| !flags.contains(flag)),
+                        }
+                        Some((*number, entry.clone()))
+                    })
+                    .collect()
+            });
+        if !silent {
+            for (number, entry) in updated {
+                if uid {
+                    self.line(&format!(
+                        "* {} FETCH (UID {} FLAGS {})",
+                        number,
+                        entry.uid,
+                        flag_list(&entry)
+                    ));
+                } else {
+                    self.line(&format!("* {} FETCH (FLAGS {})", number, flag_list(&entry)));
+                }
+            }
+        }
+        Ok(())
+    }
+
+    /// SEARCH and UID SEARCH. Criteria are ANDed; supported keys are ALL,
+    /// SEEN, UNSEEN, NEW, DELETED, UNDELETED, FROM, TO, SUBJECT, BODY, TEXT,
+    /// UID, and sequence sets.
+    fn search(&mut self, fields: &[String], uid: bool) -> std::result::Result<(), String> {
+        let entries = self.entries();
+        let messages: Vec<Vec<u8>> = entries
+            .iter()
+            .map(|entry| self.message(entry).map(|(data, _)| data).unwrap_or_default())
+            .collect();
+        let mut keep = vec![true; entries.len()];
+
+        let mut i = 0;
+        while i < fields.len() {
+            let key = fields[i].to_ascii_uppercase();
+            let mut argument = || {
+                i += 1;
+                fields
+                    .get(i)
+                    .cloned()
+                    .ok_or_else(|| format!("SEARCH {} expects an argument", key))
+            };
+            let matches: Vec<bool> = match key.as_str() {
+                "ALL" => vec![true; entries.len()],
+                "CHARSET" => {
+                    argument()?;
+                    vec![true; entries.len()]
+                }
+                "SEEN" | "UNSEEN" | "NEW" | "DELETED" | "UNDELETED" => {
+                    let (flag, want) = match key.as_str() {
+                        "SEEN" => (SEEN, true),
+                        "DELETED" => (DELETED, true),
+                        "UNDELETED" => (DELETED, false),
+                        _ => (SEEN, false),
+                    };
+                    entries.iter().map(|e| e.flags.contains(flag) == want).collect()
+                }
+                "FROM" | "TO" | "SUBJECT" => {
+                    let value = argument()?;
+                    messages
+                        .iter()
+                        .map(|data| {
+                            let fields = header_fields(data);
+                            header_value(&fields, &key)
+                                .is_some_and(|v| contains_ignore_case(v.as_bytes(), &value))
+                        })
+                        .collect()
+                }
+                "BODY" | "TEXT" => {
+                    let value = argument()?;
+                    messages
+                        .iter()
+                        .map(|data| {
+                            let (header, body) = split_message(data);
+                            contains_ignore_case(body, &value)
+                                || (key == "TEXT" && contains_ignore_case(header, &value))
+                        })
+                        .collect()
+                }
+                "UID" => {
+                    let set = argument()?;
+                    let spans = sequence_set(&set, entries.last().map_or(0, |e| e.uid))?;
+                    entries
+                        .iter()
+                        .map(|e| spans.iter().any(|(lo, hi)| (*lo..=*hi).contains(&e.uid)))
+                        .collect()
+                }
+                _ => {
+                    let spans = sequence_set(&fields[i], entries.len() as u32)
+                        .map_err(|_| format!("unsupported search key {}", fields[i]))?;
+                    (1..=entries.len() as u32)
+                        .map(|n| spans.iter().any(|(lo, hi)| (*lo..=*hi).contains(&n)))
+                        .collect()
+                }
+            };
+            for (keep, matches) in keep.iter_mut().zip(matches) {
+                *keep &= matches;
+            }
+            i += 1;
+        }
+
+        let results: Vec<String> = entries
+            .iter()
+            .enumerate()
+            .filter(|(i, _)| keep[*i])
+            .map(|(i, entry)| (if uid { entry.uid as usize } else { i + 1 }).to_string())
+            .collect();
+        self.line(format!("* SEARCH {}", results.join(" ")).trim_end());
+        Ok(())
+    }
+
+    /// Remove messages flagged `\Deleted` from the selected mailbox
+    fn expunge(&mut self, report: bool) {
+        if self.read_only {
+            return;
+        }
+        let user = self.user().to_string();
+        let expunged: Vec<usize> = self.registry.mailboxes().with(&user, |mailbox| {
+            let mut expunged = Vec::new();
+            let mut i = 0;
+            mailbox.entries.retain(|entry| {
+                i += 1;
+                if entry.flags.contains(DELETED) {
+                    // Each EXPUNGE renumbers the messages after it
+                    expunged.push(i - expunged.len());
+                    false
+                } else {
+                    true
+                }
+            });
+            expunged
+        });
+        self.known = self.known.saturating_sub(expunged.len());
+        if report {
+            for number in expunged {
+                self.line(&format!("* {} EXPUNGE", number));
+            }
+        }
+    }
+}
+
+/// Split IMAP arguments on spaces outside quotes, parentheses, and brackets,
+/// unquoting quoted strings
+fn fields(args: &str) -> Vec<String> {
+    let mut fields = Vec::new();
+    let mut field = String::new();
+    let (mut depth, mut quoted, mut in_field) = (0usize, false, false);
+    let mut chars = args.chars();
+    while let Some(c) = chars.next() {
+        match c {
+            '\\' if quoted => {
+                if let Some(escaped) = chars.next() {
+                    field.push(escaped);
+                }
+            }
+            '"' if depth == 0 => {
+                quoted = !quoted;
+                in_field = true;
+            }
+            _ if quoted => field.push(c),
+            ' ' if depth == 0 => {
+                if in_field {
+                    fields.push(std::mem::take(&mut field));
+                    in_field = false;
+                }
+            }
+            _ => {
+                if c == '(' || c == '[' {
+                    depth += 1;
+                } else if (c == ')' || c == ']') && depth > 0 {
+                    depth -= 1;
+                }
+                field.push(c);
+                in_field = true;
+            }
+        }
+    }
+    if in_field {
+        fields.push(field);
+    }
+    fields
+}
+
+/// Strip the parentheses around a list
+fn unparen(list: &str) -> &str {
+    list.strip_prefix('(').and_then(|l| l.strip_suffix(')')).unwrap_or(list)
+}
+
+/// Parse a set such as `1:3,5,7:*`, where `*` is `max`, into inclusive spans
+fn sequence_set(set: &str, max: u32) -> std::result::Result<Vec<(u32, u32)>, String> {
+    let parse = |s: &str| -> std::result::Result<u32, String> {
+        if s == "*" {
+            return Ok(max);
+        }
+        s.parse::<u32>()
+            .ok()
+            .filter(|n| *n > 0)
+            .ok_or_else(|| format!("invalid sequence set {}", set))
+    };
+    set.split(',')
+        .map(|part| {
+            let (lo, hi) = match part.split_once(':') {
+                Some((lo, hi)) => (parse(lo)?, parse(hi)?),
+                None => {
+                    let n = parse(part)?;
+                    (n, n)
+                }
+            };
+            Ok((lo.min(hi), lo.max(hi)))
+        })
+        .collect()
+}
+
+/// Whether fetching `items` reads a message body
+fn sets_seen(items: &[String]) -> bool {
+    items.iter().any(|item| {
+        let upper = item.to_ascii_uppercase();
+        upper == "RFC822" || upper == "RFC822.TEXT" || upper.starts_with("BODY[")
+    })
+}
+
+/// Extract a BODY[] section: the whole message, HEADER, TEXT,
+/// HEADER.FIELDS (...), HEADER.FIELDS.NOT (...), or part 1 of a single-part
+/// message
+fn section(data: &[u8], section: &str) -> std::result::Result<Vec<u8>, String> {
+    let (header, body) = split_message(data);
+    let upper = section.to_ascii_uppercase();
+    let (name, list) = upper.split_once(' ').unwrap_or((upper.as_str(), ""));
+    match name {
+        "" => Ok(data.to_vec()),
+        "HEADER" => Ok(header.to_vec()),
+        "TEXT" | "1" => Ok(body.to_vec()),
+        "HEADER.FIELDS" | "HEADER.FIELDS.NOT" => {
+            let wanted: Vec<String> =
+                fields(unparen(list)).iter().map(|f| f.to_lowercase()).collect();
+            let keep_listed = name == "HEADER.FIELDS";
+            let mut filtered = Vec::new();
+            let mut include = false;
+            for line in header.split_inclusive(|b| *b == b'\n') {
+                if line == b"\r\n" {
+                    continue;
+                }
+                if !line.starts_with(b" ") && !line.starts_with(b"\t") {
+                    let field = line.split(|b| *b == b':').next().unwrap_or_default();
+                    let field = String::from_utf8_lossy(field).trim().to_lowercase();
+                    include = wanted.contains(&field) == keep_listed;
+                }
+                if include {
+                    filtered.extend_from_slice(line);
+                }
+            }
+            filtered.extend_from_slice(b"\r\n");
+            Ok(filtered)
+        }
+        _ => Err(format!("unsupported body section {}", section)),
+    }
+}
+
+/// Parse a `<start.length>` partial fetch suffix
+fn parse_partial(partial: &str) -> Option<(usize, usize)> {
+    let inner = partial.strip_prefix('<')?.strip_suffix('>')?;
+    let (start, length) = inner.split_once('.')?;
+    Some((start.parse().ok()?, length.parse().ok()?))
+}
+
+/// Canonicalize the case of system flags
+fn canonical_flag(flag: &str) -> String {
+    SYSTEM_FLAGS
+        .iter()
+        .find(|system| system.eq_ignore_ascii_case(flag))
+        .map_or_else(|| flag.to_string(), |system| system.to_string())
+}
+
+/// An entry's flags as a parenthesized list
+fn flag_list(entry: &MailboxEntry) -> String {
+    format!("({})", entry.flags.iter().cloned().collect::<Vec<_>>().join(" "))
+}
+
+/// A fetch item with its value as an IMAP literal
+fn literal_item(name: &str, data: &[u8]) -> Vec<u8> {
+    let mut item = format!("{} {{{}}}\r\n", name, data.len()).into_bytes();
+    item.extend_from_slice(data);
+    item
+}
+
+/// Format `s` as an IMAP quoted string
+fn quote(s: &str) -> String {
+    format!("\"{}\"", s.replace('\\', "\\\\").replace('"', "\\\""))
+}
+
+/// Format `s` as a quoted string, or NIL when empty
+fn nstring(s: Option<&str>) -> String {
+    match s {
+        Some(s) if !s.is_empty() => quote(s),
+        _ => "NIL".to_string(),
+    }
+}
+
+/// The ENVELOPE structure of a message
+fn envelope(data: &[u8]) -> String {
+    let fields = header_fields(data);
+    let addresses = |name: &str| {
+        let list = header_value(&fields, name).map(address_list).unwrap_or_default();
+        if list.is_empty() {
+            return "NIL".to_string();
+        }
+        let formatted: Vec<String> = list
+            .iter()
+            .map(|(display, address)| {
+                let (local, domain) = address.split_once('@').unwrap_or((address.as_str(), ""));
+                format!(
+                    "({} NIL {} {})",
+                    nstring(Some(display.as_str())),
+                    quote(local),
+                    quote(domain)
+                )
+            })
+            .collect();
+        format!("({})", formatted.concat())
+    };
+    let from = addresses("From");
+    let mut sender = addresses("Sender");
+    let mut reply_to = addresses("Reply-To");
+    if sender == "NIL" {
+        sender = from.clone();
+    }
+    if reply_to == "NIL" {
+        reply_to = from.clone();
+    }
+    format!(
+        "({} {} {} {} {} {} {} {} {} {})",
+        nstring(header_value(&fields, "Date")),
+        nstring(header_value(&fields, "Subject")),
+        from,
+        sender,
+        reply_to,
+        addresses("To"),
+        addresses("Cc"),
+        addresses("Bcc"),
+        nstring(header_value(&fields, "In-Reply-To")),
+        nstring(header_value(&fields, "Message-Id")),
+    )
+}
+
+/// Parse an address list header into display names and addresses
+fn address_list(value: &str) -> Vec<(String, String)> {
+    let mut entries = Vec::new();
+    let (mut current, mut quoted, mut angle) = (String::new(), false, false);
+    for c in value.chars() {
+        match c {
+            '"' => quoted = !quoted,
+            '<' if !quoted => angle = true,
+            '>' if !quoted => angle = false,
+            ',' if !quoted && !angle => {
+                entries.push(std::mem::take(&mut current));
+                continue;
+            }
+            _ => {}
+        }
+        current.push(c);
+    }
+    entries.push(current);
+
+    entries
+        .iter()
+        .filter_map(|entry| {
+            let entry = entry.trim();
+            match (entry.find('<'), entry.rfind('>')) {
+                (Some(open), Some(close)) if open < close => {
+                    let display = entry[..open].trim().trim_matches('"').to_string();
+                    Some((display, entry[open + 1..close].trim().to_string()))
+                }
+                _ if entry.contains('@') => Some((String::new(), entry.to_string())),
+                _ => None,
+            }
+        })
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_fields_unquote_and_keep_lists_together() {
+        assert_eq!(
+            fields(r#"1:* (FLAGS BODY.PEEK[HEADER.FIELDS (SUBJECT FROM)]) "a \"b\"""#),
+            vec![
+                "1:*",
+                "(FLAGS BODY.PEEK[HEADER.FIELDS (SUBJECT FROM)])",
+                "a \"b\""
+            ]
+        );
+    }
+
+    #[test]
+    fn test_sequence_set() {
+        assert_eq!(sequence_set("1:3,5,7:*", 9).unwrap(), vec![(1, 3), (5, 5), (7, 9)]);
+        assert_eq!(sequence_set("*:2", 4).unwrap(), vec![(2, 4)]);
+        assert!(sequence_set("0", 4).is_err());
+    }
+
+    #[test]
+    fn test_section_header_fields() {
+        let data =
+            b"From: a@example.com\r\nSubject: Hi\r\n there\r\nTo: b@example.com\r\n\r\nbody\r\n";
+        assert_eq!(
+            section(data, "HEADER.FIELDS (SUBJECT)").unwrap(),
+            b"Subject: Hi\r\n there\r\n\r\n"
+        );
+        assert_eq!(section(data, "TEXT").unwrap(), b"body\r\n");
+        assert!(section(data, "2.MIME").is_err());
+    }
+
+    #[test]
+    fn test_envelope() {
+        let data = b"From: \"Shop, Inc\" <shop@example.com>\r\n\
+                     To: a@example.com, B <b@example.com>\r\n\
+                     Subject: Order\r\n\r\n";
+        assert_eq!(
+            envelope(data),
+            "(NIL \"Order\" ((\"Shop, Inc\" NIL \"shop\" \"example.com\")) \
+             ((\"Shop, Inc\" NIL \"shop\" \"example.com\")) \
+             ((\"Shop, Inc\" NIL \"shop\" \"example.com\")) \
+             ((NIL NIL \"a\" \"example.com\")(\"B\" NIL \"b\" \"example.com\")) NIL NIL NIL NIL)"
+        );
+    }
+}
@@ -22,12 +22,17 @@
 //! ```
 
 mod fixtures;
+mod imap;
+mod mailboxes;
+mod pop3;
 /// Unified protocol server lifecycle implementation
 pub mod protocol_server;
 mod server;
 mod spec_registry;
 
 pub use fixtures::*;
+pub use imap::ImapServer;
+pub use pop3::Pop3Server;
 pub use server::*;
 pub use spec_registry::*;
 
@@ -63,6 +68,14 @@ pub struct SmtpConfig {
     pub tls_cert_path: Option<PathBuf>,
     /// Path to TLS private key file
     pub tls_key_path: Option<PathBuf>,
+    /// Port of the IMAP reader over the captured mail; 0 picks a free
+    /// port, and `None` disables it
+    #[serde(default)]
+    pub imap_port: Option<u16>,
+    /// Port of the POP3 reader over the captured mail; 0 picks a free
+    /// port, and `None` disables it
+    #[serde(default)]
+    pub pop3_port: Option<u16>,
 }
 
 /// Default maximum accepted DATA payload size (25 MiB).
@@ -85,6 +98,8 @@ impl Default for SmtpConfig {
             enable_starttls: false,
             tls_cert_path: None,
             tls_key_path: None,
+            imap_port: None,
+            pop3_port: None,
         }
     }
 }
@@ -0,0 +1,200 @@
+//! Per-recipient mailboxes served by the IMAP and POP3 readers.
+//!
+//! Every message the SMTP server captures is delivered to the mailbox of each
+//! envelope recipient. A mailbox holds the IDs of captured messages together
+//! with the UID and flags a retrieval client sees, so flags set and messages
+//! deleted over one protocol are visible to the other. A message deleted from
+//! a mailbox stays in the captured list the admin API returns.
+
+use crate::fixtures::StoredEmail;
+use std::collections::{BTreeSet, HashMap};
+use std::sync::Mutex;
+
+/// The `\Seen` system flag
+pub(crate) const SEEN: &str = "\\Seen";
+/// The `\Deleted` system flag
+pub(crate) const DELETED: &str = "\\Deleted";
+
+/// A captured message in one recipient's mailbox
+#[derive(Debug, Clone)]
+pub(crate) struct MailboxEntry {
+    /// ID of the captured message
+    pub email_id: String,
+    /// UID, unique within the mailbox and never reused
+    pub uid: u32,
+    /// IMAP flags, such as `\Seen`
+    pub flags: BTreeSet<String>,
+}
+
+/// The messages of one address
+#[derive(Debug)]
+pub(crate) struct Mailbox {
+    pub entries: Vec<MailboxEntry>,
+    /// UID the next delivered message gets
+    pub uid_next: u32,
+}
+
+impl Default for Mailbox {
+    fn default() -> Self {
+        Self {
+            entries: Vec::new(),
+            uid_next: 1,
+        }
+    }
+}
+
+/// Mailboxes by lowercased address
+#[derive(Debug, Default)]
+pub(crate) struct Mailboxes {
+    boxes: Mutex<HashMap<String, Mailbox>>,
+}
+
+impl Mailboxes {
+    /// Add a captured message to each recipient's mailbox
+    pub fn deliver(&self, email_id: &str, recipients: &[String]) {
+        let mut boxes = self.boxes.lock().unwrap_or_else(|e| e.into_inner());
+        for address in recipients {
+            let mailbox = boxes.entry(address.to_lowercase()).or_default();
+            mailbox.entries.push(MailboxEntry {
+                email_id: email_id.to_string(),
+                uid: mailbox.uid_next,
+                flags: BTreeSet::new(),
+            });
+            mailbox.uid_next += 1;
+        }
+    }
+
+    /// Run `f` on `address`'s mailbox, creating it if needed
+    pub fn with<R>(&self, address: &str, f: impl FnOnce(&mut Mailbox) -> R) -> R {
+        let mut boxes = self.boxes.lock().unwrap_or_else(|e| e.into_inner());
+        f(boxes.entry(address.to_lowercase()).or_default())
+    }
+
+    /// Drop a message evicted from the captured list from every mailbox
+    pub fn forget(&self, email_id: &str) {
+        let mut boxes = self.boxes.lock().unwrap_or_else(|e| e.into_inner());
+        for mailbox in boxes.values_mut() {
+            mailbox.entries.retain(|entry| entry.email_id != email_id);
+        }
+    }
+
+    /// Empty every mailbox. UIDs keep counting up, as IMAP requires.
+    pub fn clear(&self) {
+        let mut boxes = self.boxes.lock().unwrap_or_else(|e| e.into_inner());
+        for mailbox in boxes.values_mut() {
+            mailbox.entries.clear();
+        }
+    }
+}
+
+/// The RFC 5322 message a retrieval client reads, with CRLF line endings
+pub(crate) fn message_data(email: &StoredEmail) -> Vec<u8> {
+    let raw = match &email.raw {
+        Some(raw) => raw.clone(),
+        None => format!("Subject: {}\n\n{}", email.subject, email.body).into_bytes(),
+    };
+    let mut data = Vec::with_capacity(raw.len() + raw.len() / 32);
+    for (i, &byte) in raw.iter().enumerate() {
+        if byte == b'\n' && (i == 0 || raw[i - 1] != b'\r') {
+            data.push(b'\r');
+        }
+        data.push(byte);
+    }
+    data
+}
+
+/// Split a message into its header, including the blank line that ends it,
+/// and its body
+pub(crate) fn split_message(data: &[u8]) -> (&[u8], &[u8]) {
+    match data.windows(4).position(|w| w == b"\r\n\r\n") {
+        Some(i) => data.split_at(i + 4),
+        None => (data, &[]),
+    }
+}
+
+/// The unfolded header fields of a message, in order
+pub(crate) fn header_fields(data: &[u8]) -> Vec<(String, String)> {
+    let (header, _) = split_message(data);
+    let mut fields: Vec<(String, String)> = Vec::new();
+    for line in String::from_utf8_lossy(header).split("\r\n") {
+        if line.is_empty() {
+            continue;
+        }
+        if line.starts_with([' ', '\t']) {
+            if let Some((_, value)) = fields.last_mut() {
+                value.push(' ');
+                value.push_str(line.trim());
+            }
+        } else if let Some((name, value)) = line.split_once(':') {
+            fields.push((name.trim().to_string(), value.trim().to_string()));
+        }
+    }
+    fields
+}
+
+/// The first value of a header field, matched case-insensitively
+pub(crate) fn header_value<'a>(fields: &'a [(String, String)], name: &str) -> Option<&'a str> {
+    fields
+        .iter()
+        .find(|(n, _)| n.eq_ignore_ascii_case(name))
+        .map(|(_, v)| v.as_str())
+}
+
+/// Case-insensitive substring search
+pub(crate) fn contains_ignore_case(haystack: &[u8], needle: &str) -> bool {
+    String::from_utf8_lossy(haystack)
+        .to_lowercase()
+        .contains(&needle.to_lowercase())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_deliver_assigns_increasing_uids_per_mailbox() {
+        let mailboxes = Mailboxes::default();
+        mailboxes.deliver(
+            "a",
+            &[
+                "Alice@Example.com".to_string(),
+                "bob@example.com".to_string(),
+            ],
+        );
+        mailboxes.deliver("b", &["alice@example.com".to_string()]);
+
+        let uids = mailboxes.with("ALICE@example.com", |mailbox| {
+            mailbox.entries.iter().map(|e| e.uid).collect::<Vec<_>>()
+        });
+        assert_eq!(uids, vec![1, 2]);
+
+        mailboxes.forget("a");
+        mailboxes.clear();
+        mailboxes.deliver("c", &["alice@example.com".to_string()]);
+        let uids = mailboxes.with("alice@example.com", |mailbox| {
+            mailbox.entries.iter().map(|e| e.uid).collect::<Vec<_>>()
+        });
+        assert_eq!(uids, vec![3]);
+    }
+
+    #[test]
+    fn test_message_data_normalizes_line_endings() {
+        let email = StoredEmail {
+            id: "1".to_string(),
+            from: "a@example.com".to_string(),
+            to: vec!["b@example.com".to_string()],
+            subject: "Hi".to_string(),
+            body: String::new(),
+            headers: HashMap::new(),
+            received_at: chrono::Utc::now(),
+            raw: Some(b"Subject: Hi\r\nX-Long: a\n  b\n\nbody\n".to_vec()),
+        };
+        let data = message_data(&email);
+        assert_eq!(data, b"Subject: Hi\r\nX-Long: a\r\n  b\r\n\r\nbody\r\n");
+
+        let (header, body) = split_message(&data);
+        assert_eq!(body, b"body\r\n");
+        let fields = header_fields(header);
+        assert_eq!(header_value(&fields, "x-long"), Some("a b"));
+    }
+}
@@ -0,0 +1,263 @@
+//! POP3 reader over the captured mail.
+//!
+//! Serves each recipient's mailbox as a POP3 maildrop. Any password is
+//! accepted; the user name selects the mailbox, which is the recipient
+//! address (case-insensitive). RETR marks a message `\Seen` for IMAP
+//! clients, and messages deleted with DELE leave the mailbox at QUIT, as
+//! RFC 1939 specifies. TLS is not offered.
+
+use crate::mailboxes::{message_data, split_message, MailboxEntry, SEEN};
+use crate::server::read_command_line;
+use crate::SmtpSpecRegistry;
+use mockforge_core::Result;
+use std::collections::HashSet;
+use std::sync::Arc;
+use tokio::io::{AsyncWriteExt, BufReader};
+use tokio::net::{TcpListener, TcpStream};
+use tracing::{debug, info};
+
+/// POP3 server reading the SMTP server's mailboxes
+pub struct Pop3Server {
+    host: String,
+    port: u16,
+    registry: Arc<SmtpSpecRegistry>,
+}
+
+impl Pop3Server {
+    /// Create a POP3 server for the mail captured in `registry`
+    pub fn new(host: impl Into<String>, port: u16, registry: Arc<SmtpSpecRegistry>) -> Self {
+        Self {
+            host: host.into(),
+            port,
+            registry,
+        }
+    }
+
+    /// Start the POP3 server
+    pub async fn start(&self) -> Result<()> {
+        let listener = self.bind().await?;
+        self.serve(listener).await
+    }
+
+    /// Bind the configured address. With port 0 the listener's
+    /// `local_addr` reports the port the OS picked.
+    pub async fn bind(&self) -> Result<TcpListener> {
+        Ok(TcpListener::bind((self.host.as_str(), self.port)).await?)
+    }
+
+    /// Accept POP3 sessions on a listener from [`Pop3Server::bind`]
+    pub async fn serve(&self, listener: TcpListener) -> Result<()> {
+        info!("POP3 server listening on {}", listener.local_addr()?);
+        loop {
+            let (stream, peer_addr) = listener.accept().await?;
+            let registry = self.registry.clone();
+            tokio::spawn(async move {
+                if let Err(e) = handle_pop3_session(stream, registry).await {
+                    debug!("POP3 session from {} ended: {}", peer_addr, e);
+                }
+            });
+        }
+    }
+}
+
+/// The maildrop a session works on: a snapshot of the mailbox taken at login
+struct Maildrop {
+    user: String,
+    entries: Vec<MailboxEntry>,
+    deleted: HashSet<usize>,
+}
+
+impl Maildrop {
+    /// The message numbered `arg`, unless it is missing or deleted
+    fn entry(&self, arg: &str) -> Option<(usize, &MailboxEntry)> {
+        let number = arg.parse::<usize>().ok()?;
+        if number == 0 || self.deleted.contains(&number) {
+            return None;
+        }
+        self.entries.get(number - 1).map(|entry| (number, entry))
+    }
+
+    /// The messages not deleted in this session, with their numbers
+    fn live(&self) -> impl Iterator<Item = (usize, &MailboxEntry)> {
+        self.entries
+            .iter()
+            .enumerate()
+            .map(|(i, entry)| (i + 1, entry))
+            .filter(|(number, _)| !self.deleted.contains(number))
+    }
+}
+
+async fn handle_pop3_session(stream: TcpStream, registry: Arc<SmtpSpecRegistry>) -> Result<()> {
+    let (read, mut write) = stream.into_split();
+    let mut reader = BufReader::new(read);
+    write.write_all(b"+OK MockForge POP3 ready\r\n").await?;
+
+    // Message data by entry, loaded once so sizes and content agree
+    let load = |entry: &MailboxEntry| -> Vec<u8> {
+        registry
+            .get_email_by_id(&entry.email_id)
+            .ok()
+            .flatten()
+            .map(|email| message_data(&email))
+            .unwrap_or_default()
+    };
+
+    let mut user: Option<String> = None;
+    let mut maildrop: Option<Maildrop> = None;
+    let mut messages: Vec<Vec<u8>> = Vec::new();
+    while let Some(line) = read_command_line(&mut reader).await? {
+        let (command, arg) = line.split_once(' ').unwrap_or((line.as_str(), ""));
+        let command = command.to_ascii_uppercase();
+        let arg = arg.trim();
+        let mut out = Vec::new();
+
+        match (command.as_str(), maildrop.as_mut()) {
+            ("CAPA", _) => out.extend_from_slice(b"+OK\r\nUSER\r\nUIDL\r\nTOP\r\n.\r\n"),
+            ("USER", None) => {
+                user = Some(arg.to_string());
+                out.extend_from_slice(b"+OK\r\n");
+            }
+            ("PASS", None) => match user.take() {
+                Some(user) => {
+                    let entries =
+                        registry.mailboxes().with(&user, |mailbox| mailbox.entries.clone());
+                    messages = entries.iter().map(&load).collect();
+                    out.extend_from_slice(
+                        format!("+OK maildrop has {} messages\r\n", entries.len()).as_bytes(),
+                    );
+                    maildrop = Some(Maildrop {
+                        user,
+                        entries,
+                        deleted: HashSet::new(),
+                    });
+                }
+                None => out.extend_from_slice(b"-ERR USER first\r\n"),
+            },
+            ("QUIT", drop) => {
+                if let Some(drop) = drop {
+                    let deleted: Vec<u32> =
+                        drop.deleted.iter().map(|number| drop.entries[number - 1].uid).collect();
+                    registry.mailboxes().with(&drop.user, |mailbox| {
+                        mailbox.entries.retain(|entry| !deleted.contains(&entry.uid));
+                    });
+                }
+                write.write_all(b"+OK MockForge POP3 closing\r\n").await?;
+                return Ok(());
+            }
+            ("NOOP", Some(_)) => out.extend_from_slice(b"+OK\r\n"),
+            ("STAT", Some(drop)) => {
+                let (count, size) = drop.live().fold((0, 0), |(count, size), (number, _)| {
+                    (count + 1, size + messages[number - 1].len())
+                });
+                out.extend_from_slice(format!("+OK {} {}\r\n", count, size).as_bytes());
+            }
+            ("LIST" | "UIDL", Some(drop)) => {
+                let describe = |number: usize, entry: &MailboxEntry| {
+                    if command == "LIST" {
+                        format!("{} {}", number, messages[number - 1].len())
+                    } else {
+                        format!("{} {}", number, entry.uid)
+                    }
+                };
+                if arg.is_empty() {
+                    out.extend_from_slice(b"+OK\r\n");
+                    for (number, entry) in drop.live() {
+                        out.extend_from_slice(
+                            format!("{}\r\n", describe(number, entry)).as_bytes(),
+                        );
+                    }
+                    out.extend_from_slice(b".\r\n");
+                } else {
+                    match drop.entry(arg) {
+                        Some((number, entry)) => out.extend_from_slice(
+                            format!("+OK {}\r\n", describe(number, entry)).as_bytes(),
+                        ),
+                        None => out.extend_from_slice(b"-ERR no such message\r\n"),
+                    }
+                }
+            }
+            ("RETR" | "TOP", Some(drop)) => {
+                let (number_arg, lines) = arg.split_once(' ').unwrap_or((arg, ""));
+                let lines = lines.trim().parse::<usize>().ok();
+                match drop.entry(number_arg) {
+                    Some(_) if command == "TOP" && lines.is_none() => {
+                        out.extend_from_slice(b"-ERR TOP expects a message and a line count\r\n")
+                    }
+                    Some((number, entry)) => {
+                        let data = &messages[number - 1];
+                        let content = match lines {
+                            Some(lines) if command == "TOP" => {
+                                let (header, body) = split_message(data);
+                                let body_len: usize = body
+                                    .split_inclusive(|b| *b == b'\n')
+                                    .take(lines)
+                                    .map(<[u8]>::len)
+                                    .sum();
+                                [header, &body[..body_len]].concat()
+                            }
+                            _ => data.clone(),
+                        };
+                        if command == "RETR" {
+                            let uid = entry.uid;
+                            registry.mailboxes().with(&drop.user, |mailbox| {
+                                if let Some(entry) =
+                                    mailbox.entries.iter_mut().find(|entry| entry.uid == uid)
+                                {
+                                    entry.flags.insert(SEEN.to_string());
+                                }
+                            });
+                        }
+                        out.extend_from_slice(format!("+OK {} octets\r\n", data.len()).as_bytes());
+                        out.extend_from_slice(&dot_stuff(&content));
+                        out.extend_from_slice(b".\r\n");
+                    }
+                    None => out.extend_from_slice(b"-ERR no such message\r\n"),
+                }
+            }
+            ("DELE", Some(drop)) => match drop.entry(arg) {
+                Some((number, _)) => {
+                    drop.deleted.insert(number);
+                    out.extend_from_slice(format!("+OK message {} deleted\r\n", number).as_bytes());
+                }
+                None => out.extend_from_slice(b"-ERR no such message\r\n"),
+            },
+            ("RSET", Some(drop)) => {
+                drop.deleted.clear();
+                out.extend_from_slice(b"+OK\r\n");
+            }
+            ("NOOP" | "STAT" | "LIST" | "UIDL" | "RETR" | "TOP" | "DELE" | "RSET", None) => {
+                out.extend_from_slice(b"-ERR not authenticated\r\n")
+            }
+            _ => out.extend_from_slice(b"-ERR unknown command\r\n"),
+        }
+        write.write_all(&out).await?;
+    }
+    Ok(())
+}
+
+/// Prefix lines starting with `.` with another `.` and make sure the data
+/// ends with CRLF, ready for the terminating `.` line
+fn dot_stuff(data: &[u8]) -> Vec<u8> {
+    let mut stuffed = Vec::with_capacity(data.len() + 2);
+    for line in data.split_inclusive(|b| *b == b'\n') {
+        if line.starts_with(b".") {
+            stuffed.push(b'.');
+        }
+        stuffed.extend_from_slice(line);
+    }
+    if !stuffed.is_empty() && !stuffed.ends_with(b"\r\n") {
+        stuffed.extend_from_slice(b"\r\n");
+    }
+    stuffed
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_dot_stuff() {
+        assert_eq!(dot_stuff(b"a\r\n.b\r\n..\r\nc"), b"a\r\n..b\r\n...\r\nc\r\n");
+        assert!(dot_stuff(b"").is_empty());
+    }
+}
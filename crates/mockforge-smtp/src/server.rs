@@ -72,6 +72,22 @@ where
     }
 }
 
+/// Read a command line for the IMAP and POP3 readers, without its line
+/// terminator. `None` once the client disconnects or sends a line longer
+/// than [`MAX_LINE_BYTES`].
+pub(crate) async fn read_command_line<R>(reader: &mut R) -> std::io::Result<Option<String>>
+where
+    R: AsyncBufReadExt + Unpin,
+{
+    let mut line = Vec::new();
+    match read_line_capped(reader, &mut line, MAX_LINE_BYTES).await? {
+        LineRead::Ok => {
+            Ok(Some(String::from_utf8_lossy(strip_line_terminator(&line)).into_owned()))
+        }
+        LineRead::Eof | LineRead::TooLong => Ok(None),
+    }
+}
+
 /// Stream wrapper that can be either plaintext TCP or TLS-upgraded.
 /// The session handler starts each connection as `Plain` and swaps to
 /// `Tls` mid-stream when the client sends STARTTLS (RFC 3207). The
@@ -217,10 +233,20 @@ impl SmtpServer {
 
     /// Start the SMTP server
     pub async fn start(&self) -> Result<()> {
+        let listener = self.bind().await?;
+        self.serve(listener).await
+    }
+
+    /// Bind the configured address. With port 0 the listener's
+    /// `local_addr` reports the port the OS picked.
+    pub async fn bind(&self) -> Result<TcpListener> {
         let addr = format!("{}:{}", self.config.host, self.config.port);
-        let listener = TcpListener::bind(&addr).await?;
+        Ok(TcpListener::bind(&addr).await?)
+    }
 
-        info!("SMTP server listening on {}", addr);
+    /// Accept SMTP sessions on a listener from [`SmtpServer::bind`]
+    pub async fn serve(&self, listener: TcpListener) -> Result<()> {
+        info!("SMTP server listening on {}", listener.local_addr()?);
 
         // Cap concurrent sessions at `max_connections` so a connection flood
         // can't spawn unbounded tasks (#755). A permit is acquired before each
@@ -346,7 +372,10 @@ async fn handle_smtp_session(
                     .await?;
                 session_state.reset();
             } else {
-                session_state.data.extend_from_slice(trimmed);
+                // Undo the client's dot-stuffing (RFC 5321 §4.5.2) so the
+                // stored message is what was sent, as the readers serve it
+                let content = trimmed.strip_prefix(b".").unwrap_or(trimmed);
+                session_state.data.extend_from_slice(content);
                 session_state.data.push(b'\n');
             }
             line.clear();
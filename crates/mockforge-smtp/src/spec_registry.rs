@@ -1,6 +1,7 @@
 //! SMTP SpecRegistry implementation
 
 use crate::fixtures::{SmtpFixture, StoredEmail};
+use crate::mailboxes::Mailboxes;
 use mockforge_core::fixture_store::{
     load_fixtures_from_dir, FixtureFileFormat, FixtureFileGranularity, FixtureLoadErrorMode,
     FixtureLoadOptions,
@@ -37,6 +38,8 @@ pub struct SmtpSpecRegistry {
     mailbox: RwLock<Vec<StoredEmail>>,
     /// Maximum mailbox size
     max_mailbox_size: usize,
+    /// Per-recipient views of the mailbox, read over IMAP and POP3
+    mailboxes: Mailboxes,
 }
 
 impl SmtpSpecRegistry {
@@ -46,6 +49,7 @@ impl SmtpSpecRegistry {
             fixtures: Vec::new(),
             mailbox: RwLock::new(Vec::new()),
             max_mailbox_size: 1000,
+            mailboxes: Mailboxes::default(),
         }
     }
 
@@ -55,6 +59,7 @@ impl SmtpSpecRegistry {
             fixtures: Vec::new(),
             mailbox: RwLock::new(Vec::new()),
             max_mailbox_size: max_size,
+            mailboxes: Mailboxes::default(),
         }
     }
 
@@ -101,22 +106,39 @@ impl SmtpSpecRegistry {
         self.fixtures.iter().find(|f| f.match_criteria.match_all)
     }
 
-    /// Store an email in the mailbox
+    /// Store an email in the mailbox and deliver it to each recipient's
+    /// IMAP and POP3 mailbox
     pub fn store_email(&self, email: StoredEmail) -> Result<()> {
-        let mut mailbox = self.mailbox.write().map_err(|e| {
-            mockforge_core::Error::internal(format!("Failed to acquire mailbox lock: {}", e))
-        })?;
+        let (id, recipients) = (email.id.clone(), email.to.clone());
+        let evicted = {
+            let mut mailbox = self.mailbox.write().map_err(|e| {
+                mockforge_core::Error::internal(format!("Failed to acquire mailbox lock: {}", e))
+            })?;
+
+            // Check mailbox size limit
+            let evicted = if mailbox.len() >= self.max_mailbox_size {
+                warn!("Mailbox is full, removing oldest email");
+                Some(mailbox.remove(0).id)
+            } else {
+                None
+            };
 
-        // Check mailbox size limit
-        if mailbox.len() >= self.max_mailbox_size {
-            warn!("Mailbox is full, removing oldest email");
-            mailbox.remove(0);
-        }
+            mailbox.push(email);
+            evicted
+        };
 
-        mailbox.push(email);
+        if let Some(evicted) = evicted {
+            self.mailboxes.forget(&evicted);
+        }
+        self.mailboxes.deliver(&id, &recipients);
         Ok(())
     }
 
+    /// The per-recipient mailboxes the IMAP and POP3 readers serve
+    pub(crate) fn mailboxes(&self) -> &Mailboxes {
+        &self.mailboxes
+    }
+
     /// Get all emails from the mailbox
     pub fn get_emails(&self) -> Result<Vec<StoredEmail>> {
         let mailbox = self.mailbox.read().map_err(|e| {
@@ -142,6 +164,9 @@ impl SmtpSpecRegistry {
         })?;
 
         mailbox.clear();
+        // The captured list and the mailboxes are never locked together
+        drop(mailbox);
+        self.mailboxes.clear();
         info!("Mailbox cleared");
         Ok(())
     }
@@ -0,0 +1,237 @@
+//! End-to-end: mail delivered over SMTP is read back over IMAP and POP3.
+//!
+//! Both readers serve the mailboxes of the registry the SMTP server captures
+//! into, so flags and deletions made over one protocol show up in the other
+//! while the captured list the admin API returns is left alone.
+
+use lettre::message::{Mailbox, Message};
+use lettre::{AsyncSmtpTransport, AsyncTransport, Tokio1Executor};
+use mockforge_smtp::{ImapServer, Pop3Server, SmtpConfig, SmtpServer, SmtpSpecRegistry};
+use std::net::SocketAddr;
+use std::sync::Arc;
+use std::time::Duration;
+use tokio::io::{AsyncBufReadExt, AsyncReadExt, AsyncWriteExt, BufReader};
+use tokio::net::tcp::{OwnedReadHalf, OwnedWriteHalf};
+use tokio::net::TcpStream;
+
+/// SMTP, IMAP, and POP3 servers sharing one registry
+struct MailServers {
+    registry: Arc<SmtpSpecRegistry>,
+    smtp: SocketAddr,
+    imap: SocketAddr,
+    pop3: SocketAddr,
+}
+
+async fn start_servers() -> MailServers {
+    let registry = Arc::new(SmtpSpecRegistry::new());
+    let config = SmtpConfig {
+        port: 0,
+        host: "127.0.0.1".into(),
+        fixtures_dir: None,
+        ..SmtpConfig::default()
+    };
+    let smtp = SmtpServer::new(config, registry.clone()).unwrap();
+    let smtp_listener = smtp.bind().await.unwrap();
+    let smtp_addr = smtp_listener.local_addr().unwrap();
+    tokio::spawn(async move { smtp.serve(smtp_listener).await });
+
+    let imap = ImapServer::new("127.0.0.1", 0, registry.clone());
+    let imap_listener = imap.bind().await.unwrap();
+    let imap_addr = imap_listener.local_addr().unwrap();
+    tokio::spawn(async move { imap.serve(imap_listener).await });
+
+    let pop3 = Pop3Server::new("127.0.0.1", 0, registry.clone());
+    let pop3_listener = pop3.bind().await.unwrap();
+    let pop3_addr = pop3_listener.local_addr().unwrap();
+    tokio::spawn(async move { pop3.serve(pop3_listener).await });
+
+    MailServers {
+        registry,
+        smtp: smtp_addr,
+        imap: imap_addr,
+        pop3: pop3_addr,
+    }
+}
+
+/// Send a message over SMTP and wait until the server has captured it
+async fn send(servers: &MailServers, to: &[&str], subject: &str, body: &str) {
+    let before = servers.registry.get_emails().unwrap().len();
+    let transport: AsyncSmtpTransport<Tokio1Executor> =
+        AsyncSmtpTransport::<Tokio1Executor>::builder_dangerous("127.0.0.1")
+            .port(servers.smtp.port())
+            .build();
+    let mut builder = Message::builder()
+        .from("shop@example.test".parse::<Mailbox>().unwrap())
+        .subject(subject);
+    for address in to {
+        builder = builder.to(address.parse::<Mailbox>().unwrap());
+    }
+    transport.send(builder.body(body.to_string()).unwrap()).await.unwrap();
+
+    let deadline = tokio::time::Instant::now() + Duration::from_secs(3);
+    while servers.registry.get_emails().unwrap().len() == before {
+        assert!(tokio::time::Instant::now() < deadline, "message was never captured");
+        tokio::time::sleep(Duration::from_millis(20)).await;
+    }
+}
+
+/// A line-oriented client for the IMAP and POP3 readers
+struct Client {
+    reader: BufReader<OwnedReadHalf>,
+    writer: OwnedWriteHalf,
+}
+
+impl Client {
+    /// Connect and read the greeting
+    async fn connect(addr: SocketAddr) -> (Self, String) {
+        let (read, writer) = TcpStream::connect(addr).await.unwrap().into_split();
+        let mut client = Self {
+            reader: BufReader::new(read),
+            writer,
+        };
+        let greeting = client.line().await;
+        (client, greeting)
+    }
+
+    async fn line(&mut self) -> String {
+        let mut line = String::new();
+        self.reader.read_line(&mut line).await.unwrap();
+        line
+    }
+
+    /// Send an IMAP command and return the response up to its tagged line,
+    /// including literals
+    async fn imap(&mut self, tag: &str, command: &str) -> String {
+        self.writer
+            .write_all(format!("{} {}\r\n", tag, command).as_bytes())
+            .await
+            .unwrap();
+        let mut response = String::new();
+        loop {
+            let line = self.line().await;
+            assert!(!line.is_empty(), "connection closed during {}", command);
+            response.push_str(&line);
+            if let Some(size) = line
+                .trim_end()
+                .strip_suffix('}')
+                .and_then(|head| head.rsplit_once('{'))
+                .and_then(|(_, size)| size.parse::<usize>().ok())
+            {
+                let mut literal = vec![0; size];
+                self.reader.read_exact(&mut literal).await.unwrap();
+                response.push_str(&String::from_utf8_lossy(&literal));
+                continue;
+            }
+            if line.starts_with(&format!("{} ", tag)) {
+                return response;
+            }
+        }
+    }
+
+    /// Send a POP3 command and return its response, reading up to the
+    /// terminating `.` line when `multiline`
+    async fn pop3(&mut self, command: &str, multiline: bool) -> String {
+        self.writer.write_all(format!("{}\r\n", command).as_bytes()).await.unwrap();
+        let mut response = self.line().await;
+        if multiline && response.starts_with("+OK") {
+            loop {
+                let line = self.line().await;
+                if line == ".\r\n" || line.is_empty() {
+                    break;
+                }
+                response.push_str(&line);
+            }
+        }
+        response
+    }
+}
+
+#[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+async fn imap_reads_flags_and_expunges_delivered_mail() {
+    let servers = start_servers().await;
+    send(&servers, &["alice@example.test"], "Order shipped", "Your order is on its way").await;
+    send(&servers, &["alice@example.test"], "Reset password", "Use code 481516").await;
+
+    let (mut imap, greeting) = Client::connect(servers.imap).await;
+    assert!(greeting.starts_with("* OK"), "{greeting}");
+    assert!(imap.imap("a0", "SELECT INBOX").await.contains("NO not authenticated"));
+    assert!(imap.imap("a1", "LOGIN Alice@Example.test secret").await.contains("a1 OK"));
+    let select = imap.imap("a2", "SELECT INBOX").await;
+    assert!(select.contains("* 2 EXISTS"), "{select}");
+    assert!(select.contains("[UIDNEXT 3]"), "{select}");
+
+    let search = imap.imap("a3", "SEARCH SUBJECT reset").await;
+    assert!(search.contains("* SEARCH 2\r\n"), "{search}");
+    let search = imap.imap("a4", "UID SEARCH UNSEEN").await;
+    assert!(search.contains("* SEARCH 1 2\r\n"), "{search}");
+
+    let fetch = imap.imap("a5", "FETCH 2 (UID BODY[TEXT])").await;
+    assert!(fetch.contains("UID 2"), "{fetch}");
+    assert!(fetch.contains("Use code 481516"), "{fetch}");
+    assert!(fetch.contains("FLAGS (\\Seen)"), "{fetch}");
+    let peek = imap.imap("a6", "FETCH 1 (BODY.PEEK[HEADER.FIELDS (SUBJECT)])").await;
+    assert!(peek.contains("Subject: Order shipped"), "{peek}");
+    assert!(!peek.contains("FLAGS"), "{peek}");
+    let search = imap.imap("a7", "SEARCH UNSEEN").await;
+    assert!(search.contains("* SEARCH 1\r\n"), "{search}");
+
+    let store = imap.imap("a8", "STORE 1 +FLAGS (\\Deleted)").await;
+    assert!(store.contains("* 1 FETCH (FLAGS (\\Deleted))"), "{store}");
+    let expunge = imap.imap("a9", "EXPUNGE").await;
+    assert!(expunge.contains("* 1 EXPUNGE"), "{expunge}");
+    let fetch = imap.imap("a10", "FETCH 1:* (UID FLAGS)").await;
+    assert!(fetch.contains("* 1 FETCH (UID 2 FLAGS (\\Seen))"), "{fetch}");
+
+    // New mail shows up in the selected mailbox
+    send(&servers, &["alice@example.test"], "Welcome", "Hello").await;
+    let noop = imap.imap("a11", "NOOP").await;
+    assert!(noop.contains("* 2 EXISTS"), "{noop}");
+    assert!(imap.imap("a12", "LOGOUT").await.contains("* BYE"));
+
+    // Expunging only touches the mailbox, not the captured list
+    assert_eq!(servers.registry.get_emails().unwrap().len(), 3);
+}
+
+#[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+async fn pop3_retrieves_and_deletes_delivered_mail() {
+    let servers = start_servers().await;
+    send(
+        &servers,
+        &["bob@example.test", "carol@example.test"],
+        "Invoice",
+        "Total: 42\r\n.hidden",
+    )
+    .await;
+    send(&servers, &["bob@example.test"], "Reminder", "Pay soon").await;
+
+    let (mut pop3, greeting) = Client::connect(servers.pop3).await;
+    assert!(greeting.starts_with("+OK"), "{greeting}");
+    assert!(pop3.pop3("STAT", false).await.starts_with("-ERR not authenticated"));
+    assert!(pop3.pop3("USER bob@example.test", false).await.starts_with("+OK"));
+    assert_eq!(pop3.pop3("PASS secret", false).await, "+OK maildrop has 2 messages\r\n");
+    assert_eq!(pop3.pop3("UIDL", true).await, "+OK\r\n1 1\r\n2 2\r\n");
+
+    let retr = pop3.pop3("RETR 1", true).await;
+    assert!(retr.contains("Subject: Invoice\r\n"), "{retr}");
+    assert!(retr.contains("\r\n..hidden\r\n"), "dot-stuffed: {retr}");
+    let top = pop3.pop3("TOP 2 0", true).await;
+    assert!(top.contains("Subject: Reminder"), "{top}");
+    assert!(!top.contains("Pay soon"), "{top}");
+
+    assert!(pop3.pop3("DELE 2", false).await.starts_with("+OK"));
+    assert!(pop3.pop3("RETR 2", false).await.starts_with("-ERR no such message"));
+    assert!(pop3.pop3("STAT", false).await.starts_with("+OK 1 "));
+    assert!(pop3.pop3("QUIT", false).await.starts_with("+OK"));
+
+    // The deletion and the \Seen flag from RETR are visible over IMAP, and
+    // other recipients' mailboxes are untouched
+    let (mut imap, _) = Client::connect(servers.imap).await;
+    imap.imap("b1", "LOGIN bob@example.test x").await;
+    let select = imap.imap("b2", "EXAMINE INBOX").await;
+    assert!(select.contains("* 1 EXISTS"), "{select}");
+    let fetch = imap.imap("b3", "FETCH 1 (UID FLAGS)").await;
+    assert!(fetch.contains("* 1 FETCH (UID 1 FLAGS (\\Seen))"), "{fetch}");
+    imap.imap("b4", "LOGIN carol@example.test x").await;
+    let status = imap.imap("b5", "STATUS INBOX (MESSAGES UNSEEN)").await;
+    assert!(status.contains("* STATUS INBOX (MESSAGES 1 UNSEEN 1)"), "{status}");
+}
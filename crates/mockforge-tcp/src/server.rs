@@ -27,10 +27,20 @@ impl TcpServer {
 
     /// Start the TCP server
     pub async fn start(&self) -> Result<()> {
+        let listener = self.bind().await?;
+        self.serve(listener).await
+    }
+
+    /// Bind the configured address. With port 0 the listener's
+    /// `local_addr` reports the port the OS picked.
+    pub async fn bind(&self) -> Result<TcpListener> {
         let addr = format!("{}:{}", self.config.host, self.config.port);
-        let listener = TcpListener::bind(&addr).await?;
+        Ok(TcpListener::bind(&addr).await?)
+    }
 
-        info!("TCP server listening on {}", addr);
+    /// Accept connections on a listener from [`TcpServer::bind`]
+    pub async fn serve(&self, listener: TcpListener) -> Result<()> {
+        info!("TCP server listening on {}", listener.local_addr()?);
 
         // Cap concurrent connections at `max_connections` so a connection flood
         // can't spawn unbounded tasks (#755). A permit is acquired before each
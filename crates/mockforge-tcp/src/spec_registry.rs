@@ -59,10 +59,17 @@ impl TcpSpecRegistry {
         self.fixtures.get(identifier)
     }
 
-    /// Find a fixture matching the given data
+    /// Find a fixture matching the given data. Fixtures with `match_all`
+    /// only answer data no other fixture matches.
     pub fn find_matching_fixture(&self, data: &[u8]) -> Option<&TcpFixture> {
-        // Try to match against all fixtures
-        self.fixtures.values().find(|&fixture| fixture.matches(data)).map(|v| v as _)
+        let mut fallback = None;
+        for fixture in self.fixtures.values().filter(|fixture| fixture.matches(data)) {
+            if !fixture.match_criteria.match_all {
+                return Some(fixture);
+            }
+            fallback.get_or_insert(fixture);
+        }
+        fallback
     }
 
     /// Get all fixtures
@@ -236,6 +243,20 @@ mod tests {
         assert!(registry.get_all_fixtures().is_empty());
     }
 
+    #[test]
+    fn test_find_matching_fixture_prefers_specific_fixtures() {
+        let mut registry = TcpSpecRegistry::new();
+        registry.add_fixture(create_test_fixture("catch-all", true));
+        let mut specific = create_test_fixture("ping", false);
+        specific.match_criteria.text_pattern = Some("^PING".to_string());
+        registry.add_fixture(specific);
+
+        for _ in 0..8 {
+            assert_eq!(registry.find_matching_fixture(b"PING 1").unwrap().identifier, "ping");
+        }
+        assert_eq!(registry.find_matching_fixture(b"QUIT").unwrap().identifier, "catch-all");
+    }
+
     #[test]
     fn test_registry_clone() {
         let mut registry = TcpSpecRegistry::new();
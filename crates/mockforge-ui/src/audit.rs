@@ -73,6 +73,11 @@ pub enum AdminActionType {
     ApiKeyDeleted,
     ApiKeyRotated,
     SecurityPolicyUpdated,
+
+    // Chaos engineering
+    ChaosToggled,
+    ChaosScenarioStarted,
+    ChaosScenarioStopped,
 }
 
 /// Audit log entry for admin actions
@@ -280,6 +285,62 @@ pub fn get_global_audit_store() -> Option<Arc<AuditLogStore>> {
     GLOBAL_AUDIT_STORE.get().cloned()
 }
 
+/// Record mocks created, updated, and deleted through the HTTP server's
+/// management API in the store. Runs once per process, and only when the
+/// HTTP router has been built in it.
+pub fn audit_management_events(store: Arc<AuditLogStore>) {
+    static STARTED: std::sync::OnceLock<()> = std::sync::OnceLock::new();
+
+    let Some(mut events) = mockforge_http::management_ws::subscribe_server_events() else {
+        return;
+    };
+    let Ok(runtime) = tokio::runtime::Handle::try_current() else {
+        return;
+    };
+    if STARTED.set(()).is_err() {
+        return;
+    }
+    runtime.spawn(async move {
+        use tokio::sync::broadcast::error::RecvError;
+        loop {
+            match events.recv().await {
+                Ok(event) => {
+                    if let Some(log) = mock_event_audit_log(&event) {
+                        store.record(log).await;
+                    }
+                }
+                Err(RecvError::Lagged(missed)) => {
+                    warn!("Audit log missed {} management API events", missed);
+                }
+                Err(RecvError::Closed) => break,
+            }
+        }
+    });
+}
+
+/// The audit log entry of a management API mock event, if it changed a mock
+fn mock_event_audit_log(event: &mockforge_http::MockEvent) -> Option<AdminAuditLog> {
+    use mockforge_http::MockEvent;
+
+    let (action_type, description, resource) = match event {
+        MockEvent::MockCreated { mock, .. } => (
+            AdminActionType::RouteCreated,
+            format!("Created {} {}", mock.method, mock.path),
+            mock.id.clone(),
+        ),
+        MockEvent::MockUpdated { mock, .. } => (
+            AdminActionType::RouteUpdated,
+            format!("Updated {} {}", mock.method, mock.path),
+            mock.id.clone(),
+        ),
+        MockEvent::MockDeleted { id, .. } => {
+            (AdminActionType::RouteDeleted, format!("Deleted mock {}", id), id.clone())
+        }
+        _ => return None,
+    };
+    Some(create_audit_log(action_type, description, Some(resource), true, None, None))
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -832,4 +893,24 @@ mod tests {
         let stats = store.get_stats().await;
         assert_eq!(stats.total_actions, 100);
     }
+
+    #[test]
+    fn test_mock_event_audit_log() {
+        let mock = serde_json::from_value(serde_json::json!({
+            "id": "users",
+            "method": "GET",
+            "path": "/users",
+            "response": { "body": [] }
+        }))
+        .unwrap();
+        let log = mock_event_audit_log(&mockforge_http::MockEvent::mock_created(mock)).unwrap();
+        assert_eq!(log.action_type, AdminActionType::RouteCreated);
+        assert_eq!(log.description, "Created GET /users");
+        assert_eq!(log.resource.as_deref(), Some("users"));
+
+        let log =
+            mock_event_audit_log(&mockforge_http::MockEvent::mock_deleted("users".to_string()))
+                .unwrap();
+        assert_eq!(log.action_type, AdminActionType::RouteDeleted);
+    }
 }
@@ -1761,6 +1761,20 @@ pub async fn update_latency(
     // Update the actual configuration
     state.update_latency_config(base_ms, jitter_ms, tag_overrides.clone()).await;
 
+    // Apply the profile to traffic: base_ms plus up to jitter_ms on every response
+    if let Some(ref chaos_api_state) = state.chaos_api_state {
+        let mut chaos_config = chaos_api_state.config.write().await;
+        let enabled = base_ms > 0 || jitter_ms > 0;
+        chaos_config.latency = Some(mockforge_chaos::config::LatencyConfig {
+            enabled,
+            fixed_delay_ms: (jitter_ms == 0).then_some(base_ms),
+            random_delay_range_ms: (jitter_ms > 0).then_some((base_ms, base_ms + jitter_ms)),
+            jitter_percent: 0.0,
+            probability: 1.0,
+        });
+        chaos_config.enabled |= enabled;
+    }
+
     // Record audit log with user context
     if let Some(audit_store) = get_global_audit_store() {
         let metadata = serde_json::json!({
@@ -1819,7 +1833,7 @@ pub async fn update_faults(
 
     let failure_rate = update.data.get("failure_rate").and_then(|v| v.as_f64()).unwrap_or(0.0);
 
-    let status_codes = update
+    let status_codes: Vec<u16> = update
         .data
         .get("status_codes")
         .and_then(|v| v.as_array())
@@ -1827,7 +1841,31 @@ pub async fn update_faults(
         .unwrap_or_else(|| vec![500, 502, 503]);
 
     // Update the actual configuration
-    state.update_fault_config(enabled, failure_rate, status_codes).await;
+    state.update_fault_config(enabled, failure_rate, status_codes.clone()).await;
+
+    // Apply the faults to traffic
+    if let Some(ref chaos_api_state) = state.chaos_api_state {
+        let mut chaos_config = chaos_api_state.config.write().await;
+        chaos_config.fault_injection = Some(mockforge_chaos::config::FaultInjectionConfig {
+            enabled,
+            http_errors: status_codes,
+            http_error_probability: failure_rate,
+            ..Default::default()
+        });
+        chaos_config.enabled |= enabled;
+    }
+
+    if let Some(audit_store) = crate::audit::get_global_audit_store() {
+        let audit_log = crate::audit::create_audit_log(
+            crate::audit::AdminActionType::ConfigFaultsUpdated,
+            format!("Fault injection updated: enabled={}, failure_rate={}", enabled, failure_rate),
+            None,
+            true,
+            None,
+            None,
+        );
+        audit_store.record(audit_log).await;
+    }
 
     tracing::info!(
         "Updated fault configuration: enabled={}, failure_rate={}",
@@ -2143,17 +2181,14 @@ pub async fn get_audit_logs(
     let limit = params.get("limit").and_then(|s| s.parse::<usize>().ok());
     let offset = params.get("offset").and_then(|s| s.parse::<usize>().ok());
 
-    // Parse action type if provided
-    let action_type = action_type_str.and_then(|s| {
-        // Simple string matching - could be enhanced with proper parsing
-        match s.as_str() {
-            "config_latency_updated" => Some(AdminActionType::ConfigLatencyUpdated),
-            "config_faults_updated" => Some(AdminActionType::ConfigFaultsUpdated),
-            "server_restarted" => Some(AdminActionType::ServerRestarted),
-            "logs_cleared" => Some(AdminActionType::LogsCleared),
-            _ => None,
-        }
-    });
+    // Parse action type if provided; an unknown type matches no entries
+    let action_type = match action_type_str {
+        Some(s) => match serde_json::from_value::<AdminActionType>(serde_json::json!(s)) {
+            Ok(action_type) => Some(action_type),
+            Err(_) => return Json(ApiResponse::success(Vec::new())),
+        },
+        None => None,
+    };
 
     if let Some(audit_store) = get_global_audit_store() {
         let logs = audit_store.get_logs(action_type, user_id, limit, offset).await;
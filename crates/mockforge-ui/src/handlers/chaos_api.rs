@@ -12,6 +12,16 @@ use serde::Deserialize;
 use serde_json::json;
 
 use super::AdminState;
+use crate::audit::{create_audit_log, get_global_audit_store, AdminActionType};
+
+/// Record a chaos change in the audit log
+async fn audit(action_type: AdminActionType, description: String, resource: Option<String>) {
+    if let Some(audit_store) = get_global_audit_store() {
+        audit_store
+            .record(create_audit_log(action_type, description, resource, true, None, None))
+            .await;
+    }
+}
 
 /// Get chaos engineering status
 pub async fn get_chaos_status(State(state): State<AdminState>) -> impl IntoResponse {
@@ -61,6 +71,12 @@ pub async fn toggle_chaos(
         Some(chaos) => {
             let mut config = chaos.config.write().await;
             config.enabled = body.enabled;
+            audit(
+                AdminActionType::ChaosToggled,
+                format!("Chaos {}", if body.enabled { "enabled" } else { "disabled" }),
+                None,
+            )
+            .await;
             Json(json!({
                 "success": true,
                 "data": { "enabled": config.enabled },
@@ -82,12 +98,11 @@ pub async fn get_chaos_scenarios_predefined(State(state): State<AdminState>) ->
     let scenarios = match &state.chaos_api_state {
         Some(_) => {
             json!([
-                { "name": "network-partition", "description": "Simulates network partition between services", "severity": "high" },
-                { "name": "latency-spike", "description": "Injects random latency spikes", "severity": "medium" },
-                { "name": "error-storm", "description": "Returns 500 errors at high rate", "severity": "high" },
-                { "name": "slow-drain", "description": "Gradually increases response times", "severity": "low" },
-                { "name": "cpu-stress", "description": "Simulates CPU pressure", "severity": "medium" },
-                { "name": "memory-pressure", "description": "Simulates memory pressure", "severity": "medium" }
+                { "name": "network_degradation", "description": "Simulates degraded network conditions with high latency and packet loss", "severity": "medium" },
+                { "name": "service_instability", "description": "Simulates an unstable service with random errors and timeouts", "severity": "high" },
+                { "name": "cascading_failure", "description": "Simulates a cascading failure with multiple simultaneous issues", "severity": "high" },
+                { "name": "peak_traffic", "description": "Simulates peak traffic conditions with aggressive rate limiting", "severity": "medium" },
+                { "name": "slow_backend", "description": "Simulates a consistently slow backend service", "severity": "low" }
             ])
         }
         None => json!([]),
@@ -101,21 +116,51 @@ pub async fn get_chaos_scenarios_predefined(State(state): State<AdminState>) ->
     }))
 }
 
-/// Start a chaos scenario by name
+/// Start a predefined chaos scenario by name
+///
+/// The scenario's settings are layered over the current ones, so latency and
+/// faults configured separately keep applying alongside it.
 pub async fn start_chaos_scenario(
     State(state): State<AdminState>,
     Path(name): Path<String>,
 ) -> impl IntoResponse {
     match &state.chaos_api_state {
         Some(chaos) => {
-            let scenario = mockforge_chaos::scenarios::ChaosScenario::new(
-                name.clone(),
-                mockforge_chaos::ChaosConfig::default(),
-            );
+            let Some(scenario) = mockforge_chaos::scenarios::PredefinedScenarios::by_name(&name)
+            else {
+                return (
+                    StatusCode::NOT_FOUND,
+                    Json(json!({
+                        "success": false,
+                        "data": null,
+                        "error": format!("Unknown chaos scenario: {}", name),
+                        "timestamp": chrono::Utc::now().to_rfc3339()
+                    })),
+                );
+            };
+            let settings = scenario.chaos_config.clone();
             chaos.scenario_engine.start_scenario(scenario);
-            // Also enable chaos if not already
+
             let mut config = chaos.config.write().await;
             config.enabled = true;
+            if settings.latency.is_some() {
+                config.latency = settings.latency;
+            }
+            if settings.fault_injection.is_some() {
+                config.fault_injection = settings.fault_injection;
+            }
+            if settings.rate_limit.is_some() {
+                config.rate_limit = settings.rate_limit;
+            }
+            if settings.traffic_shaping.is_some() {
+                config.traffic_shaping = settings.traffic_shaping;
+            }
+            audit(
+                AdminActionType::ChaosScenarioStarted,
+                format!("Started chaos scenario {}", name),
+                Some(name.clone()),
+            )
+            .await;
             (
                 StatusCode::OK,
                 Json(json!({
@@ -157,7 +202,7 @@ pub async fn get_chaos_stats(State(_state): State<AdminState>) -> impl IntoRespo
     )
 }
 
-/// Stop a chaos scenario by name
+/// Stop a chaos scenario by name; stopping the last running one turns chaos off
 pub async fn stop_chaos_scenario(
     State(state): State<AdminState>,
     Path(name): Path<String>,
@@ -165,6 +210,17 @@ pub async fn stop_chaos_scenario(
     match &state.chaos_api_state {
         Some(chaos) => {
             let stopped = chaos.scenario_engine.stop_scenario(&name);
+            if stopped {
+                if chaos.scenario_engine.get_active_scenarios().is_empty() {
+                    chaos.config.write().await.enabled = false;
+                }
+                audit(
+                    AdminActionType::ChaosScenarioStopped,
+                    format!("Stopped chaos scenario {}", name),
+                    Some(name.clone()),
+                )
+                .await;
+            }
             (
                 StatusCode::OK,
                 Json(json!({
@@ -68,8 +68,10 @@ pub fn create_admin_router(
     // Initialize global logger if not already initialized
     let _logger = get_global_logger().unwrap_or_else(|| init_global_logger(1000));
 
-    // Initialize audit log store (keep last 10000 audit entries)
-    let _audit_store = init_global_audit_store(10000);
+    // Initialize audit log store (keep last 10000 audit entries), recording
+    // mock changes made through the HTTP management API too
+    let audit_store = init_global_audit_store(10000);
+    crate::audit::audit_management_events(audit_store);
 
     // Initialize user store for authentication
     let _user_store = init_global_user_store();
@@ -116,6 +116,15 @@
 //! - `dir`: Direction ("in" = received, "out" = sent)
 //! - `text`: Message content (supports template expansion)
 //! - `waitFor`: Optional regex/JSONPath pattern to wait for
+//! - `delay_ms`: Optional delay in milliseconds before sending
+//! - `close`: Optional close frame (`{"code":1001,"reason":"going away"}`)
+//!   ending the replay
+//! - `binary`: Optional base64 payload sent as a binary frame
+//! - `waitForBinary`: Optional base64 bytes an inbound binary message must
+//!   contain before the entry is sent
+//!
+//! Scripts in this format can also be registered per path at runtime; see
+//! [`scripts`].
 //!
 //! ## JSONPath Message Matching
 //!
@@ -152,9 +161,10 @@ pub mod ai_event_generator;
 pub mod handlers;
 /// Unified protocol server lifecycle implementation
 pub mod protocol_server;
+pub mod scripts;
 pub mod ws_tracing;
 
-use axum::extract::ws::{Message, WebSocket, WebSocketUpgrade};
+use axum::extract::ws::{CloseFrame, Message, WebSocket, WebSocketUpgrade};
 use axum::extract::{Path, State};
 use axum::{response::IntoResponse, routing::get, Router};
 use futures::sink::SinkExt;
@@ -178,6 +188,9 @@ pub use ws_tracing::{
     record_ws_error, record_ws_message_success,
 };
 
+// Re-export script registry
+pub use scripts::{ScriptError, WsScriptRegistry};
+
 // Re-export handler utilities
 pub use handlers::{
     HandlerError, HandlerRegistry, HandlerResult, MessagePattern, MessageRouter, PassthroughConfig,
@@ -224,6 +237,18 @@ pub fn router_with_handlers(registry: std::sync::Arc<HandlerRegistry>) -> Router
         .with_state(registry)
 }
 
+/// Build the WebSocket router with per-path scripts. Paths without a script
+/// are served like [`router`].
+pub fn router_with_scripts(scripts: std::sync::Arc<WsScriptRegistry>) -> Router {
+    #[cfg(feature = "data-faker")]
+    register_core_faker_provider();
+
+    Router::new()
+        .route("/ws", get(ws_handler_with_scripts))
+        .route("/ws/{*path}", get(ws_handler_with_scripts_path))
+        .with_state(scripts)
+}
+
 /// Start WebSocket server with latency simulation
 pub async fn start_with_latency(
     port: u16,
@@ -244,7 +269,23 @@ pub async fn start_with_latency_and_host(
     } else {
         router()
     };
+    serve_router(port, host, router).await
+}
+
+/// Start WebSocket server running the scripts registered in `scripts`
+pub async fn start_with_scripts_and_host(
+    port: u16,
+    host: &str,
+    scripts: std::sync::Arc<WsScriptRegistry>,
+) -> Result<(), Box<dyn std::error::Error>> {
+    serve_router(port, host, router_with_scripts(scripts)).await
+}
 
+async fn serve_router(
+    port: u16,
+    host: &str,
+    router: Router,
+) -> Result<(), Box<dyn std::error::Error>> {
     let addr: std::net::SocketAddr = format!("{}:{}", host, port).parse()?;
     info!("WebSocket server listening on {}", addr);
 
@@ -304,6 +345,22 @@ async fn ws_handler_with_registry_path(
     ws.on_upgrade(move |socket| handle_socket_with_handlers(socket, registry, full_path))
 }
 
+async fn ws_handler_with_scripts(
+    ws: WebSocketUpgrade,
+    State(scripts): State<std::sync::Arc<WsScriptRegistry>>,
+) -> impl IntoResponse {
+    ws.on_upgrade(move |socket| handle_socket_with_scripts(socket, scripts, "/ws".to_string()))
+}
+
+async fn ws_handler_with_scripts_path(
+    Path(path): Path<String>,
+    ws: WebSocketUpgrade,
+    State(scripts): State<std::sync::Arc<WsScriptRegistry>>,
+) -> impl IntoResponse {
+    let full_path = format!("/ws/{}", path);
+    ws.on_upgrade(move |socket| handle_socket_with_scripts(socket, scripts, full_path))
+}
+
 async fn handle_socket(mut socket: WebSocket) {
     use std::time::Instant;
 
@@ -356,7 +413,31 @@ async fn handle_socket(mut socket: WebSocket) {
     debug!("WebSocket connection closed (status: {}, duration: {:.2}s)", status, duration);
 }
 
-async fn handle_socket_with_replay(mut socket: WebSocket, replay_file: &str) {
+async fn handle_socket_with_scripts(
+    socket: WebSocket,
+    scripts: std::sync::Arc<WsScriptRegistry>,
+    path: String,
+) {
+    use std::time::Instant;
+
+    let Some(entries) = scripts.get(&path) else {
+        handle_socket(socket).await;
+        return;
+    };
+
+    let registry = get_global_registry();
+    let connection_start = Instant::now();
+    registry.record_ws_connection_established();
+
+    info!("Running WebSocket script for path: {}", path);
+    run_replay(socket, &entries).await;
+
+    let duration = connection_start.elapsed().as_secs_f64();
+    registry.record_ws_connection_closed(duration, "normal");
+    debug!("Scripted WebSocket connection closed (duration: {:.2}s)", duration);
+}
+
+async fn handle_socket_with_replay(socket: WebSocket, replay_file: &str) {
     let _registry = get_global_registry(); // Available for future message tracking
 
     // Read the replay file
@@ -377,8 +458,10 @@ async fn handle_socket_with_replay(mut socket: WebSocket, replay_file: &str) {
     }
 
     info!("Loaded {} replay entries", replay_entries.len());
+    run_replay(socket, &replay_entries).await;
+}
 
-    // Process replay entries
+async fn run_replay(mut socket: WebSocket, replay_entries: &[Value]) {
     for entry in replay_entries {
         // Check if we need to wait for a specific message
         if let Some(wait_for) = entry.get("waitFor") {
@@ -400,6 +483,35 @@ async fn handle_socket_with_replay(mut socket: WebSocket, replay_file: &str) {
             }
         }
 
+        // Or for a binary message containing specific bytes
+        if let Some(wait_for) = entry.get("waitForBinary").and_then(|v| v.as_str()) {
+            let expected = match scripts::decode_binary(wait_for) {
+                Ok(expected) => expected,
+                Err(e) => {
+                    error!("Invalid waitForBinary in replay entry: {}", e);
+                    break;
+                }
+            };
+            info!("Waiting for {} binary bytes", expected.len());
+            let mut found = false;
+            while let Some(msg) = socket.recv().await {
+                if let Ok(Message::Binary(data)) = msg {
+                    if contains_bytes(&data, &expected) {
+                        found = true;
+                        break;
+                    }
+                }
+            }
+            if !found {
+                break;
+            }
+        }
+
+        // Wait before sending, if requested
+        if let Some(delay_ms) = entry.get("delay_ms").and_then(|v| v.as_u64()) {
+            sleep(Duration::from_millis(delay_ms)).await;
+        }
+
         // Get the message text
         if let Some(text) = entry.get("text").and_then(|v| v.as_str()) {
             // Expand tokens if enabled
@@ -418,6 +530,31 @@ async fn handle_socket_with_replay(mut socket: WebSocket, replay_file: &str) {
             }
         }
 
+        if let Some(binary) = entry.get("binary").and_then(|v| v.as_str()) {
+            let data = match scripts::decode_binary(binary) {
+                Ok(data) => data,
+                Err(e) => {
+                    error!("Invalid binary in replay entry: {}", e);
+                    break;
+                }
+            };
+            info!("Sending {} byte binary replay message", data.len());
+            if socket.send(Message::Binary(data.into())).await.is_err() {
+                break;
+            }
+        }
+
+        // Close the connection, ending the replay
+        if let Some(close) = entry.get("close") {
+            let frame = CloseFrame {
+                code: close.get("code").and_then(|v| v.as_u64()).unwrap_or(1000) as u16,
+                reason: close.get("reason").and_then(|v| v.as_str()).unwrap_or("").into(),
+            };
+            info!("Closing replay connection with code {}", frame.code);
+            let _ = socket.send(Message::Close(Some(frame))).await;
+            break;
+        }
+
         // Wait for the specified time
         if let Some(ts) = entry.get("ts").and_then(|v| v.as_u64()) {
             sleep(Duration::from_millis(ts * 10)).await; // Convert to milliseconds
@@ -425,6 +562,11 @@ async fn handle_socket_with_replay(mut socket: WebSocket, replay_file: &str) {
     }
 }
 
+/// Whether `data` contains `needle` as a contiguous run of bytes
+fn contains_bytes(data: &[u8], needle: &[u8]) -> bool {
+    needle.is_empty() || data.windows(needle.len()).any(|window| window == needle)
+}
+
 fn expand_tokens(text: &str) -> String {
     let mut result = text.to_string();
 
@@ -686,6 +828,20 @@ mod tests {
         assert!(result.is_ok());
     }
 
+    #[test]
+    fn test_router_with_scripts_creation() {
+        let scripts = std::sync::Arc::new(WsScriptRegistry::new());
+        let _router = router_with_scripts(scripts);
+    }
+
+    #[test]
+    fn test_contains_bytes() {
+        assert!(contains_bytes(&[0xff, 0x01, 0x02, 0x03], &[0x01, 0x02]));
+        assert!(contains_bytes(&[0x01], &[]));
+        assert!(!contains_bytes(&[0x01, 0x03, 0x02], &[0x01, 0x02]));
+        assert!(!contains_bytes(&[0x01], &[0x01, 0x02]));
+    }
+
     // ==================== Token Expansion Tests ====================
 
     #[test]
@@ -0,0 +1,134 @@
+//! Scripted conversations registered per path at runtime
+//!
+//! A [`WsScriptRegistry`] holds one replay script per WebSocket path, in the
+//! replay file's entry format. Routers built with
+//! [`router_with_scripts`](crate::router_with_scripts) run a path's script for
+//! every client that connects to it, and fall back to the replay file or echo
+//! mode for paths without one. Scripts can be registered while the server is
+//! running; connections already open keep the script they started with.
+
+use base64::Engine;
+use serde_json::Value;
+use std::collections::HashMap;
+use std::sync::{Arc, RwLock};
+
+/// Error registering a script
+#[derive(Debug, thiserror::Error)]
+pub enum ScriptError {
+    /// The path is not served by the WebSocket router
+    #[error("WebSocket script path must be /ws or start with /ws/, got {0}")]
+    InvalidPath(String),
+    /// The path already has a script
+    #[error("a WebSocket script is already registered for {0}")]
+    AlreadyRegistered(String),
+    /// An entry is not a valid replay entry
+    #[error("invalid WebSocket script entry {index}: {reason}")]
+    InvalidEntry {
+        /// Index of the entry in the script
+        index: usize,
+        /// What is wrong with it
+        reason: String,
+    },
+}
+
+/// Replay scripts keyed by WebSocket path
+#[derive(Debug, Default)]
+pub struct WsScriptRegistry {
+    scripts: RwLock<HashMap<String, Arc<Vec<Value>>>>,
+}
+
+impl WsScriptRegistry {
+    /// Create an empty registry
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Register the script for `path`. A path runs a single script, so
+    /// registering a second one is an error rather than a replacement.
+    pub fn register(&self, path: &str, entries: Vec<Value>) -> Result<(), ScriptError> {
+        if path != "/ws" && !path.starts_with("/ws/") {
+            return Err(ScriptError::InvalidPath(path.to_string()));
+        }
+        for (index, entry) in entries.iter().enumerate() {
+            validate_entry(entry).map_err(|reason| ScriptError::InvalidEntry { index, reason })?;
+        }
+
+        let mut scripts = self.scripts.write().unwrap_or_else(|e| e.into_inner());
+        if scripts.contains_key(path) {
+            return Err(ScriptError::AlreadyRegistered(path.to_string()));
+        }
+        scripts.insert(path.to_string(), Arc::new(entries));
+        Ok(())
+    }
+
+    /// The script registered for `path`
+    pub fn get(&self, path: &str) -> Option<Arc<Vec<Value>>> {
+        self.scripts.read().unwrap_or_else(|e| e.into_inner()).get(path).cloned()
+    }
+
+    /// The registered paths and their number of entries, sorted by path
+    pub fn list(&self) -> Vec<(String, usize)> {
+        let scripts = self.scripts.read().unwrap_or_else(|e| e.into_inner());
+        let mut list: Vec<_> =
+            scripts.iter().map(|(path, entries)| (path.clone(), entries.len())).collect();
+        list.sort();
+        list
+    }
+}
+
+/// Check an entry is an object whose binary payloads decode
+fn validate_entry(entry: &Value) -> Result<(), String> {
+    let Some(entry) = entry.as_object() else {
+        return Err("entry must be a JSON object".to_string());
+    };
+    for field in ["binary", "waitForBinary"] {
+        if let Some(value) = entry.get(field) {
+            let encoded = value.as_str().ok_or_else(|| format!("{field} must be a string"))?;
+            decode_binary(encoded).map_err(|e| format!("{field} is not valid base64: {e}"))?;
+        }
+    }
+    Ok(())
+}
+
+/// Decode a base64 `binary` or `waitForBinary` payload
+pub(crate) fn decode_binary(encoded: &str) -> Result<Vec<u8>, base64::DecodeError> {
+    base64::engine::general_purpose::STANDARD.decode(encoded)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    #[test]
+    fn test_register_one_script_per_path() {
+        let registry = WsScriptRegistry::new();
+        registry
+            .register("/ws/orders", vec![json!({"dir": "out", "text": "hi"})])
+            .unwrap();
+        registry.register("/ws", vec![]).unwrap();
+
+        assert_eq!(registry.get("/ws/orders").unwrap().len(), 1);
+        assert!(registry.get("/ws/missing").is_none());
+        assert!(matches!(
+            registry.register("/ws/orders", vec![]),
+            Err(ScriptError::AlreadyRegistered(_))
+        ));
+        assert_eq!(registry.list(), vec![("/ws".to_string(), 0), ("/ws/orders".to_string(), 1)]);
+    }
+
+    #[test]
+    fn test_register_rejects_invalid_scripts() {
+        let registry = WsScriptRegistry::new();
+        assert!(matches!(registry.register("/other", vec![]), Err(ScriptError::InvalidPath(_))));
+        assert!(matches!(
+            registry.register("/ws", vec![json!("text")]),
+            Err(ScriptError::InvalidEntry { index: 0, .. })
+        ));
+        assert!(matches!(
+            registry.register("/ws", vec![json!({}), json!({"dir": "out", "binary": "!!"})]),
+            Err(ScriptError::InvalidEntry { index: 1, .. })
+        ));
+        assert!(registry.get("/ws").is_none());
+    }
+}
@@ -0,0 +1,62 @@
+//! Replay entries can delay a message and end the conversation with a close
+//! frame, so scripted clients see the server hang up the way a real one
+//! would.
+
+use futures_util::{SinkExt, StreamExt};
+use std::time::{Duration, Instant};
+use tempfile::NamedTempFile;
+use tokio_tungstenite::tungstenite::protocol::frame::coding::CloseCode;
+use tokio_tungstenite::tungstenite::protocol::Message;
+
+#[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+async fn replay_delays_and_closes_the_connection() {
+    let replay = NamedTempFile::new().unwrap();
+    std::fs::write(
+        replay.path(),
+        r#"{"dir":"in","waitFor":"subscribe"}
+{"dir":"out","text":"subscribed","delay_ms":200}
+{"dir":"out","close":{"code":1001,"reason":"going away"}}
+"#,
+    )
+    .unwrap();
+    std::env::set_var("MOCKFORGE_WS_REPLAY_FILE", replay.path());
+
+    let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+    let addr = listener.local_addr().unwrap();
+    let _server =
+        tokio::spawn(async move { axum::serve(listener, mockforge_ws::router()).await.unwrap() });
+    tokio::time::sleep(Duration::from_millis(50)).await;
+
+    let url = format!("ws://{}/ws", addr);
+    let (mut ws, _) =
+        tokio::time::timeout(Duration::from_secs(3), tokio_tungstenite::connect_async(url))
+            .await
+            .expect("ws handshake should not time out")
+            .expect("ws handshake ok");
+
+    let sent = Instant::now();
+    ws.send(Message::Text(r#"{"type":"subscribe"}"#.into())).await.unwrap();
+
+    let reply = tokio::time::timeout(Duration::from_secs(3), ws.next())
+        .await
+        .expect("replay frame should arrive")
+        .expect("stream produced a message")
+        .expect("no transport error");
+    assert_eq!(reply, Message::Text("subscribed".into()));
+    assert!(sent.elapsed() >= Duration::from_millis(200), "reply should wait for delay_ms");
+
+    let close = tokio::time::timeout(Duration::from_secs(3), ws.next())
+        .await
+        .expect("close frame should arrive")
+        .expect("stream produced a message")
+        .expect("no transport error");
+    match close {
+        Message::Close(Some(frame)) => {
+            assert_eq!(frame.code, CloseCode::Away);
+            assert_eq!(frame.reason.as_str(), "going away");
+        }
+        other => panic!("expected close frame, got {other:?}"),
+    }
+
+    std::env::remove_var("MOCKFORGE_WS_REPLAY_FILE");
+}
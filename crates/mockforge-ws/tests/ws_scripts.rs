@@ -0,0 +1,65 @@
+//! Scripts registered per path run for the clients of that path, including
+//! scripts registered after the server started, and can exchange binary
+//! frames.
+
+use futures_util::{SinkExt, StreamExt};
+use mockforge_ws::WsScriptRegistry;
+use serde_json::json;
+use std::sync::Arc;
+use std::time::Duration;
+use tokio_tungstenite::tungstenite::protocol::Message;
+
+async fn next_message<S>(ws: &mut S) -> Message
+where
+    S: StreamExt<Item = Result<Message, tokio_tungstenite::tungstenite::Error>> + Unpin,
+{
+    tokio::time::timeout(Duration::from_secs(3), ws.next())
+        .await
+        .expect("message should arrive")
+        .expect("stream produced a message")
+        .expect("no transport error")
+}
+
+#[tokio::test(flavor = "multi_thread", worker_threads = 2)]
+async fn scripts_run_per_path() {
+    let scripts = Arc::new(WsScriptRegistry::new());
+    scripts
+        .register("/ws/prices", vec![json!({"dir": "out", "text": "price 42"})])
+        .unwrap();
+
+    let listener = tokio::net::TcpListener::bind("127.0.0.1:0").await.unwrap();
+    let addr = listener.local_addr().unwrap();
+    let router = mockforge_ws::router_with_scripts(scripts.clone());
+    let _server = tokio::spawn(async move { axum::serve(listener, router).await.unwrap() });
+    tokio::time::sleep(Duration::from_millis(50)).await;
+
+    // Registered while the server runs
+    scripts
+        .register(
+            "/ws/frames",
+            vec![
+                json!({"dir": "in", "waitForBinary": "AQI="}),
+                json!({"dir": "out", "binary": "BgcI"}),
+            ],
+        )
+        .unwrap();
+
+    let (mut prices, _) = tokio_tungstenite::connect_async(format!("ws://{}/ws/prices", addr))
+        .await
+        .unwrap();
+    assert_eq!(next_message(&mut prices).await, Message::Text("price 42".into()));
+
+    let (mut frames, _) = tokio_tungstenite::connect_async(format!("ws://{}/ws/frames", addr))
+        .await
+        .unwrap();
+    frames.send(Message::Binary(vec![0x09].into())).await.unwrap();
+    frames.send(Message::Binary(vec![0x00, 0x01, 0x02].into())).await.unwrap();
+    assert_eq!(next_message(&mut frames).await, Message::Binary(vec![0x06, 0x07, 0x08].into()));
+
+    // Paths without a script echo
+    let (mut other, _) = tokio_tungstenite::connect_async(format!("ws://{}/ws/other", addr))
+        .await
+        .unwrap();
+    other.send(Message::Text("hi".into())).await.unwrap();
+    assert_eq!(next_message(&mut other).await, Message::Text("echo: hi".into()));
+}
@@ -119,6 +119,8 @@ async fn start_embedded_servers(
         None,                         // mqtt_broker
         None,                         // amqp_broker
         None,                         // kafka_broker
+        None,                         // ftp_registry
+        None,                         // ws_scripts
         None,                         // traffic_shaper
         false,                        // traffic_shaping_enabled
         Some(health_manager.clone()), // health_manager
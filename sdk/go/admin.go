@@ -0,0 +1,155 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// adminURL returns the absolute admin API URL for path. The management API
+// under /__mockforge/api is served by the HTTP server, the rest of the admin
+// API by the admin UI server.
+func (m *MockServer) adminURL(path string) (string, error) {
+	m.portMutex.RLock()
+	adminPort := m.adminPort
+	port := m.port
+	host := m.host
+	m.portMutex.RUnlock()
+
+	if adminPort == 0 {
+		return "", fmt.Errorf("admin port not available")
+	}
+	if strings.HasPrefix(path, "/__mockforge/api/") {
+		adminPort = port
+	}
+
+	return fmt.Sprintf("http://%s:%d%s", host, adminPort, path), nil
+}
+
+// adminJSON sends in (if non-nil) as a JSON body to the admin API and decodes
+// the response into out (if non-nil). Non-2xx responses are returned as an
+// admin API error carrying the response body.
+func (m *MockServer) adminJSON(operation, method, path string, in, out interface{}) error {
+	url, err := m.adminURL(path)
+	if err != nil {
+		return NewAdminAPIError(operation, err.Error(), err)
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return NewAdminAPIError(operation, "failed to marshal request", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return NewAdminAPIError(operation, "failed to build request", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := adminDo(operation, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// An empty body leaves out untouched
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+			return NewAdminAPIError(operation, "failed to decode response", err)
+		}
+	}
+
+	return nil
+}
+
+// adminBytes sends in (if non-nil) as a raw body to the admin API and
+// returns the raw response body, for endpoints serving file content
+func (m *MockServer) adminBytes(operation, method, path string, in []byte) ([]byte, error) {
+	url, err := m.adminURL(path)
+	if err != nil {
+		return nil, NewAdminAPIError(operation, err.Error(), err)
+	}
+
+	var body io.Reader
+	if in != nil {
+		body = bytes.NewReader(in)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, NewAdminAPIError(operation, "failed to build request", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	resp, err := adminDo(operation, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewAdminAPIError(operation, "failed to read response", err)
+	}
+	return data, nil
+}
+
+// adminDo sends req, returning non-2xx responses as an admin API error
+// carrying the response body
+func adminDo(operation string, req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, NewAdminAPIError(operation, "request failed", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := NewAdminAPIError(operation, fmt.Sprintf("status %d: %s", resp.StatusCode, bytes.TrimSpace(message)), nil)
+		apiErr.Details["status"] = resp.StatusCode
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// isAdminStatus reports whether err is an admin API error answered with
+// status
+func isAdminStatus(err error, status int) bool {
+	var apiErr *MockServerError
+	return errors.As(err, &apiErr) && apiErr.Details["status"] == status
+}
+
+// adminEnvelope calls an admin endpoint that wraps its result in the
+// {success, data, error} envelope and decodes data into out (if non-nil)
+func (m *MockServer) adminEnvelope(operation, method, path string, in, out interface{}) error {
+	var result struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+		Error   string          `json:"error"`
+	}
+	if err := m.adminJSON(operation, method, path, in, &result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return NewAdminAPIError(operation, result.Error, nil)
+	}
+
+	if out != nil && len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return NewAdminAPIError(operation, "failed to decode response", err)
+		}
+	}
+
+	return nil
+}
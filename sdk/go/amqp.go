@@ -0,0 +1,200 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AMQPMessage is a message published to the AMQP broker
+type AMQPMessage struct {
+	Exchange    string
+	RoutingKey  string
+	Headers     map[string]string
+	ContentType string
+	Body        []byte
+	Timestamp   time.Time
+}
+
+// AMQPMatcher selects published messages. Empty fields match everything.
+type AMQPMatcher struct {
+	Exchange string
+	// RoutingKey may use topic wildcards: * matches one word and # matches
+	// zero or more, e.g. "orders.*.created"
+	RoutingKey string
+	// Headers must all be present with these values
+	Headers map[string]string
+	// BodyContains matches messages whose body contains these bytes
+	BodyContains string
+}
+
+// matches reports whether message passes the matcher
+func (a AMQPMatcher) matches(message AMQPMessage) bool {
+	if a.Exchange != "" && message.Exchange != a.Exchange {
+		return false
+	}
+	if a.RoutingKey != "" && !amqpRoutingKeyMatches(a.RoutingKey, message.RoutingKey) {
+		return false
+	}
+	for name, value := range a.Headers {
+		if got, ok := message.Headers[name]; !ok || got != value {
+			return false
+		}
+	}
+	if a.BodyContains != "" && !bytes.Contains(message.Body, []byte(a.BodyContains)) {
+		return false
+	}
+	return true
+}
+
+// AMQPMock controls the server's AMQP broker, which is enabled in the
+// server's config file. Clients connect to Addr.
+type AMQPMock struct {
+	server *MockServer
+}
+
+// AMQP returns the controller of the server's AMQP broker
+func (m *MockServer) AMQP() *AMQPMock {
+	return &AMQPMock{server: m}
+}
+
+// Addr returns the host:port AMQP clients connect to, or an empty string if
+// the server has not reported an AMQP broker
+func (a *AMQPMock) Addr() string {
+	a.server.portMutex.RLock()
+	defer a.server.portMutex.RUnlock()
+	if a.server.amqpPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", a.server.host, a.server.amqpPort)
+}
+
+// DeclareExchange declares an exchange of kind direct, fanout, topic, or
+// headers
+func (a *AMQPMock) DeclareExchange(name, kind string) error {
+	if name == "" {
+		return NewInvalidConfigError("AMQP exchange name must not be empty", nil)
+	}
+	switch kind {
+	case "direct", "fanout", "topic", "headers":
+	default:
+		return NewInvalidConfigError("AMQP exchange kind must be direct, fanout, topic, or headers", map[string]interface{}{"exchange": name, "kind": kind})
+	}
+	body := map[string]interface{}{"name": name, "type": kind}
+	return a.server.adminJSON("declare amqp exchange", http.MethodPost, "/__mockforge/api/amqp/exchanges", body, nil)
+}
+
+// DeclareQueue declares a queue
+func (a *AMQPMock) DeclareQueue(name string) error {
+	if name == "" {
+		return NewInvalidConfigError("AMQP queue name must not be empty", nil)
+	}
+	body := map[string]interface{}{"name": name}
+	return a.server.adminJSON("declare amqp queue", http.MethodPost, "/__mockforge/api/amqp/queues", body, nil)
+}
+
+// Bind routes messages published to exchange with routingKey into queue
+func (a *AMQPMock) Bind(exchange, queue, routingKey string) error {
+	if exchange == "" || queue == "" {
+		return NewInvalidConfigError("AMQP binding needs an exchange and a queue", map[string]interface{}{"exchange": exchange, "queue": queue})
+	}
+	body := map[string]interface{}{"queue": queue, "routing_key": routingKey}
+	return a.server.adminJSON("bind amqp queue", http.MethodPost, "/__mockforge/api/amqp/exchanges/"+url.PathEscape(exchange)+"/bindings", body, nil)
+}
+
+// Publish routes a message through exchange into the bound queues, so
+// consumers under test receive it. The empty exchange delivers straight to
+// the queue named routingKey. Messages published here are not returned by
+// Published.
+func (a *AMQPMock) Publish(exchange, routingKey string, body []byte, headers map[string]string) error {
+	request := map[string]interface{}{"exchange": exchange, "routing_key": routingKey, "payload": string(body)}
+	if len(headers) > 0 {
+		request["headers"] = headers
+	}
+	return a.server.adminJSON("publish amqp message", http.MethodPost, "/__mockforge/api/amqp/publish", request, nil)
+}
+
+// Published returns the messages clients published to the broker, oldest
+// first. The broker keeps the most recent 1000.
+func (a *AMQPMock) Published() ([]AMQPMessage, error) {
+	var result struct {
+		Messages []struct {
+			Exchange    string            `json:"exchange"`
+			RoutingKey  string            `json:"routing_key"`
+			Headers     map[string]string `json:"headers"`
+			ContentType *string           `json:"content_type"`
+			Payload     string            `json:"payload"`
+			Timestamp   int64             `json:"timestamp"`
+		} `json:"messages"`
+	}
+	if err := a.server.adminJSON("list amqp messages", http.MethodGet, "/__mockforge/api/amqp/published", nil, &result); err != nil {
+		return nil, err
+	}
+
+	messages := make([]AMQPMessage, len(result.Messages))
+	for i, message := range result.Messages {
+		body, err := base64.StdEncoding.DecodeString(message.Payload)
+		if err != nil {
+			return nil, NewAdminAPIError("list amqp messages", "invalid message payload", err)
+		}
+		messages[i] = AMQPMessage{
+			Exchange:   message.Exchange,
+			RoutingKey: message.RoutingKey,
+			Headers:    message.Headers,
+			Body:       body,
+			Timestamp:  time.UnixMilli(message.Timestamp),
+		}
+		if message.ContentType != nil {
+			messages[i].ContentType = *message.ContentType
+		}
+	}
+	return messages, nil
+}
+
+// VerifyPublished asserts how many messages clients published that match
+// matcher, to check what the service under test sent
+func (a *AMQPMock) VerifyPublished(matcher AMQPMatcher, count VerificationCount) error {
+	messages, err := a.Published()
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, message := range messages {
+		if matcher.matches(message) {
+			n++
+		}
+	}
+	if !count.Satisfied(n) {
+		return fmt.Errorf("expected %s matching AMQP message(s), got %d of %d", count, n, len(messages))
+	}
+	return nil
+}
+
+// amqpRoutingKeyMatches reports whether key matches a topic exchange
+// binding pattern, where * matches one word and # zero or more
+func amqpRoutingKeyMatches(pattern, key string) bool {
+	return amqpWordsMatch(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+// amqpWordsMatch matches the dot-separated words of a routing key
+func amqpWordsMatch(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	if pattern[0] == "#" {
+		for i := 0; i <= len(words); i++ {
+			if amqpWordsMatch(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(words) == 0 || (pattern[0] != "*" && pattern[0] != words[0]) {
+		return false
+	}
+	return amqpWordsMatch(pattern[1:], words[1:])
+}
@@ -0,0 +1,88 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestAMQPMock(t *testing.T) {
+	var binding, published map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/__mockforge/api/amqp/exchanges", "/__mockforge/api/amqp/queues":
+			w.Write([]byte(`{"declared":"x"}`))
+		case "/__mockforge/api/amqp/exchanges/orders/bindings":
+			json.NewDecoder(r.Body).Decode(&binding)
+			w.Write([]byte(`{"bound":true}`))
+		case "/__mockforge/api/amqp/publish":
+			json.NewDecoder(r.Body).Decode(&published)
+			w.Write([]byte(`{"success":true}`))
+		case "/__mockforge/api/amqp/published":
+			w.Write([]byte(`{"messages":[
+				{"exchange":"orders","routing_key":"orders.eu.created","headers":{"tenant":"acme"},"content_type":"application/json","payload":"eyJpZCI6MX0=","timestamp":1700000000000},
+				{"exchange":"orders","routing_key":"orders.us.cancelled","headers":{},"content_type":null,"payload":"","timestamp":1700000000001}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	amqp := server.AMQP()
+
+	if err := amqp.DeclareExchange("orders", "topic"); err != nil {
+		t.Fatalf("Failed to declare exchange: %v", err)
+	}
+	if err := amqp.DeclareExchange("orders", "x-delayed"); err == nil {
+		t.Error("Expected error for unknown exchange kind")
+	}
+	if err := amqp.DeclareQueue("billing"); err != nil {
+		t.Fatalf("Failed to declare queue: %v", err)
+	}
+	if err := amqp.Bind("orders", "billing", "orders.#"); err != nil {
+		t.Fatalf("Failed to bind: %v", err)
+	}
+	if binding["queue"] != "billing" || binding["routing_key"] != "orders.#" {
+		t.Errorf("Unexpected binding request: %v", binding)
+	}
+	if err := amqp.Publish("orders", "orders.eu.paid", []byte(`{"id":1}`), map[string]string{"tenant": "acme"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if published["payload"] != `{"id":1}` || published["headers"].(map[string]interface{})["tenant"] != "acme" {
+		t.Errorf("Unexpected publish request: %v", published)
+	}
+
+	messages, err := amqp.Published()
+	if err != nil {
+		t.Fatalf("Failed to list messages: %v", err)
+	}
+	if len(messages) != 2 || string(messages[0].Body) != `{"id":1}` || messages[0].ContentType != "application/json" {
+		t.Errorf("Unexpected messages: %+v", messages)
+	}
+
+	matcher := AMQPMatcher{RoutingKey: "orders.*.created", Headers: map[string]string{"tenant": "acme"}, BodyContains: `"id":1`}
+	if err := amqp.VerifyPublished(matcher, Exactly(1)); err != nil {
+		t.Errorf("Expected created message, got %v", err)
+	}
+	if err := amqp.VerifyPublished(AMQPMatcher{Exchange: "orders", RoutingKey: "orders.#"}, Exactly(1)); err == nil {
+		t.Error("Expected error for wrong count")
+	}
+}
+
+func TestAMQPRoutingKeyMatches(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.*", "a.b", true},
+		{"a.*", "a.b.c", false},
+		{"a.#", "a", true},
+		{"#.c", "a.b.c", true},
+		{"a.#.c", "a.c", true},
+		{"a.b", "a.c", false},
+	}
+	for _, tt := range tests {
+		if got := amqpRoutingKeyMatches(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("Expected %v for %q against %q, got %v", tt.want, tt.key, tt.pattern, got)
+		}
+	}
+}
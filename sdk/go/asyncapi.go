@@ -0,0 +1,334 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AsyncAPIChannel is a channel of the server's AsyncAPI document, mapped to
+// the broker that carries it
+type AsyncAPIChannel struct {
+	// Name is the channel's key in the document
+	Name string
+	// Address is the Kafka topic, MQTT topic, or AMQP routing key
+	Address string
+	// Protocol is "kafka", "mqtt", or "amqp"
+	Protocol string
+	// Messages names the channel's messages
+	Messages []string
+	// Exchange, ExchangeType, and Queue come from the channel's AMQP
+	// bindings
+	Exchange     string
+	ExchangeType string
+	Queue        string
+
+	payloads     map[string]interface{}
+	contentTypes map[string]string
+}
+
+// asyncAPIProtocols maps AsyncAPI server protocols to the brokers
+var asyncAPIProtocols = map[string]string{
+	"kafka": "kafka", "kafka-secure": "kafka",
+	"mqtt": "mqtt", "mqtts": "mqtt", "secure-mqtt": "mqtt",
+	"amqp": "amqp", "amqps": "amqp",
+}
+
+// AsyncAPIChannels returns the channels of the AsyncAPI document set in
+// MockServerConfig.AsyncAPISpec, ordered by name. Both AsyncAPI 2 and 3
+// documents are read.
+func (m *MockServer) AsyncAPIChannels() ([]AsyncAPIChannel, error) {
+	if m.config.AsyncAPISpec == "" {
+		return nil, NewInvalidConfigError("AsyncAPI helpers require an AsyncAPI spec", nil)
+	}
+	return loadAsyncAPIChannels(m.config.AsyncAPISpec)
+}
+
+// AsyncAPIExample generates a message of channel that is valid against the
+// message's payload schema, preferring the document's own examples. An
+// empty message name selects the channel's first message.
+func (m *MockServer) AsyncAPIExample(channel, message string) ([]byte, error) {
+	ch, err := m.asyncAPIChannel(channel)
+	if err != nil {
+		return nil, err
+	}
+	return ch.example(message)
+}
+
+// PublishAsyncAPIExample publishes a generated message of channel through
+// the channel's broker, so consumers under test receive a message shaped
+// like production traffic
+func (m *MockServer) PublishAsyncAPIExample(channel, message string) error {
+	ch, err := m.asyncAPIChannel(channel)
+	if err != nil {
+		return err
+	}
+	payload, err := ch.example(message)
+	if err != nil {
+		return err
+	}
+
+	switch ch.Protocol {
+	case "kafka":
+		return m.Kafka().ProduceRecords(ch.Address, []KafkaRecord{{Value: payload}})
+	case "mqtt":
+		body := map[string]interface{}{"topic": ch.Address, "payload": string(payload), "qos": 1, "retain": false}
+		return m.adminJSON("publish mqtt message", http.MethodPost, "/__mockforge/api/mqtt/publish", body, nil)
+	default:
+		// The default exchange delivers straight to the queue
+		exchange, routingKey := ch.Exchange, ch.Address
+		if exchange == "" && ch.Queue != "" {
+			routingKey = ch.Queue
+		}
+		return m.AMQP().Publish(exchange, routingKey, payload, nil)
+	}
+}
+
+// ConfigureAsyncAPI declares the AMQP exchanges and queues of the AsyncAPI
+// document's channels and binds them. Kafka topics and MQTT topics need no
+// declaration. Start calls it when AsyncAPISpec is set and the server
+// reports an AMQP broker.
+func (m *MockServer) ConfigureAsyncAPI() error {
+	channels, err := m.AsyncAPIChannels()
+	if err != nil {
+		return err
+	}
+	amqp := m.AMQP()
+	for _, ch := range channels {
+		if ch.Protocol != "amqp" {
+			continue
+		}
+		if ch.Exchange != "" {
+			if err := amqp.DeclareExchange(ch.Exchange, ch.ExchangeType); err != nil {
+				return err
+			}
+		}
+		if ch.Queue != "" {
+			if err := amqp.DeclareQueue(ch.Queue); err != nil {
+				return err
+			}
+		}
+		if ch.Exchange != "" && ch.Queue != "" {
+			if err := amqp.Bind(ch.Exchange, ch.Queue, ch.Address); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// asyncAPIChannel returns the channel named name
+func (m *MockServer) asyncAPIChannel(name string) (*AsyncAPIChannel, error) {
+	channels, err := m.AsyncAPIChannels()
+	if err != nil {
+		return nil, err
+	}
+	for i := range channels {
+		if channels[i].Name == name {
+			return &channels[i], nil
+		}
+	}
+	return nil, NewInvalidConfigError(fmt.Sprintf("unknown AsyncAPI channel %q", name), map[string]interface{}{"spec": m.config.AsyncAPISpec})
+}
+
+// example generates a payload of the named message
+func (c *AsyncAPIChannel) example(message string) ([]byte, error) {
+	if message == "" && len(c.Messages) > 0 {
+		message = c.Messages[0]
+	}
+	payload, ok := c.payloads[message]
+	if !ok {
+		return nil, NewInvalidConfigError(fmt.Sprintf("channel %q has no message %q", c.Name, message), map[string]interface{}{"messages": c.Messages})
+	}
+	if text, ok := payload.(string); ok && !strings.Contains(c.contentTypes[message], "json") {
+		return []byte(text), nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s message: %w", c.Name, err)
+	}
+	return data, nil
+}
+
+// loadAsyncAPIChannels reads the channels of an AsyncAPI 2 or 3 document
+func loadAsyncAPIChannels(path string) ([]AsyncAPIChannel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to read AsyncAPI spec: %v", err), map[string]interface{}{"spec": path})
+	}
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to parse AsyncAPI spec: %v", err), map[string]interface{}{"spec": path})
+	}
+	version, _ := root["asyncapi"].(string)
+	channels, ok := root["channels"].(map[string]interface{})
+	if version == "" || !ok {
+		return nil, NewInvalidConfigError("spec is not an AsyncAPI document with channels", map[string]interface{}{"spec": path})
+	}
+	// AsyncAPI payloads are JSON Schemas with the same $ref pointers as
+	// OpenAPI, so examples are generated the same way
+	doc := &openAPIDocument{root: root}
+	v3 := strings.HasPrefix(version, "3.")
+
+	var result []AsyncAPIChannel
+	for _, name := range sortedKeys(channels) {
+		node, _ := doc.resolve(channels[name]).(map[string]interface{})
+		ch := AsyncAPIChannel{
+			Name:         name,
+			Address:      name,
+			payloads:     make(map[string]interface{}),
+			contentTypes: make(map[string]string),
+		}
+		if address, ok := node["address"].(string); ok && v3 {
+			ch.Address = address
+		}
+		ch.Protocol = doc.asyncAPIProtocol(node)
+		if ch.Protocol == "" {
+			return nil, NewInvalidConfigError(fmt.Sprintf("channel %q has no Kafka, MQTT, or AMQP server or binding", name), map[string]interface{}{"spec": path})
+		}
+		bindings, _ := doc.resolve(node["bindings"]).(map[string]interface{})
+		ch.applyBindings(bindings)
+
+		var messages []interface{}
+		if v3 {
+			declared, _ := node["messages"].(map[string]interface{})
+			for _, key := range sortedKeys(declared) {
+				messages = append(messages, asyncAPINamed(key, doc.resolve(declared[key])))
+			}
+		} else {
+			for _, operation := range []string{"subscribe", "publish"} {
+				op, _ := doc.resolve(node[operation]).(map[string]interface{})
+				message, _ := doc.resolve(op["message"]).(map[string]interface{})
+				if options, ok := message["oneOf"].([]interface{}); ok {
+					for _, option := range options {
+						messages = append(messages, doc.resolve(option))
+					}
+				} else if message != nil {
+					messages = append(messages, message)
+				}
+			}
+		}
+		for i, raw := range messages {
+			message, _ := raw.(map[string]interface{})
+			id := asyncAPIMessageName(message, i)
+			if _, seen := ch.payloads[id]; seen {
+				continue
+			}
+			ch.Messages = append(ch.Messages, id)
+			ch.payloads[id] = doc.asyncAPIPayload(message)
+			ch.contentTypes[id], _ = message["contentType"].(string)
+			if ch.contentTypes[id] == "" {
+				ch.contentTypes[id], _ = root["defaultContentType"].(string)
+			}
+		}
+		result = append(result, ch)
+	}
+	return result, nil
+}
+
+// asyncAPIProtocol returns the broker of a channel, from its bindings or
+// else from the servers it is available on
+func (d *openAPIDocument) asyncAPIProtocol(channel map[string]interface{}) string {
+	bindings, _ := d.resolve(channel["bindings"]).(map[string]interface{})
+	for _, protocol := range []string{"kafka", "mqtt", "amqp"} {
+		if _, ok := bindings[protocol]; ok {
+			return protocol
+		}
+	}
+
+	servers, _ := d.root["servers"].(map[string]interface{})
+	names := sortedKeys(servers)
+	if listed, ok := channel["servers"].([]interface{}); ok && len(listed) > 0 {
+		names = names[:0]
+		for _, server := range listed {
+			switch s := server.(type) {
+			case string:
+				names = append(names, s)
+			case map[string]interface{}:
+				// AsyncAPI 3 references servers as #/servers/name
+				ref, _ := s["$ref"].(string)
+				names = append(names, strings.TrimPrefix(ref, "#/servers/"))
+			}
+		}
+	}
+	found := ""
+	for _, name := range names {
+		server, _ := d.resolve(servers[name]).(map[string]interface{})
+		protocol, _ := server["protocol"].(string)
+		if broker := asyncAPIProtocols[strings.ToLower(protocol)]; broker != "" {
+			if found != "" && found != broker {
+				// Ambiguous without a binding
+				return ""
+			}
+			found = broker
+		}
+	}
+	return found
+}
+
+// applyBindings reads a channel's Kafka topic and AMQP exchange and queue
+func (c *AsyncAPIChannel) applyBindings(bindings map[string]interface{}) {
+	if kafka, ok := bindings["kafka"].(map[string]interface{}); ok {
+		if topic, ok := kafka["topic"].(string); ok && topic != "" {
+			c.Address = topic
+		}
+	}
+	amqp, ok := bindings["amqp"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if exchange, ok := amqp["exchange"].(map[string]interface{}); ok {
+		c.Exchange, _ = exchange["name"].(string)
+		c.ExchangeType, _ = exchange["type"].(string)
+		if c.ExchangeType == "" {
+			c.ExchangeType = "topic"
+		}
+	}
+	if queue, ok := amqp["queue"].(map[string]interface{}); ok {
+		c.Queue, _ = queue["name"].(string)
+	}
+}
+
+// asyncAPINamed gives an AsyncAPI 3 message its key as a fallback name
+func asyncAPINamed(key string, message interface{}) interface{} {
+	object, ok := message.(map[string]interface{})
+	if !ok {
+		return message
+	}
+	if _, named := object["name"]; named {
+		return object
+	}
+	copied := make(map[string]interface{}, len(object)+1)
+	for k, v := range object {
+		copied[k] = v
+	}
+	copied["name"] = key
+	return copied
+}
+
+// asyncAPIMessageName returns a message's name, messageId, or title
+func asyncAPIMessageName(message map[string]interface{}, index int) string {
+	for _, key := range []string{"name", "messageId", "title"} {
+		if name, ok := message[key].(string); ok && name != "" {
+			return name
+		}
+	}
+	return fmt.Sprintf("message%d", index+1)
+}
+
+// asyncAPIPayload returns a message's first example payload, or one
+// generated from its payload schema
+func (d *openAPIDocument) asyncAPIPayload(message map[string]interface{}) interface{} {
+	if examples, ok := message["examples"].([]interface{}); ok && len(examples) > 0 {
+		if example, ok := d.resolve(examples[0]).(map[string]interface{}); ok {
+			if payload, ok := example["payload"]; ok {
+				return payload
+			}
+		}
+	}
+	return d.exampleFromSchema(message["payload"], 0)
+}
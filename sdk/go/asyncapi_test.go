@@ -0,0 +1,89 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAsyncAPIChannels(t *testing.T) {
+	server := NewMockServer(MockServerConfig{AsyncAPISpec: "testdata/events.yaml"})
+	channels, err := server.AsyncAPIChannels()
+	if err != nil {
+		t.Fatalf("Failed to read channels: %v", err)
+	}
+	if len(channels) != 3 {
+		t.Fatalf("Expected 3 channels, got %+v", channels)
+	}
+	protocols := map[string]string{}
+	for _, ch := range channels {
+		protocols[ch.Name] = ch.Protocol
+	}
+	if protocols["orders.created"] != "kafka" || protocols["devices/telemetry"] != "mqtt" || protocols["invoices"] != "amqp" {
+		t.Errorf("Unexpected protocols %v", protocols)
+	}
+	invoices := channels[1]
+	if invoices.Exchange != "billing" || invoices.Queue != "invoices" || len(invoices.Messages) != 2 {
+		t.Errorf("Unexpected AMQP channel %+v", invoices)
+	}
+
+	example, err := server.AsyncAPIExample("orders.created", "")
+	if err != nil {
+		t.Fatalf("Failed to generate example: %v", err)
+	}
+	if string(example) != `{"id":"00000000-0000-4000-8000-000000000000","quantity":0}` {
+		t.Errorf("Unexpected generated message %s", example)
+	}
+	example, err = server.AsyncAPIExample("invoices", "InvoiceIssued")
+	if err != nil || string(example) != `{"id":"inv-1","total":12}` {
+		t.Errorf("Expected the document's example, got %s (%v)", example, err)
+	}
+	if _, err := server.AsyncAPIExample("invoices", "InvoiceVoided"); err == nil {
+		t.Error("Expected an error for an unknown message")
+	}
+
+	v3 := filepath.Join(t.TempDir(), "v3.yaml")
+	os.WriteFile(v3, []byte(`asyncapi: 3.0.0
+servers:
+  broker: {host: localhost, protocol: mqtt}
+channels:
+  telemetry:
+    address: devices/{id}/telemetry
+    messages:
+      reading:
+        payload: {type: object, properties: {ok: {type: boolean}}}
+`), 0o644)
+	channels, err = NewMockServer(MockServerConfig{AsyncAPISpec: v3}).AsyncAPIChannels()
+	if err != nil || len(channels) != 1 || channels[0].Address != "devices/{id}/telemetry" || channels[0].Messages[0] != "reading" {
+		t.Errorf("Unexpected AsyncAPI 3 channels %+v (%v)", channels, err)
+	}
+}
+
+func TestPublishAsyncAPIExample(t *testing.T) {
+	var paths []string
+	var bodies []map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		w.Write([]byte(`{}`))
+	}))
+	server.config.AsyncAPISpec = "testdata/events.yaml"
+
+	if err := server.PublishAsyncAPIExample("devices/telemetry", ""); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if paths[0] != "/__mockforge/api/mqtt/publish" || bodies[0]["payload"] != `{"celsius":20}` {
+		t.Errorf("Unexpected MQTT publish %s %v", paths[0], bodies[0])
+	}
+
+	if err := server.ConfigureAsyncAPI(); err != nil {
+		t.Fatalf("Failed to configure channels: %v", err)
+	}
+	if len(paths) != 4 || paths[3] != "/__mockforge/api/amqp/exchanges/billing/bindings" || bodies[3]["routing_key"] != "invoices" {
+		t.Errorf("Expected an exchange, a queue, and a binding, got %v", paths)
+	}
+}
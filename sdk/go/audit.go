@@ -0,0 +1,85 @@
+package mockforge
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AuditAction is the kind of admin operation recorded in the audit log
+type AuditAction string
+
+// Common audit actions; the server may record others
+const (
+	AuditRouteCreated         AuditAction = "route_created"
+	AuditRouteUpdated         AuditAction = "route_updated"
+	AuditRouteDeleted         AuditAction = "route_deleted"
+	AuditConfigLatencyUpdated AuditAction = "config_latency_updated"
+	AuditConfigFaultsUpdated  AuditAction = "config_faults_updated"
+	AuditLogsCleared          AuditAction = "logs_cleared"
+	AuditFixtureCreated       AuditAction = "fixture_created"
+	AuditFixtureDeleted       AuditAction = "fixture_deleted"
+	AuditServerRestarted      AuditAction = "server_restarted"
+	AuditChaosToggled         AuditAction = "chaos_toggled"
+	AuditChaosScenarioStarted AuditAction = "chaos_scenario_started"
+	AuditChaosScenarioStopped AuditAction = "chaos_scenario_stopped"
+)
+
+// AuditEntry is one admin operation recorded by the server
+type AuditEntry struct {
+	ID          string                 `json:"id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Action      AuditAction            `json:"action_type"`
+	UserID      string                 `json:"user_id,omitempty"`
+	Username    string                 `json:"username,omitempty"`
+	IPAddress   string                 `json:"ip_address,omitempty"`
+	UserAgent   string                 `json:"user_agent,omitempty"`
+	Description string                 `json:"description"`
+	Resource    string                 `json:"resource,omitempty"`
+	Success     bool                   `json:"success"`
+	Error       string                 `json:"error_message,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// AuditQuery filters the audit log. Zero values match everything.
+type AuditQuery struct {
+	Action AuditAction
+	UserID string
+	Limit  int
+	Offset int
+}
+
+// AuditLog returns every admin operation recorded by the server
+func (m *MockServer) AuditLog() ([]AuditEntry, error) {
+	return m.QueryAuditLog(AuditQuery{})
+}
+
+// QueryAuditLog returns the admin operations matching query
+func (m *MockServer) QueryAuditLog(query AuditQuery) ([]AuditEntry, error) {
+	params := url.Values{}
+	if query.Action != "" {
+		params.Set("action_type", string(query.Action))
+	}
+	if query.UserID != "" {
+		params.Set("user_id", query.UserID)
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Offset > 0 {
+		params.Set("offset", strconv.Itoa(query.Offset))
+	}
+
+	path := "/__mockforge/audit/logs"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var entries []AuditEntry
+	if err := m.adminEnvelope("list audit log", http.MethodGet, path, nil, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,26 @@
+package mockforge
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestQueryAuditLog(t *testing.T) {
+	var query string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"success":true,"data":[{"id":"1","timestamp":"2024-01-01T00:00:00Z","action_type":"route_created","description":"Created GET /users","success":true}]}`))
+	}))
+
+	entries, err := server.QueryAuditLog(AuditQuery{Action: AuditRouteCreated, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to query audit log: %v", err)
+	}
+
+	if query != "action_type=route_created&limit=10" {
+		t.Errorf("Expected filter query, got %q", query)
+	}
+	if len(entries) != 1 || entries[0].Action != AuditRouteCreated || entries[0].Description != "Created GET /users" {
+		t.Errorf("Expected one route_created entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,226 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// benchScenarios are the load shapes mockforge bench accepts
+var benchScenarios = map[string]bool{"constant": true, "ramp-up": true, "spike": true, "stress": true, "soak": true}
+
+// BenchConfig configures a k6 load test run by Bench
+type BenchConfig struct {
+	// Spec is the OpenAPI spec whose operations are exercised
+	Spec string
+	// Target is the base URL under load, e.g. server.URL()
+	Target string
+	// Scenario is "constant", "ramp-up", "spike", "stress", or "soak";
+	// defaults to "ramp-up"
+	Scenario string
+	// VUs is the number of virtual users; defaults to 10
+	VUs int
+	// Duration defaults to one minute and is rounded up to whole seconds
+	Duration time.Duration
+	// OutputDir keeps the script and k6's summary.json; when empty, a
+	// temporary directory is used and removed afterwards
+	OutputDir string
+	// GenerateOnly writes and validates the script without running k6
+	GenerateOnly bool
+}
+
+// BenchResult is the outcome of a Bench run
+type BenchResult struct {
+	// Script is the generated k6 script
+	Script         string
+	TotalRequests  int64
+	FailedRequests int64
+	Iterations     int64
+	// RPS is the achieved request rate
+	RPS    float64
+	VUsMax int
+	// Latencies of all requests, from k6's http_req_duration
+	AvgLatency    time.Duration
+	MinLatency    time.Duration
+	MedianLatency time.Duration
+	P90Latency    time.Duration
+	P95Latency    time.Duration
+	P99Latency    time.Duration
+	MaxLatency    time.Duration
+}
+
+// ErrorRate returns the fraction of failed requests, from 0 to 1
+func (r *BenchResult) ErrorRate() float64 {
+	if r.TotalRequests == 0 {
+		return 0
+	}
+	return float64(r.FailedRequests) / float64(r.TotalRequests)
+}
+
+// Bench runs `mockforge bench` against cfg.Target and parses k6's summary.
+// The generated script is checked before any load is sent: every top-level
+// variable must be a valid, unique JavaScript identifier, so specs whose
+// operationIds contain dots or collide after sanitizing fail fast rather
+// than midway through a k6 run. Requires the mockforge CLI and, unless
+// GenerateOnly is set, k6.
+func Bench(cfg BenchConfig) (*BenchResult, error) {
+	if cfg.Spec == "" || cfg.Target == "" {
+		return nil, NewInvalidConfigError("Bench requires a Spec and a Target", nil)
+	}
+	if cfg.Scenario == "" {
+		cfg.Scenario = "ramp-up"
+	}
+	if !benchScenarios[cfg.Scenario] {
+		return nil, NewInvalidConfigError(fmt.Sprintf("unknown bench scenario %q", cfg.Scenario), map[string]interface{}{"scenarios": "constant, ramp-up, spike, stress, soak"})
+	}
+	if cfg.VUs <= 0 {
+		cfg.VUs = 10
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = time.Minute
+	}
+	if _, err := exec.LookPath("mockforge"); err != nil {
+		return nil, NewCLINotFoundError(err)
+	}
+
+	dir := cfg.OutputDir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "mockforge-bench-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create bench directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create bench directory: %w", err)
+	}
+
+	args := []string{
+		"bench",
+		"--spec", cfg.Spec,
+		"--target", cfg.Target,
+		"--scenario", cfg.Scenario,
+		"--vus", fmt.Sprint(cfg.VUs),
+		"--duration", fmt.Sprintf("%ds", int64(math.Ceil(cfg.Duration.Seconds()))),
+		"--output", dir,
+	}
+	scriptPath := filepath.Join(dir, "k6-script.js")
+	if err := runBench(append(args, "--generate-only", "--script-output", scriptPath)); err != nil {
+		return nil, err
+	}
+	script, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read k6 script: %w", err)
+	}
+	if problems := validateK6Script(string(script)); len(problems) > 0 {
+		return nil, NewInvalidConfigError("generated k6 script is invalid: "+strings.Join(problems, "; "), map[string]interface{}{"script": scriptPath})
+	}
+
+	result := &BenchResult{Script: string(script)}
+	if cfg.GenerateOnly {
+		return result, nil
+	}
+	if err := runBench(args); err != nil {
+		return nil, err
+	}
+	summary, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read k6 summary: %w", err)
+	}
+	if err := result.parseSummary(summary); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// runBench runs the mockforge CLI, reporting its output on failure
+func runBench(args []string) error {
+	var output bytes.Buffer
+	cmd := exec.Command("mockforge", args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("mockforge bench failed: %w\n%s", err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// k6Summary is the part of k6's summary.json Bench reads
+type k6Summary struct {
+	Metrics map[string]struct {
+		Values map[string]float64 `json:"values"`
+	} `json:"metrics"`
+}
+
+// parseSummary fills the result from k6's summary.json
+func (r *BenchResult) parseSummary(data []byte) error {
+	var summary k6Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return fmt.Errorf("failed to parse k6 summary: %w", err)
+	}
+	value := func(metric, stat string) float64 {
+		return summary.Metrics[metric].Values[stat]
+	}
+	latency := func(stat string) time.Duration {
+		return time.Duration(value("http_req_duration", stat) * float64(time.Millisecond))
+	}
+
+	r.TotalRequests = int64(value("http_reqs", "count"))
+	// http_req_failed is a Rate whose passes are the failed requests
+	r.FailedRequests = int64(value("http_req_failed", "passes"))
+	r.Iterations = int64(value("iterations", "count"))
+	r.RPS = value("http_reqs", "rate")
+	r.VUsMax = int(value("vus_max", "value"))
+	r.AvgLatency = latency("avg")
+	r.MinLatency = latency("min")
+	r.MedianLatency = latency("med")
+	r.P90Latency = latency("p(90)")
+	r.P95Latency = latency("p(95)")
+	r.P99Latency = latency("p(99)")
+	r.MaxLatency = latency("max")
+	return nil
+}
+
+// validateK6Script lists top-level declarations of a k6 script that are not
+// valid JavaScript identifiers or that repeat an earlier name
+func validateK6Script(script string) []string {
+	var problems []string
+	declared := make(map[string]bool)
+	for i, line := range strings.Split(script, "\n") {
+		var rest string
+		for _, keyword := range []string{"const ", "let "} {
+			if strings.HasPrefix(line, keyword) {
+				rest = strings.TrimPrefix(line, keyword)
+			}
+		}
+		name := strings.TrimRight(strings.TrimSpace(strings.SplitN(rest, "=", 2)[0]), ";")
+		if name == "" || strings.HasPrefix(name, "{") || strings.HasPrefix(name, "[") {
+			continue
+		}
+		switch {
+		case !isJSIdentifier(name):
+			problems = append(problems, fmt.Sprintf("line %d: %q is not a valid identifier", i+1, name))
+		case declared[name]:
+			problems = append(problems, fmt.Sprintf("line %d: %q is declared twice", i+1, name))
+		}
+		declared[name] = true
+	}
+	return problems
+}
+
+// isJSIdentifier reports whether name is an ASCII JavaScript identifier
+func isJSIdentifier(name string) bool {
+	for i, r := range name {
+		letter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == '$'
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,105 @@
+package mockforge
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+const benchTestSummary = `{"metrics": {
+  "http_reqs": {"values": {"count": 200, "rate": 19.5}},
+  "http_req_failed": {"values": {"passes": 4, "fails": 196, "rate": 0.02}},
+  "http_req_duration": {"values": {"avg": 12.5, "min": 1, "med": 10, "p(90)": 20, "p(95)": 25, "max": 80}},
+  "iterations": {"values": {"count": 100}},
+  "vus_max": {"values": {"value": 5}}
+}}`
+
+// fakeBenchCLI puts a mockforge on PATH that writes script for
+// --generate-only runs and benchTestSummary otherwise, recording each call
+func fakeBenchCLI(t *testing.T, script string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake mockforge is a shell script")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "script.js"), []byte(script), 0o644)
+	os.WriteFile(filepath.Join(dir, "summary.json"), []byte(benchTestSummary), 0o644)
+	cli := `#!/bin/sh
+echo "$@" >> ` + filepath.Join(dir, "calls") + `
+while [ $# -gt 0 ]; do
+  case $1 in
+    --output) out=$2; shift ;;
+    --script-output) script=$2; shift ;;
+  esac
+  shift
+done
+if [ -n "$script" ]; then cp ` + filepath.Join(dir, "script.js") + ` "$script"; else cp ` + filepath.Join(dir, "summary.json") + ` "$out/summary.json"; fi
+`
+	os.WriteFile(filepath.Join(dir, "mockforge"), []byte(cli), 0o755)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestBench(t *testing.T) {
+	dir := fakeBenchCLI(t, "import http from 'k6/http';\nconst billing_subscriptions_v1_latency = new Trend('billing_subscriptions_v1_latency');\n")
+
+	result, err := Bench(BenchConfig{Spec: "api.yaml", Target: "http://localhost:3000", Scenario: "spike", VUs: 5, Duration: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Bench failed: %v", err)
+	}
+	if result.TotalRequests != 200 || result.FailedRequests != 4 || result.Iterations != 100 || result.VUsMax != 5 {
+		t.Errorf("Unexpected counts: %+v", result)
+	}
+	if result.RPS != 19.5 || result.ErrorRate() != 0.02 {
+		t.Errorf("Expected 19.5 rps at 2%% errors, got %v rps at %v", result.RPS, result.ErrorRate())
+	}
+	if result.AvgLatency != 12500*time.Microsecond || result.P95Latency != 25*time.Millisecond || result.P99Latency != 0 {
+		t.Errorf("Unexpected latencies: avg %v, p95 %v, p99 %v", result.AvgLatency, result.P95Latency, result.P99Latency)
+	}
+	if !strings.Contains(result.Script, "billing_subscriptions_v1_latency") {
+		t.Errorf("Expected the generated script, got %q", result.Script)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "--generate-only") {
+		t.Fatalf("Expected a generate-only call then a run, got %q", calls)
+	}
+	if !strings.Contains(lines[1], "--scenario spike --vus 5 --duration 2s") {
+		t.Errorf("Expected scenario, VUs, and rounded duration, got %q", lines[1])
+	}
+}
+
+func TestBenchRejectsInvalidScript(t *testing.T) {
+	dir := fakeBenchCLI(t, "const billing.subscriptions.v1_latency = new Trend('x');\n")
+
+	if _, err := Bench(BenchConfig{Spec: "api.yaml", Target: "http://localhost:3000"}); err == nil || !strings.Contains(err.Error(), "not a valid identifier") {
+		t.Errorf("Expected invalid identifier error, got %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if strings.Count(string(calls), "\n") != 1 {
+		t.Errorf("Expected no load run after an invalid script, got %q", calls)
+	}
+
+	if _, err := Bench(BenchConfig{Spec: "api.yaml", Target: "http://localhost:3000", Scenario: "burst"}); err == nil {
+		t.Error("Expected error for unknown scenario")
+	}
+}
+
+func TestValidateK6Script(t *testing.T) {
+	script := `const plans_list_latency = new Trend('plans_list_latency');
+const plans_list_latency = new Trend('plans_list_latency');
+const { SharedArray } = require('k6/data');
+export default function() {
+  const res = http.get(BASE_URL);
+}
+function other() {
+  const res = http.get(BASE_URL);
+}`
+	problems := validateK6Script(script)
+	if len(problems) != 1 || !strings.Contains(problems[0], `"plans_list_latency" is declared twice`) {
+		t.Errorf("Expected one duplicate declaration, got %v", problems)
+	}
+}
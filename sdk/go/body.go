@@ -0,0 +1,136 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Chunk is one write of a streamed response body
+type Chunk struct {
+	// Data is written as a single chunk of the chunked transfer encoding
+	Data []byte `json:"data"`
+	// DelayMs is how long the server waits before writing the chunk
+	DelayMs int `json:"delay_ms,omitempty"`
+}
+
+// chunkConfigs converts chunks to the wire format expected by the Admin API
+func chunkConfigs(chunks []Chunk) []map[string]interface{} {
+	configs := make([]map[string]interface{}, len(chunks))
+	for i, chunk := range chunks {
+		configs[i] = map[string]interface{}{
+			"data_base64": base64.StdEncoding.EncodeToString(chunk.Data),
+			"delay_ms":    chunk.DelayMs,
+		}
+	}
+	return configs
+}
+
+// validateBody checks at most one body source is set and that a body file
+// can be read by the server
+func (stub *ResponseStub) validateBody() error {
+	sources := 0
+	for _, set := range []bool{stub.Body != nil, len(stub.BodyBytes) > 0, stub.BodyFile != "", len(stub.Chunks) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return NewInvalidConfigError("only one of Body, BodyBytes, BodyFile, and Chunks may be set", map[string]interface{}{
+			"path": stub.Path,
+		})
+	}
+
+	for i, chunk := range stub.Chunks {
+		if chunk.DelayMs < 0 {
+			return NewInvalidConfigError("chunk delay must not be negative", map[string]interface{}{
+				"chunk":    i,
+				"delay_ms": chunk.DelayMs,
+			})
+		}
+	}
+	if stub.Throttle < 0 {
+		return NewInvalidConfigError("throttle must not be negative", map[string]interface{}{
+			"path":             stub.Path,
+			"bytes_per_second": stub.Throttle,
+		})
+	}
+	if len(stub.Chunks) > 0 && stub.Throttle > 0 {
+		return NewInvalidConfigError("streamed chunks set their own pace and cannot be throttled", map[string]interface{}{
+			"path": stub.Path,
+		})
+	}
+	if len(stub.Chunks) > 0 && (len(stub.Sequence) > 0 || len(stub.RawResponse) > 0) {
+		return NewInvalidConfigError("streamed chunks cannot be combined with a sequence or raw response", map[string]interface{}{
+			"path": stub.Path,
+		})
+	}
+
+	if stub.BodyFile == "" {
+		return nil
+	}
+
+	// The server resolves paths relative to its own working directory
+	path, err := filepath.Abs(stub.BodyFile)
+	if err != nil {
+		return NewInvalidConfigError("invalid body file path", map[string]interface{}{"body_file": stub.BodyFile})
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return NewInvalidConfigError("body file must be a readable file", map[string]interface{}{"body_file": stub.BodyFile})
+	}
+	stub.BodyFile = path
+
+	return nil
+}
+
+// responseHeaders returns the stub's headers, adding a Content-Type for
+// binary, file, and streamed bodies and a Content-Encoding for encoded bodies when none
+// is set
+func (stub ResponseStub) responseHeaders() map[string]string {
+	var contentType string
+	if (len(stub.BodyBytes) > 0 || stub.BodyFile != "" || len(stub.Chunks) > 0) && !hasHeader(stub.Headers, "Content-Type") {
+		contentType = mime.TypeByExtension(filepath.Ext(stub.BodyFile))
+		if contentType == "" && len(stub.BodyBytes) > 0 {
+			contentType = http.DetectContentType(stub.BodyBytes)
+		}
+		if contentType == "" && len(stub.Chunks) > 0 {
+			contentType = http.DetectContentType(stub.Chunks[0].Data)
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	var contentEncoding string
+	if stub.Encoding != "" && !hasHeader(stub.Headers, "Content-Encoding") {
+		contentEncoding = string(stub.Encoding)
+	}
+	if contentType == "" && contentEncoding == "" {
+		return stub.Headers
+	}
+
+	headers := make(map[string]string, len(stub.Headers)+2)
+	for name, value := range stub.Headers {
+		headers[name] = value
+	}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	if contentEncoding != "" {
+		headers["Content-Encoding"] = contentEncoding
+	}
+	return headers
+}
+
+// hasHeader reports whether headers sets name, ignoring case
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
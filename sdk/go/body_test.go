@@ -0,0 +1,121 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBinaryBodies(t *testing.T) {
+	var received map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+
+	t.Run("bytes are base64 encoded", func(t *testing.T) {
+		png := []byte("\x89PNG\r\n\x1a\n\x00")
+		if err := server.AddStub(NewStubBuilder("GET", "/logo.png").BodyBytes(png).Build()); err != nil {
+			t.Fatalf("Failed to add stub: %v", err)
+		}
+
+		response := received["response"].(map[string]interface{})
+		if response["body_base64"] != "iVBORw0KGgoA" {
+			t.Errorf("Expected base64 body, got %v", response["body_base64"])
+		}
+		if headers := response["headers"].(map[string]interface{}); headers["Content-Type"] != "image/png" {
+			t.Errorf("Expected detected image/png, got %v", headers)
+		}
+	})
+
+	t.Run("files are sent as absolute paths", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "report.pdf")
+		os.WriteFile(path, []byte("%PDF-1.4"), 0o644)
+
+		if err := server.AddStub(NewStubBuilder("GET", "/report").BodyFile(path).Build()); err != nil {
+			t.Fatalf("Failed to add stub: %v", err)
+		}
+
+		response := received["response"].(map[string]interface{})
+		if response["body_file"] != path {
+			t.Errorf("Expected body_file %s, got %v", path, response["body_file"])
+		}
+		if headers := response["headers"].(map[string]interface{}); headers["Content-Type"] != "application/pdf" {
+			t.Errorf("Expected application/pdf, got %v", headers)
+		}
+	})
+
+	t.Run("rejects several body sources", func(t *testing.T) {
+		stub := NewStubBuilder("GET", "/").Body("x").BodyBytes([]byte("y")).Build()
+		if err := server.AddStub(stub); err == nil {
+			t.Error("Expected error for Body and BodyBytes together")
+		}
+	})
+
+	t.Run("encoded bodies carry Content-Encoding", func(t *testing.T) {
+		if err := server.AddStub(NewStubBuilder("GET", "/big").Body(map[string]int{"n": 1}).Encoding("br").Build()); err != nil {
+			t.Fatalf("Failed to add stub: %v", err)
+		}
+
+		response := received["response"].(map[string]interface{})
+		if response["content_encoding"] != "br" {
+			t.Errorf("Expected content_encoding br, got %v", response["content_encoding"])
+		}
+		if headers := response["headers"].(map[string]interface{}); headers["Content-Encoding"] != "br" {
+			t.Errorf("Expected Content-Encoding br, got %v", headers)
+		}
+	})
+
+	t.Run("rejects unknown and unsupported encodings", func(t *testing.T) {
+		if err := server.AddStub(NewStubBuilder("GET", "/").Encoding("zstd").Build()); err == nil {
+			t.Error("Expected error for unknown encoding")
+		}
+		chunked := NewStubBuilder("GET", "/").StreamChunks([]Chunk{{Data: []byte("x")}}).GzipBody().Build()
+		if err := server.AddStub(chunked); err == nil {
+			t.Error("Expected error for an encoded chunked body")
+		}
+	})
+
+	t.Run("chunks are streamed with delays", func(t *testing.T) {
+		stub := NewStubBuilder("GET", "/events").StreamChunks([]Chunk{
+			{Data: []byte("data: 1\n\n")},
+			{Data: []byte("data: 2\n\n"), DelayMs: 500},
+		}).Build()
+		if err := server.AddStub(stub); err != nil {
+			t.Fatalf("Failed to add stub: %v", err)
+		}
+
+		response := received["response"].(map[string]interface{})
+		chunks := response["chunks"].([]interface{})
+		if len(chunks) != 2 {
+			t.Fatalf("Expected 2 chunks, got %v", chunks)
+		}
+		second := chunks[1].(map[string]interface{})
+		if second["data_base64"] != "ZGF0YTogMgoK" || second["delay_ms"] != float64(500) {
+			t.Errorf("Expected encoded chunk delayed 500ms, got %v", second)
+		}
+		if _, ok := response["body"]; ok {
+			t.Error("Expected no body alongside chunks")
+		}
+	})
+
+	t.Run("throttled body", func(t *testing.T) {
+		stub := NewStubBuilder("GET", "/download").BodyBytes(make([]byte, 4096)).ThrottleBytesPerSecond(1024).Build()
+		if err := server.AddStub(stub); err != nil {
+			t.Fatalf("Failed to add stub: %v", err)
+		}
+		response := received["response"].(map[string]interface{})
+		if response["throttle_bytes_per_second"] != float64(1024) {
+			t.Errorf("Expected 1024 bytes per second, got %v", response["throttle_bytes_per_second"])
+		}
+
+		if err := server.AddStub(NewStubBuilder("GET", "/download").ThrottleBytesPerSecond(-1).Build()); err == nil {
+			t.Error("Expected error for negative throttle")
+		}
+		chunked := NewStubBuilder("GET", "/download").StreamChunks([]Chunk{{Data: []byte("x")}}).ThrottleBytesPerSecond(1024).Build()
+		if err := server.AddStub(chunked); err == nil {
+			t.Error("Expected error for a throttled chunked body")
+		}
+	})
+}
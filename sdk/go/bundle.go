@@ -0,0 +1,160 @@
+package mockforge
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// StubBundle is a named set of stubs that is loaded into a server in one call
+type StubBundle struct {
+	Name  string         `json:"name"`
+	Stubs []ResponseStub `json:"stubs"`
+}
+
+// LoadBundle registers every stub of bundle in one call, see StubAll.
+// In dry-run mode nothing is registered and the import is recorded instead.
+func (m *MockServer) LoadBundle(bundle *StubBundle) error {
+	if m.isDryRun() {
+		targets := make([]string, len(bundle.Stubs))
+		for i, stub := range bundle.Stubs {
+			targets[i] = fmt.Sprintf("%s %s", stub.Method, stub.Path)
+		}
+		m.recordDryRun("import bundle "+bundle.Name, targets)
+		return nil
+	}
+
+	return m.StubAll(bundle.Stubs)
+}
+
+// specExample is a response example mined from an OpenAPI document
+type specExample struct {
+	status    int
+	name      string
+	mediaType string
+	value     interface{}
+}
+
+// BundleFromSpecExamples mines every response example of an OpenAPI 3 or
+// Swagger 2 document (example, examples, and schema examples, following
+// $refs) into a stub bundle.
+//
+// Each operation answers with its first success example by default. Other
+// variants are selected the way Prism does, with a Prefer request header:
+// "Prefer: code=404" returns the first example of that status and
+// "Prefer: example=premium" returns the named example.
+func BundleFromSpecExamples(specPath string) (*StubBundle, error) {
+	doc, err := loadOpenAPIDocument(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &StubBundle{Name: strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath))}
+
+	for _, op := range doc.operations() {
+		responses, _ := doc.resolve(op.Node["responses"]).(map[string]interface{})
+		var examples []specExample
+		for _, code := range sortedKeys(responses) {
+			status, err := strconv.Atoi(code)
+			if err != nil {
+				// default and range keys such as 2XX have no concrete status
+				continue
+			}
+			response, _ := doc.resolve(responses[code]).(map[string]interface{})
+			examples = append(examples, doc.responseExamples(status, response)...)
+		}
+		if len(examples) == 0 {
+			continue
+		}
+
+		primary := examples[0]
+		for _, example := range examples {
+			if example.status >= 200 && example.status < 300 {
+				primary = example
+				break
+			}
+		}
+		bundle.Stubs = append(bundle.Stubs, primary.stub(op, 0, nil))
+
+		byStatus := make(map[int]bool)
+		for _, example := range examples {
+			if example.status != primary.status && !byStatus[example.status] {
+				byStatus[example.status] = true
+				bundle.Stubs = append(bundle.Stubs, example.stub(op, 1, preferMatch("code", strconv.Itoa(example.status))))
+			}
+			if example.name != "" {
+				bundle.Stubs = append(bundle.Stubs, example.stub(op, 2, preferMatch("example", example.name)))
+			}
+		}
+	}
+
+	if len(bundle.Stubs) == 0 {
+		return nil, NewInvalidConfigError("spec has no response examples", map[string]interface{}{"spec": specPath})
+	}
+	return bundle, nil
+}
+
+// responseExamples collects the examples of one response object
+func (d *openAPIDocument) responseExamples(status int, response map[string]interface{}) []specExample {
+	var examples []specExample
+
+	// OpenAPI 3: content.<media type>.example / examples / schema.example
+	content, _ := response["content"].(map[string]interface{})
+	for _, mediaType := range sortedKeys(content) {
+		media, _ := d.resolve(content[mediaType]).(map[string]interface{})
+		found := len(examples)
+
+		if value, ok := media["example"]; ok {
+			examples = append(examples, specExample{status: status, mediaType: mediaType, value: value})
+		}
+		named, _ := media["examples"].(map[string]interface{})
+		for _, name := range sortedKeys(named) {
+			example, _ := d.resolve(named[name]).(map[string]interface{})
+			if value, ok := example["value"]; ok {
+				examples = append(examples, specExample{status: status, name: name, mediaType: mediaType, value: value})
+			}
+		}
+		if len(examples) == found {
+			schema, _ := d.resolve(media["schema"]).(map[string]interface{})
+			if value, ok := schema["example"]; ok {
+				examples = append(examples, specExample{status: status, mediaType: mediaType, value: value})
+			}
+		}
+	}
+
+	// Swagger 2: examples.<media type>
+	named, _ := response["examples"].(map[string]interface{})
+	for _, mediaType := range sortedKeys(named) {
+		examples = append(examples, specExample{status: status, mediaType: mediaType, value: named[mediaType]})
+	}
+
+	return examples
+}
+
+// stub builds the stub serving the example
+func (e specExample) stub(op openAPIOperation, priority int, match *RequestMatch) ResponseStub {
+	stub := ResponseStub{
+		Method:   op.Method,
+		Path:     op.Path,
+		Status:   e.status,
+		Headers:  map[string]string{"Content-Type": e.mediaType},
+		Priority: priority,
+		Match:    match,
+	}
+	// Non-JSON examples such as text/plain or XML are served verbatim
+	if text, ok := e.value.(string); ok && !strings.Contains(e.mediaType, "json") {
+		stub.BodyBytes = []byte(text)
+	} else {
+		stub.Body = e.value
+	}
+	return stub
+}
+
+// preferMatch matches requests whose Prefer header carries key=value
+func preferMatch(key, value string) *RequestMatch {
+	return &RequestMatch{Headers: map[string]string{
+		"Prefer": `(^|[;,\s])` + key + "=" + regexp.QuoteMeta(value) + `([;,\s]|$)`,
+	}}
+}
@@ -0,0 +1,76 @@
+package mockforge
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBundleFromSpecExamples(t *testing.T) {
+	bundle, err := BundleFromSpecExamples("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("Failed to mine examples: %v", err)
+	}
+
+	if bundle.Name != "petstore" {
+		t.Errorf("Expected bundle name petstore, got %s", bundle.Name)
+	}
+
+	type variant struct {
+		method, path string
+		status       int
+		prefer       string
+	}
+	var got []variant
+	for _, stub := range bundle.Stubs {
+		v := variant{stub.Method, stub.Path, stub.Status, ""}
+		if stub.Match != nil {
+			v.prefer = stub.Match.Headers["Prefer"]
+		}
+		got = append(got, v)
+	}
+
+	expected := []variant{
+		{"GET", "/health", 200, ""},
+		{"POST", "/pets", 201, ""},
+		{"GET", "/pets/{id}", 200, ""},
+		{"GET", "/pets/{id}", 200, `(^|[;,\s])example=cat([;,\s]|$)`},
+		{"GET", "/pets/{id}", 200, `(^|[;,\s])example=dog([;,\s]|$)`},
+		{"GET", "/pets/{id}", 404, `(^|[;,\s])code=404([;,\s]|$)`},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d stubs, got %+v", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Stub %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+
+	if body := string(bundle.Stubs[0].BodyBytes); body != "ok" {
+		t.Errorf("Expected verbatim text body, got %q", body)
+	}
+	if dog := bundle.Stubs[4].Body.(map[string]interface{}); dog["name"] != "Spike" {
+		t.Errorf("Expected $ref example to be resolved, got %v", dog)
+	}
+}
+
+func TestLoadBundleDryRun(t *testing.T) {
+	calls := 0
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	server.WithDryRun(true)
+
+	bundle := &StubBundle{Name: "users", Stubs: []ResponseStub{{Method: "GET", Path: "/users", Status: 200}}}
+	if err := server.LoadBundle(bundle); err != nil {
+		t.Fatalf("Failed to load bundle: %v", err)
+	}
+
+	if calls != 0 {
+		t.Errorf("Expected no admin calls in dry-run mode, got %d", calls)
+	}
+	changes := server.DryRunChanges()
+	if len(changes) != 1 || changes[0].Operation != "import bundle users" || changes[0].Targets[0] != "GET /users" {
+		t.Errorf("Expected recorded import, got %+v", changes)
+	}
+}
@@ -0,0 +1,33 @@
+package mockforge
+
+import "time"
+
+// Callback is an outbound HTTP call a stub makes after responding, such as
+// the webhook an async API sends once a 202-accepted job completes. The URL,
+// header values, and body strings may use {{request.path.<name>}},
+// {{request.header.<name>}}, and {{request.body.<field>}} placeholders,
+// filled from the matched request.
+type Callback struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+	// Delay is the pause between the stub responding and the callback
+	Delay time.Duration `json:"delay,omitempty"`
+}
+
+// mockConfig converts the callback to the format expected by the Admin API
+func (c Callback) mockConfig() map[string]interface{} {
+	config := map[string]interface{}{
+		"method":   c.Method,
+		"url":      c.URL,
+		"delay_ms": c.Delay.Milliseconds(),
+	}
+	if len(c.Headers) > 0 {
+		config["headers"] = c.Headers
+	}
+	if c.Body != nil {
+		config["body"] = c.Body
+	}
+	return config
+}
@@ -0,0 +1,93 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Extractor pulls a typed value out of a recorded request
+type Extractor[T any] func(request RecordedRequest) (T, error)
+
+// CaptureRequests returns the value extract pulls from each request matching
+// pattern, oldest first. It fails on the first request extract cannot handle.
+//
+//	skus, err := mockforge.CaptureRequests(server,
+//	    mockforge.VerificationRequest{Method: "POST", Path: "/orders"},
+//	    mockforge.CaptureJSONPath[string]("$.items[0].sku"))
+func CaptureRequests[T any](server *MockServer, pattern VerificationRequest, extract Extractor[T]) ([]T, error) {
+	result, err := server.verify(pattern, AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	logged, err := decodeLoggedRequests(result.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := recordedRequests(logged)
+	values := make([]T, 0, len(requests))
+	for _, request := range requests {
+		value, err := extract(request)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// CaptureBody decodes the whole JSON body of each request into T
+func CaptureBody[T any]() Extractor[T] {
+	return func(request RecordedRequest) (T, error) {
+		var value T
+		err := request.DecodeJSON(&value)
+		return value, err
+	}
+}
+
+// CaptureJSONPath decodes the value at a simple JSONPath ($.a.b,
+// $.items[0]) of each JSON body into T
+func CaptureJSONPath[T any](path string) Extractor[T] {
+	return func(request RecordedRequest) (T, error) {
+		var value T
+		var decoded interface{}
+		if err := request.DecodeJSON(&decoded); err != nil {
+			return value, err
+		}
+		found, ok := jsonPathValue(decoded, path)
+		if !ok {
+			return value, fmt.Errorf("body of %s %s has no %s", request.Method, request.Path, path)
+		}
+
+		data, err := json.Marshal(found)
+		if err == nil {
+			err = json.Unmarshal(data, &value)
+		}
+		if err != nil {
+			return value, fmt.Errorf("failed to decode %s of %s %s: %w", path, request.Method, request.Path, err)
+		}
+		return value, nil
+	}
+}
+
+// CaptureHeader extracts a header, matched case-insensitively
+func CaptureHeader(name string) Extractor[string] {
+	return func(request RecordedRequest) (string, error) {
+		value, ok := lookupHeader(request.Headers, name)
+		if !ok {
+			return "", fmt.Errorf("%s %s has no header %s", request.Method, request.Path, name)
+		}
+		return value, nil
+	}
+}
+
+// CaptureQueryParam extracts a query parameter
+func CaptureQueryParam(name string) Extractor[string] {
+	return func(request RecordedRequest) (string, error) {
+		value, ok := request.Query[name]
+		if !ok {
+			return "", fmt.Errorf("%s %s has no query parameter %s", request.Method, request.Path, name)
+		}
+		return value, nil
+	}
+}
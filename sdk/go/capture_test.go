@@ -0,0 +1,47 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCaptureRequests(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server returns the most recent entry first
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   2,
+			"matches": []map[string]interface{}{
+				{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "POST", "path": "/orders", "headers": map[string]string{"x-request-id": "b"}, "query_params": map[string]string{"dry_run": "true"}, "metadata": map[string]string{"request_body": `{"items":[{"sku":"b","qty":2}]}`}},
+				{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "POST", "path": "/orders", "headers": map[string]string{"x-request-id": "a"}, "metadata": map[string]string{"request_body": `{"items":[{"sku":"a","qty":1}]}`}},
+			},
+		})
+	}))
+	pattern := VerificationRequest{Method: "POST", Path: "/orders"}
+
+	quantities, err := CaptureRequests(server, pattern, CaptureJSONPath[int]("$.items[0].qty"))
+	if err != nil || len(quantities) != 2 || quantities[0] != 1 || quantities[1] != 2 {
+		t.Errorf("Expected quantities [1 2], got %v (%v)", quantities, err)
+	}
+
+	type order struct {
+		Items []struct{ SKU string }
+	}
+	orders, err := CaptureRequests(server, pattern, CaptureBody[order]())
+	if err != nil || len(orders) != 2 || orders[1].Items[0].SKU != "b" {
+		t.Errorf("Expected decoded orders, got %+v (%v)", orders, err)
+	}
+
+	ids, err := CaptureRequests(server, pattern, CaptureHeader("X-Request-ID"))
+	if err != nil || len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("Expected request IDs [a b], got %v (%v)", ids, err)
+	}
+
+	if _, err := CaptureRequests(server, pattern, CaptureQueryParam("dry_run")); err == nil {
+		t.Error("Expected an error for a request without the query parameter")
+	}
+	if _, err := CaptureRequests(server, pattern, CaptureJSONPath[string]("$.customer")); err == nil {
+		t.Error("Expected an error for a missing JSONPath")
+	}
+}
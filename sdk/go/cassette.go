@@ -0,0 +1,161 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cassetteDir is where cassettes are kept, relative to the test's package
+const cassetteDir = "testdata/cassettes"
+
+// CassetteMode selects whether UseCassette records or replays
+type CassetteMode string
+
+const (
+	// CassetteAuto records when MOCKFORGE_RECORD=1 or the cassette does not
+	// exist yet, and replays otherwise
+	CassetteAuto CassetteMode = "auto"
+	// CassetteRecord always records, replacing the cassette
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay always replays, failing the test without a cassette
+	CassetteReplay CassetteMode = "replay"
+)
+
+// Cassette is the recording UseCassette manages for a test
+type Cassette struct {
+	// Name is the cassette's name, which defaults to the test's name
+	Name string
+	// Path is the cassette file, under testdata/cassettes
+	Path string
+	// Recording is true when the test runs against the upstream
+	Recording bool
+}
+
+// cassetteFile is the file a cassette is saved to: the recorded fixtures,
+// as the server stores them
+type cassetteFile struct {
+	Name     string            `json:"name"`
+	Fixtures []json.RawMessage `json:"fixtures"`
+}
+
+// UseCassette records or replays server traffic for the test, VCR-style.
+// The cassette testdata/cassettes/<name>.json is recorded on the first run,
+// or whenever MOCKFORGE_RECORD=1 is set, by passing requests no stub matches
+// to opts.Upstream; it is saved when the test finishes, unless the test
+// failed. Later runs replay the cassette as stubs without the upstream, so
+// they are deterministic and work offline. Commit the cassettes with the
+// tests.
+//
+//	server := mockforge.NewMockServer(mockforge.MockServerConfig{})
+//	server.Start()
+//	t.Cleanup(func() { server.Stop() }) // Before UseCassette, so the cassette is saved first
+//	mockforge.UseCassette(t, server, "", mockforge.CassetteAuto, mockforge.RecordOptions{
+//	    Upstream: "https://api.example.com",
+//	})
+func UseCassette(t testing.TB, server *MockServer, name string, mode CassetteMode, opts RecordOptions) *Cassette {
+	t.Helper()
+
+	if name == "" {
+		name = t.Name()
+	}
+	cassette := &Cassette{Name: name, Path: filepath.Join(cassetteDir, fixtureName(name)+".json")}
+	_, err := os.Stat(cassette.Path)
+	missing := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !missing {
+		t.Fatalf("Failed to read cassette %s: %v", cassette.Path, err)
+	}
+
+	switch mode {
+	case CassetteAuto, "":
+		cassette.Recording = missing || os.Getenv("MOCKFORGE_RECORD") == "1"
+	case CassetteRecord:
+		cassette.Recording = true
+	case CassetteReplay:
+		if missing {
+			t.Fatalf("Cassette %s not recorded; run with MOCKFORGE_RECORD=1 to record it", cassette.Path)
+		}
+	default:
+		t.Fatalf("Unknown cassette mode %q", mode)
+	}
+
+	if !cassette.Recording {
+		if err := cassette.replay(server); err != nil {
+			t.Fatalf("Failed to replay cassette %s: %v", cassette.Path, err)
+		}
+		return cassette
+	}
+
+	if err := server.StartRecording(opts); err != nil {
+		t.Fatalf("Failed to record cassette %s: %v", cassette.Path, err)
+	}
+	t.Cleanup(func() {
+		if err := cassette.save(server, t.Failed()); err != nil {
+			t.Errorf("Failed to save cassette %s: %v", cassette.Path, err)
+		}
+	})
+	return cassette
+}
+
+// replay registers the cassette's fixtures as stubs
+func (c *Cassette) replay(server *MockServer) error {
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return NewInvalidConfigError(fmt.Sprintf("failed to parse cassette: %v", err), map[string]interface{}{"path": c.Path})
+	}
+
+	stubs := make([]ResponseStub, 0, len(file.Fixtures))
+	for i, fixture := range file.Fixtures {
+		stub, err := fixtureStub(fixture)
+		if err != nil {
+			return NewInvalidConfigError(fmt.Sprintf("fixture %d: %v", i+1, err), map[string]interface{}{"path": c.Path})
+		}
+		stubs = append(stubs, stub)
+	}
+	return server.StubAll(stubs)
+}
+
+// save stops recording and writes the recorded fixtures to the cassette,
+// moving them out of the server's fixture storage. A failed test discards
+// the recording.
+func (c *Cassette) save(server *MockServer, discard bool) error {
+	fixtures, err := server.StopRecording()
+	if err != nil {
+		return err
+	}
+
+	file := cassetteFile{Name: c.Name, Fixtures: make([]json.RawMessage, 0, len(fixtures))}
+	for _, fixture := range fixtures {
+		data, err := server.DownloadFixture(fixture.ID)
+		if err != nil {
+			return err
+		}
+		file.Fixtures = append(file.Fixtures, data)
+		if err := server.DeleteFixture(fixture.ID); err != nil {
+			return err
+		}
+	}
+	if discard {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(c.Path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
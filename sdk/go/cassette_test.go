@@ -0,0 +1,95 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// inTempDir runs the rest of the test in a fresh working directory
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestUseCassette(t *testing.T) {
+	inTempDir(t)
+	upstreamHits := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	defer upstream.Close()
+	store := &fixtureStore{}
+	server := newAdminTestServer(t, store)
+	opts := RecordOptions{Upstream: upstream.URL}
+
+	// First run: no cassette, so the traffic is recorded
+	t.Run("checkout", func(t *testing.T) {
+		cassette := UseCassette(t, server, "", CassetteAuto, opts)
+		if !cassette.Recording || cassette.Path != filepath.Join("testdata", "cassettes", "TestUseCassette_checkout.json") {
+			t.Fatalf("Expected to record the test's cassette, got %+v", cassette)
+		}
+		resp, err := http.Get(store.passthroughURL() + "/orders/42")
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	data, err := os.ReadFile("testdata/cassettes/TestUseCassette_checkout.json")
+	if err != nil {
+		t.Fatalf("Expected the cassette saved: %v", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil || len(file.Fixtures) != 1 {
+		t.Fatalf("Expected one recorded fixture, got %s", data)
+	}
+	if len(store.fixtures) != 0 {
+		t.Errorf("Expected the fixtures moved out of the server, got %+v", store.fixtures)
+	}
+
+	// Second run: the cassette replays as stubs without the upstream
+	t.Run("replay", func(t *testing.T) {
+		cassette := UseCassette(t, server, "TestUseCassette/checkout", CassetteAuto, opts)
+		if cassette.Recording {
+			t.Fatal("Expected the existing cassette replayed")
+		}
+	})
+	if upstreamHits != 1 || store.passthroughURL() != "" {
+		t.Errorf("Expected the upstream called once, got %d", upstreamHits)
+	}
+	if len(server.stubs) != 1 || server.stubs[0].Path != "/orders/42" || server.stubs[0].Body.(map[string]interface{})["id"] != "42" {
+		t.Errorf("Expected the recorded response as a stub, got %+v", server.stubs)
+	}
+
+	// MOCKFORGE_RECORD=1 records again
+	t.Setenv("MOCKFORGE_RECORD", "1")
+	t.Run("rerecord", func(t *testing.T) {
+		if cassette := UseCassette(t, server, "TestUseCassette/checkout", CassetteAuto, opts); !cassette.Recording {
+			t.Error("Expected MOCKFORGE_RECORD to force recording")
+		}
+	})
+}
+
+func TestUseCassetteReplayMissing(t *testing.T) {
+	inTempDir(t)
+	server := NewMockServer(MockServerConfig{})
+
+	recorder := &recordingTB{TB: t}
+	UseCassette(recorder, server, "missing", CassetteReplay, RecordOptions{})
+	if recorder.failure == "" {
+		t.Error("Expected replaying a missing cassette to fail the test")
+	}
+}
@@ -0,0 +1,72 @@
+package mockforge
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// FaultInjection configures random failures across every route
+type FaultInjection struct {
+	Enabled bool
+	// FailureRate is the fraction of requests that fail, from 0 to 1
+	FailureRate float64
+	// StatusCodes are the statuses failed requests return
+	StatusCodes []int
+}
+
+// SetChaosEnabled turns chaos engineering on or off on the server
+func (m *MockServer) SetChaosEnabled(enabled bool) error {
+	return m.adminEnvelope("toggle chaos", http.MethodPost, "/__mockforge/chaos/toggle", map[string]bool{"enabled": enabled}, nil)
+}
+
+// StartChaosScenario starts a predefined chaos scenario: network_degradation,
+// service_instability, cascading_failure, peak_traffic, or slow_backend. Its
+// settings are layered over the current latency and faults, enabling chaos
+// if it is off.
+func (m *MockServer) StartChaosScenario(name string) error {
+	return m.adminEnvelope("start chaos scenario", http.MethodPost, "/__mockforge/chaos/scenarios/"+url.PathEscape(name), nil, nil)
+}
+
+// StopChaosScenario stops a running chaos scenario. Stopping the last one
+// turns chaos off.
+func (m *MockServer) StopChaosScenario(name string) error {
+	return m.adminEnvelope("stop chaos scenario", http.MethodDelete, "/__mockforge/chaos/scenarios/"+url.PathEscape(name), nil, nil)
+}
+
+// SetGlobalLatency adds base plus up to jitter of latency to every response,
+// turning chaos on. The admin API is never delayed.
+func (m *MockServer) SetGlobalLatency(base, jitter time.Duration) error {
+	return m.updateConfig("latency", map[string]interface{}{
+		"base_ms":   base.Milliseconds(),
+		"jitter_ms": jitter.Milliseconds(),
+	})
+}
+
+// SetFaultInjection configures random failures across every route, turning
+// chaos on when faults are enabled. The admin API never fails.
+func (m *MockServer) SetFaultInjection(faults FaultInjection) error {
+	if faults.FailureRate < 0 || faults.FailureRate > 1 {
+		return NewInvalidConfigError("failure rate must be between 0 and 1", map[string]interface{}{
+			"failure_rate": faults.FailureRate,
+		})
+	}
+
+	statusCodes := faults.StatusCodes
+	if statusCodes == nil {
+		statusCodes = []int{}
+	}
+	return m.updateConfig("faults", map[string]interface{}{
+		"enabled":      faults.Enabled,
+		"failure_rate": faults.FailureRate,
+		"status_codes": statusCodes,
+	})
+}
+
+// updateConfig applies a runtime configuration update of configType
+func (m *MockServer) updateConfig(configType string, data map[string]interface{}) error {
+	return m.adminEnvelope("update "+configType+" config", http.MethodPost, "/__mockforge/config/"+configType, map[string]interface{}{
+		"config_type": configType,
+		"data":        data,
+	}, nil)
+}
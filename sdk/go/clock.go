@@ -0,0 +1,74 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// clockStatus is the server's virtual clock status
+type clockStatus struct {
+	Enabled     bool       `json:"enabled"`
+	CurrentTime *time.Time `json:"current_time"`
+}
+
+// SetMockTime freezes the server clock at t. Template functions such as
+// {{now}}, token expiry, and scheduled callbacks all use this clock until
+// ResetTime is called.
+func (m *MockServer) SetMockTime(t time.Time) error {
+	return m.adminJSON("set mock time", http.MethodPost, "/__mockforge/time-travel/enable", map[string]interface{}{
+		"time": t.UTC().Format(time.RFC3339Nano),
+		// At scale 1 the server holds virtual time still between changes
+		"scale": 1,
+	}, nil)
+}
+
+// AdvanceTime moves the server clock forward by d, or back for a negative
+// d. If the clock is not frozen yet, it is frozen at the current time first.
+func (m *MockServer) AdvanceTime(d time.Duration) error {
+	if d > 0 && d%time.Second == 0 {
+		status, err := m.clockStatus()
+		if err != nil {
+			return err
+		}
+		if status.Enabled {
+			return m.adminJSON("advance time", http.MethodPost, "/__mockforge/time-travel/advance", map[string]string{
+				"duration": fmt.Sprintf("%ds", d/time.Second),
+			}, nil)
+		}
+	}
+
+	// The server advances in whole seconds only, so anything else is set
+	// as an absolute time
+	now, err := m.MockTime()
+	if err != nil {
+		return err
+	}
+	return m.SetMockTime(now.Add(d))
+}
+
+// MockTime returns the server's current time, virtual or real
+func (m *MockServer) MockTime() (time.Time, error) {
+	status, err := m.clockStatus()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if status.Enabled && status.CurrentTime != nil {
+		return *status.CurrentTime, nil
+	}
+	return time.Now(), nil
+}
+
+// ResetTime returns the server to the real clock
+func (m *MockServer) ResetTime() error {
+	return m.adminJSON("reset time", http.MethodPost, "/__mockforge/time-travel/reset", nil, nil)
+}
+
+// clockStatus reads the virtual clock status
+func (m *MockServer) clockStatus() (*clockStatus, error) {
+	var status clockStatus
+	if err := m.adminJSON("get time", http.MethodGet, "/__mockforge/time-travel/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
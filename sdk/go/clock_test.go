@@ -0,0 +1,62 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMockClock(t *testing.T) {
+	var current *time.Time
+	var advanced []string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/__mockforge/time-travel/enable":
+			var req struct {
+				Time time.Time `json:"time"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			current = &req.Time
+		case "/__mockforge/time-travel/advance":
+			var req struct {
+				Duration string `json:"duration"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			advanced = append(advanced, req.Duration)
+			d, _ := time.ParseDuration(req.Duration)
+			next := current.Add(d)
+			current = &next
+		case "/__mockforge/time-travel/status":
+			json.NewEncoder(w).Encode(map[string]interface{}{"enabled": current != nil, "current_time": current})
+		case "/__mockforge/time-travel/reset":
+			current = nil
+		}
+	}))
+
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := server.SetMockTime(start); err != nil {
+		t.Fatalf("Failed to set time: %v", err)
+	}
+	if err := server.AdvanceTime(2 * time.Hour); err != nil {
+		t.Fatalf("Failed to advance time: %v", err)
+	}
+	if err := server.AdvanceTime(1500 * time.Millisecond); err != nil {
+		t.Fatalf("Failed to advance time: %v", err)
+	}
+
+	now, err := server.MockTime()
+	if err != nil {
+		t.Fatalf("Failed to get time: %v", err)
+	}
+	if want := start.Add(2*time.Hour + 1500*time.Millisecond); !now.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, now)
+	}
+	if len(advanced) != 1 || advanced[0] != "7200s" {
+		t.Errorf("Expected one whole-second advance, got %v", advanced)
+	}
+
+	if err := server.ResetTime(); err != nil || current != nil {
+		t.Errorf("Expected clock to be reset, got %v (%v)", current, err)
+	}
+}
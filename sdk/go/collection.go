@@ -0,0 +1,308 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// CollectionFormat is an API client's collection format for ExportCollection
+type CollectionFormat string
+
+const (
+	// CollectionFormatInsomnia writes an Insomnia v4 export
+	CollectionFormatInsomnia CollectionFormat = "insomnia"
+	// CollectionFormatBruno writes a Bruno collection export
+	CollectionFormatBruno CollectionFormat = "bruno"
+)
+
+// collectionRequest is a request that a stub answers, ready for an API
+// client
+type collectionRequest struct {
+	folder     string
+	name       string
+	method     string
+	path       string
+	pathParams []string
+	query      [][2]string
+	headers    [][2]string
+	body       string
+	docs       string
+}
+
+// ExportCollection writes the registered stubs to w as an Insomnia or Bruno
+// collection with one request per stub, grouped in folders by the first
+// path segment they share, so the mock can be explored interactively. Requests carry
+// the query parameters, literal headers, cookies, and JSON body the stub
+// matches on, and their docs show the response the stub returns. Requests
+// target a base URL variable set to the server's URL. Stubs on PathRegex
+// paths are left out.
+func (m *MockServer) ExportCollection(format CollectionFormat, w io.Writer) error {
+	if format != CollectionFormatInsomnia && format != CollectionFormatBruno {
+		return NewInvalidConfigError("unknown collection format", map[string]interface{}{"format": format})
+	}
+	stubs, err := m.registeredStubs()
+	if err != nil {
+		return err
+	}
+
+	var requests []collectionRequest
+	perFolder := make(map[string]int)
+	for i := range stubs {
+		if isRegexPath(stubs[i].Path) {
+			continue
+		}
+		request := newCollectionRequest(&stubs[i])
+		perFolder[request.folder]++
+		requests = append(requests, request)
+	}
+	names := make(map[string]int)
+	for i := range requests {
+		// A folder holding a single request is only clutter
+		if perFolder[requests[i].folder] < 2 {
+			requests[i].folder = ""
+		}
+		// Clients key requests by name within a folder
+		key := requests[i].folder + "\x00" + requests[i].name
+		names[key]++
+		if n := names[key]; n > 1 {
+			requests[i].name = fmt.Sprintf("%s (%d)", requests[i].name, n)
+		}
+	}
+
+	var document interface{}
+	if format == CollectionFormatInsomnia {
+		document = insomniaCollection(requests, m.URL())
+	} else {
+		document = brunoCollection(requests, m.URL())
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode collection: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// newCollectionRequest describes the request a stub answers
+func newCollectionRequest(stub *ResponseStub) collectionRequest {
+	request := collectionRequest{method: strings.ToUpper(stub.Method)}
+	request.name = request.method + " " + stub.Path
+	segments := strings.Split(stub.Path, "/")
+	for i, segment := range segments {
+		if matches := pathParamPattern.FindStringSubmatch(segment); matches != nil {
+			segments[i] = ":" + matches[1]
+			request.pathParams = append(request.pathParams, matches[1])
+		}
+	}
+	request.path = strings.Join(segments, "/")
+	if len(segments) > 1 {
+		request.folder = segments[1]
+	}
+
+	if match := stub.Match; match != nil {
+		for _, name := range sortedStringKeys(match.QueryParams) {
+			request.query = append(request.query, [2]string{name, match.QueryParams[name]})
+		}
+		// Header values are regular expressions; only exact ones make an
+		// example
+		for _, name := range sortedStringKeys(match.Headers) {
+			if value, ok := literalHeaderValue(match.Headers[name]); ok {
+				request.headers = append(request.headers, [2]string{name, value})
+			}
+		}
+		for _, name := range match.HeadersPresent {
+			request.headers = append(request.headers, [2]string{name, ""})
+		}
+		if len(match.Cookies) > 0 {
+			var cookies []string
+			for _, name := range sortedStringKeys(match.Cookies) {
+				cookies = append(cookies, name+"="+match.Cookies[name])
+			}
+			request.headers = append(request.headers, [2]string{"Cookie", strings.Join(cookies, "; ")})
+		}
+		if match.BodyJSON != nil {
+			if data, err := json.MarshalIndent(normalizeJSON(match.BodyJSON), "", "  "); err == nil {
+				request.body = string(data)
+			}
+		}
+	}
+
+	status, body := stub.Status, stub.Body
+	if len(stub.Sequence) > 0 {
+		status, body = stub.Sequence[0].Status, stub.Sequence[0].Body
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	request.docs = fmt.Sprintf("Responds %d %s", status, http.StatusText(status))
+	switch {
+	case len(stub.BodyBytes) > 0 || stub.BodyFile != "" || len(stub.Chunks) > 0:
+		request.docs += " with a binary or streamed body."
+	case body != nil:
+		if text, ok := body.(string); ok {
+			request.docs += fmt.Sprintf(" with:\n\n```\n%s\n```\n", text)
+		} else if data, err := json.MarshalIndent(normalizeJSON(body), "", "  "); err == nil {
+			request.docs += fmt.Sprintf(" with:\n\n```json\n%s\n```\n", data)
+		}
+	default:
+		request.docs += "."
+	}
+	return request
+}
+
+// insomniaCollection builds an Insomnia v4 export
+func insomniaCollection(requests []collectionRequest, baseURL string) map[string]interface{} {
+	const workspace = "wrk_mockforge"
+	resources := []interface{}{
+		map[string]interface{}{"_id": workspace, "_type": "workspace", "parentId": nil, "name": "MockForge stubs", "scope": "collection"},
+		map[string]interface{}{"_id": "env_mockforge", "_type": "environment", "parentId": workspace, "name": "Base Environment", "data": map[string]interface{}{"base_url": baseURL}},
+	}
+	folders := make(map[string]string)
+	for _, folder := range collectionFolders(requests) {
+		folders[folder] = fmt.Sprintf("fld_mockforge_%d", len(folders)+1)
+		resources = append(resources, map[string]interface{}{"_id": folders[folder], "_type": "request_group", "parentId": workspace, "name": folder})
+	}
+
+	for i, r := range requests {
+		parent := workspace
+		if r.folder != "" {
+			parent = folders[r.folder]
+		}
+		resource := map[string]interface{}{
+			"_id":         fmt.Sprintf("req_mockforge_%d", i+1),
+			"_type":       "request",
+			"parentId":    parent,
+			"name":        r.name,
+			"method":      r.method,
+			"url":         "{{ _.base_url }}" + r.path,
+			"description": r.docs,
+			"parameters":  insomniaPairs(r.query),
+			"headers":     insomniaPairs(r.headers),
+			"body":        map[string]interface{}{},
+		}
+		if len(r.pathParams) > 0 {
+			params := make([]interface{}, len(r.pathParams))
+			for j, name := range r.pathParams {
+				params[j] = map[string]interface{}{"name": name, "value": "1"}
+			}
+			resource["pathParameters"] = params
+		}
+		if r.body != "" {
+			resource["body"] = map[string]interface{}{"mimeType": "application/json", "text": r.body}
+			if !hasHeaderPair(r.headers, "Content-Type") {
+				resource["headers"] = append(resource["headers"].([]interface{}), map[string]interface{}{"name": "Content-Type", "value": "application/json"})
+			}
+		}
+		resources = append(resources, resource)
+	}
+
+	return map[string]interface{}{
+		"_type":           "export",
+		"__export_format": 4,
+		"__export_source": "mockforge.sdk.go",
+		"resources":       resources,
+	}
+}
+
+// insomniaPairs converts name/value pairs to Insomnia's form
+func insomniaPairs(pairs [][2]string) []interface{} {
+	converted := make([]interface{}, len(pairs))
+	for i, pair := range pairs {
+		converted[i] = map[string]interface{}{"name": pair[0], "value": pair[1]}
+	}
+	return converted
+}
+
+// brunoCollection builds a Bruno collection export
+func brunoCollection(requests []collectionRequest, baseURL string) map[string]interface{} {
+	var items []interface{}
+	folderItems := make(map[string][]interface{})
+	for i, r := range requests {
+		rawURL := "{{baseUrl}}" + r.path
+		params := make([]interface{}, 0, len(r.query)+len(r.pathParams))
+		if len(r.query) > 0 {
+			query := make([]string, len(r.query))
+			for j, pair := range r.query {
+				query[j] = url.QueryEscape(pair[0]) + "=" + url.QueryEscape(pair[1])
+				params = append(params, map[string]interface{}{"name": pair[0], "value": pair[1], "type": "query", "enabled": true})
+			}
+			rawURL += "?" + strings.Join(query, "&")
+		}
+		for _, name := range r.pathParams {
+			params = append(params, map[string]interface{}{"name": name, "value": "1", "type": "path", "enabled": true})
+		}
+		headers := make([]interface{}, len(r.headers))
+		for j, pair := range r.headers {
+			headers[j] = map[string]interface{}{"name": pair[0], "value": pair[1], "enabled": true}
+		}
+		body := map[string]interface{}{"mode": "none"}
+		if r.body != "" {
+			body = map[string]interface{}{"mode": "json", "json": r.body}
+		}
+
+		item := map[string]interface{}{
+			"type": "http-request",
+			"name": r.name,
+			"seq":  i + 1,
+			"request": map[string]interface{}{
+				"url":     rawURL,
+				"method":  r.method,
+				"headers": headers,
+				"params":  params,
+				"body":    body,
+				"auth":    map[string]interface{}{"mode": "none"},
+				"docs":    r.docs,
+			},
+		}
+		if r.folder == "" {
+			items = append(items, item)
+		} else {
+			folderItems[r.folder] = append(folderItems[r.folder], item)
+		}
+	}
+	for _, folder := range collectionFolders(requests) {
+		items = append(items, map[string]interface{}{"type": "folder", "name": folder, "items": folderItems[folder]})
+	}
+
+	return map[string]interface{}{
+		"name":    "MockForge stubs",
+		"version": "1",
+		"items":   items,
+		"environments": []interface{}{map[string]interface{}{
+			"name": "MockForge",
+			"variables": []interface{}{map[string]interface{}{
+				"name": "baseUrl", "value": baseURL, "enabled": true, "secret": false, "type": "text",
+			}},
+		}},
+		"brunoConfig": map[string]interface{}{"version": "1", "name": "MockForge stubs", "type": "collection"},
+	}
+}
+
+// collectionFolders returns the requests' folders in name order
+func collectionFolders(requests []collectionRequest) []string {
+	seen := make(map[string]bool)
+	var folders []string
+	for _, r := range requests {
+		if r.folder != "" && !seen[r.folder] {
+			seen[r.folder] = true
+			folders = append(folders, r.folder)
+		}
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+// hasHeaderPair reports whether pairs set the named header
+func hasHeaderPair(pairs [][2]string, name string) bool {
+	for _, pair := range pairs {
+		if strings.EqualFold(pair[0], name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// collectionTestServer has two stubs on one route, a JSON-matching POST,
+// a root path, and a regex path that cannot be exported
+func collectionTestServer() *MockServer {
+	server := NewMockServer(MockServerConfig{})
+	server.AddStub(NewStubBuilder("GET", "/orders/{id}").
+		WhenQuery("expand", "items").
+		WhenHeader("X-Tenant", "acme").
+		Body(map[string]interface{}{"id": "42"}).
+		Build())
+	server.AddStub(NewStubBuilder("GET", "/orders/{id}").Status(404).Body("not found").Build())
+	server.AddStub(NewStubBuilder("POST", "/orders").WhenBodyJSON(map[string]string{"sku": "a"}).Status(201).Build())
+	server.AddStub(NewStubBuilder("GET", "/health").Body("ok").Build())
+	server.AddStub(ResponseStub{Method: "GET", Path: PathRegex(`^/legacy/.*$`), Status: 200})
+	return server
+}
+
+func TestExportCollectionInsomnia(t *testing.T) {
+	var out bytes.Buffer
+	if err := collectionTestServer().ExportCollection(CollectionFormatInsomnia, &out); err != nil {
+		t.Fatalf("Failed to export collection: %v", err)
+	}
+	var export struct {
+		Format    int                      `json:"__export_format"`
+		Resources []map[string]interface{} `json:"resources"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &export); err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	if export.Format != 4 {
+		t.Errorf("Expected export format 4, got %d", export.Format)
+	}
+
+	requests := make(map[string]map[string]interface{})
+	folders := make(map[string]string)
+	for _, resource := range export.Resources {
+		switch resource["_type"] {
+		case "request":
+			requests[resource["name"].(string)] = resource
+		case "request_group":
+			folders[resource["_id"].(string)] = resource["name"].(string)
+		}
+	}
+	if len(requests) != 4 {
+		t.Fatalf("Expected 4 requests without the regex stub, got %v", requests)
+	}
+
+	get := requests["GET /orders/{id}"]
+	if get["url"] != "{{ _.base_url }}/orders/:id" || folders[get["parentId"].(string)] != "orders" {
+		t.Errorf("Expected a templated URL in the orders folder, got %v", get)
+	}
+	if params := get["parameters"].([]interface{}); len(params) != 1 || params[0].(map[string]interface{})["value"] != "items" {
+		t.Errorf("Expected the matched query parameter, got %v", params)
+	}
+	if !strings.Contains(get["description"].(string), `"id": "42"`) {
+		t.Errorf("Expected the response body in the description, got %q", get["description"])
+	}
+	if missing := requests["GET /orders/{id} (2)"]; missing == nil || !strings.HasPrefix(missing["description"].(string), "Responds 404 Not Found") {
+		t.Errorf("Expected the second stub under a distinct name, got %v", missing)
+	}
+
+	post := requests["POST /orders"]
+	if body := post["body"].(map[string]interface{}); body["mimeType"] != "application/json" || !strings.Contains(body["text"].(string), `"sku": "a"`) {
+		t.Errorf("Expected the matched JSON body, got %v", body)
+	}
+	if requests["GET /health"]["parentId"] != "wrk_mockforge" {
+		t.Errorf("Expected a root path outside folders, got %v", requests["GET /health"])
+	}
+}
+
+func TestExportCollectionBruno(t *testing.T) {
+	var out bytes.Buffer
+	if err := collectionTestServer().ExportCollection(CollectionFormatBruno, &out); err != nil {
+		t.Fatalf("Failed to export collection: %v", err)
+	}
+	var collection struct {
+		Items []struct {
+			Type    string                   `json:"type"`
+			Name    string                   `json:"name"`
+			Items   []map[string]interface{} `json:"items"`
+			Request map[string]interface{}   `json:"request"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &collection); err != nil {
+		t.Fatalf("Failed to parse collection: %v", err)
+	}
+	if len(collection.Items) != 2 || collection.Items[0].Name != "GET /health" || collection.Items[1].Type != "folder" {
+		t.Fatalf("Expected the health request and an orders folder, got %s", out.String())
+	}
+
+	orders := collection.Items[1].Items
+	if len(orders) != 3 {
+		t.Fatalf("Expected 3 order requests, got %v", orders)
+	}
+	get := orders[1]["request"].(map[string]interface{})
+	if get["url"] != "{{baseUrl}}/orders/:id?expand=items" {
+		t.Errorf("Expected query and path parameters in the URL, got %v", get["url"])
+	}
+	if headers := get["headers"].([]interface{}); len(headers) != 1 || headers[0].(map[string]interface{})["value"] != "acme" {
+		t.Errorf("Expected the literal tenant header, got %v", headers)
+	}
+	if body := orders[0]["request"].(map[string]interface{})["body"].(map[string]interface{}); body["mode"] != "json" {
+		t.Errorf("Expected a JSON body on the POST, got %v", body)
+	}
+
+	if err := collectionTestServer().ExportCollection("postman", &out); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
@@ -0,0 +1,256 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of this SDK. It is also the CLI version the SDK is
+// developed against and the one CheckCompatibility recommends.
+const Version = "0.3.209"
+
+// MinimumCLIVersion is the oldest mockforge CLI the SDK supports
+const MinimumCLIVersion = "0.3.0"
+
+// sdkFlags are the serve flags Start passes to the CLI
+var sdkFlags = []string{"--config", "--spec", "--http-port", "--admin", "--admin-port"}
+
+// sdkFeatures are the capabilities the SDK relies on in every server
+var sdkFeatures = []string{"management", "mocks", "proxy", "chaos"}
+
+// sdkEndpoints are admin endpoints the SDK calls. Each is probed with a GET,
+// which a route answers with anything but 404 when it exists.
+var sdkEndpoints = []string{
+	"/__mockforge/api/mocks",
+	"/__mockforge/api/mocks/bulk",
+	"/__mockforge/api/scenario-states",
+	"/__mockforge/api/fallbacks",
+	"/__mockforge/config/latency",
+	"/__mockforge/chaos/toggle",
+	"/__mockforge/audit/logs",
+}
+
+// CompatibilityReport describes how well the installed CLI and a running
+// server match this SDK
+type CompatibilityReport struct {
+	SDKVersion string
+	// CLIVersion is the version of the mockforge binary on PATH, or empty if
+	// it is not installed
+	CLIVersion string
+	// ServerVersion and Features are the running server's capabilities; both
+	// are empty when no server was checked
+	ServerVersion string
+	Features      []string
+	// MissingFeatures are capabilities the SDK relies on that the server
+	// does not report
+	MissingFeatures []string
+	// MissingEndpoints are admin endpoints the SDK calls that the server
+	// does not serve
+	MissingEndpoints []string
+	// DeprecatedFlags are serve flags the SDK passes that the CLI marks as
+	// deprecated; UnsupportedFlags are ones it does not know at all
+	DeprecatedFlags  []string
+	UnsupportedFlags []string
+	// RecommendedUpgrade is a human-readable upgrade advice, or empty if
+	// everything is compatible
+	RecommendedUpgrade string
+}
+
+// Compatible reports whether nothing the SDK relies on is missing
+func (r *CompatibilityReport) Compatible() bool {
+	return r.CLIVersion != "" && compareVersions(r.CLIVersion, MinimumCLIVersion) >= 0 &&
+		len(r.MissingFeatures) == 0 && len(r.MissingEndpoints) == 0 && len(r.UnsupportedFlags) == 0
+}
+
+// String summarizes the report for test logs
+func (r *CompatibilityReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "sdk %s, cli %s", r.SDKVersion, orNone(r.CLIVersion))
+	if r.ServerVersion != "" {
+		fmt.Fprintf(&b, ", server %s", r.ServerVersion)
+	}
+	for _, list := range []struct {
+		name  string
+		items []string
+	}{
+		{"missing features", r.MissingFeatures},
+		{"missing endpoints", r.MissingEndpoints},
+		{"deprecated flags", r.DeprecatedFlags},
+		{"unsupported flags", r.UnsupportedFlags},
+	} {
+		if len(list.items) > 0 {
+			fmt.Fprintf(&b, "; %s: %s", list.name, strings.Join(list.items, ", "))
+		}
+	}
+	if r.RecommendedUpgrade != "" {
+		fmt.Fprintf(&b, "; %s", r.RecommendedUpgrade)
+	}
+	return b.String()
+}
+
+// CheckCompatibility compares the SDK with the mockforge CLI on PATH: its
+// version and the serve flags the SDK passes. Use the MockServer method of
+// the same name to also check a running server's admin API.
+func CheckCompatibility() (*CompatibilityReport, error) {
+	report := &CompatibilityReport{SDKVersion: Version}
+
+	out, err := exec.Command("mockforge", "--version").Output()
+	if err != nil {
+		report.recommend()
+		return report, nil
+	}
+	help, err := exec.Command("mockforge", "serve", "--help").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mockforge serve flags: %w", err)
+	}
+	report.checkCLI(string(out), string(help))
+	report.recommend()
+	return report, nil
+}
+
+// CheckCompatibility checks the CLI like the package-level CheckCompatibility
+// and additionally the running server's capabilities and admin endpoints
+func (m *MockServer) CheckCompatibility() (*CompatibilityReport, error) {
+	report, err := CheckCompatibility()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkServer(report); err != nil {
+		return nil, err
+	}
+	report.recommend()
+	return report, nil
+}
+
+// cliVersionPattern extracts the version from "mockforge 0.3.209"
+var cliVersionPattern = regexp.MustCompile(`\d+\.\d+\.\d+\S*`)
+
+// checkCLI fills in the CLI version and flag findings from the output of
+// mockforge --version and mockforge serve --help
+func (r *CompatibilityReport) checkCLI(version, help string) {
+	r.CLIVersion = cliVersionPattern.FindString(version)
+
+	for _, flag := range sdkFlags {
+		pattern := regexp.MustCompile(`(^|[\s,])` + regexp.QuoteMeta(flag) + `([\s=<,]|$)`)
+		found := false
+		for _, line := range strings.Split(help, "\n") {
+			if !pattern.MatchString(line) {
+				continue
+			}
+			found = true
+			if strings.Contains(strings.ToLower(line), "deprecated") {
+				r.DeprecatedFlags = append(r.DeprecatedFlags, flag)
+			}
+			break
+		}
+		if !found {
+			r.UnsupportedFlags = append(r.UnsupportedFlags, flag)
+		}
+	}
+}
+
+// checkServer fills in the server's capabilities and probes the admin
+// endpoints the SDK calls
+func (m *MockServer) checkServer(report *CompatibilityReport) error {
+	var capabilities struct {
+		Features []string `json:"features"`
+		Version  string   `json:"version"`
+	}
+	if err := m.adminJSON("get capabilities", http.MethodGet, "/__mockforge/api/capabilities", nil, &capabilities); err != nil {
+		return err
+	}
+	report.ServerVersion = capabilities.Version
+	report.Features = capabilities.Features
+
+	features := make(map[string]bool, len(capabilities.Features))
+	for _, feature := range capabilities.Features {
+		features[feature] = true
+	}
+	for _, feature := range sdkFeatures {
+		if !features[feature] {
+			report.MissingFeatures = append(report.MissingFeatures, feature)
+		}
+	}
+
+	for _, endpoint := range sdkEndpoints {
+		url, err := m.adminURL(endpoint)
+		if err != nil {
+			return NewAdminAPIError("probe endpoint", err.Error(), err)
+		}
+		resp, err := http.Get(url)
+		if err != nil {
+			return NewAdminAPIError("probe endpoint", "request failed", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			report.MissingEndpoints = append(report.MissingEndpoints, endpoint)
+		}
+	}
+	return nil
+}
+
+// recommend sets the upgrade advice from the findings so far
+func (r *CompatibilityReport) recommend() {
+	switch {
+	case r.CLIVersion == "":
+		r.RecommendedUpgrade = "install the mockforge CLI " + Version
+	case compareVersions(r.CLIVersion, MinimumCLIVersion) < 0:
+		r.RecommendedUpgrade = fmt.Sprintf("upgrade the mockforge CLI from %s to %s (minimum %s)", r.CLIVersion, Version, MinimumCLIVersion)
+	case len(r.MissingFeatures) > 0 || len(r.MissingEndpoints) > 0 || len(r.UnsupportedFlags) > 0:
+		r.RecommendedUpgrade = fmt.Sprintf("upgrade the mockforge CLI from %s to %s", r.CLIVersion, Version)
+	case r.ServerVersion != "" && r.ServerVersion != r.CLIVersion:
+		r.RecommendedUpgrade = fmt.Sprintf("the server runs %s but the CLI on PATH is %s", r.ServerVersion, r.CLIVersion)
+	case len(r.DeprecatedFlags) > 0:
+		r.RecommendedUpgrade = "upgrade the SDK, it still passes deprecated flags"
+	default:
+		r.RecommendedUpgrade = ""
+	}
+}
+
+// compareVersions compares dotted numeric versions, ignoring any
+// pre-release suffix, and returns -1, 0, or 1
+func compareVersions(a, b string) int {
+	as, bs := versionParts(a), versionParts(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts splits "0.3.209-rc1" into [0 3 209]
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, _ := strconv.Atoi(part)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// orNone returns s, or "none" if it is empty
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCheckCLI(t *testing.T) {
+	help := `Usage: mockforge serve [OPTIONS]
+
+Options:
+      --config <CONFIG>          Configuration file path
+      --spec <SPEC>              OpenAPI spec file
+      --http-port <HTTP_PORT>    HTTP server port
+      --admin                    Enable admin UI (deprecated: use --admin-port)
+`
+	report := &CompatibilityReport{SDKVersion: Version}
+	report.checkCLI("mockforge 0.2.7\n", help)
+	report.recommend()
+
+	if report.CLIVersion != "0.2.7" {
+		t.Errorf("Expected CLI version 0.2.7, got %q", report.CLIVersion)
+	}
+	if !reflect.DeepEqual(report.DeprecatedFlags, []string{"--admin"}) {
+		t.Errorf("Expected --admin to be deprecated, got %v", report.DeprecatedFlags)
+	}
+	if !reflect.DeepEqual(report.UnsupportedFlags, []string{"--admin-port"}) {
+		t.Errorf("Expected --admin-port to be unsupported, got %v", report.UnsupportedFlags)
+	}
+	if report.Compatible() || report.RecommendedUpgrade == "" {
+		t.Errorf("Expected an incompatible report with upgrade advice, got %s", report)
+	}
+}
+
+func TestCheckServer(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/__mockforge/api/capabilities":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"features": []string{"core", "management", "mocks", "proxy"},
+				"version":  "0.3.100",
+			})
+		case "/__mockforge/api/mocks/bulk":
+			http.NotFound(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	report := &CompatibilityReport{SDKVersion: Version, CLIVersion: "0.3.100"}
+	if err := server.checkServer(report); err != nil {
+		t.Fatalf("Failed to check server: %v", err)
+	}
+	report.recommend()
+
+	if report.ServerVersion != "0.3.100" {
+		t.Errorf("Expected server version 0.3.100, got %q", report.ServerVersion)
+	}
+	if !reflect.DeepEqual(report.MissingFeatures, []string{"chaos"}) {
+		t.Errorf("Expected chaos to be missing, got %v", report.MissingFeatures)
+	}
+	if !reflect.DeepEqual(report.MissingEndpoints, []string{"/__mockforge/api/mocks/bulk"}) {
+		t.Errorf("Expected the bulk endpoint to be missing, got %v", report.MissingEndpoints)
+	}
+	if report.Compatible() {
+		t.Errorf("Expected an incompatible report, got %s", report)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.3.209", "0.3.209", 0},
+		{"0.3.10", "0.3.9", 1},
+		{"v0.2.0", "0.3.0", -1},
+		{"1.0.0-rc1", "1.0", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("Expected compareVersions(%q, %q) = %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package mockforge
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// VerifyMaxConcurrency verifies that at most max requests matching pattern
+// were in flight at once, e.g. to check that a client honors its connection
+// pool or semaphore limit. The server logs each request when its response
+// completes, so a request is taken to be in flight from its timestamp minus
+// its response time until its timestamp, to millisecond precision.
+//
+// The result's Count is the peak number of overlapping requests and Matches
+// holds the requests in flight at the peak.
+func (m *MockServer) VerifyMaxConcurrency(pattern VerificationRequest, max int) (*VerificationResult, error) {
+	if max < 0 {
+		return nil, NewInvalidConfigError("max concurrency must not be negative", map[string]interface{}{"max": max})
+	}
+
+	all, err := m.verify(pattern, AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	logged, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	peak, inFlight := peakConcurrency(logged)
+	result := &VerificationResult{
+		Matched:  peak <= max,
+		Count:    peak,
+		Expected: AtMost(max),
+		Matches:  make([]map[string]interface{}, 0, len(inFlight)),
+	}
+	for _, i := range inFlight {
+		result.Matches = append(result.Matches, all.Matches[i])
+	}
+	if !result.Matched {
+		message := fmt.Sprintf("expected at most %d concurrent %s request(s), got %d", max, describePattern(pattern), peak)
+		result.ErrorMessage = &message
+	}
+	m.recordVerification(fmt.Sprintf("VerifyMaxConcurrency %s %d", describePattern(pattern), max), result)
+	return result, nil
+}
+
+// concurrencyEvent is a request starting or finishing
+type concurrencyEvent struct {
+	at    time.Time
+	start bool
+	index int
+}
+
+// peakConcurrency returns the largest number of requests in flight at once
+// and the indexes of those requests. Requests that finish exactly when
+// another starts do not overlap.
+func peakConcurrency(requests []LoggedRequest) (int, []int) {
+	events := make([]concurrencyEvent, 0, 2*len(requests))
+	for i, request := range requests {
+		started := request.Timestamp.Add(-time.Duration(request.ResponseTimeMs) * time.Millisecond)
+		events = append(events, concurrencyEvent{at: started, start: true, index: i}, concurrencyEvent{at: request.Timestamp, index: i})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].at.Equal(events[j].at) {
+			return events[i].at.Before(events[j].at)
+		}
+		return !events[i].start && events[j].start
+	})
+
+	inFlight := make(map[int]bool)
+	var peak []int
+	for _, event := range events {
+		if !event.start {
+			delete(inFlight, event.index)
+			continue
+		}
+		inFlight[event.index] = true
+		if len(inFlight) > len(peak) {
+			peak = peak[:0]
+			for index := range inFlight {
+				peak = append(peak, index)
+			}
+			sort.Ints(peak)
+		}
+	}
+	return len(peak), peak
+}
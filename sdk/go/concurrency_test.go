@@ -0,0 +1,34 @@
+package mockforge
+
+import "testing"
+
+func TestVerifyMaxConcurrency(t *testing.T) {
+	// Timestamps are when responses completed: /a runs 0-100ms, /b 50-150ms,
+	// /c 80-120ms, and /d starts exactly when /b finishes
+	server := newJournalTestServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00.100Z", "response_time_ms": 100, "method": "GET", "path": "/a"},
+		{"id": "2", "timestamp": "2024-01-01T00:00:00.150Z", "response_time_ms": 100, "method": "GET", "path": "/b"},
+		{"id": "3", "timestamp": "2024-01-01T00:00:00.120Z", "response_time_ms": 40, "method": "GET", "path": "/c"},
+		{"id": "4", "timestamp": "2024-01-01T00:00:00.200Z", "response_time_ms": 50, "method": "GET", "path": "/d"},
+	})
+
+	result, err := server.VerifyMaxConcurrency(VerificationRequest{Method: "GET"}, 2)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Matched || result.Count != 3 || len(result.Matches) != 3 {
+		t.Errorf("Expected a peak of 3 concurrent requests, got %+v", result)
+	}
+	if result.ErrorMessage == nil || *result.ErrorMessage != "expected at most 2 concurrent GET * request(s), got 3" {
+		t.Errorf("Expected a failure message, got %v", result.ErrorMessage)
+	}
+
+	result, err = server.VerifyMaxConcurrency(VerificationRequest{Method: "GET"}, 3)
+	if err != nil || !result.Matched {
+		t.Errorf("Expected a limit of 3 to hold, got %+v (%v)", result, err)
+	}
+
+	if _, err := server.VerifyMaxConcurrency(VerificationRequest{}, -1); err == nil {
+		t.Error("Expected an error for a negative limit")
+	}
+}
@@ -0,0 +1,50 @@
+package mockforge
+
+import (
+	"strconv"
+	"time"
+)
+
+// ConnectionConfig tunes how the mock server's HTTP listener treats client
+// connections, so clients can be tested against restrictive upstreams. The
+// settings are applied where the server reads and keeps connections, ahead
+// of any mock, and only on plain HTTP listeners. Zero values keep the server
+// defaults.
+type ConnectionConfig struct {
+	// HeaderReadTimeout bounds reading a request head, however slowly its
+	// bytes trickle in; the connection is closed when it runs out. It also
+	// bounds how long a kept-alive connection may wait for its next request
+	HeaderReadTimeout time.Duration
+	// MaxHeaderBytes caps the size of a request head; larger heads get 431.
+	// The server cannot go below 8 KiB and raises smaller values to that
+	MaxHeaderBytes int
+	// IdleTimeout closes connections with no request in flight and no
+	// traffic for this long
+	IdleTimeout time.Duration
+	// MaxConnections caps connections open at once; further connections
+	// wait in the listen backlog until one closes
+	MaxConnections int
+	// DisableKeepAlive closes every connection after one response
+	DisableKeepAlive bool
+}
+
+// env returns the server environment variables applying the configuration
+func (c ConnectionConfig) env() []string {
+	var env []string
+	if c.HeaderReadTimeout > 0 {
+		env = append(env, "MOCKFORGE_HTTP_HEADER_READ_TIMEOUT_MS="+strconv.FormatInt(c.HeaderReadTimeout.Milliseconds(), 10))
+	}
+	if c.MaxHeaderBytes > 0 {
+		env = append(env, "MOCKFORGE_HTTP_MAX_HEADER_BYTES="+strconv.Itoa(c.MaxHeaderBytes))
+	}
+	if c.IdleTimeout > 0 {
+		env = append(env, "MOCKFORGE_HTTP_IDLE_TIMEOUT_MS="+strconv.FormatInt(c.IdleTimeout.Milliseconds(), 10))
+	}
+	if c.MaxConnections > 0 {
+		env = append(env, "MOCKFORGE_HTTP_MAX_CONNECTIONS="+strconv.Itoa(c.MaxConnections))
+	}
+	if c.DisableKeepAlive {
+		env = append(env, "MOCKFORGE_HTTP_KEEPALIVE=0")
+	}
+	return env
+}
@@ -0,0 +1,30 @@
+package mockforge
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConnectionConfigEnv(t *testing.T) {
+	config := ConnectionConfig{
+		HeaderReadTimeout: 2 * time.Second,
+		MaxHeaderBytes:    16384,
+		MaxConnections:    4,
+		DisableKeepAlive:  true,
+	}
+
+	expected := []string{
+		"MOCKFORGE_HTTP_HEADER_READ_TIMEOUT_MS=2000",
+		"MOCKFORGE_HTTP_MAX_HEADER_BYTES=16384",
+		"MOCKFORGE_HTTP_MAX_CONNECTIONS=4",
+		"MOCKFORGE_HTTP_KEEPALIVE=0",
+	}
+	if env := config.env(); !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+
+	if env := (ConnectionConfig{}).env(); len(env) != 0 {
+		t.Errorf("Expected no overrides for zero config, got %v", env)
+	}
+}
@@ -0,0 +1,56 @@
+package mockforge
+
+import (
+	"net/http"
+	"time"
+)
+
+// CookieOptions are the attributes of a cookie set by a stub
+type CookieOptions struct {
+	Path   string
+	Domain string
+	// Expires sets an absolute expiry; MaxAge a relative one in whole
+	// seconds. A negative MaxAge deletes the cookie.
+	Expires  time.Time
+	MaxAge   time.Duration
+	Secure   bool
+	HTTPOnly bool
+	SameSite http.SameSite
+}
+
+// setCookieHeader formats a Set-Cookie header value
+func setCookieHeader(name, value string, opts CookieOptions) string {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		Expires:  opts.Expires,
+		Secure:   opts.Secure,
+		HttpOnly: opts.HTTPOnly,
+		SameSite: opts.SameSite,
+	}
+	switch {
+	case opts.MaxAge < 0:
+		cookie.MaxAge = -1
+	case opts.MaxAge > 0:
+		cookie.MaxAge = int(opts.MaxAge / time.Second)
+	}
+	return cookie.String()
+}
+
+// SetCookie adds a Set-Cookie header to the response. Several cookies may be
+// set on one stub.
+func (b *StubBuilder) SetCookie(name, value string, opts CookieOptions) *StubBuilder {
+	b.cookies = append(b.cookies, setCookieHeader(name, value, opts))
+	return b
+}
+
+// WhenCookie matches only requests sending the cookie with exactly value
+func (b *StubBuilder) WhenCookie(name, value string) *StubBuilder {
+	if b.match.Cookies == nil {
+		b.match.Cookies = make(map[string]string)
+	}
+	b.match.Cookies[name] = value
+	return b
+}
@@ -0,0 +1,157 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OperationCoverage is how often the requests exercised one spec operation
+type OperationCoverage struct {
+	OperationID string
+	Method      string
+	// Path is the spec's path template, e.g. /orders/{id}
+	Path string
+	Hits int
+}
+
+// Covered reports whether the operation received at least one request
+func (o OperationCoverage) Covered() bool {
+	return o.Hits > 0
+}
+
+// CoverageReport compares a spec's operations against the request journal
+type CoverageReport struct {
+	// Operations lists every operation, ordered by path then method
+	Operations []OperationCoverage
+	Covered    int
+	Total      int
+}
+
+// Ratio returns the fraction of operations exercised, from 0 to 1. A spec
+// without operations is fully covered.
+func (r CoverageReport) Ratio() float64 {
+	if r.Total == 0 {
+		return 1
+	}
+	return float64(r.Covered) / float64(r.Total)
+}
+
+// Percent returns the percentage of operations exercised
+func (r CoverageReport) Percent() float64 {
+	return r.Ratio() * 100
+}
+
+// Missed returns the operations no request exercised
+func (r CoverageReport) Missed() []OperationCoverage {
+	var missed []OperationCoverage
+	for _, op := range r.Operations {
+		if !op.Covered() {
+			missed = append(missed, op)
+		}
+	}
+	return missed
+}
+
+// String summarizes the report, e.g. "12/15 operations (80.0%)"
+func (r CoverageReport) String() string {
+	return fmt.Sprintf("%d/%d operations (%.1f%%)", r.Covered, r.Total, r.Percent())
+}
+
+// SpecCoverage reports which operations of the server's OpenAPI spec the
+// recorded requests exercised. A request counts towards the operation whose
+// method and path template match it, preferring literal segments over
+// parameters, so GET /users/me counts for /users/me rather than
+// /users/{id}. Paths may carry the spec's base path.
+func (m *MockServer) SpecCoverage() (CoverageReport, error) {
+	if m.config.OpenAPISpec == "" {
+		return CoverageReport{}, NewInvalidConfigError("SpecCoverage requires an OpenAPI spec", nil)
+	}
+	doc, err := loadOpenAPIDocument(m.config.OpenAPISpec)
+	if err != nil {
+		return CoverageReport{}, err
+	}
+
+	all, err := m.verify(VerificationRequest{}, AtLeast(0))
+	if err != nil {
+		return CoverageReport{}, err
+	}
+	requests, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return CoverageReport{}, err
+	}
+
+	report := CoverageReport{}
+	for _, op := range doc.operations() {
+		id, _ := op.Node["operationId"].(string)
+		report.Operations = append(report.Operations, OperationCoverage{OperationID: id, Method: op.Method, Path: op.Path})
+	}
+	basePath := doc.basePath()
+	for _, request := range requests {
+		path := request.Path
+		if basePath != "" && strings.HasPrefix(path, basePath+"/") {
+			path = strings.TrimPrefix(path, basePath)
+		}
+		best, bestParams := -1, 0
+		for i, op := range report.Operations {
+			if !strings.EqualFold(op.Method, request.Method) {
+				continue
+			}
+			if params, ok := matchPathTemplate(op.Path, path); ok && (best < 0 || params < bestParams) {
+				best, bestParams = i, params
+			}
+		}
+		if best >= 0 {
+			report.Operations[best].Hits++
+		}
+	}
+
+	report.Total = len(report.Operations)
+	for _, op := range report.Operations {
+		if op.Covered() {
+			report.Covered++
+		}
+	}
+	return report, nil
+}
+
+// basePath returns the path prefix of the spec's first server, or of a
+// Swagger 2 basePath, without a trailing slash
+func (d *openAPIDocument) basePath() string {
+	var base string
+	if servers, ok := d.root["servers"].([]interface{}); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]interface{}); ok {
+			raw, _ := server["url"].(string)
+			if parsed, err := url.Parse(raw); err == nil {
+				base = parsed.Path
+			}
+		}
+	} else {
+		base, _ = d.root["basePath"].(string)
+	}
+	return strings.TrimSuffix(base, "/")
+}
+
+// matchPathTemplate reports whether path matches an OpenAPI path template
+// and how many parameter segments the match used
+func matchPathTemplate(template, path string) (int, bool) {
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return 0, false
+	}
+	params := 0
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return 0, false
+			}
+			params++
+			continue
+		}
+		if segment != pathSegments[i] {
+			return 0, false
+		}
+	}
+	return params, true
+}
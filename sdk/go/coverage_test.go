@@ -0,0 +1,44 @@
+package mockforge
+
+import "testing"
+
+func TestSpecCoverage(t *testing.T) {
+	server := newJournalTestServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/pets/1"},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/pets/2"},
+		{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "GET", "path": "/pets"},
+		{"id": "4", "timestamp": "2024-01-01T00:00:03Z", "method": "GET", "path": "/health"},
+	})
+	server.config.OpenAPISpec = "testdata/petstore.yaml"
+
+	report, err := server.SpecCoverage()
+	if err != nil {
+		t.Fatalf("Failed to get coverage: %v", err)
+	}
+	if report.Total != 3 || report.Covered != 2 || report.String() != "2/3 operations (66.7%)" {
+		t.Errorf("Expected 2 of 3 operations covered, got %s", report)
+	}
+	missed := report.Missed()
+	if len(missed) != 1 || missed[0].Method != "POST" || missed[0].Path != "/pets" {
+		t.Errorf("Expected POST /pets to be missed, got %+v", missed)
+	}
+	for _, op := range report.Operations {
+		if op.Path == "/pets/{id}" && op.Hits != 2 {
+			t.Errorf("Expected 2 hits on /pets/{id}, got %d", op.Hits)
+		}
+	}
+
+	server.config.OpenAPISpec = ""
+	if _, err := server.SpecCoverage(); err == nil {
+		t.Error("Expected an error without a spec")
+	}
+}
+
+func TestMatchPathTemplate(t *testing.T) {
+	if params, ok := matchPathTemplate("/users/{id}/posts", "/users/7/posts"); !ok || params != 1 {
+		t.Errorf("Expected a match with 1 parameter, got %d, %v", params, ok)
+	}
+	if _, ok := matchPathTemplate("/users/{id}", "/users/7/posts"); ok {
+		t.Error("Expected no match for a longer path")
+	}
+}
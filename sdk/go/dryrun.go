@@ -0,0 +1,69 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DryRunChange is a destructive operation skipped because dry-run mode is on
+type DryRunChange struct {
+	// Operation names what was requested, e.g. "clear stubs"
+	Operation string
+	// Targets lists what the operation would have changed, e.g. mock IDs
+	Targets []string
+}
+
+// WithDryRun toggles dry-run mode. While enabled, destructive operations
+// (ClearStubs, ResetScenarios, fixture deletion, and imports) record what
+// they would change in DryRunChanges instead of applying it, protecting
+// shared mock servers from misconfigured test runs.
+func (m *MockServer) WithDryRun(enabled bool) *MockServer {
+	m.mu.Lock()
+	m.dryRun = enabled
+	m.mu.Unlock()
+	return m
+}
+
+// DryRunChanges returns the operations skipped in dry-run mode, oldest first
+func (m *MockServer) DryRunChanges() []DryRunChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]DryRunChange(nil), m.dryRunChanges...)
+}
+
+// isDryRun reports whether dry-run mode is enabled
+func (m *MockServer) isDryRun() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dryRun
+}
+
+// recordDryRun records a skipped destructive operation
+func (m *MockServer) recordDryRun(operation string, targets []string) {
+	m.mu.Lock()
+	m.dryRunChanges = append(m.dryRunChanges, DryRunChange{Operation: operation, Targets: targets})
+	m.mu.Unlock()
+}
+
+// stubTargets lists the stubs ClearStubs would remove: the server's mock IDs
+// when the admin API is reachable, otherwise the locally registered stubs
+func (m *MockServer) stubTargets() []string {
+	var result struct {
+		Mocks []struct {
+			ID string `json:"id"`
+		} `json:"mocks"`
+	}
+	if err := m.adminJSON("list mocks", http.MethodGet, "/__mockforge/api/mocks", nil, &result); err == nil {
+		targets := make([]string, len(result.Mocks))
+		for i, mock := range result.Mocks {
+			targets[i] = mock.ID
+		}
+		return targets
+	}
+
+	targets := make([]string, len(m.stubs))
+	for i, stub := range m.stubs {
+		targets[i] = fmt.Sprintf("%s %s", stub.Method, stub.Path)
+	}
+	return targets
+}
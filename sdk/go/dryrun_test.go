@@ -0,0 +1,37 @@
+package mockforge
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDryRunClearStubs(t *testing.T) {
+	deletes := 0
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"mocks":[{"id":"a"},{"id":"b"}]}`))
+		case http.MethodDelete:
+			deletes++
+		}
+	}))
+	server.WithDryRun(true)
+
+	if err := server.ClearStubs(); err != nil {
+		t.Fatalf("Failed to clear stubs: %v", err)
+	}
+
+	if deletes != 0 {
+		t.Errorf("Expected no deletes in dry-run mode, got %d", deletes)
+	}
+	changes := server.DryRunChanges()
+	if len(changes) != 1 || changes[0].Operation != "clear stubs" || len(changes[0].Targets) != 2 {
+		t.Errorf("Expected clear stubs change for 2 mocks, got %+v", changes)
+	}
+
+	server.WithDryRun(false)
+	server.ClearStubs()
+	if deletes != 2 {
+		t.Errorf("Expected 2 deletes after disabling dry-run, got %d", deletes)
+	}
+}
@@ -0,0 +1,124 @@
+package mockforge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DuplicateOption adjusts what makes two requests duplicates
+type DuplicateOption func(*duplicateConfig)
+
+// duplicateConfig is the request fingerprint configuration
+type duplicateConfig struct {
+	ignoredHeaders map[string]bool
+	ignoredQuery   map[string]bool
+}
+
+// IgnoreHeader excludes a header (case-insensitive) from the comparison, e.g.
+// an attempt counter or request ID that differs between retries
+func IgnoreHeader(name string) DuplicateOption {
+	return func(c *duplicateConfig) {
+		c.ignoredHeaders[strings.ToLower(name)] = true
+	}
+}
+
+// IgnoreQueryParam excludes a query parameter from the comparison
+func IgnoreQueryParam(name string) DuplicateOption {
+	return func(c *duplicateConfig) {
+		c.ignoredQuery[name] = true
+	}
+}
+
+// VerifyNoDuplicates verifies that no two requests matching pattern with the
+// same method, path, query parameters, and headers arrived within window of
+// each other. It is meant for asserting that hedged or retried requests are
+// deduplicated by the client. Request bodies are not compared.
+//
+// The result's Count is the number of duplicates and Matches holds them.
+func (m *MockServer) VerifyNoDuplicates(pattern VerificationRequest, window time.Duration, opts ...DuplicateOption) (*VerificationResult, error) {
+	config := &duplicateConfig{
+		ignoredHeaders: make(map[string]bool),
+		ignoredQuery:   make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	all, err := m.verify(pattern, AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	requests, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	byFingerprint := make(map[string][]int)
+	for i, req := range requests {
+		key := config.fingerprint(req)
+		byFingerprint[key] = append(byFingerprint[key], i)
+	}
+
+	var duplicates []int
+	for _, indices := range byFingerprint {
+		sort.Slice(indices, func(a, b int) bool {
+			return requests[indices[a]].Timestamp.Before(requests[indices[b]].Timestamp)
+		})
+		for j := 1; j < len(indices); j++ {
+			previous, current := requests[indices[j-1]], requests[indices[j]]
+			if current.Timestamp.Sub(previous.Timestamp) <= window {
+				duplicates = append(duplicates, indices[j])
+			}
+		}
+	}
+	sort.Ints(duplicates)
+
+	result := &VerificationResult{
+		Matched:  len(duplicates) == 0,
+		Count:    len(duplicates),
+		Expected: Never(),
+		Matches:  make([]map[string]interface{}, 0, len(duplicates)),
+	}
+	for _, i := range duplicates {
+		result.Matches = append(result.Matches, all.Matches[i])
+	}
+	if !result.Matched {
+		first := requests[duplicates[0]]
+		message := fmt.Sprintf("found %d duplicate request(s) within %s, first: %s %s at %s",
+			len(duplicates), window, first.Method, first.Path, first.Timestamp.Format(time.RFC3339Nano))
+		result.ErrorMessage = &message
+	}
+
+	m.recordVerification(fmt.Sprintf("VerifyNoDuplicates %s within %s", describePattern(pattern), window), result)
+	return result, nil
+}
+
+// fingerprint identifies requests that are duplicates of each other
+func (c *duplicateConfig) fingerprint(req LoggedRequest) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(req.Method))
+	b.WriteString(" ")
+	b.WriteString(req.Path)
+
+	query := make([]string, 0, len(req.QueryParams))
+	for k, v := range req.QueryParams {
+		if !c.ignoredQuery[k] {
+			query = append(query, k+"="+v)
+		}
+	}
+	sort.Strings(query)
+	b.WriteString("?" + strings.Join(query, "&"))
+
+	headers := make([]string, 0, len(req.Headers))
+	for k, v := range req.Headers {
+		if !c.ignoredHeaders[strings.ToLower(k)] {
+			headers = append(headers, strings.ToLower(k)+":"+v)
+		}
+	}
+	sort.Strings(headers)
+	b.WriteString("\n" + strings.Join(headers, "\n"))
+
+	return b.String()
+}
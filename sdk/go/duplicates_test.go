@@ -0,0 +1,43 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVerifyNoDuplicates(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   3,
+			"matches": []map[string]interface{}{
+				{"timestamp": "2024-01-01T00:00:00.000Z", "method": "POST", "path": "/orders", "headers": map[string]string{"X-Attempt": "1"}},
+				{"timestamp": "2024-01-01T00:00:00.050Z", "method": "POST", "path": "/orders", "headers": map[string]string{"X-Attempt": "2"}},
+				{"timestamp": "2024-01-01T00:00:01.000Z", "method": "POST", "path": "/orders", "headers": map[string]string{"X-Attempt": "3"}},
+			},
+		})
+	}))
+	pattern := VerificationRequest{Method: "POST", Path: "/orders"}
+
+	t.Run("headers distinguish requests by default", func(t *testing.T) {
+		result, err := server.VerifyNoDuplicates(pattern, 100*time.Millisecond)
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		if !result.Matched {
+			t.Errorf("Expected no duplicates, got %d", result.Count)
+		}
+	})
+
+	t.Run("ignored headers expose hedged requests", func(t *testing.T) {
+		result, err := server.VerifyNoDuplicates(pattern, 100*time.Millisecond, IgnoreHeader("x-attempt"))
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		if result.Matched || result.Count != 1 {
+			t.Errorf("Expected 1 duplicate, got %d", result.Count)
+		}
+	})
+}
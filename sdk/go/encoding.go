@@ -0,0 +1,26 @@
+package mockforge
+
+import "fmt"
+
+// ContentEncoding is a compression the server applies to a stub's body
+// before serving it
+type ContentEncoding string
+
+const (
+	// EncodingGzip compresses the body with gzip
+	EncodingGzip ContentEncoding = "gzip"
+	// EncodingDeflate compresses the body with zlib-wrapped deflate
+	EncodingDeflate ContentEncoding = "deflate"
+	// EncodingBrotli compresses the body with Brotli
+	EncodingBrotli ContentEncoding = "br"
+)
+
+// validate checks the encoding is one the server can produce
+func (e ContentEncoding) validate() error {
+	switch e {
+	case "", EncodingGzip, EncodingDeflate, EncodingBrotli:
+		return nil
+	default:
+		return NewInvalidConfigError(fmt.Sprintf("unknown content encoding %q", e), map[string]interface{}{"encoding": string(e)})
+	}
+}
@@ -48,6 +48,18 @@ func NewCLINotFoundError(cause error) *MockServerError {
 	}
 }
 
+// NewProtocNotFoundError creates an error for protoc not found
+func NewProtocNotFoundError(cause error) *MockServerError {
+	return &MockServerError{
+		Code:    ErrorCodeCLINotFound,
+		Message: "protoc not found. Install it from https://github.com/protocolbuffers/protobuf/releases",
+		Cause:   cause,
+		Details: map[string]interface{}{
+			"hint": "Ensure protoc is in your PATH or set PROTOC",
+		},
+	}
+}
+
 // NewServerStartFailedError creates an error for server start failure
 func NewServerStartFailedError(message string, cause error) *MockServerError {
 	return &MockServerError{
@@ -0,0 +1,264 @@
+package mockforge
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// When only matches requests for which expr evaluates to true. The server
+// evaluates expr against each request; the SDK checks its syntax when the
+// stub is registered.
+//
+// Expressions compare request fields with literals using == != < <= > >=
+// and =~ (regular expression match), combined with && || ! and
+// parentheses. Fields are request.method, request.path, request.body with
+// .field and [index] access into the JSON body, and request.header,
+// request.query, and request.cookie indexed by name:
+//
+//	request.body.amount > 1000 && request.header['X-Region'] == 'EU'
+//	request.query["page"] =~ '^[0-9]+$' || !(request.body.items[0].sku == null)
+func (b *StubBuilder) When(expr string) *StubBuilder {
+	b.match.Expression = expr
+	return b
+}
+
+// exprRoots are the request fields expressions can refer to, and whether
+// they must be indexed by name
+var exprRoots = map[string]bool{
+	"method": false,
+	"path":   false,
+	"body":   false,
+	"header": true,
+	"query":  true,
+	"cookie": true,
+}
+
+// exprToken is a lexical token of an expression
+type exprToken struct {
+	kind  string // ident, number, string, op, or eof
+	text  string
+	index int
+}
+
+// exprParser checks expressions by recursive descent
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+// validateExpression checks expr is a well-formed boolean expression
+func validateExpression(expr string) error {
+	tokens, err := lexExpression(expr)
+	if err == nil {
+		p := &exprParser{tokens: tokens}
+		err = p.parseOr()
+		if err == nil && p.peek().kind != "eof" {
+			err = p.errorf("unexpected %q", p.peek().text)
+		}
+	}
+	if err != nil {
+		return NewInvalidConfigError(fmt.Sprintf("invalid match expression: %v", err), map[string]interface{}{"expression": expr})
+	}
+	return nil
+}
+
+// lexExpression splits expr into tokens
+func lexExpression(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '-') {
+				i++
+			}
+			tokens = append(tokens, exprToken{"ident", string(runes[start:i]), start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{"number", string(runes[start:i]), start})
+		case r == '\'' || r == '"':
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{"string", string(runes[start:i]), start})
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "<", ">", "!", "(", ")", "[", "]", "."} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", r, start)
+			}
+			i += len(op)
+			tokens = append(tokens, exprToken{"op", op, start})
+		}
+	}
+	return append(tokens, exprToken{"eof", "end of expression", len(runes)}), nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	token := p.tokens[p.pos]
+	if token.kind != "eof" {
+		p.pos++
+	}
+	return token
+}
+
+// accept consumes the next token if it is the operator op
+func (p *exprParser) accept(op string) bool {
+	if token := p.peek(); token.kind == "op" && token.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at %d", fmt.Sprintf(format, args...), p.peek().index)
+}
+
+// parseOr parses and ('||' and)*
+func (p *exprParser) parseOr() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.accept("||") {
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAnd parses not ('&&' not)*
+func (p *exprParser) parseAnd() error {
+	if err := p.parseNot(); err != nil {
+		return err
+	}
+	for p.accept("&&") {
+		if err := p.parseNot(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseNot parses '!' not | comparison
+func (p *exprParser) parseNot() error {
+	if p.accept("!") {
+		return p.parseNot()
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses operand (op operand)?
+func (p *exprParser) parseComparison() error {
+	if err := p.parseOperand(); err != nil {
+		return err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if p.accept(op) {
+			return p.parseOperand()
+		}
+	}
+	return nil
+}
+
+// parseOperand parses a literal, a request field, or a parenthesized
+// expression
+func (p *exprParser) parseOperand() error {
+	if p.accept("(") {
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		if !p.accept(")") {
+			return p.errorf("expected )")
+		}
+		return nil
+	}
+
+	token := p.peek()
+	switch {
+	case token.kind == "number" || token.kind == "string":
+		p.next()
+		return nil
+	case token.kind == "ident" && (token.text == "true" || token.text == "false" || token.text == "null"):
+		p.next()
+		return nil
+	case token.kind == "ident" && token.text == "request":
+		p.next()
+		return p.parseField()
+	case token.kind == "ident":
+		return p.errorf("unknown identifier %q, fields start with request.", token.text)
+	}
+	return p.errorf("expected a value, got %q", token.text)
+}
+
+// parseField parses the accessors following request
+func (p *exprParser) parseField() error {
+	if !p.accept(".") {
+		return p.errorf("expected . after request")
+	}
+	root := p.next()
+	indexed, known := exprRoots[root.text]
+	if root.kind != "ident" || !known {
+		return fmt.Errorf("unknown request field %q at %d", root.text, root.index)
+	}
+	if indexed {
+		if !p.accept("[") {
+			return p.errorf("request.%s must be indexed by name, e.g. request.%s['name']", root.text, root.text)
+		}
+		if p.next().kind != "string" {
+			return p.errorf("expected a quoted name")
+		}
+		if !p.accept("]") {
+			return p.errorf("expected ]")
+		}
+		return nil
+	}
+	if root.text != "body" {
+		return nil
+	}
+
+	for {
+		switch {
+		case p.accept("."):
+			if p.next().kind != "ident" {
+				return p.errorf("expected a field name")
+			}
+		case p.accept("["):
+			if key := p.next(); key.kind != "string" && key.kind != "number" {
+				return p.errorf("expected an index or quoted key")
+			}
+			if !p.accept("]") {
+				return p.errorf("expected ]")
+			}
+		default:
+			return nil
+		}
+	}
+}
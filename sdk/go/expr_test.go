@@ -0,0 +1,51 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateExpression(t *testing.T) {
+	valid := []string{
+		`request.body.amount > 1000 && request.header['X-Region'] == 'EU'`,
+		`request.query["page"] =~ '^[0-9]+$' || !(request.body.items[0].sku == null)`,
+		`request.method == "POST" && request.body["first name"] != -1.5`,
+		`request.cookie['session'] == 'abc' || request.path == '/health'`,
+	}
+	for _, expr := range valid {
+		if err := validateExpression(expr); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		`request.body.amount >`,
+		`request.header == 'EU'`,
+		`request.bdy.amount > 1`,
+		`amount > 1000`,
+		`(request.path == '/a'`,
+		`request.path == 'unterminated`,
+		`request.path == '/a' request.method == 'GET'`,
+		`request.path # 1`,
+	}
+	for _, expr := range invalid {
+		if err := validateExpression(expr); err == nil {
+			t.Errorf("Expected %q to be invalid", expr)
+		}
+	}
+
+	stub := NewStubBuilder("POST", "/payments").When(valid[0]).Build()
+	if stub.Match == nil || stub.Match.Expression != valid[0] {
+		t.Errorf("Expected expression matcher, got %+v", stub.Match)
+	}
+	// The server evaluates it as the mock's custom matcher
+	data, _ := json.Marshal(stub.mockConfig())
+	if !strings.Contains(string(data), `"custom_matcher":`) {
+		t.Errorf("Expected the expression sent as custom_matcher, got %s", data)
+	}
+	stub = NewStubBuilder("POST", "/payments").When(invalid[0]).Build()
+	if err := stub.validate(); err == nil {
+		t.Error("Expected stub with invalid expression to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Fallback is what the server does with requests no stub matches
+type Fallback struct {
+	// Strategy is not_found, respond, proxy, from_spec, or echo
+	Strategy string `json:"strategy"`
+
+	// response is the stub answering requests with the respond strategy
+	response *ResponseStub
+	// upstream is the backend requests are forwarded to with the proxy
+	// strategy
+	upstream string
+}
+
+// Fallback404 answers unmatched requests with 404 Not Found, even in shadow
+// mode
+var Fallback404 = Fallback{Strategy: "not_found"}
+
+// FallbackRespond answers unmatched requests with stub's status, headers,
+// body, and latency. Its method, path, and match criteria are ignored.
+func FallbackRespond(stub ResponseStub) Fallback {
+	return Fallback{Strategy: "respond", response: &stub}
+}
+
+// FallbackProxy forwards unmatched requests to upstream, appending the
+// request path and query. Unlike PassthroughUnmatched it can be limited to
+// route prefixes, and never records.
+func FallbackProxy(upstream string) Fallback {
+	return Fallback{Strategy: "proxy", upstream: upstream}
+}
+
+// FallbackFromSpec answers unmatched requests with the response the
+// server's OpenAPI spec generates for their operation, or 404 when the spec
+// has none. The server must be started with a spec.
+func FallbackFromSpec() Fallback {
+	return Fallback{Strategy: "from_spec"}
+}
+
+// FallbackEcho answers unmatched requests with a JSON description of the
+// request: its method, path, query, headers, and body
+func FallbackEcho() Fallback {
+	return Fallback{Strategy: "echo"}
+}
+
+// SetDefaultResponse answers every unmatched request with stub instead of
+// the built-in 404, e.g. the API's error envelope. It is shorthand for
+// WithFallback(FallbackRespond(stub)).
+func (m *MockServer) SetDefaultResponse(stub ResponseStub) error {
+	return m.WithFallback(FallbackRespond(stub))
+}
+
+// WithFallback sets the fallback for unmatched requests under each route
+// prefix, or for every route when no prefix is given. The most specific
+// prefix wins, and fallbacks can be changed while the server runs. To
+// forward unmatched requests to a real backend, use PassthroughUnmatched.
+//
+//	server.WithFallback(mockforge.Fallback404)
+//	server.WithFallback(mockforge.FallbackRespond(legacyError), "/api/legacy")
+//	server.WithFallback(mockforge.FallbackProxy("https://staging.example.com"), "/api/v2")
+func (m *MockServer) WithFallback(fallback Fallback, prefixes ...string) error {
+	switch fallback.Strategy {
+	case "not_found", "from_spec", "echo":
+	case "proxy":
+		if err := validateUpstream(fallback.upstream); err != nil {
+			return err
+		}
+	case "respond":
+		if fallback.response == nil {
+			return NewInvalidConfigError("respond fallback requires a response", nil)
+		}
+		if err := fallback.response.validate(); err != nil {
+			return err
+		}
+	default:
+		return NewInvalidConfigError(fmt.Sprintf("unknown fallback strategy %q", fallback.Strategy), map[string]interface{}{
+			"strategy": fallback.Strategy,
+		})
+	}
+
+	if len(prefixes) == 0 {
+		prefixes = []string{"/"}
+	}
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return NewInvalidConfigError("fallback prefix must start with /", map[string]interface{}{"prefix": prefix})
+		}
+	}
+
+	for _, prefix := range prefixes {
+		data := map[string]interface{}{"prefix": prefix, "strategy": fallback.Strategy}
+		if fallback.response != nil {
+			data["response"] = fallback.response.fallbackConfig()
+		}
+		if fallback.upstream != "" {
+			data["upstream_url"] = fallback.upstream
+		}
+		if err := m.adminJSON("set fallback", http.MethodPut, "/__mockforge/api/fallbacks", data, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fallbackConfig is the stub's mock config without the keys that select
+// requests, which a fallback does not use
+func (stub ResponseStub) fallbackConfig() map[string]interface{} {
+	config := stub.mockConfig()
+	for _, key := range []string{"id", "name", "method", "path", "path_match", "enabled", "request_match", "priority"} {
+		delete(config, key)
+	}
+	if _, ok := config["status_code"]; !ok {
+		config["status_code"] = stub.Status
+	}
+	return config
+}
@@ -0,0 +1,96 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestWithFallback(t *testing.T) {
+	var updates []map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/__mockforge/api/fallbacks" {
+			t.Errorf("Unexpected request to %s %s", r.Method, r.URL.Path)
+		}
+		var update map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&update)
+		updates = append(updates, update)
+		w.Write([]byte(`{}`))
+	}))
+
+	if err := server.WithFallback(Fallback404); err != nil {
+		t.Fatalf("Failed to set fallback: %v", err)
+	}
+	legacy := NewStubBuilder("", "").Status(410).Body("gone").Build()
+	if err := server.WithFallback(FallbackRespond(legacy), "/api/legacy", "/v1"); err != nil {
+		t.Fatalf("Failed to set respond fallback: %v", err)
+	}
+
+	if err := server.WithFallback(FallbackProxy("http://staging.test"), "/api/v2"); err != nil {
+		t.Fatalf("Failed to set proxy fallback: %v", err)
+	}
+	if err := server.WithFallback(FallbackFromSpec(), "/users"); err != nil {
+		t.Fatalf("Failed to set spec fallback: %v", err)
+	}
+	if err := server.WithFallback(FallbackEcho(), "/debug"); err != nil {
+		t.Fatalf("Failed to set echo fallback: %v", err)
+	}
+
+	if len(updates) != 6 {
+		t.Fatalf("Expected 6 updates, got %v", updates)
+	}
+	if updates[0]["prefix"] != "/" || updates[0]["strategy"] != "not_found" {
+		t.Errorf("Expected global 404 fallback, got %v", updates[0])
+	}
+	if response, _ := updates[2]["response"].(map[string]interface{}); updates[2]["prefix"] != "/v1" || response["status_code"] != float64(410) {
+		t.Errorf("Expected respond fallback for /v1, got %v", updates[2])
+	}
+	if updates[3]["strategy"] != "proxy" || updates[3]["upstream_url"] != "http://staging.test" {
+		t.Errorf("Expected proxy fallback with its upstream, got %v", updates[3])
+	}
+	if updates[4]["strategy"] != "from_spec" || updates[5]["strategy"] != "echo" || updates[5]["prefix"] != "/debug" {
+		t.Errorf("Expected spec and echo fallbacks, got %v %v", updates[4], updates[5])
+	}
+
+	t.Run("rejects invalid fallbacks", func(t *testing.T) {
+		for name, err := range map[string]error{
+			"unknown strategy":     server.WithFallback(Fallback{Strategy: "mirror"}),
+			"respond without stub": server.WithFallback(Fallback{Strategy: "respond"}),
+			"proxy without url":    server.WithFallback(Fallback{Strategy: "proxy"}),
+			"relative upstream":    server.WithFallback(FallbackProxy("staging.test")),
+			"relative prefix":      server.WithFallback(Fallback404, "api"),
+		} {
+			if err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+		if len(updates) != 6 {
+			t.Errorf("Expected no further updates, got %d", len(updates))
+		}
+	})
+}
+
+func TestSetDefaultResponse(t *testing.T) {
+	var update map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&update)
+		w.Write([]byte(`{}`))
+	}))
+
+	envelope := StubJSON("", "", map[string]interface{}{"error": map[string]string{"code": "not_found"}}).Status(404).Build()
+	if err := server.SetDefaultResponse(envelope); err != nil {
+		t.Fatalf("Failed to set default response: %v", err)
+	}
+
+	if update["prefix"] != "/" || update["strategy"] != "respond" {
+		t.Errorf("Expected global respond fallback, got %v", update)
+	}
+	response, _ := update["response"].(map[string]interface{})
+	if response["status_code"] != float64(404) || response["path"] != nil {
+		t.Errorf("Expected 404 response without request selectors, got %v", response)
+	}
+	body, _ := response["response"].(map[string]interface{})
+	if body["body"] == nil {
+		t.Errorf("Expected error envelope body, got %v", response)
+	}
+}
@@ -0,0 +1,32 @@
+package mockforge
+
+import "fmt"
+
+// Fault is a network-level failure a stub simulates instead of returning a
+// well-formed HTTP response. Faults are applied by the server's chaos
+// subsystem.
+type Fault string
+
+const (
+	// FaultConnectionReset closes the connection with a TCP reset before any
+	// response is written
+	FaultConnectionReset Fault = "connection_reset"
+	// FaultEmptyResponse closes the connection without writing a response
+	FaultEmptyResponse Fault = "empty_response"
+	// FaultMalformedChunk writes the status and headers followed by an invalid
+	// chunked-encoding frame
+	FaultMalformedChunk Fault = "malformed_chunk"
+	// FaultSlowTrickle writes the response a byte at a time, exercising
+	// client read timeouts
+	FaultSlowTrickle Fault = "slow_trickle"
+)
+
+// validate checks the fault is one the server understands
+func (f Fault) validate() error {
+	switch f {
+	case "", FaultConnectionReset, FaultEmptyResponse, FaultMalformedChunk, FaultSlowTrickle:
+		return nil
+	default:
+		return NewInvalidConfigError(fmt.Sprintf("unknown fault %q", f), map[string]interface{}{"fault": string(f)})
+	}
+}
@@ -0,0 +1,373 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Subgraph is a mocked GraphQL subgraph composed into the federation gateway
+type Subgraph struct {
+	// Name identifies the subgraph, e.g. "accounts"
+	Name string
+	// SDL is the subgraph schema. Its Query and Mutation fields are routed to
+	// this subgraph by the gateway.
+	SDL string
+	// Data holds the value each root field resolves to. Selection sets are
+	// applied to it, so it may contain more fields than a query asks for.
+	// Arguments are not interpreted.
+	Data map[string]interface{}
+}
+
+// SubgraphFault is failure behavior injected into one subgraph
+type SubgraphFault struct {
+	// Latency delays every response involving the subgraph
+	Latency time.Duration
+	// StatusCode makes the subgraph endpoint fail with this HTTP status
+	StatusCode int
+	// Message makes the subgraph return a GraphQL error instead of data
+	Message string
+}
+
+// federationSubgraph is a composed subgraph and its current fault
+type federationSubgraph struct {
+	Subgraph
+	fault    *SubgraphFault
+	requests int
+}
+
+// FederationMock is a federated GraphQL endpoint composed from mocked
+// subgraphs. Each subgraph is also served on its own endpoint (including the
+// _service { sdl } query) so router configurations can point at them.
+//
+// The gateway routes each root field to the subgraph that declares it and
+// merges the results, reporting faulty subgraphs the way Apollo-style gateways
+// do: the affected fields resolve to null with an error carrying the
+// subgraph's serviceName.
+type FederationMock struct {
+	sidecar *httpSidecar
+
+	mu        sync.Mutex
+	subgraphs map[string]*federationSubgraph
+	owners    map[string]string // "Query.field" to subgraph name
+}
+
+// Federation returns the GraphQL federation gateway, starting it on first use
+func (m *MockServer) Federation() (*FederationMock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.federation != nil {
+		return m.federation, nil
+	}
+
+	federation := &FederationMock{
+		subgraphs: make(map[string]*federationSubgraph),
+		owners:    make(map[string]string),
+	}
+
+	sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(federation.serveHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start federation gateway: %w", err)
+	}
+	federation.sidecar = sidecar
+
+	m.attached = append(m.attached, sidecar)
+	m.federation = federation
+
+	return federation, nil
+}
+
+// URL returns the federated GraphQL endpoint
+func (f *FederationMock) URL() string {
+	return f.sidecar.URL() + "/graphql"
+}
+
+// SubgraphURL returns the GraphQL endpoint of a single subgraph
+func (f *FederationMock) SubgraphURL(name string) string {
+	return f.sidecar.URL() + "/subgraphs/" + name + "/graphql"
+}
+
+// AddSubgraph composes a subgraph into the gateway. Root fields may only be
+// declared by one subgraph.
+func (f *FederationMock) AddSubgraph(subgraph Subgraph) error {
+	if subgraph.Name == "" {
+		return NewInvalidConfigError("subgraph name is required", nil)
+	}
+
+	owned := make(map[string]string)
+	for _, root := range []string{"Query", "Mutation"} {
+		fields, err := rootFieldsOf(subgraph.SDL, root)
+		if err != nil {
+			return NewInvalidConfigError(
+				fmt.Sprintf("invalid SDL for subgraph %s: %v", subgraph.Name, err),
+				map[string]interface{}{"subgraph": subgraph.Name},
+			)
+		}
+		for _, field := range fields {
+			owned[root+"."+field] = subgraph.Name
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.subgraphs[subgraph.Name]; exists {
+		return NewInvalidConfigError(fmt.Sprintf("subgraph %s already exists", subgraph.Name), nil)
+	}
+	for field := range owned {
+		if owner, taken := f.owners[field]; taken {
+			return NewInvalidConfigError(
+				fmt.Sprintf("composition failed: %s is declared by subgraphs %s and %s", field, owner, subgraph.Name),
+				map[string]interface{}{"field": field},
+			)
+		}
+	}
+
+	f.subgraphs[subgraph.Name] = &federationSubgraph{Subgraph: subgraph}
+	for field, owner := range owned {
+		f.owners[field] = owner
+	}
+
+	return nil
+}
+
+// SupergraphSDL returns the composed schema of every subgraph
+func (f *FederationMock) SupergraphSDL() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var sdl strings.Builder
+	for _, name := range f.subgraphNames() {
+		fmt.Fprintf(&sdl, "# subgraph: %s\n%s\n\n", name, strings.TrimSpace(f.subgraphs[name].SDL))
+	}
+	return sdl.String()
+}
+
+// FailSubgraph injects fault into every response involving the subgraph
+func (f *FederationMock) FailSubgraph(name string, fault SubgraphFault) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	subgraph, ok := f.subgraphs[name]
+	if !ok {
+		return NewInvalidConfigError(fmt.Sprintf("unknown subgraph %s", name), nil)
+	}
+	subgraph.fault = &fault
+	return nil
+}
+
+// HealSubgraph removes any fault injected into the subgraph
+func (f *FederationMock) HealSubgraph(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if subgraph, ok := f.subgraphs[name]; ok {
+		subgraph.fault = nil
+	}
+}
+
+// Requests returns how many operations reached the subgraph, either through
+// the gateway or directly
+func (f *FederationMock) Requests(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if subgraph, ok := f.subgraphs[name]; ok {
+		return subgraph.requests
+	}
+	return 0
+}
+
+// subgraphNames returns the subgraph names in order; f.mu must be held
+func (f *FederationMock) subgraphNames() []string {
+	names := make([]string, 0, len(f.subgraphs))
+	for name := range f.subgraphs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// graphqlRequest is a GraphQL-over-HTTP request body
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlError is an entry of a GraphQL response's errors list
+type graphqlError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (f *FederationMock) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	op, err := parseGraphQL(req.Query, req.OperationName)
+	if err != nil {
+		writeGraphQLErrors(w, http.StatusBadRequest, "GRAPHQL_PARSE_FAILED", err.Error())
+		return
+	}
+
+	if r.URL.Path == "/graphql" {
+		f.serveGateway(w, op)
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subgraphs/"), "/graphql")
+	if name == r.URL.Path || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	f.serveSubgraph(w, name, op)
+}
+
+// serveGateway resolves op across the subgraphs owning its root fields
+func (f *FederationMock) serveGateway(w http.ResponseWriter, op *graphqlOperation) {
+	root := "Query"
+	if op.Type == "mutation" {
+		root = "Mutation"
+	}
+
+	f.mu.Lock()
+	data := make(map[string]interface{})
+	var errs []graphqlError
+	var latency time.Duration
+	involved := make(map[string]bool)
+
+	for _, field := range expandGraphQLFragments(op.Selections, op.Fragments) {
+		key := field.ResponseKey()
+		if field.Name == "__typename" {
+			data[key] = root
+			continue
+		}
+
+		owner, ok := f.owners[root+"."+field.Name]
+		if !ok {
+			f.mu.Unlock()
+			writeGraphQLErrors(w, http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED",
+				fmt.Sprintf("Cannot query field %q on type %q.", field.Name, root))
+			return
+		}
+
+		subgraph := f.subgraphs[owner]
+		if !involved[owner] {
+			involved[owner] = true
+			subgraph.requests++
+		}
+
+		if fault := subgraph.fault; fault != nil {
+			if fault.Latency > latency {
+				latency = fault.Latency
+			}
+			if message := fault.errorMessage(owner); message != "" {
+				data[key] = nil
+				errs = append(errs, graphqlError{
+					Message: message,
+					Path:    []interface{}{key},
+					Extensions: map[string]interface{}{
+						"code":        "SUBREQUEST_HTTP_ERROR",
+						"serviceName": owner,
+					},
+				})
+				continue
+			}
+		}
+
+		data[key] = projectGraphQL(subgraph.Data[field.Name], field.Selections, op.Fragments)
+	}
+	f.mu.Unlock()
+
+	time.Sleep(latency)
+
+	body := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		body["errors"] = errs
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// serveSubgraph resolves op against a single subgraph
+func (f *FederationMock) serveSubgraph(w http.ResponseWriter, name string, op *graphqlOperation) {
+	f.mu.Lock()
+	subgraph, ok := f.subgraphs[name]
+	if !ok {
+		f.mu.Unlock()
+		writeGraphQLErrors(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("unknown subgraph %s", name))
+		return
+	}
+	subgraph.requests++
+	var fault SubgraphFault
+	if subgraph.fault != nil {
+		fault = *subgraph.fault
+	}
+	sdl, values := subgraph.SDL, subgraph.Data
+	f.mu.Unlock()
+
+	time.Sleep(fault.Latency)
+
+	if fault.StatusCode != 0 {
+		writeGraphQLErrors(w, fault.StatusCode, "SUBGRAPH_ERROR", fault.errorMessage(name))
+		return
+	}
+	if fault.Message != "" {
+		writeGraphQLErrors(w, http.StatusOK, "SUBGRAPH_ERROR", fault.Message)
+		return
+	}
+
+	data := make(map[string]interface{})
+	for _, field := range expandGraphQLFragments(op.Selections, op.Fragments) {
+		switch field.Name {
+		case "_service":
+			data[field.ResponseKey()] = projectGraphQL(map[string]interface{}{"sdl": sdl}, field.Selections, op.Fragments)
+		case "__typename":
+			data[field.ResponseKey()] = "Query"
+		default:
+			data[field.ResponseKey()] = projectGraphQL(values[field.Name], field.Selections, op.Fragments)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// errorMessage returns the error reported for a failing subgraph, or "" when
+// the fault only adds latency
+func (fault SubgraphFault) errorMessage(subgraph string) string {
+	switch {
+	case fault.Message != "":
+		return fault.Message
+	case fault.StatusCode != 0:
+		return fmt.Sprintf("HTTP fetch failed from '%s': %d: %s", subgraph, fault.StatusCode, http.StatusText(fault.StatusCode))
+	default:
+		return ""
+	}
+}
+
+// writeGraphQLErrors writes a GraphQL response carrying a single error
+func writeGraphQLErrors(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []graphqlError{{
+			Message:    message,
+			Extensions: map[string]interface{}{"code": code},
+		}},
+	})
+}
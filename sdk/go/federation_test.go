@@ -0,0 +1,93 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestFederationMock(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	federation, err := server.Federation()
+	if err != nil {
+		t.Fatalf("Failed to start federation gateway: %v", err)
+	}
+
+	err = federation.AddSubgraph(Subgraph{
+		Name: "accounts",
+		SDL:  `type Query { me: User } type User @key(fields: "id") { id: ID! name: String }`,
+		Data: map[string]interface{}{"me": map[string]interface{}{"id": "1", "name": "Ada", "email": "ada@example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add subgraph: %v", err)
+	}
+	err = federation.AddSubgraph(Subgraph{
+		Name: "products",
+		SDL:  `type Query { topProducts(first: Int = 5): [Product] } type Product { upc: String! }`,
+		Data: map[string]interface{}{"topProducts": []interface{}{map[string]interface{}{"upc": "1", "price": 899}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add subgraph: %v", err)
+	}
+
+	query := func(url, query string) map[string]interface{} {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+		defer resp.Body.Close()
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result
+	}
+
+	t.Run("rejects conflicting root fields", func(t *testing.T) {
+		err := federation.AddSubgraph(Subgraph{Name: "users", SDL: `type Query { me: User }`})
+		if err == nil {
+			t.Error("Expected composition error")
+		}
+	})
+
+	t.Run("merges subgraph results", func(t *testing.T) {
+		result := query(federation.URL(), `query { me { name } products: topProducts(first: 1) { ...P } } fragment P on Product { upc }`)
+		data := result["data"].(map[string]interface{})
+
+		me := data["me"].(map[string]interface{})
+		if me["name"] != "Ada" || me["email"] != nil {
+			t.Errorf("Expected projected me, got %v", me)
+		}
+		products := data["products"].([]interface{})
+		if products[0].(map[string]interface{})["upc"] != "1" {
+			t.Errorf("Expected aliased products, got %v", products)
+		}
+	})
+
+	t.Run("reports faulty subgraphs", func(t *testing.T) {
+		federation.FailSubgraph("products", SubgraphFault{StatusCode: http.StatusServiceUnavailable})
+		defer federation.HealSubgraph("products")
+
+		result := query(federation.URL(), `{ me { id } topProducts { upc } }`)
+		data := result["data"].(map[string]interface{})
+		if data["topProducts"] != nil || data["me"] == nil {
+			t.Errorf("Expected only topProducts to be null, got %v", data)
+		}
+
+		errs := result["errors"].([]interface{})
+		extensions := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})
+		if extensions["serviceName"] != "products" {
+			t.Errorf("Expected serviceName products, got %v", extensions)
+		}
+	})
+
+	t.Run("serves subgraph SDL", func(t *testing.T) {
+		result := query(federation.SubgraphURL("accounts"), `{ _service { sdl } }`)
+		service := result["data"].(map[string]interface{})["_service"].(map[string]interface{})
+		if service["sdl"] == "" {
+			t.Error("Expected subgraph SDL")
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// fixtureNameInvalid matches the characters the server refuses in fixture
+// names
+var fixtureNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// fixtureCreateRequest is the admin API's body for creating a fixture
+type fixtureCreateRequest struct {
+	Name        string      `json:"name"`
+	Method      string      `json:"method,omitempty"`
+	Path        string      `json:"path,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Protocol    string      `json:"protocol,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Content     interface{} `json:"content"`
+}
+
+// fixtureDocument is a fixture file as the server saves and downloads it
+type fixtureDocument struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Protocol    string   `json:"protocol"`
+	Request     *struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	} `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+// UploadFixture saves data as a fixture on the server and returns the ID
+// the server assigned, so fixtures checked into the repo can be pushed into
+// a fresh server at test start. data is the fixture's response: JSON is
+// stored as-is, anything else as a string. A fixture file as DownloadFixture
+// returns it is unwrapped, so downloaded fixtures upload unchanged.
+//
+// meta supplies the method, path, and protocol (default "http"); the name
+// comes from meta.Metadata["name"], the file's own name, meta.FilePath's
+// base name, or the method and path, in that order. meta.Metadata may also
+// carry a "description" and "tags". The server refuses a name that already
+// exists.
+//
+//	f, _ := os.Open("testdata/fixtures/get_order.json")
+//	defer f.Close()
+//	id, err := server.UploadFixture(mockforge.FixtureInfo{FilePath: f.Name()}, f)
+func (m *MockServer) UploadFixture(meta FixtureInfo, data io.Reader) (string, error) {
+	raw, err := io.ReadAll(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to read fixture data: %w", err)
+	}
+
+	req := fixtureCreateRequest{
+		Method:   strings.ToUpper(meta.Method),
+		Path:     meta.Path,
+		Protocol: strings.ToLower(meta.Protocol),
+		Content:  string(raw),
+	}
+	var document fixtureDocument
+	switch {
+	case json.Unmarshal(raw, &document) == nil && document.Request != nil && document.Response != nil:
+		req.Content = document.Response
+		req.Description = document.Description
+		req.Tags = document.Tags
+		if req.Method == "" {
+			req.Method = strings.ToUpper(document.Request.Method)
+		}
+		if req.Path == "" {
+			req.Path = document.Request.Path
+		}
+		if req.Protocol == "" {
+			req.Protocol = strings.ToLower(document.Protocol)
+		}
+	case json.Valid(raw):
+		req.Content = json.RawMessage(bytes.TrimSpace(raw))
+	}
+	if req.Protocol == "" {
+		req.Protocol = "http"
+	}
+
+	if description, ok := meta.Metadata["description"].(string); ok {
+		req.Description = description
+	}
+	switch tags := meta.Metadata["tags"].(type) {
+	case []string:
+		req.Tags = tags
+	case []interface{}:
+		req.Tags = nil
+		for _, tag := range tags {
+			if tag, ok := tag.(string); ok {
+				req.Tags = append(req.Tags, tag)
+			}
+		}
+	}
+
+	req.Name, _ = meta.Metadata["name"].(string)
+	if req.Name == "" {
+		req.Name = document.Name
+	}
+	if req.Name == "" && meta.FilePath != "" {
+		base := filepath.Base(meta.FilePath)
+		req.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if req.Name == "" {
+		req.Name = req.Method + req.Path
+	}
+	req.Name = fixtureName(req.Name)
+	if req.Name == "" {
+		return "", NewInvalidConfigError("fixture requires a name, file path, or method and path", nil)
+	}
+
+	var info FixtureInfo
+	if err := m.adminEnvelope("upload fixture", http.MethodPost, "/__mockforge/fixtures", req, &info); err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// fixtureName makes name acceptable to the server: characters outside
+// [a-zA-Z0-9._-] become '_', leading dots are dropped, and the name is cut
+// to 200 characters
+func fixtureName(name string) string {
+	name = strings.TrimLeft(fixtureNameInvalid.ReplaceAllString(name, "_"), "._")
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	return name
+}
+
+// FixtureFilter selects fixtures by age, protocol, and path; the zero
+// filter selects every fixture
+type FixtureFilter struct {
+	// OlderThan selects fixtures saved at least this long ago
+	OlderThan time.Duration
+	// Protocol selects fixtures of this protocol, e.g. "http" or "grpc"
+	Protocol string
+	// PathPrefix selects fixtures whose request path starts with this
+	// prefix, e.g. "/api/v1/"
+	PathPrefix string
+}
+
+// matches reports whether fixture passes the filter at now. A fixture
+// whose save time cannot be parsed is never old enough.
+func (f FixtureFilter) matches(fixture *FixtureInfo, now time.Time) bool {
+	if f.Protocol != "" && !strings.EqualFold(fixture.Protocol, f.Protocol) {
+		return false
+	}
+	if !strings.HasPrefix(fixture.Path, f.PathPrefix) {
+		return false
+	}
+	if f.OlderThan > 0 {
+		saved, err := time.Parse(time.RFC3339Nano, fixture.SavedAt)
+		if err != nil || now.Sub(saved) < f.OlderThan {
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteFixture removes the fixture with the given ID from the server's
+// fixtures directory. In dry-run mode the deletion is recorded instead.
+func (m *MockServer) DeleteFixture(id string) error {
+	if m.isDryRun() {
+		m.recordDryRun("delete fixture", []string{id})
+		return nil
+	}
+	body := map[string]string{"fixture_id": id}
+	return m.adminEnvelope("delete fixture", http.MethodDelete, "/__mockforge/fixtures/"+url.PathEscape(id), body, nil)
+}
+
+// PruneFixtures removes the fixtures filter selects in one admin call and
+// returns them, so test infrastructure can manage fixture storage without
+// deleting the server's data directory. In dry-run mode the deletion is
+// recorded instead and the fixtures that would be removed are returned.
+//
+//	// Drop HTTP fixtures older than a week
+//	pruned, err := server.PruneFixtures(mockforge.FixtureFilter{
+//	    OlderThan: 7 * 24 * time.Hour,
+//	    Protocol:  "http",
+//	})
+func (m *MockServer) PruneFixtures(filter FixtureFilter) ([]FixtureInfo, error) {
+	fixtures, err := m.ListFixtures()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var pruned []FixtureInfo
+	var ids []string
+	for i := range fixtures {
+		if filter.matches(&fixtures[i], now) {
+			pruned = append(pruned, fixtures[i])
+			ids = append(ids, fixtures[i].ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if m.isDryRun() {
+		m.recordDryRun("prune fixtures", ids)
+		return pruned, nil
+	}
+	body := map[string][]string{"fixture_ids": ids}
+	if err := m.adminEnvelope("prune fixtures", http.MethodDelete, "/__mockforge/fixtures/bulk", body, nil); err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
@@ -0,0 +1,253 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureUploadServer records the bodies of fixture uploads
+func fixtureUploadServer(t *testing.T, uploads *[]map[string]interface{}) *MockServer {
+	return newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/__mockforge/fixtures" {
+			http.NotFound(w, r)
+			return
+		}
+		var upload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&upload)
+		*uploads = append(*uploads, upload)
+		w.Write([]byte(`{"success":true,"data":{"id":"fixture_1a2b","protocol":"http"}}`))
+	}))
+}
+
+func TestUploadFixture(t *testing.T) {
+	var uploads []map[string]interface{}
+	server := fixtureUploadServer(t, &uploads)
+
+	id, err := server.UploadFixture(FixtureInfo{
+		Method:   "get",
+		Path:     "/orders/42",
+		FilePath: "testdata/fixtures/order 42.json",
+		Metadata: map[string]interface{}{"tags": []interface{}{"orders"}},
+	}, strings.NewReader(`{"id": "42"}`))
+	if err != nil {
+		t.Fatalf("Failed to upload fixture: %v", err)
+	}
+	if id != "fixture_1a2b" {
+		t.Errorf("Expected the server's fixture ID, got %q", id)
+	}
+
+	upload := uploads[0]
+	if upload["name"] != "order_42" || upload["method"] != "GET" || upload["path"] != "/orders/42" || upload["protocol"] != "http" {
+		t.Errorf("Expected name, method, path, and default protocol, got %v", upload)
+	}
+	if content, ok := upload["content"].(map[string]interface{}); !ok || content["id"] != "42" {
+		t.Errorf("Expected JSON content, got %v", upload["content"])
+	}
+	if tags, _ := upload["tags"].([]interface{}); len(tags) != 1 || tags[0] != "orders" {
+		t.Errorf("Expected tags from metadata, got %v", upload["tags"])
+	}
+}
+
+func TestUploadFixtureDocument(t *testing.T) {
+	var uploads []map[string]interface{}
+	server := fixtureUploadServer(t, &uploads)
+
+	// A fixture file as DownloadFixture returns it
+	document := `{"name": "list-users", "protocol": "http", "request": {"method": "GET", "path": "/users"}, "response": [{"id": 1}]}`
+	if _, err := server.UploadFixture(FixtureInfo{}, strings.NewReader(document)); err != nil {
+		t.Fatalf("Failed to upload fixture: %v", err)
+	}
+	if _, err := server.UploadFixture(FixtureInfo{Method: "POST", Path: "/echo"}, strings.NewReader("plain text")); err != nil {
+		t.Fatalf("Failed to upload fixture: %v", err)
+	}
+
+	if upload := uploads[0]; upload["name"] != "list-users" || upload["path"] != "/users" {
+		t.Errorf("Expected name and path from the document, got %v", upload)
+	}
+	if content, ok := uploads[0]["content"].([]interface{}); !ok || len(content) != 1 {
+		t.Errorf("Expected the document's response as content, got %v", uploads[0]["content"])
+	}
+	if upload := uploads[1]; upload["name"] != "POST_echo" || upload["content"] != "plain text" {
+		t.Errorf("Expected a name from method and path and string content, got %v", upload)
+	}
+
+	if _, err := server.UploadFixture(FixtureInfo{}, strings.NewReader("{}")); err == nil {
+		t.Error("Expected error without a name")
+	}
+}
+
+func TestDeleteFixture(t *testing.T) {
+	var path, deleted string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			FixtureID string `json:"fixture_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		path, deleted = r.URL.Path, body.FixtureID
+		w.Write([]byte(`{"success":true,"data":"Fixture deleted successfully"}`))
+	}))
+
+	if err := server.DeleteFixture("fixture_1a2b"); err != nil {
+		t.Fatalf("Failed to delete fixture: %v", err)
+	}
+	if path != "/__mockforge/fixtures/fixture_1a2b" || deleted != "fixture_1a2b" {
+		t.Errorf("Expected the fixture ID in path and body, got %q and %q", path, deleted)
+	}
+}
+
+func TestPruneFixtures(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339Nano)
+	recent := time.Now().UTC().Format(time.RFC3339Nano)
+	var bulk []string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"success":true,"data":[
+				{"id":"a","protocol":"http","path":"/api/orders","saved_at":"` + old + `"},
+				{"id":"b","protocol":"http","path":"/api/users","saved_at":"` + recent + `"},
+				{"id":"c","protocol":"grpc","path":"/api/Greeter","saved_at":"` + old + `"},
+				{"id":"d","protocol":"http","path":"/health","saved_at":"` + old + `"}
+			]}`))
+			return
+		}
+		if r.URL.Path != "/__mockforge/fixtures/bulk" {
+			t.Errorf("Expected a bulk delete, got %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			FixtureIDs []string `json:"fixture_ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		bulk = body.FixtureIDs
+		w.Write([]byte(`{"success":true,"data":{"deleted_count":1,"total_requested":1,"errors":[]}}`))
+	}))
+
+	filter := FixtureFilter{OlderThan: 24 * time.Hour, Protocol: "HTTP", PathPrefix: "/api/"}
+	server.WithDryRun(true)
+	pruned, err := server.PruneFixtures(filter)
+	if err != nil {
+		t.Fatalf("Failed to prune fixtures: %v", err)
+	}
+	if bulk != nil || len(pruned) != 1 {
+		t.Errorf("Expected no deletes in dry-run mode but the fixture to prune, got %v and %v", bulk, pruned)
+	}
+	if changes := server.DryRunChanges(); len(changes) != 1 || changes[0].Operation != "prune fixtures" {
+		t.Errorf("Expected a prune fixtures change, got %+v", changes)
+	}
+
+	server.WithDryRun(false)
+	pruned, err = server.PruneFixtures(filter)
+	if err != nil {
+		t.Fatalf("Failed to prune fixtures: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].ID != "a" || len(bulk) != 1 || bulk[0] != "a" {
+		t.Errorf("Expected only the old HTTP fixture under /api/ pruned, got %v and %v", pruned, bulk)
+	}
+
+	if pruned, err := server.PruneFixtures(FixtureFilter{PathPrefix: "/admin/"}); err != nil || pruned != nil {
+		t.Errorf("Expected nothing to prune, got %v, %v", pruned, err)
+	}
+}
+
+// fixtureStore fakes the admin API's fixture endpoints, passthrough
+// fallback, and mock creation, keeping fixtures in memory
+type fixtureStore struct {
+	mu       sync.Mutex
+	fixtures []FixtureInfo
+	contents map[string]json.RawMessage // Fixture file documents by ID
+	proxy    map[string]interface{}     // The passthrough fallback, if set
+}
+
+func (s *fixtureStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	respond := func(data interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+	}
+
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/__mockforge/api/fallbacks":
+		json.NewDecoder(r.Body).Decode(&s.proxy)
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodDelete && r.URL.Path == "/__mockforge/api/fallbacks":
+		s.proxy = nil
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/__mockforge/api/mocks/bulk":
+		var configs []interface{}
+		json.NewDecoder(r.Body).Decode(&configs)
+		created := make([]map[string]string, len(configs))
+		for i := range created {
+			created[i] = map[string]string{"id": fmt.Sprintf("mock_%d", i+1)}
+		}
+		json.NewEncoder(w).Encode(created)
+	case r.URL.Path == "/__mockforge/api/mocks":
+		w.Write([]byte(`{"id":"mock_1"}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/__mockforge/fixtures":
+		respond(append([]FixtureInfo{}, s.fixtures...))
+	case r.Method == http.MethodPost && r.URL.Path == "/__mockforge/fixtures":
+		var req fixtureCreateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		filePath := req.Protocol + "/" + req.Name + ".json"
+		for _, fixture := range s.fixtures {
+			if fixture.FilePath == filePath {
+				json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": "already exists"})
+				return
+			}
+		}
+		document, _ := json.Marshal(map[string]interface{}{
+			"name":     req.Name,
+			"protocol": req.Protocol,
+			"request":  map[string]string{"method": req.Method, "path": req.Path},
+			"response": req.Content,
+		})
+		info := FixtureInfo{
+			ID:       fmt.Sprintf("fixture_%d", len(s.contents)+1),
+			Protocol: req.Protocol,
+			Method:   req.Method,
+			Path:     req.Path,
+			SavedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+			FilePath: filePath,
+		}
+		json.Unmarshal(document, &info.Metadata)
+		if s.contents == nil {
+			s.contents = make(map[string]json.RawMessage)
+		}
+		s.contents[info.ID] = document
+		s.fixtures = append(s.fixtures, info)
+		respond(info)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/download"):
+		document, ok := s.contents[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/__mockforge/fixtures/"), "/download")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(document)
+	case r.Method == http.MethodDelete:
+		var req struct {
+			FixtureID string `json:"fixture_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		for i, fixture := range s.fixtures {
+			if fixture.ID == req.FixtureID {
+				s.fixtures = append(s.fixtures[:i], s.fixtures[i+1:]...)
+				respond("Fixture deleted successfully")
+				return
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": "not found"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// passthroughURL returns the upstream the server was told to forward
+// unmatched requests to, or "" when passthrough is off
+func (s *fixtureStore) passthroughURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upstream, _ := s.proxy["upstream_url"].(string)
+	return upstream
+}
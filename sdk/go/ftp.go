@@ -0,0 +1,146 @@
+package mockforge
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+// FTPUpload is a file an FTP client stored, as the server's upload log
+// records it
+type FTPUpload struct {
+	ID   string    `json:"id"`
+	Path string    `json:"path"`
+	Size int64     `json:"size"`
+	At   time.Time `json:"uploaded_at"`
+}
+
+// FTPFile is a file of the FTP server's virtual file system
+type FTPFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified_at"`
+}
+
+// FTPMock controls MockForge's FTP server, enabled with MockServerConfig.FTP.
+// The server serves an in-memory file system: tests seed the files a batch
+// integration downloads and assert on the files it uploads. Any login is
+// accepted. Uploads to any path are stored, unless the config file sets
+// ftp.fixtures_dir, whose upload rules then decide.
+type FTPMock struct {
+	server *MockServer
+}
+
+// FTP returns the controller of the server's FTP server
+func (m *MockServer) FTP() *FTPMock {
+	return &FTPMock{server: m}
+}
+
+// Addr returns the host:port of the FTP control connection, or an empty
+// string if the server has not reported an FTP server
+func (f *FTPMock) Addr() string {
+	f.server.portMutex.RLock()
+	defer f.server.portMutex.RUnlock()
+	if f.server.ftpPort == 0 {
+		return ""
+	}
+	return net.JoinHostPort(f.server.host, strconv.Itoa(f.server.ftpPort))
+}
+
+// SeedFile stores a file, replacing any file at name and creating its
+// parent directories
+func (f *FTPMock) SeedFile(name string, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	_, err := f.server.adminBytes("seed ftp file", http.MethodPut, "/__mockforge/api/ftp/files"+ftpURLPath(name), data)
+	return err
+}
+
+// SeedDir creates a directory and its parents
+func (f *FTPMock) SeedDir(name string) error {
+	return f.server.adminJSON("seed ftp directory", http.MethodPut, "/__mockforge/api/ftp/dirs"+ftpURLPath(name), nil, nil)
+}
+
+// File returns the current content of a file, seeded or uploaded
+func (f *FTPMock) File(name string) ([]byte, error) {
+	return f.server.adminBytes("get ftp file", http.MethodGet, "/__mockforge/api/ftp/files"+ftpURLPath(name), nil)
+}
+
+// DeleteFile removes a file
+func (f *FTPMock) DeleteFile(name string) error {
+	if f.server.isDryRun() {
+		f.server.recordDryRun("delete ftp file", []string{path.Clean("/" + name)})
+		return nil
+	}
+	return f.server.adminJSON("delete ftp file", http.MethodDelete, "/__mockforge/api/ftp/files"+ftpURLPath(name), nil, nil)
+}
+
+// Files lists every file of the virtual file system, sorted by path
+func (f *FTPMock) Files() ([]FTPFile, error) {
+	var files []FTPFile
+	if err := f.server.adminJSON("list ftp files", http.MethodGet, "/__mockforge/api/ftp/files", nil, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Uploads returns every file clients stored, in order of arrival. Files
+// stay listed after they are deleted or renamed.
+func (f *FTPMock) Uploads() ([]FTPUpload, error) {
+	var uploads []FTPUpload
+	if err := f.server.adminJSON("list ftp uploads", http.MethodGet, "/__mockforge/api/ftp/uploads", nil, &uploads); err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// ClearUploads empties the upload log. Uploaded files stay in the file
+// system.
+func (f *FTPMock) ClearUploads() error {
+	if f.server.isDryRun() {
+		uploads, err := f.Uploads()
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for _, upload := range uploads {
+			ids = append(ids, upload.ID)
+		}
+		f.server.recordDryRun("clear ftp uploads", ids)
+		return nil
+	}
+	return f.server.adminJSON("clear ftp uploads", http.MethodDelete, "/__mockforge/api/ftp/uploads", nil, nil)
+}
+
+// VerifyUploaded asserts how many uploads were stored at a path matching
+// pattern, a path.Match glob such as "/outbox/*.csv"
+func (f *FTPMock) VerifyUploaded(pattern string, count VerificationCount) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return NewInvalidConfigError("invalid FTP path pattern", map[string]interface{}{"pattern": pattern})
+	}
+	uploads, err := f.Uploads()
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, upload := range uploads {
+		if matched, _ := path.Match(pattern, upload.Path); matched {
+			n++
+		}
+	}
+	if !count.Satisfied(n) {
+		return fmt.Errorf("expected %s FTP upload(s) to %q, got %d of %d", count, pattern, n, len(uploads))
+	}
+	return nil
+}
+
+// ftpURLPath turns a file system path into the escaped, absolute URL path
+// the admin API's file endpoints take after their prefix
+func ftpURLPath(name string) string {
+	return (&url.URL{Path: path.Clean("/" + name)}).EscapedPath()
+}
@@ -0,0 +1,149 @@
+package mockforge
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFTPMock(t *testing.T) {
+	var seeded []byte
+	var dirs []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/__mockforge/api/ftp/files/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/__mockforge/api/ftp/files/inbox/orders%20today.csv" {
+			http.Error(w, `{"error":"File not found"}`, http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			if got := r.Header.Get("Content-Type"); got != "application/octet-stream" {
+				t.Errorf("Expected an octet-stream body, got %q", got)
+			}
+			seeded, _ = io.ReadAll(r.Body)
+			w.Write([]byte(`{"path":"/inbox/orders today.csv","size":11}`))
+		case http.MethodGet:
+			w.Write(seeded)
+		}
+	})
+	mux.HandleFunc("/__mockforge/api/ftp/dirs/outbox", func(w http.ResponseWriter, r *http.Request) {
+		dirs = append(dirs, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{"path":"/outbox"}`))
+	})
+	mux.HandleFunc("/__mockforge/api/ftp/uploads", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"u1","path":"/outbox/report.csv","size":12,"uploaded_at":"2026-01-02T03:04:05Z","rule_name":null},
+			{"id":"u2","path":"/outbox/notes.txt","size":3,"uploaded_at":"2026-01-02T03:04:06Z","rule_name":null}
+		]`))
+	})
+	server := newAdminTestServer(t, mux)
+	ftp := server.FTP()
+
+	if ftp.Addr() != "" {
+		t.Errorf("Expected no address before the server reports one, got %q", ftp.Addr())
+	}
+	if err := ftp.SeedFile("inbox/orders today.csv", []byte("id,qty\n1,2\n")); err != nil {
+		t.Fatalf("Failed to seed file: %v", err)
+	}
+	if err := ftp.SeedDir("/outbox/"); err != nil {
+		t.Fatalf("Failed to seed directory: %v", err)
+	}
+	if want := []string{"PUT /__mockforge/api/ftp/dirs/outbox"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("Expected requests %v, got %v", want, dirs)
+	}
+	data, err := ftp.File("/inbox/orders today.csv")
+	if err != nil {
+		t.Fatalf("Failed to get file: %v", err)
+	}
+	if string(data) != "id,qty\n1,2\n" {
+		t.Errorf("Expected the seeded content, got %q", data)
+	}
+	if _, err := ftp.File("/missing.csv"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	uploads, err := ftp.Uploads()
+	if err != nil {
+		t.Fatalf("Failed to list uploads: %v", err)
+	}
+	if len(uploads) != 2 || uploads[0].ID != "u1" || uploads[0].Path != "/outbox/report.csv" || uploads[0].Size != 12 || uploads[0].At.IsZero() {
+		t.Errorf("Unexpected uploads %+v", uploads)
+	}
+	if err := ftp.VerifyUploaded("/outbox/*.csv", Exactly(1)); err != nil {
+		t.Errorf("Expected one CSV upload: %v", err)
+	}
+	if err := ftp.VerifyUploaded("/outbox/*", AtLeast(3)); err == nil || !strings.Contains(err.Error(), "got 2 of 2") {
+		t.Errorf("Expected a count mismatch, got %v", err)
+	}
+	if err := ftp.VerifyUploaded("[", Exactly(1)); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestFTPServerConfig(t *testing.T) {
+	server := NewMockServer(MockServerConfig{FTP: true})
+	path, dir, err := server.writeServerConfig()
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	var config struct {
+		FTP map[string]interface{} `yaml:"ftp"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	fixturesDir := filepath.Join(dir, "ftp-fixtures")
+	want := map[string]interface{}{"enabled": true, "port": 0, "host": "127.0.0.1", "fixtures_dir": fixturesDir}
+	if !reflect.DeepEqual(config.FTP, want) {
+		t.Errorf("Expected ftp section %v, got %v", want, config.FTP)
+	}
+
+	var fixture struct {
+		UploadRules []struct {
+			PathPattern string `yaml:"path_pattern"`
+			AutoAccept  bool   `yaml:"auto_accept"`
+			Storage     struct {
+				Type string `yaml:"type"`
+			} `yaml:"storage"`
+		} `yaml:"upload_rules"`
+	}
+	data, err = os.ReadFile(filepath.Join(fixturesDir, "uploads.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read FTP fixture: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		t.Fatalf("Failed to parse FTP fixture: %v", err)
+	}
+	if len(fixture.UploadRules) != 1 || fixture.UploadRules[0].PathPattern != ".*" || !fixture.UploadRules[0].AutoAccept || fixture.UploadRules[0].Storage.Type != "memory" {
+		t.Errorf("Expected a rule storing every upload, got %+v", fixture.UploadRules)
+	}
+
+	// A config file's own fixtures decide which uploads are accepted
+	configFile := filepath.Join(t.TempDir(), "mockforge.yaml")
+	os.WriteFile(configFile, []byte("ftp:\n  port: 2121\n  fixtures_dir: /srv/ftp-fixtures\n"), 0o644)
+	server = NewMockServer(MockServerConfig{FTP: true, ConfigFile: configFile})
+	path, dir, err = server.writeServerConfig()
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	data, _ = os.ReadFile(path)
+	config.FTP = nil
+	yaml.Unmarshal(data, &config)
+	want = map[string]interface{}{"enabled": true, "port": 2121, "host": "127.0.0.1", "fixtures_dir": "/srv/ftp-fixtures"}
+	if !reflect.DeepEqual(config.FTP, want) {
+		t.Errorf("Expected ftp section %v, got %v", want, config.FTP)
+	}
+}
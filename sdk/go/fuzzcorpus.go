@@ -0,0 +1,151 @@
+package mockforge
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CorpusOptions configures WriteFuzzCorpus
+type CorpusOptions struct {
+	// Minimize compacts JSON bodies, dropping insignificant whitespace
+	Minimize bool
+	// Deduplicate keeps one body per JSON shape (the same keys with the same
+	// value types), preferring the smallest. Non-JSON bodies are only
+	// deduplicated when identical.
+	Deduplicate bool
+	// MaxEntries bounds the number of seed files written; zero means no limit
+	MaxEntries int
+}
+
+// WriteFuzzCorpus writes the bodies of recorded requests matching pattern to
+// dir as seed files in Go's native fuzzing corpus format, so recorded traffic
+// bootstraps `go test -fuzz`. Point dir at testdata/fuzz/<FuzzTarget> of a
+// fuzz target taking a single []byte argument:
+//
+//	server.WriteFuzzCorpus(mockforge.VerificationRequest{Method: "POST", Path: "/orders"},
+//	    "testdata/fuzz/FuzzParseOrder", mockforge.CorpusOptions{Minimize: true, Deduplicate: true})
+//
+// It returns the number of seed files written. Requests without a body are
+// skipped.
+func (m *MockServer) WriteFuzzCorpus(pattern VerificationRequest, dir string, opts CorpusOptions) (int, error) {
+	all, err := m.verify(pattern, AtLeast(0))
+	if err != nil {
+		return 0, err
+	}
+	requests, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return 0, err
+	}
+
+	var bodies [][]byte
+	for _, req := range requests {
+		body := req.bodyBytes()
+		if len(body) == 0 {
+			continue
+		}
+		if opts.Minimize {
+			var compact bytes.Buffer
+			if json.Compact(&compact, body) == nil {
+				body = compact.Bytes()
+			}
+		}
+		bodies = append(bodies, body)
+	}
+	if opts.Deduplicate {
+		bodies = dedupeByShape(bodies)
+	}
+	if opts.MaxEntries > 0 && len(bodies) > opts.MaxEntries {
+		bodies = bodies[:opts.MaxEntries]
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+	written := make(map[string]bool)
+	for _, body := range bodies {
+		entry := []byte("go test fuzz v1\n[]byte(" + strconv.Quote(string(body)) + ")\n")
+		// Seed files are named after their content, as go test does
+		name := fmt.Sprintf("%x", sha256.Sum256(entry))[:16]
+		if written[name] {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), entry, 0o644); err != nil {
+			return len(written), fmt.Errorf("failed to write corpus entry: %w", err)
+		}
+		written[name] = true
+	}
+
+	return len(written), nil
+}
+
+// dedupeByShape keeps the smallest body of each JSON shape, in order of
+// first appearance
+func dedupeByShape(bodies [][]byte) [][]byte {
+	var order []string
+	smallest := make(map[string][]byte)
+	for _, body := range bodies {
+		key := "raw:" + string(body)
+		var value interface{}
+		if json.Unmarshal(body, &value) == nil {
+			key = "json:" + jsonShape(value)
+		}
+		current, seen := smallest[key]
+		if !seen {
+			order = append(order, key)
+		}
+		if !seen || len(body) < len(current) {
+			smallest[key] = body
+		}
+	}
+
+	deduped := make([][]byte, len(order))
+	for i, key := range order {
+		deduped[i] = smallest[key]
+	}
+	return deduped
+}
+
+// jsonShape describes the structure of a decoded JSON value: object keys and
+// value types, ignoring the values themselves and array lengths
+func jsonShape(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = strconv.Quote(key) + ":" + jsonShape(v[key])
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	case []interface{}:
+		// Arrays are characterized by the distinct shapes of their items
+		shapes := make(map[string]bool)
+		for _, item := range v {
+			shapes[jsonShape(item)] = true
+		}
+		items := make([]string, 0, len(shapes))
+		for shape := range shapes {
+			items = append(items, shape)
+		}
+		sort.Strings(items)
+		return "[" + strings.Join(items, "|") + "]"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	default:
+		return "null"
+	}
+}
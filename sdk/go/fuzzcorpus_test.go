@@ -0,0 +1,75 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteFuzzCorpus(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   4,
+			"matches": []map[string]interface{}{
+				{"method": "POST", "path": "/orders", "body": `{"sku": "A1", "qty": 2}`},
+				{"method": "POST", "path": "/orders", "body": `{"sku":"B","qty":1}`},
+				{"method": "POST", "path": "/orders", "body": `{"sku":"C","qty":1,"note":"gift"}`},
+				{"method": "POST", "path": "/orders"},
+			},
+		})
+	}))
+
+	dir := filepath.Join(t.TempDir(), "testdata", "fuzz", "FuzzParseOrder")
+	n, err := server.WriteFuzzCorpus(VerificationRequest{Method: "POST", Path: "/orders"}, dir, CorpusOptions{Minimize: true, Deduplicate: true})
+	if err != nil {
+		t.Fatalf("Failed to write corpus: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Expected one seed per body shape, got %d", n)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	found := false
+	for _, entry := range entries {
+		data, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if string(data) == "go test fuzz v1\n[]byte(\"{\\\"sku\\\":\\\"B\\\",\\\"qty\\\":1}\")\n" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the smallest minimized order as a seed, got %d files", len(entries))
+	}
+}
+
+func TestWriteFuzzCorpusBinaryBodies(t *testing.T) {
+	body := []byte{0xff, 0xfe, 0x00, 'o', 'k', 0x80}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   1,
+			"matches": []map[string]interface{}{
+				{"method": "POST", "path": "/upload", "metadata": map[string]string{
+					"request_body":        strings.ToValidUTF8(string(body), "\uFFFD"),
+					"request_body_base64": base64.StdEncoding.EncodeToString(body),
+				}},
+			},
+		})
+	}))
+
+	dir := t.TempDir()
+	if n, err := server.WriteFuzzCorpus(VerificationRequest{Path: "/upload"}, dir, CorpusOptions{}); err != nil || n != 1 {
+		t.Fatalf("Expected one seed, got %d (%v)", n, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	data, _ := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	want := "go test fuzz v1\n[]byte(" + strconv.Quote(string(body)) + ")\n"
+	if string(data) != want {
+		t.Errorf("Expected the body bytes intact, got %q", data)
+	}
+}
@@ -0,0 +1,388 @@
+package mockforge
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// graphqlSelection is a field, fragment spread, or inline fragment in a
+// GraphQL selection set. Arguments and directives are parsed but not kept.
+type graphqlSelection struct {
+	Alias      string
+	Name       string
+	Fragment   string             // set for named fragment spreads
+	Selections []graphqlSelection // sub-selections, or an inline fragment's selections
+}
+
+// ResponseKey returns the key the field is reported under in the response
+func (s graphqlSelection) ResponseKey() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// graphqlOperation is the executable part of a GraphQL document
+type graphqlOperation struct {
+	Type       string // query, mutation, or subscription
+	Name       string
+	Selections []graphqlSelection
+	Fragments  map[string][]graphqlSelection
+}
+
+// graphqlParser is a small recursive-descent parser covering the executable
+// GraphQL grammar mocks need: operations, fields, aliases, and fragments
+type graphqlParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseGraphQL parses query and returns the operation named operationName,
+// or the only operation when operationName is empty
+func parseGraphQL(query, operationName string) (*graphqlOperation, error) {
+	tokens, err := tokenizeGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &graphqlParser{tokens: tokens}
+
+	var operations []*graphqlOperation
+	fragments := make(map[string][]graphqlSelection)
+
+	for !p.done() {
+		switch tok := p.peek(); tok {
+		case "{":
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			operations = append(operations, &graphqlOperation{Type: "query", Selections: selections})
+		case "query", "mutation", "subscription":
+			p.next()
+			op := &graphqlOperation{Type: tok}
+			if name := p.peek(); name != "(" && name != "{" && name != "@" {
+				op.Name = p.next()
+			}
+			if p.peek() == "(" {
+				if err := p.skipBalanced("(", ")"); err != nil {
+					return nil, err
+				}
+			}
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.Selections = selections
+			operations = append(operations, op)
+		case "fragment":
+			p.next()
+			name := p.next()
+			if p.next() != "on" {
+				return nil, fmt.Errorf("expected type condition on fragment %s", name)
+			}
+			p.next()
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			fragments[name] = selections
+		default:
+			return nil, fmt.Errorf("unexpected %q", tok)
+		}
+	}
+
+	for _, op := range operations {
+		if operationName == "" || op.Name == operationName {
+			if operationName == "" && len(operations) > 1 {
+				return nil, fmt.Errorf("operationName is required when the document has several operations")
+			}
+			op.Fragments = fragments
+			return op, nil
+		}
+	}
+	if operationName != "" {
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+	return nil, fmt.Errorf("document has no operation")
+}
+
+// selectionSet parses { selection ... }
+func (p *graphqlParser) selectionSet() ([]graphqlSelection, error) {
+	if tok := p.next(); tok != "{" {
+		return nil, fmt.Errorf("expected {, got %q", tok)
+	}
+
+	var selections []graphqlSelection
+	for p.peek() != "}" {
+		if p.done() {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+
+		if p.peek() == "..." {
+			p.next()
+			if p.peek() == "on" || p.peek() == "{" || p.peek() == "@" {
+				if p.peek() == "on" {
+					p.next()
+					p.next()
+				}
+				if err := p.skipDirectives(); err != nil {
+					return nil, err
+				}
+				sub, err := p.selectionSet()
+				if err != nil {
+					return nil, err
+				}
+				selections = append(selections, graphqlSelection{Selections: sub})
+			} else {
+				selections = append(selections, graphqlSelection{Fragment: p.next()})
+				if err := p.skipDirectives(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		field := graphqlSelection{Name: p.next()}
+		if !isGraphQLName(field.Name) {
+			return nil, fmt.Errorf("unexpected %q", field.Name)
+		}
+		if p.peek() == ":" {
+			p.next()
+			field.Alias = field.Name
+			field.Name = p.next()
+		}
+		if p.peek() == "(" {
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.skipDirectives(); err != nil {
+			return nil, err
+		}
+		if p.peek() == "{" {
+			sub, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			field.Selections = sub
+		}
+		selections = append(selections, field)
+	}
+	p.next()
+
+	return selections, nil
+}
+
+// skipDirectives skips any @directive(args) annotations
+func (p *graphqlParser) skipDirectives() error {
+	for p.peek() == "@" {
+		p.next()
+		p.next()
+		if p.peek() == "(" {
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipBalanced skips a bracketed group such as an argument list
+func (p *graphqlParser) skipBalanced(open, close string) error {
+	depth := 0
+	for !p.done() {
+		switch p.next() {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unterminated %s", open)
+}
+
+func (p *graphqlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *graphqlParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *graphqlParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// tokenizeGraphQL splits a GraphQL document into names, values, and
+// punctuators, dropping whitespace, commas, and comments
+func tokenizeGraphQL(source string) ([]string, error) {
+	var tokens []string
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',' || r == '\uFEFF':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.ContainsRune("{}()[]:!$=@|&", r):
+			tokens = append(tokens, string(r))
+			i++
+		case r == '"':
+			start := i
+			if strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), `"""`) {
+				end := strings.Index(string(runes[i+3:]), `"""`)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated block string")
+				}
+				i += 3 + len([]rune(string(runes[i+3:])[:end])) + 3
+			} else {
+				i++
+				for i < len(runes) && runes[i] != '"' {
+					if runes[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string")
+				}
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (strings.ContainsRune(".eE+-", runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	return tokens, nil
+}
+
+// isGraphQLName reports whether tok is a valid GraphQL name
+func isGraphQLName(tok string) bool {
+	if tok == "" || unicode.IsDigit(rune(tok[0])) {
+		return false
+	}
+	for _, r := range tok {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// projectGraphQL shapes value to the selection set, as a GraphQL server would
+// when resolving fields from value. Fields missing from value resolve to null.
+func projectGraphQL(value interface{}, selections []graphqlSelection, fragments map[string][]graphqlSelection) interface{} {
+	if len(selections) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = projectGraphQL(item, selections, fragments)
+		}
+		return items
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for _, field := range expandGraphQLFragments(selections, fragments) {
+			result[field.ResponseKey()] = projectGraphQL(v[field.Name], field.Selections, fragments)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// expandGraphQLFragments flattens fragment spreads and inline fragments in a
+// selection set into the fields they select
+func expandGraphQLFragments(selections []graphqlSelection, fragments map[string][]graphqlSelection) []graphqlSelection {
+	var fields []graphqlSelection
+	for _, selection := range selections {
+		switch {
+		case selection.Fragment != "":
+			fields = append(fields, expandGraphQLFragments(fragments[selection.Fragment], fragments)...)
+		case selection.Name == "":
+			fields = append(fields, expandGraphQLFragments(selection.Selections, fragments)...)
+		default:
+			fields = append(fields, selection)
+		}
+	}
+	return fields
+}
+
+// rootFieldsOf returns the field names declared on typeName (including
+// extensions) in an SDL document
+func rootFieldsOf(sdl, typeName string) ([]string, error) {
+	tokens, err := tokenizeGraphQL(sdl)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i] != "type" || tokens[i+1] != typeName {
+			continue
+		}
+		// Skip implements clauses and directives up to the field block
+		j := i + 2
+		for j < len(tokens) && tokens[j] != "{" {
+			j++
+		}
+		depth := 0
+		for ; j < len(tokens); j++ {
+			switch tokens[j] {
+			case "{", "(":
+				depth++
+			case "}", ")":
+				depth--
+			default:
+				if depth == 1 && j+1 < len(tokens) && (tokens[j+1] == ":" || tokens[j+1] == "(") && isGraphQLName(tokens[j]) {
+					fields = append(fields, tokens[j])
+				}
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		i = j
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,98 @@
+package mockforge
+
+import (
+	"net/http"
+	"sort"
+)
+
+// defaultGraphQLPath is where StubGraphQL stubs are served unless
+// MockServerConfig.GraphQLPath is set
+const defaultGraphQLPath = "/graphql"
+
+// GraphQLError is an entry of a GraphQL response's errors list
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse is a complete GraphQL response. Pass one to StubGraphQL to
+// control errors as well as data; any other value is served as data.
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLErrors returns a response with null data and the given errors, as
+// servers answer when an operation fails as a whole
+func GraphQLErrors(errs ...GraphQLError) GraphQLResponse {
+	return GraphQLResponse{Errors: errs}
+}
+
+// GraphQLPartial returns a response with both data and errors, as servers
+// answer when some fields failed to resolve
+func GraphQLPartial(data interface{}, errs ...GraphQLError) GraphQLResponse {
+	return GraphQLResponse{Data: data, Errors: errs}
+}
+
+// GraphQLErrorCode returns an error carrying extensions.code, the convention
+// clients use to classify failures (e.g. "UNAUTHENTICATED", "NOT_FOUND")
+func GraphQLErrorCode(message, code string) GraphQLError {
+	return GraphQLError{Message: message, Extensions: map[string]interface{}{"code": code}}
+}
+
+// StubGraphQL answers GraphQL operations named operationName with response.
+// The operation is recognized from the request's operationName or, if that
+// is absent, from the query document. If variables is non-empty, only
+// requests whose variables have those values match, and such stubs take
+// precedence over ones without variables.
+//
+//	server.StubGraphQL("GetUser", map[string]interface{}{"id": "1"},
+//	    map[string]interface{}{"user": map[string]interface{}{"name": "Alice"}})
+//	server.StubGraphQL("GetUser", nil, mockforge.GraphQLErrors(
+//	    mockforge.GraphQLErrorCode("not authenticated", "UNAUTHENTICATED")))
+func (m *MockServer) StubGraphQL(operationName string, variables map[string]interface{}, response interface{}) error {
+	stub, err := m.graphQLStub(operationName, variables, response)
+	if err != nil {
+		return err
+	}
+	return m.AddStub(*stub)
+}
+
+// graphQLStub builds the HTTP stub StubGraphQL registers
+func (m *MockServer) graphQLStub(operationName string, variables map[string]interface{}, response interface{}) (*ResponseStub, error) {
+	if !isGraphQLName(operationName) {
+		return nil, NewInvalidConfigError("invalid GraphQL operation name", map[string]interface{}{"operation": operationName})
+	}
+
+	path := m.config.GraphQLPath
+	if path == "" {
+		path = defaultGraphQLPath
+	}
+
+	body := response
+	switch r := response.(type) {
+	case GraphQLResponse:
+	case *GraphQLResponse:
+		body = *r
+	default:
+		body = GraphQLResponse{Data: r}
+	}
+
+	builder := StubJSON(http.MethodPost, path, body).
+		When(`request.body.operationName == '` + operationName + `' || ` +
+			`request.body.query =~ '(^|[^_0-9A-Za-z])(query|mutation|subscription)[[:space:],]+` + operationName + `([^_0-9A-Za-z]|$)'`).
+		Priority(len(variables))
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		builder.WhenJSONPath("$.variables."+name, variables[name])
+	}
+
+	stub := builder.Build()
+	return &stub, nil
+}
@@ -0,0 +1,59 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStubGraphQL(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+
+	stub, err := server.graphQLStub("GetUser", map[string]interface{}{"id": "1"},
+		map[string]interface{}{"user": map[string]interface{}{"name": "Alice"}})
+	if err != nil {
+		t.Fatalf("Failed to build stub: %v", err)
+	}
+	if stub.Method != "POST" || stub.Path != "/graphql" || stub.Priority != 1 {
+		t.Errorf("Expected POST /graphql with priority 1, got %s %s %d", stub.Method, stub.Path, stub.Priority)
+	}
+	if err := stub.validate(); err != nil {
+		t.Errorf("Expected valid stub, got %v", err)
+	}
+	if stub.Match.JSONPaths["$.variables.id"] != "1" {
+		t.Errorf("Expected variables matcher, got %v", stub.Match.JSONPaths)
+	}
+	body, _ := json.Marshal(stub.Body)
+	if string(body) != `{"data":{"user":{"name":"Alice"}}}` {
+		t.Errorf("Expected data envelope, got %s", body)
+	}
+
+	// The query pattern recognizes the operation without operationName
+	pattern := regexp.MustCompile(`(^|[^_0-9A-Za-z])(query|mutation|subscription)[[:space:],]+GetUser([^_0-9A-Za-z]|$)`)
+	if !strings.Contains(stub.Match.Expression, pattern.String()) {
+		t.Errorf("Expected query pattern in %q", stub.Match.Expression)
+	}
+	for query, want := range map[string]bool{
+		"query GetUser($id: ID!) { user(id: $id) { name } }": true,
+		"\n  mutation\n GetUser { x }":                       true,
+		"query GetUsers { users { name } }":                  false,
+	} {
+		if pattern.MatchString(query) != want {
+			t.Errorf("Expected match %v for %q", want, query)
+		}
+	}
+
+	stub, err = server.graphQLStub("GetUser", nil, GraphQLErrors(GraphQLErrorCode("not authenticated", "UNAUTHENTICATED")))
+	if err != nil {
+		t.Fatalf("Failed to build stub: %v", err)
+	}
+	body, _ = json.Marshal(stub.Body)
+	if string(body) != `{"data":null,"errors":[{"message":"not authenticated","extensions":{"code":"UNAUTHENTICATED"}}]}` {
+		t.Errorf("Expected error response, got %s", body)
+	}
+
+	if _, err := server.graphQLStub("Get User'", nil, nil); err == nil {
+		t.Error("Expected error for invalid operation name")
+	}
+}
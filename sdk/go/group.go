@@ -0,0 +1,98 @@
+package mockforge
+
+import "sort"
+
+// VerificationGroup verifies requests across several named mock servers,
+// for integration tests with one MockForge instance per upstream. Ordering
+// across servers relies on their request timestamps, so the servers should
+// run on the same host.
+type VerificationGroup struct {
+	servers map[string]*MockServer
+}
+
+// GroupStep is a step of a cross-server sequence: a pattern matched on the
+// server added under Server
+type GroupStep struct {
+	Server  string
+	Pattern VerificationRequest
+}
+
+// NewVerificationGroup creates an empty verification group
+func NewVerificationGroup() *VerificationGroup {
+	return &VerificationGroup{servers: make(map[string]*MockServer)}
+}
+
+// Add adds server to the group under name, replacing any server already
+// added under that name
+func (g *VerificationGroup) Add(name string, server *MockServer) *VerificationGroup {
+	g.servers[name] = server
+	return g
+}
+
+// server returns the server added under name
+func (g *VerificationGroup) server(name string) (*MockServer, error) {
+	server, ok := g.servers[name]
+	if !ok {
+		return nil, NewInvalidConfigError("no server in verification group", map[string]interface{}{"server": name})
+	}
+	return server, nil
+}
+
+// names returns the names of the group's servers in order
+func (g *VerificationGroup) names() []string {
+	names := make([]string, 0, len(g.servers))
+	for name := range g.servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Verify verifies that the group's servers together received requests
+// matching pattern the expected number of times. Matches holds the matching
+// requests of every server.
+func (g *VerificationGroup) Verify(pattern VerificationRequest, expected VerificationCount) (*VerificationResult, error) {
+	combined := &VerificationResult{Expected: expected, Matches: []map[string]interface{}{}}
+	for _, name := range g.names() {
+		result, err := g.servers[name].verify(pattern, AtLeast(0))
+		if err != nil {
+			return nil, err
+		}
+		combined.Count += result.Count
+		combined.Matches = append(combined.Matches, result.Matches...)
+	}
+	combined.Matched = expected.Satisfied(combined.Count)
+	return combined, nil
+}
+
+// VerifySequence verifies that requests matching steps arrived in order
+// across the group's servers, e.g. that payments was charged before the
+// ledger was written. It accepts the same options as
+// MockServer.VerifySequence and reports failures the same way.
+//
+//	result, err := group.VerifySequence([]mockforge.GroupStep{
+//	    {Server: "payments", Pattern: mockforge.VerificationRequest{Method: "POST", Path: "/charges"}},
+//	    {Server: "ledger", Pattern: mockforge.VerificationRequest{Method: "POST", Path: "/entries"}},
+//	})
+func (g *VerificationGroup) VerifySequence(steps []GroupStep, opts ...SequenceOption) (*VerificationResult, error) {
+	sequence := make([]sequenceStep, len(steps))
+	for i, step := range steps {
+		server, err := g.server(step.Server)
+		if err != nil {
+			return nil, err
+		}
+		sequence[i] = sequenceStep{server: server, name: step.Server, pattern: step.Pattern}
+	}
+	return verifySequence(sequence, opts)
+}
+
+// ResetRequestJournals clears the request journal of every server in the
+// group
+func (g *VerificationGroup) ResetRequestJournals() error {
+	for _, name := range g.names() {
+		if err := g.servers[name].ResetRequestJournal(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// newJournalTestServer serves verification queries over logged, matching on
+// method and path
+func newJournalTestServer(t *testing.T, logged []map[string]interface{}) *MockServer {
+	t.Helper()
+	return newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pattern VerificationRequest `json:"pattern"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		matches := []map[string]interface{}{}
+		for i := len(logged) - 1; i >= 0; i-- {
+			if (body.Pattern.Method == "" || logged[i]["method"] == body.Pattern.Method) &&
+				(body.Pattern.Path == "" || logged[i]["path"] == body.Pattern.Path) {
+				matches = append(matches, logged[i])
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"matched": true, "count": len(matches), "matches": matches})
+	}))
+}
+
+func TestVerificationGroup(t *testing.T) {
+	// Both servers number their entries from 1, so IDs collide across them
+	payments := newJournalTestServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "POST", "path": "/charges"},
+	})
+	ledger := newJournalTestServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:01Z", "method": "POST", "path": "/entries"},
+		{"id": "2", "timestamp": "2024-01-01T00:00:02Z", "method": "POST", "path": "/charges"},
+	})
+	group := NewVerificationGroup().Add("payments", payments).Add("ledger", ledger)
+
+	charge := GroupStep{Server: "payments", Pattern: VerificationRequest{Method: "POST", Path: "/charges"}}
+	entry := GroupStep{Server: "ledger", Pattern: VerificationRequest{Method: "POST", Path: "/entries"}}
+
+	result, err := group.VerifySequence([]GroupStep{charge, entry})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if !result.Matched || result.Count != 2 {
+		t.Errorf("Expected the charge to precede the ledger entry, got %+v", result)
+	}
+
+	result, err = group.VerifySequence([]GroupStep{entry, charge})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Matched || result.FailedStep == nil || *result.FailedStep != 1 {
+		t.Fatalf("Expected step 1 to fail, got %+v", result)
+	}
+	if !strings.Contains(*result.ErrorMessage, "payments: POST /charges") {
+		t.Errorf("Expected the message to name the server, got %q", *result.ErrorMessage)
+	}
+
+	result, err = group.Verify(VerificationRequest{Method: "POST", Path: "/charges"}, Exactly(2))
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if !result.Matched || result.Count != 2 || len(result.Matches) != 2 {
+		t.Errorf("Expected 2 charges across servers, got %+v", result)
+	}
+
+	if _, err := group.VerifySequence([]GroupStep{{Server: "billing"}}); err == nil {
+		t.Error("Expected an error for an unknown server")
+	}
+}
@@ -0,0 +1,281 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GRPCCode is a gRPC status code, as defined by google.golang.org/grpc/codes
+type GRPCCode int
+
+// The gRPC status codes
+const (
+	GRPCOK                 GRPCCode = 0
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCAborted            GRPCCode = 10
+	GRPCOutOfRange         GRPCCode = 11
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCDataLoss           GRPCCode = 15
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+// grpcCodeNames are the status names the server config uses, by code
+var grpcCodeNames = [...]string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+// GRPCStatus is a non-OK status a gRPC stub answers with instead of a
+// response message
+type GRPCStatus struct {
+	Code    GRPCCode `json:"code"`
+	Message string   `json:"message,omitempty"`
+}
+
+// GRPCError returns a status with code and message, for passing to StubGRPC
+// as the response
+func GRPCError(code GRPCCode, message string) GRPCStatus {
+	return GRPCStatus{Code: code, Message: message}
+}
+
+// grpcMethodPattern matches "pkg.Service/Method"
+var grpcMethodPattern = regexp.MustCompile(`^/?([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)/([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// grpcOverride is a gRPC stub as a rule of the server config's
+// grpc.overrides, which the gRPC server loads at startup
+type grpcOverride struct {
+	Service  string               `yaml:"service"`
+	Method   string               `yaml:"method"`
+	Match    map[string]string    `yaml:"match,omitempty"`
+	Response grpcOverrideResponse `yaml:"response"`
+}
+
+// grpcOverrideResponse is the status or message a grpcOverride answers
+// with, or the messages it streams
+type grpcOverrideResponse struct {
+	Status  string           `yaml:"status,omitempty"`
+	Message string           `yaml:"message,omitempty"`
+	Body    interface{}      `yaml:"body,omitempty"`
+	Stream  []grpcStreamStep `yaml:"stream,omitempty"`
+}
+
+// RegisterProtoDescriptors registers a serialized FileDescriptorSet, as
+// written by protoc --descriptor_set_out (with --include_imports) or buf
+// build, so the server can serve its services. Once descriptors are
+// registered, StubGRPC rejects methods they do not declare.
+//
+// The gRPC server loads descriptors at startup, so register them before
+// Start. They replace the config file's grpc.proto_dir.
+func (m *MockServer) RegisterProtoDescriptors(descriptorSet []byte) error {
+	methods, err := parseDescriptorSet(descriptorSet)
+	if err != nil {
+		return NewInvalidConfigError(fmt.Sprintf("invalid descriptor set: %v", err), nil)
+	}
+	if len(methods) == 0 {
+		return NewInvalidConfigError("descriptor set declares no services", nil)
+	}
+	if m.IsRunning() {
+		return NewInvalidConfigError("proto descriptors must be registered before Start; the gRPC server loads them at startup", nil)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.grpcDescriptors = append(m.grpcDescriptors, descriptorSet)
+	if m.grpcMethods == nil {
+		m.grpcMethods = make(map[string]grpcMethod)
+	}
+	for _, method := range methods {
+		m.grpcMethods[method.FullName()] = method
+	}
+	return nil
+}
+
+// StubGRPC answers unary calls to method, given as "pkg.Service/Method",
+// with response. The response is the message as its protobuf JSON mapping,
+// e.g. a map or a struct with json tags, or a GRPCStatus to fail the call.
+//
+// If requestMatcher is non-empty, only calls whose request message has
+// those field values match; dotted keys address nested fields, and enum
+// fields match by number. Such stubs take precedence over ones without a
+// matcher.
+//
+// The gRPC server loads stubs at startup, so register them before Start.
+//
+//	server.StubGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "42"},
+//	    map[string]interface{}{"id": "42", "status": "SHIPPED"})
+//	server.StubGRPC("shop.v1.Orders/GetOrder", nil,
+//	    mockforge.GRPCError(mockforge.GRPCNotFound, "order not found"))
+func (m *MockServer) StubGRPC(method string, requestMatcher map[string]interface{}, response interface{}) error {
+	stub, err := m.grpcStub(method, requestMatcher, response)
+	if err != nil {
+		return err
+	}
+	if m.IsRunning() {
+		return NewInvalidConfigError("gRPC stubs must be registered before Start; the gRPC server loads them at startup", map[string]interface{}{"method": method})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.grpcOverrides = append(m.grpcOverrides, stub)
+	return nil
+}
+
+// grpcStub builds the server config rule of StubGRPC
+func (m *MockServer) grpcStub(method string, requestMatcher map[string]interface{}, response interface{}) (grpcOverride, error) {
+	service, name, _, err := m.resolveGRPCMethod(method)
+	if err != nil {
+		return grpcOverride{}, err
+	}
+
+	stub := grpcOverride{Service: service, Method: name}
+	for field, value := range requestMatcher {
+		matched, ok := grpcMatchValue(value)
+		if !ok {
+			return grpcOverride{}, NewInvalidConfigError("gRPC request matcher values must be strings, numbers, or booleans", map[string]interface{}{"method": method, "field": field})
+		}
+		if stub.Match == nil {
+			stub.Match = make(map[string]string, len(requestMatcher))
+		}
+		stub.Match[field] = matched
+	}
+
+	status, isStatus := response.(GRPCStatus)
+	if pointer, ok := response.(*GRPCStatus); ok && pointer != nil {
+		status, isStatus = *pointer, true
+	}
+	switch {
+	case isStatus && status.Code == GRPCOK:
+		return grpcOverride{}, NewInvalidConfigError("gRPC error status must not be OK", map[string]interface{}{"method": method})
+	case isStatus && (status.Code < 0 || int(status.Code) >= len(grpcCodeNames)):
+		return grpcOverride{}, NewInvalidConfigError("unknown gRPC status code", map[string]interface{}{"method": method, "code": int(status.Code)})
+	case isStatus:
+		stub.Response = grpcOverrideResponse{Status: grpcCodeNames[status.Code], Message: status.Message}
+	default:
+		body, err := grpcMessageBody(method, response)
+		if err != nil {
+			return grpcOverride{}, err
+		}
+		stub.Response.Body = body
+	}
+	return stub, nil
+}
+
+// grpcMessageBody converts a response message to the JSON object the server
+// config holds
+func grpcMessageBody(method string, message interface{}) (interface{}, error) {
+	// Round-trip through JSON so structs use their json tags
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to encode gRPC response: %v", err), map[string]interface{}{"method": method})
+	}
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	if _, ok := body.(map[string]interface{}); !ok {
+		return nil, NewInvalidConfigError("gRPC response must be a message", map[string]interface{}{"method": method})
+	}
+	return body, nil
+}
+
+// grpcMatchValue converts a request matcher value to the string the server
+// compares the field's value with
+func grpcMatchValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// resolveGRPCMethod splits "pkg.Service/Method" and, once descriptors are
+// registered, checks they declare it. declared is nil if none are.
+func (m *MockServer) resolveGRPCMethod(method string) (service, name string, declared *grpcMethod, err error) {
+	parts := grpcMethodPattern.FindStringSubmatch(method)
+	if parts == nil {
+		return "", "", nil, NewInvalidConfigError("gRPC method must be \"pkg.Service/Method\"", map[string]interface{}{"method": method})
+	}
+	service, name = parts[1], parts[2]
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.grpcMethods) == 0 {
+		return service, name, nil, nil
+	}
+	found, ok := m.grpcMethods[service+"/"+name]
+	if !ok {
+		return "", "", nil, NewInvalidConfigError("gRPC method is not declared by the registered descriptors", map[string]interface{}{"method": method, "known": knownGRPCMethods(m.grpcMethods)})
+	}
+	return service, name, &found, nil
+}
+
+// knownGRPCMethods lists registered methods for error details
+func knownGRPCMethods(methods map[string]grpcMethod) string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// GRPCService is a service the gRPC server exposes
+type GRPCService struct {
+	// Name is fully qualified, e.g. "shop.v1.Orders"
+	Name    string          `json:"name"`
+	Methods []GRPCMethodDef `json:"methods"`
+}
+
+// GRPCMethodDef is an RPC of a GRPCService. Message types are fully
+// qualified.
+type GRPCMethodDef struct {
+	Name            string `json:"name"`
+	InputType       string `json:"input_type"`
+	OutputType      string `json:"output_type"`
+	ClientStreaming bool   `json:"client_streaming"`
+	ServerStreaming bool   `json:"server_streaming"`
+}
+
+// ListGRPCServices returns the services the gRPC server exposes, from its
+// proto files and registered descriptors, sorted by name. Enable
+// MockServerConfig.GRPCReflection to let grpcurl and other reflection-based
+// clients discover the same services.
+func (m *MockServer) ListGRPCServices() ([]GRPCService, error) {
+	var result struct {
+		Services []GRPCService `json:"services"`
+	}
+	if err := m.adminJSON("list grpc services", http.MethodGet, "/__mockforge/api/grpc/services", nil, &result); err != nil {
+		return nil, err
+	}
+	services := result.Services
+	if services == nil {
+		services = []GRPCService{}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
@@ -0,0 +1,278 @@
+package mockforge
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// protoBytes encodes a length-delimited protobuf field
+func protoBytes(number int, value []byte) []byte {
+	out := []byte{byte(number<<3 | 2)}
+	for length := len(value); ; length >>= 7 {
+		if length < 0x80 {
+			out = append(out, byte(length))
+			break
+		}
+		out = append(out, byte(length)|0x80)
+	}
+	return append(out, value...)
+}
+
+// testDescriptorSet declares shop.v1.Orders with a unary GetOrder, a
+// server-streaming WatchOrders, and a client-streaming ImportOrders
+func testDescriptorSet() []byte {
+	method := func(name string, streaming ...byte) []byte {
+		out := append(protoBytes(1, []byte(name)), protoBytes(2, []byte(".shop.v1.GetOrderRequest"))...)
+		out = append(out, protoBytes(3, []byte(".shop.v1.Order"))...)
+		return append(out, streaming...)
+	}
+	service := append(protoBytes(1, []byte("Orders")), protoBytes(2, method("GetOrder"))...)
+	service = append(service, protoBytes(2, method("WatchOrders", 6<<3, 1))...)
+	service = append(service, protoBytes(2, method("ImportOrders", 5<<3, 1))...)
+	file := append(protoBytes(1, []byte("shop/v1/orders.proto")), protoBytes(2, []byte("shop.v1"))...)
+	file = append(file, protoBytes(4, protoBytes(1, []byte("GetOrderRequest")))...)
+	file = append(file, protoBytes(4, protoBytes(1, []byte("Order")))...)
+	file = append(file, protoBytes(6, service)...)
+	return protoBytes(1, file)
+}
+
+func TestParseDescriptorSet(t *testing.T) {
+	methods, err := parseDescriptorSet(testDescriptorSet())
+	if err != nil {
+		t.Fatalf("Failed to parse descriptor set: %v", err)
+	}
+	if len(methods) != 3 {
+		t.Fatalf("Expected 3 methods, got %d", len(methods))
+	}
+	get := methods[0]
+	if get.FullName() != "shop.v1.Orders/GetOrder" || get.InputType != "shop.v1.GetOrderRequest" || get.OutputType != "shop.v1.Order" {
+		t.Errorf("Unexpected unary method: %+v", get)
+	}
+	if !methods[1].ServerStreaming || methods[1].ClientStreaming {
+		t.Errorf("Expected server-streaming WatchOrders, got %+v", methods[1])
+	}
+	if !methods[2].ClientStreaming || methods[2].ServerStreaming {
+		t.Errorf("Expected client-streaming ImportOrders, got %+v", methods[2])
+	}
+
+	if _, err := parseDescriptorSet(testDescriptorSet()[:10]); err == nil {
+		t.Error("Expected error for truncated descriptor set")
+	}
+}
+
+func TestStubGRPC(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+
+	// Any method is accepted before descriptors are registered
+	if err := server.StubGRPC("other.Service/Call", nil, map[string]interface{}{}); err != nil {
+		t.Fatalf("Failed to stub method: %v", err)
+	}
+
+	if err := server.RegisterProtoDescriptors(testDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register descriptors: %v", err)
+	}
+	if err := server.StubGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "42", "customer.vip": true, "limit": float64(10)},
+		struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		}{"42", "SHIPPED"}); err != nil {
+		t.Fatalf("Failed to stub method: %v", err)
+	}
+	if err := server.StubGRPC("shop.v1.Orders/GetOrder", nil, GRPCError(GRPCNotFound, "order not found")); err != nil {
+		t.Fatalf("Failed to stub error: %v", err)
+	}
+	if len(server.grpcOverrides) != 3 {
+		t.Fatalf("Expected 3 stubs, got %d", len(server.grpcOverrides))
+	}
+	stub := server.grpcOverrides[1]
+	if stub.Service != "shop.v1.Orders" || stub.Method != "GetOrder" || stub.Match["customer.vip"] != "true" || stub.Match["limit"] != "10" {
+		t.Errorf("Unexpected stub: %+v", stub)
+	}
+	if body, _ := stub.Response.Body.(map[string]interface{}); body["status"] != "SHIPPED" {
+		t.Errorf("Expected the response by its json tags, got %v", stub.Response.Body)
+	}
+	if response := server.grpcOverrides[2].Response; response.Status != "NOT_FOUND" || response.Message != "order not found" || response.Body != nil {
+		t.Errorf("Expected NOT_FOUND status, got %+v", response)
+	}
+
+	for _, method := range []string{"shop.v1.Orders/DeleteOrder", "shop.v1.Orders.GetOrder", "GetOrder"} {
+		if err := server.StubGRPC(method, nil, map[string]interface{}{}); err == nil {
+			t.Errorf("Expected error for %q", method)
+		}
+	}
+	for name, response := range map[string]interface{}{
+		"OK status":    GRPCError(GRPCOK, ""),
+		"unknown code": GRPCError(GRPCCode(17), ""),
+		"non-message":  "SHIPPED",
+	} {
+		if err := server.StubGRPC("shop.v1.Orders/GetOrder", nil, response); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+	if err := server.StubGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"items": []string{"a"}}, map[string]interface{}{}); err == nil {
+		t.Error("Expected error for a list matcher")
+	}
+}
+
+func TestGRPCServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "mockforge.yaml")
+	os.WriteFile(configFile, []byte("http:\n  openapi_spec: api.yaml\ngrpc:\n  reflection: true\n  overrides:\n    - service: shop.v1.Orders\n      method: ListOrders\n      response:\n        status: UNAVAILABLE\n"), 0o644)
+	server := NewMockServer(MockServerConfig{ConfigFile: configFile})
+
+	// Without registered stubs the config file is used as is
+	if path, written, err := server.writeServerConfig(); err != nil || path != configFile || written != "" {
+		t.Fatalf("Expected the config file unchanged, got %q, %q, %v", path, written, err)
+	}
+
+	server.RegisterProtoDescriptors(testDescriptorSet())
+	server.StubGRPC("shop.v1.Orders/GetOrder", nil, GRPCError(GRPCNotFound, "order not found"))
+	server.StubGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "42"}, map[string]interface{}{"id": "42"})
+	path, written, err := server.writeServerConfig()
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	defer os.RemoveAll(written)
+
+	var config struct {
+		HTTP struct {
+			OpenAPISpec string `yaml:"openapi_spec"`
+		} `yaml:"http"`
+		GRPC struct {
+			Reflection bool           `yaml:"reflection"`
+			ProtoDir   string         `yaml:"proto_dir"`
+			Overrides  []grpcOverride `yaml:"overrides"`
+		} `yaml:"grpc"`
+	}
+	data, _ := os.ReadFile(path)
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Failed to parse written config: %v", err)
+	}
+	if config.HTTP.OpenAPISpec != filepath.Join(dir, "api.yaml") || !config.GRPC.Reflection {
+		t.Errorf("Expected the config file's settings kept, got %s", data)
+	}
+	if descriptors, _ := os.ReadFile(filepath.Join(config.GRPC.ProtoDir, "descriptors_1.binpb")); !bytes.Equal(descriptors, testDescriptorSet()) {
+		t.Errorf("Expected the descriptors in the proto directory, got %s", data)
+	}
+	if overrides := config.GRPC.Overrides; len(overrides) != 3 || overrides[0].Match["id"] != "42" || overrides[1].Response.Status != "NOT_FOUND" || overrides[2].Method != "ListOrders" {
+		t.Errorf("Expected matched stubs, then catch-all stubs, then the config file's rules, got %+v", overrides)
+	}
+
+	// The server's proto directory cannot hold both
+	os.WriteFile(configFile, []byte("grpc:\n  proto_dir: protos\n"), 0o644)
+	if _, _, err := server.writeServerConfig(); err == nil {
+		t.Error("Expected error for descriptors with a configured proto_dir")
+	}
+}
+
+func TestStubGRPCClientStream(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	if err := server.RegisterProtoDescriptors(testDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register descriptors: %v", err)
+	}
+
+	if err := server.StubGRPCClientStream("shop.v1.Orders/GetOrder", map[string]interface{}{}); err == nil {
+		t.Error("Expected error for unary method")
+	}
+	if err := server.StubGRPCClientStream("shop.v1.Orders/WatchOrders", map[string]interface{}{}); err == nil {
+		t.Error("Expected error for server-streaming method")
+	}
+	if err := server.StubGRPCClientStream("shop.v1.Orders/ImportOrders", GRPCError(GRPCResourceExhausted, "too many orders")); err != nil {
+		t.Fatalf("Failed to stub client stream: %v", err)
+	}
+	if len(server.grpcOverrides) != 1 || server.grpcOverrides[0].Method != "ImportOrders" || server.grpcOverrides[0].Response.Status != "RESOURCE_EXHAUSTED" {
+		t.Errorf("Expected a catch-all stub for the client stream, got %+v", server.grpcOverrides)
+	}
+}
+
+func TestGRPCStreamStub(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	if err := server.RegisterProtoDescriptors(testDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register descriptors: %v", err)
+	}
+
+	err := server.StubGRPCServerStream("shop.v1.Orders/WatchOrders", []StreamMessage{
+		StreamSend(map[string]interface{}{"status": "PACKED"}, 0),
+		StreamSend(map[string]interface{}{"status": "SHIPPED"}, 250*time.Millisecond),
+		StreamFail(GRPCUnavailable, "connection reset", 0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub server stream: %v", err)
+	}
+	data, _ := yaml.Marshal(server.grpcOverrides)
+	expected := `- service: shop.v1.Orders
+  method: WatchOrders
+  response:
+    stream:
+        - body:
+            status: PACKED
+        - body:
+            status: SHIPPED
+          delay_ms: 250
+        - status: UNAVAILABLE
+          message: connection reset
+`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	for name, messages := range map[string][]StreamMessage{
+		"empty":          nil,
+		"error not last": {StreamFail(GRPCInternal, "boom", 0), StreamSend(map[string]interface{}{}, 0)},
+		"negative delay": {StreamSend(map[string]interface{}{}, -time.Second)},
+		"after requests": {{Message: map[string]interface{}{}, AfterRequests: 1}},
+		"not a message":  {StreamSend("x", 0)},
+		"ok status":      {StreamFail(GRPCOK, "", 0)},
+	} {
+		if err := server.StubGRPCServerStream("shop.v1.Orders/WatchOrders", messages); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+	if err := server.StubGRPCServerStream("shop.v1.Orders/GetOrder", []StreamMessage{StreamSend(map[string]interface{}{}, 0)}); err == nil {
+		t.Error("Expected error for unary method")
+	}
+	if err := server.StubGRPCBidiStream("shop.v1.Orders/WatchOrders", []StreamMessage{StreamSend(map[string]interface{}{}, 0)}); err == nil {
+		t.Error("Expected error for server-streaming method")
+	}
+
+	// Without descriptors, any method can be a bidirectional stream
+	server = NewMockServer(MockServerConfig{})
+	err = server.StubGRPCBidiStream("chat.v1.Chat/Talk", []StreamMessage{
+		{Message: map[string]interface{}{"text": "hi"}, AfterRequests: 2},
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub bidi stream: %v", err)
+	}
+	if step := server.grpcOverrides[0].Response.Stream[0]; step.AfterRequests != 2 {
+		t.Errorf("Expected AfterRequests 2, got %+v", step)
+	}
+}
+
+func TestListGRPCServices(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/__mockforge/api/grpc/services" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"services":[
+			{"name":"shop.v1.Orders","methods":[{"name":"WatchOrders","input_type":"shop.v1.WatchRequest","output_type":"shop.v1.Order","client_streaming":false,"server_streaming":true}]},
+			{"name":"grpc.health.v1.Health","methods":[{"name":"Check","input_type":"grpc.health.v1.HealthCheckRequest","output_type":"grpc.health.v1.HealthCheckResponse"}]}]}`))
+	}))
+
+	services, err := server.ListGRPCServices()
+	if err != nil {
+		t.Fatalf("Failed to list services: %v", err)
+	}
+	if len(services) != 2 || services[0].Name != "grpc.health.v1.Health" {
+		t.Fatalf("Expected services sorted by name, got %+v", services)
+	}
+	if method := services[1].Methods[0]; method.Name != "WatchOrders" || !method.ServerStreaming || method.InputType != "shop.v1.WatchRequest" {
+		t.Errorf("Unexpected method: %+v", method)
+	}
+}
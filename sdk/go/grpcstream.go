@@ -0,0 +1,140 @@
+package mockforge
+
+import "time"
+
+// StreamMessage is one step of a streamed gRPC response: a message sent
+// after Delay, or, if Status is set, the error that ends the stream
+type StreamMessage struct {
+	Message interface{}
+	Delay   time.Duration
+	Status  *GRPCStatus
+	// AfterRequests, for bidirectional streams, holds the message until the
+	// client has sent this many messages in total
+	AfterRequests int
+}
+
+// StreamSend returns a step sending message after delay
+func StreamSend(message interface{}, delay time.Duration) StreamMessage {
+	return StreamMessage{Message: message, Delay: delay}
+}
+
+// StreamFail returns a step ending the stream with a status error after
+// delay, as servers do when they fail mid-stream
+func StreamFail(code GRPCCode, message string, delay time.Duration) StreamMessage {
+	status := GRPCError(code, message)
+	return StreamMessage{Status: &status, Delay: delay}
+}
+
+// grpcStreamStep is a StreamMessage as a step of a grpcOverride's stream
+type grpcStreamStep struct {
+	Body          interface{} `yaml:"body,omitempty"`
+	Status        string      `yaml:"status,omitempty"`
+	Message       string      `yaml:"message,omitempty"`
+	DelayMS       int64       `yaml:"delay_ms,omitempty"`
+	AfterRequests int         `yaml:"after_requests,omitempty"`
+}
+
+// StubGRPCServerStream answers calls to the server-streaming method with
+// messages, sent in order with their delays. The stream ends with OK after
+// the last message, unless a StreamFail step ends it first. Like StubGRPC,
+// it must be registered before Start.
+//
+//	server.StubGRPCServerStream("shop.v1.Orders/WatchOrders", []mockforge.StreamMessage{
+//	    mockforge.StreamSend(map[string]interface{}{"status": "PACKED"}, 0),
+//	    mockforge.StreamSend(map[string]interface{}{"status": "SHIPPED"}, time.Second),
+//	    mockforge.StreamFail(mockforge.GRPCUnavailable, "connection reset", 0),
+//	})
+func (m *MockServer) StubGRPCServerStream(method string, messages []StreamMessage) error {
+	return m.stubGRPCStream(method, false, messages)
+}
+
+// StubGRPCClientStream answers calls to the client-streaming method with
+// response, or a GRPCStatus, once the client closes its stream. Like
+// StubGRPC, it must be registered before Start.
+func (m *MockServer) StubGRPCClientStream(method string, response interface{}) error {
+	if _, _, declared, err := m.resolveGRPCMethod(method); err != nil {
+		return err
+	} else if declared != nil && !declared.ClientStreaming {
+		return NewInvalidConfigError("gRPC method is not client-streaming", map[string]interface{}{"method": method})
+	}
+	return m.StubGRPC(method, nil, response)
+}
+
+// StubGRPCBidiStream answers calls to the bidirectional-streaming method
+// with messages. The server sends them while the client is still sending;
+// set AfterRequests on a step to wait for the client's messages first. If
+// the client closes its stream before a step's AfterRequests is reached,
+// the stream ends with OK. Like StubGRPC, it must be registered before
+// Start.
+func (m *MockServer) StubGRPCBidiStream(method string, messages []StreamMessage) error {
+	return m.stubGRPCStream(method, true, messages)
+}
+
+// stubGRPCStream registers a streamed response for a server-streaming or,
+// if bidi is set, bidirectional method
+func (m *MockServer) stubGRPCStream(method string, bidi bool, messages []StreamMessage) error {
+	stub, err := m.grpcStreamStub(method, bidi, messages)
+	if err != nil {
+		return err
+	}
+	if m.IsRunning() {
+		return NewInvalidConfigError("gRPC stubs must be registered before Start; the gRPC server loads them at startup", map[string]interface{}{"method": method})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.grpcOverrides = append(m.grpcOverrides, stub)
+	return nil
+}
+
+// grpcStreamStub builds the server config rule of a streamed response
+func (m *MockServer) grpcStreamStub(method string, bidi bool, messages []StreamMessage) (grpcOverride, error) {
+	service, name, declared, err := m.resolveGRPCMethod(method)
+	if err != nil {
+		return grpcOverride{}, err
+	}
+	if declared != nil && (!declared.ServerStreaming || declared.ClientStreaming != bidi) {
+		kind := "server-streaming"
+		if bidi {
+			kind = "bidirectional-streaming"
+		}
+		return grpcOverride{}, NewInvalidConfigError("gRPC method is not "+kind, map[string]interface{}{"method": method})
+	}
+	if len(messages) == 0 {
+		return grpcOverride{}, NewInvalidConfigError("gRPC stream must have at least one message", map[string]interface{}{"method": method})
+	}
+
+	steps := make([]grpcStreamStep, len(messages))
+	for i, message := range messages {
+		details := map[string]interface{}{"method": method, "step": i}
+		switch {
+		case message.Delay < 0:
+			return grpcOverride{}, NewInvalidConfigError("stream delay must not be negative", details)
+		case message.AfterRequests < 0:
+			return grpcOverride{}, NewInvalidConfigError("stream AfterRequests must not be negative", details)
+		case message.AfterRequests > 0 && !bidi:
+			return grpcOverride{}, NewInvalidConfigError("AfterRequests requires a bidirectional stream", details)
+		case message.Status != nil && message.Status.Code == GRPCOK:
+			return grpcOverride{}, NewInvalidConfigError("gRPC error status must not be OK", details)
+		case message.Status != nil && (message.Status.Code < 0 || int(message.Status.Code) >= len(grpcCodeNames)):
+			return grpcOverride{}, NewInvalidConfigError("unknown gRPC status code", details)
+		case message.Status != nil && i != len(messages)-1:
+			return grpcOverride{}, NewInvalidConfigError("stream error must be the last step", details)
+		}
+
+		step := grpcStreamStep{DelayMS: message.Delay.Milliseconds(), AfterRequests: message.AfterRequests}
+		if message.Status != nil {
+			step.Status = grpcCodeNames[message.Status.Code]
+			step.Message = message.Status.Message
+		} else {
+			body, err := grpcMessageBody(method, message.Message)
+			if err != nil {
+				return grpcOverride{}, err
+			}
+			step.Body = body
+		}
+		steps[i] = step
+	}
+
+	return grpcOverride{Service: service, Method: name, Response: grpcOverrideResponse{Stream: steps}}, nil
+}
@@ -0,0 +1,103 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// VerifyGRPC verifies that method, given as "pkg.Service/Method", was called
+// the expected number of times with request messages matching
+// messageMatcher. The matcher holds field values in the message's protobuf
+// JSON mapping; dotted keys address nested fields, as in StubGRPC. An empty
+// matcher matches every call.
+//
+//	result, err := server.VerifyGRPC("shop.v1.Orders/GetOrder",
+//	    map[string]interface{}{"id": "42"}, mockforge.Exactly(1))
+//
+// Only calls with a single request message whose type the server knows are
+// recorded with their message; a non-empty matcher never matches others.
+// When too few calls match, NearMisses lists calls of the method with other
+// messages.
+func (m *MockServer) VerifyGRPC(method string, messageMatcher map[string]interface{}, expected VerificationCount) (*VerificationResult, error) {
+	service, name, _, err := m.resolveGRPCMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server journals gRPC calls under their HTTP/2 path
+	all, err := m.verify(VerificationRequest{Path: "/" + service + "/" + name}, AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	calls, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{Expected: expected, Matches: []map[string]interface{}{}}
+	var misses []NearMiss
+	for i, call := range calls {
+		differences := grpcMessageDifferences(messageMatcher, call.Metadata["request_message"])
+		if len(differences) == 0 {
+			result.Count++
+			result.Matches = append(result.Matches, all.Matches[i])
+		} else {
+			misses = append(misses, NearMiss{Request: call, Differences: differences})
+		}
+	}
+
+	result.Matched = expected.Satisfied(result.Count)
+	if !result.Matched {
+		message := fmt.Sprintf("expected %s to be called %s time(s) with matching messages, got %d", method, expected, result.Count)
+		result.ErrorMessage = &message
+		if expected.tooFew(result.Count) {
+			sort.SliceStable(misses, func(i, j int) bool {
+				return len(misses[i].Differences) < len(misses[j].Differences)
+			})
+			if len(misses) > nearMissLimit {
+				misses = misses[:nearMissLimit]
+			}
+			result.NearMisses = misses
+		}
+	}
+	m.recordVerification(fmt.Sprintf("VerifyGRPC %s %s", method, expected), result)
+	return result, nil
+}
+
+// grpcMessageDifferences describes the fields of a recorded JSON request
+// message that fail matcher
+func grpcMessageDifferences(matcher map[string]interface{}, message string) []string {
+	if len(matcher) == 0 {
+		return nil
+	}
+	var decoded interface{}
+	if message == "" || json.Unmarshal([]byte(message), &decoded) != nil {
+		return []string{"request message is not recorded"}
+	}
+
+	fields := make([]string, 0, len(matcher))
+	for field := range matcher {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var differences []string
+	for _, field := range fields {
+		actual, ok := jsonPathValue(decoded, "$."+field)
+		if !ok {
+			differences = append(differences, fmt.Sprintf("message has no %s", field))
+			continue
+		}
+		// Compare through JSON so 2 and 2.0 are equal
+		want, _ := json.Marshal(matcher[field])
+		var wantValue interface{}
+		json.Unmarshal(want, &wantValue)
+		if !reflect.DeepEqual(wantValue, actual) {
+			got, _ := json.Marshal(actual)
+			differences = append(differences, fmt.Sprintf("message %s: expected %s, got %s", field, want, got))
+		}
+	}
+	return differences
+}
@@ -0,0 +1,41 @@
+package mockforge
+
+import "testing"
+
+func TestVerifyGRPC(t *testing.T) {
+	server := newJournalTestServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "shop.v1.Orders/GetOrder", "path": "/shop.v1.Orders/GetOrder", "metadata": map[string]string{"request_message": `{"id":"41","options":{"expand":true}}`}},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "shop.v1.Orders/GetOrder", "path": "/shop.v1.Orders/GetOrder", "metadata": map[string]string{"request_message": `{"id":"42","options":{"expand":true}}`}},
+		{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "shop.v1.Orders/GetOrder", "path": "/shop.v1.Orders/GetOrder"},
+	})
+
+	result, err := server.VerifyGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "42", "options.expand": true}, Exactly(1))
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if !result.Matched || result.Count != 1 {
+		t.Errorf("Expected one matching call, got %+v", result)
+	}
+
+	result, err = server.VerifyGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "43"}, AtLeastOnce())
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Matched || len(result.NearMisses) != 3 {
+		t.Fatalf("Expected 3 near misses, got %+v", result)
+	}
+	// Most recent first
+	if result.NearMisses[0].Differences[0] != "request message is not recorded" ||
+		result.NearMisses[1].Differences[0] != `message id: expected "43", got "42"` {
+		t.Errorf("Unexpected near misses %+v", result.NearMisses)
+	}
+
+	result, err = server.VerifyGRPC("shop.v1.Orders/GetOrder", nil, Exactly(3))
+	if err != nil || !result.Matched {
+		t.Errorf("Expected an empty matcher to match every call, got %+v (%v)", result, err)
+	}
+
+	if _, err := server.VerifyGRPC("GetOrder", nil, Never()); err == nil {
+		t.Error("Expected an error for a method without a service")
+	}
+}
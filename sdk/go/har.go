@@ -0,0 +1,464 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HARImportOptions controls how ImportHAR turns captured traffic into stubs
+type HARImportOptions struct {
+	// Hosts, if set, limits the import to requests sent to these hosts,
+	// leaving out analytics and CDN traffic
+	Hosts []string
+	// MatchHeaders are request headers the stubs match on with the captured
+	// value, e.g. "Accept"; by default stubs match method, path, and query
+	MatchHeaders []string
+	// MatchBody makes stubs match the captured request body, so requests
+	// that differ only in their body get their own stubs
+	MatchBody bool
+	// ExcludeHeaders are response headers to leave out, on top of those
+	// that describe the captured connection (Date, Content-Length,
+	// Content-Encoding, Transfer-Encoding, Connection, Keep-Alive)
+	ExcludeHeaders []string
+	// RewriteHosts replaces captured hosts in response headers and bodies,
+	// e.g. {"api.example.com": "localhost:3000"}, so links and redirects
+	// point back at the mock
+	RewriteHosts map[string]string
+	// Sequence replays repeated requests' responses in captured order
+	// instead of answering all of them with the last response
+	Sequence bool
+}
+
+// harConnectionHeaders describe the captured connection, not the response
+var harConnectionHeaders = []string{"date", "content-length", "content-encoding", "transfer-encoding", "connection", "keep-alive"}
+
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Headers  []harHeader `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status  int         `json:"status"`
+		Headers []harHeader `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding,omitempty"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ImportHAR registers stubs answering the requests in a HAR capture, as
+// saved by browser developer tools or a recording proxy, with the captured
+// responses. Identical requests share one stub. In dry-run mode nothing is
+// registered and the import is recorded instead.
+//
+//	f, _ := os.Open("testdata/checkout.har")
+//	defer f.Close()
+//	err := server.ImportHAR(f, mockforge.HARImportOptions{
+//	    Hosts:        []string{"api.example.com"},
+//	    RewriteHosts: map[string]string{"api.example.com": "localhost:3000"},
+//	})
+func (m *MockServer) ImportHAR(r io.Reader, opts HARImportOptions) error {
+	var har harFile
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return NewInvalidConfigError(fmt.Sprintf("failed to parse HAR: %v", err), nil)
+	}
+	stubs, err := harStubs(har.Log.Entries, opts)
+	if err != nil {
+		return err
+	}
+
+	if m.isDryRun() {
+		targets := make([]string, len(stubs))
+		for i, stub := range stubs {
+			targets[i] = fmt.Sprintf("%s %s", stub.Method, stub.Path)
+		}
+		m.recordDryRun("import HAR", targets)
+		return nil
+	}
+	return m.StubAll(stubs)
+}
+
+// harStubs converts entries to stubs, one per distinct request in order of
+// first appearance
+func harStubs(entries []harEntry, opts HARImportOptions) ([]ResponseStub, error) {
+	hosts := make(map[string]bool, len(opts.Hosts))
+	for _, host := range opts.Hosts {
+		hosts[strings.ToLower(host)] = true
+	}
+	excluded := make(map[string]bool)
+	for _, name := range append(append([]string(nil), harConnectionHeaders...), opts.ExcludeHeaders...) {
+		excluded[strings.ToLower(name)] = true
+	}
+
+	var stubs []ResponseStub
+	index := make(map[string]int)
+	for _, entry := range entries {
+		// Aborted requests have no response
+		if entry.Response.Status == 0 {
+			continue
+		}
+		requestURL, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, NewInvalidConfigError(fmt.Sprintf("invalid HAR request URL: %v", err), map[string]interface{}{"url": entry.Request.URL})
+		}
+		if len(hosts) > 0 && !hosts[strings.ToLower(requestURL.Host)] {
+			continue
+		}
+
+		stub, err := harStub(entry, requestURL, opts, excluded)
+		if err != nil {
+			return nil, err
+		}
+		key, _ := json.Marshal([]interface{}{stub.Method, stub.Path, stub.Match})
+		i, seen := index[string(key)]
+		if !seen {
+			index[string(key)] = len(stubs)
+			stubs = append(stubs, stub)
+			continue
+		}
+
+		if !opts.Sequence || stub.BodyBytes != nil || stubs[i].BodyBytes != nil {
+			// The last capture of a request is the most current one
+			stubs[i].Status, stubs[i].Headers, stubs[i].SetCookies = stub.Status, stub.Headers, stub.SetCookies
+			stubs[i].Body, stubs[i].BodyBytes = stub.Body, stub.BodyBytes
+			continue
+		}
+		if stubs[i].Sequence == nil {
+			stubs[i].Sequence = []SequencedResponse{{Status: stubs[i].Status, Headers: stubs[i].Headers, Body: stubs[i].Body}}
+		}
+		stubs[i].Sequence = append(stubs[i].Sequence, SequencedResponse{Status: stub.Status, Headers: stub.Headers, Body: stub.Body})
+	}
+	return stubs, nil
+}
+
+// harStub converts one entry to a stub
+func harStub(entry harEntry, requestURL *url.URL, opts HARImportOptions, excluded map[string]bool) (ResponseStub, error) {
+	path := requestURL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	stub := ResponseStub{
+		Method: strings.ToUpper(entry.Request.Method),
+		Path:   path,
+		Status: entry.Response.Status,
+	}
+
+	match := &RequestMatch{}
+	for name, values := range requestURL.Query() {
+		if match.QueryParams == nil {
+			match.QueryParams = make(map[string]string)
+		}
+		match.QueryParams[name] = values[0]
+	}
+	for _, name := range opts.MatchHeaders {
+		for _, header := range entry.Request.Headers {
+			if strings.EqualFold(header.Name, name) {
+				if match.Headers == nil {
+					match.Headers = make(map[string]string)
+				}
+				match.Headers[name] = exactHeaderPattern(header.Value)
+				break
+			}
+		}
+	}
+	if opts.MatchBody && entry.Request.PostData != nil && entry.Request.PostData.Text != "" {
+		var body interface{}
+		if err := json.Unmarshal([]byte(entry.Request.PostData.Text), &body); err == nil {
+			match.BodyJSON = body
+		} else {
+			match.BodyPattern = "^" + regexp.QuoteMeta(entry.Request.PostData.Text) + "$"
+		}
+	}
+	if match.QueryParams != nil || match.Headers != nil || match.BodyJSON != nil || match.BodyPattern != "" {
+		stub.Match = match
+	}
+
+	// Headers such as Vary may repeat in a capture
+	for _, header := range entry.Response.Headers {
+		lower := strings.ToLower(header.Name)
+		if excluded[lower] || strings.HasPrefix(header.Name, ":") {
+			continue
+		}
+		value := rewriteHosts(header.Value, opts.RewriteHosts)
+		if lower == "set-cookie" {
+			stub.SetCookies = append(stub.SetCookies, value)
+			continue
+		}
+		if stub.Headers == nil {
+			stub.Headers = make(map[string]string)
+		}
+		if previous, ok := stub.Headers[header.Name]; ok {
+			value = previous + ", " + value
+		}
+		stub.Headers[header.Name] = value
+	}
+
+	content := entry.Response.Content
+	switch {
+	case content.Encoding == "base64":
+		data, err := base64.StdEncoding.DecodeString(content.Text)
+		if err != nil {
+			return stub, NewInvalidConfigError(fmt.Sprintf("invalid base64 HAR response body: %v", err), map[string]interface{}{"url": entry.Request.URL})
+		}
+		stub.BodyBytes = data
+	case strings.Contains(content.MimeType, "json"):
+		text := rewriteHosts(content.Text, opts.RewriteHosts)
+		var body interface{}
+		if err := json.Unmarshal([]byte(text), &body); err == nil {
+			stub.Body = body
+		} else {
+			stub.Body = text
+		}
+	case content.Text != "":
+		stub.Body = rewriteHosts(content.Text, opts.RewriteHosts)
+	}
+	return stub, nil
+}
+
+// rewriteHosts replaces every captured host in text, trying longer hosts
+// first so that a host is not rewritten inside a longer one
+func rewriteHosts(text string, rewrites map[string]string) string {
+	if len(rewrites) == 0 {
+		return text
+	}
+	hosts := make([]string, 0, len(rewrites))
+	for host := range rewrites {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if len(hosts[i]) != len(hosts[j]) {
+			return len(hosts[i]) > len(hosts[j])
+		}
+		return hosts[i] < hosts[j]
+	})
+	pairs := make([]string, 0, 2*len(hosts))
+	for _, host := range hosts {
+		pairs = append(pairs, host, rewrites[host])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// harLog is a HAR 1.2 document as written by ExportHAR
+type harLog struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []harExportEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harExportEntry struct {
+	StartedDateTime string            `json:"startedDateTime"`
+	Time            float64           `json:"time"`
+	Request         harExportRequest  `json:"request"`
+	Response        harExportResponse `json:"response"`
+	Cache           struct{}          `json:"cache"`
+	Timings         harTimings        `json:"timings"`
+}
+
+type harExportRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harHeader  `json:"cookies"`
+	Headers     []harHeader  `json:"headers"`
+	QueryString []harHeader  `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harExportResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []harHeader `json:"cookies"`
+	Headers     []harHeader `json:"headers"`
+	Content     harContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// ExportHAR writes the journal entries selected by filter to w as a HAR 1.2
+// capture, oldest first, which browser developer tools and HAR viewers can
+// open. The server does not record response bodies, so each response is
+// the one the serving stub defines for the served status, with templates
+// unexpanded; requests no stub served have an empty response body.
+//
+//	f, _ := os.Create("checkout.har")
+//	defer f.Close()
+//	err := server.ExportHAR(mockforge.RequestFilter{Path: "/api/*"}, f)
+func (m *MockServer) ExportHAR(filter RequestFilter, w io.Writer) error {
+	requests, err := m.GetRequests(filter)
+	if err != nil {
+		return err
+	}
+	stubs, err := m.stubsByID()
+	if err != nil {
+		return err
+	}
+
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator.Name = "mockforge-go"
+	har.Log.Creator.Version = Version
+	har.Log.Entries = make([]harExportEntry, 0, len(requests))
+	for _, request := range requests {
+		entry, err := m.harEntry(request, stubs[request.StubID])
+		if err != nil {
+			return err
+		}
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// harEntry describes a journal entry and the response of the stub that
+// served it, if any
+func (m *MockServer) harEntry(request RecordedRequest, stub *ResponseStub) (harExportEntry, error) {
+	ms := float64(request.ResponseTime) / float64(time.Millisecond)
+	entry := harExportEntry{
+		StartedDateTime: request.Timestamp.Add(-request.ResponseTime).UTC().Format(time.RFC3339Nano),
+		Time:            ms,
+		Timings:         harTimings{Wait: ms},
+	}
+
+	query := url.Values{}
+	for name, value := range request.Query {
+		query.Set(name, value)
+	}
+	requestURL := m.URL() + request.Path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	entry.Request = harExportRequest{
+		Method:      request.Method,
+		URL:         requestURL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harHeader{},
+		Headers:     harHeaders(request.Headers),
+		QueryString: harHeaders(request.Query),
+		HeadersSize: -1,
+		BodySize:    len(request.Body),
+	}
+	if len(request.Body) > 0 {
+		mimeType, _ := lookupHeader(request.Headers, "Content-Type")
+		entry.Request.PostData = &harPostData{MimeType: mimeType, Text: string(request.Body)}
+	}
+
+	entry.Response = harExportResponse{
+		Status:      request.Status,
+		StatusText:  http.StatusText(request.Status),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harHeader{},
+		Headers:     []harHeader{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	if stub == nil {
+		return entry, nil
+	}
+
+	headers, body := stub.responseHeaders(), stub.Body
+	// For sequenced stubs, use the first response with the served status
+	for _, response := range stub.Sequence {
+		if response.Status == request.Status {
+			headers, body = response.Headers, response.Body
+			break
+		}
+	}
+	entry.Response.Headers = harHeaders(headers)
+	for _, cookie := range stub.SetCookies {
+		entry.Response.Headers = append(entry.Response.Headers, harHeader{Name: "Set-Cookie", Value: cookie})
+	}
+	entry.Response.RedirectURL, _ = lookupHeader(headers, "Location")
+
+	content := harContent{}
+	content.MimeType, _ = lookupHeader(headers, "Content-Type")
+	switch {
+	case len(stub.BodyBytes) > 0:
+		content.Size = len(stub.BodyBytes)
+		content.Text = base64.StdEncoding.EncodeToString(stub.BodyBytes)
+		content.Encoding = "base64"
+	case body != nil:
+		text, ok := body.(string)
+		if !ok {
+			data, err := json.Marshal(body)
+			if err != nil {
+				return entry, fmt.Errorf("failed to encode response body: %w", err)
+			}
+			text = string(data)
+			if content.MimeType == "" {
+				content.MimeType = "application/json"
+			}
+		}
+		content.Size = len(text)
+		content.Text = text
+	}
+	entry.Response.Content = content
+	entry.Response.BodySize = content.Size
+	return entry, nil
+}
+
+// harHeaders lists a header or query map as HAR name/value pairs, sorted
+func harHeaders(values map[string]string) []harHeader {
+	pairs := make([]harHeader, 0, len(values))
+	for _, name := range sortedStringKeys(values) {
+		pairs = append(pairs, harHeader{Name: name, Value: values[name]})
+	}
+	return pairs
+}
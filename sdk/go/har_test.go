@@ -0,0 +1,116 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testHAR = `{"log": {"entries": [
+  {"request": {"method": "GET", "url": "https://api.example.com/orders?page=1", "headers": [{"name": "Accept", "value": "application/json"}]},
+   "response": {"status": 200, "headers": [{"name": "Date", "value": "Mon"}, {"name": "Link", "value": "<https://api.example.com/orders?page=2>"}, {"name": "Set-Cookie", "value": "sid=1"}],
+     "content": {"mimeType": "application/json", "text": "{\"next\":\"https://api.example.com/orders?page=2\"}"}}},
+  {"request": {"method": "GET", "url": "https://cdn.example.com/app.js"},
+   "response": {"status": 200, "content": {"mimeType": "text/javascript", "text": "x"}}},
+  {"request": {"method": "GET", "url": "https://api.example.com/orders?page=1"},
+   "response": {"status": 500, "content": {"mimeType": "text/plain", "text": "oops"}}},
+  {"request": {"method": "GET", "url": "https://api.example.com/logo.png"},
+   "response": {"status": 200, "content": {"mimeType": "image/png", "text": "iVBORw==", "encoding": "base64"}}},
+  {"request": {"method": "GET", "url": "https://api.example.com/aborted"},
+   "response": {"status": 0, "content": {}}}
+]}}`
+
+func TestImportHAR(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	err := server.ImportHAR(strings.NewReader(testHAR), HARImportOptions{
+		Hosts:        []string{"api.example.com"},
+		MatchHeaders: []string{"Accept"},
+		RewriteHosts: map[string]string{"api.example.com": "localhost:3000"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import HAR: %v", err)
+	}
+	// The Accept header tells the two /orders captures apart
+	if len(server.stubs) != 3 {
+		t.Fatalf("Expected 3 stubs, got %+v", server.stubs)
+	}
+	orders := server.stubs[0]
+	if orders.Path != "/orders" || orders.Match.QueryParams["page"] != "1" || orders.Match.Headers["Accept"] != "^application/json$" {
+		t.Errorf("Unexpected stub %+v", orders)
+	}
+	if _, ok := orders.Headers["Date"]; ok || orders.Headers["Link"] != "<https://localhost:3000/orders?page=2>" {
+		t.Errorf("Expected filtered, rewritten headers, got %v", orders.Headers)
+	}
+	if len(orders.SetCookies) != 1 || orders.Body.(map[string]interface{})["next"] != "https://localhost:3000/orders?page=2" {
+		t.Errorf("Expected cookies and a rewritten JSON body, got %+v", orders)
+	}
+	if server.stubs[2].Path != "/logo.png" || len(server.stubs[2].BodyBytes) != 4 {
+		t.Errorf("Expected a binary body, got %+v", server.stubs[2])
+	}
+
+	// Without header matching the repeated request becomes a sequence
+	server = NewMockServer(MockServerConfig{})
+	if err := server.ImportHAR(strings.NewReader(testHAR), HARImportOptions{Hosts: []string{"api.example.com"}, Sequence: true}); err != nil {
+		t.Fatalf("Failed to import HAR: %v", err)
+	}
+	if len(server.stubs) != 2 || len(server.stubs[0].Sequence) != 2 || server.stubs[0].Sequence[1].Status != 500 {
+		t.Errorf("Expected a sequenced stub, got %+v", server.stubs)
+	}
+
+	if err := server.ImportHAR(strings.NewReader("not json"), HARImportOptions{}); err == nil {
+		t.Error("Expected an error for an invalid HAR")
+	}
+}
+
+func TestExportHAR(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/__mockforge/api/mocks" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"mocks": []map[string]interface{}{{
+					"id": "create-order", "method": "POST", "path": "/orders", "status_code": 201,
+					"response": map[string]interface{}{"body": map[string]string{"id": "42"}, "headers": map[string]string{"Content-Type": "application/json"}},
+				}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   2,
+			"matches": []map[string]interface{}{
+				{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/health", "status_code": 404, "response_time_ms": 1, "query_params": map[string]string{"v": "1"}},
+				{"id": "1", "timestamp": "2024-01-01T00:00:00.5Z", "method": "POST", "path": "/orders", "status_code": 201, "response_time_ms": 500, "body": `{"sku":"a"}`,
+					"headers": map[string]string{"content-type": "application/json"}, "metadata": map[string]string{"stub_id": "create-order"}},
+			},
+		})
+	}))
+
+	var out bytes.Buffer
+	if err := server.ExportHAR(RequestFilter{}, &out); err != nil {
+		t.Fatalf("Failed to export HAR: %v", err)
+	}
+	var har harLog
+	if err := json.Unmarshal(out.Bytes(), &har); err != nil {
+		t.Fatalf("Failed to parse HAR: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Fatalf("Expected a HAR 1.2 log of 2 entries, got %s", out.String())
+	}
+
+	order := har.Log.Entries[0]
+	if order.StartedDateTime != "2024-01-01T00:00:00Z" || order.Time != 500 {
+		t.Errorf("Expected the request start and duration, got %s and %v", order.StartedDateTime, order.Time)
+	}
+	if order.Request.URL != server.URL()+"/orders" || order.Request.PostData == nil || order.Request.PostData.Text != `{"sku":"a"}` {
+		t.Errorf("Unexpected request %+v", order.Request)
+	}
+	if order.Response.Status != 201 || order.Response.Content.Text != `{"id":"42"}` || order.Response.Content.MimeType != "application/json" {
+		t.Errorf("Expected the stub's response, got %+v", order.Response)
+	}
+
+	health := har.Log.Entries[1]
+	if health.Request.URL != server.URL()+"/health?v=1" || health.Response.Status != 404 || health.Response.Content.Text != "" {
+		t.Errorf("Expected an empty 404 for the unmatched request, got %+v", health)
+	}
+}
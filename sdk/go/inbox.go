@@ -0,0 +1,292 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email is a message captured by the SMTP server, parsed for assertions
+type Email struct {
+	ID   string
+	From string
+	// To are the SMTP envelope recipients, which include Bcc addresses
+	To      []string
+	Subject string
+	Headers textproto.MIMEHeader
+	// Text and HTML are the decoded text/plain and text/html bodies
+	Text        string
+	HTML        string
+	Attachments []EmailAttachment
+	ReceivedAt  time.Time
+	// Raw is the message as received
+	Raw []byte
+}
+
+// EmailAttachment is a file attached to an Email
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// EmailFilter selects captured emails. Empty fields match everything;
+// matching is case-insensitive.
+type EmailFilter struct {
+	// To matches emails with this envelope recipient
+	To string
+	// From matches the envelope sender
+	From string
+	// Subject matches emails whose subject contains this text
+	Subject string
+	// BodyContains matches emails whose text or HTML body contains this text
+	BodyContains string
+}
+
+// matches reports whether email passes the filter
+func (f EmailFilter) matches(email *Email) bool {
+	if f.To != "" {
+		found := false
+		for _, to := range email.To {
+			if strings.EqualFold(to, f.To) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.From != "" && !strings.EqualFold(email.From, f.From) {
+		return false
+	}
+	if f.Subject != "" && !containsFold(email.Subject, f.Subject) {
+		return false
+	}
+	if f.BodyContains != "" && !containsFold(email.Text, f.BodyContains) && !containsFold(email.HTML, f.BodyContains) {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether s contains substr, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// SMTPAddr returns the host:port of MockForge's SMTP server, enabled with
+// MockServerConfig.SMTP. Point the code under test's SMTP settings here.
+func (m *MockServer) SMTPAddr() (string, error) {
+	m.portMutex.RLock()
+	defer m.portMutex.RUnlock()
+	if m.smtpPort == 0 {
+		return "", NewInvalidConfigError("SMTP server not running; set MockServerConfig.SMTP", nil)
+	}
+	return net.JoinHostPort(m.host, strconv.Itoa(m.smtpPort)), nil
+}
+
+// IMAPAddr returns the host:port of the IMAP reader, enabled with
+// MockServerConfig.IMAP. It serves each recipient's mail as the INBOX of
+// the user named by the address; any password is accepted.
+func (m *MockServer) IMAPAddr() (string, error) {
+	m.portMutex.RLock()
+	defer m.portMutex.RUnlock()
+	if m.imapPort == 0 {
+		return "", NewInvalidConfigError("IMAP server not running; set MockServerConfig.IMAP", nil)
+	}
+	return net.JoinHostPort(m.host, strconv.Itoa(m.imapPort)), nil
+}
+
+// POP3Addr returns the host:port of the POP3 reader, enabled with
+// MockServerConfig.POP3. The user name selects the recipient's maildrop;
+// any password is accepted.
+func (m *MockServer) POP3Addr() (string, error) {
+	m.portMutex.RLock()
+	defer m.portMutex.RUnlock()
+	if m.pop3Port == 0 {
+		return "", NewInvalidConfigError("POP3 server not running; set MockServerConfig.POP3", nil)
+	}
+	return net.JoinHostPort(m.host, strconv.Itoa(m.pop3Port)), nil
+}
+
+// DeliverEmail sends message, a complete RFC 5322 message, to the SMTP
+// server, which captures it and delivers it to each recipient's mailbox.
+// Use it to seed the mail that code reading over IMAP or POP3 expects.
+func (m *MockServer) DeliverEmail(from string, to []string, message []byte) error {
+	addr, err := m.SMTPAddr()
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(addr, nil, from, to, message); err != nil {
+		return fmt.Errorf("failed to deliver email: %w", err)
+	}
+	return nil
+}
+
+// storedEmail is a message in the SMTP server's mailbox, as the admin API
+// returns it
+type storedEmail struct {
+	ID         string            `json:"id"`
+	From       string            `json:"from"`
+	To         []string          `json:"to"`
+	Subject    string            `json:"subject"`
+	Body       string            `json:"body"`
+	Headers    map[string]string `json:"headers"`
+	ReceivedAt time.Time         `json:"received_at"`
+	Raw        []byte            `json:"raw"`
+}
+
+// ListEmails returns the emails captured by the SMTP server that match
+// filter, in order of arrival
+func (m *MockServer) ListEmails(filter EmailFilter) ([]Email, error) {
+	var stored []storedEmail
+	if err := m.adminJSON("list emails", http.MethodGet, "/__mockforge/api/smtp/mailbox", nil, &stored); err != nil {
+		return nil, err
+	}
+	emails := []Email{}
+	for _, message := range stored {
+		email := parseEmail(message)
+		if filter.matches(email) {
+			emails = append(emails, *email)
+		}
+	}
+	return emails, nil
+}
+
+// GetEmail returns the captured email with id
+func (m *MockServer) GetEmail(id string) (*Email, error) {
+	var stored storedEmail
+	if err := m.adminJSON("get email", http.MethodGet, "/__mockforge/api/smtp/mailbox/"+url.PathEscape(id), nil, &stored); err != nil {
+		return nil, err
+	}
+	return parseEmail(stored), nil
+}
+
+// ClearEmails discards every captured email. In dry-run mode the emails are
+// kept and the clear is recorded instead.
+func (m *MockServer) ClearEmails() error {
+	if m.isDryRun() {
+		emails, err := m.ListEmails(EmailFilter{})
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for _, email := range emails {
+			ids = append(ids, email.ID)
+		}
+		m.recordDryRun("clear emails", ids)
+		return nil
+	}
+	return m.adminJSON("clear emails", http.MethodDelete, "/__mockforge/api/smtp/mailbox", nil, nil)
+}
+
+// parseEmail parses a captured message. Parts that fail to parse are left
+// out rather than failing, so malformed mail can still be asserted on, and
+// a message stored without its raw form keeps the server's parse.
+func parseEmail(message storedEmail) *Email {
+	email := &Email{
+		ID:         message.ID,
+		From:       message.From,
+		To:         message.To,
+		Subject:    message.Subject,
+		Headers:    textproto.MIMEHeader{},
+		Text:       message.Body,
+		ReceivedAt: message.ReceivedAt,
+		Raw:        message.Raw,
+	}
+	for name, value := range message.Headers {
+		email.Headers.Set(name, value)
+	}
+	if len(message.Raw) == 0 {
+		return email
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(message.Raw))
+	if err != nil {
+		return email
+	}
+	email.Headers = textproto.MIMEHeader(parsed.Header)
+	email.Subject = decodeHeader(parsed.Header.Get("Subject"))
+	email.Text = ""
+	email.parsePart(textproto.MIMEHeader(parsed.Header), parsed.Body)
+	return email
+}
+
+// parsePart adds a MIME part's bodies and attachments to the email,
+// descending into multipart containers
+func (e *Email) parsePart(header textproto.MIMEHeader, body io.Reader) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err != nil {
+				return
+			}
+			e.parsePart(part.Header, part)
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	switch {
+	case disposition == "attachment" || filename != "":
+		e.Attachments = append(e.Attachments, EmailAttachment{Filename: decodeHeader(filename), ContentType: mediaType, Data: data})
+	case mediaType == "text/html" && e.HTML == "":
+		e.HTML = string(data)
+	case mediaType == "text/plain" && e.Text == "":
+		e.Text = string(data)
+	}
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeHeader decodes RFC 2047 encoded words, returning the input if it
+// is malformed
+func decodeHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// String summarizes the email for test failure messages
+func (e Email) String() string {
+	return fmt.Sprintf("%s from %s to %s: %q", e.ID, e.From, strings.Join(e.To, ", "), e.Subject)
+}
@@ -0,0 +1,168 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestEmailInbox(t *testing.T) {
+	message := strings.Join([]string{
+		"From: shop@example.com",
+		"To: alice@example.com",
+		"Subject: =?UTF-8?Q?Your_order_=E2=9C=93?=",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Order 42 has sh=",
+		"ipped.",
+		"--inner",
+		"Content-Type: text/html",
+		"",
+		"<p>Order 42 has shipped.</p>",
+		"--inner--",
+		"--outer",
+		"Content-Type: application/pdf",
+		`Content-Disposition: attachment; filename="invoice.pdf"`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		"JVBERi0xLjQK",
+		"--outer--",
+		"",
+	}, "\r\n")
+	// The server sends the raw message as an array of bytes
+	rawBytes := make([]int, len(message))
+	for i := range rawBytes {
+		rawBytes[i] = int(message[i])
+	}
+	raw, _ := json.Marshal(rawBytes)
+	stored := `[{"id":"email-1","from":"shop@example.com","to":["alice@example.com"],"subject":"ignored",` +
+		`"body":"","headers":{},"received_at":"2024-05-01T10:00:00Z","raw":` + string(raw) + `},` +
+		`{"id":"email-2","from":"news@example.com","to":["bob@example.com"],"subject":"Newsletter",` +
+		`"body":"Hello\r\n","headers":{"Subject":"Newsletter"},"received_at":"2024-05-01T10:01:00Z"}]`
+
+	cleared := false
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete && r.URL.Path == "/__mockforge/api/smtp/mailbox":
+			cleared = true
+		case r.URL.Path == "/__mockforge/api/smtp/mailbox":
+			w.Write([]byte(stored))
+		case r.URL.Path == "/__mockforge/api/smtp/mailbox/email-2":
+			var emails []json.RawMessage
+			json.Unmarshal([]byte(stored), &emails)
+			w.Write(emails[1])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	if _, err := server.SMTPAddr(); err == nil {
+		t.Error("Expected an error without an SMTP server")
+	}
+
+	emails, err := server.ListEmails(EmailFilter{To: "Alice@example.com", BodyContains: "has shipped"})
+	if err != nil {
+		t.Fatalf("Failed to list emails: %v", err)
+	}
+	if len(emails) != 1 {
+		t.Fatalf("Expected 1 email, got %v", emails)
+	}
+	email := emails[0]
+	if email.Subject != "Your order ✓" {
+		t.Errorf("Expected decoded subject, got %q", email.Subject)
+	}
+	if email.Text != "Order 42 has shipped." || email.HTML != "<p>Order 42 has shipped.</p>" {
+		t.Errorf("Unexpected bodies: %q, %q", email.Text, email.HTML)
+	}
+	if len(email.Attachments) != 1 || email.Attachments[0].Filename != "invoice.pdf" || string(email.Attachments[0].Data) != "%PDF-1.4\n" {
+		t.Errorf("Unexpected attachments: %+v", email.Attachments)
+	}
+	if email.Headers.Get("From") != "shop@example.com" || !email.ReceivedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected From header and arrival time, got %v at %v", email.Headers, email.ReceivedAt)
+	}
+
+	// Without the raw message, the server's own parse is kept
+	newsletter, err := server.GetEmail("email-2")
+	if err != nil || newsletter.Subject != "Newsletter" || newsletter.Text != "Hello\r\n" {
+		t.Errorf("Expected newsletter, got %v (%v)", newsletter, err)
+	}
+	if _, err := server.GetEmail("missing"); err == nil {
+		t.Error("Expected error for a missing email")
+	}
+
+	if err := server.ClearEmails(); err != nil || !cleared {
+		t.Errorf("Expected the mailbox cleared, got %v", err)
+	}
+}
+
+func TestSMTPServerConfig(t *testing.T) {
+	server := NewMockServer(MockServerConfig{SMTP: true, SMTPPort: 2525})
+	path, dir, err := server.writeServerConfig()
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	var config struct {
+		SMTP map[string]interface{} `yaml:"smtp"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	want := map[string]interface{}{"enabled": true, "enable_mailbox": true, "port": 2525, "host": "127.0.0.1"}
+	if !reflect.DeepEqual(config.SMTP, want) {
+		t.Errorf("Expected smtp section %v, got %v", want, config.SMTP)
+	}
+}
+
+func TestMailReaderServerConfig(t *testing.T) {
+	server := NewMockServer(MockServerConfig{IMAP: true, POP3: true})
+	path, dir, err := server.writeServerConfig()
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	var config struct {
+		SMTP map[string]interface{} `yaml:"smtp"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	want := map[string]interface{}{
+		"enabled": true, "enable_mailbox": true, "port": 0, "host": "127.0.0.1",
+		"imap_port": 0, "pop3_port": 0,
+	}
+	if !reflect.DeepEqual(config.SMTP, want) {
+		t.Errorf("Expected smtp section %v, got %v", want, config.SMTP)
+	}
+
+	if _, err := server.IMAPAddr(); err == nil {
+		t.Error("Expected an error for IMAPAddr before Start")
+	}
+	if _, err := server.POP3Addr(); err == nil {
+		t.Error("Expected an error for POP3Addr before Start")
+	}
+}
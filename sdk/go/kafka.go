@@ -0,0 +1,155 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// KafkaRecord is a record in a topic of the Kafka mock
+type KafkaRecord struct {
+	Key     string
+	Value   []byte
+	Headers map[string]string
+	// Partition, Offset, and Timestamp are set on records read from the
+	// broker; ProduceRecords assigns partitions by key, as producers do
+	Partition int
+	Offset    int64
+	Timestamp time.Time
+}
+
+// KafkaMatcher selects records. Empty fields match everything.
+type KafkaMatcher struct {
+	Key string
+	// ValueContains matches records whose value contains these bytes
+	ValueContains string
+	// Headers must all be present with these values
+	Headers map[string]string
+}
+
+// matches reports whether record passes the matcher
+func (k KafkaMatcher) matches(record KafkaRecord) bool {
+	if k.Key != "" && record.Key != k.Key {
+		return false
+	}
+	if k.ValueContains != "" && !bytes.Contains(record.Value, []byte(k.ValueContains)) {
+		return false
+	}
+	for name, value := range k.Headers {
+		if got, ok := record.Headers[name]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// KafkaMock controls the server's Kafka broker, which is enabled in the
+// server's config file. Clients connect to BootstrapServers.
+type KafkaMock struct {
+	server *MockServer
+}
+
+// Kafka returns the controller of the server's Kafka broker
+func (m *MockServer) Kafka() *KafkaMock {
+	return &KafkaMock{server: m}
+}
+
+// BootstrapServers returns the host:port Kafka clients bootstrap from, or an
+// empty string if the server has not reported a Kafka broker
+func (k *KafkaMock) BootstrapServers() string {
+	k.server.portMutex.RLock()
+	defer k.server.portMutex.RUnlock()
+	if k.server.kafkaPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", k.server.host, k.server.kafkaPort)
+}
+
+// ProduceRecords appends records to topic, creating it if needed, so
+// consumers under test receive them
+func (k *KafkaMock) ProduceRecords(topic string, records []KafkaRecord) error {
+	if topic == "" {
+		return NewInvalidConfigError("Kafka topic must not be empty", nil)
+	}
+	if len(records) == 0 {
+		return NewInvalidConfigError("at least one Kafka record is required", map[string]interface{}{"topic": topic})
+	}
+
+	messages := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		message := map[string]interface{}{"topic": topic, "value": string(record.Value)}
+		if record.Key != "" {
+			message["key"] = record.Key
+		}
+		if len(record.Headers) > 0 {
+			message["headers"] = record.Headers
+		}
+		messages[i] = message
+	}
+	body := map[string]interface{}{"messages": messages, "delay_ms": 0}
+	return k.server.adminJSON("produce kafka records", http.MethodPost, "/__mockforge/api/kafka/produce/batch", body, nil)
+}
+
+// Records returns the records in topic, oldest first. A topic nothing was
+// produced to has no records.
+func (k *KafkaMock) Records(topic string) ([]KafkaRecord, error) {
+	var result struct {
+		Messages []struct {
+			Partition int               `json:"partition"`
+			Offset    int64             `json:"offset"`
+			Timestamp int64             `json:"timestamp"`
+			Key       *string           `json:"key"`
+			Value     string            `json:"value"`
+			Headers   map[string]string `json:"headers"`
+		} `json:"messages"`
+	}
+	err := k.server.adminJSON("list kafka records", http.MethodGet, "/__mockforge/api/kafka/topics/"+url.PathEscape(topic)+"/messages", nil, &result)
+	if err != nil {
+		// The broker answers 404 for topics that were never created
+		if isAdminStatus(err, http.StatusNotFound) {
+			return []KafkaRecord{}, nil
+		}
+		return nil, err
+	}
+
+	records := make([]KafkaRecord, len(result.Messages))
+	for i, message := range result.Messages {
+		value, err := base64.StdEncoding.DecodeString(message.Value)
+		if err != nil {
+			return nil, NewAdminAPIError("list kafka records", "invalid record value", err)
+		}
+		records[i] = KafkaRecord{
+			Value:     value,
+			Headers:   message.Headers,
+			Partition: message.Partition,
+			Offset:    message.Offset,
+			Timestamp: time.UnixMilli(message.Timestamp),
+		}
+		if message.Key != nil {
+			records[i].Key = *message.Key
+		}
+	}
+	return records, nil
+}
+
+// VerifyProduced asserts how many records in topic match matcher, to check
+// what the service under test published
+func (k *KafkaMock) VerifyProduced(topic string, matcher KafkaMatcher, count VerificationCount) error {
+	records, err := k.Records(topic)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, record := range records {
+		if matcher.matches(record) {
+			n++
+		}
+	}
+	if !count.Satisfied(n) {
+		return fmt.Errorf("expected %s matching record(s) in Kafka topic %q, got %d of %d", count, topic, n, len(records))
+	}
+	return nil
+}
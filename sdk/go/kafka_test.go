@@ -0,0 +1,73 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestKafkaMock(t *testing.T) {
+	var produced map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/__mockforge/api/kafka/produce/batch":
+			json.NewDecoder(r.Body).Decode(&produced)
+			w.Write([]byte(`{"success":true}`))
+		case "/__mockforge/api/kafka/topics/orders/messages":
+			w.Write([]byte(`{"topic":"orders","messages":[
+				{"partition":0,"offset":0,"timestamp":1700000000000,"key":"42","value":"eyJzdGF0dXMiOiJwYWlkIn0=","headers":{"type":"OrderPaid"}},
+				{"partition":1,"offset":0,"timestamp":1700000000001,"key":null,"value":"aGVsbG8=","headers":{}}]}`))
+		default:
+			http.Error(w, `{"error":"Topic not found"}`, http.StatusNotFound)
+		}
+	}))
+	kafka := server.Kafka()
+
+	err := kafka.ProduceRecords("payments", []KafkaRecord{{Key: "42", Value: []byte(`{"amount":10}`), Headers: map[string]string{"type": "PaymentRequested"}}})
+	if err != nil {
+		t.Fatalf("Failed to produce records: %v", err)
+	}
+	messages, _ := produced["messages"].([]interface{})
+	if len(messages) != 1 || produced["delay_ms"] != float64(0) {
+		t.Fatalf("Expected 1 message without delay, got %v", produced)
+	}
+	if message := messages[0].(map[string]interface{}); message["topic"] != "payments" || message["key"] != "42" || message["value"] != `{"amount":10}` {
+		t.Errorf("Unexpected message: %v", message)
+	}
+
+	records, err := kafka.Records("orders")
+	if err != nil {
+		t.Fatalf("Failed to list records: %v", err)
+	}
+	if len(records) != 2 || records[0].Key != "42" || string(records[0].Value) != `{"status":"paid"}` || records[1].Partition != 1 {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+
+	if err := kafka.VerifyProduced("orders", KafkaMatcher{Key: "42", Headers: map[string]string{"type": "OrderPaid"}}, Exactly(1)); err != nil {
+		t.Errorf("Expected verification to pass, got %v", err)
+	}
+	if err := kafka.VerifyProduced("orders", KafkaMatcher{ValueContains: "refunded"}, AtLeastOnce()); err == nil || !strings.Contains(err.Error(), "got 0 of 2") {
+		t.Errorf("Expected verification failure, got %v", err)
+	}
+	if err := kafka.VerifyProduced("unknown", KafkaMatcher{}, Never()); err != nil {
+		t.Errorf("Expected unknown topic to have no records, got %v", err)
+	}
+}
+
+func TestParseProtocolPorts(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	server.parsePortsFromOutput(strings.NewReader("⚡ gRPC server listening on localhost:50051\n📨 Kafka broker listening on 0.0.0.0:9092\n📁 FTP server listening on 127.0.0.1:2121\n🔌 TCP server listening on 127.0.0.1:9999\n"))
+	if server.GRPCAddress() != "127.0.0.1:50051" {
+		t.Errorf("Expected gRPC address, got %q", server.GRPCAddress())
+	}
+	if server.Kafka().BootstrapServers() != "127.0.0.1:9092" {
+		t.Errorf("Expected Kafka address, got %q", server.Kafka().BootstrapServers())
+	}
+	if server.FTP().Addr() != "127.0.0.1:2121" {
+		t.Errorf("Expected FTP address, got %q", server.FTP().Addr())
+	}
+	if addr, err := server.TCPAddr(); err != nil || addr != "127.0.0.1:9999" {
+		t.Errorf("Expected TCP address, got %q (%v)", addr, err)
+	}
+}
@@ -0,0 +1,160 @@
+package mockforge
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// LatencyDistribution is the shape of a stub's simulated latency
+type LatencyDistribution string
+
+const (
+	// LatencyFixed delays every response by the same amount
+	LatencyFixed LatencyDistribution = "fixed"
+	// LatencyUniform picks a delay uniformly between a minimum and maximum
+	LatencyUniform LatencyDistribution = "uniform"
+	// LatencyNormal picks a delay from a normal distribution, clamped at zero
+	LatencyNormal LatencyDistribution = "normal"
+	// LatencyLogNormal picks a delay from a log-normal distribution, giving
+	// the long right tail typical of real services
+	LatencyLogNormal LatencyDistribution = "lognormal"
+	// LatencyPercentiles picks delays matching a P50/P95/P99 table
+	LatencyPercentiles LatencyDistribution = "percentiles"
+)
+
+// LatencySpec describes the simulated latency of a stub. All values are in
+// milliseconds; build specs with FixedLatency, UniformLatency, NormalLatency,
+// LogNormalLatency, or PercentileLatency.
+type LatencySpec struct {
+	Distribution LatencyDistribution `json:"distribution"`
+	// FixedMs is the delay of a fixed distribution
+	FixedMs float64 `json:"fixed_ms,omitempty"`
+	// MinMs and MaxMs bound a uniform distribution
+	MinMs float64 `json:"min_ms,omitempty"`
+	MaxMs float64 `json:"max_ms,omitempty"`
+	// MeanMs and StdDevMs describe a normal or log-normal distribution. For
+	// log-normal they are the mean and standard deviation of the delay itself,
+	// not of its logarithm.
+	MeanMs   float64 `json:"mean_ms,omitempty"`
+	StdDevMs float64 `json:"std_dev_ms,omitempty"`
+	// P50Ms, P95Ms, and P99Ms are the percentiles of a percentile table
+	P50Ms float64 `json:"p50_ms,omitempty"`
+	P95Ms float64 `json:"p95_ms,omitempty"`
+	P99Ms float64 `json:"p99_ms,omitempty"`
+}
+
+// FixedLatency delays every response by d
+func FixedLatency(d time.Duration) *LatencySpec {
+	return &LatencySpec{Distribution: LatencyFixed, FixedMs: durationMs(d)}
+}
+
+// UniformLatency delays responses by a random amount between min and max
+func UniformLatency(min, max time.Duration) *LatencySpec {
+	return &LatencySpec{Distribution: LatencyUniform, MinMs: durationMs(min), MaxMs: durationMs(max)}
+}
+
+// NormalLatency delays responses by a normally distributed amount
+func NormalLatency(mean, stddev time.Duration) *LatencySpec {
+	return &LatencySpec{Distribution: LatencyNormal, MeanMs: durationMs(mean), StdDevMs: durationMs(stddev)}
+}
+
+// LogNormalLatency delays responses by a log-normally distributed amount with
+// the given mean and standard deviation
+func LogNormalLatency(mean, stddev time.Duration) *LatencySpec {
+	return &LatencySpec{Distribution: LatencyLogNormal, MeanMs: durationMs(mean), StdDevMs: durationMs(stddev)}
+}
+
+// PercentileLatency delays responses so that their P50, P95, and P99 match
+// the given values
+func PercentileLatency(p50, p95, p99 time.Duration) *LatencySpec {
+	return &LatencySpec{
+		Distribution: LatencyPercentiles,
+		P50Ms:        durationMs(p50),
+		P95Ms:        durationMs(p95),
+		P99Ms:        durationMs(p99),
+	}
+}
+
+// Validate checks the spec's parameters are consistent
+func (l *LatencySpec) Validate() error {
+	details := map[string]interface{}{"distribution": string(l.Distribution)}
+
+	switch l.Distribution {
+	case LatencyFixed:
+		if l.FixedMs < 0 {
+			return NewInvalidConfigError("fixed latency must not be negative", details)
+		}
+	case LatencyUniform:
+		if l.MinMs < 0 || l.MaxMs < l.MinMs {
+			return NewInvalidConfigError("uniform latency requires 0 <= min <= max", details)
+		}
+	case LatencyNormal:
+		if l.MeanMs < 0 || l.StdDevMs < 0 {
+			return NewInvalidConfigError("normal latency requires a non-negative mean and standard deviation", details)
+		}
+	case LatencyLogNormal:
+		if l.MeanMs <= 0 || l.StdDevMs < 0 {
+			return NewInvalidConfigError("log-normal latency requires a positive mean and non-negative standard deviation", details)
+		}
+	case LatencyPercentiles:
+		if l.P50Ms < 0 || l.P95Ms < l.P50Ms || l.P99Ms < l.P95Ms {
+			return NewInvalidConfigError("percentile latency requires 0 <= p50 <= p95 <= p99", details)
+		}
+	default:
+		return NewInvalidConfigError(fmt.Sprintf("unknown latency distribution %q", l.Distribution), details)
+	}
+
+	return nil
+}
+
+// Sample draws one delay from the distribution using rng. The server samples
+// latencies itself; Sample lets tests and in-process presets reproduce them.
+func (l *LatencySpec) Sample(rng *rand.Rand) time.Duration {
+	var ms float64
+
+	switch l.Distribution {
+	case LatencyFixed:
+		ms = l.FixedMs
+	case LatencyUniform:
+		ms = l.MinMs + rng.Float64()*(l.MaxMs-l.MinMs)
+	case LatencyNormal:
+		ms = l.MeanMs + rng.NormFloat64()*l.StdDevMs
+	case LatencyLogNormal:
+		// Convert the delay's mean and deviation into the parameters of its
+		// logarithm
+		sigma2 := math.Log(1 + (l.StdDevMs*l.StdDevMs)/(l.MeanMs*l.MeanMs))
+		mu := math.Log(l.MeanMs) - sigma2/2
+		ms = math.Exp(mu + rng.NormFloat64()*math.Sqrt(sigma2))
+	case LatencyPercentiles:
+		ms = l.percentile(rng.Float64())
+	}
+
+	return time.Duration(math.Max(ms, 0) * float64(time.Millisecond))
+}
+
+// percentile interpolates the inverse CDF of a percentile table linearly
+// through (0, 0), (0.5, P50), (0.95, P95), (0.99, P99), and (1, P99 + the
+// P95-P99 spread)
+func (l *LatencySpec) percentile(q float64) float64 {
+	points := [][2]float64{
+		{0, 0},
+		{0.5, l.P50Ms},
+		{0.95, l.P95Ms},
+		{0.99, l.P99Ms},
+		{1, l.P99Ms + (l.P99Ms - l.P95Ms)},
+	}
+	for i := 1; i < len(points); i++ {
+		lo, hi := points[i-1], points[i]
+		if q <= hi[0] {
+			return lo[1] + (q-lo[0])/(hi[0]-lo[0])*(hi[1]-lo[1])
+		}
+	}
+	return points[len(points)-1][1]
+}
+
+// durationMs converts d to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,58 @@
+package mockforge
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLatencySpecSample(t *testing.T) {
+	spec := PercentileLatency(20*time.Millisecond, 100*time.Millisecond, 400*time.Millisecond)
+	rng := rand.New(rand.NewSource(1))
+
+	samples := make([]time.Duration, 10000)
+	for i := range samples {
+		samples[i] = spec.Sample(rng)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	for _, tc := range []struct {
+		quantile float64
+		expected time.Duration
+	}{
+		{0.50, 20 * time.Millisecond},
+		{0.95, 100 * time.Millisecond},
+		{0.99, 400 * time.Millisecond},
+	} {
+		got := samples[int(tc.quantile*float64(len(samples)))]
+		if got < tc.expected*9/10 || got > tc.expected*11/10 {
+			t.Errorf("Expected p%.0f near %v, got %v", tc.quantile*100, tc.expected, got)
+		}
+	}
+}
+
+func TestLatencyMockConfig(t *testing.T) {
+	t.Run("fixed latency uses latency_ms", func(t *testing.T) {
+		config := NewStubBuilder("GET", "/").Latency(150).Build().mockConfig()
+		if config["latency_ms"] != 150 {
+			t.Errorf("Expected latency_ms 150, got %v", config["latency_ms"])
+		}
+	})
+
+	t.Run("distributions use latency spec", func(t *testing.T) {
+		config := NewStubBuilder("GET", "/").LatencyNormal(100*time.Millisecond, 20*time.Millisecond).Build().mockConfig()
+		spec, ok := config["latency"].(*LatencySpec)
+		if !ok || spec.Distribution != LatencyNormal || spec.MeanMs != 100 || spec.StdDevMs != 20 {
+			t.Errorf("Expected normal latency spec, got %v", config["latency"])
+		}
+	})
+
+	t.Run("rejects inconsistent specs", func(t *testing.T) {
+		server := NewMockServer(MockServerConfig{})
+		stub := NewStubBuilder("GET", "/").LatencyUniform(time.Second, time.Millisecond).Build()
+		if err := server.AddStub(stub); err == nil {
+			t.Error("Expected error for min > max")
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package mockforge
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LatencyStats summarizes the response times of journal entries, as
+// measured by the server to millisecond precision
+type LatencyStats struct {
+	Count int
+	Min   time.Duration
+	Max   time.Duration
+	P50   time.Duration
+	P95   time.Duration
+}
+
+// String formats the stats for failure messages
+func (s LatencyStats) String() string {
+	return fmt.Sprintf("%d request(s), min %v, p50 %v, p95 %v, max %v", s.Count, s.Min, s.P50, s.P95, s.Max)
+}
+
+// RequestLatencyStats returns the response time statistics of the requests
+// matching pattern. Count is zero, and the durations are zero, if nothing
+// matched.
+func (m *MockServer) RequestLatencyStats(pattern VerificationRequest) (LatencyStats, error) {
+	result, err := m.verify(pattern, AtLeast(0))
+	if err != nil {
+		return LatencyStats{}, err
+	}
+	logged, err := decodeLoggedRequests(result.Matches)
+	if err != nil {
+		return LatencyStats{}, err
+	}
+
+	latencies := make([]time.Duration, len(logged))
+	for i, entry := range logged {
+		latencies[i] = time.Duration(entry.ResponseTimeMs) * time.Millisecond
+	}
+	return latencyStats(latencies), nil
+}
+
+// latencyStats computes stats over latencies, using nearest-rank
+// percentiles
+func latencyStats(latencies []time.Duration) LatencyStats {
+	if len(latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p int) time.Duration {
+		rank := (p*len(sorted) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return sorted[rank-1]
+	}
+	return LatencyStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		P50:   percentile(50),
+		P95:   percentile(95),
+	}
+}
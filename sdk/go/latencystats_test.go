@@ -0,0 +1,31 @@
+package mockforge
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRequestLatencyStats(t *testing.T) {
+	logged := make([]map[string]interface{}, 0, 20)
+	for i := 1; i <= 20; i++ {
+		logged = append(logged, map[string]interface{}{
+			"id": fmt.Sprint(i), "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/search", "response_time_ms": i * 10,
+		})
+	}
+	server := newJournalTestServer(t, logged)
+
+	stats, err := server.RequestLatencyStats(VerificationRequest{Path: "/search"})
+	if err != nil {
+		t.Fatalf("Failed to get latency stats: %v", err)
+	}
+	expected := LatencyStats{Count: 20, Min: 10 * time.Millisecond, Max: 200 * time.Millisecond, P50: 100 * time.Millisecond, P95: 190 * time.Millisecond}
+	if stats != expected {
+		t.Errorf("Expected %s, got %s", expected, stats)
+	}
+
+	stats, err = server.RequestLatencyStats(VerificationRequest{Path: "/other"})
+	if err != nil || stats != (LatencyStats{}) {
+		t.Errorf("Expected empty stats, got %s (%v)", stats, err)
+	}
+}
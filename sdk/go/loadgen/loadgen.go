@@ -0,0 +1,334 @@
+// Package loadgen generates HTTP load from Go, for quick latency checks in
+// test suites without installing k6. It follows the load shapes of
+// mockforge bench, so a scenario tuned here behaves the same when moved to
+// a full k6 run, and attacks a mock server or a real target alike:
+//
+//	result, err := loadgen.Attack(ctx, loadgen.Config{
+//	    BaseURL:  server.URL(),
+//	    Targets:  []loadgen.Target{{Method: "GET", URL: "/orders/42"}},
+//	    Scenario: loadgen.Constant,
+//	    VUs:      20,
+//	    Duration: 5 * time.Second,
+//	})
+//	if result.Percentile(95) > 100*time.Millisecond { ... }
+//
+// Each virtual user sends the targets in order, over and over, like one
+// iteration of a bench script after another. Set Rate for an open-model
+// run at a fixed request rate instead.
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// Target is one request of the attack
+type Target struct {
+	Method string
+	// URL is absolute, or a path resolved against Config.BaseURL
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Config configures an attack
+type Config struct {
+	// BaseURL prefixes targets given as paths, e.g. server.URL()
+	BaseURL string
+	Targets []Target
+	// Scenario defaults to RampUp, as with mockforge bench
+	Scenario Scenario
+	// VUs is the peak number of virtual users; defaults to 10
+	VUs int
+	// Duration defaults to one minute
+	Duration time.Duration
+	// Rate, when set, sends that many requests per second regardless of
+	// response times, using up to VUs concurrent requests; Scenario is
+	// ignored, like bench's --rps
+	Rate int
+	// Pause is each virtual user's wait after a request. Bench scripts
+	// pause one second; the default of none measures peak throughput.
+	Pause time.Duration
+	// Client defaults to a client pooling a connection per virtual user
+	Client *http.Client
+}
+
+// Result summarizes an attack. A request fails without a complete response or
+// a status outside 2xx.
+type Result struct {
+	Requests int
+	Failures int
+	// Dropped counts requests a Rate attack skipped because every virtual
+	// user was busy
+	Dropped  int
+	Duration time.Duration
+	// StatusCodes counts responses by status
+	StatusCodes map[int]int
+	// Errors counts requests without a complete response, by error message
+	Errors map[string]int
+
+	latencies []time.Duration
+}
+
+// RPS returns the achieved request rate
+func (r *Result) RPS() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Duration.Seconds()
+}
+
+// ErrorRate returns the fraction of failed requests, from 0 to 1
+func (r *Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Failures) / float64(r.Requests)
+}
+
+// Percentile returns the latency below which p percent of the responses
+// fell, e.g. Percentile(95); zero without responses
+func (r *Result) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	index := int(p/100*float64(len(r.latencies))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(r.latencies) {
+		index = len(r.latencies) - 1
+	}
+	return r.latencies[index]
+}
+
+// Mean returns the average latency of the responses
+func (r *Result) Mean() time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, latency := range r.latencies {
+		total += latency
+	}
+	return total / time.Duration(len(r.latencies))
+}
+
+// Min returns the fastest response's latency
+func (r *Result) Min() time.Duration {
+	return r.Percentile(0)
+}
+
+// Max returns the slowest response's latency
+func (r *Result) Max() time.Duration {
+	return r.Percentile(100)
+}
+
+// Attack sends load described by cfg until its Duration elapses or ctx is
+// done, and summarizes the responses
+func Attack(ctx context.Context, cfg Config) (*Result, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, mockforge.NewInvalidConfigError("an attack requires at least one target", nil)
+	}
+	if cfg.Scenario == "" {
+		cfg.Scenario = RampUp
+	}
+	if cfg.VUs <= 0 {
+		cfg.VUs = 10
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = time.Minute
+	}
+	stages, err := cfg.Scenario.Stages(cfg.Duration, cfg.VUs)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := cfg.requests()
+	if err != nil {
+		return nil, err
+	}
+	client := cfg.Client
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = cfg.VUs
+		defer transport.CloseIdleConnections()
+		client = &http.Client{Transport: transport}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	a := &attack{ctx: ctx, client: client, requests: requests, result: &Result{
+		StatusCodes: make(map[int]int),
+		Errors:      make(map[string]int),
+	}}
+	started := time.Now()
+	if cfg.Rate > 0 {
+		a.atRate(cfg.Rate, cfg.VUs)
+	} else {
+		a.ramping(cfg, stages)
+	}
+	a.result.Duration = time.Since(started)
+
+	sort.Slice(a.result.latencies, func(i, j int) bool {
+		return a.result.latencies[i] < a.result.latencies[j]
+	})
+	return a.result, nil
+}
+
+// requests resolves the targets' URLs and validates them
+func (c Config) requests() ([]Target, error) {
+	resolved := make([]Target, len(c.Targets))
+	for i, target := range c.Targets {
+		if target.Method == "" {
+			target.Method = http.MethodGet
+		}
+		if !strings.Contains(target.URL, "://") {
+			if c.BaseURL == "" {
+				return nil, mockforge.NewInvalidConfigError("targets given as paths require a BaseURL", map[string]interface{}{"url": target.URL})
+			}
+			target.URL = strings.TrimSuffix(c.BaseURL, "/") + "/" + strings.TrimPrefix(target.URL, "/")
+		}
+		if _, err := http.NewRequest(target.Method, target.URL, nil); err != nil {
+			return nil, mockforge.NewInvalidConfigError(err.Error(), map[string]interface{}{"url": target.URL})
+		}
+		resolved[i] = target
+	}
+	return resolved, nil
+}
+
+// attack is the state of a running Attack
+type attack struct {
+	ctx      context.Context
+	client   *http.Client
+	requests []Target
+
+	mu     sync.Mutex
+	result *Result
+}
+
+// ramping runs a closed-model attack whose active virtual users follow the
+// scenario's stages
+func (a *attack) ramping(cfg Config, stages []Stage) {
+	var active atomic.Int64
+	start := cfg.Scenario.startVUs(cfg.VUs)
+	active.Store(int64(start))
+	began := time.Now()
+
+	var wg sync.WaitGroup
+	for vu := 0; vu < cfg.VUs; vu++ {
+		wg.Add(1)
+		go func(vu int) {
+			defer wg.Done()
+			for next := 0; a.ctx.Err() == nil; {
+				if int64(vu) >= active.Load() {
+					a.wait(10 * time.Millisecond)
+					continue
+				}
+				a.send(a.requests[next])
+				next = (next + 1) % len(a.requests)
+				if cfg.Pause > 0 {
+					a.wait(cfg.Pause)
+				}
+			}
+		}(vu)
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+			active.Store(int64(activeVUs(stages, start, time.Since(began))))
+		}
+	}
+}
+
+// atRate runs an open-model attack at rate requests per second
+func (a *attack) atRate(rate, vus int) {
+	slots := make(chan struct{}, vus)
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	for next := 0; ; next = (next + 1) % len(a.requests) {
+		select {
+		case <-a.ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			a.mu.Lock()
+			a.result.Dropped++
+			a.mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(target Target) {
+			defer func() { <-slots; wg.Done() }()
+			a.send(target)
+		}(a.requests[next])
+	}
+}
+
+// send makes one request and records its outcome. Requests cut short by
+// the end of the attack are not counted.
+func (a *attack) send(target Target) {
+	req, err := http.NewRequestWithContext(a.ctx, target.Method, target.URL, bytes.NewReader(target.Body))
+	if err != nil {
+		return
+	}
+	for name, values := range target.Header {
+		req.Header[name] = values
+	}
+
+	began := time.Now()
+	resp, err := a.client.Do(req)
+	if err == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	latency := time.Since(began)
+	if err != nil && (a.ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.result.Requests++
+	if err != nil {
+		a.result.Failures++
+		a.result.Errors[err.Error()]++
+		return
+	}
+	a.result.StatusCodes[resp.StatusCode]++
+	a.result.latencies = append(a.result.latencies, latency)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.result.Failures++
+	}
+}
+
+// wait sleeps for d or until the attack ends
+func (a *attack) wait(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-a.ctx.Done():
+	case <-timer.C:
+	}
+}
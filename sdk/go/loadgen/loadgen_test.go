@@ -0,0 +1,112 @@
+package loadgen
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScenarioStages(t *testing.T) {
+	stages, err := RampUp.Stages(time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Failed to compute stages: %v", err)
+	}
+	// The stages mockforge bench generates for --scenario ramp-up --duration 1m --vus 10
+	expected := []Stage{{10 * time.Second, 2}, {10 * time.Second, 5}, {20 * time.Second, 10}, {20 * time.Second, 0}}
+	if !reflect.DeepEqual(stages, expected) {
+		t.Errorf("Expected %v, got %v", expected, stages)
+	}
+
+	if scenario, err := ParseScenario("ramp_up"); err != nil || scenario != RampUp {
+		t.Errorf("Expected ramp_up to parse as RampUp, got %q, %v", scenario, err)
+	}
+	if _, err := ParseScenario("burst"); err == nil {
+		t.Error("Expected error for unknown scenario")
+	}
+}
+
+func TestActiveVUs(t *testing.T) {
+	stages := []Stage{{10 * time.Second, 10}, {10 * time.Second, 10}, {10 * time.Second, 0}}
+	for _, tc := range []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{0, 0},
+		{5 * time.Second, 5},
+		{15 * time.Second, 10},
+		{27 * time.Second, 3},
+		{time.Minute, 0},
+	} {
+		if got := activeVUs(stages, 0, tc.elapsed); got != tc.want {
+			t.Errorf("Expected %d VUs at %v, got %d", tc.want, tc.elapsed, got)
+		}
+	}
+}
+
+func TestAttack(t *testing.T) {
+	var hits int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	defer target.Close()
+
+	result, err := Attack(context.Background(), Config{
+		BaseURL:  target.URL,
+		Targets:  []Target{{URL: "/orders/42"}, {Method: http.MethodGet, URL: target.URL + "/missing"}},
+		Scenario: Constant,
+		VUs:      2,
+		Duration: 300 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Attack failed: %v", err)
+	}
+	if result.Requests == 0 || result.Requests > int(atomic.LoadInt32(&hits)) {
+		t.Fatalf("Expected requests counted for responses received, got %d of %d", result.Requests, hits)
+	}
+	if result.StatusCodes[http.StatusOK] == 0 || result.StatusCodes[http.StatusNotFound] == 0 {
+		t.Errorf("Expected both targets to be hit, got %v", result.StatusCodes)
+	}
+	if result.Failures != result.StatusCodes[http.StatusNotFound] {
+		t.Errorf("Expected 404s to count as failures, got %d failures for %v", result.Failures, result.StatusCodes)
+	}
+	if result.Min() > result.Percentile(95) || result.Percentile(95) > result.Max() || result.RPS() <= 0 {
+		t.Errorf("Inconsistent latencies: min %v, p95 %v, max %v, %v rps", result.Min(), result.Percentile(95), result.Max(), result.RPS())
+	}
+}
+
+func TestAttackAtRate(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	result, err := Attack(context.Background(), Config{
+		Targets:  []Target{{URL: target.URL}},
+		Rate:     50,
+		VUs:      5,
+		Duration: 500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Attack failed: %v", err)
+	}
+	// 50 requests per second for half a second, allowing for scheduling
+	if result.Requests < 15 || result.Requests > 26 {
+		t.Errorf("Expected about 25 requests, got %d", result.Requests)
+	}
+}
+
+func TestAttackRequiresTargets(t *testing.T) {
+	if _, err := Attack(context.Background(), Config{}); err == nil {
+		t.Error("Expected error without targets")
+	}
+	if _, err := Attack(context.Background(), Config{Targets: []Target{{URL: "/orders"}}}); err == nil {
+		t.Error("Expected error for a path target without BaseURL")
+	}
+}
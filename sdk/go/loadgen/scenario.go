@@ -0,0 +1,124 @@
+package loadgen
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// Scenario is a load shape, named like mockforge bench's --scenario values
+type Scenario string
+
+const (
+	// Constant holds VUs virtual users for the whole run
+	Constant Scenario = "constant"
+	// RampUp climbs to VUs in two steps, holds, then ramps down
+	RampUp Scenario = "ramp-up"
+	// Spike jumps from a tenth of VUs to all of them and back
+	Spike Scenario = "spike"
+	// Stress adds a fifth of VUs per step to find the breaking point
+	Stress Scenario = "stress"
+	// Soak holds VUs for a long run with a short ramp either side
+	Soak Scenario = "soak"
+)
+
+// ParseScenario reads a scenario name, accepting the same spellings as
+// mockforge bench
+func ParseScenario(name string) (Scenario, error) {
+	switch strings.ToLower(name) {
+	case "constant":
+		return Constant, nil
+	case "ramp-up", "ramp_up", "rampup":
+		return RampUp, nil
+	case "spike":
+		return Spike, nil
+	case "stress":
+		return Stress, nil
+	case "soak":
+		return Soak, nil
+	}
+	return "", mockforge.NewInvalidConfigError(fmt.Sprintf("unknown load scenario %q", name), map[string]interface{}{"scenarios": "constant, ramp-up, spike, stress, soak"})
+}
+
+// Stage moves the number of active virtual users linearly to Target over
+// Duration, like a k6 ramping-vus stage
+type Stage struct {
+	Duration time.Duration
+	Target   int
+}
+
+// Stages returns the stages of s for a run of duration with at most vus
+// virtual users. They are the stages mockforge bench writes into its k6
+// scripts, computed on whole seconds with duration rounded up.
+func (s Scenario) Stages(duration time.Duration, vus int) ([]Stage, error) {
+	secs := int64(math.Ceil(duration.Seconds()))
+	stage := func(seconds int64, target int) Stage {
+		return Stage{Duration: time.Duration(seconds) * time.Second, Target: target}
+	}
+
+	switch s {
+	case Constant:
+		return []Stage{stage(secs, vus)}, nil
+	case RampUp:
+		ramp, sustain := secs/3, secs/3
+		return []Stage{
+			stage(ramp/2, vus/4),
+			stage(ramp/2, vus/2),
+			stage(sustain, vus),
+			stage(secs-ramp-sustain, 0),
+		}, nil
+	case Spike:
+		baseline, spike := secs/5, secs/10
+		return []Stage{
+			stage(baseline, vus/10),
+			stage(spike, vus),
+			stage(secs-baseline*2-spike, vus/10),
+			stage(baseline, 0),
+		}, nil
+	case Stress:
+		step, stepVUs := secs/6, vus/5
+		return []Stage{
+			stage(step, stepVUs),
+			stage(step, stepVUs*2),
+			stage(step, stepVUs*3),
+			stage(step, stepVUs*4),
+			stage(step, vus),
+			stage(step, 0),
+		}, nil
+	case Soak:
+		ramp := secs / 20
+		return []Stage{
+			stage(ramp, vus),
+			stage(secs-ramp*2, vus),
+			stage(ramp, 0),
+		}, nil
+	}
+	return nil, mockforge.NewInvalidConfigError(fmt.Sprintf("unknown load scenario %q", s), nil)
+}
+
+// startVUs is the number of virtual users active when s begins; only
+// Constant starts at full load
+func (s Scenario) startVUs(vus int) int {
+	if s == Constant {
+		return vus
+	}
+	return 0
+}
+
+// activeVUs returns how many virtual users stages call for at elapsed,
+// interpolating within the current stage
+func activeVUs(stages []Stage, start int, elapsed time.Duration) int {
+	from := start
+	for _, s := range stages {
+		if elapsed < s.Duration {
+			progress := float64(elapsed) / float64(s.Duration)
+			return int(math.Round(float64(from) + float64(s.Target-from)*progress))
+		}
+		elapsed -= s.Duration
+		from = s.Target
+	}
+	return from
+}
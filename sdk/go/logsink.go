@@ -0,0 +1,317 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogSeverity is the normalized severity of a captured log record
+type LogSeverity string
+
+const (
+	// SeverityAny matches records of every severity in ExpectLog
+	SeverityAny   LogSeverity = ""
+	SeverityTrace LogSeverity = "TRACE"
+	SeverityDebug LogSeverity = "DEBUG"
+	SeverityInfo  LogSeverity = "INFO"
+	SeverityWarn  LogSeverity = "WARN"
+	SeverityError LogSeverity = "ERROR"
+	SeverityFatal LogSeverity = "FATAL"
+)
+
+// LogRecord is a log entry captured by the LogSink
+type LogRecord struct {
+	// Source is "syslog" or "otlp"
+	Source   string
+	Severity LogSeverity
+	Message  string
+	// Attributes holds OTLP resource and record attributes, or the syslog
+	// hostname/app-name fields
+	Attributes map[string]string
+	// Timestamp is the time reported by the sender, or the receive time if
+	// the sender did not include one
+	Timestamp time.Time
+}
+
+// LogSink receives syslog and OTLP/HTTP log exports from the system under test.
+//
+// Point the application's syslog writer at SyslogAddr() and its OTLP log
+// exporter (http/json protocol) at OTLPEndpoint(), then assert on what
+// arrived with ExpectLog.
+type LogSink struct {
+	syslog *udpSidecar
+	otlp   *httpSidecar
+
+	mu      sync.Mutex
+	records []LogRecord
+}
+
+// LogSink returns the server's log sink, starting its listeners on first use.
+// The listeners are closed when the server is stopped.
+func (m *MockServer) LogSink() (*LogSink, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.logSink != nil {
+		return m.logSink, nil
+	}
+
+	sink := &LogSink{}
+
+	syslog, err := newUDPSidecar(m.host, sink.receiveSyslog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start syslog sink: %w", err)
+	}
+	sink.syslog = syslog
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/logs", sink.receiveOTLP)
+	otlp, err := newHTTPSidecar(m.host, mux)
+	if err != nil {
+		syslog.Close()
+		return nil, fmt.Errorf("failed to start OTLP log sink: %w", err)
+	}
+	sink.otlp = otlp
+
+	m.attached = append(m.attached, syslog, otlp)
+	m.logSink = sink
+
+	return sink, nil
+}
+
+// SyslogAddr returns the UDP host:port accepting RFC 5424 and RFC 3164 messages
+func (s *LogSink) SyslogAddr() string {
+	return s.syslog.Addr()
+}
+
+// OTLPEndpoint returns the base URL for an OTLP/HTTP exporter; logs are
+// accepted at OTLPEndpoint() + "/v1/logs"
+func (s *LogSink) OTLPEndpoint() string {
+	return s.otlp.URL()
+}
+
+// Records returns a snapshot of every captured record in arrival order
+func (s *LogSink) Records() []LogRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]LogRecord(nil), s.records...)
+}
+
+// Reset discards all captured records
+func (s *LogSink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = nil
+}
+
+// FindLogs returns the captured records with the given severity whose message
+// contains msgContains. SeverityAny matches every severity.
+func (s *LogSink) FindLogs(severity LogSeverity, msgContains string) []LogRecord {
+	var found []LogRecord
+	for _, record := range s.Records() {
+		if severity != SeverityAny && record.Severity != severity {
+			continue
+		}
+		if !strings.Contains(record.Message, msgContains) {
+			continue
+		}
+		found = append(found, record)
+	}
+	return found
+}
+
+// ExpectLog asserts how many captured records have the given severity and a
+// message containing msgContains
+func (s *LogSink) ExpectLog(severity LogSeverity, msgContains string, count VerificationCount) error {
+	n := len(s.FindLogs(severity, msgContains))
+	if !count.Satisfied(n) {
+		level := string(severity)
+		if severity == SeverityAny {
+			level = "any"
+		}
+		return fmt.Errorf("expected %s log record(s) with severity %s containing %q, got %d", count, level, msgContains, n)
+	}
+	return nil
+}
+
+func (s *LogSink) add(records ...LogRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+}
+
+// syslogPriPattern matches the leading <PRI> of a syslog message
+var syslogPriPattern = regexp.MustCompile(`^<(\d{1,3})>`)
+
+// receiveSyslog parses a single syslog datagram
+func (s *LogSink) receiveSyslog(datagram []byte) {
+	line := strings.TrimRight(string(datagram), "\r\n\x00")
+	record := LogRecord{
+		Source:     "syslog",
+		Severity:   SeverityInfo,
+		Attributes: make(map[string]string),
+		Timestamp:  time.Now(),
+	}
+
+	if matches := syslogPriPattern.FindStringSubmatch(line); matches != nil {
+		pri, _ := strconv.Atoi(matches[1])
+		record.Severity = syslogSeverity(pri % 8)
+		line = line[len(matches[0]):]
+	}
+
+	if strings.HasPrefix(line, "1 ") {
+		// RFC 5424: VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+		fields := strings.SplitN(line, " ", 7)
+		if len(fields) == 7 {
+			if ts, err := time.Parse(time.RFC3339Nano, fields[1]); err == nil {
+				record.Timestamp = ts
+			}
+			record.Attributes["hostname"] = fields[2]
+			record.Attributes["app_name"] = fields[3]
+			record.Message = syslogStripStructuredData(fields[6])
+		} else {
+			record.Message = line
+		}
+	} else if len(line) >= 16 {
+		// RFC 3164: Mmm dd hh:mm:ss HOSTNAME TAG: MSG
+		fields := strings.SplitN(line[16:], " ", 2)
+		record.Attributes["hostname"] = fields[0]
+		record.Message = line[16:]
+		if len(fields) == 2 {
+			record.Message = fields[1]
+			if tag, msg, ok := strings.Cut(fields[1], ": "); ok {
+				record.Attributes["app_name"] = tag
+				record.Message = msg
+			}
+		}
+	} else {
+		record.Message = line
+	}
+
+	s.add(record)
+}
+
+// syslogStripStructuredData removes the STRUCTURED-DATA element preceding the
+// message of an RFC 5424 line
+func syslogStripStructuredData(rest string) string {
+	if strings.HasPrefix(rest, "- ") {
+		return rest[2:]
+	}
+	if rest == "-" {
+		return ""
+	}
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "] "); end >= 0 {
+			return rest[end+2:]
+		}
+	}
+	return rest
+}
+
+// syslogSeverity maps a syslog severity (0-7) to a LogSeverity
+func syslogSeverity(level int) LogSeverity {
+	switch {
+	case level <= 2:
+		return SeverityFatal
+	case level == 3:
+		return SeverityError
+	case level == 4:
+		return SeverityWarn
+	case level <= 6:
+		return SeverityInfo
+	default:
+		return SeverityDebug
+	}
+}
+
+// otlpSeverity maps an OTLP SeverityNumber (1-24) to a LogSeverity
+func otlpSeverity(number int, text string) LogSeverity {
+	switch {
+	case number >= 21:
+		return SeverityFatal
+	case number >= 17:
+		return SeverityError
+	case number >= 13:
+		return SeverityWarn
+	case number >= 9:
+		return SeverityInfo
+	case number >= 5:
+		return SeverityDebug
+	case number >= 1:
+		return SeverityTrace
+	}
+
+	switch strings.ToUpper(text) {
+	case "WARNING":
+		return SeverityWarn
+	case "CRITICAL", "EMERGENCY", "ALERT":
+		return SeverityFatal
+	case "":
+		return SeverityInfo
+	default:
+		return LogSeverity(strings.ToUpper(text))
+	}
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []struct {
+		Resource  otlpResource `json:"resource"`
+		ScopeLogs []struct {
+			LogRecords []struct {
+				TimeUnixNano   json.RawMessage `json:"timeUnixNano"`
+				SeverityNumber int             `json:"severityNumber"`
+				SeverityText   string          `json:"severityText"`
+				Body           otlpAnyValue    `json:"body"`
+				Attributes     []otlpKeyValue  `json:"attributes"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+// receiveOTLP handles an OTLP/HTTP JSON logs export
+func (s *LogSink) receiveOTLP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		http.Error(w, "only the http/json OTLP encoding is supported", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req otlpLogsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var records []LogRecord
+	for _, rl := range req.ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			for _, lr := range sl.LogRecords {
+				record := LogRecord{
+					Source:     "otlp",
+					Severity:   otlpSeverity(lr.SeverityNumber, lr.SeverityText),
+					Message:    lr.Body.String(),
+					Attributes: otlpAttributes(rl.Resource.Attributes, lr.Attributes),
+					Timestamp:  now,
+				}
+				if nanos, err := otlpUint64(lr.TimeUnixNano); err == nil && nanos > 0 {
+					record.Timestamp = time.Unix(0, int64(nanos))
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	s.add(records...)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
@@ -0,0 +1,75 @@
+package mockforge
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogSink(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	sink, err := server.LogSink()
+	if err != nil {
+		t.Fatalf("Failed to start log sink: %v", err)
+	}
+
+	t.Run("captures syslog messages", func(t *testing.T) {
+		conn, err := net.Dial("udp", sink.SyslogAddr())
+		if err != nil {
+			t.Fatalf("Failed to dial syslog sink: %v", err)
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("<11>1 2024-01-01T00:00:00Z host checkout 42 - - payment failed for order 7"))
+		conn.Write([]byte("<14>Jan  1 00:00:00 host checkout: order 8 created"))
+
+		waitForRecords(t, sink, 2)
+
+		if err := sink.ExpectLog(SeverityError, "payment failed", Exactly(1)); err != nil {
+			t.Error(err)
+		}
+		if err := sink.ExpectLog(SeverityInfo, "order 8 created", Exactly(1)); err != nil {
+			t.Error(err)
+		}
+		if records := sink.FindLogs(SeverityError, "payment"); records[0].Attributes["app_name"] != "checkout" {
+			t.Errorf("Expected app_name checkout, got %q", records[0].Attributes["app_name"])
+		}
+	})
+
+	t.Run("captures OTLP log exports", func(t *testing.T) {
+		sink.Reset()
+
+		body := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"billing"}}]},
+			"scopeLogs":[{"logRecords":[{"severityNumber":13,"body":{"stringValue":"retrying charge"}}]}]}]}`
+		resp, err := http.Post(sink.OTLPEndpoint()+"/v1/logs", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to export logs: %v", err)
+		}
+		resp.Body.Close()
+
+		if err := sink.ExpectLog(SeverityWarn, "retrying", AtLeastOnce()); err != nil {
+			t.Error(err)
+		}
+		if err := sink.ExpectLog(SeverityAny, "retrying", Exactly(2)); err == nil {
+			t.Error("Expected ExpectLog to fail for a count mismatch")
+		}
+		if service := sink.Records()[0].Attributes["service.name"]; service != "billing" {
+			t.Errorf("Expected service.name billing, got %q", service)
+		}
+	})
+}
+
+func waitForRecords(t *testing.T, sink *LogSink, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.Records()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d records, got %d", n, len(sink.Records()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
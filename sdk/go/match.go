@@ -0,0 +1,78 @@
+package mockforge
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RequestMatch restricts a stub to requests whose headers, query parameters,
+// and body satisfy every configured criterion. It is sent to the admin API as
+// the mock's request_match block.
+type RequestMatch struct {
+	// Headers that must be present with the given value (case-insensitive
+	// header names). Values are treated as unanchored regular expressions by
+	// the server; see exactHeaderPattern for literal values.
+	Headers map[string]string `json:"headers,omitempty"`
+	// HeadersAbsent are headers the request must not carry
+	HeadersAbsent []string `json:"headers_absent,omitempty"`
+	// HeadersPresent are headers the request must carry, with any value
+	HeadersPresent []string `json:"headers_present,omitempty"`
+	// Query parameters that must be present with exactly the given value
+	QueryParams map[string]string `json:"query_params,omitempty"`
+	// Cookies that must be sent with exactly the given value
+	Cookies map[string]string `json:"cookies,omitempty"`
+	// BodyJSON requires the request body to be JSON equal to this value
+	BodyJSON interface{} `json:"body_json,omitempty"`
+	// JSONPaths maps JSONPath expressions (e.g. "$.type") to the value they
+	// must select in the request body
+	JSONPaths map[string]interface{} `json:"json_paths,omitempty"`
+	// BodyPattern is a regular expression the raw request body must match
+	BodyPattern string `json:"body_pattern,omitempty"`
+	// XPaths maps XPath expressions (e.g. "//GetOrder/id") to the text the
+	// selected XML element must have; an empty value only requires the
+	// element to exist. Element names match without namespace prefixes.
+	XPaths map[string]string `json:"xpaths,omitempty"`
+	// Expression is a boolean expression over the request, see
+	// StubBuilder.When
+	Expression string `json:"custom_matcher,omitempty"`
+}
+
+// IsEmpty reports whether no criteria are configured
+func (rm *RequestMatch) IsEmpty() bool {
+	return len(rm.Headers) == 0 &&
+		len(rm.HeadersAbsent) == 0 &&
+		len(rm.HeadersPresent) == 0 &&
+		len(rm.QueryParams) == 0 &&
+		len(rm.Cookies) == 0 &&
+		rm.BodyJSON == nil &&
+		len(rm.JSONPaths) == 0 &&
+		rm.BodyPattern == "" &&
+		len(rm.XPaths) == 0 &&
+		rm.Expression == ""
+}
+
+// exactHeaderPattern returns the header pattern that only matches value
+// itself, since the server treats header values as regular expressions
+func exactHeaderPattern(value string) string {
+	return "^" + regexp.QuoteMeta(value) + "$"
+}
+
+// literalHeaderValue returns the value an exactHeaderPattern pattern matches,
+// and false for patterns that match anything else
+func literalHeaderValue(pattern string) (string, bool) {
+	if !strings.HasPrefix(pattern, "^") || !strings.HasSuffix(pattern, "$") {
+		return "", false
+	}
+	quoted := pattern[1 : len(pattern)-1]
+	var value strings.Builder
+	for i := 0; i < len(quoted); i++ {
+		if quoted[i] == '\\' && i+1 < len(quoted) {
+			i++
+		}
+		value.WriteByte(quoted[i])
+	}
+	if regexp.QuoteMeta(value.String()) != quoted {
+		return "", false
+	}
+	return value.String(), true
+}
@@ -0,0 +1,470 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricKind is the normalized type of a captured metric
+type MetricKind string
+
+const (
+	MetricCounter   MetricKind = "counter"
+	MetricGauge     MetricKind = "gauge"
+	MetricHistogram MetricKind = "histogram"
+	MetricSet       MetricKind = "set"
+)
+
+// MetricSample is a single metric data point captured by the MetricsSink
+type MetricSample struct {
+	// Source is "statsd" or "otlp"
+	Source string
+	Name   string
+	Kind   MetricKind
+	// Value is the counter increment, gauge reading, or the sum of the
+	// observations for histograms and timers
+	Value float64
+	// Count is the number of observations the sample represents (1 for
+	// everything except OTLP histograms)
+	Count uint64
+	// Labels holds DogStatsD tags or OTLP resource and data point attributes
+	Labels    map[string]string
+	Timestamp time.Time
+}
+
+// MetricsSink receives StatsD and OTLP/HTTP metric exports from the system
+// under test.
+//
+// Point the application's StatsD client at StatsDAddr() and its OTLP metric
+// exporter (http/json protocol) at OTLPEndpoint(), then query what arrived
+// with Counter, Gauge, and Histogram.
+//
+// Cumulative OTLP sums and histograms are converted to deltas per series, so
+// Sum() reports the total across exports rather than double counting.
+type MetricsSink struct {
+	statsd *udpSidecar
+	otlp   *httpSidecar
+
+	mu         sync.Mutex
+	samples    []MetricSample
+	cumulative map[string]MetricSample
+}
+
+// MetricsSink returns the server's metrics sink, starting its listeners on
+// first use. The listeners are closed when the server is stopped.
+func (m *MockServer) MetricsSink() (*MetricsSink, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.metricsSink != nil {
+		return m.metricsSink, nil
+	}
+
+	sink := &MetricsSink{cumulative: make(map[string]MetricSample)}
+
+	statsd, err := newUDPSidecar(m.host, sink.receiveStatsD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start StatsD sink: %w", err)
+	}
+	sink.statsd = statsd
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/metrics", sink.receiveOTLP)
+	otlp, err := newHTTPSidecar(m.host, mux)
+	if err != nil {
+		statsd.Close()
+		return nil, fmt.Errorf("failed to start OTLP metrics sink: %w", err)
+	}
+	sink.otlp = otlp
+
+	m.attached = append(m.attached, statsd, otlp)
+	m.metricsSink = sink
+
+	return sink, nil
+}
+
+// StatsDAddr returns the UDP host:port accepting StatsD and DogStatsD lines
+func (s *MetricsSink) StatsDAddr() string {
+	return s.statsd.Addr()
+}
+
+// OTLPEndpoint returns the base URL for an OTLP/HTTP exporter; metrics are
+// accepted at OTLPEndpoint() + "/v1/metrics"
+func (s *MetricsSink) OTLPEndpoint() string {
+	return s.otlp.URL()
+}
+
+// Samples returns a snapshot of every captured sample in arrival order
+func (s *MetricsSink) Samples() []MetricSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]MetricSample(nil), s.samples...)
+}
+
+// Reset discards all captured samples
+func (s *MetricsSink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = nil
+	s.cumulative = make(map[string]MetricSample)
+}
+
+// Counter returns the captured samples of the named counter
+func (s *MetricsSink) Counter(name string) MetricSeries {
+	return s.series(name, MetricCounter)
+}
+
+// Gauge returns the captured samples of the named gauge
+func (s *MetricsSink) Gauge(name string) MetricSeries {
+	return s.series(name, MetricGauge)
+}
+
+// Histogram returns the captured samples of the named histogram or timer
+func (s *MetricsSink) Histogram(name string) MetricSeries {
+	return s.series(name, MetricHistogram)
+}
+
+// Set returns the captured samples of the named StatsD set
+func (s *MetricsSink) Set(name string) MetricSeries {
+	return s.series(name, MetricSet)
+}
+
+func (s *MetricsSink) series(name string, kind MetricKind) MetricSeries {
+	var samples []MetricSample
+	for _, sample := range s.Samples() {
+		if sample.Name == name && sample.Kind == kind {
+			samples = append(samples, sample)
+		}
+	}
+	return MetricSeries{Name: name, Kind: kind, samples: samples}
+}
+
+// MetricSeries is a filtered view over the samples of one metric
+type MetricSeries struct {
+	Name    string
+	Kind    MetricKind
+	samples []MetricSample
+}
+
+// WithLabels narrows the series to samples carrying all the given labels
+func (q MetricSeries) WithLabels(labels map[string]string) MetricSeries {
+	var samples []MetricSample
+	for _, sample := range q.samples {
+		if labelsContain(sample.Labels, labels) {
+			samples = append(samples, sample)
+		}
+	}
+	return MetricSeries{Name: q.Name, Kind: q.Kind, samples: samples}
+}
+
+// Samples returns the samples in the series
+func (q MetricSeries) Samples() []MetricSample {
+	return append([]MetricSample(nil), q.samples...)
+}
+
+// Exists reports whether any sample was captured for the series
+func (q MetricSeries) Exists() bool {
+	return len(q.samples) > 0
+}
+
+// Sum adds up the sample values: the counter total, or the sum of all
+// histogram observations
+func (q MetricSeries) Sum() float64 {
+	var sum float64
+	for _, sample := range q.samples {
+		sum += sample.Value
+	}
+	return sum
+}
+
+// Count returns the number of observations in the series
+func (q MetricSeries) Count() uint64 {
+	var count uint64
+	for _, sample := range q.samples {
+		count += sample.Count
+	}
+	return count
+}
+
+// Last returns the most recent value, typically used for gauges
+func (q MetricSeries) Last() float64 {
+	if len(q.samples) == 0 {
+		return 0
+	}
+	return q.samples[len(q.samples)-1].Value
+}
+
+// Values returns the individual sample values in arrival order
+func (q MetricSeries) Values() []float64 {
+	values := make([]float64, len(q.samples))
+	for i, sample := range q.samples {
+		values[i] = sample.Value
+	}
+	return values
+}
+
+// LabelValues returns the distinct values seen for a label across the series
+func (q MetricSeries) LabelValues(label string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, sample := range q.samples {
+		if v, ok := sample.Labels[label]; ok && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+func labelsContain(have, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := have[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *MetricsSink) add(samples ...MetricSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, samples...)
+}
+
+// receiveStatsD parses a datagram of newline-separated StatsD lines:
+// name:value|type[|@rate][|#tag:value,...]
+func (s *MetricsSink) receiveStatsD(datagram []byte) {
+	now := time.Now()
+	var samples []MetricSample
+
+	for _, line := range strings.Split(string(datagram), "\n") {
+		line = strings.TrimSpace(line)
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok || name == "" {
+			continue
+		}
+
+		parts := strings.Split(rest, "|")
+		if len(parts) < 2 {
+			continue
+		}
+
+		sample := MetricSample{
+			Source:    "statsd",
+			Name:      name,
+			Count:     1,
+			Labels:    make(map[string]string),
+			Timestamp: now,
+		}
+
+		switch parts[1] {
+		case "c":
+			sample.Kind = MetricCounter
+		case "g":
+			sample.Kind = MetricGauge
+		case "ms", "h", "d":
+			sample.Kind = MetricHistogram
+		case "s":
+			sample.Kind = MetricSet
+		default:
+			continue
+		}
+
+		if sample.Kind == MetricSet {
+			// Sets carry an arbitrary member rather than a number
+			sample.Labels["member"] = parts[0]
+		} else {
+			value, err := strconv.ParseFloat(parts[0], 64)
+			if err != nil {
+				continue
+			}
+			sample.Value = value
+		}
+
+		for _, part := range parts[2:] {
+			switch {
+			case strings.HasPrefix(part, "@"):
+				rate, err := strconv.ParseFloat(part[1:], 64)
+				if err == nil && rate > 0 && sample.Kind == MetricCounter {
+					sample.Value /= rate
+				}
+			case strings.HasPrefix(part, "#"):
+				for _, tag := range strings.Split(part[1:], ",") {
+					k, v, _ := strings.Cut(tag, ":")
+					sample.Labels[k] = v
+				}
+			}
+		}
+
+		samples = append(samples, sample)
+	}
+
+	s.add(samples...)
+}
+
+type otlpNumberDataPoint struct {
+	Attributes   []otlpKeyValue  `json:"attributes"`
+	TimeUnixNano json.RawMessage `json:"timeUnixNano"`
+	AsInt        json.RawMessage `json:"asInt"`
+	AsDouble     *float64        `json:"asDouble"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes   []otlpKeyValue  `json:"attributes"`
+	TimeUnixNano json.RawMessage `json:"timeUnixNano"`
+	Count        json.RawMessage `json:"count"`
+	Sum          *float64        `json:"sum"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []struct {
+		Resource     otlpResource `json:"resource"`
+		ScopeMetrics []struct {
+			Metrics []struct {
+				Name string `json:"name"`
+				Sum  *struct {
+					DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+					AggregationTemporality int                   `json:"aggregationTemporality"`
+				} `json:"sum"`
+				Gauge *struct {
+					DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+				} `json:"gauge"`
+				Histogram *struct {
+					DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+					AggregationTemporality int                      `json:"aggregationTemporality"`
+				} `json:"histogram"`
+			} `json:"metrics"`
+		} `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+// otlpCumulative is the OTLP AGGREGATION_TEMPORALITY_CUMULATIVE value
+const otlpCumulative = 2
+
+// receiveOTLP handles an OTLP/HTTP JSON metrics export
+func (s *MetricsSink) receiveOTLP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		http.Error(w, "only the http/json OTLP encoding is supported", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req otlpMetricsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for _, rm := range req.ResourceMetrics {
+		for _, sm := range rm.ScopeMetrics {
+			for _, metric := range sm.Metrics {
+				base := MetricSample{Source: "otlp", Name: metric.Name, Count: 1, Timestamp: now}
+
+				switch {
+				case metric.Sum != nil:
+					base.Kind = MetricCounter
+					for _, dp := range metric.Sum.DataPoints {
+						sample := otlpNumberSample(base, rm.Resource, dp)
+						if metric.Sum.AggregationTemporality == otlpCumulative {
+							sample = s.delta(sample)
+						}
+						s.samples = append(s.samples, sample)
+					}
+				case metric.Gauge != nil:
+					base.Kind = MetricGauge
+					for _, dp := range metric.Gauge.DataPoints {
+						s.samples = append(s.samples, otlpNumberSample(base, rm.Resource, dp))
+					}
+				case metric.Histogram != nil:
+					base.Kind = MetricHistogram
+					for _, dp := range metric.Histogram.DataPoints {
+						sample := base
+						sample.Labels = otlpAttributes(rm.Resource.Attributes, dp.Attributes)
+						sample.Count, _ = otlpUint64(dp.Count)
+						if dp.Sum != nil {
+							sample.Value = *dp.Sum
+						}
+						if nanos, err := otlpUint64(dp.TimeUnixNano); err == nil && nanos > 0 {
+							sample.Timestamp = time.Unix(0, int64(nanos))
+						}
+						if metric.Histogram.AggregationTemporality == otlpCumulative {
+							sample = s.delta(sample)
+						}
+						s.samples = append(s.samples, sample)
+					}
+				}
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+func otlpNumberSample(base MetricSample, resource otlpResource, dp otlpNumberDataPoint) MetricSample {
+	sample := base
+	sample.Labels = otlpAttributes(resource.Attributes, dp.Attributes)
+	switch {
+	case dp.AsDouble != nil:
+		sample.Value = *dp.AsDouble
+	case len(dp.AsInt) > 0:
+		var s string
+		if json.Unmarshal(dp.AsInt, &s) != nil {
+			s = string(dp.AsInt)
+		}
+		sample.Value, _ = strconv.ParseFloat(s, 64)
+	}
+	if nanos, err := otlpUint64(dp.TimeUnixNano); err == nil && nanos > 0 {
+		sample.Timestamp = time.Unix(0, int64(nanos))
+	}
+	return sample
+}
+
+// delta converts a cumulative sample into the increment since the previous
+// export of the same series. Callers must hold s.mu.
+func (s *MetricsSink) delta(sample MetricSample) MetricSample {
+	key := seriesKey(sample)
+	previous, seen := s.cumulative[key]
+	s.cumulative[key] = sample
+
+	// A total lower than the previous one means the exporter restarted
+	if !seen || sample.Value < previous.Value || sample.Count < previous.Count {
+		return sample
+	}
+
+	sample.Value -= previous.Value
+	if sample.Kind == MetricHistogram {
+		sample.Count -= previous.Count
+	}
+	return sample
+}
+
+func seriesKey(sample MetricSample) string {
+	keys := make([]string, 0, len(sample.Labels))
+	for k := range sample.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(string(sample.Kind))
+	b.WriteString("|")
+	b.WriteString(sample.Name)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "|%s=%s", k, sample.Labels[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package mockforge
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsSink(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	sink, err := server.MetricsSink()
+	if err != nil {
+		t.Fatalf("Failed to start metrics sink: %v", err)
+	}
+
+	t.Run("captures StatsD lines with tags", func(t *testing.T) {
+		conn, err := net.Dial("udp", sink.StatsDAddr())
+		if err != nil {
+			t.Fatalf("Failed to dial StatsD sink: %v", err)
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("orders_created:1|c|#region:eu\norders_created:2|c|@0.5|#region:us\nqueue_depth:7|g"))
+
+		deadline := time.Now().Add(2 * time.Second)
+		for len(sink.Samples()) < 3 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if sum := sink.Counter("orders_created").Sum(); sum != 5 {
+			t.Errorf("Expected orders_created sum 5, got %v", sum)
+		}
+		if sum := sink.Counter("orders_created").WithLabels(map[string]string{"region": "eu"}).Sum(); sum != 1 {
+			t.Errorf("Expected eu orders_created sum 1, got %v", sum)
+		}
+		if last := sink.Gauge("queue_depth").Last(); last != 7 {
+			t.Errorf("Expected queue_depth 7, got %v", last)
+		}
+	})
+
+	t.Run("converts cumulative OTLP sums to deltas", func(t *testing.T) {
+		sink.Reset()
+
+		for _, total := range []string{"3", "5"} {
+			body := `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"orders_created",
+				"sum":{"aggregationTemporality":2,"dataPoints":[{"asInt":"` + total + `"}]}}]}]}]}`
+			resp, err := http.Post(sink.OTLPEndpoint()+"/v1/metrics", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("Failed to export metrics: %v", err)
+			}
+			resp.Body.Close()
+		}
+
+		if sum := sink.Counter("orders_created").Sum(); sum != 5 {
+			t.Errorf("Expected orders_created sum 5, got %v", sum)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+// Package mockassert provides testing.T assertions over the requests a
+// MockForge server received. Failures explain why nothing matched by listing
+// the closest requests and how they differ from the pattern:
+//
+//	mockassert.CalledTimes(t, server, mockforge.VerificationRequest{
+//	    Method: "POST",
+//	    Path:   "/orders",
+//	}, 1)
+//
+// fails with
+//
+//	expected POST /orders to be called exactly 1 time(s), got 0
+//	closest requests:
+//	  POST /order: path differs at segment 1: expected "orders", got "order"
+//
+// Assertions report with Errorf and return whether they passed, so a test
+// can stop early with if !mockassert.Called(...) { t.FailNow() }.
+package mockassert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// Called asserts that server received at least one request matching pattern
+func Called(t testing.TB, server *mockforge.MockServer, pattern mockforge.VerificationRequest) bool {
+	t.Helper()
+	return verify(t, server, pattern, mockforge.AtLeastOnce())
+}
+
+// NeverCalled asserts that server received no request matching pattern
+func NeverCalled(t testing.TB, server *mockforge.MockServer, pattern mockforge.VerificationRequest) bool {
+	t.Helper()
+	return verify(t, server, pattern, mockforge.Never())
+}
+
+// CalledTimes asserts that server received exactly n requests matching
+// pattern
+func CalledTimes(t testing.TB, server *mockforge.MockServer, pattern mockforge.VerificationRequest, n int) bool {
+	t.Helper()
+	return verify(t, server, pattern, mockforge.Exactly(n))
+}
+
+// LatencyBetween asserts that server received requests matching pattern
+// and answered all of them in between min and max, e.g. to check that a
+// stub's configured latency was honored. A zero max means no upper bound.
+func LatencyBetween(t testing.TB, server *mockforge.MockServer, pattern mockforge.VerificationRequest, min, max time.Duration) bool {
+	t.Helper()
+
+	stats, err := server.RequestLatencyStats(pattern)
+	if err != nil {
+		t.Errorf("failed to get latency of %s: %v", describe(pattern), err)
+		return false
+	}
+	switch {
+	case stats.Count == 0:
+		t.Errorf("expected %s to be called, got 0", describe(pattern))
+	case stats.Min < min:
+		t.Errorf("expected %s to take at least %v, got %s", describe(pattern), min, stats)
+	case max > 0 && stats.Max > max:
+		t.Errorf("expected %s to take at most %v, got %s", describe(pattern), max, stats)
+	default:
+		return true
+	}
+	return false
+}
+
+// NoUnmatchedRequests fails the test at cleanup if server received any
+// request that no stub matched, listing those requests. Register it right
+// after starting the server, typically together with StrictStubbing.
+func NoUnmatchedRequests(t testing.TB, server *mockforge.MockServer) {
+	t.Helper()
+	t.Cleanup(func() {
+		unmatched, err := server.UnmatchedRequests()
+		if err != nil {
+			t.Errorf("failed to list unmatched requests: %v", err)
+			return
+		}
+		if len(unmatched) == 0 {
+			return
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d request(s) matched no stub:", len(unmatched))
+		for _, request := range unmatched {
+			fmt.Fprintf(&b, "\n  %s %s -> %d", request.Method, request.Path, request.Status)
+		}
+		t.Errorf("%s", b.String())
+	})
+}
+
+// MinCoverage asserts that the requests server received exercised at least
+// min, from 0 to 1, of the operations in its OpenAPI spec, listing the
+// operations left unexercised
+func MinCoverage(t testing.TB, server *mockforge.MockServer, min float64) bool {
+	t.Helper()
+
+	report, err := server.SpecCoverage()
+	if err != nil {
+		t.Errorf("failed to get spec coverage: %v", err)
+		return false
+	}
+	if report.Ratio() >= min {
+		return true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "expected spec coverage of at least %.1f%%, got %s\nnot exercised:", min*100, report)
+	for _, op := range report.Missed() {
+		fmt.Fprintf(&b, "\n  %s %s", op.Method, op.Path)
+		if op.OperationID != "" {
+			fmt.Fprintf(&b, " (%s)", op.OperationID)
+		}
+	}
+	t.Errorf("%s", b.String())
+	return false
+}
+
+// verify runs the verification and reports a readable failure
+func verify(t testing.TB, server *mockforge.MockServer, pattern mockforge.VerificationRequest, expected mockforge.VerificationCount) bool {
+	t.Helper()
+
+	result, err := server.Verify(pattern, expected)
+	if err != nil {
+		t.Errorf("failed to verify %s: %v", describe(pattern), err)
+		return false
+	}
+	if result.Matched {
+		return true
+	}
+	t.Errorf("%s", failureMessage(pattern, expected, result))
+	return false
+}
+
+// failureMessage explains a failed verification, listing near misses
+func failureMessage(pattern mockforge.VerificationRequest, expected mockforge.VerificationCount, result *mockforge.VerificationResult) string {
+	var b strings.Builder
+	switch expected.Type {
+	case "never":
+		fmt.Fprintf(&b, "expected %s never to be called, got %d call(s)", describe(pattern), result.Count)
+	case "at_least_once":
+		fmt.Fprintf(&b, "expected %s to be called at least once, got %d", describe(pattern), result.Count)
+	default:
+		fmt.Fprintf(&b, "expected %s to be called %s time(s), got %d", describe(pattern), expected, result.Count)
+	}
+
+	if len(result.NearMisses) > 0 {
+		b.WriteString("\nclosest requests:")
+		for _, miss := range result.NearMisses {
+			fmt.Fprintf(&b, "\n  %s %s: %s", miss.Request.Method, miss.Request.Path, strings.Join(miss.Differences, "; "))
+		}
+	} else if result.Count == 0 {
+		b.WriteString("\nno requests were received")
+	}
+	return b.String()
+}
+
+// describe formats a pattern as METHOD path plus its other criteria
+func describe(pattern mockforge.VerificationRequest) string {
+	method, path := strings.ToUpper(pattern.Method), pattern.Path
+	if method == "" {
+		method = "any method"
+	}
+	if path == "" {
+		path = "any path"
+	}
+
+	var criteria []string
+	for name, value := range pattern.Headers {
+		criteria = append(criteria, fmt.Sprintf("%s: %s", name, value))
+	}
+	for name, value := range pattern.QueryParams {
+		criteria = append(criteria, fmt.Sprintf("%s=%s", name, value))
+	}
+	if pattern.BodyPattern != "" {
+		criteria = append(criteria, fmt.Sprintf("body ~ %q", pattern.BodyPattern))
+	}
+	if len(criteria) == 0 {
+		return method + " " + path
+	}
+	sort.Strings(criteria)
+	return fmt.Sprintf("%s %s (%s)", method, path, strings.Join(criteria, ", "))
+}
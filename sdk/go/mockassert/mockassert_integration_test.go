@@ -0,0 +1,105 @@
+//go:build integration
+// +build integration
+
+// Integration tests that require the MockForge CLI to be installed and on PATH.
+// Run with: go test -tags=integration
+package mockassert
+
+import (
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// startCLIServer starts a server with the MockForge CLI for the rest of the
+// test, skipping the test when the CLI is not on PATH
+func startCLIServer(t *testing.T, config mockforge.MockServerConfig) *mockforge.MockServer {
+	t.Helper()
+	if _, err := exec.LookPath("mockforge"); err != nil {
+		t.Skip("Requires MockForge CLI to be installed")
+	}
+	server := mockforge.NewMockServer(config)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+	return server
+}
+
+// get sends a GET request to the server and returns the response status
+func get(t *testing.T, server *mockforge.MockServer, path string) int {
+	t.Helper()
+	resp, err := http.Get(server.URL() + path)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAssertionsAgainstServer(t *testing.T) {
+	server := startCLIServer(t, mockforge.MockServerConfig{})
+	err := server.StubAll([]mockforge.ResponseStub{
+		mockforge.NewStubBuilder("GET", "/health").Body("ok").Build(),
+		mockforge.NewStubBuilder("GET", "/slow").Latency(200).Body("ok").Build(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+	for _, path := range []string{"/health", "/slow", "/order"} {
+		get(t, server, path)
+	}
+
+	health := mockforge.VerificationRequest{Method: "GET", Path: "/health"}
+	orders := mockforge.VerificationRequest{Method: "GET", Path: "/orders"}
+	slow := mockforge.VerificationRequest{Method: "GET", Path: "/slow"}
+
+	r := &recorder{TB: t}
+	if !Called(r, server, health) || !CalledTimes(r, server, health, 1) || !NeverCalled(r, server, orders) {
+		t.Errorf("Expected assertions to pass, got %v", r.failures)
+	}
+	if !LatencyBetween(r, server, slow, 150*time.Millisecond, 5*time.Second) {
+		t.Errorf("Expected the latency assertion to pass, got %v", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	if Called(r, server, orders) || len(r.failures) != 1 || !strings.Contains(r.failures[0], "GET /order:") {
+		t.Errorf("Expected a failure listing GET /order as a near miss, got %q", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	NoUnmatchedRequests(r, server)
+	for _, cleanup := range r.cleanups {
+		cleanup()
+	}
+	expected := "1 request(s) matched no stub:\n  GET /order -> 404"
+	if len(r.failures) != 1 || r.failures[0] != expected {
+		t.Errorf("Expected failure %q, got %q", expected, r.failures)
+	}
+}
+
+func TestMinCoverageAgainstServer(t *testing.T) {
+	spec, err := filepath.Abs("../testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("Failed to resolve spec: %v", err)
+	}
+	server := startCLIServer(t, mockforge.MockServerConfig{OpenAPISpec: spec})
+	for _, path := range []string{"/pets/1", "/health"} {
+		if status := get(t, server, path); status != 200 {
+			t.Fatalf("Expected the spec to serve %s, got %d", path, status)
+		}
+	}
+
+	r := &recorder{TB: t}
+	if !MinCoverage(r, server, 0.6) {
+		t.Errorf("Expected the coverage assertion to pass, got %q", r.failures)
+	}
+	if MinCoverage(r, server, 0.8) || len(r.failures) != 1 || !strings.HasSuffix(r.failures[0], "not exercised:\n  POST /pets") {
+		t.Errorf("Expected POST /pets reported unexercised, got %q", r.failures)
+	}
+}
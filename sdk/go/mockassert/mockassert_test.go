@@ -0,0 +1,159 @@
+package mockassert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// recorder captures assertion failures and cleanups instead of failing the
+// test
+type recorder struct {
+	testing.TB
+	failures []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// newJournalServer serves the verification API over a fixed request log
+func newJournalServer(t *testing.T, logged []map[string]interface{}) *mockforge.MockServer {
+	t.Helper()
+	return newSpecJournalServer(t, logged, "")
+}
+
+// newSpecJournalServer is newJournalServer for a server with an OpenAPI spec
+func newSpecJournalServer(t *testing.T, logged []map[string]interface{}, spec string) *mockforge.MockServer {
+	t.Helper()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pattern  mockforge.VerificationRequest `json:"pattern"`
+			Expected mockforge.VerificationCount   `json:"expected"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		matches := []map[string]interface{}{}
+		for _, entry := range logged {
+			if (body.Pattern.Method == "" || entry["method"] == body.Pattern.Method) &&
+				(body.Pattern.Path == "" || entry["path"] == body.Pattern.Path) {
+				matches = append(matches, entry)
+			}
+		}
+		json.NewEncoder(w).Encode(mockforge.VerificationResult{
+			Matched: body.Expected.Satisfied(len(matches)),
+			Count:   len(matches),
+			Matches: matches,
+		})
+	}))
+	t.Cleanup(api.Close)
+
+	host, port, _ := net.SplitHostPort(api.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return mockforge.NewMockServer(mockforge.MockServerConfig{Host: host, Port: portNum, OpenAPISpec: spec})
+}
+
+func TestAssertions(t *testing.T) {
+	server := newJournalServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "POST", "path": "/order"},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/health"},
+	})
+	orders := mockforge.VerificationRequest{Method: "POST", Path: "/orders"}
+	health := mockforge.VerificationRequest{Method: "GET", Path: "/health"}
+
+	t.Run("passing assertions", func(t *testing.T) {
+		r := &recorder{TB: t}
+		if !Called(r, server, health) || !CalledTimes(r, server, health, 1) || !NeverCalled(r, server, orders) {
+			t.Errorf("Expected assertions to pass, got %v", r.failures)
+		}
+	})
+
+	t.Run("failure lists near misses", func(t *testing.T) {
+		r := &recorder{TB: t}
+		if Called(r, server, orders) {
+			t.Fatal("Expected the assertion to fail")
+		}
+		expected := "expected POST /orders to be called at least once, got 0\n" +
+			"closest requests:\n" +
+			`  POST /order: path differs at segment 1: expected "orders", got "order"`
+		if len(r.failures) != 1 || !strings.HasPrefix(r.failures[0], expected) {
+			t.Errorf("Expected failure %q, got %q", expected, r.failures)
+		}
+	})
+
+	t.Run("never called reports the calls", func(t *testing.T) {
+		r := &recorder{TB: t}
+		NeverCalled(r, server, health)
+		if len(r.failures) != 1 || r.failures[0] != "expected GET /health never to be called, got 1 call(s)" {
+			t.Errorf("Expected a never-called failure, got %q", r.failures)
+		}
+	})
+}
+
+func TestNoUnmatchedRequests(t *testing.T) {
+	server := newJournalServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/helth", "status_code": 404, "metadata": map[string]string{"unmatched": "true"}},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/health", "status_code": 200},
+	})
+
+	r := &recorder{TB: t}
+	NoUnmatchedRequests(r, server)
+	if len(r.failures) != 0 || len(r.cleanups) != 1 {
+		t.Fatalf("Expected the check to be deferred to cleanup, got %q", r.failures)
+	}
+	r.cleanups[0]()
+
+	expected := "1 request(s) matched no stub:\n  GET /helth -> 404"
+	if len(r.failures) != 1 || r.failures[0] != expected {
+		t.Errorf("Expected failure %q, got %q", expected, r.failures)
+	}
+}
+
+func TestLatencyBetween(t *testing.T) {
+	server := newJournalServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/slow", "response_time_ms": 250},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/slow", "response_time_ms": 310},
+	})
+	slow := mockforge.VerificationRequest{Method: "GET", Path: "/slow"}
+
+	r := &recorder{TB: t}
+	if !LatencyBetween(r, server, slow, 200*time.Millisecond, 0) {
+		t.Errorf("Expected the latency assertion to pass, got %q", r.failures)
+	}
+	if LatencyBetween(r, server, slow, 0, 300*time.Millisecond) || len(r.failures) != 1 ||
+		!strings.HasPrefix(r.failures[0], "expected GET /slow to take at most 300ms, got 2 request(s)") {
+		t.Errorf("Expected a max latency failure, got %q", r.failures)
+	}
+}
+
+func TestMinCoverage(t *testing.T) {
+	server := newSpecJournalServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/pets/1"},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/health"},
+	}, "../testdata/petstore.yaml")
+
+	r := &recorder{TB: t}
+	if !MinCoverage(r, server, 0.6) {
+		t.Errorf("Expected the coverage assertion to pass, got %q", r.failures)
+	}
+	if MinCoverage(r, server, 0.8) || len(r.failures) != 1 ||
+		r.failures[0] != "expected spec coverage of at least 80.0%, got 2/3 operations (66.7%)\nnot exercised:\n  POST /pets" {
+		t.Errorf("Expected a coverage failure, got %q", r.failures)
+	}
+}
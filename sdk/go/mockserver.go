@@ -4,13 +4,17 @@ package mockforge
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -21,16 +25,170 @@ type MockServerConfig struct {
 	Host        string
 	ConfigFile  string
 	OpenAPISpec string
+	// AsyncAPISpec is an AsyncAPI 2 or 3 document describing the Kafka,
+	// MQTT, and AMQP channels of the brokers the config file enables; see
+	// PublishAsyncAPIExample
+	AsyncAPISpec string
+	// PassthroughUpstream, if set, forwards requests no stub matches to this
+	// backend once the server starts (see PassthroughUnmatched)
+	PassthroughUpstream string
+	// RecordPassthrough records passthrough responses as stubs
+	RecordPassthrough bool
+	// Connection tunes keep-alive, timeouts, and limits for client connections
+	Connection ConnectionConfig
+	// ValidationMode sets how requests that violate the OpenAPI spec or a
+	// stub's request schema are handled; empty keeps the server default
+	ValidationMode ValidationMode
+	// RandomSeed, if non-zero, seeds faker and template generated values so
+	// they are the same on every run
+	RandomSeed int64
+	// GraphQLPath is the endpoint StubGraphQL stubs are served on, by
+	// default /graphql
+	GraphQLPath string
+	// SOAPPath restricts StubSOAP stubs to one endpoint; by default they
+	// match any path
+	SOAPPath string
+	// GRPCPort is the port StubGRPC stubs are served on; zero keeps the
+	// server default
+	GRPCPort int
+	// GRPCReflection serves the gRPC server reflection service, so grpcurl
+	// and dynamic clients can discover the mocked services
+	GRPCReflection bool
+	// WebSocketPort is the port StubWebSocket scripts are served on; zero
+	// keeps the server default
+	WebSocketPort int
+	// KafkaPort is the port of the Kafka broker, if the config file enables
+	// it; zero keeps the configured port
+	KafkaPort int
+	// MQTTPort is the port of the MQTT broker, if the config file enables
+	// it; zero keeps the configured port
+	MQTTPort int
+	// AMQPPort is the port of the AMQP broker, if the config file enables
+	// it; zero keeps the configured port
+	AMQPPort int
+	// SMTP starts MockForge's SMTP server, which captures the mail the code
+	// under test sends for ListEmails; see SMTPAddr. It needs a mockforge
+	// built with the smtp feature.
+	SMTP bool
+	// SMTPPort is the port of the SMTP server; zero keeps the config file's
+	// port, or picks a free one
+	SMTPPort int
+	// IMAP serves the SMTP server's mailboxes over IMAP, one mailbox per
+	// recipient address; see IMAPAddr. It implies SMTP.
+	IMAP bool
+	// POP3 serves the SMTP server's mailboxes over POP3; see POP3Addr. It
+	// implies SMTP.
+	POP3 bool
+	// FTP starts MockForge's FTP server, whose files and uploads FTP()
+	// seeds and inspects. It needs a mockforge built with the ftp feature.
+	FTP bool
+	// FTPPort is the port of the FTP server; zero keeps the config file's
+	// port, or picks a free one
+	FTPPort int
+	// TCPPort is the port StubTCP stubs are served on; zero keeps the config
+	// file's port, or picks a free one
+	TCPPort int
+	// JournalLimit caps how many requests the server keeps for verification,
+	// dropping the oldest; zero keeps the server default of 1000
+	JournalLimit int
+	// StrictStubbing answers every request no stub matches with a 404, even
+	// in shadow mode, so UnmatchedRequests catches mistyped paths. It cannot
+	// be combined with PassthroughUpstream.
+	StrictStubbing bool
 }
 
 // ResponseStub represents a stubbed HTTP response
 type ResponseStub struct {
-	Method    string            `json:"method"`
-	Path      string            `json:"path"`
-	Status    int               `json:"status"`
-	Headers   map[string]string `json:"headers"`
-	Body      interface{}       `json:"body"`
-	LatencyMs *int              `json:"latency_ms,omitempty"`
+	Method string `json:"method"`
+	// Path is a literal path, a template such as /users/{id}, or a regular
+	// expression built with PathRegex. Captured parameters are available to
+	// response templates as {{request.path.<name>}}.
+	Path    string            `json:"path"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+	// BodyBytes is served verbatim instead of Body, for binary payloads
+	BodyBytes []byte `json:"body_bytes,omitempty"`
+	// BodyFile is a file the server reads on each request and serves as the
+	// body instead of Body
+	BodyFile string `json:"body_file,omitempty"`
+	// Chunks streams the body as a sequence of delayed writes using chunked
+	// transfer encoding, instead of Body
+	Chunks []Chunk `json:"chunks,omitempty"`
+	// SetCookies are Set-Cookie header values, sent as separate headers
+	SetCookies []string `json:"set_cookies,omitempty"`
+	// Throttle limits how fast the body is written, in bytes per second;
+	// zero writes it at full speed
+	Throttle int `json:"throttle_bytes_per_second,omitempty"`
+	// KeepOpen holds a streamed response open after the last chunk until the
+	// client disconnects
+	KeepOpen bool `json:"keep_open,omitempty"`
+	// Encoding makes the server compress the body and set Content-Encoding,
+	// so clients exercise decompression and Content-Length handling. To serve
+	// bytes that are already compressed, use BodyBytes with a Content-Encoding
+	// header instead.
+	Encoding ContentEncoding `json:"encoding,omitempty"`
+	// Latency simulates response time; nil responds immediately
+	Latency *LatencySpec `json:"latency,omitempty"`
+	// RequestSchema is a JSON Schema request bodies are validated against,
+	// as configured by the server's ValidationMode
+	RequestSchema json.RawMessage `json:"request_schema,omitempty"`
+	// Match restricts the stub to requests with matching headers, query
+	// parameters, or body. If nil, only method and path are matched.
+	Match *RequestMatch `json:"match,omitempty"`
+	// Priority orders overlapping stubs; higher priorities are matched first
+	Priority int `json:"priority,omitempty"`
+	// Scenario names the state machine the stub takes part in. The stub only
+	// matches while the scenario is in RequiredState (if set) and moves the
+	// scenario to NewState (if set) when it matches.
+	Scenario      string `json:"scenario,omitempty"`
+	RequiredState string `json:"required_state,omitempty"`
+	NewState      string `json:"new_state,omitempty"`
+	// Sequence, if set, replaces Status, Headers, and Body with one response
+	// per matching request, in order. The last response repeats once the
+	// sequence is exhausted.
+	Sequence []SequencedResponse `json:"sequence,omitempty"`
+	// RateLimit, if set, answers 429 once too many requests matched
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// Times disables the stub after it has matched this many requests; zero
+	// means unlimited
+	Times int `json:"times,omitempty"`
+	// ExpiresAfter disables the stub once this long has passed since it was
+	// registered; zero means never
+	ExpiresAfter time.Duration `json:"expires_after,omitempty"`
+	// Callbacks are sent after the stub responds
+	Callbacks []Callback `json:"callbacks,omitempty"`
+	// RawResponse, if set, is written to the connection byte for byte in place
+	// of a rendered response. It must be a complete HTTP/1.x response.
+	RawResponse []byte `json:"raw_response,omitempty"`
+	// Proxy, if set, forwards matching requests to a real backend instead of
+	// returning the stub's response
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+	// Fault, if set, makes the stub fail at the network level instead of
+	// returning its response
+	Fault Fault `json:"fault,omitempty"`
+	// Transformers name server-side transformers applied, in order, to the
+	// stub's responses
+	Transformers []string `json:"transformers,omitempty"`
+	// Group is the StubGroup the stub was registered through
+	Group string `json:"group,omitempty"`
+
+	// buildErr is an error the builder could not return, such as a body
+	// that failed to marshal, reported when the stub is registered
+	buildErr error
+}
+
+// SequencedResponse is one response in a stub's response sequence
+type SequencedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body"`
+	Latency *LatencySpec      `json:"latency,omitempty"`
+}
+
+// Respond returns a SequencedResponse with the given status and body
+func Respond(status int, body interface{}) SequencedResponse {
+	return SequencedResponse{Status: status, Body: body}
 }
 
 // MockServer represents an embedded mock server
@@ -42,6 +200,46 @@ type MockServer struct {
 	adminPort int
 	stubs     []ResponseStub
 	portMutex sync.RWMutex // Protects port and adminPort during detection
+
+	mu          sync.Mutex  // Protects the attached resources and sinks below
+	attached    []io.Closer // Sidecars closed on Stop
+	logSink     *LogSink
+	metricsSink *MetricsSink
+	push        *PushMock
+	twilio      *TwilioMock
+	search      *SearchMock
+	uploads     *UploadMock
+	federation  *FederationMock
+	resources   map[string]*ResourceMock
+	webhooks    map[string]*WebhookReceiver
+	oidc        *OIDCProvider
+	s3          *S3Mock
+	recording   *fixtureRecorder // Set between StartRecording and StopRecording
+
+	verifications []verificationRecord // Outcomes for WriteTrafficReport
+
+	dryRun        bool
+	dryRunChanges []DryRunChange
+
+	persistDir string // Set by PersistStubs
+
+	grpcPort    int                   // Detected from output, like port
+	kafkaPort   int                   // Detected from output, like port
+	mqttPort    int                   // Detected from output, like port
+	amqpPort    int                   // Detected from output, like port
+	smtpPort    int                   // Detected from output, like port
+	imapPort    int                   // Detected from output, like port
+	pop3Port    int                   // Detected from output, like port
+	ftpPort     int                   // Detected from output, like port
+	tcpPort     int                   // Detected from output, like port
+	wsPort      int                   // Detected from output, like port
+	grpcMethods map[string]grpcMethod // Declared by RegisterProtoDescriptors
+
+	grpcDescriptors [][]byte       // Registered by RegisterProtoDescriptors, loaded at Start
+	grpcOverrides   []grpcOverride // Registered by StubGRPC, loaded at Start
+	wsScripts       []wsScript     // Registered by StubWebSocket, loaded at Start
+	tcpFixtures     []tcpFixture   // Registered by StubTCP, loaded at Start
+	configDir       string         // Holds the config file written by Start
 }
 
 // NewMockServer creates a new mock server with the given configuration
@@ -60,10 +258,36 @@ func NewMockServer(config MockServerConfig) *MockServer {
 
 // Start starts the mock server
 func (m *MockServer) Start() error {
+	if err := m.config.ValidationMode.validate(); err != nil {
+		return err
+	}
+	if m.config.JournalLimit < 0 {
+		return NewInvalidConfigError("journal limit must not be negative", map[string]interface{}{"journal_limit": m.config.JournalLimit})
+	}
+	if m.config.AsyncAPISpec != "" {
+		if _, err := loadAsyncAPIChannels(m.config.AsyncAPISpec); err != nil {
+			return err
+		}
+	}
+	if m.config.StrictStubbing && m.config.PassthroughUpstream != "" {
+		return NewInvalidConfigError("strict stubbing cannot be combined with a passthrough upstream", map[string]interface{}{"passthrough_upstream": m.config.PassthroughUpstream})
+	}
+
 	args := []string{"serve"}
 
-	if m.config.ConfigFile != "" {
-		args = append(args, "--config", m.config.ConfigFile)
+	configFile, configDir, err := m.writeServerConfig()
+	if err != nil {
+		return err
+	}
+	m.configDir = configDir
+	running := false
+	defer func() {
+		if !running {
+			m.removeConfigDir()
+		}
+	}()
+	if configFile != "" {
+		args = append(args, "--config", configFile)
 	}
 
 	if m.config.OpenAPISpec != "" {
@@ -77,10 +301,45 @@ func (m *MockServer) Start() error {
 		args = append(args, "--http-port", "0")
 	}
 
+	if m.config.GRPCPort != 0 {
+		args = append(args, "--grpc-port", fmt.Sprintf("%d", m.config.GRPCPort))
+	}
+
+	if m.config.WebSocketPort != 0 {
+		args = append(args, "--ws-port", fmt.Sprintf("%d", m.config.WebSocketPort))
+	}
+
+	if m.config.KafkaPort != 0 {
+		args = append(args, "--kafka-port", fmt.Sprintf("%d", m.config.KafkaPort))
+	}
+
+	if m.config.MQTTPort != 0 {
+		args = append(args, "--mqtt-port", fmt.Sprintf("%d", m.config.MQTTPort))
+	}
+
+	if m.config.AMQPPort != 0 {
+		args = append(args, "--amqp-port", fmt.Sprintf("%d", m.config.AMQPPort))
+	}
+
 	// Enable admin API for dynamic stub management
 	args = append(args, "--admin", "--admin-port", "0")
 
 	m.cmd = exec.Command("mockforge", args...)
+	env := m.config.Connection.env()
+	env = append(env, m.config.ValidationMode.env()...)
+	env = append(env, seedEnv(m.config.RandomSeed)...)
+	if m.config.GRPCReflection {
+		env = append(env, "MOCKFORGE_GRPC_REFLECTION=true")
+	}
+	if m.config.JournalLimit > 0 {
+		env = append(env, fmt.Sprintf("MOCKFORGE_REQUEST_JOURNAL_LIMIT=%d", m.config.JournalLimit))
+	}
+	if m.config.StrictStubbing {
+		env = append(env, "MOCKFORGE_STRICT_STUBBING=true")
+	}
+	if len(env) > 0 {
+		m.cmd.Env = append(os.Environ(), env...)
+	}
 
 	// Capture stdout and stderr for port detection
 	stdoutPipe, err := m.cmd.StdoutPipe()
@@ -116,6 +375,26 @@ func (m *MockServer) Start() error {
 		m.cmd = nil  // Clear cmd so IsRunning() returns false
 		return err
 	}
+	running = true
+
+	if m.config.PassthroughUpstream != "" {
+		if err := m.PassthroughUnmatched(m.config.PassthroughUpstream, m.config.RecordPassthrough); err != nil {
+			m.Stop()
+			return err
+		}
+	}
+
+	if err := m.registerWebSocketScripts(); err != nil {
+		m.Stop()
+		return err
+	}
+
+	if m.config.AsyncAPISpec != "" && m.AMQP().Addr() != "" {
+		if err := m.ConfigureAsyncAPI(); err != nil {
+			m.Stop()
+			return err
+		}
+	}
 
 	return nil
 }
@@ -133,6 +412,24 @@ func (m *MockServer) parsePortsFromOutput(stdout io.Reader) {
 	// - "🎛️ Admin UI on port PORT"
 	adminPortPattern := regexp.MustCompile(`Admin UI (?:listening on http://[^:]+:|on port )(\d+)`)
 
+	// Protocol listeners, e.g. "⚡ gRPC server listening on localhost:PORT"
+	// and "📨 Kafka broker listening on HOST:PORT"
+	protocolPorts := []struct {
+		pattern *regexp.Regexp
+		port    *int
+	}{
+		{regexp.MustCompile(`gRPC server (?:listening on [^:\s]+:|on port )(\d+)`), &m.grpcPort},
+		{regexp.MustCompile(`Kafka broker listening on [^:\s]+:(\d+)`), &m.kafkaPort},
+		{regexp.MustCompile(`MQTT broker listening on [^:\s]+:(\d+)`), &m.mqttPort},
+		{regexp.MustCompile(`AMQP broker listening on [^:\s]+:(\d+)`), &m.amqpPort},
+		{regexp.MustCompile(`SMTP server listening on [^:\s]+:(\d+)`), &m.smtpPort},
+		{regexp.MustCompile(`IMAP server listening on [^:\s]+:(\d+)`), &m.imapPort},
+		{regexp.MustCompile(`POP3 server listening on [^:\s]+:(\d+)`), &m.pop3Port},
+		{regexp.MustCompile(`FTP server listening on [^:\s]+:(\d+)`), &m.ftpPort},
+		{regexp.MustCompile(`TCP server listening on [^:\s]+:(\d+)`), &m.tcpPort},
+		{regexp.MustCompile(`WebSocket server listening on ws://[^:\s]+:(\d+)`), &m.wsPort},
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -157,6 +454,17 @@ func (m *MockServer) parsePortsFromOutput(stdout io.Reader) {
 			}
 			m.portMutex.Unlock()
 		}
+
+		// Parse protocol listener ports
+		for _, protocol := range protocolPorts {
+			if matches := protocol.pattern.FindStringSubmatch(line); matches != nil {
+				m.portMutex.Lock()
+				if port, err := strconv.Atoi(matches[1]); err == nil && port > 0 {
+					*protocol.port = port
+				}
+				m.portMutex.Unlock()
+			}
+		}
 	}
 }
 
@@ -196,8 +504,17 @@ func (m *MockServer) waitForServer() error {
 			}
 
 			resp, err := http.Get(fmt.Sprintf("http://%s:%d/health", m.host, port))
-			if err == nil && resp.StatusCode == 200 {
-				resp.Body.Close()
+			if err != nil {
+				continue
+			}
+			resp.Body.Close()
+
+			// Admin calls made right after Start need the admin port too,
+			// announced once the admin UI server has bound it
+			m.portMutex.RLock()
+			adminPort := m.adminPort
+			m.portMutex.RUnlock()
+			if resp.StatusCode == 200 && adminPort != 0 {
 				return nil
 			}
 		}
@@ -222,67 +539,261 @@ func (m *MockServer) StubResponseWithOptions(
 	}
 
 	stub := ResponseStub{
-		Method:    method,
-		Path:      path,
-		Status:    status,
-		Headers:   headers,
-		Body:      body,
-		LatencyMs: latencyMs,
-	}
-
-	m.stubs = append(m.stubs, stub)
-
-	// If admin API is available, use it to add the stub dynamically
-	if m.adminPort != 0 {
-		// Convert ResponseStub to MockConfig format expected by Admin API
-		mockConfig := map[string]interface{}{
-			"id":     "",                                           // Empty ID - server will generate one
-			"name":   fmt.Sprintf("%s %s", stub.Method, stub.Path), // Generate a name from method and path
-			"method": stub.Method,
-			"path":   stub.Path,
-			"response": map[string]interface{}{
-				"body": stub.Body,
-			},
-			"enabled": true,
-		}
+		Method:  method,
+		Path:    path,
+		Status:  status,
+		Headers: headers,
+		Body:    body,
+	}
+	if latencyMs != nil {
+		stub.Latency = FixedLatency(time.Duration(*latencyMs) * time.Millisecond)
+	}
+
+	return m.AddStub(stub)
+}
+
+// AddStub registers a stub, typically one produced by StubBuilder.Build
+func (m *MockServer) AddStub(stub ResponseStub) error {
+	_, err := m.CreateStub(stub)
+	return err
+}
 
-		// Add optional fields only if they have values
-		if len(stub.Headers) > 0 {
-			response := mockConfig["response"].(map[string]interface{})
-			response["headers"] = stub.Headers
+// validate checks the stub is well-formed, resolving its body file path
+func (stub *ResponseStub) validate() error {
+	if stub.buildErr != nil {
+		return stub.buildErr
+	}
+	if err := validateStubPath(stub.Path); err != nil {
+		return err
+	}
+	if err := stub.Fault.validate(); err != nil {
+		return err
+	}
+	if err := stub.validateBody(); err != nil {
+		return err
+	}
+	if err := stub.Encoding.validate(); err != nil {
+		return err
+	}
+	if err := validateRequestSchema(stub); err != nil {
+		return err
+	}
+	if stub.Match != nil {
+		for xpath := range stub.Match.XPaths {
+			if _, _, err := parseXPath(xpath); err != nil {
+				return err
+			}
 		}
-		if stub.LatencyMs != nil {
-			mockConfig["latency_ms"] = *stub.LatencyMs
+	}
+	if stub.Match != nil && stub.Match.Expression != "" {
+		if err := validateExpression(stub.Match.Expression); err != nil {
+			return err
 		}
-		if stub.Status != 200 {
-			mockConfig["status_code"] = stub.Status
+	}
+	for _, name := range stub.Transformers {
+		if err := validateTransformerName(name); err != nil {
+			return err
 		}
-
-		mockConfigJSON, err := json.Marshal(mockConfig)
-		if err != nil {
+	}
+	if stub.RateLimit != nil {
+		if err := stub.RateLimit.validate(); err != nil {
 			return err
 		}
-
-		resp, err := http.Post(
-			fmt.Sprintf("http://%s:%d/__mockforge/api/mocks", m.host, m.adminPort),
-			"application/json",
-			bytes.NewBuffer(mockConfigJSON),
-		)
-		if err == nil {
-			resp.Body.Close()
+	}
+	if stub.Encoding != "" && len(stub.RawResponse) > 0 {
+		return NewInvalidConfigError("raw responses are served verbatim and cannot be encoded", map[string]interface{}{
+			"path": stub.Path,
+		})
+	}
+	if stub.Encoding != "" && len(stub.Chunks) > 0 {
+		return NewInvalidConfigError("streamed chunks are written as given and cannot be encoded", map[string]interface{}{
+			"path": stub.Path,
+		})
+	}
+	if len(stub.RawResponse) > 0 && !bytes.HasPrefix(stub.RawResponse, []byte("HTTP/")) {
+		return NewInvalidConfigError("raw response must start with an HTTP/1.x status line", map[string]interface{}{
+			"path": stub.Path,
+		})
+	}
+	for _, callback := range stub.Callbacks {
+		if err := validateUpstream(callback.URL); err != nil && !strings.Contains(callback.URL, "{{") {
+			return NewInvalidConfigError("callback URL must be an absolute http or https URL", map[string]interface{}{
+				"url": callback.URL,
+			})
+		}
+	}
+	if stub.Proxy != nil {
+		if err := validateUpstream(stub.Proxy.UpstreamURL); err != nil {
+			return err
+		}
+	}
+	if stub.Latency != nil {
+		if err := stub.Latency.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, resp := range stub.Sequence {
+		if resp.Latency != nil {
+			if err := resp.Latency.Validate(); err != nil {
+				return err
+			}
 		}
 	}
+	if stub.Times < 0 || stub.ExpiresAfter < 0 {
+		return NewInvalidConfigError("stub call limit and expiry must not be negative", map[string]interface{}{
+			"times":         stub.Times,
+			"expires_after": stub.ExpiresAfter.String(),
+		})
+	}
 
 	return nil
 }
 
+// mockConfig converts the stub to the MockConfig format expected by the Admin API
+func (stub ResponseStub) mockConfig() map[string]interface{} {
+	mockConfig := map[string]interface{}{
+		"id":     "",                                           // Empty ID - server will generate one
+		"name":   fmt.Sprintf("%s %s", stub.Method, stub.Path), // Generate a name from method and path
+		"method": stub.Method,
+		"path":   stub.Path,
+		"response": map[string]interface{}{
+			"body": stub.Body,
+		},
+		"enabled": true,
+	}
+
+	// Regex paths are sent without the SDK prefix and flagged for the matcher
+	if isRegexPath(stub.Path) {
+		mockConfig["path"] = strings.TrimPrefix(stub.Path, regexPathPrefix)
+		mockConfig["path_match"] = "regex"
+	}
+
+	// Add optional fields only if they have values
+	response := mockConfig["response"].(map[string]interface{})
+	switch {
+	case len(stub.BodyBytes) > 0:
+		delete(response, "body")
+		response["body_base64"] = base64.StdEncoding.EncodeToString(stub.BodyBytes)
+	case stub.BodyFile != "":
+		delete(response, "body")
+		response["body_file"] = stub.BodyFile
+	case len(stub.Chunks) > 0:
+		delete(response, "body")
+		response["chunks"] = chunkConfigs(stub.Chunks)
+		if stub.KeepOpen {
+			response["keep_open"] = true
+		}
+	}
+	if headers := stub.responseHeaders(); len(headers) > 0 {
+		response["headers"] = headers
+	}
+	if len(stub.SetCookies) > 0 {
+		response["set_cookies"] = stub.SetCookies
+	}
+	if stub.Encoding != "" {
+		response["content_encoding"] = string(stub.Encoding)
+	}
+	if stub.Throttle > 0 {
+		response["throttle_bytes_per_second"] = stub.Throttle
+	}
+	if len(stub.RawResponse) > 0 {
+		response["raw_base64"] = base64.StdEncoding.EncodeToString(stub.RawResponse)
+	}
+	if stub.Fault != "" {
+		response["fault"] = map[string]interface{}{"type": stub.Fault}
+	}
+	if stub.Latency != nil {
+		setLatency(mockConfig, stub.Latency)
+	}
+	if stub.Status != 200 {
+		mockConfig["status_code"] = stub.Status
+	}
+	if stub.Match != nil && !stub.Match.IsEmpty() {
+		mockConfig["request_match"] = stub.Match
+	}
+	if len(stub.RequestSchema) > 0 {
+		mockConfig["request_schema"] = stub.RequestSchema
+	}
+	if stub.Priority != 0 {
+		mockConfig["priority"] = stub.Priority
+	}
+	if stub.Group != "" {
+		mockConfig["group"] = stub.Group
+	}
+	if len(stub.Transformers) > 0 {
+		mockConfig["transformers"] = stub.Transformers
+	}
+	if stub.Scenario != "" {
+		mockConfig["scenario"] = stub.Scenario
+		if stub.RequiredState != "" {
+			mockConfig["required_scenario_state"] = stub.RequiredState
+		}
+		if stub.NewState != "" {
+			mockConfig["new_scenario_state"] = stub.NewState
+		}
+	}
+	if len(stub.Sequence) > 0 {
+		sequence := make([]map[string]interface{}, len(stub.Sequence))
+		for i, resp := range stub.Sequence {
+			status := resp.Status
+			if status == 0 {
+				status = 200
+			}
+			sequence[i] = map[string]interface{}{"status_code": status, "body": resp.Body}
+			if len(resp.Headers) > 0 {
+				sequence[i]["headers"] = resp.Headers
+			}
+			if resp.Latency != nil {
+				setLatency(sequence[i], resp.Latency)
+			}
+		}
+		mockConfig["response_sequence"] = sequence
+	}
+	if stub.Times > 0 {
+		mockConfig["max_matches"] = stub.Times
+	}
+	if stub.RateLimit != nil {
+		mockConfig["rate_limit"] = stub.RateLimit.mockConfig()
+	}
+	if stub.ExpiresAfter > 0 {
+		mockConfig["expires_after_ms"] = stub.ExpiresAfter.Milliseconds()
+	}
+	if len(stub.Callbacks) > 0 {
+		callbacks := make([]map[string]interface{}, len(stub.Callbacks))
+		for i, callback := range stub.Callbacks {
+			callbacks[i] = callback.mockConfig()
+		}
+		mockConfig["callbacks"] = callbacks
+	}
+	if stub.Proxy != nil {
+		mockConfig["proxy"] = stub.Proxy
+	}
+
+	return mockConfig
+}
+
+// setLatency adds latency to a mock config. Fixed delays use the plain
+// latency_ms key every server version understands.
+func setLatency(config map[string]interface{}, latency *LatencySpec) {
+	if latency.Distribution == LatencyFixed {
+		config["latency_ms"] = int(math.Round(latency.FixedMs))
+		return
+	}
+	config["latency"] = latency
+}
+
 // ClearStubs removes all stubs
 func (m *MockServer) ClearStubs() error {
+	if m.isDryRun() {
+		m.recordDryRun("clear stubs", m.stubTargets())
+		return nil
+	}
+
 	m.stubs = make([]ResponseStub, 0)
 
-	if m.adminPort != 0 {
+	if mocksURL, err := m.adminURL("/__mockforge/api/mocks"); err == nil {
 		// Get all mocks and delete them one by one
-		resp, err := http.Get(fmt.Sprintf("http://%s:%d/__mockforge/api/mocks", m.host, m.adminPort))
+		resp, err := http.Get(mocksURL)
 		if err == nil {
 			var result struct {
 				Mocks []struct {
@@ -295,7 +806,7 @@ func (m *MockServer) ClearStubs() error {
 				for _, mock := range result.Mocks {
 					req, err := http.NewRequest(
 						"DELETE",
-						fmt.Sprintf("http://%s:%d/__mockforge/api/mocks/%s", m.host, m.adminPort, mock.ID),
+						mocksURL+"/"+mock.ID,
 						nil,
 					)
 					if err == nil {
@@ -311,7 +822,7 @@ func (m *MockServer) ClearStubs() error {
 		}
 	}
 
-	return nil
+	return m.persist()
 }
 
 // URL returns the server URL
@@ -328,6 +839,29 @@ func (m *MockServer) Port() int {
 	return m.port
 }
 
+// GRPCAddress returns the host:port gRPC clients dial, or an empty string
+// if the server has not reported a gRPC listener
+func (m *MockServer) GRPCAddress() string {
+	m.portMutex.RLock()
+	defer m.portMutex.RUnlock()
+	if m.grpcPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", m.host, m.grpcPort)
+}
+
+// WebSocketURL returns the URL of the WebSocket endpoint StubWebSocket
+// scripts, or an empty string if the server has not reported a WebSocket
+// listener
+func (m *MockServer) WebSocketURL() string {
+	m.portMutex.RLock()
+	defer m.portMutex.RUnlock()
+	if m.wsPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ws://%s:%d/ws", m.host, m.wsPort)
+}
+
 // IsRunning checks if the server is running
 func (m *MockServer) IsRunning() bool {
 	return m.cmd != nil && m.cmd.Process != nil
@@ -335,6 +869,8 @@ func (m *MockServer) IsRunning() bool {
 
 // Stop stops the mock server
 func (m *MockServer) Stop() error {
+	m.closeAttached()
+
 	if m.cmd != nil && m.cmd.Process != nil {
 		if err := m.cmd.Process.Kill(); err != nil {
 			return err
@@ -342,9 +878,18 @@ func (m *MockServer) Stop() error {
 		m.cmd.Wait()
 		m.cmd = nil
 	}
+	m.removeConfigDir()
 	return nil
 }
 
+// removeConfigDir deletes the config file Start wrote, if any
+func (m *MockServer) removeConfigDir() {
+	if m.configDir != "" {
+		os.RemoveAll(m.configDir)
+		m.configDir = ""
+	}
+}
+
 // FixtureInfo represents fixture metadata
 type FixtureInfo struct {
 	ID       string                 `json:"id"`
@@ -0,0 +1,260 @@
+//go:build integration && go1.24
+
+// gRPC integration tests. They call the server over cleartext HTTP/2, which
+// net/http supports from Go 1.24, hence the separate build constraint.
+package mockforge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// protoVarintField encodes a varint protobuf field with a value below 128
+func protoVarintField(number int, value byte) []byte {
+	return []byte{byte(number << 3), value}
+}
+
+// protoStringField encodes a string field of a descriptor
+func protoStringField(number int, value string) []byte {
+	return protoBytes(number, []byte(value))
+}
+
+// grpcIntegrationDescriptorSet declares shop.v1.Orders with a unary
+// GetOrder, a server-streaming WatchOrders, a client-streaming ImportOrders,
+// and a bidirectional SyncOrders, over messages with string fields
+func grpcIntegrationDescriptorSet() []byte {
+	field := func(name string, number byte) []byte {
+		out := protoStringField(1, name)
+		out = append(out, protoVarintField(3, number)...)
+		out = append(out, protoVarintField(4, 1)...) // LABEL_OPTIONAL
+		out = append(out, protoVarintField(5, 9)...) // TYPE_STRING
+		return append(out, protoStringField(10, name)...)
+	}
+	request := append(protoStringField(1, "GetOrderRequest"), protoBytes(2, field("id", 1))...)
+	order := append(protoStringField(1, "Order"), protoBytes(2, field("id", 1))...)
+	order = append(order, protoBytes(2, field("status", 2))...)
+
+	method := func(name string, clientStreaming, serverStreaming bool) []byte {
+		out := protoStringField(1, name)
+		out = append(out, protoStringField(2, ".shop.v1.GetOrderRequest")...)
+		out = append(out, protoStringField(3, ".shop.v1.Order")...)
+		if clientStreaming {
+			out = append(out, protoVarintField(5, 1)...)
+		}
+		if serverStreaming {
+			out = append(out, protoVarintField(6, 1)...)
+		}
+		return out
+	}
+	service := protoStringField(1, "Orders")
+	service = append(service, protoBytes(2, method("GetOrder", false, false))...)
+	service = append(service, protoBytes(2, method("WatchOrders", false, true))...)
+	service = append(service, protoBytes(2, method("ImportOrders", true, false))...)
+	service = append(service, protoBytes(2, method("SyncOrders", true, true))...)
+
+	file := append(protoStringField(1, "shop/v1/orders.proto"), protoStringField(2, "shop.v1")...)
+	file = append(file, protoStringField(12, "proto3")...)
+	file = append(file, protoBytes(4, request)...)
+	file = append(file, protoBytes(4, order)...)
+	file = append(file, protoBytes(6, service)...)
+	return protoBytes(1, file)
+}
+
+// grpcResult is the outcome of a gRPC call: the response messages and the
+// status the call ended with
+type grpcResult struct {
+	messages [][]byte
+	status   string
+	message  string
+}
+
+// callGRPC calls method, given as "/pkg.Service/Method", sending requests as
+// the client's stream
+func callGRPC(t *testing.T, server *MockServer, method string, requests ...[]byte) grpcResult {
+	t.Helper()
+	address := server.GRPCAddress()
+	if address == "" {
+		t.Fatal("Expected the server to report its gRPC address")
+	}
+
+	var body bytes.Buffer
+	for _, request := range requests {
+		header := [5]byte{}
+		binary.BigEndian.PutUint32(header[1:], uint32(len(request)))
+		body.Write(header[:])
+		body.Write(request)
+	}
+	req, err := http.NewRequest("POST", "http://"+address+method, &body)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}, Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call %s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response of %s: %v", method, err)
+	}
+
+	var result grpcResult
+	for len(data) >= 5 {
+		length := int(binary.BigEndian.Uint32(data[1:5]))
+		if len(data) < 5+length {
+			t.Fatalf("Truncated response message from %s", method)
+		}
+		result.messages = append(result.messages, data[5:5+length])
+		data = data[5+length:]
+	}
+	// Calls failing before any message report their status in the headers
+	trailer := resp.Trailer
+	if trailer.Get("Grpc-Status") == "" {
+		trailer = resp.Header
+	}
+	result.status, result.message = trailer.Get("Grpc-Status"), trailer.Get("Grpc-Message")
+	return result
+}
+
+// orderFields decodes an Order message into its field values by number
+func orderFields(t *testing.T, message []byte) map[int]string {
+	t.Helper()
+	fields, err := parseProtoFields(message)
+	if err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+	values := make(map[int]string, len(fields))
+	for _, field := range fields {
+		values[field.number] = string(field.bytes)
+	}
+	return values
+}
+
+func TestMockServerGRPCCalls(t *testing.T) {
+	requireCLI(t)
+	server := NewMockServer(MockServerConfig{GRPCPort: freePort(t), GRPCReflection: true})
+	if err := server.RegisterProtoDescriptors(grpcIntegrationDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register descriptors: %v", err)
+	}
+	stubs := []error{
+		server.StubGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "42"}, map[string]interface{}{"id": "42", "status": "SHIPPED"}),
+		server.StubGRPC("shop.v1.Orders/GetOrder", nil, GRPCError(GRPCNotFound, "order not found")),
+		server.StubGRPCServerStream("shop.v1.Orders/WatchOrders", []StreamMessage{
+			StreamSend(map[string]interface{}{"status": "PACKED"}, 0),
+			StreamSend(map[string]interface{}{"status": "SHIPPED"}, 10*time.Millisecond),
+			StreamFail(GRPCUnavailable, "connection reset", 0),
+		}),
+		server.StubGRPCClientStream("shop.v1.Orders/ImportOrders", map[string]interface{}{"status": "IMPORTED"}),
+		server.StubGRPCBidiStream("shop.v1.Orders/SyncOrders", []StreamMessage{
+			StreamSend(map[string]interface{}{"status": "SYNCING"}, 0),
+			{Message: map[string]interface{}{"status": "SYNCED"}, AfterRequests: 2},
+		}),
+	}
+	for _, err := range stubs {
+		if err != nil {
+			t.Fatalf("Failed to stub method: %v", err)
+		}
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	order := func(id string) []byte { return protoStringField(1, id) }
+	statuses := func(result grpcResult) []string {
+		var values []string
+		for _, message := range result.messages {
+			values = append(values, orderFields(t, message)[2])
+		}
+		return values
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		result := callGRPC(t, server, "/shop.v1.Orders/GetOrder", order("42"))
+		if result.status != "0" || len(result.messages) != 1 || !reflect.DeepEqual(orderFields(t, result.messages[0]), map[int]string{1: "42", 2: "SHIPPED"}) {
+			t.Errorf("Expected order 42 shipped, got %+v", result)
+		}
+		result = callGRPC(t, server, "/shop.v1.Orders/GetOrder", order("7"))
+		if result.status != "5" || result.message != "order not found" {
+			t.Errorf("Expected NOT_FOUND for another order, got %+v", result)
+		}
+	})
+
+	t.Run("server stream", func(t *testing.T) {
+		result := callGRPC(t, server, "/shop.v1.Orders/WatchOrders", order("42"))
+		if !reflect.DeepEqual(statuses(result), []string{"PACKED", "SHIPPED"}) || result.status != "14" || result.message != "connection reset" {
+			t.Errorf("Expected two updates then UNAVAILABLE, got %+v", result)
+		}
+	})
+
+	t.Run("client stream", func(t *testing.T) {
+		result := callGRPC(t, server, "/shop.v1.Orders/ImportOrders", order("1"), order("2"), order("3"))
+		if !reflect.DeepEqual(statuses(result), []string{"IMPORTED"}) || result.status != "0" {
+			t.Errorf("Expected one IMPORTED response, got %+v", result)
+		}
+	})
+
+	t.Run("bidirectional stream", func(t *testing.T) {
+		result := callGRPC(t, server, "/shop.v1.Orders/SyncOrders", order("1"), order("2"))
+		if !reflect.DeepEqual(statuses(result), []string{"SYNCING", "SYNCED"}) || result.status != "0" {
+			t.Errorf("Expected SYNCING then SYNCED after two requests, got %+v", result)
+		}
+		result = callGRPC(t, server, "/shop.v1.Orders/SyncOrders", order("1"))
+		if !reflect.DeepEqual(statuses(result), []string{"SYNCING"}) || result.status != "0" {
+			t.Errorf("Expected the stream to end with OK when the client stops early, got %+v", result)
+		}
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		result, err := server.VerifyGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "42"}, Exactly(1))
+		if err != nil || !result.Matched {
+			t.Errorf("Expected one GetOrder call for order 42, got %+v (%v)", result, err)
+		}
+		result, err = server.VerifyGRPC("shop.v1.Orders/GetOrder", map[string]interface{}{"id": "9"}, AtLeastOnce())
+		if err != nil || result.Matched || len(result.NearMisses) != 2 {
+			t.Errorf("Expected both calls as near misses for order 9, got %+v (%v)", result, err)
+		}
+		if result, err := server.VerifyGRPC("shop.v1.Orders/ImportOrders", nil, Exactly(1)); err != nil || !result.Matched {
+			t.Errorf("Expected one ImportOrders call, got %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("reflection", func(t *testing.T) {
+		// ServerReflectionRequest.list_services = 7
+		result := callGRPC(t, server, "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", protoStringField(7, ""))
+		if result.status != "0" || len(result.messages) != 1 {
+			t.Fatalf("Expected one reflection response, got %+v", result)
+		}
+		// ServerReflectionResponse.list_services_response = 6, whose
+		// services = 1 are ServiceResponse messages with name = 1
+		var services []string
+		response, _ := parseProtoFields(result.messages[0])
+		for _, field := range response {
+			if field.number != 6 {
+				continue
+			}
+			listed, _ := parseProtoFields(field.bytes)
+			for _, service := range listed {
+				if service.number == 1 {
+					services = append(services, orderFields(t, service.bytes)[1])
+				}
+			}
+		}
+		sort.Strings(services)
+		if i := sort.SearchStrings(services, "shop.v1.Orders"); i == len(services) || services[i] != "shop.v1.Orders" {
+			t.Errorf("Expected shop.v1.Orders listed by reflection, got %v", services)
+		}
+	})
+}
@@ -11,9 +11,75 @@
 package mockforge
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 )
 
+// requireCLI skips the test when the MockForge CLI is not on PATH
+func requireCLI(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("mockforge"); err != nil {
+		t.Skip("Requires MockForge CLI to be installed")
+	}
+}
+
+// startCLIServer starts a server with the MockForge CLI for the rest of the
+// test, skipping the test when the CLI is not on PATH
+func startCLIServer(t *testing.T, config MockServerConfig) *MockServer {
+	t.Helper()
+	requireCLI(t)
+	server := NewMockServer(config)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+	return server
+}
+
+// sendRequest sends a request to the server and returns the response status
+func sendRequest(t *testing.T, server *MockServer, method, path string, headers map[string]string, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL()+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
 func TestMockServerStart(t *testing.T) {
 	t.Skip("Requires MockForge CLI to be installed")
 
@@ -103,3 +169,3395 @@ func TestMockServerFixtureOperations(t *testing.T) {
 		}
 	}
 }
+
+func TestMockServerPathParams(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+
+	if err := server.StubResponse("GET", "/users/{id}", map[string]interface{}{"id": PathParam("id")}); err != nil {
+		t.Fatalf("Failed to stub template path: %v", err)
+	}
+	if err := server.StubResponse("GET", PathRegex(`^/orders/(?P<id>\d+)$`), map[string]interface{}{"order": PathParam("id")}); err != nil {
+		t.Fatalf("Failed to stub regex path: %v", err)
+	}
+
+	for path, want := range map[string]string{"/users/42": `{"id":"42"}`, "/orders/7": `{"order":"7"}`} {
+		resp, err := http.Get(server.URL() + path)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("%s: expected %s, got %s", path, want, body)
+		}
+	}
+	if got := sendRequest(t, server, "GET", "/orders/abc", nil, ""); got != 404 {
+		t.Errorf("Expected the regex to reject /orders/abc, got %d", got)
+	}
+}
+
+func TestMockServerSSE(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	events := []SSEEvent{{Name: "ready", Data: "1"}, {Data: "2", Delay: 100 * time.Millisecond}}
+	if err := server.StubSSE("/events", events); err != nil {
+		t.Fatalf("Failed to stub SSE: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL()+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %v", resp.Header)
+	}
+
+	want := "event: ready\ndata: 1\n\ndata: 2\n\n"
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(resp.Body, got); err != nil || string(got) != want {
+		t.Fatalf("Expected %q, got %q, %v", want, got, err)
+	}
+	// The stream stays open after the last event until the client leaves
+	held := make(chan error, 1)
+	go func() {
+		_, err := resp.Body.Read(make([]byte, 1))
+		held <- err
+	}()
+	select {
+	case err := <-held:
+		t.Errorf("Expected the stream held open, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestMockServerContentEncoding(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/report").Body(map[string]string{"report": "q3"}).GzipBody().Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	// The transport decompresses gzip it asked for, and reports it did
+	resp, err := http.Get(server.URL() + "/report")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !resp.Uncompressed || string(body) != `{"report":"q3"}` {
+		t.Errorf("Expected a gzip body, got uncompressed=%v %q", resp.Uncompressed, body)
+	}
+}
+
+func TestMockServerThrottle(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/download").BodyBytes(make([]byte, 2048)).ThrottleBytesPerSecond(4096).Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := http.Get(server.URL() + "/download")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if elapsed := time.Since(start); len(body) != 2048 || elapsed < 400*time.Millisecond {
+		t.Errorf("Expected 2048 bytes over about half a second, got %d in %v", len(body), elapsed)
+	}
+}
+
+func TestMockServerLatencyDistribution(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/slow").LatencyUniform(200*time.Millisecond, 300*time.Millisecond).Body("ok").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	start := time.Now()
+	if got := sendRequest(t, server, "GET", "/slow", nil, ""); got != 200 {
+		t.Fatalf("Expected 200, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected at least the uniform minimum, took %v", elapsed)
+	}
+
+	// Without deviation, normal and log-normal delays are their mean
+	stubs := []ResponseStub{
+		NewStubBuilder("GET", "/normal").LatencyNormal(200*time.Millisecond, 0).Build(),
+		NewStubBuilder("GET", "/lognormal").LatencyLogNormal(200*time.Millisecond, 0).Build(),
+		NewStubBuilder("GET", "/percentiles").LatencyPercentiles(200*time.Millisecond, 250*time.Millisecond, 300*time.Millisecond).Build(),
+	}
+	if err := server.StubAll(stubs); err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+	for _, path := range []string{"/normal", "/lognormal"} {
+		if status, elapsed := timedGet(t, server, path); status != 200 || elapsed < 200*time.Millisecond || elapsed > time.Second {
+			t.Errorf("%s: expected 200 after about 200ms, got %d after %v", path, status, elapsed)
+		}
+	}
+	var slowest time.Duration
+	for i := 0; i < 8; i++ {
+		status, elapsed := timedGet(t, server, "/percentiles")
+		if status != 200 || elapsed > time.Second {
+			t.Fatalf("Expected 200 within the percentile table, got %d after %v", status, elapsed)
+		}
+		if elapsed > slowest {
+			slowest = elapsed
+		}
+	}
+	if slowest < 150*time.Millisecond {
+		t.Errorf("Expected some responses near the P50 of 200ms, slowest took %v", slowest)
+	}
+}
+
+func TestMockServerStubResponseWithOptions(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	latency := 150
+	err := server.StubResponseWithOptions("PUT", "/items/1", map[string]interface{}{"updated": true}, 202,
+		map[string]string{"X-Version": "2"}, &latency)
+	if err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+
+	req, _ := http.NewRequest("PUT", server.URL()+"/items/1", strings.NewReader(`{}`))
+	started := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 202 || resp.Header.Get("X-Version") != "2" || string(body) != `{"updated":true}` {
+		t.Errorf("Expected the stubbed 202 with X-Version, got %d %v %s", resp.StatusCode, resp.Header, body)
+	}
+	if elapsed := time.Since(started); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected the 150ms latency, took %v", elapsed)
+	}
+}
+
+func TestMockServerCookies(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+
+	stub := NewStubBuilder("GET", "/account").
+		WhenCookie("session", "abc123").
+		SetCookie("theme", "dark", CookieOptions{Path: "/"}).
+		SetCookie("seen", "1", CookieOptions{HTTPOnly: true}).
+		Body("ok").
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	if got := sendRequest(t, server, "GET", "/account", map[string]string{"Cookie": "session=other"}, ""); got != 404 {
+		t.Errorf("Expected the wrong cookie unmatched, got %d", got)
+	}
+	req, _ := http.NewRequest("GET", server.URL()+"/account", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if cookies := resp.Cookies(); resp.StatusCode != 200 || len(cookies) != 2 || cookies[0].Name != "theme" || !cookies[1].HttpOnly {
+		t.Errorf("Expected 200 setting both cookies, got %d %v", resp.StatusCode, cookies)
+	}
+}
+
+func TestMockServerBinaryBodies(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("id,total\n1,9.99\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write body file: %v", err)
+	}
+	png := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	for _, stub := range []ResponseStub{
+		NewStubBuilder("GET", "/logo.png").BodyBytes(png).Build(),
+		NewStubBuilder("GET", "/report").BodyFile(path).Build(),
+	} {
+		if err := server.AddStub(stub); err != nil {
+			t.Fatalf("Failed to add stub: %v", err)
+		}
+	}
+
+	for route, want := range map[string]string{"/logo.png": string(png), "/report": "id,total\n1,9.99\n"} {
+		resp, err := http.Get(server.URL() + route)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("%s: expected %q, got %q", route, want, body)
+		}
+	}
+}
+
+func TestMockServerRecordsRequestBodies(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.StubResponse("POST", "/upload", map[string]interface{}{"ok": true}); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	// A reader without a known length is sent with chunked encoding
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte(`{"part":`))
+		pw.Write([]byte(`1}`))
+		pw.Close()
+	}()
+	resp, err := http.Post(server.URL()+"/upload", "application/json", pr)
+	if err != nil {
+		t.Fatalf("Failed to send chunked request: %v", err)
+	}
+	resp.Body.Close()
+
+	large := strings.Repeat("x", 64*1024+10)
+	if status := sendRequest(t, server, "POST", "/upload", nil, large); status != http.StatusOK {
+		t.Fatalf("Expected 200 for a large body, got %d", status)
+	}
+
+	requests, err := server.GetRequests(RequestFilter{Method: "POST", Path: "/upload"})
+	if err != nil {
+		t.Fatalf("Failed to get requests: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 recorded requests, got %d", len(requests))
+	}
+	if string(requests[0].Body) != `{"part":1}` || requests[0].BodyTruncated {
+		t.Errorf("Expected the chunked body in full, got %q (truncated %v)", requests[0].Body, requests[0].BodyTruncated)
+	}
+	if len(requests[1].Body) != 64*1024 || !requests[1].BodyTruncated {
+		t.Errorf("Expected a truncated 64 KiB body, got %d bytes (truncated %v)", len(requests[1].Body), requests[1].BodyTruncated)
+	}
+}
+
+func TestMockServerRecordsBinaryBodies(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.StubResponse("POST", "/upload", map[string]interface{}{"ok": true}); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	body := []byte{0xff, 0xfe, 0x00, 'o', 'k', 0x80}
+	headers := map[string]string{"Content-Type": "application/octet-stream"}
+	if status := sendRequest(t, server, "POST", "/upload", headers, string(body)); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+
+	requests, err := server.GetRequests(RequestFilter{Path: "/upload"})
+	if err != nil {
+		t.Fatalf("Failed to get requests: %v", err)
+	}
+	if len(requests) != 1 || !bytes.Equal(requests[0].Body, body) {
+		t.Fatalf("Expected the body bytes intact, got %v", requests)
+	}
+
+	dir := t.TempDir()
+	if n, err := server.WriteFuzzCorpus(VerificationRequest{Path: "/upload"}, dir, CorpusOptions{}); err != nil || n != 1 {
+		t.Fatalf("Expected one seed, got %d (%v)", n, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	data, _ := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if want := "go test fuzz v1\n[]byte(" + strconv.Quote(string(body)) + ")\n"; string(data) != want {
+		t.Errorf("Expected the seed to hold the body bytes, got %q", data)
+	}
+}
+
+func TestMockServerFaults(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	for path, fault := range map[string]Fault{
+		"/reset":     FaultConnectionReset,
+		"/empty":     FaultEmptyResponse,
+		"/malformed": FaultMalformedChunk,
+		"/trickle":   FaultSlowTrickle,
+	} {
+		stub := NewStubBuilder("GET", path).Body(map[string]interface{}{"ok": true}).Fault(fault).Build()
+		if err := server.AddStub(stub); err != nil {
+			t.Fatalf("Failed to add %s stub: %v", fault, err)
+		}
+	}
+	// A fresh connection per request, so no request is retried on a reused one
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	t.Run("connection reset", func(t *testing.T) {
+		_, err := client.Get(server.URL() + "/reset")
+		if !errors.Is(err, syscall.ECONNRESET) {
+			t.Errorf("Expected a connection reset, got %v", err)
+		}
+	})
+
+	t.Run("empty response", func(t *testing.T) {
+		_, err := client.Get(server.URL() + "/empty")
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Expected EOF before a response, got %v", err)
+		}
+	})
+
+	t.Run("malformed chunk", func(t *testing.T) {
+		resp, err := client.Get(server.URL() + "/malformed")
+		if err != nil {
+			t.Fatalf("Expected the status and headers, got %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200, got %d", resp.StatusCode)
+		}
+		if _, err := io.ReadAll(resp.Body); err == nil {
+			t.Error("Expected reading the malformed chunked body to fail")
+		}
+	})
+
+	t.Run("slow trickle", func(t *testing.T) {
+		slow := &http.Client{Transport: client.Transport, Timeout: 300 * time.Millisecond}
+		resp, err := slow.Get(server.URL() + "/trickle")
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("Expected the trickled body to time out, got %v", err)
+		}
+	})
+}
+
+func TestMockServerRawHTTP(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	raw := []byte("HTTP/1.1 299 Whatever\r\nX-B: 1\r\nX-A: 2\r\n\r\nhi")
+	if err := server.AddStub(NewStubBuilder("GET", "/legacy").RawHTTP(raw).Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL(), "http://"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /legacy HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Errorf("Expected the raw response verbatim, got %q", got)
+	}
+}
+
+func TestMockServerRequestMatching(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+
+	stub := NewStubBuilder("POST", "/refunds").
+		Status(201).
+		WhenHeader("X-Tenant", "acme").
+		WhenQuery("dry_run", "false").
+		WhenJSONPath("$.type", "refund").
+		WhenJSONPath("$.items[*].sku", "A1").
+		Body(map[string]interface{}{"ok": true}).
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	exact := NewStubBuilder("POST", "/refunds/exact").
+		WhenBodyJSON(map[string]interface{}{"type": "refund", "amount": 10}).
+		Body("exact").
+		Build()
+	if err := server.AddStub(exact); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	versioned := NewStubBuilder("GET", "/catalog").
+		WhenHeader("Accept", "application/json+v1").
+		WhenHeaderMatches("Authorization", "^Bearer ").
+		Body("v1").
+		Build()
+	if err := server.AddStub(versioned); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	for _, tc := range []struct {
+		accept string
+		want   int
+	}{
+		{"application/json+v1", 200},
+		{"application/jsonnv1", 404},
+		{"xapplication/json+v1", 404},
+	} {
+		headers := map[string]string{"Accept": tc.accept, "Authorization": "Bearer t0k3n"}
+		if got := sendRequest(t, server, "GET", "/catalog", headers, ""); got != tc.want {
+			t.Errorf("Accept %q: expected %d, got %d", tc.accept, tc.want, got)
+		}
+	}
+
+	invoice := NewStubBuilder("POST", "/invoices").
+		WhenHeaderPresent("X-Signature").
+		WhenBodyMatches(`<total>\d+</total>`).
+		WhenXPath("//Invoice/currency", "EUR").
+		Body("invoice").
+		Build()
+	if err := server.AddStub(invoice); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	tenant := map[string]string{"X-Tenant": "acme", "Content-Type": "application/json"}
+	body := `{"type":"refund","items":[{"sku":"B2"},{"sku":"A1"}]}`
+	signed := map[string]string{"X-Signature": "sig", "Content-Type": "application/xml"}
+	for _, tc := range []struct {
+		name    string
+		path    string
+		headers map[string]string
+		body    string
+		want    int
+	}{
+		{"all criteria", "/refunds?dry_run=false", tenant, body, 201},
+		{"missing header", "/refunds?dry_run=false", nil, body, 404},
+		{"wrong query", "/refunds?dry_run=true", tenant, body, 404},
+		{"wrong JSONPath value", "/refunds?dry_run=false", tenant, `{"type":"sale","items":[{"sku":"A1"}]}`, 404},
+		{"no matching array element", "/refunds?dry_run=false", tenant, `{"type":"refund","items":[{"sku":"B2"}]}`, 404},
+		{"substring header value", "/refunds?dry_run=false", map[string]string{"X-Tenant": "acmecorp"}, body, 404},
+		{"equal JSON body", "/refunds/exact", nil, `{"amount":10,"type":"refund"}`, 200},
+		{"different JSON body", "/refunds/exact", nil, `{"amount":10,"type":"refund","note":"x"}`, 404},
+		{"signed invoice", "/invoices", signed, `<Invoice><currency>EUR</currency><total>12</total></Invoice>`, 200},
+		{"unsigned invoice", "/invoices", nil, `<Invoice><currency>EUR</currency><total>12</total></Invoice>`, 404},
+		{"body pattern mismatch", "/invoices", signed, `<Invoice><currency>EUR</currency><total>n/a</total></Invoice>`, 404},
+		{"XPath mismatch", "/invoices", signed, `<Invoice><currency>USD</currency><total>12</total></Invoice>`, 404},
+	} {
+		if got := sendRequest(t, server, "POST", tc.path, tc.headers, tc.body); got != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestMockServerStubLimits(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/once").Times(1).Body("once").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	if err := server.AddStub(NewStubBuilder("GET", "/brief").ExpiresAfter(200 * time.Millisecond).Body("brief").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	if got := sendRequest(t, server, "GET", "/once", nil, ""); got != 200 {
+		t.Errorf("Expected the first request served, got %d", got)
+	}
+	if got := sendRequest(t, server, "GET", "/once", nil, ""); got != 404 {
+		t.Errorf("Expected the stub used up after one match, got %d", got)
+	}
+	if got := sendRequest(t, server, "GET", "/brief", nil, ""); got != 200 {
+		t.Errorf("Expected the stub served before it expires, got %d", got)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if got := sendRequest(t, server, "GET", "/brief", nil, ""); got != 404 {
+		t.Errorf("Expected the stub expired, got %d", got)
+	}
+}
+
+func TestMockServerExpressionMatching(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+
+	stub := NewStubBuilder("POST", "/payments").
+		When(`request.body.amount > 1000 && request.header['X-Region'] == 'EU' || request.cookie['vip'] == 'yes'`).
+		Status(202).
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		body    string
+		want    int
+	}{
+		{"large EU payment", map[string]string{"X-Region": "EU"}, `{"amount":1500}`, 202},
+		{"small EU payment", map[string]string{"X-Region": "EU"}, `{"amount":10}`, 404},
+		{"large US payment", map[string]string{"X-Region": "US"}, `{"amount":1500}`, 404},
+		{"VIP cookie", map[string]string{"Cookie": "theme=dark; vip=yes"}, `{}`, 202},
+	} {
+		if got := sendRequest(t, server, "POST", "/payments", tc.headers, tc.body); got != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestMockServerRateLimit(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/quota").RateLimited(2, time.Minute).Body("ok").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		if got := sendRequest(t, server, "GET", "/quota", nil, ""); got != 200 {
+			t.Errorf("Expected request %d within the limit, got %d", i, got)
+		}
+	}
+
+	resp, err := http.Get(server.URL() + "/quota")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("Expected 429 with rate limit headers, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// A 429 does not use up one of the stub's matches
+	if err := server.AddStub(NewStubBuilder("GET", "/burst").RateLimited(1, 200*time.Millisecond).Times(2).Body("ok").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	for i, want := range []int{200, 429, 0, 200, 0, 404} {
+		if want == 0 {
+			time.Sleep(300 * time.Millisecond)
+			continue
+		}
+		if got := sendRequest(t, server, "GET", "/burst", nil, ""); got != want {
+			t.Errorf("Expected step %d to get %d, got %d", i+1, want, got)
+		}
+	}
+}
+
+func TestMockServerResponseSequence(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	stub := NewStubBuilder("GET", "/flaky").
+		RespondInSequence(Respond(503, map[string]string{"error": "unavailable"}), Respond(200, map[string]bool{"ok": true})).
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	for i, want := range []int{503, 200, 200} {
+		if got := sendRequest(t, server, "GET", "/flaky", nil, ""); got != want {
+			t.Errorf("Expected request %d to get %d, got %d", i+1, want, got)
+		}
+	}
+}
+
+func TestMockServerCallbacks(t *testing.T) {
+	type callback struct {
+		path, job, body string
+	}
+	received := make(chan callback, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- callback{path: r.URL.Path, job: r.Header.Get("X-Job"), body: string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	server := startCLIServer(t, MockServerConfig{})
+
+	stub := NewStubBuilder("POST", "/jobs/{id}").
+		Status(202).
+		ThenCallbackWith(Callback{
+			Method:  "POST",
+			URL:     receiver.URL + "/hooks",
+			Headers: map[string]string{"X-Job": "{{request.path.id}}"},
+			Body:    map[string]string{"status": "{{request.body.status}}"},
+			Delay:   10 * time.Millisecond,
+		}).
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	if got := sendRequest(t, server, "POST", "/jobs/42", map[string]string{"Content-Type": "application/json"}, `{"status":"done"}`); got != 202 {
+		t.Fatalf("Expected 202, got %d", got)
+	}
+
+	select {
+	case got := <-received:
+		if want := (callback{path: "/hooks", job: "42", body: `{"status":"done"}`}); got != want {
+			t.Errorf("Expected callback %+v, got %+v", want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a callback")
+	}
+}
+
+func TestMockServerTransformers(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	err := server.DefineTransformer(Transformer{
+		Name:       "tag-and-sign",
+		StatusCode: 201,
+		SetHeaders: map[string]string{"X-Request-Id": "{{request.header.x-request-id}}"},
+		BodyPatch:  []PatchOperation{{Op: "add", Path: "/order", Value: "{{request.path.id}}"}},
+		Sign:       &ResponseSignature{Header: "X-Signature", Secret: "key"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to define transformer: %v", err)
+	}
+	transformers, err := server.Transformers()
+	if err != nil {
+		t.Fatalf("Failed to list transformers: %v", err)
+	}
+	if len(transformers) != 1 || transformers[0].Name != "tag-and-sign" || transformers[0].StatusCode != 201 || transformers[0].Sign == nil {
+		t.Errorf("Expected the defined transformer listed, got %+v", transformers)
+	}
+	stub := NewStubBuilder("GET", "/orders/{id}").
+		Body(map[string]bool{"ok": true}).
+		Transform("tag-and-sign").
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL()+"/orders/42", nil)
+	req.Header.Set("X-Request-Id", "req-7")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 201 || resp.Header.Get("X-Request-Id") != "req-7" {
+		t.Errorf("Expected a transformed 201 with X-Request-Id, got %d %v", resp.StatusCode, resp.Header)
+	}
+	if string(body) != `{"ok":true,"order":"42"}` {
+		t.Errorf("Expected the patched body, got %s", body)
+	}
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write(body)
+	if want := hex.EncodeToString(mac.Sum(nil)); resp.Header.Get("X-Signature") != want {
+		t.Errorf("Expected signature %s, got %q", want, resp.Header.Get("X-Signature"))
+	}
+
+	// Stubs referencing a deleted transformer respond untransformed
+	if err := server.DeleteTransformer("tag-and-sign"); err != nil {
+		t.Fatalf("Failed to delete transformer: %v", err)
+	}
+	if got := sendRequest(t, server, "GET", "/orders/42", nil, ""); got != 200 {
+		t.Errorf("Expected 200 once the transformer is deleted, got %d", got)
+	}
+	if transformers, err := server.Transformers(); err != nil || len(transformers) != 0 {
+		t.Errorf("Expected no transformers left, got %+v (%v)", transformers, err)
+	}
+}
+
+func TestMockServerProxy(t *testing.T) {
+	var upstreamHits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"` + strings.TrimPrefix(r.URL.Path, "/users/") + `"}`))
+	}))
+	defer upstream.Close()
+	server := startCLIServer(t, MockServerConfig{})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/users/{id}").ProxyTo(upstream.URL).RecordProxied().Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(server.URL() + "/users/7")
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != 200 || string(body) != `{"id":"7"}` {
+			t.Errorf("Expected the upstream response, got %d %s", resp.StatusCode, body)
+		}
+	}
+	if hits := upstreamHits.Load(); hits != 1 {
+		t.Errorf("Expected the recorded stub to serve the second request, upstream saw %d", hits)
+	}
+}
+
+func TestMockServerPassthrough(t *testing.T) {
+	var upstreamHits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer upstream.Close()
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.StubResponse("GET", "/local", map[string]interface{}{"local": true}); err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+	if err := server.PassthroughUnmatched(upstream.URL, true); err != nil {
+		t.Fatalf("Failed to enable passthrough: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if status, body := getBody(t, server, "/users/5", nil); status != 200 || body != `{"path":"/users/5"}` {
+			t.Errorf("Expected the upstream response, got %d %s", status, body)
+		}
+	}
+	if hits := upstreamHits.Load(); hits != 1 {
+		t.Errorf("Expected the recorded response to serve the second request, upstream saw %d", hits)
+	}
+	if status, body := getBody(t, server, "/local", nil); status != 200 || !strings.Contains(body, "local") {
+		t.Errorf("Expected the stub to answer its own path, got %d %s", status, body)
+	}
+
+	if err := server.DisablePassthrough(); err != nil {
+		t.Fatalf("Failed to disable passthrough: %v", err)
+	}
+	if status, _ := getBody(t, server, "/orders", nil); status != 404 {
+		t.Errorf("Expected 404 once passthrough is off, got %d", status)
+	}
+	if hits := upstreamHits.Load(); hits != 1 {
+		t.Errorf("Expected no more upstream requests, upstream saw %d", hits)
+	}
+}
+
+func TestMockServerRecording(t *testing.T) {
+	var upstreamAuth atomic.Value
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamAuth.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	t.Setenv("MOCKFORGE_FIXTURES_DIR", t.TempDir())
+	server := startCLIServer(t, MockServerConfig{})
+
+	if err := server.StartRecording(RecordOptions{Upstream: upstream.URL, PathFilters: []string{"/v1/*"}}); err != nil {
+		t.Fatalf("Failed to start recording: %v", err)
+	}
+	headers := map[string]string{"Authorization": "Bearer secret"}
+	if status, body := getBody(t, server, "/v1/orders/42", headers); status != 200 || body != `{"path":"/v1/orders/42"}` {
+		t.Errorf("Expected the upstream response while recording, got %d %s", status, body)
+	}
+	if auth := upstreamAuth.Load(); auth != "Bearer secret" {
+		t.Errorf("Expected the upstream to get the real credentials, got %v", auth)
+	}
+	if status, _ := getBody(t, server, "/health", nil); status != 200 {
+		t.Errorf("Expected filtered paths to still reach the upstream, got %d", status)
+	}
+	recorded, err := server.StopRecording()
+	if err != nil {
+		t.Fatalf("Failed to stop recording: %v", err)
+	}
+	if len(recorded) != 1 || recorded[0].Path != "/v1/orders/42" {
+		t.Fatalf("Expected only the filtered path recorded, got %+v", recorded)
+	}
+	data, err := server.DownloadFixture(recorded[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to download fixture: %v", err)
+	}
+	var document fixtureDocument
+	var fixture recordedFixture
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	if err := json.Unmarshal(document.Response, &fixture); err != nil {
+		t.Fatalf("Failed to parse recorded response: %v", err)
+	}
+	if auth := fixture.RequestHeaders["Authorization"]; auth != redactedValue {
+		t.Errorf("Expected the Authorization header redacted, got %q", auth)
+	}
+
+	upstream.Close()
+	if status, _ := getBody(t, server, "/v1/orders/42", nil); status != 404 {
+		t.Errorf("Expected 404 once recording stops, got %d", status)
+	}
+	if err := server.ReplayFixture(recorded[0].ID); err != nil {
+		t.Fatalf("Failed to replay fixture: %v", err)
+	}
+	if status, body := getBody(t, server, "/v1/orders/42", nil); status != 200 || body != `{"path":"/v1/orders/42"}` {
+		t.Errorf("Expected the recorded response replayed, got %d %s", status, body)
+	}
+
+	id, err := server.UploadFixture(FixtureInfo{Method: "GET", Path: "/v1/users/7"}, strings.NewReader(`{"id":7}`))
+	if err != nil {
+		t.Fatalf("Failed to upload fixture: %v", err)
+	}
+	if _, err := server.UploadFixture(FixtureInfo{Method: "GET", Path: "/v1/users/7"}, strings.NewReader(`{"id":8}`)); err == nil {
+		t.Error("Expected uploading a duplicate fixture name to fail")
+	}
+	replayed, err := server.ReplayAll(FixtureFilter{PathPrefix: "/v1/users/"})
+	if err != nil {
+		t.Fatalf("Failed to replay fixtures: %v", err)
+	}
+	if len(replayed) != 1 || replayed[0].ID != id {
+		t.Errorf("Expected the uploaded fixture replayed, got %+v", replayed)
+	}
+	if status, body := getBody(t, server, "/v1/users/7", nil); status != 200 || body != `{"id":7}` {
+		t.Errorf("Expected the uploaded fixture served, got %d %s", status, body)
+	}
+
+	if err := server.DeleteFixture(id); err != nil {
+		t.Fatalf("Failed to delete fixture: %v", err)
+	}
+	pruned, err := server.PruneFixtures(FixtureFilter{Protocol: "http"})
+	if err != nil {
+		t.Fatalf("Failed to prune fixtures: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].ID != recorded[0].ID {
+		t.Errorf("Expected the recorded fixture pruned, got %+v", pruned)
+	}
+	if fixtures, err := server.ListFixtures(); err != nil || len(fixtures) != 0 {
+		t.Errorf("Expected no fixtures left, got %+v (%v)", fixtures, err)
+	}
+}
+
+func TestMockServerCassette(t *testing.T) {
+	requireCLI(t)
+	inTempDir(t)
+	t.Setenv("MOCKFORGE_FIXTURES_DIR", t.TempDir())
+	var upstreamHits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	opts := RecordOptions{Upstream: upstream.URL}
+
+	t.Run("record", func(t *testing.T) {
+		server := startCLIServer(t, MockServerConfig{})
+		if cassette := UseCassette(t, server, "checkout", CassetteAuto, opts); !cassette.Recording {
+			t.Fatal("Expected a missing cassette recorded")
+		}
+		if status, body := getBody(t, server, "/orders/42", nil); status != 200 || body != `{"id":"42"}` {
+			t.Errorf("Expected the upstream response, got %d %s", status, body)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(cassetteDir, "checkout.json")); err != nil {
+		t.Fatalf("Expected the cassette saved: %v", err)
+	}
+
+	upstream.Close()
+	t.Run("replay", func(t *testing.T) {
+		server := startCLIServer(t, MockServerConfig{})
+		if cassette := UseCassette(t, server, "checkout", CassetteAuto, opts); cassette.Recording {
+			t.Fatal("Expected the saved cassette replayed")
+		}
+		if status, body := getBody(t, server, "/orders/42", nil); status != 200 || body != `{"id":"42"}` {
+			t.Errorf("Expected the recorded response, got %d %s", status, body)
+		}
+		if fixtures, err := server.ListFixtures(); err != nil || len(fixtures) != 0 {
+			t.Errorf("Expected the cassette to leave no fixtures on the server, got %+v (%v)", fixtures, err)
+		}
+	})
+	if hits := upstreamHits.Load(); hits != 1 {
+		t.Errorf("Expected the upstream called once, got %d", hits)
+	}
+}
+
+func TestMockServerForAllResponses(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Price int    `json:"price"`
+	}
+	server := startCLIServer(t, MockServerConfig{})
+
+	gen := GeneratorFunc[[]item](func(rng *rand.Rand, size int) []item {
+		items := make([]item, rng.Intn(size+1))
+		for i := range items {
+			items[i] = item{Name: strings.Repeat("x", rng.Intn(5)), Price: rng.Intn(200) - 50}
+		}
+		return items
+	})
+	served := func(pt PropertyT) []item {
+		resp, err := http.Get(server.URL() + "/items")
+		if err != nil {
+			pt.Fatalf("Failed to send request: %v", err)
+			return nil
+		}
+		defer resp.Body.Close()
+		var items []item
+		if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+			pt.Fatalf("Failed to decode response: %v", err)
+		}
+		return items
+	}
+
+	ForAllResponsesWith(t, server, NewStubBuilder("GET", "/items"), gen, PropertyConfig{Iterations: 20, Seed: 1},
+		func(pt PropertyT, resp []item) {
+			if got := served(pt); len(got) != len(resp) || (len(resp) > 0 && !reflect.DeepEqual(got, resp)) {
+				pt.Fatalf("Expected the generated response served, got %+v want %+v", got, resp)
+			}
+		})
+
+	recorder := &recordingTB{TB: t}
+	ForAllResponsesWith(recorder, server, NewStubBuilder("GET", "/items"), gen, PropertyConfig{Iterations: 50, Seed: 1},
+		func(pt PropertyT, resp []item) {
+			for _, it := range served(pt) {
+				if it.Price < 0 {
+					pt.Errorf("negative price %d", it.Price)
+				}
+			}
+		})
+	if !strings.Contains(recorder.failure, `minimal response: [{"name":"","price":-1}]`) {
+		t.Errorf("Expected the minimal counterexample, got %q", recorder.failure)
+	}
+	if status, _ := getBody(t, server, "/items", nil); status != 404 {
+		t.Errorf("Expected the property's stub removed, got %d", status)
+	}
+}
+
+func TestMockServerRequestSchema(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{ValidationMode: ValidationEnforce, StrictStubbing: true})
+
+	stub := NewStubBuilder("POST", "/orders").
+		ValidateRequestAgainstSchema(`{"type":"object","required":["sku"],"properties":{"sku":{"type":"string"}}}`).
+		Status(201).
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	if got := sendRequest(t, server, "POST", "/orders", headers, `{"sku":"A-1"}`); got != 201 {
+		t.Errorf("Expected a conforming request to get 201, got %d", got)
+	}
+	if got := sendRequest(t, server, "POST", "/orders", headers, `{"sku":1}`); got != 400 {
+		t.Errorf("Expected a non-conforming request to get 400, got %d", got)
+	}
+
+	// A rejected request does not use up a Times(1) stub
+	once := NewStubBuilder("POST", "/once").
+		ValidateRequestAgainstSchema(`{"type":"object","required":["sku"]}`).
+		Times(1).
+		Build()
+	if err := server.AddStub(once); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	for i, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{}`, 400},
+		{`{"sku":"A-1"}`, 200},
+		{`{"sku":"A-1"}`, 404},
+	} {
+		if got := sendRequest(t, server, "POST", "/once", headers, tc.body); got != tc.want {
+			t.Errorf("Expected request %d to get %d, got %d", i+1, tc.want, got)
+		}
+	}
+}
+
+func TestMockServerStubGroup(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/shared").Body("shared").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	group := server.Group("checkout")
+	if err := group.AddStub(NewStubBuilder("GET", "/cart").Body("cart").Build()); err != nil {
+		t.Fatalf("Failed to add group stub: %v", err)
+	}
+	id, err := server.CreateStub(NewStubBuilder("GET", "/pay").Body("pay").Build())
+	if err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	stub, err := server.GetStub(id)
+	if err != nil {
+		t.Fatalf("Failed to get stub: %v", err)
+	}
+	stub.Group = "checkout"
+	if err := server.UpdateStub(id, *stub); err != nil {
+		t.Fatalf("Failed to move stub into the group: %v", err)
+	}
+
+	if err := group.Clear(); err != nil {
+		t.Fatalf("Failed to clear group: %v", err)
+	}
+	for route, want := range map[string]int{"/shared": 200, "/cart": 404, "/pay": 404} {
+		if got := sendRequest(t, server, "GET", route, nil, ""); got != want {
+			t.Errorf("Expected %s to get %d after clearing the group, got %d", route, want, got)
+		}
+	}
+}
+
+func TestMockServerGetStubRoundTrip(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	stubs := map[string]ResponseStub{
+		"json": NewStubBuilder("POST", "/orders/{id}").
+			Status(201).
+			Header("X-Trace", "abc").
+			Body(map[string]interface{}{"id": "{{request.path.id}}", "total": 12.5}).
+			SetCookie("session", "s1", CookieOptions{Path: "/"}).
+			LatencyNormal(20*time.Millisecond, 5*time.Millisecond).
+			WhenHeaderMatches("Authorization", "Bearer .*").
+			WhenHeaderAbsent("X-Debug").
+			WhenQuery("mode", "fast").
+			WhenCookie("tenant", "acme").
+			WhenJSONPath("$.sku", "A-1").
+			ValidateRequestAgainstSchema(`{"type":"object"}`).
+			Priority(5).
+			Scenario("checkout").
+			RequiredState("Started").
+			NewState("paid").
+			Times(3).
+			ExpiresAfter(time.Hour).
+			RateLimited(10, time.Minute).
+			ThenCallback("POST", "http://127.0.0.1:9/hooks", map[string]interface{}{"id": "{{request.path.id}}"}, 50*time.Millisecond).
+			Build(),
+		"sequence": NewStubBuilder("GET", "/flaky").
+			RespondInSequence(
+				SequencedResponse{Status: 503, Body: map[string]interface{}{"error": "busy"}, Latency: FixedLatency(10 * time.Millisecond)},
+				SequencedResponse{Status: 200, Headers: map[string]string{"X-Attempt": "2"}, Body: "ok"},
+			).
+			Build(),
+		"binary": NewStubBuilder("GET", "/download").
+			BodyBytes([]byte{0x89, 'P', 'N', 'G'}).
+			Encoding(EncodingGzip).
+			ThrottleBytesPerSecond(1024).
+			Build(),
+		"chunks": NewStubBuilder("GET", "/events").
+			StreamChunks([]Chunk{{Data: []byte("data: 1\n\n")}, {Data: []byte("data: 2\n\n"), DelayMs: 10}}).
+			Build(),
+		"proxy": NewStubBuilder("GET", PathRegex(`^/legacy/(?P<rest>.*)$`)).ProxyTo("http://127.0.0.1:9").RecordProxied().Build(),
+	}
+	normalize := func(stub ResponseStub) interface{} {
+		data, err := json.Marshal(stub.mockConfig())
+		if err != nil {
+			t.Fatalf("Failed to encode stub: %v", err)
+		}
+		var config interface{}
+		json.Unmarshal(data, &config)
+		return config
+	}
+
+	for name, stub := range stubs {
+		if name == "json" {
+			stub.Group = "orders"
+		}
+		id, err := server.CreateStub(stub)
+		if err != nil {
+			t.Fatalf("Failed to add %s stub: %v", name, err)
+		}
+		got, err := server.GetStub(id)
+		if err != nil {
+			t.Fatalf("Failed to get %s stub: %v", name, err)
+		}
+		if want, got := normalize(stub), normalize(*got); !reflect.DeepEqual(want, got) {
+			t.Errorf("Expected the %s stub to round-trip\nwant %v\ngot  %v", name, want, got)
+		}
+	}
+}
+
+func TestMockServerPushPreset(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	push, err := server.Push()
+	if err != nil {
+		t.Fatalf("Failed to set up push preset: %v", err)
+	}
+	if err := push.FailDevice("dead", PushInvalidToken); err != nil {
+		t.Fatalf("Failed to fail device: %v", err)
+	}
+	if err := push.FailDevice("dead", PushUnregistered); err != nil {
+		t.Fatalf("Failed to fail device again: %v", err)
+	}
+
+	auth := map[string]string{"Authorization": "Bearer token", "Content-Type": "application/json"}
+	cases := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"FCM healthy device", "/v1/projects/demo/messages:send", `{"message":{"token":"live"}}`, 200},
+		{"FCM failing device", "/v1/projects/demo/messages:send", `{"message":{"token":"dead"}}`, 404},
+		{"APNs healthy device", "/3/device/live", `{"aps":{}}`, 200},
+		{"APNs failing device", "/3/device/dead", `{"aps":{}}`, 410},
+	}
+	for _, c := range cases {
+		if got := sendRequest(t, server, "POST", c.path, auth, c.body); got != c.want {
+			t.Errorf("%s: expected %d, got %d", c.name, c.want, got)
+		}
+	}
+
+	if delivered, err := push.Delivered("live"); err != nil || !delivered {
+		t.Errorf("Expected delivery to the healthy device, got %v, %v", delivered, err)
+	}
+	if attempts, err := push.Attempts("dead"); err != nil || attempts != 2 {
+		t.Errorf("Expected 2 attempts for the failing device, got %d, %v", attempts, err)
+	}
+}
+
+func TestMockServerConnectionConfig(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{Connection: ConnectionConfig{
+		HeaderReadTimeout: 300 * time.Millisecond,
+		MaxHeaderBytes:    8192,
+		DisableKeepAlive:  true,
+	}})
+	if err := server.AddStub(NewStubBuilder("GET", "/fast").Body("ok").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	addr := strings.TrimPrefix(server.URL(), "http://")
+
+	// A slowloris client trickles header lines and never finishes the head
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /fast HTTP/1.1\r\nHost: test\r\n")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	closed := false
+	for !closed && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		_, err := conn.Write([]byte("X-Slow: 1\r\n"))
+		closed = err != nil
+	}
+	if !closed {
+		t.Errorf("Expected the server to close the slowloris connection")
+	}
+
+	resp, err := http.Get(server.URL() + "/fast")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !resp.Close {
+		t.Errorf("Expected 200 with Connection: close, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL()+"/fast", nil)
+	req.Header.Set("X-Big", strings.Repeat("a", 16*1024))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("Expected 431 for an oversized head, got %d", resp.StatusCode)
+	}
+}
+
+func TestMockServerScenarios(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true, ValidationMode: ValidationEnforce})
+
+	for _, stub := range []ResponseStub{
+		NewStubBuilder("POST", "/orders").Scenario("checkout").RequiredState(ScenarioStarted).NewState("created").Status(201).Build(),
+		NewStubBuilder("POST", "/orders").Scenario("checkout").RequiredState("created").NewState("paid").Status(200).Build(),
+	} {
+		if err := server.AddStub(stub); err != nil {
+			t.Fatalf("Failed to add stub: %v", err)
+		}
+	}
+
+	if got := sendRequest(t, server, "POST", "/orders", nil, ""); got != 201 {
+		t.Errorf("Expected the started state served, got %d", got)
+	}
+	if got := sendRequest(t, server, "POST", "/orders", nil, ""); got != 200 {
+		t.Errorf("Expected the created state served, got %d", got)
+	}
+	if got := sendRequest(t, server, "POST", "/orders", nil, ""); got != 404 {
+		t.Errorf("Expected no stub for the paid state, got %d", got)
+	}
+	states, err := server.ScenarioStates()
+	if err != nil || len(states) != 1 || states[0].State != "paid" {
+		t.Errorf("Expected checkout paid, got %+v, %v", states, err)
+	}
+
+	if err := server.SetScenarioState("checkout", "created"); err != nil {
+		t.Fatalf("Failed to set scenario state: %v", err)
+	}
+	if got := sendRequest(t, server, "POST", "/orders", nil, ""); got != 200 {
+		t.Errorf("Expected the forced state served, got %d", got)
+	}
+	if err := server.ResetScenarios(); err != nil {
+		t.Fatalf("Failed to reset scenarios: %v", err)
+	}
+	if got := sendRequest(t, server, "POST", "/orders", nil, ""); got != 201 {
+		t.Errorf("Expected the reset state served, got %d", got)
+	}
+
+	// A request rejected by the stub's schema leaves the scenario alone
+	stub := NewStubBuilder("POST", "/carts").
+		Scenario("cart").
+		RequiredState(ScenarioStarted).
+		NewState("filled").
+		ValidateRequestAgainstSchema(`{"type":"object","required":["sku"]}`).
+		Build()
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	if got := sendRequest(t, server, "POST", "/carts", headers, `{}`); got != 400 {
+		t.Errorf("Expected the invalid request rejected, got %d", got)
+	}
+	if got := sendRequest(t, server, "POST", "/carts", headers, `{"sku":"A-1"}`); got != 200 {
+		t.Errorf("Expected the cart scenario still started, got %d", got)
+	}
+}
+
+func TestMockServerFallbacks(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+
+	envelope := NewStubBuilder("", "").Status(418).Body(map[string]interface{}{"error": "unmatched"}).Build()
+	if err := server.SetDefaultResponse(envelope); err != nil {
+		t.Fatalf("Failed to set default response: %v", err)
+	}
+	if err := server.WithFallback(Fallback404, "/api/legacy"); err != nil {
+		t.Fatalf("Failed to set fallback: %v", err)
+	}
+
+	if got := sendRequest(t, server, "GET", "/orders", nil, ""); got != 418 {
+		t.Errorf("Expected the default response, got %d", got)
+	}
+	if got := sendRequest(t, server, "GET", "/api/legacy/users", nil, ""); got != 404 {
+		t.Errorf("Expected the prefix's 404, got %d", got)
+	}
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "upstream "+r.URL.RequestURI())
+	}))
+	defer upstream.Close()
+	if err := server.WithFallback(FallbackProxy(upstream.URL), "/api/v2"); err != nil {
+		t.Fatalf("Failed to set proxy fallback: %v", err)
+	}
+	if err := server.WithFallback(FallbackEcho(), "/debug"); err != nil {
+		t.Fatalf("Failed to set echo fallback: %v", err)
+	}
+
+	resp, err := http.Get(server.URL() + "/api/v2/orders?page=2")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 202 || string(body) != "upstream /api/v2/orders?page=2" {
+		t.Errorf("Expected the upstream's response, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, err = http.Post(server.URL()+"/debug/orders?dry=1", "application/json", strings.NewReader(`{"qty":2}`))
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	var echoed struct {
+		Method string                 `json:"method"`
+		Path   string                 `json:"path"`
+		Query  string                 `json:"query"`
+		Body   map[string]interface{} `json:"body"`
+	}
+	json.NewDecoder(resp.Body).Decode(&echoed)
+	resp.Body.Close()
+	if echoed.Method != "POST" || echoed.Path != "/debug/orders" || echoed.Query != "dry=1" || echoed.Body["qty"] != float64(2) {
+		t.Errorf("Expected the request echoed back, got %+v", echoed)
+	}
+}
+
+func TestMockServerBench(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{OpenAPISpec: "testdata/petstore.yaml"})
+	config := BenchConfig{Spec: "testdata/petstore.yaml", Target: server.URL(), Scenario: "constant", VUs: 2, Duration: 2 * time.Second}
+
+	generateOnly := config
+	generateOnly.GenerateOnly = true
+	result, err := Bench(generateOnly)
+	if err != nil {
+		t.Fatalf("Failed to generate the k6 script: %v", err)
+	}
+	if !strings.Contains(result.Script, "k6/http") || result.TotalRequests != 0 {
+		t.Errorf("Expected only a k6 script, got %+v", result)
+	}
+
+	if _, err := exec.LookPath("k6"); err != nil {
+		t.Skip("Requires k6 to be installed")
+	}
+	result, err = Bench(config)
+	if err != nil {
+		t.Fatalf("Failed to run the benchmark: %v", err)
+	}
+	if result.TotalRequests == 0 || result.VUsMax != 2 || result.MaxLatency == 0 {
+		t.Errorf("Expected requests from 2 VUs, got %+v", result)
+	}
+}
+
+func TestMockServerSpecFallback(t *testing.T) {
+	// Without a spec there is nothing to generate responses from
+	specless := startCLIServer(t, MockServerConfig{})
+	if err := specless.WithFallback(FallbackFromSpec()); err == nil {
+		t.Error("Expected the spec fallback rejected without a spec")
+	}
+
+	spec, err := filepath.Abs("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("Failed to resolve spec: %v", err)
+	}
+	server := startCLIServer(t, MockServerConfig{OpenAPISpec: spec})
+	if err := server.SetDefaultResponse(NewStubBuilder("", "").Status(418).Body("unmatched").Build()); err != nil {
+		t.Fatalf("Failed to set default response: %v", err)
+	}
+	if err := server.WithFallback(FallbackFromSpec(), "/pets"); err != nil {
+		t.Fatalf("Failed to set spec fallback: %v", err)
+	}
+
+	if got := sendRequest(t, server, "GET", "/other", nil, ""); got != 418 {
+		t.Errorf("Expected the default response outside the prefix, got %d", got)
+	}
+	// The spec documents no such operation, so its fallback answers 404
+	if got := sendRequest(t, server, "GET", "/pets/2/photos", nil, ""); got != 404 {
+		t.Errorf("Expected 404 for an undocumented operation, got %d", got)
+	}
+	if status, body := getBody(t, server, "/pets/2", nil); status != 200 || !strings.Contains(body, `"name"`) {
+		t.Errorf("Expected a generated pet, got %d %s", status, body)
+	}
+}
+
+func TestMockServerRandomSeed(t *testing.T) {
+	t.Setenv("MOCKFORGE_RESPONSE_TEMPLATE_EXPAND", "true")
+	server := startCLIServer(t, MockServerConfig{RandomSeed: 42})
+
+	if err := server.AddStub(NewStubBuilder("GET", "/id").Body(map[string]interface{}{"id": "{{uuid}}"}).Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	fetch := func() string {
+		resp, err := http.Get(server.URL() + "/id")
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if err := server.SetSeed(7); err != nil {
+		t.Fatalf("Failed to set seed: %v", err)
+	}
+	first := fetch()
+	if strings.Contains(first, "{{uuid}}") {
+		t.Fatalf("Expected the template expanded, got %s", first)
+	}
+	if err := server.SetSeed(7); err != nil {
+		t.Fatalf("Failed to set seed: %v", err)
+	}
+	if again := fetch(); again != first {
+		t.Errorf("Expected the reseeded value repeated, got %s and %s", first, again)
+	}
+}
+
+func TestMockServerGRPCStubs(t *testing.T) {
+	requireCLI(t)
+	server := NewMockServer(MockServerConfig{})
+	if err := server.RegisterProtoDescriptors(testDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register descriptors: %v", err)
+	}
+	if err := server.StubGRPC("shop.v1.Orders/GetOrder", nil, GRPCError(GRPCNotFound, "order not found")); err != nil {
+		t.Fatalf("Failed to stub method: %v", err)
+	}
+	// The server loads stream scripts from the same config
+	err := server.StubGRPCServerStream("shop.v1.Orders/WatchOrders", []StreamMessage{
+		StreamSend(map[string]interface{}{}, 10*time.Millisecond),
+		StreamFail(GRPCUnavailable, "connection reset", 0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub server stream: %v", err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server with gRPC stubs: %v", err)
+	}
+	configDir := server.configDir
+	if _, err := os.Stat(filepath.Join(configDir, "mockforge.yaml")); err != nil {
+		t.Errorf("Expected the server started with the written config: %v", err)
+	}
+
+	if err := server.StubGRPC("shop.v1.Orders/GetOrder", nil, map[string]interface{}{}); err == nil {
+		t.Error("Expected error for a stub registered after Start")
+	}
+
+	// The gRPC server discovers the registered services in the background
+	var orders *GRPCService
+	for deadline := time.Now().Add(5 * time.Second); orders == nil && time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		services, err := server.ListGRPCServices()
+		if err != nil {
+			t.Fatalf("Failed to list services: %v", err)
+		}
+		for i := range services {
+			if services[i].Name == "shop.v1.Orders" {
+				orders = &services[i]
+			}
+		}
+	}
+	if orders == nil || len(orders.Methods) != 3 {
+		t.Errorf("Expected the registered service listed, got %+v", orders)
+	}
+
+	server.Stop()
+	if _, err := os.Stat(configDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the written config removed on Stop, got %v", err)
+	}
+}
+
+func TestMockServerEmail(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{SMTP: true})
+
+	addr, err := server.SMTPAddr()
+	if err != nil {
+		t.Fatalf("Failed to get SMTP address: %v", err)
+	}
+	message := "From: shop@example.com\r\nTo: alice@example.com\r\nSubject: Your order shipped\r\n\r\nOrder 42 has shipped.\r\n"
+	if err := smtp.SendMail(addr, nil, "shop@example.com", []string{"alice@example.com"}, []byte(message)); err != nil {
+		t.Fatalf("Failed to send mail: %v", err)
+	}
+
+	emails, err := server.ListEmails(EmailFilter{To: "alice@example.com", BodyContains: "has shipped"})
+	if err != nil {
+		t.Fatalf("Failed to list emails: %v", err)
+	}
+	if len(emails) != 1 || emails[0].Subject != "Your order shipped" {
+		t.Fatalf("Expected the sent email, got %v", emails)
+	}
+	email, err := server.GetEmail(emails[0].ID)
+	if err != nil || email.From != "shop@example.com" {
+		t.Errorf("Expected the email by id, got %v (%v)", email, err)
+	}
+
+	if err := server.ClearEmails(); err != nil {
+		t.Fatalf("Failed to clear emails: %v", err)
+	}
+	if emails, _ := server.ListEmails(EmailFilter{}); len(emails) != 0 {
+		t.Errorf("Expected no emails after clear, got %v", emails)
+	}
+}
+
+func TestMockServerMailReaders(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{IMAP: true, POP3: true})
+
+	message := "From: shop@example.com\r\nTo: alice@example.com\r\nSubject: Reset password\r\n\r\nUse code 481516.\r\n"
+	if err := server.DeliverEmail("shop@example.com", []string{"alice@example.com"}, []byte(message)); err != nil {
+		t.Fatalf("Failed to deliver email: %v", err)
+	}
+
+	// IMAP: read the message and mark it deleted
+	imapAddr, err := server.IMAPAddr()
+	if err != nil {
+		t.Fatalf("Failed to get IMAP address: %v", err)
+	}
+	imap, err := textproto.Dial("tcp", imapAddr)
+	if err != nil {
+		t.Fatalf("Failed to connect to IMAP: %v", err)
+	}
+	defer imap.Close()
+	imapCommand := func(tag, command string) string {
+		if err := imap.PrintfLine("%s %s", tag, command); err != nil {
+			t.Fatalf("Failed to send %q: %v", command, err)
+		}
+		var response strings.Builder
+		for {
+			line, err := imap.ReadLine()
+			if err != nil {
+				t.Fatalf("Failed to read the response to %q: %v", command, err)
+			}
+			response.WriteString(line + "\n")
+			if strings.HasPrefix(line, tag+" ") {
+				return response.String()
+			}
+		}
+	}
+	if _, err := imap.ReadLine(); err != nil {
+		t.Fatalf("Failed to read the IMAP greeting: %v", err)
+	}
+	imapCommand("a1", "LOGIN alice@example.com secret")
+	if response := imapCommand("a2", "SELECT INBOX"); !strings.Contains(response, "* 1 EXISTS") {
+		t.Errorf("Expected one message in the INBOX, got %q", response)
+	}
+	if response := imapCommand("a3", "FETCH 1 (FLAGS BODY[])"); !strings.Contains(response, "Use code 481516.") || !strings.Contains(response, `\Seen`) {
+		t.Errorf("Expected the message body fetched and marked seen, got %q", response)
+	}
+
+	// POP3 sees the same mailbox, including the flag set over IMAP
+	pop3Addr, err := server.POP3Addr()
+	if err != nil {
+		t.Fatalf("Failed to get POP3 address: %v", err)
+	}
+	pop3, err := textproto.Dial("tcp", pop3Addr)
+	if err != nil {
+		t.Fatalf("Failed to connect to POP3: %v", err)
+	}
+	defer pop3.Close()
+	pop3Command := func(command string) string {
+		if err := pop3.PrintfLine("%s", command); err != nil {
+			t.Fatalf("Failed to send %q: %v", command, err)
+		}
+		line, err := pop3.ReadLine()
+		if err != nil {
+			t.Fatalf("Failed to read the response to %q: %v", command, err)
+		}
+		return line
+	}
+	if _, err := pop3.ReadLine(); err != nil {
+		t.Fatalf("Failed to read the POP3 greeting: %v", err)
+	}
+	pop3Command("USER alice@example.com")
+	if response := pop3Command("PASS secret"); response != "+OK maildrop has 1 messages" {
+		t.Errorf("Expected one message in the maildrop, got %q", response)
+	}
+	if response := pop3Command("RETR 1"); !strings.HasPrefix(response, "+OK") {
+		t.Fatalf("Expected RETR to succeed, got %q", response)
+	}
+	body, err := pop3.ReadDotBytes()
+	if err != nil || !strings.Contains(string(body), "Subject: Reset password") {
+		t.Errorf("Expected the message retrieved, got %q (%v)", body, err)
+	}
+	pop3Command("DELE 1")
+	pop3Command("QUIT")
+
+	// The POP3 deletion empties the IMAP mailbox but keeps the captured email
+	if response := imapCommand("a4", "NOOP"); !strings.Contains(response, "* 0 EXISTS") {
+		t.Errorf("Expected the deletion reported over IMAP, got %q", response)
+	}
+	if emails, _ := server.ListEmails(EmailFilter{To: "alice@example.com"}); len(emails) != 1 {
+		t.Errorf("Expected the captured email kept, got %v", emails)
+	}
+}
+
+func TestMockServerFTP(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{FTP: true})
+	ftp := server.FTP()
+
+	if err := ftp.SeedFile("/inbox/orders.csv", []byte("id,qty\n1,2\n")); err != nil {
+		t.Fatalf("Failed to seed file: %v", err)
+	}
+	if err := ftp.SeedDir("/outbox"); err != nil {
+		t.Fatalf("Failed to seed directory: %v", err)
+	}
+
+	// The FTP listener reports its port after the HTTP server is ready
+	var conn *textproto.Conn
+	for deadline := time.Now().Add(5 * time.Second); conn == nil; {
+		if addr := ftp.Addr(); addr != "" {
+			conn, _ = textproto.Dial("tcp", addr)
+		}
+		if conn == nil {
+			if time.Now().After(deadline) {
+				t.Fatal("FTP server never accepted connections")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	defer conn.Close()
+	command := func(expect int, format string, args ...interface{}) string {
+		t.Helper()
+		if err := conn.PrintfLine(format, args...); err != nil {
+			t.Fatalf("Failed to send %q: %v", format, err)
+		}
+		_, message, err := conn.ReadResponse(expect)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		return message
+	}
+	// transfer runs a data command over a passive connection, sending upload
+	// if non-nil, and returns what the server sent
+	transfer := func(cmd string, upload []byte) []byte {
+		t.Helper()
+		message := command(227, "PASV")
+		var h1, h2, h3, h4, p1, p2 int
+		if _, err := fmt.Sscanf(message[strings.Index(message, "(")+1:], "%d,%d,%d,%d,%d,%d", &h1, &h2, &h3, &h4, &p1, &p2); err != nil {
+			t.Fatalf("Failed to parse %q: %v", message, err)
+		}
+		host, _, _ := net.SplitHostPort(ftp.Addr())
+		data, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(p1*256+p2)))
+		if err != nil {
+			t.Fatalf("Failed to open data connection: %v", err)
+		}
+		command(150, "%s", cmd)
+		var received []byte
+		if upload != nil {
+			data.Write(upload)
+		} else {
+			received, _ = io.ReadAll(data)
+		}
+		data.Close()
+		if _, message, err := conn.ReadResponse(226); err != nil {
+			t.Fatalf("Expected 226 after %s, got %q: %v", cmd, message, err)
+		}
+		return received
+	}
+
+	if _, _, err := conn.ReadResponse(220); err != nil {
+		t.Fatalf("Failed to read the FTP greeting: %v", err)
+	}
+	command(331, "USER batch")
+	command(230, "PASS secret")
+	command(200, "TYPE I")
+	command(250, "CWD /outbox")
+
+	if got := transfer("RETR /inbox/orders.csv", nil); string(got) != "id,qty\n1,2\n" {
+		t.Errorf("Expected the seeded file, got %q", got)
+	}
+	transfer("STOR /outbox/report.csv", []byte("total,3\n"))
+	command(221, "QUIT")
+
+	if err := ftp.VerifyUploaded("/outbox/*.csv", Exactly(1)); err != nil {
+		t.Errorf("Expected the upload recorded: %v", err)
+	}
+	if data, err := ftp.File("/outbox/report.csv"); err != nil || string(data) != "total,3\n" {
+		t.Errorf("Expected the uploaded file stored, got %q (%v)", data, err)
+	}
+	if err := ftp.ClearUploads(); err != nil {
+		t.Fatalf("Failed to clear uploads: %v", err)
+	}
+	if uploads, err := ftp.Uploads(); err != nil || len(uploads) != 0 {
+		t.Errorf("Expected no uploads after clearing, got %v (%v)", uploads, err)
+	}
+}
+
+func TestMockServerTCP(t *testing.T) {
+	requireCLI(t)
+	server := NewMockServer(MockServerConfig{})
+	stubs := []TCPStub{
+		{DataHex: "02 30 31 03", ResponseHex: "06 41 50 50 52 4f 56 45 44"},
+		{TextPattern: "^QUIT", Response: []byte("BYE\n"), Close: true},
+	}
+	for _, stub := range stubs {
+		if err := server.StubTCP(stub); err != nil {
+			t.Fatalf("Failed to stub TCP: %v", err)
+		}
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server with TCP stubs: %v", err)
+	}
+	defer server.Stop()
+	if err := server.StubTCP(TCPStub{Response: []byte("late")}); err == nil {
+		t.Error("Expected an error registering a TCP stub after Start")
+	}
+
+	// The TCP listener reports its port after the HTTP server is ready
+	var conn net.Conn
+	for deadline := time.Now().Add(5 * time.Second); conn == nil; {
+		if addr, err := server.TCPAddr(); err == nil {
+			conn, _ = net.Dial("tcp", addr)
+		}
+		if conn == nil {
+			if time.Now().After(deadline) {
+				t.Fatal("TCP server never accepted connections")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	conn.Write([]byte{0x02, 0x30, 0x31, 0x03})
+	reply := make([]byte, 9)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		t.Fatalf("Failed to read reply: %v", err)
+	}
+	if string(reply) != "\x06APPROVED" {
+		t.Errorf("Expected ACK and APPROVED, got %q", reply)
+	}
+
+	// The connection stays open until a stub closes it
+	conn.Write([]byte("QUIT\n"))
+	if rest, err := io.ReadAll(conn); err != nil || string(rest) != "BYE\n" {
+		t.Errorf("Expected BYE and a closed connection, got %q (%v)", rest, err)
+	}
+}
+
+// wsConn is a minimal WebSocket client, enough to drive scripted
+// conversations without a client library
+type wsConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocket opens a WebSocket connection to path on the server's HTTP
+// port, which serves the same scripts as the WebSocket listener
+func dialWebSocket(t *testing.T, server *MockServer, path string) *wsConn {
+	t.Helper()
+	addr := strings.TrimPrefix(server.URL(), "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", path, addr)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101 Switching Protocols, got %d", resp.StatusCode)
+	}
+	return &wsConn{Conn: conn, reader: reader}
+}
+
+// send writes a masked client frame with opcode, 1 for text and 2 for binary
+func (c *wsConn) send(t *testing.T, opcode byte, payload []byte) {
+	t.Helper()
+	if len(payload) > 125 {
+		t.Fatalf("Payload of %d bytes needs an extended length", len(payload))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame := append([]byte{0x80 | opcode, 0x80 | byte(len(payload))}, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.Write(frame); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+}
+
+// receive reads the next server frame
+func (c *wsConn) receive(t *testing.T) (opcode byte, payload []byte) {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		io.ReadFull(c.reader, ext)
+		length = uint64(ext[0])<<8 | uint64(ext[1])
+	case 127:
+		ext := make([]byte, 8)
+		io.ReadFull(c.reader, ext)
+		length = 0
+		for _, b := range ext {
+			length = length<<8 | uint64(b)
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatalf("Failed to read frame payload: %v", err)
+	}
+	return header[0] & 0x0f, payload
+}
+
+func TestMockServerWebSocketScripts(t *testing.T) {
+	requireCLI(t)
+	server := NewMockServer(MockServerConfig{})
+	err := server.StubWebSocket("/ws/orders", WSScript{
+		WSExpect("subscribe"),
+		WSSendText("subscribed", 0),
+		WSCloseWith(1001, "going away", 0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub WebSocket: %v", err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server with WebSocket scripts: %v", err)
+	}
+	defer server.Stop()
+
+	// Scripts can be added while the server runs, one per path
+	err = server.StubWebSocket("/ws/frames", WSScript{
+		WSExpectBinary([]byte{0x01, 0x02}),
+		WSSendBinary([]byte{0x06, 0x07}, 0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub WebSocket after Start: %v", err)
+	}
+	if err := server.StubWebSocket("/ws/orders", WSScript{WSSendText("hi", 0)}); err == nil {
+		t.Error("Expected error for a second script on a path")
+	}
+
+	orders := dialWebSocket(t, server, "/ws/orders")
+	orders.send(t, 1, []byte(`{"type":"subscribe"}`))
+	if opcode, payload := orders.receive(t); opcode != 1 || string(payload) != "subscribed" {
+		t.Errorf("Expected text frame subscribed, got opcode %d %q", opcode, payload)
+	}
+	if opcode, payload := orders.receive(t); opcode != 8 || len(payload) < 2 || int(payload[0])<<8|int(payload[1]) != 1001 || string(payload[2:]) != "going away" {
+		t.Errorf("Expected close 1001 going away, got opcode %d %q", opcode, payload)
+	}
+
+	frames := dialWebSocket(t, server, "/ws/frames")
+	frames.send(t, 2, []byte{0x00, 0x01, 0x02})
+	if opcode, payload := frames.receive(t); opcode != 2 || !bytes.Equal(payload, []byte{0x06, 0x07}) {
+		t.Errorf("Expected binary frame 06 07, got opcode %d %x", opcode, payload)
+	}
+}
+
+// timedGet sends a GET to path and returns the status and how long the
+// response took
+func timedGet(t *testing.T, server *MockServer, path string) (int, time.Duration) {
+	t.Helper()
+	started := time.Now()
+	status := sendRequest(t, server, "GET", path, nil, "")
+	return status, time.Since(started)
+}
+
+func TestMockServerChaos(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.StubResponse("GET", "/orders", []interface{}{}); err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+
+	if err := server.SetGlobalLatency(300*time.Millisecond, 0); err != nil {
+		t.Fatalf("Failed to set latency: %v", err)
+	}
+	if status, elapsed := timedGet(t, server, "/orders"); status != http.StatusOK || elapsed < 300*time.Millisecond {
+		t.Errorf("Expected a 200 delayed by 300ms, got %d after %v", status, elapsed)
+	}
+	// The admin API is never delayed
+	started := time.Now()
+	if _, err := server.AuditLog(); err != nil {
+		t.Fatalf("Failed to list audit log: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed >= 300*time.Millisecond {
+		t.Errorf("Expected the admin API undelayed, took %v", elapsed)
+	}
+	if err := server.SetGlobalLatency(0, 0); err != nil {
+		t.Fatalf("Failed to clear latency: %v", err)
+	}
+
+	if err := server.SetFaultInjection(FaultInjection{Enabled: true, FailureRate: 1, StatusCodes: []int{503}}); err != nil {
+		t.Fatalf("Failed to set faults: %v", err)
+	}
+	if status := sendRequest(t, server, "GET", "/orders", nil, ""); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected the injected 503, got %d", status)
+	}
+	result, err := server.VerifyResponses(VerificationRequest{Path: "/orders"}, ResponseMatcher{Status: 503}, Exactly(1))
+	if err != nil {
+		t.Fatalf("Failed to verify responses: %v", err)
+	}
+	if !result.Matched {
+		t.Errorf("Expected one journaled 503, got %d", result.Count)
+	}
+	if err := server.SetChaosEnabled(false); err != nil {
+		t.Fatalf("Failed to turn chaos off: %v", err)
+	}
+	if status := sendRequest(t, server, "GET", "/orders", nil, ""); status != http.StatusOK {
+		t.Errorf("Expected 200 with chaos off, got %d", status)
+	}
+
+	t.Run("scenario", func(t *testing.T) {
+		if err := server.SetFaultInjection(FaultInjection{}); err != nil {
+			t.Fatalf("Failed to clear faults: %v", err)
+		}
+		// slow_backend delays every response by 2s, give or take 10%
+		if err := server.StartChaosScenario("slow_backend"); err != nil {
+			t.Fatalf("Failed to start scenario: %v", err)
+		}
+		if status, elapsed := timedGet(t, server, "/orders"); status != http.StatusOK || elapsed < 1500*time.Millisecond {
+			t.Errorf("Expected a 200 delayed by about 2s, got %d after %v", status, elapsed)
+		}
+		if err := server.StopChaosScenario("slow_backend"); err != nil {
+			t.Fatalf("Failed to stop scenario: %v", err)
+		}
+		if _, elapsed := timedGet(t, server, "/orders"); elapsed >= 1500*time.Millisecond {
+			t.Errorf("Expected no delay after stopping the scenario, took %v", elapsed)
+		}
+		if err := server.StartChaosScenario("no_such_scenario"); err == nil {
+			t.Error("Expected error for an unknown scenario")
+		}
+	})
+}
+
+func TestMockServerAuditLog(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	id, err := server.CreateStub(NewStubBuilder("GET", "/audited").Body("ok").Build())
+	if err != nil {
+		t.Fatalf("Failed to create stub: %v", err)
+	}
+	if err := server.SetGlobalLatency(10*time.Millisecond, 0); err != nil {
+		t.Fatalf("Failed to set latency: %v", err)
+	}
+	if err := server.DeleteStub(id); err != nil {
+		t.Fatalf("Failed to delete stub: %v", err)
+	}
+
+	// Mock changes reach the audit log asynchronously
+	find := func(action AuditAction) *AuditEntry {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+			entries, err := server.QueryAuditLog(AuditQuery{Action: action})
+			if err != nil {
+				t.Fatalf("Failed to query audit log: %v", err)
+			}
+			for i := range entries {
+				if entries[i].Action != action {
+					t.Errorf("Expected only %s entries, got %s", action, entries[i].Action)
+				}
+				if action == AuditConfigLatencyUpdated || entries[i].Resource == id {
+					return &entries[i]
+				}
+			}
+			if time.Now().After(deadline) {
+				return nil
+			}
+		}
+	}
+
+	if entry := find(AuditRouteCreated); entry == nil {
+		t.Error("Expected the stub's creation audited")
+	} else if entry.Description != "Created GET /audited" {
+		t.Errorf("Expected description Created GET /audited, got %q", entry.Description)
+	}
+	if find(AuditConfigLatencyUpdated) == nil {
+		t.Error("Expected the latency change audited")
+	}
+	if find(AuditRouteDeleted) == nil {
+		t.Error("Expected the stub's deletion audited")
+	}
+	entries, err := server.QueryAuditLog(AuditQuery{Action: "no_such_action"})
+	if err != nil {
+		t.Fatalf("Failed to query audit log: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries for an unknown action, got %d", len(entries))
+	}
+}
+
+func TestMockServerClock(t *testing.T) {
+	t.Setenv("MOCKFORGE_RESPONSE_TEMPLATE_EXPAND", "true")
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.AddStub(NewStubBuilder("GET", "/now").Body(map[string]interface{}{"now": "{{now}}"}).Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	fetchNow := func() string {
+		t.Helper()
+		resp, err := http.Get(server.URL() + "/now")
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Now string `json:"now"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode body: %v", err)
+		}
+		return body.Now
+	}
+
+	frozen := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := server.SetMockTime(frozen); err != nil {
+		t.Fatalf("Failed to set mock time: %v", err)
+	}
+	if now := fetchNow(); !strings.HasPrefix(now, "2030-01-02T03:04:05") {
+		t.Errorf("Expected {{now}} at the mock time, got %s", now)
+	}
+
+	if err := server.AdvanceTime(time.Hour); err != nil {
+		t.Fatalf("Failed to advance time: %v", err)
+	}
+	if now, err := server.MockTime(); err != nil {
+		t.Fatalf("Failed to get mock time: %v", err)
+	} else if !now.Equal(frozen.Add(time.Hour)) {
+		t.Errorf("Expected %v, got %v", frozen.Add(time.Hour), now)
+	}
+	// Sub-second steps are set as an absolute time
+	if err := server.AdvanceTime(1500 * time.Millisecond); err != nil {
+		t.Fatalf("Failed to advance time: %v", err)
+	}
+	if now, err := server.MockTime(); err != nil {
+		t.Fatalf("Failed to get mock time: %v", err)
+	} else if !now.Equal(frozen.Add(time.Hour + 1500*time.Millisecond)) {
+		t.Errorf("Expected %v, got %v", frozen.Add(time.Hour+1500*time.Millisecond), now)
+	}
+	if now := fetchNow(); !strings.HasPrefix(now, "2030-01-02T04:04:06") {
+		t.Errorf("Expected {{now}} at the advanced time, got %s", now)
+	}
+
+	if err := server.ResetTime(); err != nil {
+		t.Fatalf("Failed to reset time: %v", err)
+	}
+	if now := fetchNow(); strings.HasPrefix(now, "2030") {
+		t.Errorf("Expected {{now}} back on the real clock, got %s", now)
+	}
+}
+
+func TestMockServerDryRun(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	id, err := server.CreateStub(NewStubBuilder("GET", "/kept").Body("kept").Build())
+	if err != nil {
+		t.Fatalf("Failed to create stub: %v", err)
+	}
+
+	server.WithDryRun(true)
+	if err := server.ClearStubs(); err != nil {
+		t.Fatalf("Failed to clear stubs in dry-run mode: %v", err)
+	}
+	changes := server.DryRunChanges()
+	if len(changes) != 1 || !reflect.DeepEqual(changes[0].Targets, []string{id}) {
+		t.Errorf("Expected one change targeting %s, got %+v", id, changes)
+	}
+	if status := sendRequest(t, server, "GET", "/kept", nil, ""); status != http.StatusOK {
+		t.Errorf("Expected the stub still served, got %d", status)
+	}
+
+	server.WithDryRun(false)
+	if err := server.ClearStubs(); err != nil {
+		t.Fatalf("Failed to clear stubs: %v", err)
+	}
+	if status := sendRequest(t, server, "GET", "/kept", nil, ""); status != http.StatusNotFound {
+		t.Errorf("Expected the cleared stub gone, got %d", status)
+	}
+}
+
+func TestMockServerVerification(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	err := server.StubAll([]ResponseStub{
+		NewStubBuilder("POST", "/orders").Status(201).Body(map[string]interface{}{"id": 1}).Build(),
+		NewStubBuilder("GET", "/orders/1").Body(map[string]interface{}{"id": 1}).Build(),
+		NewStubBuilder("GET", "/slow").Latency(300).Body("ok").Build(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+	if err := server.ResetRequestJournal(); err != nil {
+		t.Fatalf("Failed to reset journal: %v", err)
+	}
+
+	order := `{"customer":"c1","items":[{"sku":"A1","qty":2}]}`
+	headers := map[string]string{"Content-Type": "application/json", "X-Request-Id": "req-1", "Authorization": "Bearer secret"}
+	if status := sendRequest(t, server, "POST", "/orders", headers, order); status != 201 {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	if status := sendRequest(t, server, "GET", "/orders/1", nil, ""); status != 200 {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+
+	verify := func(pattern VerificationRequest, expected VerificationCount) *VerificationResult {
+		t.Helper()
+		result, err := server.Verify(pattern, expected)
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		return result
+	}
+
+	t.Run("body and header matchers", func(t *testing.T) {
+		result := verify(VerificationRequest{
+			Method:         "POST",
+			Path:           "/orders",
+			HeaderPatterns: map[string]string{"X-Request-Id": `^req-\d+$`},
+			HeadersPresent: []string{"Authorization"},
+			BodyJSONPath:   map[string]interface{}{"$.items[0].sku": "A1"},
+			BodyJSONSchema: json.RawMessage(`{"required": ["customer"]}`),
+		}, Exactly(1))
+		if !result.Matched {
+			t.Errorf("Expected the order matched, got %d", result.Count)
+		}
+
+		// Credentials are journaled by name only, so absence still fails
+		result = verify(VerificationRequest{Method: "POST", Path: "/orders", HeadersAbsent: []string{"Authorization"}}, AtLeastOnce())
+		if result.Matched || len(result.NearMisses) == 0 || !reflect.DeepEqual(result.NearMisses[0].Differences, []string{"unexpected header Authorization"}) {
+			t.Errorf("Expected a near miss for the Authorization header, got %+v", result)
+		}
+	})
+
+	t.Run("near misses", func(t *testing.T) {
+		result := verify(VerificationRequest{Method: "GET", Path: "/order/1"}, Exactly(1))
+		if result.Matched || len(result.NearMisses) == 0 || result.NearMisses[0].Request.Path != "/orders/1" {
+			t.Errorf("Expected /orders/1 as the nearest miss, got %+v", result.NearMisses)
+		}
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		if result, err := server.VerifyNever(VerificationRequest{Path: "/refunds"}); err != nil || !result.Matched {
+			t.Errorf("Expected no refunds, got %+v (%v)", result, err)
+		}
+		if result, err := server.VerifyAtLeast(VerificationRequest{Path: "/orders/*"}, 1); err != nil || !result.Matched {
+			t.Errorf("Expected at least one order lookup, got %+v (%v)", result, err)
+		}
+		if count, err := server.CountRequests(VerificationRequest{Path: "/orders/1"}); err != nil || count != 1 {
+			t.Errorf("Expected 1 order lookup, got %d (%v)", count, err)
+		}
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		create := VerificationRequest{Method: "POST", Path: "/orders"}
+		lookup := VerificationRequest{Method: "GET", Path: "/orders/1"}
+		if result, err := server.VerifySequence([]VerificationRequest{create, lookup}, StrictOrder()); err != nil || !result.Matched {
+			t.Errorf("Expected create then lookup, got %+v (%v)", result, err)
+		}
+		result, err := server.VerifySequence([]VerificationRequest{lookup, create})
+		if err != nil {
+			t.Fatalf("Failed to verify sequence: %v", err)
+		}
+		if result.Matched || result.FailedStep == nil || *result.FailedStep != 1 {
+			t.Errorf("Expected step 1 to fail, got %+v", result)
+		}
+		result, err = server.VerifySequence([]VerificationRequest{create, lookup}, StepCounts(Exactly(1), Exactly(2)))
+		if err != nil || result.Matched || result.FailedStep == nil || *result.FailedStep != 1 {
+			t.Errorf("Expected step 1 to fail its count, got %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		skus, err := CaptureRequests(server, VerificationRequest{Path: "/orders"}, CaptureJSONPath[string]("$.items[0].sku"))
+		if err != nil || !reflect.DeepEqual(skus, []string{"A1"}) {
+			t.Errorf("Expected captured SKU A1, got %v (%v)", skus, err)
+		}
+		ids, err := CaptureRequests(server, VerificationRequest{Path: "/orders"}, CaptureHeader("X-Request-Id"))
+		if err != nil || !reflect.DeepEqual(ids, []string{"req-1"}) {
+			t.Errorf("Expected captured request ID req-1, got %v (%v)", ids, err)
+		}
+
+		requests, err := server.GetRequests(RequestFilter{Method: "POST", Path: "/orders"})
+		if err != nil {
+			t.Fatalf("Failed to get requests: %v", err)
+		}
+		if len(requests) != 1 {
+			t.Fatalf("Expected 1 request, got %d", len(requests))
+		}
+		var body struct {
+			Customer string `json:"customer"`
+		}
+		if err := requests[0].DecodeJSON(&body); err != nil || body.Customer != "c1" {
+			t.Errorf("Expected customer c1, got %q (%v)", body.Customer, err)
+		}
+		if requests[0].StubID == "" || requests[0].Status != 201 {
+			t.Errorf("Expected a stub ID and status 201, got %q and %d", requests[0].StubID, requests[0].Status)
+		}
+	})
+
+	t.Run("marks and eventually", func(t *testing.T) {
+		mark, err := server.MarkJournal()
+		if err != nil {
+			t.Fatalf("Failed to mark journal: %v", err)
+		}
+		go func() {
+			time.Sleep(300 * time.Millisecond)
+			if resp, err := http.Get(server.URL() + "/orders/1"); err == nil {
+				resp.Body.Close()
+			}
+		}()
+		pattern := VerificationRequest{Path: "/orders/1", SinceMark: mark}
+		result, err := server.VerifyEventually(pattern, Exactly(1), 5*time.Second, 50*time.Millisecond)
+		if err != nil || !result.Matched {
+			t.Errorf("Expected the lookup after the mark, got %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		if status := sendRequest(t, server, "GET", "/typo", nil, ""); status != 404 {
+			t.Errorf("Expected 404, got %d", status)
+		}
+		unmatched, err := server.UnmatchedRequests()
+		if err != nil || len(unmatched) != 1 || unmatched[0].Path != "/typo" {
+			t.Errorf("Expected /typo unmatched, got %+v (%v)", unmatched, err)
+		}
+		result, err := server.VerifyResponses(VerificationRequest{Path: "/typo"}, ResponseMatcher{MinStatus: 400, MaxStatus: 499}, Exactly(1))
+		if err != nil || !result.Matched {
+			t.Errorf("Expected one client error, got %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("timing", func(t *testing.T) {
+		done := make(chan struct{})
+		for i := 0; i < 3; i++ {
+			go func() {
+				defer func() { done <- struct{}{} }()
+				if resp, err := http.Get(server.URL() + "/slow"); err == nil {
+					resp.Body.Close()
+				}
+			}()
+		}
+		for i := 0; i < 3; i++ {
+			<-done
+		}
+
+		slow := VerificationRequest{Path: "/slow"}
+		if result, err := server.VerifyMaxConcurrency(slow, 1); err != nil || result.Matched || result.Count < 2 {
+			t.Errorf("Expected overlapping slow requests, got %+v (%v)", result, err)
+		}
+		if result, err := server.VerifyMaxConcurrency(slow, 3); err != nil || !result.Matched {
+			t.Errorf("Expected at most 3 concurrent slow requests, got %+v (%v)", result, err)
+		}
+		stats, err := server.RequestLatencyStats(slow)
+		if err != nil || stats.Count != 3 || stats.Min < 300*time.Millisecond {
+			t.Errorf("Expected 3 requests of at least 300ms, got %v (%v)", stats, err)
+		}
+		if result, err := server.VerifyNoDuplicates(slow, time.Second); err != nil || result.Matched {
+			t.Errorf("Expected the identical slow requests reported as duplicates, got %+v (%v)", result, err)
+		}
+		if result, err := server.VerifyNoDuplicates(VerificationRequest{Method: "POST", Path: "/orders"}, time.Second); err != nil || !result.Matched {
+			t.Errorf("Expected no duplicate orders, got %+v (%v)", result, err)
+		}
+	})
+
+	if err := server.ResetRequestJournal(); err != nil {
+		t.Fatalf("Failed to reset journal: %v", err)
+	}
+	if count, err := server.CountRequests(VerificationRequest{Path: "/orders/1"}); err != nil || count != 0 {
+		t.Errorf("Expected an empty journal after reset, got %d (%v)", count, err)
+	}
+}
+
+func TestMockServerRetryDuplicates(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.StubResponse("POST", "/payments", map[string]interface{}{"ok": true}); err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+	for _, attempt := range []string{"1", "2"} {
+		headers := map[string]string{"Content-Type": "application/json", "X-Attempt": attempt}
+		if status := sendRequest(t, server, "POST", "/payments?attempt="+attempt, headers, `{"amount":10}`); status != 200 {
+			t.Fatalf("Expected 200, got %d", status)
+		}
+	}
+
+	payments := VerificationRequest{Method: "POST", Path: "/payments"}
+	duplicates := func(opts ...DuplicateOption) *VerificationResult {
+		t.Helper()
+		result, err := server.VerifyNoDuplicates(payments, time.Minute, opts...)
+		if err != nil {
+			t.Fatalf("Failed to verify duplicates: %v", err)
+		}
+		return result
+	}
+	if result := duplicates(); !result.Matched {
+		t.Errorf("Expected retries with different attempts to differ, got %+v", result)
+	}
+	if result := duplicates(IgnoreHeader("x-attempt")); !result.Matched {
+		t.Errorf("Expected the query parameter to tell retries apart, got %+v", result)
+	}
+	if result := duplicates(IgnoreHeader("x-attempt"), IgnoreQueryParam("attempt")); result.Matched || result.Count != 1 {
+		t.Errorf("Expected one duplicate ignoring the attempt, got %+v", result)
+	}
+
+	attempts, err := CaptureRequests(server, payments, CaptureQueryParam("attempt"))
+	if err != nil || !reflect.DeepEqual(attempts, []string{"1", "2"}) {
+		t.Errorf("Expected attempts 1 and 2, got %v (%v)", attempts, err)
+	}
+	type payment struct {
+		Amount int `json:"amount"`
+	}
+	bodies, err := CaptureRequests(server, payments, CaptureBody[payment]())
+	if err != nil || !reflect.DeepEqual(bodies, []payment{{10}, {10}}) {
+		t.Errorf("Expected two payments of 10, got %v (%v)", bodies, err)
+	}
+}
+
+func TestMockServerVerificationGroup(t *testing.T) {
+	payments := startCLIServer(t, MockServerConfig{})
+	ledger := startCLIServer(t, MockServerConfig{})
+	if err := payments.StubResponse("POST", "/charges", "charged"); err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+	if err := ledger.StubResponse("POST", "/entries", "written"); err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+	group := NewVerificationGroup().Add("payments", payments).Add("ledger", ledger)
+	if err := group.ResetRequestJournals(); err != nil {
+		t.Fatalf("Failed to reset journals: %v", err)
+	}
+
+	if status := sendRequest(t, payments, "POST", "/charges", nil, ""); status != 200 {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	// Timestamps order requests across servers, so keep them apart
+	time.Sleep(50 * time.Millisecond)
+	if status := sendRequest(t, ledger, "POST", "/entries", nil, ""); status != 200 {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+
+	charge := GroupStep{Server: "payments", Pattern: VerificationRequest{Method: "POST", Path: "/charges"}}
+	entry := GroupStep{Server: "ledger", Pattern: VerificationRequest{Method: "POST", Path: "/entries"}}
+	if result, err := group.VerifySequence([]GroupStep{charge, entry}); err != nil || !result.Matched {
+		t.Errorf("Expected the charge before the entry, got %+v (%v)", result, err)
+	}
+	result, err := group.VerifySequence([]GroupStep{entry, charge})
+	if err != nil || result.Matched || result.FailedStep == nil || *result.FailedStep != 1 {
+		t.Errorf("Expected step 1 to fail in reverse, got %+v (%v)", result, err)
+	}
+
+	if result, err := group.Verify(VerificationRequest{Method: "POST"}, Exactly(2)); err != nil || !result.Matched {
+		t.Errorf("Expected two requests across the group, got %+v (%v)", result, err)
+	}
+	if result, err := group.Verify(VerificationRequest{Path: "/charges"}, AtMost(1)); err != nil || !result.Matched {
+		t.Errorf("Expected at most one charge, got %+v (%v)", result, err)
+	}
+	if err := group.ResetRequestJournals(); err != nil {
+		t.Fatalf("Failed to reset journals: %v", err)
+	}
+	if result, err := group.Verify(VerificationRequest{Method: "POST"}, Never()); err != nil || !result.Matched {
+		t.Errorf("Expected empty journals after reset, got %+v (%v)", result, err)
+	}
+}
+
+func TestMockServerStrictStubbing(t *testing.T) {
+	// Shadow mode would answer unknown paths; strict stubbing overrides it
+	t.Setenv("MOCKFORGE_SHADOW_MODE", "true")
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true, JournalLimit: 3})
+
+	for i := 0; i < 5; i++ {
+		if status := sendRequest(t, server, "GET", fmt.Sprintf("/missing/%d", i), nil, ""); status != 404 {
+			t.Errorf("Expected 404 for an unstubbed path, got %d", status)
+		}
+	}
+	unmatched, err := server.UnmatchedRequests()
+	if err != nil {
+		t.Fatalf("Failed to list unmatched requests: %v", err)
+	}
+	// The journal keeps only the newest three requests
+	if len(unmatched) != 3 || unmatched[2].Path != "/missing/4" {
+		t.Errorf("Expected the last 3 requests unmatched, got %+v", unmatched)
+	}
+}
+
+// getBody sends a GET to path with headers and returns the status and body
+func getBody(t *testing.T, server *MockServer, path string, headers map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest("GET", server.URL()+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestMockServerApplyPreset(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	err := server.ApplyPreset(ComposePresets("secured",
+		EnvironmentPreset{Stubs: []ResponseStub{NewStubBuilder("GET", "/account").Body(map[string]interface{}{"plan": "pro"}).Build()}},
+		EnvironmentPreset{Latency: &PresetLatency{Base: 200 * time.Millisecond}, Auth: &PresetAuth{BearerToken: "s3cret"}},
+	))
+	if err != nil {
+		t.Fatalf("Failed to apply preset: %v", err)
+	}
+
+	if status, _ := getBody(t, server, "/account", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", status)
+	}
+	if status, _ := getBody(t, server, "/account", map[string]string{"Authorization": "Bearer wrong"}); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for the wrong token, got %d", status)
+	}
+	started := time.Now()
+	status, body := getBody(t, server, "/account", map[string]string{"Authorization": "Bearer s3cret"})
+	if status != http.StatusOK || !strings.Contains(body, `"pro"`) {
+		t.Errorf("Expected the account with the token, got %d %s", status, body)
+	}
+	if elapsed := time.Since(started); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected the preset's 200ms latency, took %v", elapsed)
+	}
+
+	if err := server.ApplyPreset(EnvironmentPreset{Name: "broken", ChaosScenarios: []string{"no_such_scenario"}}); err == nil {
+		t.Error("Expected error for a preset with an unknown chaos scenario")
+	}
+}
+
+func TestMockServerRedirects(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	final := NewStubBuilder("GET", "/final").Body(map[string]interface{}{"arrived": true}).Build()
+	if err := server.StubRedirectChain(http.StatusFound, final, "/start", "/hop"); err != nil {
+		t.Fatalf("Failed to stub redirect chain: %v", err)
+	}
+	if err := server.StubRedirectLoop(http.StatusTemporaryRedirect, "/ping", "/pong"); err != nil {
+		t.Fatalf("Failed to stub redirect loop: %v", err)
+	}
+	if err := server.StubRedirect("/old", "/final", http.StatusMovedPermanently); err != nil {
+		t.Fatalf("Failed to stub redirect: %v", err)
+	}
+
+	var hops []string
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		hops = append(hops, req.URL.Path)
+		if len(via) >= 5 {
+			return errors.New("redirect loop")
+		}
+		return nil
+	}}
+	resp, err := client.Get(server.URL() + "/start")
+	if err != nil {
+		t.Fatalf("Failed to follow redirects: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "arrived") {
+		t.Errorf("Expected the final response, got %d %s", resp.StatusCode, body)
+	}
+	if !reflect.DeepEqual(hops, []string{"/hop", "/final"}) {
+		t.Errorf("Expected redirects to /hop and /final, got %v", hops)
+	}
+
+	if _, err := client.Get(server.URL() + "/ping"); err == nil || !strings.Contains(err.Error(), "redirect loop") {
+		t.Errorf("Expected the loop to be detected, got %v", err)
+	}
+
+	noFollow := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err = noFollow.Get(server.URL() + "/old")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "/final" {
+		t.Errorf("Expected a 301 to /final, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+func TestMockServerStubFiles(t *testing.T) {
+	source := startCLIServer(t, MockServerConfig{})
+	stubs := []ResponseStub{
+		NewStubBuilder("GET", "/users/{id}").Body(map[string]interface{}{"name": "Alice"}).Build(),
+		NewStubBuilder("POST", "/users").Status(201).WhenHeader("X-Tenant", "acme").Build(),
+	}
+	if err := source.StubAll(stubs); err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+
+	for _, format := range []StubFormat{StubFormatJSON, StubFormatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := source.ExportStubs(&buf, format); err != nil {
+				t.Fatalf("Failed to export stubs: %v", err)
+			}
+			target := startCLIServer(t, MockServerConfig{})
+			if err := target.ImportStubs(&buf); err != nil {
+				t.Fatalf("Failed to import stubs: %v", err)
+			}
+			if status, body := getBody(t, target, "/users/7", nil); status != 200 || !strings.Contains(body, "Alice") {
+				t.Errorf("Expected the imported user stub, got %d %s", status, body)
+			}
+			if status := sendRequest(t, target, "POST", "/users", map[string]string{"X-Tenant": "acme"}, ""); status != 201 {
+				t.Errorf("Expected the imported matcher honored, got %d", status)
+			}
+			if status := sendRequest(t, target, "POST", "/users", nil, ""); status != 404 {
+				t.Errorf("Expected 404 without the tenant header, got %d", status)
+			}
+		})
+	}
+}
+
+func TestMockServerPersistStubs(t *testing.T) {
+	dir := t.TempDir()
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.PersistStubs(dir); err != nil {
+		t.Fatalf("Failed to persist stubs: %v", err)
+	}
+	if err := server.StubResponse("GET", "/kept", map[string]interface{}{"kept": true}); err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stubs.json")); err != nil {
+		t.Fatalf("Expected stubs.json written: %v", err)
+	}
+
+	port := server.Port()
+	if err := server.Restart(); err != nil {
+		t.Fatalf("Failed to restart: %v", err)
+	}
+	if server.Port() != port {
+		t.Errorf("Expected the restarted server on port %d, got %d", port, server.Port())
+	}
+	if status, body := getBody(t, server, "/kept", nil); status != 200 || !strings.Contains(body, "kept") {
+		t.Errorf("Expected the persisted stub served after restart, got %d %s", status, body)
+	}
+}
+
+func TestMockServerStubFromSpec(t *testing.T) {
+	spec, err := filepath.Abs("testdata/users.yaml")
+	if err != nil {
+		t.Fatalf("Failed to resolve spec: %v", err)
+	}
+	server := startCLIServer(t, MockServerConfig{OpenAPISpec: spec})
+
+	// The spec's own route answers until a stub overrides it
+	if status, _ := getBody(t, server, "/users/1", nil); status != 200 {
+		t.Errorf("Expected the spec route to answer, got %d", status)
+	}
+	if err := server.StubFromSpec("getUser", WithField("email", "carol@example.com"), WithResponseHeader("X-Source", "stub")); err != nil {
+		t.Fatalf("Failed to stub from spec: %v", err)
+	}
+	status, body := getBody(t, server, "/users/1", nil)
+	if status != 200 || !strings.Contains(body, "carol@example.com") {
+		t.Errorf("Expected the stub's user, got %d %s", status, body)
+	}
+
+	if err := server.ClearStubs(); err != nil {
+		t.Fatalf("Failed to clear stubs: %v", err)
+	}
+	if err := server.StubFromSpec("getUser", WithStatus(404)); err != nil {
+		t.Fatalf("Failed to stub from spec: %v", err)
+	}
+	if status, body := getBody(t, server, "/users/1", nil); status != 404 || !strings.Contains(body, "not found") {
+		t.Errorf("Expected the spec's 404 example, got %d %s", status, body)
+	}
+}
+
+func TestMockServerBundle(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	bundle, err := BundleFromSpecExamples("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("Failed to mine bundle: %v", err)
+	}
+	if err := server.LoadBundle(bundle); err != nil {
+		t.Fatalf("Failed to load bundle: %v", err)
+	}
+
+	tests := []struct {
+		prefer string
+		status int
+		want   string
+	}{
+		{"", 200, "Tom"},
+		{"example=dog", 200, "Spike"},
+		{"code=404", 404, "not found"},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		if tt.prefer != "" {
+			headers["Prefer"] = tt.prefer
+		}
+		if status, body := getBody(t, server, "/pets/9", headers); status != tt.status || !strings.Contains(body, tt.want) {
+			t.Errorf("Prefer %q: expected %d with %s, got %d %s", tt.prefer, tt.status, tt.want, status, body)
+		}
+	}
+}
+
+func TestMockServerGraphQL(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	err := server.StubGraphQL("GetUser", map[string]interface{}{"id": "1"},
+		map[string]interface{}{"user": map[string]interface{}{"name": "Alice"}})
+	if err != nil {
+		t.Fatalf("Failed to stub GraphQL: %v", err)
+	}
+	err = server.StubGraphQL("GetUser", nil, GraphQLErrors(GraphQLErrorCode("user not found", "NOT_FOUND")))
+	if err != nil {
+		t.Fatalf("Failed to stub GraphQL: %v", err)
+	}
+	err = server.StubGraphQL("ListUsers", nil, GraphQLPartial(
+		map[string]interface{}{"users": []interface{}{map[string]interface{}{"name": "Alice"}, nil}},
+		GraphQLError{Message: "user 2 unavailable"}))
+	if err != nil {
+		t.Fatalf("Failed to stub GraphQL: %v", err)
+	}
+
+	query := func(body string) GraphQLResponse {
+		t.Helper()
+		resp, err := http.Post(server.URL()+"/graphql", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to send query: %v", err)
+		}
+		defer resp.Body.Close()
+		var result GraphQLResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return result
+	}
+
+	// Named by operationName, with matching variables
+	result := query(`{"operationName":"GetUser","query":"query GetUser($id: ID!) { user(id: $id) { name } }","variables":{"id":"1"}}`)
+	if data, _ := json.Marshal(result.Data); !strings.Contains(string(data), "Alice") || len(result.Errors) != 0 {
+		t.Errorf("Expected Alice, got %+v", result)
+	}
+	// Named in the query document only, with other variables
+	result = query(`{"query":"query GetUser($id: ID!) { user(id: $id) { name } }","variables":{"id":"2"}}`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "NOT_FOUND" {
+		t.Errorf("Expected a NOT_FOUND error, got %+v", result)
+	}
+	// Partial results carry data and errors together
+	result = query(`{"operationName":"ListUsers","query":"query ListUsers { users { name } }"}`)
+	if data, _ := json.Marshal(result.Data); string(data) != `{"users":[{"name":"Alice"},null]}` || len(result.Errors) != 1 {
+		t.Errorf("Expected partial data with one error, got %+v", result)
+	}
+}
+
+// freePort returns a TCP port nothing listens on, for listeners whose port
+// must be known before the server starts
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to pick a port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// dialBroker connects to the address addr reports, retrying while the
+// broker starts; brokers report their port before they bind it
+func dialBroker(t *testing.T, name string, addr func() string) net.Conn {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+		if a := addr(); a != "" {
+			if conn, err := net.Dial("tcp", a); err == nil {
+				t.Cleanup(func() { conn.Close() })
+				conn.SetDeadline(time.Now().Add(10 * time.Second))
+				return conn
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s broker never accepted connections", name)
+		}
+	}
+}
+
+// mqttPacket frames an MQTT control packet
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	for n := len(body); ; {
+		b := byte(n % 128)
+		if n /= 128; n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// mqttString encodes s as an MQTT UTF-8 string
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
+
+// readMQTT reads the next MQTT packet, returning its fixed header byte and
+// body
+func readMQTT(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	header, err := r.ReadByte()
+	if err != nil {
+		t.Fatalf("Failed to read MQTT packet: %v", err)
+	}
+	length, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			t.Fatalf("Failed to read MQTT packet length: %v", err)
+		}
+		length |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		t.Fatalf("Failed to read MQTT packet body: %v", err)
+	}
+	return header, body
+}
+
+// readMQTTPublish reads the next PUBLISH packet, which must be QoS 0
+func readMQTTPublish(t *testing.T, r *bufio.Reader) (topic string, payload []byte, retain bool) {
+	t.Helper()
+	header, body := readMQTT(t, r)
+	if header&0xf6 != 0x30 {
+		t.Fatalf("Expected a QoS 0 PUBLISH, got packet 0x%02x", header)
+	}
+	n := int(body[0])<<8 | int(body[1])
+	return string(body[2 : 2+n]), body[2+n:], header&0x01 != 0
+}
+
+// amqpFrame frames an AMQP 0-9-1 frame
+func amqpFrame(kind byte, channel uint16, payload []byte) []byte {
+	frame := []byte{kind, byte(channel >> 8), byte(channel)}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	return append(append(frame, payload...), 0xce)
+}
+
+// amqpMethod encodes a method frame payload
+func amqpMethod(class, method uint16, args ...[]byte) []byte {
+	payload := binary.BigEndian.AppendUint16(nil, class)
+	payload = binary.BigEndian.AppendUint16(payload, method)
+	return append(payload, bytes.Join(args, nil)...)
+}
+
+// amqpShort encodes s as an AMQP short string
+func amqpShort(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// readAMQP reads the next frame, returning its type and payload
+func readAMQP(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatalf("Failed to read AMQP frame: %v", err)
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[3:])+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("Failed to read AMQP frame payload: %v", err)
+	}
+	return header[0], payload[:len(payload)-1]
+}
+
+// expectAMQPMethod reads the next frame, failing unless it is the method
+func expectAMQPMethod(t *testing.T, r *bufio.Reader, class, method uint16) []byte {
+	t.Helper()
+	kind, payload := readAMQP(t, r)
+	if kind != 1 || binary.BigEndian.Uint16(payload) != class || binary.BigEndian.Uint16(payload[2:]) != method {
+		t.Fatalf("Expected AMQP method %d.%d, got frame %d % x", class, method, kind, payload)
+	}
+	return payload[4:]
+}
+
+// openAMQPChannel performs the connection handshake and opens channel 1
+func openAMQPChannel(t *testing.T, conn net.Conn) *bufio.Reader {
+	t.Helper()
+	r := bufio.NewReader(conn)
+	conn.Write([]byte("AMQP\x00\x00\x09\x01"))
+	expectAMQPMethod(t, r, 10, 10)
+	response := []byte("\x00guest\x00guest")
+	conn.Write(amqpFrame(1, 0, amqpMethod(10, 11, []byte{0, 0, 0, 0}, amqpShort("PLAIN"),
+		binary.BigEndian.AppendUint32(nil, uint32(len(response))), response, amqpShort("en_US"))))
+	expectAMQPMethod(t, r, 10, 30)
+	conn.Write(amqpFrame(1, 0, amqpMethod(10, 31, []byte{0, 0, 0, 2, 0, 0, 0, 0})))
+	conn.Write(amqpFrame(1, 0, amqpMethod(10, 40, amqpShort("/"), amqpShort(""), []byte{0})))
+	expectAMQPMethod(t, r, 10, 41)
+	conn.Write(amqpFrame(1, 1, amqpMethod(20, 10, amqpShort(""))))
+	expectAMQPMethod(t, r, 20, 11)
+	return r
+}
+
+// getAMQPMessage takes the next message of queue with Basic.Get, returning
+// its content header and body
+func getAMQPMessage(t *testing.T, conn net.Conn, r *bufio.Reader, queue string) (header, body []byte) {
+	t.Helper()
+	conn.Write(amqpFrame(1, 1, amqpMethod(60, 70, []byte{0, 0}, amqpShort(queue), []byte{1})))
+	expectAMQPMethod(t, r, 60, 71)
+	if _, header = readAMQP(t, r); binary.BigEndian.Uint64(header[4:]) > 0 {
+		_, body = readAMQP(t, r)
+	}
+	return header, body
+}
+
+func TestMockServerBrokers(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "mockforge.yaml")
+	config := fmt.Sprintf("kafka: {enabled: true, port: %d}\nmqtt: {enabled: true, port: %d}\namqp: {enabled: true, port: %d}\n",
+		freePort(t), freePort(t), freePort(t))
+	if err := os.WriteFile(configFile, []byte(config), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	server := startCLIServer(t, MockServerConfig{ConfigFile: configFile, AsyncAPISpec: "testdata/events.yaml"})
+
+	t.Run("kafka", func(t *testing.T) {
+		kafka := server.Kafka()
+		dialBroker(t, "Kafka", kafka.BootstrapServers)
+
+		err := kafka.ProduceRecords("orders", []KafkaRecord{
+			{Key: "o-1", Value: []byte(`{"id":1}`), Headers: map[string]string{"source": "test"}},
+			{Key: "o-2", Value: []byte(`{"id":2}`)},
+		})
+		if err != nil {
+			t.Fatalf("Failed to produce records: %v", err)
+		}
+		records, err := kafka.Records("orders")
+		if err != nil {
+			t.Fatalf("Failed to list records: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("Expected 2 records, got %+v", records)
+		}
+		for _, record := range records {
+			if record.Key == "o-1" && (string(record.Value) != `{"id":1}` || record.Headers["source"] != "test") {
+				t.Errorf("Unexpected record %+v", record)
+			}
+		}
+		if err := kafka.VerifyProduced("orders", KafkaMatcher{Headers: map[string]string{"source": "test"}}, Exactly(1)); err != nil {
+			t.Error(err)
+		}
+		if err := kafka.VerifyProduced("orders", KafkaMatcher{ValueContains: `"id":3`}, AtLeastOnce()); err == nil {
+			t.Error("Expected verification of a missing record to fail")
+		}
+		if records, err := kafka.Records("unknown"); err != nil || len(records) != 0 {
+			t.Errorf("Expected no records in an unknown topic, got %v, %v", records, err)
+		}
+	})
+
+	t.Run("mqtt", func(t *testing.T) {
+		if err := server.PublishRetained("devices/d1/state", []byte(`{"on":true}`)); err != nil {
+			t.Fatalf("Failed to publish retained message: %v", err)
+		}
+
+		conn := dialBroker(t, "MQTT", server.MQTTAddr)
+		r := bufio.NewReader(conn)
+		connect := append(mqttString("MQTT"), 4, 0x02, 0, 60)
+		conn.Write(mqttPacket(0x10, append(connect, mqttString("thermostat")...)))
+		if header, body := readMQTT(t, r); header != 0x20 || body[1] != 0 {
+			t.Fatalf("Expected an accepting CONNACK, got 0x%02x % x", header, body)
+		}
+		subscribe := append([]byte{0, 1}, mqttString("devices/+/state")...)
+		subscribe = append(append(subscribe, 0), mqttString("devices/telemetry")...)
+		conn.Write(mqttPacket(0x82, append(subscribe, 0)))
+
+		// The SUBACK and the retained message arrive in either order
+		var retained []byte
+		for acked := false; !acked || retained == nil; {
+			header, body := readMQTT(t, r)
+			switch {
+			case header == 0x90:
+				acked = true
+			case header&0xf0 == 0x30:
+				n := int(body[0])<<8 | int(body[1])
+				if topic := string(body[2 : 2+n]); topic != "devices/d1/state" || header&0x01 == 0 {
+					t.Fatalf("Expected the retained state, got 0x%02x on %s", header, topic)
+				}
+				retained = body[2+n:]
+			default:
+				t.Fatalf("Unexpected MQTT packet 0x%02x", header)
+			}
+		}
+		if string(retained) != `{"on":true}` {
+			t.Errorf("Expected the retained payload, got %q", retained)
+		}
+
+		if err := server.VerifySubscribed("devices/+/state"); err != nil {
+			t.Error(err)
+		}
+		if err := server.VerifySubscribed("devices/#"); err == nil {
+			t.Error("Expected verification of a missing subscription to fail")
+		}
+
+		conn.Write(mqttPacket(0x30, append(mqttString("devices/d1/telemetry"), `{"celsius":21}`...)))
+		var err error
+		for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+			if err = server.VerifyPublished("devices/+/telemetry", "celsius", Exactly(1)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			t.Error(err)
+		}
+		// Messages published through the SDK are left out
+		messages, err := server.MQTTMessages()
+		if err != nil {
+			t.Fatalf("Failed to list messages: %v", err)
+		}
+		if len(messages) != 1 || messages[0].PublisherID != "thermostat" || messages[0].Topic != "devices/d1/telemetry" {
+			t.Errorf("Expected the thermostat's message only, got %+v", messages)
+		}
+
+		if err := server.PublishAsyncAPIExample("devices/telemetry", ""); err != nil {
+			t.Fatalf("Failed to publish example: %v", err)
+		}
+		topic, payload, _ := readMQTTPublish(t, r)
+		var reading struct{ Celsius *float64 }
+		if topic != "devices/telemetry" || json.Unmarshal(payload, &reading) != nil || reading.Celsius == nil || *reading.Celsius < 20 {
+			t.Errorf("Expected a generated reading on devices/telemetry, got %q on %s", payload, topic)
+		}
+	})
+
+	t.Run("amqp", func(t *testing.T) {
+		amqp := server.AMQP()
+		conn := dialBroker(t, "AMQP", amqp.Addr)
+		r := openAMQPChannel(t, conn)
+
+		if err := amqp.DeclareExchange("orders", "topic"); err != nil {
+			t.Fatalf("Failed to declare exchange: %v", err)
+		}
+		if err := amqp.DeclareQueue("order-events"); err != nil {
+			t.Fatalf("Failed to declare queue: %v", err)
+		}
+		if err := amqp.Bind("orders", "order-events", "orders.*.created"); err != nil {
+			t.Fatalf("Failed to bind queue: %v", err)
+		}
+		if err := amqp.Bind("missing", "order-events", "#"); err == nil {
+			t.Error("Expected binding to a missing exchange to fail")
+		}
+
+		// Seeded messages reach consumers with their headers
+		if err := amqp.Publish("orders", "orders.eu.created", []byte(`{"id":7}`), map[string]string{"x-tenant": "acme"}); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+		header, body := getAMQPMessage(t, conn, r, "order-events")
+		if string(body) != `{"id":7}` || !bytes.Contains(header, []byte("x-tenant")) || !bytes.Contains(header, []byte("acme")) {
+			t.Errorf("Expected the seeded message with its header, got header % x body %q", header, body)
+		}
+
+		// A client publish, with a content type and a header
+		table := append(amqpShort("x-tenant"), 'S', 0, 0, 0, 6)
+		table = append(table, "globex"...)
+		properties := append([]byte{0xa0, 0}, amqpShort("application/json")...)
+		properties = append(binary.BigEndian.AppendUint32(properties, uint32(len(table))), table...)
+		message := []byte(`{"id":8}`)
+		conn.Write(amqpFrame(1, 1, amqpMethod(60, 40, []byte{0, 0}, amqpShort("orders"), amqpShort("orders.us.created"), []byte{0})))
+		conn.Write(amqpFrame(2, 1, append(binary.BigEndian.AppendUint64([]byte{0, 60, 0, 0}, uint64(len(message))), properties...)))
+		conn.Write(amqpFrame(3, 1, message))
+
+		matcher := AMQPMatcher{Exchange: "orders", RoutingKey: "orders.#", Headers: map[string]string{"x-tenant": "globex"}, BodyContains: `"id":8`}
+		var err error
+		for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+			if err = amqp.VerifyPublished(matcher, Exactly(1)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			t.Error(err)
+		}
+		published, err := amqp.Published()
+		if err != nil {
+			t.Fatalf("Failed to list published messages: %v", err)
+		}
+		if len(published) != 1 || published[0].ContentType != "application/json" || published[0].RoutingKey != "orders.us.created" {
+			t.Errorf("Expected the client's message only, got %+v", published)
+		}
+		if _, body := getAMQPMessage(t, conn, r, "order-events"); string(body) != `{"id":8}` {
+			t.Errorf("Expected the client's message to be routed, got %q", body)
+		}
+
+		// Start declared and bound the AsyncAPI document's exchange and queue
+		if err := server.PublishAsyncAPIExample("invoices", "InvoiceIssued"); err != nil {
+			t.Fatalf("Failed to publish example: %v", err)
+		}
+		if _, body := getAMQPMessage(t, conn, r, "invoices"); !strings.Contains(string(body), "inv-1") {
+			t.Errorf("Expected the document's example invoice, got %q", body)
+		}
+	})
+
+	t.Run("asyncapi kafka", func(t *testing.T) {
+		channels, err := server.AsyncAPIChannels()
+		if err != nil || len(channels) != 3 {
+			t.Fatalf("Expected 3 channels, got %+v, %v", channels, err)
+		}
+		if err := server.PublishAsyncAPIExample("orders.created", ""); err != nil {
+			t.Fatalf("Failed to publish example: %v", err)
+		}
+		records, err := server.Kafka().Records("orders.created")
+		if err != nil || len(records) != 1 {
+			t.Fatalf("Expected 1 record, got %+v, %v", records, err)
+		}
+		var order struct{ ID string }
+		if json.Unmarshal(records[0].Value, &order) != nil || order.ID == "" {
+			t.Errorf("Expected a generated order, got %q", records[0].Value)
+		}
+	})
+}
+
+func TestMockServerSOAP(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{SOAPPath: "/ws"})
+	order := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+		`<GetOrderResponse><status>paid</status></GetOrderResponse></soap:Body></soap:Envelope>`
+	if err := server.StubSOAP("urn:GetOrder", map[string]string{"//GetOrder/id": "42"}, order); err != nil {
+		t.Fatalf("Failed to stub SOAP: %v", err)
+	}
+	if err := server.StubSOAP("urn:GetOrder", nil, SOAPFault("soap:Server", "unknown order")); err != nil {
+		t.Fatalf("Failed to stub SOAP fault: %v", err)
+	}
+
+	call := func(contentType, soapAction, id string) (int, string) {
+		t.Helper()
+		envelope := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<m:GetOrder xmlns:m="urn:orders"><m:id>` + id + `</m:id></m:GetOrder></soap:Body></soap:Envelope>`
+		req, err := http.NewRequest("POST", server.URL()+"/ws", strings.NewReader(envelope))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		if soapAction != "" {
+			req.Header.Set("SOAPAction", soapAction)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := call("text/xml", `"urn:GetOrder"`, "42"); status != 200 || !strings.Contains(body, "paid") {
+		t.Errorf("Expected the order for id 42, got %d %s", status, body)
+	}
+	if status, body := call("text/xml", "urn:GetOrder", "7"); status != 500 || !strings.Contains(body, "unknown order") {
+		t.Errorf("Expected the fault for id 7, got %d %s", status, body)
+	}
+	// SOAP 1.2 carries the action in the Content-Type
+	if status, body := call(`application/soap+xml; charset=utf-8; action="urn:GetOrder"`, "", "42"); status != 200 || !strings.Contains(body, "paid") {
+		t.Errorf("Expected the order via the SOAP 1.2 action, got %d %s", status, body)
+	}
+	if status, _ := call("text/xml", "urn:CancelOrder", "42"); status != 404 {
+		t.Errorf("Expected 404 for an unstubbed action, got %d", status)
+	}
+
+	if err := server.VerifySOAP("urn:GetOrder", map[string]string{"//GetOrder/id": "42"}, Exactly(2)); err != nil {
+		t.Errorf("Expected two GetOrder requests for id 42: %v", err)
+	}
+	if err := server.VerifySOAP("urn:GetOrder", nil, Exactly(3)); err != nil {
+		t.Errorf("Expected three GetOrder requests: %v", err)
+	}
+}
+
+func TestMockServerTypedBodies(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	type invoice struct {
+		XMLName xml.Name `xml:"invoice" json:"-"`
+		ID      int      `xml:"id,attr" json:"id"`
+		Total   string   `xml:"total" json:"total"`
+	}
+	err := server.StubAll([]ResponseStub{
+		StubJSON("GET", "/invoice.json", invoice{ID: 7, Total: "9.50"}).Build(),
+		StubXML("GET", "/invoice.xml", invoice{ID: 7, Total: "9.50"}).Build(),
+		StubText("GET", "/invoice.txt", "Invoice 7: 9.50").Build(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+	}{
+		{"/invoice.json", "application/json", `{"id":7,"total":"9.50"}`},
+		{"/invoice.xml", "application/xml", xml.Header + `<invoice id="7"><total>9.50</total></invoice>`},
+		{"/invoice.txt", "text/plain; charset=utf-8", "Invoice 7: 9.50"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL() + tt.path)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%s: expected Content-Type %q, got %q", tt.path, tt.contentType, got)
+		}
+		if string(body) != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.path, tt.body, body)
+		}
+	}
+}
+
+func TestMockServerHAR(t *testing.T) {
+	source := startCLIServer(t, MockServerConfig{})
+	err := source.StubAll([]ResponseStub{
+		NewStubBuilder("GET", "/orders/{id}").Body(map[string]interface{}{"status": "paid"}).Build(),
+		NewStubBuilder("POST", "/orders").Status(201).Body(map[string]interface{}{"id": 9}).Build(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+	if status := sendRequest(t, source, "GET", "/orders/1?expand=items", nil, ""); status != 200 {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if status := sendRequest(t, source, "POST", "/orders", map[string]string{"Content-Type": "application/json"}, `{"sku":"A1"}`); status != 201 {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+
+	var buf bytes.Buffer
+	if err := source.ExportHAR(RequestFilter{}, &buf); err != nil {
+		t.Fatalf("Failed to export HAR: %v", err)
+	}
+	var har harLog
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("Failed to decode HAR: %v", err)
+	}
+	if len(har.Log.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(har.Log.Entries))
+	}
+	get, post := har.Log.Entries[0], har.Log.Entries[1]
+	if !strings.HasSuffix(get.Request.URL, "/orders/1?expand=items") || !strings.Contains(get.Response.Content.Text, "paid") {
+		t.Errorf("Expected the order lookup with the stub's body, got %+v", get)
+	}
+	if post.Response.Status != 201 || post.Request.PostData == nil || post.Request.PostData.Text != `{"sku":"A1"}` {
+		t.Errorf("Expected the order creation with its body, got %+v", post)
+	}
+
+	target := startCLIServer(t, MockServerConfig{})
+	if err := target.ImportHAR(&buf, HARImportOptions{}); err != nil {
+		t.Fatalf("Failed to import HAR: %v", err)
+	}
+	if status, body := getBody(t, target, "/orders/1?expand=items", nil); status != 200 || !strings.Contains(body, "paid") {
+		t.Errorf("Expected the imported order lookup, got %d %s", status, body)
+	}
+	if status := sendRequest(t, target, "POST", "/orders", nil, `{"sku":"A1"}`); status != 201 {
+		t.Errorf("Expected the imported order creation, got %d", status)
+	}
+}
+
+func TestMockServerExportOpenAPI(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	err := server.StubAll([]ResponseStub{
+		NewStubBuilder("GET", "/users/{id}").Body(map[string]interface{}{"name": "Alice"}).Build(),
+		NewStubBuilder("GET", "/users").WhenQuery("role", "admin").Body([]interface{}{}).Build(),
+		NewStubBuilder("POST", "/users").Status(201).WhenBodyJSON(map[string]interface{}{"name": "Bob"}).Build(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := server.ExportOpenAPI(&buf); err != nil {
+		t.Fatalf("Failed to export OpenAPI: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters  []map[string]interface{} `json:"parameters"`
+			RequestBody map[string]interface{}   `json:"requestBody"`
+			Responses   map[string]interface{}   `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if op, ok := doc.Paths["/users/{id}"]["get"]; !ok || op.Responses["200"] == nil || len(op.Parameters) != 1 || op.Parameters[0]["name"] != "id" {
+		t.Errorf("Expected GET /users/{id} with its path parameter, got %+v", doc.Paths["/users/{id}"])
+	}
+	if op := doc.Paths["/users"]["get"]; len(op.Parameters) != 1 || op.Parameters[0]["name"] != "role" || op.Parameters[0]["in"] != "query" {
+		t.Errorf("Expected the role query parameter, got %+v", op.Parameters)
+	}
+	if op := doc.Paths["/users"]["post"]; op.RequestBody == nil || op.Responses["201"] == nil {
+		t.Errorf("Expected POST /users with a request body and a 201, got %+v", op)
+	}
+}
+
+func TestMockServerPact(t *testing.T) {
+	consumer := startCLIServer(t, MockServerConfig{})
+	err := consumer.StubAll([]ResponseStub{
+		NewStubBuilder("GET", "/orders/{id}").Body(map[string]interface{}{"id": 1, "status": "paid"}).Build(),
+		NewStubBuilder("POST", "/orders").Status(201).WhenHeader("X-Tenant", "acme").Body(map[string]interface{}{"id": 2}).Build(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+	if status := sendRequest(t, consumer, "GET", "/orders/1", nil, ""); status != 200 {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if status := sendRequest(t, consumer, "POST", "/orders", map[string]string{"X-Tenant": "acme"}, ""); status != 201 {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	// Unmatched requests are not part of the contract
+	sendRequest(t, consumer, "DELETE", "/orders/1", nil, "")
+
+	pactPath := filepath.Join(t.TempDir(), "web-orders.json")
+	f, err := os.Create(pactPath)
+	if err != nil {
+		t.Fatalf("Failed to create pact file: %v", err)
+	}
+	if err := consumer.ExportPact("web", "orders", f); err != nil {
+		t.Fatalf("Failed to export pact: %v", err)
+	}
+	f.Close()
+
+	var pact pactFile
+	data, _ := os.ReadFile(pactPath)
+	if err := json.Unmarshal(data, &pact); err != nil {
+		t.Fatalf("Failed to decode pact: %v", err)
+	}
+	if len(pact.Interactions) != 2 || pact.Interactions[0].Request.Path != "/orders/1" || pact.Interactions[1].Request.Headers["x-tenant"] != "acme" {
+		t.Fatalf("Expected the two served requests as interactions, got %+v", pact.Interactions)
+	}
+
+	t.Run("stubs from the contract", func(t *testing.T) {
+		server := startCLIServer(t, MockServerConfig{})
+		result, err := VerifyProviderAgainstPact(pactPath, PactTarget{Server: server})
+		if err != nil || result.Interactions != 2 {
+			t.Fatalf("Expected 2 interactions stubbed, got %+v, %v", result, err)
+		}
+		if status, body := getBody(t, server, "/orders/1", nil); status != 200 || !strings.Contains(body, "paid") {
+			t.Errorf("Expected the contract's order, got %d %s", status, body)
+		}
+	})
+
+	t.Run("provider honors the contract", func(t *testing.T) {
+		result, err := VerifyProviderAgainstPact(pactPath, PactTarget{ProviderURL: consumer.URL()})
+		if err != nil || !result.Passed() {
+			t.Errorf("Expected the contract honored, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("provider breaks the contract", func(t *testing.T) {
+		provider := startCLIServer(t, MockServerConfig{})
+		err := provider.StubAll([]ResponseStub{
+			NewStubBuilder("GET", "/orders/{id}").Body(map[string]interface{}{"id": 1, "status": "open"}).Build(),
+			NewStubBuilder("POST", "/orders").Status(201).Body(map[string]interface{}{"id": 2}).Build(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to register stubs: %v", err)
+		}
+		result, err := VerifyProviderAgainstPact(pactPath, PactTarget{ProviderURL: provider.URL()})
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		if len(result.Mismatches) != 1 || !strings.Contains(strings.Join(result.Mismatches[0].Differences, "\n"), "status") {
+			t.Errorf("Expected the order status mismatch, got %+v", result.Mismatches)
+		}
+	})
+}
+
+func TestMockServerExportCollection(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	err := server.StubAll([]ResponseStub{
+		NewStubBuilder("GET", "/orders/{id}").WhenQuery("expand", "items").Body(map[string]interface{}{"id": 1}).Build(),
+		NewStubBuilder("POST", "/orders").Status(201).WhenHeader("X-Tenant", "acme").WhenBodyJSON(map[string]interface{}{"sku": "A1"}).Build(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+
+	t.Run("insomnia", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := server.ExportCollection(CollectionFormatInsomnia, &buf); err != nil {
+			t.Fatalf("Failed to export collection: %v", err)
+		}
+		var export struct {
+			Resources []map[string]interface{} `json:"resources"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+			t.Fatalf("Failed to decode collection: %v", err)
+		}
+		requests := make(map[string]map[string]interface{})
+		for _, resource := range export.Resources {
+			if resource["_type"] == "request" {
+				requests[resource["name"].(string)] = resource
+			}
+		}
+		get, post := requests["GET /orders/{id}"], requests["POST /orders"]
+		if get == nil || get["url"] != "{{ _.base_url }}/orders/:id" || !strings.Contains(fmt.Sprint(get["parameters"]), "expand") {
+			t.Errorf("Expected the order lookup with its query, got %+v", get)
+		}
+		if post == nil || !strings.Contains(fmt.Sprint(post["headers"]), "acme") || !strings.Contains(fmt.Sprint(post["body"]), "A1") {
+			t.Errorf("Expected the order creation with its header and body, got %+v", post)
+		}
+	})
+
+	t.Run("bruno", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := server.ExportCollection(CollectionFormatBruno, &buf); err != nil {
+			t.Fatalf("Failed to export collection: %v", err)
+		}
+		var export struct {
+			Items []struct {
+				Type  string `json:"type"`
+				Name  string `json:"name"`
+				Items []struct {
+					Request struct {
+						URL    string `json:"url"`
+						Method string `json:"method"`
+					} `json:"request"`
+				} `json:"items"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+			t.Fatalf("Failed to decode collection: %v", err)
+		}
+		if len(export.Items) != 1 || export.Items[0].Type != "folder" || export.Items[0].Name != "orders" || len(export.Items[0].Items) != 2 {
+			t.Fatalf("Expected both requests in an orders folder, got %+v", export.Items)
+		}
+		for _, item := range export.Items[0].Items {
+			if item.Request.Method == "GET" && item.Request.URL != "{{baseUrl}}/orders/:id?expand=items" {
+				t.Errorf("Expected the lookup URL with its query, got %s", item.Request.URL)
+			}
+		}
+	})
+}
+
+func TestMockServerSpecCoverage(t *testing.T) {
+	spec, err := filepath.Abs("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("Failed to resolve spec: %v", err)
+	}
+	server := startCLIServer(t, MockServerConfig{OpenAPISpec: spec})
+	for _, path := range []string{"/pets/1", "/pets/2", "/health"} {
+		if status, _ := getBody(t, server, path, nil); status != 200 {
+			t.Fatalf("Expected the spec to answer %s, got %d", path, status)
+		}
+	}
+
+	// The journal keeps the requested path, not the spec's template
+	requests, err := server.GetRequests(RequestFilter{Path: "/pets/2"})
+	if err != nil || len(requests) != 1 {
+		t.Errorf("Expected one request for /pets/2, got %+v, %v", requests, err)
+	}
+
+	report, err := server.SpecCoverage()
+	if err != nil {
+		t.Fatalf("Failed to compute coverage: %v", err)
+	}
+	if report.Covered != 2 || report.Total != 3 {
+		t.Errorf("Expected 2 of 3 operations covered, got %s", report)
+	}
+	hits := make(map[string]int)
+	for _, op := range report.Operations {
+		hits[op.Method+" "+op.Path] = op.Hits
+	}
+	if hits["GET /pets/{id}"] != 2 || hits["GET /health"] != 1 || hits["POST /pets"] != 0 {
+		t.Errorf("Expected hits per operation, got %v", hits)
+	}
+}
+
+func TestMockServerTrafficReport(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{StrictStubbing: true})
+	if err := server.StubResponse("GET", "/orders", []interface{}{}); err != nil {
+		t.Fatalf("Failed to stub response: %v", err)
+	}
+	sendRequest(t, server, "GET", "/orders", nil, "")
+	sendRequest(t, server, "GET", "/missing", nil, "")
+	if _, err := server.Verify(VerificationRequest{Method: "GET", Path: "/orders"}, Exactly(1)); err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if _, err := server.Verify(VerificationRequest{Method: "POST", Path: "/orders"}, Exactly(1)); err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+
+	t.Run("junit", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := server.WriteTrafficReport(&buf, ReportFormatJUnit); err != nil {
+			t.Fatalf("Failed to write report: %v", err)
+		}
+		var suites junitTestSuites
+		if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		if len(suites.Suites) != 1 {
+			t.Fatalf("Expected one suite, got %d", len(suites.Suites))
+		}
+		suite := suites.Suites[0]
+		// Two verifications and the strict stubbing check, of which the
+		// POST verification and the unmatched request fail
+		if suite.Tests != 3 || suite.Failures != 2 {
+			t.Errorf("Expected 3 tests with 2 failures, got %d with %d", suite.Tests, suite.Failures)
+		}
+		if !strings.Contains(suite.SystemOut, "GET /missing -> 404 (unmatched)") {
+			t.Errorf("Expected the unmatched request in the output, got %s", suite.SystemOut)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := server.WriteTrafficReport(&buf, ReportFormatHTML); err != nil {
+			t.Fatalf("Failed to write report: %v", err)
+		}
+		if html := buf.String(); !strings.Contains(html, "/orders") || !strings.Contains(html, "/missing") {
+			t.Errorf("Expected the traffic in the page, got %s", html)
+		}
+	})
+}
+
+func TestMockServerFuzzCorpus(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	if err := server.AddStub(NewStubBuilder("POST", "/orders").Status(201).Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	for _, body := range []string{`{"sku": "A1", "qty": 2}`, `{"sku":"B2","qty":10}`, `{"sku":"C3"}`} {
+		sendRequest(t, server, "POST", "/orders", map[string]string{"Content-Type": "application/json"}, body)
+	}
+	sendRequest(t, server, "POST", "/other", nil, `{"ignored":true}`)
+
+	dir := t.TempDir()
+	n, err := server.WriteFuzzCorpus(VerificationRequest{Method: "POST", Path: "/orders"}, dir, CorpusOptions{Minimize: true, Deduplicate: true})
+	if err != nil {
+		t.Fatalf("Failed to write corpus: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Expected one seed per body shape, got %d", n)
+	}
+	entries, _ := os.ReadDir(dir)
+	var seeds []string
+	for _, entry := range entries {
+		data, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+		seeds = append(seeds, string(data))
+	}
+	corpus := strings.Join(seeds, "")
+	if !strings.Contains(corpus, `[]byte("{\"sku\":\"A1\",\"qty\":2}")`) || !strings.Contains(corpus, `[]byte("{\"sku\":\"C3\"}")`) {
+		t.Errorf("Expected the smallest body of each shape, minimized, got %s", corpus)
+	}
+}
+
+func TestMockServerCheckCompatibility(t *testing.T) {
+	server := startCLIServer(t, MockServerConfig{})
+	report, err := server.CheckCompatibility()
+	if err != nil {
+		t.Fatalf("Failed to check compatibility: %v", err)
+	}
+	if !report.Compatible() {
+		t.Errorf("Expected the installed CLI compatible, got %s", report)
+	}
+	if report.CLIVersion == "" || report.ServerVersion == "" || len(report.Features) == 0 {
+		t.Errorf("Expected the CLI and server versions and features, got %+v", report)
+	}
+}
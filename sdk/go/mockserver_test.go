@@ -1,6 +1,10 @@
 package mockforge
 
 import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 )
 
@@ -44,3 +48,22 @@ func TestMockServerIsRunning(t *testing.T) {
 		t.Error("Expected server to not be running before start")
 	}
 }
+
+// newAdminTestServer returns a MockServer whose admin and main APIs are both
+// served by handler
+func newAdminTestServer(t *testing.T, handler http.Handler) *MockServer {
+	t.Helper()
+
+	admin := httptest.NewServer(handler)
+	t.Cleanup(admin.Close)
+
+	host, port, err := net.SplitHostPort(admin.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to parse admin address: %v", err)
+	}
+
+	server := NewMockServer(MockServerConfig{Host: host})
+	server.adminPort, _ = strconv.Atoi(port)
+	server.port = server.adminPort
+	return server
+}
@@ -0,0 +1,146 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// mqttAdminPublisher is the client ID the broker records for messages
+// published through the admin API
+const mqttAdminPublisher = "mockforge-management-api"
+
+// MQTTMessage is a message published to the MQTT broker
+type MQTTMessage struct {
+	// PublisherID is the client ID of the publisher
+	PublisherID string
+	Topic       string
+	Payload     []byte
+	QoS         int
+	Retain      bool
+	Timestamp   time.Time
+}
+
+// MQTTAddr returns the host:port of the MQTT broker, which is enabled in
+// the server's config file, or an empty string if the server has not
+// reported one
+func (m *MockServer) MQTTAddr() string {
+	m.portMutex.RLock()
+	defer m.portMutex.RUnlock()
+	if m.mqttPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", m.host, m.mqttPort)
+}
+
+// PublishRetained publishes payload to topic as a retained message, so
+// clients that subscribe later receive it immediately, as they would a
+// device's last reported state
+func (m *MockServer) PublishRetained(topic string, payload []byte) error {
+	if topic == "" || strings.ContainsAny(topic, "+#") {
+		return NewInvalidConfigError("MQTT topic must be non-empty and free of wildcards", map[string]interface{}{"topic": topic})
+	}
+	body := map[string]interface{}{"topic": topic, "payload": string(payload), "qos": 1, "retain": true}
+	return m.adminJSON("publish mqtt message", http.MethodPost, "/__mockforge/api/mqtt/publish", body, nil)
+}
+
+// MQTTMessages returns the messages clients published to the broker, oldest
+// first. The broker keeps the most recent 1000; messages published through
+// the SDK are left out.
+func (m *MockServer) MQTTMessages() ([]MQTTMessage, error) {
+	var result struct {
+		Messages []struct {
+			PublisherID string `json:"publisher_id"`
+			Topic       string `json:"topic"`
+			Payload     string `json:"payload"`
+			QoS         int    `json:"qos"`
+			Retain      bool   `json:"retain"`
+			Timestamp   int64  `json:"timestamp"`
+		} `json:"messages"`
+	}
+	if err := m.adminJSON("list mqtt messages", http.MethodGet, "/__mockforge/api/mqtt/messages", nil, &result); err != nil {
+		return nil, err
+	}
+
+	messages := []MQTTMessage{}
+	for _, message := range result.Messages {
+		if message.PublisherID == mqttAdminPublisher {
+			continue
+		}
+		payload, err := base64.StdEncoding.DecodeString(message.Payload)
+		if err != nil {
+			return nil, NewAdminAPIError("list mqtt messages", "invalid message payload", err)
+		}
+		messages = append(messages, MQTTMessage{
+			PublisherID: message.PublisherID,
+			Topic:       message.Topic,
+			Payload:     payload,
+			QoS:         message.QoS,
+			Retain:      message.Retain,
+			Timestamp:   time.UnixMilli(message.Timestamp),
+		})
+	}
+	return messages, nil
+}
+
+// VerifySubscribed asserts a connected client subscribed to topicFilter,
+// compared literally, e.g. "devices/+/commands"
+func (m *MockServer) VerifySubscribed(topicFilter string) error {
+	var result struct {
+		Subscriptions []struct {
+			ClientID    string `json:"client_id"`
+			TopicFilter string `json:"topic_filter"`
+		} `json:"subscriptions"`
+	}
+	if err := m.adminJSON("list mqtt subscriptions", http.MethodGet, "/__mockforge/api/mqtt/subscriptions", nil, &result); err != nil {
+		return err
+	}
+
+	var filters []string
+	for _, subscription := range result.Subscriptions {
+		if subscription.TopicFilter == topicFilter {
+			return nil
+		}
+		filters = append(filters, subscription.TopicFilter)
+	}
+	return fmt.Errorf("expected a subscription to %q, got [%s]", topicFilter, strings.Join(filters, ", "))
+}
+
+// VerifyPublished asserts how many messages clients published to topic with
+// a payload containing payloadContains. topic may be a filter with + and #
+// wildcards; an empty payloadContains matches every payload.
+func (m *MockServer) VerifyPublished(topic, payloadContains string, count VerificationCount) error {
+	messages, err := m.MQTTMessages()
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, message := range messages {
+		if mqttTopicMatches(topic, message.Topic) && bytes.Contains(message.Payload, []byte(payloadContains)) {
+			n++
+		}
+	}
+	if !count.Satisfied(n) {
+		return fmt.Errorf("expected %s MQTT message(s) on %q containing %q, got %d", count, topic, payloadContains, n)
+	}
+	return nil
+}
+
+// mqttTopicMatches reports whether topic matches filter, where + matches
+// one level and a trailing # matches any number of levels
+func mqttTopicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return i == len(filterLevels)-1
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
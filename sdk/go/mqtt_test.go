@@ -0,0 +1,71 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestMQTTControl(t *testing.T) {
+	var published map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/__mockforge/api/mqtt/publish":
+			json.NewDecoder(r.Body).Decode(&published)
+			w.Write([]byte(`{"success":true}`))
+		case "/__mockforge/api/mqtt/subscriptions":
+			w.Write([]byte(`{"subscriptions":[{"client_id":"thermostat","topic_filter":"devices/+/commands","qos":1}]}`))
+		case "/__mockforge/api/mqtt/messages":
+			w.Write([]byte(`{"messages":[
+				{"publisher_id":"mockforge-management-api","topic":"devices/t1/state","payload":"b24=","qos":1,"retain":true,"timestamp":1700000000000},
+				{"publisher_id":"thermostat","topic":"devices/t1/telemetry","payload":"eyJ0ZW1wIjoyMX0=","qos":0,"retain":false,"timestamp":1700000000001}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	if err := server.PublishRetained("devices/t1/state", []byte("on")); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if published["topic"] != "devices/t1/state" || published["payload"] != "on" || published["retain"] != true {
+		t.Errorf("Unexpected publish request: %v", published)
+	}
+	if err := server.PublishRetained("devices/#", nil); err == nil {
+		t.Error("Expected error for wildcard topic")
+	}
+
+	if err := server.VerifySubscribed("devices/+/commands"); err != nil {
+		t.Errorf("Expected subscription, got %v", err)
+	}
+	if err := server.VerifySubscribed("devices/#"); err == nil {
+		t.Error("Expected error for missing subscription")
+	}
+
+	// The SDK's own retained message is not counted
+	if err := server.VerifyPublished("devices/+/state", "", Never()); err != nil {
+		t.Errorf("Expected admin publishes to be ignored, got %v", err)
+	}
+	if err := server.VerifyPublished("devices/#", `"temp":21`, Exactly(1)); err != nil {
+		t.Errorf("Expected telemetry message, got %v", err)
+	}
+}
+
+func TestMQTTTopicMatches(t *testing.T) {
+	tests := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/#", "a", true},
+		{"a/#", "a/b/c", true},
+		{"#", "a/b", true},
+		{"a/b", "a/c", false},
+	}
+	for _, tt := range tests {
+		if got := mqttTopicMatches(tt.filter, tt.topic); got != tt.want {
+			t.Errorf("Expected %v for %q against %q, got %v", tt.want, tt.topic, tt.filter, got)
+		}
+	}
+}
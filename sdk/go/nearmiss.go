@@ -0,0 +1,307 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nearMissLimit is how many near misses a failed verification reports
+const nearMissLimit = 3
+
+// NearMiss is a logged request that almost matched a verification pattern
+type NearMiss struct {
+	Request LoggedRequest `json:"request"`
+	// Differences describe each part of the pattern the request failed,
+	// e.g. `path differs at segment 2: expected "orders", got "order"`
+	Differences []string `json:"differences"`
+}
+
+// addNearMisses fills in the closest non-matching requests of a result
+// that has too few matches
+func (m *MockServer) addNearMisses(result *VerificationResult, pattern VerificationRequest, expected VerificationCount) error {
+	if result.Matched || !expected.tooFew(result.Count) {
+		return nil
+	}
+
+	all, err := m.verify(VerificationRequest{}, AtLeast(0))
+	if err != nil {
+		return err
+	}
+	requests, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return err
+	}
+
+	result.NearMisses = nearMisses(pattern, requests, nearMissLimit)
+	return nil
+}
+
+// nearMisses returns up to limit requests that fail pattern, those with the
+// fewest differences first and the most recent among equals
+func nearMisses(pattern VerificationRequest, requests []LoggedRequest, limit int) []NearMiss {
+	var misses []NearMiss
+	for _, request := range requests {
+		if differences := verificationDifferences(pattern, request); len(differences) > 0 {
+			misses = append(misses, NearMiss{Request: request, Differences: differences})
+		}
+	}
+	sort.SliceStable(misses, func(i, j int) bool {
+		if len(misses[i].Differences) != len(misses[j].Differences) {
+			return len(misses[i].Differences) < len(misses[j].Differences)
+		}
+		return misses[i].Request.Timestamp.After(misses[j].Request.Timestamp)
+	})
+	if len(misses) > limit {
+		misses = misses[:limit]
+	}
+	return misses
+}
+
+// verificationDifferences describes how request fails pattern, following
+// the server's matching rules; it is empty if the request matches
+func verificationDifferences(pattern VerificationRequest, request LoggedRequest) []string {
+	var differences []string
+
+	if pattern.Method != "" && !strings.EqualFold(pattern.Method, request.Method) {
+		differences = append(differences, fmt.Sprintf("method: expected %s, got %s", strings.ToUpper(pattern.Method), request.Method))
+	}
+	if pattern.Path != "" && !verificationPathMatches(pattern.Path, request.Path) {
+		differences = append(differences, describePathDifference(pattern.Path, request.Path))
+	}
+
+	for _, name := range sortedStringKeys(pattern.QueryParams) {
+		expected := pattern.QueryParams[name]
+		if actual, ok := request.QueryParams[name]; !ok {
+			differences = append(differences, fmt.Sprintf("missing query parameter %s", name))
+		} else if actual != expected {
+			differences = append(differences, fmt.Sprintf("query parameter %s: expected %q, got %q", name, expected, actual))
+		}
+	}
+	for _, name := range sortedStringKeys(pattern.Headers) {
+		expected := pattern.Headers[name]
+		actual, ok := lookupHeader(request.Headers, name)
+		if !ok {
+			differences = append(differences, fmt.Sprintf("missing header %s", name))
+		} else if actual != expected {
+			differences = append(differences, fmt.Sprintf("header %s: expected %q, got %q", name, expected, actual))
+		}
+	}
+	for _, name := range sortedStringKeys(pattern.HeaderPatterns) {
+		expected := pattern.HeaderPatterns[name]
+		actual, ok := lookupHeader(request.Headers, name)
+		re, err := regexp.Compile(expected)
+		if !ok {
+			differences = append(differences, fmt.Sprintf("missing header %s", name))
+		} else if err != nil || !re.MatchString(actual) {
+			differences = append(differences, fmt.Sprintf("header %s: %q does not match %q", name, actual, expected))
+		}
+	}
+	for _, name := range pattern.HeadersAbsent {
+		if request.sentHeader(name) {
+			differences = append(differences, fmt.Sprintf("unexpected header %s", name))
+		}
+	}
+	for _, name := range pattern.HeadersPresent {
+		if !request.sentHeader(name) {
+			differences = append(differences, fmt.Sprintf("missing header %s", name))
+		}
+	}
+
+	if pattern.Since != nil && request.Timestamp.Before(*pattern.Since) {
+		differences = append(differences, fmt.Sprintf("logged at %s, before %s", request.Timestamp.Format(time.RFC3339Nano), pattern.Since.Format(time.RFC3339Nano)))
+	}
+	if pattern.Until != nil && !request.Timestamp.Before(*pattern.Until) {
+		differences = append(differences, fmt.Sprintf("logged at %s, not before %s", request.Timestamp.Format(time.RFC3339Nano), pattern.Until.Format(time.RFC3339Nano)))
+	}
+	if pattern.SinceMark != "" {
+		// Marks are server timestamps
+		if marked, err := time.Parse(time.RFC3339Nano, string(pattern.SinceMark)); err == nil && request.Timestamp.Before(marked) {
+			differences = append(differences, "logged before the journal mark")
+		}
+	}
+
+	// The server skips the body check for requests whose body it did not
+	// record
+	if pattern.BodyPattern != "" && request.Body != "" && !verificationBodyMatches(pattern.BodyPattern, request.Body) {
+		differences = append(differences, fmt.Sprintf("body does not match %q", pattern.BodyPattern))
+	}
+	if len(pattern.BodyJSONPath) > 0 {
+		differences = append(differences, jsonPathDifferences(pattern.BodyJSONPath, request.Body)...)
+	}
+	return differences
+}
+
+// jsonPathDifferences describes the JSONPath matchers a JSON body fails.
+// JSON Schema matchers are only evaluated by the server.
+func jsonPathDifferences(matchers map[string]interface{}, body string) []string {
+	var decoded interface{}
+	if body == "" || json.Unmarshal([]byte(body), &decoded) != nil {
+		return []string{"body is not recorded JSON"}
+	}
+
+	paths := make([]string, 0, len(matchers))
+	for path := range matchers {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var differences []string
+	for _, path := range paths {
+		actual, ok := jsonPathValue(decoded, path)
+		if !ok {
+			differences = append(differences, fmt.Sprintf("body has no %s", path))
+			continue
+		}
+		// Compare through JSON so 2 and 2.0 are equal, as on the server
+		want, _ := json.Marshal(matchers[path])
+		got, _ := json.Marshal(actual)
+		var wantValue interface{}
+		json.Unmarshal(want, &wantValue)
+		if !reflect.DeepEqual(wantValue, actual) {
+			differences = append(differences, fmt.Sprintf("body %s: expected %s, got %s", path, want, got))
+		}
+	}
+	return differences
+}
+
+// jsonPathValue looks up a simple JSONPath ($.a.b, $.items[0]) in a decoded
+// JSON value
+func jsonPathValue(value interface{}, path string) (interface{}, bool) {
+	for _, part := range strings.Split(strings.TrimPrefix(path, "$"), ".") {
+		if part == "" {
+			continue
+		}
+		field, indexes, _ := strings.Cut(part, "[")
+		if field != "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if value, ok = object[field]; !ok {
+				return nil, false
+			}
+		}
+		if indexes == "" {
+			continue
+		}
+		for _, index := range strings.Split("["+indexes, "[")[1:] {
+			i, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+			array, ok := value.([]interface{})
+			if err != nil || !ok || i < 0 || i >= len(array) {
+				return nil, false
+			}
+			value = array[i]
+		}
+	}
+	return value, true
+}
+
+// verificationPathMatches matches a path as the server's verification does:
+// exactly, with * (one segment) and ** (any segments) wildcards, or as a
+// regular expression
+func verificationPathMatches(pattern, path string) bool {
+	if pattern == path || pattern == "*" {
+		return true
+	}
+	if strings.Contains(pattern, "*") {
+		return matchWildcardSegments(pathSegments(pattern), pathSegments(path))
+	}
+	re, err := regexp.Compile(pattern)
+	return err == nil && re.MatchString(path)
+}
+
+// matchWildcardSegments matches path segments against pattern segments
+func matchWildcardSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	switch pattern[0] {
+	case "**":
+		for i := 0; i <= len(path); i++ {
+			if matchWildcardSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(path) > 0 && matchWildcardSegments(pattern[1:], path[1:])
+	default:
+		return len(path) > 0 && path[0] == pattern[0] && matchWildcardSegments(pattern[1:], path[1:])
+	}
+}
+
+// describePathDifference locates where a path departs from a literal or
+// single-wildcard pattern
+func describePathDifference(pattern, path string) string {
+	if strings.Contains(pattern, "**") || strings.ContainsAny(pattern, `^$+?()[]{}|\`) {
+		return fmt.Sprintf("path: %q does not match %q", path, pattern)
+	}
+
+	expected, actual := pathSegments(pattern), pathSegments(path)
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if expected[i] != "*" && expected[i] != actual[i] {
+			return fmt.Sprintf("path differs at segment %d: expected %q, got %q", i+1, expected[i], actual[i])
+		}
+	}
+	return fmt.Sprintf("path has %d segment(s), expected %d: got %q", len(actual), len(expected), path)
+}
+
+// pathSegments splits a path into its non-empty segments
+func pathSegments(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// verificationBodyMatches matches a body as a regular expression, falling
+// back to equality for invalid expressions
+func verificationBodyMatches(pattern, body string) bool {
+	if re, err := regexp.Compile(pattern); err == nil {
+		return re.MatchString(body)
+	}
+	return body == pattern
+}
+
+// lookupHeader finds a header by case-insensitive name
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// sentHeader reports whether the request had a header, counting sensitive
+// headers the server logged by name only
+func (r LoggedRequest) sentHeader(name string) bool {
+	if _, ok := lookupHeader(r.Headers, name); ok {
+		return true
+	}
+	for _, redacted := range strings.Split(r.Metadata["redacted_headers"], ",") {
+		if strings.EqualFold(redacted, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedStringKeys returns the keys of m in order, for stable messages
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVerifyNearMisses(t *testing.T) {
+	logged := []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/api/users/42", "headers": map[string]string{}},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "POST", "path": "/api/user/42", "headers": map[string]string{"Content-Type": "text/plain"}},
+		{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "POST", "path": "/api/users/42/avatar", "headers": map[string]string{"content-type": "application/json"}},
+	}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pattern VerificationRequest `json:"pattern"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Pattern.Path == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"matched": true, "count": len(logged), "matches": logged})
+			return
+		}
+		w.WriteHeader(http.StatusExpectationFailed)
+		json.NewEncoder(w).Encode(map[string]interface{}{"matched": false, "count": 0})
+	}))
+
+	pattern := VerificationRequest{Method: "POST", Path: "/api/users/*", Headers: map[string]string{"Content-Type": "application/json"}}
+	result, err := server.Verify(pattern, AtLeastOnce())
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if len(result.NearMisses) != 3 {
+		t.Fatalf("Expected 3 near misses, got %+v", result.NearMisses)
+	}
+
+	closest := result.NearMisses[0]
+	if closest.Request.ID != "3" || len(closest.Differences) != 1 || !strings.Contains(closest.Differences[0], "path has 4 segment(s), expected 3") {
+		t.Errorf("Expected the avatar request to differ only in segment count, got %+v", closest)
+	}
+	// Requests with as many differences are ordered most recent first
+	if differences := strings.Join(result.NearMisses[1].Differences, "; "); !strings.Contains(differences, `path differs at segment 2: expected "users", got "user"`) {
+		t.Errorf("Expected a segment 2 difference, got %s", differences)
+	}
+	if differences := strings.Join(result.NearMisses[2].Differences, "; "); !strings.Contains(differences, "method: expected POST, got GET") || !strings.Contains(differences, "missing header Content-Type") {
+		t.Errorf("Expected method and header differences, got %s", differences)
+	}
+
+	if result, err := server.Verify(pattern, Never()); err != nil || len(result.NearMisses) != 0 {
+		t.Errorf("Expected no near misses when too many requests match, got %+v (%v)", result, err)
+	}
+}
+
+func TestJSONPathDifferences(t *testing.T) {
+	body := `{"items":[{"sku":"A1","qty":2}],"total":20.0}`
+
+	if differences := jsonPathDifferences(map[string]interface{}{"$.items[0].sku": "A1", "$.total": 20}, body); len(differences) != 0 {
+		t.Errorf("Expected the body to match, got %v", differences)
+	}
+
+	differences := jsonPathDifferences(map[string]interface{}{"$.items[0].qty": 3, "$.items[1].sku": "B2"}, body)
+	if len(differences) != 2 || differences[0] != "body $.items[0].qty: expected 3, got 2" || differences[1] != "body has no $.items[1].sku" {
+		t.Errorf("Expected a value and a missing path difference, got %v", differences)
+	}
+}
+
+func TestVerificationDifferencesHeaderPresence(t *testing.T) {
+	pattern := VerificationRequest{
+		HeadersAbsent:  []string{"authorization"},
+		HeadersPresent: []string{"X-Request-Id"},
+		HeaderPatterns: map[string]string{"User-Agent": "^billing/"},
+	}
+	// The server logs sensitive headers by name only
+	request := LoggedRequest{
+		Headers:  map[string]string{"user-agent": "curl/8.0"},
+		Metadata: map[string]string{"redacted_headers": "authorization,cookie"},
+	}
+
+	differences := strings.Join(verificationDifferences(pattern, request), "; ")
+	for _, want := range []string{`header User-Agent: "curl/8.0" does not match "^billing/"`, "unexpected header authorization", "missing header X-Request-Id"} {
+		if !strings.Contains(differences, want) {
+			t.Errorf("Expected %q in differences, got %s", want, differences)
+		}
+	}
+}
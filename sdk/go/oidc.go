@@ -0,0 +1,258 @@
+package mockforge
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OIDCConfig configures the OAuth2/OpenID Connect provider preset
+type OIDCConfig struct {
+	// Audience is the "aud" claim of issued tokens, by default "mockforge"
+	Audience string
+	// Claims are added to every issued token, e.g. roles or an email
+	Claims map[string]interface{}
+	// Clients maps client IDs to secrets the token endpoint accepts. If
+	// empty, any client is accepted.
+	Clients map[string]string
+	// SigningKey signs tokens with RS256. A 2048-bit key is generated if
+	// nil.
+	SigningKey *rsa.PrivateKey
+	// KeyID is the "kid" of the signing key, by default "mockforge"
+	KeyID string
+	// TokenTTL is how long issued tokens are valid (default 1h)
+	TokenTTL time.Duration
+}
+
+// OIDCProvider is an OAuth2/OpenID Connect provider serving discovery,
+// JWKS, token, and userinfo endpoints:
+//
+//	GET  /.well-known/openid-configuration
+//	GET  /jwks
+//	POST /token     client_credentials and password grants
+//	GET  /userinfo  claims of the bearer token
+//
+// It is served by an in-process listener; configure the application with
+// Issuer() as its issuer URL.
+type OIDCProvider struct {
+	config  OIDCConfig
+	sidecar *httpSidecar
+}
+
+// EnableOIDCProvider starts the OIDC provider preset, or returns the running
+// one. The config is only applied when the provider is first started.
+func (m *MockServer) EnableOIDCProvider(config OIDCConfig) (*OIDCProvider, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.oidc != nil {
+		return m.oidc, nil
+	}
+
+	if config.Audience == "" {
+		config.Audience = "mockforge"
+	}
+	if config.KeyID == "" {
+		config.KeyID = "mockforge"
+	}
+	if config.TokenTTL == 0 {
+		config.TokenTTL = time.Hour
+	}
+	if config.SigningKey == nil {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate OIDC signing key: %w", err)
+		}
+		config.SigningKey = key
+	}
+
+	provider := &OIDCProvider{config: config}
+
+	sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(provider.serveHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start OIDC provider: %w", err)
+	}
+	provider.sidecar = sidecar
+
+	m.attached = append(m.attached, sidecar)
+	m.oidc = provider
+
+	return provider, nil
+}
+
+// Issuer returns the issuer URL, which is also the base of the endpoints
+func (p *OIDCProvider) Issuer() string {
+	return p.sidecar.URL()
+}
+
+// MintToken returns a signed access token carrying the configured claims
+// and claims, which override them. Standard claims (iss, aud, iat, exp) are
+// filled in unless set.
+func (p *OIDCProvider) MintToken(claims map[string]interface{}) (string, error) {
+	now := time.Now()
+	payload := map[string]interface{}{
+		"iss": p.Issuer(),
+		"aud": p.config.Audience,
+		"iat": now.Unix(),
+		"exp": now.Add(p.config.TokenTTL).Unix(),
+	}
+	for name, value := range p.config.Claims {
+		payload[name] = value
+	}
+	for name, value := range claims {
+		payload[name] = value
+	}
+	return p.sign(payload)
+}
+
+// sign encodes payload as an RS256 JWT
+func (p *OIDCProvider) sign(payload map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": p.config.KeyID})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.config.SigningKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verify checks a token was signed by the provider and has not expired,
+// returning its claims
+func (p *OIDCProvider) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&p.config.SigningKey.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errors.New("invalid token signature")
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() >= int64(exp) {
+		return nil, errors.New("token expired")
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/.well-known/openid-configuration" && r.Method == http.MethodGet:
+		issuer := p.Issuer()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                issuer,
+			"token_endpoint":                        issuer + "/token",
+			"jwks_uri":                              issuer + "/jwks",
+			"userinfo_endpoint":                     issuer + "/userinfo",
+			"grant_types_supported":                 []string{"client_credentials", "password"},
+			"response_types_supported":              []string{"token"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		})
+	case r.URL.Path == "/jwks" && r.Method == http.MethodGet:
+		key := p.config.SigningKey.PublicKey
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": p.config.KeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	case r.URL.Path == "/token" && r.Method == http.MethodPost:
+		p.issueToken(w, r)
+	case r.URL.Path == "/userinfo":
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		claims, err := p.verify(token)
+		if !ok || err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+			return
+		}
+		writeJSON(w, http.StatusOK, claims)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// issueToken serves the token endpoint
+func (p *OIDCProvider) issueToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if expected, known := p.config.Clients[clientID]; len(p.config.Clients) > 0 && (!known || secret != expected) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	claims := map[string]interface{}{"client_id": clientID}
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		claims["sub"] = clientID
+	case "password":
+		claims["sub"] = r.PostForm.Get("username")
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	scope := r.PostForm.Get("scope")
+	if scope != "" {
+		claims["scope"] = scope
+	}
+
+	token, err := p.MintToken(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	response := map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(p.config.TokenTTL / time.Second),
+	}
+	if scope != "" {
+		response["scope"] = scope
+	}
+	// The ID token is the access token, whose claims include sub and aud
+	if r.PostForm.Get("grant_type") == "password" && strings.Contains(" "+scope+" ", " openid ") {
+		response["id_token"] = token
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, response)
+}
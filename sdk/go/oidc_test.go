@@ -0,0 +1,106 @@
+package mockforge
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOIDCProvider(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	provider, err := server.EnableOIDCProvider(OIDCConfig{
+		Claims:  map[string]interface{}{"roles": []string{"reader"}},
+		Clients: map[string]string{"orders-service": "s3cret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start OIDC provider: %v", err)
+	}
+
+	getJSON := func(url, token string, out interface{}) int {
+		request, _ := http.NewRequest(http.MethodGet, url, nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", url, err)
+		}
+		defer response.Body.Close()
+		json.NewDecoder(response.Body).Decode(out)
+		return response.StatusCode
+	}
+
+	var discovery map[string]interface{}
+	getJSON(provider.Issuer()+"/.well-known/openid-configuration", "", &discovery)
+	if discovery["issuer"] != provider.Issuer() || discovery["token_endpoint"] != provider.Issuer()+"/token" {
+		t.Errorf("Unexpected discovery document: %v", discovery)
+	}
+
+	response, err := http.PostForm(provider.Issuer()+"/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {"orders-service"}, "client_secret": {"wrong"}})
+	if err != nil {
+		t.Fatalf("Failed to request token: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a wrong secret, got %d", response.StatusCode)
+	}
+
+	response, err = http.PostForm(provider.Issuer()+"/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {"orders-service"}, "client_secret": {"s3cret"}})
+	if err != nil {
+		t.Fatalf("Failed to request token: %v", err)
+	}
+	var issued struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+	}
+	json.NewDecoder(response.Body).Decode(&issued)
+	response.Body.Close()
+	if issued.TokenType != "Bearer" || issued.AccessToken == "" {
+		t.Fatalf("Unexpected token response: %+v", issued)
+	}
+
+	var userinfo map[string]interface{}
+	if status := getJSON(provider.Issuer()+"/userinfo", issued.AccessToken, &userinfo); status != http.StatusOK {
+		t.Fatalf("Expected status 200 from userinfo, got %d", status)
+	}
+	if userinfo["sub"] != "orders-service" || userinfo["aud"] != "mockforge" || userinfo["roles"] == nil {
+		t.Errorf("Unexpected userinfo claims: %v", userinfo)
+	}
+	if status := getJSON(provider.Issuer()+"/userinfo", "forged.token.value", &userinfo); status != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a forged token, got %d", status)
+	}
+
+	// Minted tokens verify against the published JWKS
+	token, err := provider.MintToken(map[string]interface{}{"sub": "alice"})
+	if err != nil {
+		t.Fatalf("Failed to mint token: %v", err)
+	}
+	var jwks struct {
+		Keys []struct {
+			N string `json:"n"`
+			E string `json:"e"`
+		} `json:"keys"`
+	}
+	getJSON(provider.Issuer()+"/jwks", "", &jwks)
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("Expected 1 key, got %d", len(jwks.Keys))
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+	e, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].E)
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	parts := strings.Split(token, ".")
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Expected minted token to verify against JWKS, got %v", err)
+	}
+}
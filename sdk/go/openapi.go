@@ -0,0 +1,223 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMethods are the operation keys of an OpenAPI path item
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIDocument is a parsed OpenAPI 3 or Swagger 2 document
+type openAPIDocument struct {
+	root map[string]interface{}
+}
+
+// openAPIOperation is one operation of an OpenAPI document
+type openAPIOperation struct {
+	Method string
+	Path   string
+	Node   map[string]interface{}
+}
+
+// loadOpenAPIDocument reads a YAML or JSON OpenAPI document
+func loadOpenAPIDocument(path string) (*openAPIDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to read spec: %v", err), map[string]interface{}{"spec": path})
+	}
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to parse spec: %v", err), map[string]interface{}{"spec": path})
+	}
+	if _, ok := root["paths"].(map[string]interface{}); !ok {
+		return nil, NewInvalidConfigError("spec has no paths", map[string]interface{}{"spec": path})
+	}
+	return &openAPIDocument{root: root}, nil
+}
+
+// operations returns every operation, ordered by path then method
+func (d *openAPIDocument) operations() []openAPIOperation {
+	paths, _ := d.root["paths"].(map[string]interface{})
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var operations []openAPIOperation
+	for _, path := range names {
+		item, _ := d.resolve(paths[path]).(map[string]interface{})
+		for _, method := range openAPIMethods {
+			if node, ok := d.resolve(item[method]).(map[string]interface{}); ok {
+				operations = append(operations, openAPIOperation{Method: strings.ToUpper(method), Path: path, Node: node})
+			}
+		}
+	}
+	return operations
+}
+
+// resolve follows local $ref pointers such as #/components/examples/User
+func (d *openAPIDocument) resolve(node interface{}) interface{} {
+	for depth := 0; depth < 32; depth++ {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+
+		var target interface{} = d.root
+		for _, token := range strings.Split(ref[2:], "/") {
+			token, _ = url.PathUnescape(token)
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			parent, ok := target.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			target = parent[token]
+		}
+		node = target
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// maxExampleDepth bounds schema recursion, so self-referencing schemas such
+// as trees still produce a finite example
+const maxExampleDepth = 8
+
+// exampleFromSchema generates a value valid against schema, preferring the
+// schema's own example, default, or first enum value
+func (d *openAPIDocument) exampleFromSchema(node interface{}, depth int) interface{} {
+	schema, _ := d.resolve(node).(map[string]interface{})
+	if schema == nil || depth > maxExampleDepth {
+		return nil
+	}
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		merged := make(map[string]interface{})
+		for _, part := range all {
+			if object, ok := d.exampleFromSchema(part, depth+1).(map[string]interface{}); ok {
+				for key, value := range object {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[key].([]interface{}); ok && len(options) > 0 {
+			return d.exampleFromSchema(options[0], depth+1)
+		}
+	}
+
+	schemaType, _ := schema["type"].(string)
+	if types, ok := schema["type"].([]interface{}); ok && len(types) > 0 {
+		// OpenAPI 3.1 type lists such as [string, "null"]
+		schemaType, _ = types[0].(string)
+	}
+	if schemaType == "" {
+		if _, ok := schema["properties"]; ok {
+			schemaType = "object"
+		} else if _, ok := schema["items"]; ok {
+			schemaType = "array"
+		}
+	}
+
+	switch schemaType {
+	case "object":
+		object := make(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(properties) {
+			object[name] = d.exampleFromSchema(properties[name], depth+1)
+		}
+		return object
+	case "array":
+		item := d.exampleFromSchema(schema["items"], depth+1)
+		minItems, _ := schema["minItems"].(int)
+		if minItems < 1 {
+			minItems = 1
+		}
+		items := make([]interface{}, minItems)
+		for i := range items {
+			items[i] = item
+		}
+		return items
+	case "integer":
+		if minimum, ok := schema["minimum"].(int); ok {
+			return minimum
+		}
+		return 0
+	case "number":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 0.0
+	case "boolean":
+		return true
+	case "string":
+		return exampleString(schema)
+	}
+	return nil
+}
+
+// exampleString generates a string matching a string schema's format and
+// length bounds
+func exampleString(schema map[string]interface{}) string {
+	format, _ := schema["format"].(string)
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00Z"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-4000-8000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+
+	value := "string"
+	if minLength, ok := schema["minLength"].(int); ok && len(value) < minLength {
+		value += strings.Repeat("x", minLength-len(value))
+	}
+	if maxLength, ok := schema["maxLength"].(int); ok && len(value) > maxLength {
+		value = value[:maxLength]
+	}
+	return value
+}
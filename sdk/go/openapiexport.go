@@ -0,0 +1,268 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ExportOpenAPI writes an OpenAPI 3.1 document describing the registered
+// stubs to w as JSON: one operation per stubbed method and path, with the
+// stubs' bodies as response examples and schemas inferred from them. Query
+// parameters, headers, and JSON bodies that stubs match on become the
+// operation's parameters and request body. Stubs on PathRegex paths cannot
+// be described and are left out.
+//
+// The result is a starting point for a real spec: inferred schemas only
+// know the fields the examples happen to have.
+func (m *MockServer) ExportOpenAPI(w io.Writer) error {
+	stubs, err := m.registeredStubs()
+	if err != nil {
+		return err
+	}
+
+	paths := make(map[string]interface{})
+	for i := range stubs {
+		stub := &stubs[i]
+		method := strings.ToLower(stub.Method)
+		if isRegexPath(stub.Path) || !isOpenAPIMethod(method) {
+			continue
+		}
+		item, _ := paths[stub.Path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[stub.Path] = item
+		}
+		operation, _ := item[method].(map[string]interface{})
+		if operation == nil {
+			operation = map[string]interface{}{
+				"operationId": openAPIOperationID(method, stub.Path),
+				"responses":   map[string]interface{}{},
+			}
+			if params := pathTemplateParameters(stub.Path); len(params) > 0 {
+				operation["parameters"] = params
+			}
+			item[method] = operation
+		}
+		addStubToOperation(operation, stub)
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.1.0",
+		"info":    map[string]interface{}{"title": "MockForge stubs", "version": "1.0.0"},
+		"paths":   paths,
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// isOpenAPIMethod reports whether method is an OpenAPI operation key
+func isOpenAPIMethod(method string) bool {
+	for _, candidate := range openAPIMethods {
+		if candidate == method {
+			return true
+		}
+	}
+	return false
+}
+
+// openAPIOperationID names an operation after its method and path, e.g.
+// getOrdersById for GET /orders/{id}
+func openAPIOperationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(method)
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// pathTemplateParameters declares the {name} segments of a stub path
+func pathTemplateParameters(path string) []interface{} {
+	var params []interface{}
+	for _, segment := range strings.Split(path, "/") {
+		if matches := pathParamPattern.FindStringSubmatch(segment); matches != nil {
+			params = append(params, map[string]interface{}{
+				"name":     matches[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return params
+}
+
+// addStubToOperation adds what a stub matches on and answers with to an
+// operation; the first stub to describe a status or parameter wins
+func addStubToOperation(operation map[string]interface{}, stub *ResponseStub) {
+	if stub.Match != nil {
+		for _, name := range sortedStringKeys(stub.Match.QueryParams) {
+			addOpenAPIParameter(operation, name, "query", stub.Match.QueryParams[name])
+		}
+		// Header values are regular expressions, so they make no example
+		for _, name := range sortedStringKeys(stub.Match.Headers) {
+			addOpenAPIParameter(operation, name, "header", "")
+		}
+		for _, name := range stub.Match.HeadersPresent {
+			addOpenAPIParameter(operation, name, "header", "")
+		}
+		if stub.Match.BodyJSON != nil {
+			if _, ok := operation["requestBody"]; !ok {
+				body := normalizeJSON(stub.Match.BodyJSON)
+				operation["requestBody"] = map[string]interface{}{
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": inferSchema(body), "example": body},
+					},
+				}
+			}
+		}
+	}
+
+	responses := operation["responses"].(map[string]interface{})
+	if len(stub.Sequence) > 0 {
+		for _, response := range stub.Sequence {
+			addOpenAPIResponse(responses, response.Status, response.Headers, response.Body, nil)
+		}
+		return
+	}
+	addOpenAPIResponse(responses, stub.Status, stub.responseHeaders(), stub.Body, stub)
+}
+
+// addOpenAPIParameter declares a query or header parameter once
+func addOpenAPIParameter(operation map[string]interface{}, name, in, example string) {
+	params, _ := operation["parameters"].([]interface{})
+	for _, param := range params {
+		if p := param.(map[string]interface{}); p["in"] == in && strings.EqualFold(p["name"].(string), name) {
+			return
+		}
+	}
+	param := map[string]interface{}{
+		"name":   name,
+		"in":     in,
+		"schema": map[string]interface{}{"type": "string"},
+	}
+	if example != "" {
+		param["example"] = example
+	}
+	operation["parameters"] = append(params, param)
+}
+
+// addOpenAPIResponse describes a stub response under its status. stub is
+// set when the response may have a binary or file body.
+func addOpenAPIResponse(responses map[string]interface{}, status int, headers map[string]string, body interface{}, stub *ResponseStub) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	key := strconv.Itoa(status)
+	if _, ok := responses[key]; ok {
+		return
+	}
+	description := http.StatusText(status)
+	if description == "" {
+		description = "Status " + key
+	}
+	response := map[string]interface{}{"description": description}
+
+	contentType, _ := lookupHeader(headers, "Content-Type")
+	var media map[string]interface{}
+	switch {
+	case stub != nil && (len(stub.BodyBytes) > 0 || stub.BodyFile != "" || len(stub.Chunks) > 0):
+		media = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+	case body != nil:
+		value := normalizeJSON(body)
+		if _, ok := value.(string); !ok && contentType == "" {
+			contentType = "application/json"
+		}
+		media = map[string]interface{}{"schema": inferSchema(value), "example": value}
+	}
+	if media != nil {
+		if contentType == "" {
+			contentType = "text/plain"
+		}
+		// Parameters such as charset do not belong in a media type key
+		contentType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+		response["content"] = map[string]interface{}{contentType: media}
+	}
+
+	described := make(map[string]interface{})
+	for _, name := range sortedStringKeys(headers) {
+		if strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		described[name] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}, "example": headers[name]}
+	}
+	if len(described) > 0 {
+		response["headers"] = described
+	}
+	responses[key] = response
+}
+
+// normalizeJSON converts a Go value to its generic JSON form, so structs and
+// typed maps are inferred like decoded JSON
+func normalizeJSON(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// inferSchema returns a JSON Schema that value is an instance of
+func inferSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{"type": "null"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	case float64:
+		if v == math.Trunc(v) {
+			return map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "number"}
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case []interface{}:
+		schema := map[string]interface{}{"type": "array"}
+		if len(v) > 0 {
+			schema["items"] = inferSchema(v[0])
+		}
+		return schema
+	case map[string]interface{}:
+		properties := make(map[string]interface{}, len(v))
+		required := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			properties[key] = inferSchema(v[key])
+			required = append(required, key)
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,52 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestExportOpenAPI(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	server.AddStub(NewStubBuilder("GET", "/orders/{id}").
+		WhenQuery("expand", "items").
+		Body(map[string]interface{}{"id": "42", "total": 9.5, "items": []interface{}{map[string]interface{}{"qty": 2}}}).
+		Build())
+	server.AddStub(NewStubBuilder("GET", "/orders/{id}").Status(404).Body("not found").Build())
+	server.AddStub(NewStubBuilder("POST", "/orders").WhenBodyJSON(map[string]string{"sku": "a"}).Status(201).Build())
+	server.AddStub(ResponseStub{Method: "GET", Path: PathRegex(`^/legacy/.*$`), Status: 200})
+
+	var out bytes.Buffer
+	if err := server.ExportOpenAPI(&out); err != nil {
+		t.Fatalf("Failed to export OpenAPI: %v", err)
+	}
+	var doc struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || len(doc.Paths) != 2 {
+		t.Fatalf("Expected 2 paths without the regex stub, got %s", out.String())
+	}
+
+	get := doc.Paths["/orders/{id}"]["get"]
+	if get["operationId"] != "getOrdersById" || len(get["parameters"].([]interface{})) != 2 {
+		t.Errorf("Expected a path and a query parameter, got %v", get)
+	}
+	responses := get["responses"].(map[string]interface{})
+	ok := responses["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+	schema := ok["schema"].(map[string]interface{})["properties"].(map[string]interface{})
+	if schema["total"].(map[string]interface{})["type"] != "number" || schema["items"].(map[string]interface{})["type"] != "array" {
+		t.Errorf("Unexpected inferred schema %v", schema)
+	}
+	if _, ok := responses["404"].(map[string]interface{})["content"].(map[string]interface{})["text/plain"]; !ok {
+		t.Errorf("Expected a text 404 response, got %v", responses["404"])
+	}
+
+	post := doc.Paths["/orders"]["post"]
+	if _, ok := post["requestBody"]; !ok {
+		t.Errorf("Expected a request body from the JSON matcher, got %v", post)
+	}
+}
@@ -0,0 +1,77 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// OTLP/HTTP JSON encoding, as produced by OpenTelemetry exporters configured
+// with the http/json protocol. Only the fields the sinks inspect are modelled.
+
+// otlpAnyValue is the JSON form of an OTLP AnyValue
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    json.RawMessage `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+}
+
+// String renders the value as plain text
+func (v otlpAnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case len(v.IntValue) > 0:
+		// int64 values may be encoded either as a JSON number or a string
+		var s string
+		if json.Unmarshal(v.IntValue, &s) == nil {
+			return s
+		}
+		return string(v.IntValue)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// otlpKeyValue is the JSON form of an OTLP KeyValue attribute
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpResource is the JSON form of an OTLP Resource
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+// otlpAttributes flattens attribute lists into a map, later lists winning
+func otlpAttributes(lists ...[]otlpKeyValue) map[string]string {
+	attrs := make(map[string]string)
+	for _, list := range lists {
+		for _, kv := range list {
+			attrs[kv.Key] = kv.Value.String()
+		}
+	}
+	return attrs
+}
+
+// otlpUint64 decodes an OTLP (u)int64 field, which may be a JSON number or string
+func otlpUint64(raw json.RawMessage) (uint64, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strconv.ParseUint(s, 10, 64)
+	}
+	var n uint64
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return 0, fmt.Errorf("invalid integer %s", raw)
+	}
+	return n, nil
+}
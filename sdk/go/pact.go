@@ -0,0 +1,176 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// pactFile is a Pact specification v3 contract
+type pactFile struct {
+	Consumer     pactParty         `json:"consumer"`
+	Provider     pactParty         `json:"provider"`
+	Interactions []pactInteraction `json:"interactions"`
+	Metadata     pactMetadata      `json:"metadata"`
+}
+
+type pactParty struct {
+	Name string `json:"name"`
+}
+
+type pactMetadata struct {
+	PactSpecification struct {
+		Version string `json:"version"`
+	} `json:"pactSpecification"`
+}
+
+type pactInteraction struct {
+	Description    string              `json:"description"`
+	ProviderStates []pactProviderState `json:"providerStates,omitempty"`
+	Request        pactRequest         `json:"request"`
+	Response       pactResponse        `json:"response"`
+}
+
+type pactProviderState struct {
+	Name string `json:"name"`
+}
+
+type pactRequest struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query,omitempty"`
+	Headers map[string]string   `json:"headers,omitempty"`
+	Body    interface{}         `json:"body,omitempty"`
+}
+
+type pactResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// ExportPact writes a Pact specification v3 contract between consumer and
+// provider to w, with one interaction per distinct request a stub served.
+// Requests carry the headers the stub matched on; responses are the stub's,
+// with the status the consumer actually received. Requests that matched no
+// stub are left out.
+//
+// The file can be published to a Pact broker like one written by a Pact
+// consumer test.
+func (m *MockServer) ExportPact(consumer, provider string, w io.Writer) error {
+	if consumer == "" || provider == "" {
+		return NewInvalidConfigError("pact consumer and provider names must not be empty", map[string]interface{}{"consumer": consumer, "provider": provider})
+	}
+
+	stubs, err := m.stubsByID()
+	if err != nil {
+		return err
+	}
+
+	all, err := m.verify(VerificationRequest{}, AtLeast(0))
+	if err != nil {
+		return err
+	}
+	requests, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return err
+	}
+	// The server lists the most recent request first
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].Timestamp.Before(requests[j].Timestamp)
+	})
+
+	pact := pactFile{Consumer: pactParty{Name: consumer}, Provider: pactParty{Name: provider}, Interactions: []pactInteraction{}}
+	pact.Metadata.PactSpecification.Version = "3.0.0"
+	seen := make(map[string]bool)
+	descriptions := make(map[string]int)
+	for _, request := range requests {
+		stub, ok := stubs[request.Metadata["stub_id"]]
+		if !ok {
+			continue
+		}
+		interaction := pactInteractionFor(stub, request)
+		key, _ := json.Marshal([]interface{}{request.Metadata["stub_id"], interaction.Request})
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+
+		// Descriptions must be unique within a provider state
+		descriptions[interaction.Description]++
+		if n := descriptions[interaction.Description]; n > 1 {
+			interaction.Description = fmt.Sprintf("%s #%d", interaction.Description, n)
+		}
+		pact.Interactions = append(pact.Interactions, interaction)
+	}
+
+	data, err := json.MarshalIndent(pact, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pact: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// pactInteractionFor describes a request a stub served as an interaction
+func pactInteractionFor(stub *ResponseStub, request LoggedRequest) pactInteraction {
+	interaction := pactInteraction{
+		Description: fmt.Sprintf("%s %s", request.Method, request.Path),
+		Request: pactRequest{
+			Method: request.Method,
+			Path:   request.Path,
+			Body:   pactBody(request.Body),
+		},
+		Response: pactResponse{Status: request.StatusCode, Headers: stub.Headers, Body: stub.Body},
+	}
+	if stub.Scenario != "" && stub.RequiredState != "" {
+		interaction.ProviderStates = []pactProviderState{{Name: fmt.Sprintf("%s is %s", stub.Scenario, stub.RequiredState)}}
+	}
+
+	for name, value := range request.QueryParams {
+		if interaction.Request.Query == nil {
+			interaction.Request.Query = make(map[string][]string)
+		}
+		interaction.Request.Query[name] = []string{value}
+	}
+	if stub.Match != nil {
+		names := append([]string(nil), stub.Match.HeadersPresent...)
+		for name := range stub.Match.Headers {
+			names = append(names, name)
+		}
+		for _, name := range names {
+			if value, ok := lookupHeader(request.Headers, name); ok {
+				if interaction.Request.Headers == nil {
+					interaction.Request.Headers = make(map[string]string)
+				}
+				interaction.Request.Headers[strings.ToLower(name)] = value
+			}
+		}
+	}
+
+	// For sequenced stubs, use the first response with the served status
+	for _, response := range stub.Sequence {
+		if response.Status == request.StatusCode {
+			interaction.Response.Headers, interaction.Response.Body = response.Headers, response.Body
+			break
+		}
+	}
+	if interaction.Response.Status == 0 {
+		interaction.Response.Status = stub.Status
+	}
+	return interaction
+}
+
+// pactBody returns a recorded body as JSON if it is JSON, else as text
+func pactBody(body string) interface{} {
+	if body == "" {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(body), &decoded); err == nil {
+		return decoded
+	}
+	return body
+}
@@ -0,0 +1,65 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestExportPact(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/__mockforge/api/mocks" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"mocks": []map[string]interface{}{{
+					"id": "create-order", "method": "POST", "path": "/orders", "status_code": 201,
+					"request_match": map[string]interface{}{"headers": map[string]string{"Authorization": "Bearer .*"}},
+					"response":      map[string]interface{}{"body": map[string]string{"id": "42"}, "headers": map[string]string{"Content-Type": "application/json"}},
+				}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   3,
+			"matches": []map[string]interface{}{
+				{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "GET", "path": "/health", "status_code": 404, "metadata": map[string]string{"unmatched": "true"}},
+				{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "POST", "path": "/orders", "status_code": 201, "headers": map[string]string{"authorization": "Bearer abc", "user-agent": "go"}, "metadata": map[string]string{"stub_id": "create-order", "request_body": `{"sku":"a"}`}},
+				{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "POST", "path": "/orders", "status_code": 201, "headers": map[string]string{"authorization": "Bearer abc", "user-agent": "go"}, "metadata": map[string]string{"stub_id": "create-order", "request_body": `{"sku":"a"}`}},
+			},
+		})
+	}))
+
+	var out bytes.Buffer
+	if err := server.ExportPact("web", "orders-api", &out); err != nil {
+		t.Fatalf("Failed to export pact: %v", err)
+	}
+	var pact pactFile
+	if err := json.Unmarshal(out.Bytes(), &pact); err != nil {
+		t.Fatalf("Failed to parse pact: %v", err)
+	}
+	if pact.Consumer.Name != "web" || pact.Provider.Name != "orders-api" || pact.Metadata.PactSpecification.Version != "3.0.0" {
+		t.Errorf("Unexpected pact header %+v", pact)
+	}
+	// Identical requests collapse and unmatched requests are left out
+	if len(pact.Interactions) != 1 {
+		t.Fatalf("Expected 1 interaction, got %+v", pact.Interactions)
+	}
+	interaction := pact.Interactions[0]
+	if interaction.Description != "POST /orders" || interaction.Response.Status != 201 {
+		t.Errorf("Unexpected interaction %+v", interaction)
+	}
+	if len(interaction.Request.Headers) != 1 || interaction.Request.Headers["authorization"] != "Bearer abc" {
+		t.Errorf("Expected only the matched header, got %v", interaction.Request.Headers)
+	}
+	if body, ok := interaction.Request.Body.(map[string]interface{}); !ok || body["sku"] != "a" {
+		t.Errorf("Expected the JSON request body, got %v", interaction.Request.Body)
+	}
+	if body, ok := interaction.Response.Body.(map[string]interface{}); !ok || body["id"] != "42" {
+		t.Errorf("Expected the stub's response body, got %v", interaction.Response.Body)
+	}
+
+	if err := server.ExportPact("", "orders-api", &out); err == nil {
+		t.Error("Expected an error for an empty consumer name")
+	}
+}
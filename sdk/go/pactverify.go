@@ -0,0 +1,415 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PactTarget is what VerifyProviderAgainstPact applies a contract to. Set
+// exactly one of Server and ProviderURL.
+type PactTarget struct {
+	// Server, if set, gets a stub for every interaction, so consumer tests
+	// can run against the contract
+	Server *MockServer
+	// ProviderURL, if set, is the base URL of a running provider the
+	// interactions are replayed against
+	ProviderURL string
+	// SetupState, if set, is called before replaying an interaction with
+	// each of its provider states
+	SetupState func(state string) error
+	// Client sends replayed requests; nil uses http.DefaultClient
+	Client *http.Client
+}
+
+// PactVerification is the outcome of VerifyProviderAgainstPact
+type PactVerification struct {
+	Consumer     string
+	Provider     string
+	Interactions int
+	// Mismatches lists the interactions the provider did not honor; it is
+	// always empty when generating stubs
+	Mismatches []PactMismatch
+}
+
+// Passed reports whether every interaction was honored
+func (v *PactVerification) Passed() bool {
+	return len(v.Mismatches) == 0
+}
+
+// PactMismatch is an interaction the provider did not honor
+type PactMismatch struct {
+	Interaction string
+	// Differences describe each way the response departed from the
+	// contract, e.g. `body $.id: expected "42", got "43"`
+	Differences []string
+}
+
+// pactContract is a Pact file as read, accepting specification v2 to v4
+type pactContract struct {
+	Consumer     pactParty `json:"consumer"`
+	Provider     pactParty `json:"provider"`
+	Interactions []struct {
+		Type           string              `json:"type"`
+		Description    string              `json:"description"`
+		ProviderState  string              `json:"providerState"`
+		ProviderStates []pactProviderState `json:"providerStates"`
+		Request        struct {
+			Method  string                 `json:"method"`
+			Path    string                 `json:"path"`
+			Query   json.RawMessage        `json:"query"`
+			Headers map[string]interface{} `json:"headers"`
+			Body    json.RawMessage        `json:"body"`
+		} `json:"request"`
+		Response struct {
+			Status  int                    `json:"status"`
+			Headers map[string]interface{} `json:"headers"`
+			Body    json.RawMessage        `json:"body"`
+		} `json:"response"`
+	} `json:"interactions"`
+}
+
+// pactCase is an interaction in the form it is stubbed or replayed
+type pactCase struct {
+	description     string
+	states          []string
+	method, path    string
+	query           url.Values
+	requestHeaders  map[string]string
+	requestBody     interface{}
+	status          int
+	responseHeaders map[string]string
+	responseBody    interface{}
+}
+
+// VerifyProviderAgainstPact loads the Pact contract in pactFile and applies
+// it to target: either it stubs every interaction on target.Server, or it
+// replays every interaction against the provider at target.ProviderURL and
+// reports the responses that break the contract. Response bodies follow
+// Pact's matching rules for examples: objects may have extra fields, but
+// arrays and values must match exactly.
+//
+//	result, err := mockforge.VerifyProviderAgainstPact("pacts/web-orders-api.json",
+//	    mockforge.PactTarget{ProviderURL: "http://localhost:8080"})
+//	if !result.Passed() {
+//	    t.Errorf("provider broke the contract: %+v", result.Mismatches)
+//	}
+func VerifyProviderAgainstPact(pactFile string, target PactTarget) (*PactVerification, error) {
+	if (target.Server == nil) == (target.ProviderURL == "") {
+		return nil, NewInvalidConfigError("pact target needs exactly one of Server and ProviderURL", nil)
+	}
+
+	data, err := os.ReadFile(pactFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pact: %w", err)
+	}
+	var contract pactContract
+	if err := json.Unmarshal(data, &contract); err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("invalid pact: %v", err), map[string]interface{}{"file": pactFile})
+	}
+	cases, err := contract.cases()
+	if err != nil {
+		return nil, NewInvalidConfigError(err.Error(), map[string]interface{}{"file": pactFile})
+	}
+
+	verification := &PactVerification{Consumer: contract.Consumer.Name, Provider: contract.Provider.Name, Interactions: len(cases)}
+	for _, c := range cases {
+		if target.Server != nil {
+			if err := target.Server.AddStub(c.stub()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		differences, err := c.replay(target)
+		if err != nil {
+			return nil, err
+		}
+		if len(differences) > 0 {
+			verification.Mismatches = append(verification.Mismatches, PactMismatch{Interaction: c.description, Differences: differences})
+		}
+	}
+	return verification, nil
+}
+
+// cases converts the contract's HTTP interactions
+func (p *pactContract) cases() ([]pactCase, error) {
+	var cases []pactCase
+	for _, interaction := range p.Interactions {
+		// Pact v4 files may hold message interactions too
+		if interaction.Type != "" && interaction.Type != "Synchronous/HTTP" {
+			continue
+		}
+		if interaction.Request.Method == "" || interaction.Request.Path == "" {
+			return nil, fmt.Errorf("interaction %q has no request method or path", interaction.Description)
+		}
+
+		c := pactCase{
+			description:     interaction.Description,
+			method:          strings.ToUpper(interaction.Request.Method),
+			path:            interaction.Request.Path,
+			requestHeaders:  pactHeaders(interaction.Request.Headers),
+			requestBody:     pactContent(interaction.Request.Body),
+			status:          interaction.Response.Status,
+			responseHeaders: pactHeaders(interaction.Response.Headers),
+			responseBody:    pactContent(interaction.Response.Body),
+		}
+		if interaction.ProviderState != "" {
+			c.states = append(c.states, interaction.ProviderState)
+		}
+		for _, state := range interaction.ProviderStates {
+			c.states = append(c.states, state.Name)
+		}
+		if c.status == 0 {
+			c.status = http.StatusOK
+		}
+
+		query, err := pactQuery(interaction.Request.Query)
+		if err != nil {
+			return nil, fmt.Errorf("interaction %q: %v", interaction.Description, err)
+		}
+		c.query = query
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// pactQuery decodes a v2 query string or a v3 map of value lists
+func pactQuery(raw json.RawMessage) (url.Values, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var query string
+	if err := json.Unmarshal(raw, &query); err == nil {
+		return url.ParseQuery(query)
+	}
+	var values map[string][]string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	return url.Values(values), nil
+}
+
+// pactHeaders flattens string or, in v4, list header values
+func pactHeaders(headers map[string]interface{}) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	flat := make(map[string]string, len(headers))
+	for name, value := range headers {
+		switch v := value.(type) {
+		case []interface{}:
+			values := make([]string, len(v))
+			for i, item := range v {
+				values[i] = fmt.Sprint(item)
+			}
+			flat[name] = strings.Join(values, ", ")
+		default:
+			flat[name] = fmt.Sprint(v)
+		}
+	}
+	return flat
+}
+
+// pactContent decodes a body, unwrapping the v4 {"content": ...} form
+func pactContent(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	var body interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil
+	}
+	if object, ok := body.(map[string]interface{}); ok {
+		if content, ok := object["content"]; ok && len(object) <= 3 {
+			if _, typed := object["contentType"]; typed {
+				return content
+			}
+		}
+	}
+	return body
+}
+
+// stub returns the stub that answers the interaction
+func (c pactCase) stub() ResponseStub {
+	stub := ResponseStub{
+		Method:  c.method,
+		Path:    c.path,
+		Status:  c.status,
+		Headers: c.responseHeaders,
+		Body:    c.responseBody,
+	}
+	match := &RequestMatch{}
+	for name, values := range c.query {
+		if match.QueryParams == nil {
+			match.QueryParams = make(map[string]string)
+		}
+		match.QueryParams[name] = values[0]
+	}
+	for name, value := range c.requestHeaders {
+		if match.Headers == nil {
+			match.Headers = make(map[string]string)
+		}
+		// Stub header values are regular expressions
+		match.Headers[name] = exactHeaderPattern(value)
+	}
+	if text, ok := c.requestBody.(string); ok {
+		match.BodyPattern = "^" + regexp.QuoteMeta(text) + "$"
+	} else if c.requestBody != nil {
+		match.BodyJSON = c.requestBody
+	}
+	if match.QueryParams != nil || match.Headers != nil || match.BodyJSON != nil || match.BodyPattern != "" {
+		stub.Match = match
+	}
+	return stub
+}
+
+// replay sends the interaction's request to the provider and describes how
+// the response breaks the contract
+func (c pactCase) replay(target PactTarget) ([]string, error) {
+	for _, state := range c.states {
+		if target.SetupState == nil {
+			break
+		}
+		if err := target.SetupState(state); err != nil {
+			return nil, fmt.Errorf("failed to set up provider state %q: %w", state, err)
+		}
+	}
+
+	var body io.Reader
+	if c.requestBody != nil {
+		if text, ok := c.requestBody.(string); ok {
+			body = strings.NewReader(text)
+		} else {
+			data, err := json.Marshal(c.requestBody)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode request of %q: %w", c.description, err)
+			}
+			body = bytes.NewReader(data)
+		}
+	}
+	requestURL := strings.TrimSuffix(target.ProviderURL, "/") + c.path
+	if len(c.query) > 0 {
+		requestURL += "?" + c.query.Encode()
+	}
+	req, err := http.NewRequest(c.method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request of %q: %w", c.description, err)
+	}
+	for name, value := range c.requestHeaders {
+		req.Header.Set(name, value)
+	}
+	if c.requestBody != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := target.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay %q: %w", c.description, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %q: %w", c.description, err)
+	}
+
+	var differences []string
+	if resp.StatusCode != c.status {
+		differences = append(differences, fmt.Sprintf("status: expected %d, got %d", c.status, resp.StatusCode))
+	}
+	for _, name := range sortedStringKeys(c.responseHeaders) {
+		expected, actual := c.responseHeaders[name], resp.Header.Get(name)
+		if actual == "" {
+			differences = append(differences, fmt.Sprintf("missing header %s", name))
+		} else if !pactHeaderMatches(expected, actual) {
+			differences = append(differences, fmt.Sprintf("header %s: expected %q, got %q", name, expected, actual))
+		}
+	}
+	if c.responseBody != nil {
+		differences = append(differences, pactBodyDifferences(c.responseBody, data)...)
+	}
+	return differences, nil
+}
+
+// pactHeaderMatches compares header values, ignoring whitespace around
+// commas as Pact does
+func pactHeaderMatches(expected, actual string) bool {
+	normalize := func(value string) string {
+		parts := strings.Split(value, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return strings.Join(parts, ",")
+	}
+	return normalize(expected) == normalize(actual)
+}
+
+// pactBodyDifferences compares a response body to the contract's example
+func pactBodyDifferences(expected interface{}, body []byte) []string {
+	if text, ok := expected.(string); ok {
+		if string(body) != text {
+			return []string{fmt.Sprintf("body: expected %q, got %q", text, body)}
+		}
+		return nil
+	}
+	var actual interface{}
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return []string{"body is not JSON"}
+	}
+	return pactValueDifferences("$", expected, actual)
+}
+
+// pactValueDifferences compares JSON values; objects may have extra keys
+func pactValueDifferences(path string, expected, actual interface{}) []string {
+	switch want := expected.(type) {
+	case map[string]interface{}:
+		got, ok := actual.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("body %s: expected an object", path)}
+		}
+		keys := make([]string, 0, len(want))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var differences []string
+		for _, key := range keys {
+			value, ok := got[key]
+			if !ok {
+				differences = append(differences, fmt.Sprintf("body %s.%s: missing", path, key))
+				continue
+			}
+			differences = append(differences, pactValueDifferences(path+"."+key, want[key], value)...)
+		}
+		return differences
+	case []interface{}:
+		got, ok := actual.([]interface{})
+		if !ok || len(got) != len(want) {
+			return []string{fmt.Sprintf("body %s: expected an array of %d item(s)", path, len(want))}
+		}
+		var differences []string
+		for i := range want {
+			differences = append(differences, pactValueDifferences(fmt.Sprintf("%s[%d]", path, i), want[i], got[i])...)
+		}
+		return differences
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			wantJSON, _ := json.Marshal(expected)
+			gotJSON, _ := json.Marshal(actual)
+			return []string{fmt.Sprintf("body %s: expected %s, got %s", path, wantJSON, gotJSON)}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPact = `{
+  "consumer": {"name": "web"},
+  "provider": {"name": "orders-api"},
+  "interactions": [
+    {
+      "description": "get an order",
+      "providerStates": [{"name": "order 42 exists"}],
+      "request": {"method": "GET", "path": "/orders/42", "query": {"expand": ["items"]}, "headers": {"accept": "application/json"}},
+      "response": {"status": 200, "headers": {"Content-Type": "application/json"}, "body": {"id": "42", "items": [{"sku": "a"}]}}
+    },
+    {
+      "description": "create an order",
+      "request": {"method": "POST", "path": "/orders", "body": {"sku": "a"}},
+      "response": {"status": 201, "body": {"id": "43"}}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+func writeTestPact(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pact.json")
+	if err := os.WriteFile(path, []byte(testPact), 0o644); err != nil {
+		t.Fatalf("Failed to write pact: %v", err)
+	}
+	return path
+}
+
+func TestVerifyProviderAgainstPactReplay(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && r.URL.Query().Get("expand") == "items" {
+			// Extra fields are allowed
+			w.Write([]byte(`{"id":"42","items":[{"sku":"a"}],"total":3}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"44"}`))
+	}))
+	defer provider.Close()
+
+	var states []string
+	result, err := VerifyProviderAgainstPact(writeTestPact(t), PactTarget{
+		ProviderURL: provider.URL,
+		SetupState:  func(state string) error { states = append(states, state); return nil },
+	})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Interactions != 2 || result.Passed() {
+		t.Fatalf("Expected a failed verification of 2 interactions, got %+v", result)
+	}
+	if len(states) != 1 || states[0] != "order 42 exists" {
+		t.Errorf("Expected the provider state to be set up, got %v", states)
+	}
+	if len(result.Mismatches) != 1 || result.Mismatches[0].Interaction != "create an order" {
+		t.Fatalf("Expected only the create interaction to fail, got %+v", result.Mismatches)
+	}
+	differences := result.Mismatches[0].Differences
+	if len(differences) != 2 || differences[0] != "status: expected 201, got 200" || differences[1] != `body $.id: expected "43", got "44"` {
+		t.Errorf("Unexpected differences %v", differences)
+	}
+}
+
+func TestVerifyProviderAgainstPactStubs(t *testing.T) {
+	var created []map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var mock map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&mock)
+		created = append(created, mock)
+		json.NewEncoder(w).Encode(mock)
+	}))
+
+	result, err := VerifyProviderAgainstPact(writeTestPact(t), PactTarget{Server: server})
+	if err != nil {
+		t.Fatalf("Failed to stub pact: %v", err)
+	}
+	if !result.Passed() || result.Consumer != "web" || len(created) != 2 {
+		t.Fatalf("Expected 2 stubs, got %+v and %v", result, created)
+	}
+	match, ok := created[0]["request_match"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected the stub to match the request, got %v", created[0])
+	}
+	if match["query_params"].(map[string]interface{})["expand"] != "items" {
+		t.Errorf("Expected a query match, got %v", match)
+	}
+
+	if _, err := VerifyProviderAgainstPact(writeTestPact(t), PactTarget{}); err == nil {
+		t.Error("Expected an error without a target")
+	}
+}
@@ -0,0 +1,67 @@
+package mockforge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regexPathPrefix marks a stub path as a regular expression
+const regexPathPrefix = "regex:"
+
+// pathParamPattern matches a {name} path template segment
+var pathParamPattern = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// PathRegex returns a stub path that matches request paths against the
+// regular expression pattern rather than literally. Named groups such as
+// (?P<id>\d+) are captured like {id} template parameters.
+//
+//	server.StubResponse("GET", mockforge.PathRegex(`^/users/(?P<id>\d+)$`), body)
+func PathRegex(pattern string) string {
+	return regexPathPrefix + pattern
+}
+
+// PathParam returns the response template placeholder for a captured path
+// parameter, e.g. PathParam("id") for a stub on /users/{id}
+func PathParam(name string) string {
+	return fmt.Sprintf("{{request.path.%s}}", name)
+}
+
+// isRegexPath reports whether path was built with PathRegex
+func isRegexPath(path string) bool {
+	return strings.HasPrefix(path, regexPathPrefix)
+}
+
+// validateStubPath checks a stub path is a well-formed literal path, path
+// template, or regular expression
+func validateStubPath(path string) error {
+	if isRegexPath(path) {
+		if _, err := regexp.Compile(strings.TrimPrefix(path, regexPathPrefix)); err != nil {
+			return NewInvalidConfigError(fmt.Sprintf("invalid path regex: %v", err), map[string]interface{}{"path": path})
+		}
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+		matches := pathParamPattern.FindStringSubmatch(segment)
+		if matches == nil {
+			return NewInvalidConfigError(
+				fmt.Sprintf("invalid path template segment %q: parameters must span a whole segment, e.g. {id}", segment),
+				map[string]interface{}{"path": path},
+			)
+		}
+		if seen[matches[1]] {
+			return NewInvalidConfigError(
+				fmt.Sprintf("duplicate path parameter %q", matches[1]),
+				map[string]interface{}{"path": path},
+			)
+		}
+		seen[matches[1]] = true
+	}
+
+	return nil
+}
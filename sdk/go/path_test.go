@@ -0,0 +1,21 @@
+package mockforge
+
+import (
+	"testing"
+)
+
+func TestValidateStubPath(t *testing.T) {
+	valid := []string{"/users", "/users/{id}", PathRegex(`^/files/.+$`)}
+	for _, path := range valid {
+		if err := validateStubPath(path); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", path, err)
+		}
+	}
+
+	invalid := []string{"/users/{id", "/users/id-{id}", "/a/{id}/b/{id}", PathRegex(`^/users/(\d+$`)}
+	for _, path := range invalid {
+		if err := validateStubPath(path); err == nil {
+			t.Errorf("Expected %s to be rejected", path)
+		}
+	}
+}
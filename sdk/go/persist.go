@@ -0,0 +1,89 @@
+package mockforge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// persistFile is the file PersistStubs keeps in its directory
+const persistFile = "stubs.json"
+
+// PersistStubs keeps every registered stub in dir/stubs.json, rewriting the
+// file whenever stubs are added, updated, or removed, and reloads the file
+// on Restart. Stubs already in the file are registered first, so stubs
+// accumulate across runs until the file is committed or deleted. The file
+// has the ExportStubs format.
+func (m *MockServer) PersistStubs(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create stub directory: %w", err)
+	}
+
+	m.persistDir = ""
+	if err := m.loadPersisted(dir); err != nil {
+		return err
+	}
+	m.persistDir = dir
+	return m.persist()
+}
+
+// Restart stops and starts the server on the same HTTP port, registering
+// the persisted stubs again if PersistStubs is in use
+func (m *MockServer) Restart() error {
+	if err := m.Stop(); err != nil {
+		return err
+	}
+
+	m.portMutex.Lock()
+	m.adminPort = 0
+	m.portMutex.Unlock()
+	m.stubs = make([]ResponseStub, 0)
+
+	if err := m.Start(); err != nil {
+		return err
+	}
+	if m.persistDir == "" {
+		return nil
+	}
+
+	dir := m.persistDir
+	m.persistDir = ""
+	defer func() { m.persistDir = dir }()
+	return m.loadPersisted(dir)
+}
+
+// loadPersisted registers the stubs persisted in dir, if any
+func (m *MockServer) loadPersisted(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, persistFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read persisted stubs: %w", err)
+	}
+	return m.ImportStubs(bytes.NewReader(data))
+}
+
+// persist rewrites the persisted stubs after a change. It does nothing
+// unless PersistStubs is in use.
+func (m *MockServer) persist() error {
+	if m.persistDir == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := m.ExportStubs(&buf, StubFormatJSON); err != nil {
+		return err
+	}
+	// Write to a temporary file first so a crash never leaves a truncated file
+	path := filepath.Join(m.persistDir, persistFile)
+	if err := os.WriteFile(path+".tmp", buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to persist stubs: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to persist stubs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package mockforge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPersistStubs(t *testing.T) {
+	dir := t.TempDir()
+
+	first := NewMockServer(MockServerConfig{})
+	if err := first.PersistStubs(dir); err != nil {
+		t.Fatalf("Failed to persist stubs: %v", err)
+	}
+	if err := first.AddStub(NewStubBuilder("GET", "/users").Body([]string{"alice"}).Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "stubs.json"))
+	if err != nil || !strings.Contains(string(data), `"/users"`) {
+		t.Fatalf("Expected the stub to be written to disk, got %s (%v)", data, err)
+	}
+
+	second := NewMockServer(MockServerConfig{})
+	if err := second.PersistStubs(dir); err != nil {
+		t.Fatalf("Failed to persist stubs: %v", err)
+	}
+	if err := second.AddStub(NewStubBuilder("GET", "/orders").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	if len(second.stubs) != 2 || second.stubs[0].Path != "/users" {
+		t.Errorf("Expected the persisted stub to be reloaded, got %+v", second.stubs)
+	}
+
+	if err := second.ClearStubs(); err != nil {
+		t.Fatalf("Failed to clear stubs: %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "stubs.json"))
+	if strings.TrimSpace(string(data)) != "[]" {
+		t.Errorf("Expected cleared stubs to be persisted, got %s", data)
+	}
+}
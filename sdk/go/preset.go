@@ -0,0 +1,139 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// EnvironmentPreset bundles everything that makes up a test "world" — stubs,
+// latency, faults, chaos, auth, and seed data — so teams can version it in
+// code and apply it with one call. See the presets package for ready-made
+// worlds.
+type EnvironmentPreset struct {
+	Name string
+	// Stubs are registered when the preset is applied
+	Stubs []ResponseStub
+	// Latency, if set, is added to every response
+	Latency *PresetLatency
+	// Faults, if set, configures random failures across every route
+	Faults *FaultInjection
+	// ChaosScenarios are started by name, e.g. "slow_backend"
+	ChaosScenarios []string
+	// Auth, if set, makes the preset's stubs require credentials
+	Auth *PresetAuth
+	// Seeds run last and load data into stateful mocks, e.g. search indices
+	Seeds []func(*MockServer) error
+}
+
+// PresetLatency is server-wide latency added by a preset
+type PresetLatency struct {
+	Base   time.Duration
+	Jitter time.Duration
+}
+
+// PresetAuth is the authentication a preset's stubs require. Requests
+// without it receive 401 from the same routes.
+type PresetAuth struct {
+	BearerToken string
+}
+
+// ComposePresets merges presets into one named preset. Stubs, chaos
+// scenarios, and seeds accumulate; latency, faults, and auth are taken from
+// the last preset that sets them.
+func ComposePresets(name string, presets ...EnvironmentPreset) EnvironmentPreset {
+	composed := EnvironmentPreset{Name: name}
+	for _, preset := range presets {
+		composed.Stubs = append(composed.Stubs, preset.Stubs...)
+		composed.ChaosScenarios = append(composed.ChaosScenarios, preset.ChaosScenarios...)
+		composed.Seeds = append(composed.Seeds, preset.Seeds...)
+		if preset.Latency != nil {
+			composed.Latency = preset.Latency
+		}
+		if preset.Faults != nil {
+			composed.Faults = preset.Faults
+		}
+		if preset.Auth != nil {
+			composed.Auth = preset.Auth
+		}
+	}
+	return composed
+}
+
+// ApplyPreset applies each preset in order
+func (m *MockServer) ApplyPreset(presets ...EnvironmentPreset) error {
+	for _, preset := range presets {
+		if err := m.applyPreset(preset); err != nil {
+			return fmt.Errorf("failed to apply preset %s: %w", preset.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyPreset applies server-wide settings first so stubs and seeds run in
+// the configured world
+func (m *MockServer) applyPreset(preset EnvironmentPreset) error {
+	if preset.Latency != nil {
+		if err := m.SetGlobalLatency(preset.Latency.Base, preset.Latency.Jitter); err != nil {
+			return err
+		}
+	}
+	if preset.Faults != nil {
+		if err := m.SetFaultInjection(*preset.Faults); err != nil {
+			return err
+		}
+	}
+
+	for _, stub := range preset.Stubs {
+		for _, s := range preset.Auth.protect(stub) {
+			if err := m.AddStub(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range preset.ChaosScenarios {
+		if err := m.StartChaosScenario(name); err != nil {
+			return err
+		}
+	}
+
+	for _, seed := range preset.Seeds {
+		if err := seed(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// protect returns stub guarded by the auth requirement together with the 401
+// fallback for unauthenticated requests to the same route
+func (a *PresetAuth) protect(stub ResponseStub) []ResponseStub {
+	if a == nil || a.BearerToken == "" {
+		return []ResponseStub{stub}
+	}
+
+	match := RequestMatch{}
+	if stub.Match != nil {
+		match = *stub.Match
+	}
+	headers := map[string]string{"Authorization": "^Bearer " + regexp.QuoteMeta(a.BearerToken) + "$"}
+	for name, value := range match.Headers {
+		headers[name] = value
+	}
+	match.Headers = headers
+	stub.Match = &match
+
+	unauthorized := ResponseStub{
+		Method:   stub.Method,
+		Path:     stub.Path,
+		Status:   http.StatusUnauthorized,
+		Headers:  map[string]string{"WWW-Authenticate": "Bearer"},
+		Body:     map[string]interface{}{"error": "unauthorized"},
+		Priority: stub.Priority - 1,
+	}
+
+	return []ResponseStub{stub, unauthorized}
+}
@@ -0,0 +1,46 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestApplyPreset(t *testing.T) {
+	var calls []string
+	var mocks []map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		if r.URL.Path == "/__mockforge/api/mocks" {
+			var mock map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&mock)
+			mocks = append(mocks, mock)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+
+	preset := ComposePresets("slow-and-secure",
+		EnvironmentPreset{Latency: &PresetLatency{Base: time.Second}},
+		EnvironmentPreset{
+			Stubs: []ResponseStub{NewStubBuilder("GET", "/orders").Body([]string{}).Build()},
+			Auth:  &PresetAuth{BearerToken: "t0k.en"},
+		},
+	)
+	if err := server.ApplyPreset(preset); err != nil {
+		t.Fatalf("Failed to apply preset: %v", err)
+	}
+
+	if len(calls) != 3 || calls[0] != "/__mockforge/config/latency" {
+		t.Fatalf("Expected latency update then 2 mocks, got %v", calls)
+	}
+
+	match := mocks[0]["request_match"].(map[string]interface{})
+	if headers := match["headers"].(map[string]interface{}); headers["Authorization"] != `^Bearer t0k\.en$` {
+		t.Errorf("Expected bearer token matcher, got %v", headers)
+	}
+	if mocks[1]["status_code"] != float64(401) || mocks[1]["priority"] != float64(-1) {
+		t.Errorf("Expected 401 fallback below the stub, got %v", mocks[1])
+	}
+}
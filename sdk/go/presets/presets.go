@@ -0,0 +1,67 @@
+// Package presets provides ready-made environment presets — the "worlds"
+// tests run in. Each returns a fresh mockforge.EnvironmentPreset that can be
+// extended with application stubs and applied with MockServer.ApplyPreset:
+//
+//	world := mockforge.ComposePresets("checkout-under-load",
+//	    presets.BlackFriday(),
+//	    mockforge.EnvironmentPreset{Stubs: checkoutStubs},
+//	)
+//	err := server.ApplyPreset(world)
+package presets
+
+import (
+	"net/http"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// Healthy is an ideal world: no added latency and no injected failures
+func Healthy() mockforge.EnvironmentPreset {
+	return mockforge.EnvironmentPreset{
+		Name:    "healthy",
+		Latency: &mockforge.PresetLatency{},
+		Faults:  &mockforge.FaultInjection{Enabled: false},
+	}
+}
+
+// Staging resembles a shared staging environment: modest latency with some
+// jitter and the occasional unavailable dependency
+func Staging() mockforge.EnvironmentPreset {
+	return mockforge.EnvironmentPreset{
+		Name:    "staging",
+		Latency: &mockforge.PresetLatency{Base: 80 * time.Millisecond, Jitter: 40 * time.Millisecond},
+		Faults: &mockforge.FaultInjection{
+			Enabled:     true,
+			FailureRate: 0.005,
+			StatusCodes: []int{http.StatusServiceUnavailable},
+		},
+	}
+}
+
+// BlackFriday is peak traffic: slow, spiky responses with rate limiting and
+// overload errors
+func BlackFriday() mockforge.EnvironmentPreset {
+	return mockforge.EnvironmentPreset{
+		Name:    "black-friday",
+		Latency: &mockforge.PresetLatency{Base: 300 * time.Millisecond, Jitter: 500 * time.Millisecond},
+		Faults: &mockforge.FaultInjection{
+			Enabled:     true,
+			FailureRate: 0.05,
+			StatusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		},
+		ChaosScenarios: []string{"peak_traffic"},
+	}
+}
+
+// Outage is a hard dependency outage: every request fails
+func Outage() mockforge.EnvironmentPreset {
+	return mockforge.EnvironmentPreset{
+		Name: "outage",
+		Faults: &mockforge.FaultInjection{
+			Enabled:     true,
+			FailureRate: 1,
+			StatusCodes: []int{http.StatusServiceUnavailable},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+//go:build integration
+// +build integration
+
+// Integration tests that require the MockForge CLI to be installed and on PATH.
+// Run with: go test -tags=integration
+package presets
+
+import (
+	"net/http"
+	"os/exec"
+	"testing"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+func TestPresetsApply(t *testing.T) {
+	if _, err := exec.LookPath("mockforge"); err != nil {
+		t.Skip("Requires MockForge CLI to be installed")
+	}
+	server := mockforge.NewMockServer(mockforge.MockServerConfig{})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	account := mockforge.NewStubBuilder("GET", "/account").Body("ok").Build()
+	for _, preset := range []mockforge.EnvironmentPreset{Healthy(), Staging(), BlackFriday(), Outage()} {
+		world := mockforge.ComposePresets(preset.Name, preset, mockforge.EnvironmentPreset{Stubs: []mockforge.ResponseStub{account}})
+		if err := server.ApplyPreset(world); err != nil {
+			t.Fatalf("Failed to apply preset %s: %v", preset.Name, err)
+		}
+	}
+
+	// Black Friday's rate limiting would answer before the outage's faults
+	if err := server.StopChaosScenario("peak_traffic"); err != nil {
+		t.Fatalf("Failed to stop Black Friday's chaos scenario: %v", err)
+	}
+
+	// Outage was applied last, so every request fails
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(server.URL() + "/account")
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503 during the outage, got %d", resp.StatusCode)
+		}
+	}
+}
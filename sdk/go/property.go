@@ -0,0 +1,324 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// Generator produces random values for property-based tests and proposes
+// smaller variants of a failing value
+type Generator[T any] interface {
+	// Generate returns a random value; size grows with each iteration and
+	// bounds the size of collections and strings
+	Generate(rng *rand.Rand, size int) T
+	// Shrink returns simpler candidates for value, most aggressive first
+	Shrink(value T) []T
+}
+
+// GeneratorFunc turns a generation function into a Generator that shrinks
+// values structurally: numbers towards zero, strings and slices towards
+// empty, struct fields one at a time
+type GeneratorFunc[T any] func(rng *rand.Rand, size int) T
+
+// Generate calls f
+func (f GeneratorFunc[T]) Generate(rng *rand.Rand, size int) T {
+	return f(rng, size)
+}
+
+// Shrink shrinks value structurally
+func (f GeneratorFunc[T]) Shrink(value T) []T {
+	return shrinkAs[T](value)
+}
+
+// Arbitrary returns a generator of arbitrary values of T built with
+// testing/quick, shrinking them structurally
+func Arbitrary[T any]() Generator[T] {
+	return GeneratorFunc[T](func(rng *rand.Rand, size int) T {
+		var zero T
+		value, ok := quick.Value(reflect.TypeOf(zero), rng)
+		if !ok {
+			return zero
+		}
+		return value.Interface().(T)
+	})
+}
+
+// PropertyT is the subset of testing.TB available to a property. Failing it
+// marks the generated response as a counterexample.
+type PropertyT interface {
+	Helper()
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// PropertyConfig configures ForAllResponsesWith
+type PropertyConfig struct {
+	// Iterations is the number of generated responses to try (default 100)
+	Iterations int
+	// Seed seeds generation; zero picks a seed from the clock. The seed is
+	// reported on failure so runs can be reproduced.
+	Seed int64
+	// MaxShrinks bounds the shrinking steps after a failure (default 200)
+	MaxShrinks int
+}
+
+// ForAllResponses checks property against the endpoint stubbed with every
+// response body gen produces. Each iteration re-stubs the endpoint described
+// by stub with the next generated body and runs property, which typically
+// calls the client under test and asserts on the outcome. On failure the
+// body is shrunk to a minimal counterexample before the test fails.
+//
+//	mockforge.ForAllResponses(t, server, mockforge.NewStubBuilder("GET", "/users/1"),
+//	    mockforge.Arbitrary[User](),
+//	    func(t mockforge.PropertyT, resp User) {
+//	        if _, err := client.GetUser(1); err != nil {
+//	            t.Errorf("GetUser failed: %v", err)
+//	        }
+//	    })
+func ForAllResponses[T any](t testing.TB, server *MockServer, stub *StubBuilder, gen Generator[T], property func(t PropertyT, resp T)) {
+	t.Helper()
+	ForAllResponsesWith(t, server, stub, gen, PropertyConfig{}, property)
+}
+
+// ForAllResponsesWith is ForAllResponses with explicit configuration
+func ForAllResponsesWith[T any](t testing.TB, server *MockServer, stub *StubBuilder, gen Generator[T], config PropertyConfig, property func(t PropertyT, resp T)) {
+	t.Helper()
+
+	if config.Iterations == 0 {
+		config.Iterations = 100
+	}
+	if config.MaxShrinks == 0 {
+		config.MaxShrinks = 200
+	}
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+
+	rng := rand.New(rand.NewSource(config.Seed))
+	mockID := fmt.Sprintf("property-%x", rng.Uint64())
+	created := false
+	defer func() {
+		if created {
+			server.adminJSON("delete mock", http.MethodDelete, "/__mockforge/api/mocks/"+url.PathEscape(mockID), nil, nil)
+		}
+	}()
+
+	check := func(value T) *propertyRun {
+		builder := *stub
+		mock := builder.Body(value).Build().mockConfig()
+		mock["id"] = mockID
+
+		var err error
+		if created {
+			err = server.adminJSON("update mock", http.MethodPut, "/__mockforge/api/mocks/"+url.PathEscape(mockID), mock, nil)
+		} else {
+			err = server.adminJSON("create mock", http.MethodPost, "/__mockforge/api/mocks", mock, nil)
+			created = err == nil
+		}
+		if err != nil {
+			t.Fatalf("Failed to stub generated response: %v", err)
+		}
+
+		return runProperty(func(pt PropertyT) { property(pt, value) })
+	}
+
+	for i := 0; i < config.Iterations; i++ {
+		value := gen.Generate(rng, i)
+		run := check(value)
+		if !run.failed {
+			continue
+		}
+
+		shrinks := 0
+	shrinking:
+		for shrinks < config.MaxShrinks {
+			for _, candidate := range gen.Shrink(value) {
+				shrinks++
+				if candidateRun := check(candidate); candidateRun.failed {
+					value, run = candidate, candidateRun
+					continue shrinking
+				}
+				if shrinks >= config.MaxShrinks {
+					break
+				}
+			}
+			break
+		}
+
+		body, _ := json.Marshal(value)
+		t.Fatalf("Property failed after %d iterations and %d shrinks (seed %d)\nminimal response: %s\n%s",
+			i+1, shrinks, config.Seed, body, strings.Join(run.messages, "\n"))
+	}
+}
+
+// propertyRun records the outcome of one property evaluation
+type propertyRun struct {
+	failed   bool
+	messages []string
+}
+
+func (r *propertyRun) Helper() {}
+
+func (r *propertyRun) Logf(format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *propertyRun) Errorf(format string, args ...interface{}) {
+	r.failed = true
+	r.Logf(format, args...)
+}
+
+func (r *propertyRun) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// runProperty evaluates property on its own goroutine so Fatalf can stop it
+func runProperty(property func(PropertyT)) *propertyRun {
+	run := &propertyRun{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				run.Errorf("panic: %v", r)
+			}
+		}()
+		property(run)
+	}()
+	<-done
+	return run
+}
+
+// shrinkAs shrinks value structurally and converts the candidates back to T
+func shrinkAs[T any](value T) []T {
+	v := reflect.ValueOf(&value).Elem()
+	var candidates []T
+	for _, candidate := range shrinkValue(v) {
+		candidates = append(candidates, candidate.Interface().(T))
+	}
+	return candidates
+}
+
+// shrinkValue proposes simpler values of v's type, most aggressive first
+func shrinkValue(v reflect.Value) []reflect.Value {
+	t := v.Type()
+	var out []reflect.Value
+	add := func(value interface{}) {
+		out = append(out, reflect.ValueOf(value).Convert(t))
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			add(false)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n != 0 {
+			add(int64(0))
+			if n/2 != 0 {
+				add(n / 2)
+			}
+			if n < 0 {
+				add(-n)
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n := v.Uint(); n != 0 {
+			add(uint64(0))
+			if n/2 != 0 {
+				add(n / 2)
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); f != 0 {
+			add(0.0)
+			if f != float64(int64(f)) {
+				add(float64(int64(f)))
+			}
+			add(f / 2)
+		}
+	case reflect.String:
+		if s := v.String(); s != "" {
+			runes := []rune(s)
+			add("")
+			if len(runes) > 1 {
+				add(string(runes[:len(runes)/2]))
+				add(string(runes[:len(runes)-1]))
+			}
+		}
+	case reflect.Slice:
+		if n := v.Len(); n > 0 {
+			out = append(out, reflect.MakeSlice(t, 0, 0))
+			if n > 1 {
+				out = append(out, v.Slice(0, n/2))
+			}
+			for i := 0; i < n; i++ {
+				without := reflect.AppendSlice(reflect.MakeSlice(t, 0, n-1), v.Slice(0, i))
+				out = append(out, reflect.AppendSlice(without, v.Slice(i+1, n)))
+			}
+			for i := 0; i < n; i++ {
+				for _, elem := range shrinkValue(v.Index(i)) {
+					shrunk := reflect.AppendSlice(reflect.MakeSlice(t, 0, n), v)
+					shrunk.Index(i).Set(elem)
+					out = append(out, shrunk)
+				}
+			}
+		}
+	case reflect.Map:
+		if v.Len() > 0 {
+			out = append(out, reflect.MakeMap(t))
+			keys := v.MapKeys()
+			for i := range keys {
+				without := reflect.MakeMap(t)
+				for j, other := range keys {
+					if j != i {
+						without.SetMapIndex(other, v.MapIndex(other))
+					}
+				}
+				out = append(out, without)
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			out = append(out, reflect.Zero(t))
+			for _, elem := range shrinkValue(v.Elem()) {
+				ptr := reflect.New(t.Elem())
+				ptr.Elem().Set(elem)
+				out = append(out, ptr)
+			}
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			for _, elem := range shrinkValue(v.Elem()) {
+				boxed := reflect.New(t).Elem()
+				boxed.Set(elem)
+				out = append(out, boxed)
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			for _, field := range shrinkValue(v.Field(i)) {
+				shrunk := reflect.New(t).Elem()
+				shrunk.Set(v)
+				shrunk.Field(i).Set(field)
+				out = append(out, shrunk)
+			}
+		}
+	}
+
+	return out
+}
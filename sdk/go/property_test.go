@@ -0,0 +1,76 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// recordingTB captures the failure of a helper under test
+type recordingTB struct {
+	testing.TB
+	failure string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Fatalf(format string, args ...interface{}) {
+	r.failure = fmt.Sprintf(format, args...)
+}
+
+func TestForAllResponses(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Price int    `json:"price"`
+	}
+
+	var current []item
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var mock struct {
+			Response struct {
+				Body []item `json:"body"`
+			} `json:"response"`
+		}
+		json.NewDecoder(r.Body).Decode(&mock)
+		current = mock.Response.Body
+	}))
+
+	gen := GeneratorFunc[[]item](func(rng *rand.Rand, size int) []item {
+		items := make([]item, rng.Intn(size+1))
+		for i := range items {
+			items[i] = item{Name: strings.Repeat("x", rng.Intn(5)), Price: rng.Intn(200) - 50}
+		}
+		return items
+	})
+
+	t.Run("passes when the property holds", func(t *testing.T) {
+		ForAllResponsesWith(t, server, NewStubBuilder("GET", "/items"), gen, PropertyConfig{Iterations: 20, Seed: 1},
+			func(pt PropertyT, resp []item) {
+				if len(current) != len(resp) {
+					pt.Fatalf("Expected stubbed body to match generated response")
+				}
+			})
+	})
+
+	t.Run("shrinks failing responses", func(t *testing.T) {
+		recorder := &recordingTB{TB: t}
+		ForAllResponsesWith(recorder, server, NewStubBuilder("GET", "/items"), gen, PropertyConfig{Iterations: 50, Seed: 1},
+			func(pt PropertyT, resp []item) {
+				for _, it := range current {
+					if it.Price < 0 {
+						pt.Errorf("negative price %d", it.Price)
+					}
+				}
+			})
+
+		if !strings.Contains(recorder.failure, `minimal response: [{"name":"","price":-1}]`) {
+			t.Errorf("Expected minimal counterexample, got %q", recorder.failure)
+		}
+	})
+}
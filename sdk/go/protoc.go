@@ -0,0 +1,105 @@
+package mockforge
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompileProtos compiles .proto files with protoc into the FileDescriptorSet
+// RegisterProtoDescriptors expects, imports included. A directory compiles
+// every .proto file below it and is an import root, so imports such as
+// "shop/v1/common.proto" resolve against it; a file's own directory is an
+// import root too. Set PROTOC to use a protoc other than the one on PATH.
+func CompileProtos(paths ...string) ([]byte, error) {
+	if len(paths) == 0 {
+		return nil, NewInvalidConfigError("CompileProtos requires at least one .proto file or directory", nil)
+	}
+	command := "protoc"
+	if env := os.Getenv("PROTOC"); env != "" {
+		command = env
+	}
+	if _, err := exec.LookPath(command); err != nil {
+		return nil, NewProtocNotFoundError(err)
+	}
+
+	var roots, fileRoots, files []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, NewInvalidConfigError(fmt.Sprintf("failed to read proto path: %v", err), map[string]interface{}{"path": path})
+		}
+		if !info.IsDir() {
+			fileRoots = appendUnique(fileRoots, filepath.Dir(abs))
+			files = appendUnique(files, abs)
+			continue
+		}
+		roots = appendUnique(roots, abs)
+		found := 0
+		err = filepath.WalkDir(abs, func(file string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.HasSuffix(file, ".proto") {
+				files = appendUnique(files, file)
+				found++
+			}
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", path, err)
+		}
+		if found == 0 {
+			return nil, NewInvalidConfigError("directory contains no .proto files", map[string]interface{}{"path": path})
+		}
+	}
+	sort.Strings(files)
+
+	out, err := os.CreateTemp("", "mockforge-*.pb")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create descriptor set file: %w", err)
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	// protoc names each file after the first root that contains it, so
+	// directory roots come before the files' own directories
+	args := []string{"--include_imports", "--descriptor_set_out=" + out.Name()}
+	for _, root := range append(roots, fileRoots...) {
+		args = append(args, "--proto_path="+root)
+	}
+	args = append(args, files...)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, NewInvalidConfigError(fmt.Sprintf("protoc failed: %s", message), map[string]interface{}{"paths": paths})
+	}
+
+	descriptorSet, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	return descriptorSet, nil
+}
+
+// appendUnique appends value unless values already holds it
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,82 @@
+package mockforge
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeProtoc installs a protoc that records its arguments and writes
+// testDescriptorSet, or fails with stderr when fail is set
+func fakeProtoc(t *testing.T, fail bool) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake protoc is a shell script")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "set.pb"), testDescriptorSet(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\n"
+	if fail {
+		script += "echo 'orders.proto:3:1: Expected \";\".' >&2\nexit 1\n"
+	} else {
+		script += "for arg; do case $arg in --descriptor_set_out=*) cp " + filepath.Join(dir, "set.pb") + " \"${arg#*=}\";; esac; done\n"
+	}
+	protoc := filepath.Join(dir, "protoc")
+	if err := os.WriteFile(protoc, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROTOC", protoc)
+	return dir
+}
+
+func TestCompileProtos(t *testing.T) {
+	dir := fakeProtoc(t, false)
+	protos := t.TempDir()
+	for _, name := range []string{"shop/v1/orders.proto", "shop/v1/common.proto", "README.md"} {
+		path := filepath.Join(protos, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte("syntax = \"proto3\";\n"), 0o644)
+	}
+
+	descriptors, err := CompileProtos(protos)
+	if err != nil {
+		t.Fatalf("Failed to compile protos: %v", err)
+	}
+	if !bytes.Equal(descriptors, testDescriptorSet()) {
+		t.Errorf("Expected protoc's descriptor set, got %d bytes", len(descriptors))
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	for _, want := range []string{"--include_imports", "--proto_path=" + protos, filepath.Join(protos, "shop/v1/common.proto"), filepath.Join(protos, "shop/v1/orders.proto")} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("Expected protoc arguments to contain %q, got %q", want, args)
+		}
+	}
+	if strings.Contains(string(args), "README.md") {
+		t.Errorf("Expected only .proto files, got %q", args)
+	}
+}
+
+func TestCompileProtosErrors(t *testing.T) {
+	if _, err := CompileProtos(); err == nil {
+		t.Error("Expected error without paths")
+	}
+
+	t.Setenv("PROTOC", filepath.Join(t.TempDir(), "missing-protoc"))
+	var serverErr *MockServerError
+	if _, err := CompileProtos("orders.proto"); !errors.As(err, &serverErr) || serverErr.Code != ErrorCodeCLINotFound {
+		t.Errorf("Expected CLI_NOT_FOUND error, got %v", err)
+	}
+
+	fakeProtoc(t, true)
+	proto := filepath.Join(t.TempDir(), "orders.proto")
+	os.WriteFile(proto, []byte("syntax = \"proto3\"\n"), 0o644)
+	if _, err := CompileProtos(proto); err == nil || !strings.Contains(err.Error(), `orders.proto:3:1: Expected ";".`) {
+		t.Errorf("Expected protoc's diagnostics in error, got %v", err)
+	}
+}
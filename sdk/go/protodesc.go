@@ -0,0 +1,169 @@
+package mockforge
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// grpcMethod is an RPC declared in a registered descriptor set
+type grpcMethod struct {
+	Service         string // fully qualified, e.g. "shop.v1.Orders"
+	Name            string
+	InputType       string // fully qualified, without the leading dot
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// FullName returns the method as "pkg.Service/Method"
+func (m grpcMethod) FullName() string {
+	return m.Service + "/" + m.Name
+}
+
+// errTruncatedProto is returned for descriptor sets that end mid-field
+var errTruncatedProto = errors.New("truncated protobuf message")
+
+// protoField is one field of a protobuf message in wire format
+type protoField struct {
+	number int
+	varint uint64
+	bytes  []byte // set for length-delimited fields
+}
+
+// parseProtoFields splits a protobuf message into its fields. Only the
+// wire types descriptors use are kept; fixed-width fields are skipped.
+func parseProtoFields(data []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(data) > 0 {
+		key, n := protoVarint(data)
+		if n == 0 {
+			return nil, errTruncatedProto
+		}
+		data = data[n:]
+		field := protoField{number: int(key >> 3)}
+
+		switch key & 7 {
+		case 0: // varint
+			value, n := protoVarint(data)
+			if n == 0 {
+				return nil, errTruncatedProto
+			}
+			field.varint = value
+			data = data[n:]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return nil, errTruncatedProto
+			}
+			data = data[8:]
+		case 2: // length-delimited
+			length, n := protoVarint(data)
+			if n == 0 || uint64(len(data)-n) < length {
+				return nil, errTruncatedProto
+			}
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return nil, errTruncatedProto
+			}
+			data = data[4:]
+		default:
+			return nil, fmt.Errorf("unsupported protobuf wire type %d", key&7)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// protoVarint decodes a base-128 varint, returning the number of bytes
+// read, or 0 if data ends first
+func protoVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		value |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i] < 0x80 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseDescriptorSet lists the RPCs of a serialized FileDescriptorSet, as
+// written by protoc --descriptor_set_out or buf build
+func parseDescriptorSet(data []byte) ([]grpcMethod, error) {
+	// FileDescriptorSet.file = 1
+	files, err := parseProtoFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []grpcMethod
+	for _, file := range files {
+		if file.number != 1 || file.bytes == nil {
+			continue
+		}
+		// FileDescriptorProto.package = 2, .service = 6
+		fileFields, err := parseProtoFields(file.bytes)
+		if err != nil {
+			return nil, err
+		}
+		var pkg string
+		var services [][]byte
+		for _, field := range fileFields {
+			switch field.number {
+			case 2:
+				pkg = string(field.bytes)
+			case 6:
+				services = append(services, field.bytes)
+			}
+		}
+
+		for _, service := range services {
+			// ServiceDescriptorProto.name = 1, .method = 2
+			serviceFields, err := parseProtoFields(service)
+			if err != nil {
+				return nil, err
+			}
+			var name string
+			var rpcs [][]byte
+			for _, field := range serviceFields {
+				switch field.number {
+				case 1:
+					name = string(field.bytes)
+				case 2:
+					rpcs = append(rpcs, field.bytes)
+				}
+			}
+			if pkg != "" {
+				name = pkg + "." + name
+			}
+
+			for _, rpc := range rpcs {
+				// MethodDescriptorProto.name = 1, .input_type = 2,
+				// .output_type = 3, .client_streaming = 5, .server_streaming = 6
+				rpcFields, err := parseProtoFields(rpc)
+				if err != nil {
+					return nil, err
+				}
+				method := grpcMethod{Service: name}
+				for _, field := range rpcFields {
+					switch field.number {
+					case 1:
+						method.Name = string(field.bytes)
+					case 2:
+						method.InputType = strings.TrimPrefix(string(field.bytes), ".")
+					case 3:
+						method.OutputType = strings.TrimPrefix(string(field.bytes), ".")
+					case 5:
+						method.ClientStreaming = field.varint != 0
+					case 6:
+						method.ServerStreaming = field.varint != 0
+					}
+				}
+				methods = append(methods, method)
+			}
+		}
+	}
+	return methods, nil
+}
@@ -0,0 +1,47 @@
+package mockforge
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// ProxyConfig forwards a stub's requests to a real backend
+type ProxyConfig struct {
+	// UpstreamURL is the base URL requests are forwarded to
+	UpstreamURL string `json:"upstream_url"`
+	// Record saves each upstream response as a stub for the request's method
+	// and path, so a live API can be mocked incrementally
+	Record bool `json:"record,omitempty"`
+}
+
+// PassthroughUnmatched forwards every request no stub matches to upstream,
+// like WithFallback(FallbackProxy(upstream)). With record set, each upstream
+// response is saved as a new stub for the request's method and path, so the
+// next such request is served without the upstream.
+func (m *MockServer) PassthroughUnmatched(upstream string, record bool) error {
+	if err := validateUpstream(upstream); err != nil {
+		return err
+	}
+
+	return m.adminJSON("enable passthrough", http.MethodPut, "/__mockforge/api/fallbacks", map[string]interface{}{
+		"prefix":       "/",
+		"strategy":     "proxy",
+		"upstream_url": upstream,
+		"record":       record,
+	}, nil)
+}
+
+// DisablePassthrough stops forwarding unmatched requests by removing the
+// fallback of the root prefix. Fallbacks of narrower prefixes are kept.
+func (m *MockServer) DisablePassthrough() error {
+	return m.adminJSON("disable passthrough", http.MethodDelete, "/__mockforge/api/fallbacks?prefix=%2F", nil, nil)
+}
+
+// validateUpstream checks upstream is an absolute http(s) URL
+func validateUpstream(upstream string) error {
+	u, err := url.Parse(upstream)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewInvalidConfigError("upstream must be an absolute http or https URL", map[string]interface{}{"upstream": upstream})
+	}
+	return nil
+}
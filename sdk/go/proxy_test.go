@@ -0,0 +1,53 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPassthroughUnmatched(t *testing.T) {
+	var requests []string
+	var fallback map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.Method == http.MethodPut {
+			json.NewDecoder(r.Body).Decode(&fallback)
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	if err := server.PassthroughUnmatched("https://api.example.com", true); err != nil {
+		t.Fatalf("Failed to enable passthrough: %v", err)
+	}
+	want := map[string]interface{}{"prefix": "/", "strategy": "proxy", "upstream_url": "https://api.example.com", "record": true}
+	if !reflect.DeepEqual(fallback, want) {
+		t.Errorf("Expected a recording proxy fallback, got %+v", fallback)
+	}
+	if err := server.DisablePassthrough(); err != nil {
+		t.Fatalf("Failed to disable passthrough: %v", err)
+	}
+	if len(requests) != 2 || requests[1] != "DELETE /__mockforge/api/fallbacks?prefix=%2F" {
+		t.Errorf("Expected the root fallback removed, got %v", requests)
+	}
+
+	if err := server.PassthroughUnmatched("api.example.com", false); err == nil {
+		t.Error("Expected error for relative upstream")
+	}
+}
+
+func TestStubBuilderProxyTo(t *testing.T) {
+	config := NewStubBuilder("GET", "/users/{id}").
+		ProxyTo("https://api.example.com").
+		RecordProxied().
+		Build().
+		mockConfig()
+
+	proxy, ok := config["proxy"].(*ProxyConfig)
+	if !ok || proxy.UpstreamURL != "https://api.example.com" || !proxy.Record {
+		t.Errorf("Expected recording proxy, got %v", config["proxy"])
+	}
+}
@@ -0,0 +1,241 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FCM HTTP v1 and APNs provider API paths served by the push preset
+const (
+	fcmSendPath    = "/v1/projects/{project}/messages:send"
+	fcmSendPattern = "/v1/projects/*/messages:send" // verification wildcard form
+	apnsDevicePath = "/3/device/"
+)
+
+// Push preset stub priorities: per-device overrides beat the authenticated
+// success stubs, which beat the unauthenticated fallbacks
+const (
+	pushPriorityFallback = 1
+	pushPrioritySuccess  = 10
+	pushPriorityDevice   = 100
+)
+
+// PushFailure is a per-device failure served by the push preset
+type PushFailure string
+
+const (
+	// PushUnregistered simulates an uninstalled app: FCM UNREGISTERED (404),
+	// APNs Unregistered (410)
+	PushUnregistered PushFailure = "unregistered"
+	// PushInvalidToken simulates a malformed token: FCM INVALID_ARGUMENT (400),
+	// APNs BadDeviceToken (400)
+	PushInvalidToken PushFailure = "invalid_token"
+	// PushThrottled simulates per-device rate limiting: FCM QUOTA_EXCEEDED
+	// (429), APNs TooManyRequests (429), both with Retry-After
+	PushThrottled PushFailure = "throttled"
+	// PushUnavailable simulates a provider outage: FCM UNAVAILABLE (503),
+	// APNs ServiceUnavailable (503)
+	PushUnavailable PushFailure = "unavailable"
+)
+
+// pushFailureResponse describes how a failure is rendered by each provider
+type pushFailureResponse struct {
+	fcmStatusCode  int
+	fcmStatus      string
+	fcmCode        string
+	apnsStatusCode int
+	apnsReason     string
+}
+
+var pushFailureResponses = map[PushFailure]pushFailureResponse{
+	PushUnregistered: {http.StatusNotFound, "NOT_FOUND", "UNREGISTERED", http.StatusGone, "Unregistered"},
+	PushInvalidToken: {http.StatusBadRequest, "INVALID_ARGUMENT", "INVALID_ARGUMENT", http.StatusBadRequest, "BadDeviceToken"},
+	PushThrottled:    {http.StatusTooManyRequests, "RESOURCE_EXHAUSTED", "QUOTA_EXCEEDED", http.StatusTooManyRequests, "TooManyRequests"},
+	PushUnavailable:  {http.StatusServiceUnavailable, "UNAVAILABLE", "UNAVAILABLE", http.StatusServiceUnavailable, "ServiceUnavailable"},
+}
+
+// PushMock mocks the FCM HTTP v1 and APNs provider APIs on the mock server.
+//
+// Every send with a bearer token succeeds unless the device was configured to
+// fail with FailDevice. Point the FCM and APNs clients' base URLs at the mock
+// server's URL().
+type PushMock struct {
+	server *MockServer
+
+	mu       sync.Mutex
+	failures map[string]PushFailure
+	// failureStubs holds the IDs of each failing device's stubs, which are
+	// replaced when the device is configured to fail differently
+	failureStubs map[string][]string
+}
+
+// Push returns the push notification preset, registering its stubs on first
+// use. The server must be started.
+func (m *MockServer) Push() (*PushMock, error) {
+	m.mu.Lock()
+	existing := m.push
+	m.mu.Unlock()
+
+	if existing != nil {
+		return existing, nil
+	}
+
+	push := &PushMock{
+		server:       m,
+		failures:     make(map[string]PushFailure),
+		failureStubs: make(map[string][]string),
+	}
+
+	stubs := []ResponseStub{
+		NewStubBuilder("POST", fcmSendPath).
+			WhenHeaderMatches("Authorization", "^Bearer .+").
+			Priority(pushPrioritySuccess).
+			Body(map[string]interface{}{"name": "projects/mockforge/messages/0:mockforge"}).
+			Build(),
+		NewStubBuilder("POST", fcmSendPath).
+			Priority(pushPriorityFallback).
+			Status(http.StatusUnauthorized).
+			Body(fcmError(http.StatusUnauthorized, "UNAUTHENTICATED", "THIRD_PARTY_AUTH_ERROR")).
+			Build(),
+		NewStubBuilder("POST", apnsDevicePath+"{token}").
+			WhenHeaderMatches("Authorization", "(?i)^bearer .+").
+			Priority(pushPrioritySuccess).
+			Header("apns-id", "00000000-0000-0000-0000-000000000000").
+			Build(),
+		NewStubBuilder("POST", apnsDevicePath+"{token}").
+			Priority(pushPriorityFallback).
+			Status(http.StatusForbidden).
+			Body(map[string]interface{}{"reason": "MissingProviderToken"}).
+			Build(),
+	}
+	for _, stub := range stubs {
+		if err := m.AddStub(stub); err != nil {
+			return nil, fmt.Errorf("failed to register push stubs: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.push == nil {
+		m.push = push
+	}
+	return m.push, nil
+}
+
+// FailDevice makes every FCM and APNs send to deviceToken fail with failure,
+// replacing any failure the device was configured with before
+func (p *PushMock) FailDevice(deviceToken string, failure PushFailure) error {
+	rendered, ok := pushFailureResponses[failure]
+	if !ok {
+		return NewInvalidConfigError(fmt.Sprintf("unknown push failure %q", failure), nil)
+	}
+
+	fcm := NewStubBuilder("POST", fcmSendPath).
+		WhenJSONPath("$.message.token", deviceToken).
+		Priority(pushPriorityDevice).
+		Status(rendered.fcmStatusCode).
+		Body(fcmError(rendered.fcmStatusCode, rendered.fcmStatus, rendered.fcmCode))
+
+	apnsBody := map[string]interface{}{"reason": rendered.apnsReason}
+	if failure == PushUnregistered {
+		// APNs reports when the token stopped being valid
+		apnsBody["timestamp"] = time.Now().UnixMilli()
+	}
+	apns := NewStubBuilder("POST", apnsDevicePath+deviceToken).
+		Priority(pushPriorityDevice).
+		Status(rendered.apnsStatusCode).
+		Body(apnsBody)
+
+	if failure == PushThrottled {
+		fcm.Header("Retry-After", "60")
+		apns.Header("Retry-After", "60")
+	}
+
+	p.mu.Lock()
+	previous := p.failureStubs[deviceToken]
+	p.mu.Unlock()
+	for _, id := range previous {
+		if err := p.server.DeleteStub(id); err != nil {
+			return err
+		}
+	}
+
+	ids := make([]string, 0, 2)
+	for _, builder := range []*StubBuilder{fcm, apns} {
+		id, err := p.server.CreateStub(builder.Build())
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	p.mu.Lock()
+	p.failures[deviceToken] = failure
+	p.failureStubs[deviceToken] = ids
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Attempts returns how many FCM and APNs sends targeted deviceToken,
+// including failed ones
+func (p *PushMock) Attempts(deviceToken string) (int, error) {
+	fcm, err := p.server.CountRequests(VerificationRequest{
+		Method:      "POST",
+		Path:        fcmSendPattern,
+		BodyPattern: regexp.QuoteMeta(strconv.Quote(deviceToken)),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	apns, err := p.server.CountRequests(VerificationRequest{
+		Method: "POST",
+		Path:   apnsDevicePath + deviceToken,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return fcm + apns, nil
+}
+
+// Delivered reports whether a notification was successfully delivered to
+// deviceToken, i.e. it was sent at least once and the device is not
+// configured to fail
+func (p *PushMock) Delivered(deviceToken string) (bool, error) {
+	p.mu.Lock()
+	_, failing := p.failures[deviceToken]
+	p.mu.Unlock()
+
+	if failing {
+		return false, nil
+	}
+
+	attempts, err := p.Attempts(deviceToken)
+	if err != nil {
+		return false, err
+	}
+	return attempts > 0, nil
+}
+
+// fcmError renders an FCM HTTP v1 error envelope
+func fcmError(code int, status, errorCode string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": fmt.Sprintf("mocked %s", errorCode),
+			"status":  status,
+			"details": []interface{}{
+				map[string]interface{}{
+					"@type":     "type.googleapis.com/google.firebase.fcm.v1.FcmError",
+					"errorCode": errorCode,
+				},
+			},
+		},
+	}
+}
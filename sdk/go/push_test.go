@@ -0,0 +1,77 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPushFailDevice(t *testing.T) {
+	var mu sync.Mutex
+	var mocks []map[string]interface{}
+	var deleted []string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/api/verification/count":
+			w.Write([]byte(`{"count":1}`))
+		case r.Method == http.MethodPost:
+			var mock map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&mock)
+			mock["id"] = fmt.Sprintf("mock-%d", len(mocks)+1)
+			mocks = append(mocks, mock)
+			json.NewEncoder(w).Encode(mock)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/__mockforge/api/mocks/"))
+		}
+	}))
+
+	push, err := server.Push()
+	if err != nil {
+		t.Fatalf("Failed to set up push preset: %v", err)
+	}
+	if len(mocks) != 4 {
+		t.Fatalf("Expected 4 preset stubs, got %d", len(mocks))
+	}
+
+	if err := push.FailDevice("tok-1", PushUnregistered); err != nil {
+		t.Fatalf("Failed to fail device: %v", err)
+	}
+	fcm, apns := mocks[4], mocks[5]
+	match := fcm["request_match"].(map[string]interface{})
+	if match["json_paths"].(map[string]interface{})["$.message.token"] != "tok-1" || fcm["priority"] != float64(pushPriorityDevice) || fcm["status_code"] != float64(404) {
+		t.Errorf("Expected an FCM UNREGISTERED stub for the device, got %v", fcm)
+	}
+	if apns["path"] != "/3/device/tok-1" || apns["status_code"] != float64(410) {
+		t.Errorf("Expected an APNs Unregistered stub for the device, got %v", apns)
+	}
+
+	if err := push.FailDevice("tok-1", PushThrottled); err != nil {
+		t.Fatalf("Failed to fail device again: %v", err)
+	}
+	if strings.Join(deleted, ",") != "mock-5,mock-6" {
+		t.Errorf("Expected the earlier failure stubs to be replaced, deleted %v", deleted)
+	}
+	headers := mocks[6]["response"].(map[string]interface{})["headers"].(map[string]interface{})
+	if mocks[6]["status_code"] != float64(429) || headers["Retry-After"] != "60" {
+		t.Errorf("Expected a throttled FCM stub, got %v", mocks[6])
+	}
+
+	if err := push.FailDevice("tok-1", PushFailure("bogus")); err == nil {
+		t.Error("Expected error for unknown failure")
+	}
+
+	if delivered, err := push.Delivered("tok-1"); err != nil || delivered {
+		t.Errorf("Expected failing device not to be delivered, got %v, %v", delivered, err)
+	}
+	if delivered, err := push.Delivered("tok-2"); err != nil || !delivered {
+		t.Errorf("Expected healthy device to be delivered, got %v, %v", delivered, err)
+	}
+	if attempts, err := push.Attempts("tok-2"); err != nil || attempts != 2 {
+		t.Errorf("Expected FCM and APNs attempts to be summed, got %d, %v", attempts, err)
+	}
+}
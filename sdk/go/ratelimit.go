@@ -0,0 +1,36 @@
+package mockforge
+
+import "time"
+
+// RateLimit limits how often a stub answers normally. Once Limit requests
+// have matched within the current Window, further requests get 429 Too Many
+// Requests with Retry-After and X-RateLimit-Limit, X-RateLimit-Remaining,
+// and X-RateLimit-Reset headers until the window ends. Counts are kept by
+// the server.
+type RateLimit struct {
+	Limit  int           `json:"limit"`
+	Window time.Duration `json:"window"`
+}
+
+// validate checks the limit and window are positive
+func (r *RateLimit) validate() error {
+	if r.Limit <= 0 || r.Window <= 0 {
+		return NewInvalidConfigError("rate limit and window must be positive", map[string]interface{}{
+			"limit":  r.Limit,
+			"window": r.Window.String(),
+		})
+	}
+	return nil
+}
+
+// mockConfig converts the limit to the admin API's rate_limit block
+func (r *RateLimit) mockConfig() map[string]interface{} {
+	return map[string]interface{}{"limit": r.Limit, "window_ms": r.Window.Milliseconds()}
+}
+
+// RateLimited serves normal responses to the first n requests in each
+// window of length per, and 429 with rate limit headers after that
+func (b *StubBuilder) RateLimited(n int, per time.Duration) *StubBuilder {
+	b.rateLimit = &RateLimit{Limit: n, Window: per}
+	return b
+}
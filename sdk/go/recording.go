@@ -0,0 +1,493 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// recordRedactedHeaders carry credentials and are always redacted
+var recordRedactedHeaders = []string{"authorization", "proxy-authorization", "cookie"}
+
+// redactedValue replaces the values of redacted headers
+const redactedValue = "REDACTED"
+
+// RecordOptions configures StartRecording
+type RecordOptions struct {
+	// Upstream is the base URL of the real service, e.g.
+	// "https://api.example.com"
+	Upstream string
+	// PathFilters limits recording to paths a path.Match glob matches, or
+	// below a path one matches, so "/api/*" records "/api/orders/42";
+	// requests outside the filters still reach the upstream. By default
+	// every request is recorded.
+	PathFilters []string
+	// RedactHeaders are request and response headers whose values are
+	// replaced with "REDACTED" in the fixtures, on top of Authorization,
+	// Proxy-Authorization, and Cookie
+	RedactHeaders []string
+}
+
+// recordedFixture is the content of a recorded fixture, in the shape the
+// server's own recorder writes
+type recordedFixture struct {
+	Query                string            `json:"query,omitempty"`
+	RequestHeaders       map[string]string `json:"request_headers,omitempty"`
+	StatusCode           int               `json:"status_code"`
+	ResponseHeaders      map[string]string `json:"response_headers"`
+	ResponseBody         string            `json:"response_body"`
+	ResponseBodyEncoding string            `json:"response_body_encoding,omitempty"`
+}
+
+// recordedExchange is a request the recorder forwarded and the response the
+// upstream gave
+type recordedExchange struct {
+	method  string
+	path    string
+	fixture recordedFixture
+}
+
+// fixtureRecorder forwards the requests the server passes through to the
+// upstream and records the exchanges
+type fixtureRecorder struct {
+	sidecar  *httpSidecar
+	upstream *url.URL
+	filters  []string
+	redacted map[string]bool
+
+	mu        sync.Mutex
+	exchanges []recordedExchange
+	index     map[string]int // Exchange by method, path, and query
+}
+
+// StartRecording runs the test against a real service: requests no stub
+// matches are forwarded to opts.Upstream, and the exchanges are kept until
+// StopRecording saves them as fixtures. Record once, commit the fixtures,
+// and replay them in later runs without the upstream.
+//
+//	err := server.StartRecording(mockforge.RecordOptions{
+//	    Upstream:      "https://api.example.com",
+//	    PathFilters:   []string{"/v1/*"},
+//	    RedactHeaders: []string{"X-Api-Key"},
+//	})
+//	// ... exercise the client against server.URL() ...
+//	fixtures, err := server.StopRecording()
+func (m *MockServer) StartRecording(opts RecordOptions) error {
+	if err := validateUpstream(opts.Upstream); err != nil {
+		return err
+	}
+	upstream, _ := url.Parse(opts.Upstream)
+	for _, filter := range opts.PathFilters {
+		if _, err := path.Match(filter, ""); err != nil {
+			return NewInvalidConfigError("invalid recording path filter", map[string]interface{}{"filter": filter})
+		}
+	}
+
+	recorder := &fixtureRecorder{
+		upstream: upstream,
+		filters:  opts.PathFilters,
+		redacted: make(map[string]bool),
+		index:    make(map[string]int),
+	}
+	for _, name := range append(append([]string(nil), recordRedactedHeaders...), opts.RedactHeaders...) {
+		recorder.redacted[strings.ToLower(name)] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.recording != nil {
+		return NewInvalidConfigError("recording already started", map[string]interface{}{"upstream": m.recording.upstream.String()})
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = upstream.Host
+		// Fixtures keep bodies as sent, so ask for them uncompressed
+		req.Header.Del("Accept-Encoding")
+	}
+	proxy.ModifyResponse = recorder.record
+	sidecar, err := newHTTPSidecar(m.host, proxy)
+	if err != nil {
+		return fmt.Errorf("failed to start recording proxy: %w", err)
+	}
+	recorder.sidecar = sidecar
+
+	if err := m.PassthroughUnmatched(sidecar.URL(), false); err != nil {
+		sidecar.Close()
+		return err
+	}
+	m.attached = append(m.attached, sidecar)
+	m.recording = recorder
+	return nil
+}
+
+// StopRecording stops forwarding requests to the upstream and saves every
+// recorded exchange as a fixture, replacing a fixture of the same name from
+// an earlier recording. Repeated requests keep the last response. Fixtures
+// are named after the method, path, and query, e.g. "GET_v1_orders_42", and
+// returned in the order first recorded. In dry-run mode nothing is saved
+// and the fixtures are recorded instead.
+func (m *MockServer) StopRecording() ([]FixtureInfo, error) {
+	m.mu.Lock()
+	recorder := m.recording
+	m.recording = nil
+	m.mu.Unlock()
+	if recorder == nil {
+		return nil, NewInvalidConfigError("recording not started", nil)
+	}
+
+	err := m.DisablePassthrough()
+	recorder.sidecar.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	exchanges := recorder.recorded()
+	if len(exchanges) == 0 {
+		return nil, nil
+	}
+	names := make([]string, len(exchanges))
+	for i, exchange := range exchanges {
+		names[i] = exchange.name()
+	}
+	if m.isDryRun() {
+		m.recordDryRun("record fixtures", names)
+		return nil, nil
+	}
+
+	existing, err := m.ListFixtures()
+	if err != nil {
+		return nil, err
+	}
+	replaced := make(map[string]bool, len(names))
+	for _, name := range names {
+		replaced["http/"+name+".json"] = true
+	}
+	for _, fixture := range existing {
+		if replaced[strings.ReplaceAll(fixture.FilePath, "\\", "/")] {
+			if err := m.DeleteFixture(fixture.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	order := make(map[string]int, len(exchanges))
+	for i, exchange := range exchanges {
+		data, err := json.Marshal(exchange.fixture)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode fixture: %w", err)
+		}
+		id, err := m.UploadFixture(FixtureInfo{
+			Protocol: "http",
+			Method:   exchange.method,
+			Path:     exchange.path,
+			Metadata: map[string]interface{}{
+				"name":        names[i],
+				"description": "Recorded from " + recorder.upstream.String(),
+			},
+		}, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		order[id] = i
+	}
+
+	listed, err := m.ListFixtures()
+	if err != nil {
+		return nil, err
+	}
+	fixtures := make([]FixtureInfo, len(exchanges))
+	found := 0
+	for _, fixture := range listed {
+		if i, ok := order[fixture.ID]; ok {
+			fixtures[i] = fixture
+			found++
+		}
+	}
+	if found != len(exchanges) {
+		return nil, NewAdminAPIError("record fixtures", fmt.Sprintf("%d of %d recorded fixtures not listed", len(exchanges)-found, len(exchanges)), nil)
+	}
+	return fixtures, nil
+}
+
+// record is the proxy's ModifyResponse hook; it keeps the exchange if its
+// path passes the filters
+func (r *fixtureRecorder) record(resp *http.Response) error {
+	req := resp.Request
+	requestPath := "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(r.upstream.Path, "/")), "/")
+	if !r.matches(requestPath) {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read upstream response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := recordedFixture{
+		Query:           req.URL.RawQuery,
+		RequestHeaders:  r.headers(req.Header, "x-forwarded-for", "x-forwarded-host", "x-forwarded-proto"),
+		StatusCode:      resp.StatusCode,
+		ResponseHeaders: r.headers(resp.Header, harConnectionHeaders...),
+		ResponseBody:    string(body),
+	}
+	if !utf8.Valid(body) {
+		fixture.ResponseBody = base64.StdEncoding.EncodeToString(body)
+		fixture.ResponseBodyEncoding = "base64"
+	}
+
+	key := req.Method + " " + requestPath + "?" + req.URL.RawQuery
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exchange := recordedExchange{method: req.Method, path: requestPath, fixture: fixture}
+	if i, ok := r.index[key]; ok {
+		r.exchanges[i] = exchange
+	} else {
+		r.index[key] = len(r.exchanges)
+		r.exchanges = append(r.exchanges, exchange)
+	}
+	return nil
+}
+
+// matches reports whether a filter matches requestPath or one of its
+// parents
+func (r *fixtureRecorder) matches(requestPath string) bool {
+	if len(r.filters) == 0 {
+		return true
+	}
+	for p := requestPath; ; p = path.Dir(p) {
+		for _, filter := range r.filters {
+			if matched, _ := path.Match(filter, p); matched {
+				return true
+			}
+		}
+		if p == "/" || p == "." {
+			return false
+		}
+	}
+}
+
+// headers flattens header into a map, leaving out skipped headers and
+// redacting credentials
+func (r *fixtureRecorder) headers(header http.Header, skipped ...string) map[string]string {
+	skip := make(map[string]bool, len(skipped))
+	for _, name := range skipped {
+		skip[name] = true
+	}
+	flattened := make(map[string]string, len(header))
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		switch {
+		case skip[lower]:
+		case r.redacted[lower]:
+			flattened[name] = redactedValue
+		default:
+			flattened[name] = strings.Join(values, ", ")
+		}
+	}
+	return flattened
+}
+
+// recorded returns the recorded exchanges in the order first recorded
+func (r *fixtureRecorder) recorded() []recordedExchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]recordedExchange(nil), r.exchanges...)
+}
+
+// name is the fixture name of the exchange
+func (e recordedExchange) name() string {
+	name := e.method + e.path
+	if e.fixture.Query != "" {
+		name += "_" + e.fixture.Query
+	}
+	return fixtureName(name)
+}
+
+// ReplayFixture registers a stub answering the fixture's request with its
+// response, so recorded traffic becomes the mock's behavior. A recorded
+// fixture replays its status, headers, and body and matches the recorded
+// query; any other fixture answers 200 with its content as the body.
+func (m *MockServer) ReplayFixture(id string) error {
+	data, err := m.DownloadFixture(id)
+	if err != nil {
+		return err
+	}
+	stub, err := fixtureStub(data)
+	if err != nil {
+		return NewInvalidConfigError(fmt.Sprintf("fixture %s: %v", id, err), map[string]interface{}{"fixture": id})
+	}
+	return m.AddStub(stub)
+}
+
+// ReplayAll registers stubs for the HTTP fixtures filter selects in a
+// single admin call, as ReplayFixture does, and returns the fixtures
+// replayed. When fixtures answer the same request, the newest one wins.
+//
+//	// Serve everything recorded under /v1/
+//	replayed, err := server.ReplayAll(mockforge.FixtureFilter{PathPrefix: "/v1/"})
+func (m *MockServer) ReplayAll(filter FixtureFilter) ([]FixtureInfo, error) {
+	fixtures, err := m.ListFixtures()
+	if err != nil {
+		return nil, err
+	}
+	saved := make(map[string]time.Time, len(fixtures))
+	for _, fixture := range fixtures {
+		saved[fixture.ID], _ = time.Parse(time.RFC3339Nano, fixture.SavedAt)
+	}
+	sort.SliceStable(fixtures, func(i, j int) bool {
+		return saved[fixtures[i].ID].After(saved[fixtures[j].ID])
+	})
+
+	now := time.Now()
+	var replayed []FixtureInfo
+	var stubs []ResponseStub
+	seen := make(map[string]bool)
+	for i := range fixtures {
+		fixture := &fixtures[i]
+		if !strings.EqualFold(fixture.Protocol, "http") || !filter.matches(fixture, now) {
+			continue
+		}
+		data, err := m.DownloadFixture(fixture.ID)
+		if err != nil {
+			return nil, err
+		}
+		stub, err := fixtureStub(data)
+		if err != nil {
+			return nil, NewInvalidConfigError(fmt.Sprintf("fixture %s: %v", fixture.ID, err), map[string]interface{}{"fixture": fixture.ID})
+		}
+		key, _ := json.Marshal([]interface{}{stub.Method, stub.Path, stub.Match})
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		replayed = append(replayed, *fixture)
+		stubs = append(stubs, stub)
+	}
+	if len(stubs) == 0 {
+		return nil, nil
+	}
+	if err := m.StubAll(stubs); err != nil {
+		return nil, err
+	}
+	return replayed, nil
+}
+
+// fixtureStub converts a fixture file, as the server or StopRecording saved
+// it, to a stub
+func fixtureStub(data []byte) (ResponseStub, error) {
+	var document struct {
+		fixtureDocument
+		// Files written by the server's own recorder describe the request
+		// with a fingerprint instead
+		Fingerprint *struct {
+			Method string `json:"method"`
+			Path   string `json:"path"`
+			Query  string `json:"query"`
+		} `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return ResponseStub{}, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+	if document.Protocol != "" && !strings.EqualFold(document.Protocol, "http") {
+		return ResponseStub{}, fmt.Errorf("cannot replay a %s fixture as an HTTP stub", document.Protocol)
+	}
+
+	var stub ResponseStub
+	var query string
+	content := json.RawMessage(data)
+	switch {
+	case document.Request != nil && document.Response != nil:
+		stub.Method, stub.Path = document.Request.Method, document.Request.Path
+		content = document.Response
+	case document.Fingerprint != nil:
+		stub.Method, stub.Path, query = document.Fingerprint.Method, document.Fingerprint.Path, document.Fingerprint.Query
+	default:
+		return ResponseStub{}, fmt.Errorf("fixture describes no request")
+	}
+	stub.Method = strings.ToUpper(stub.Method)
+	if stub.Method == "" {
+		stub.Method = http.MethodGet
+	}
+	if stub.Path == "" {
+		stub.Path = "/"
+	}
+
+	var fields map[string]json.RawMessage
+	json.Unmarshal(content, &fields)
+	if _, ok := fields["status_code"]; !ok {
+		stub.Status = http.StatusOK
+		if err := json.Unmarshal(content, &stub.Body); err != nil {
+			return ResponseStub{}, fmt.Errorf("failed to parse fixture response: %w", err)
+		}
+		return stub, nil
+	}
+
+	var recorded recordedFixture
+	if err := json.Unmarshal(content, &recorded); err != nil {
+		return ResponseStub{}, fmt.Errorf("failed to parse recorded response: %w", err)
+	}
+	if recorded.Query != "" {
+		query = recorded.Query
+	}
+	if values, err := url.ParseQuery(query); err == nil && len(values) > 0 {
+		stub.Match = &RequestMatch{QueryParams: make(map[string]string, len(values))}
+		for name, value := range values {
+			stub.Match.QueryParams[name] = value[0]
+		}
+	}
+
+	stub.Status = recorded.StatusCode
+	connection := make(map[string]bool, len(harConnectionHeaders))
+	for _, name := range harConnectionHeaders {
+		connection[name] = true
+	}
+	contentType := ""
+	for name, value := range recorded.ResponseHeaders {
+		lower := strings.ToLower(name)
+		switch {
+		case connection[lower]:
+		case lower == "set-cookie":
+			stub.SetCookies = append(stub.SetCookies, value)
+		default:
+			if stub.Headers == nil {
+				stub.Headers = make(map[string]string)
+			}
+			stub.Headers[name] = value
+			if lower == "content-type" {
+				contentType = value
+			}
+		}
+	}
+
+	switch {
+	case recorded.ResponseBodyEncoding == "base64":
+		body, err := base64.StdEncoding.DecodeString(recorded.ResponseBody)
+		if err != nil {
+			return ResponseStub{}, fmt.Errorf("invalid base64 response body: %w", err)
+		}
+		stub.BodyBytes = body
+	case strings.Contains(contentType, "json"):
+		if err := json.Unmarshal([]byte(recorded.ResponseBody), &stub.Body); err != nil {
+			stub.Body = recorded.ResponseBody
+		}
+	case recorded.ResponseBody != "":
+		stub.Body = recorded.ResponseBody
+	}
+	return stub, nil
+}
@@ -0,0 +1,195 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecording(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "abc")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `","query":"` + r.URL.RawQuery + `"}`))
+	}))
+	defer upstream.Close()
+
+	store := &fixtureStore{}
+	server := newAdminTestServer(t, store)
+	if err := server.StartRecording(RecordOptions{
+		Upstream:      upstream.URL,
+		PathFilters:   []string{"/api/*"},
+		RedactHeaders: []string{"x-request-id"},
+	}); err != nil {
+		t.Fatalf("Failed to start recording: %v", err)
+	}
+	if err := server.StartRecording(RecordOptions{Upstream: upstream.URL}); err == nil {
+		t.Error("Expected error for a second recording")
+	}
+
+	// The server forwards unmatched requests to the recorder
+	proxy := store.passthroughURL()
+	if proxy == "" {
+		t.Fatal("Expected passthrough to the recorder")
+	}
+	for _, path := range []string{"/api/orders/42?expand=items", "/api/orders/42?expand=items", "/health"} {
+		req, _ := http.NewRequest(http.MethodGet, proxy+path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || len(body) == 0 {
+			t.Errorf("Expected the upstream response for %s, got %d %q", path, resp.StatusCode, body)
+		}
+	}
+
+	fixtures, err := server.StopRecording()
+	if err != nil {
+		t.Fatalf("Failed to stop recording: %v", err)
+	}
+	if store.passthroughURL() != "" {
+		t.Error("Expected passthrough disabled after recording")
+	}
+	if len(fixtures) != 1 || fixtures[0].Path != "/api/orders/42" || fixtures[0].FilePath != "http/GET_api_orders_42_expand_items.json" {
+		t.Fatalf("Expected one fixture for the repeated request under /api, got %+v", fixtures)
+	}
+
+	var recorded recordedFixture
+	data, _ := json.Marshal(fixtures[0].Metadata["response"])
+	json.Unmarshal(data, &recorded)
+	if recorded.StatusCode != http.StatusOK || recorded.Query != "expand=items" || recorded.ResponseBody != `{"path":"/api/orders/42","query":"expand=items"}` {
+		t.Errorf("Expected the recorded exchange, got %+v", recorded)
+	}
+	if recorded.RequestHeaders["Authorization"] != redactedValue || recorded.ResponseHeaders["X-Request-Id"] != redactedValue {
+		t.Errorf("Expected credentials and configured headers redacted, got %v and %v", recorded.RequestHeaders, recorded.ResponseHeaders)
+	}
+	if _, ok := recorded.ResponseHeaders["Content-Length"]; ok {
+		t.Errorf("Expected connection headers dropped, got %v", recorded.ResponseHeaders)
+	}
+
+	// Recording again replaces the fixture
+	server.StartRecording(RecordOptions{Upstream: upstream.URL})
+	http.Get(store.passthroughURL() + "/api/orders/42?expand=items")
+	if fixtures, err := server.StopRecording(); err != nil || len(fixtures) != 1 || len(store.fixtures) != 1 {
+		t.Errorf("Expected the fixture replaced, got %+v, %v with %d stored", fixtures, err, len(store.fixtures))
+	}
+
+	if _, err := server.StopRecording(); err == nil {
+		t.Error("Expected error when not recording")
+	}
+}
+
+func TestRecordingFilters(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	if err := server.StartRecording(RecordOptions{Upstream: "api.example.com"}); err == nil {
+		t.Error("Expected error for relative upstream")
+	}
+	if err := server.StartRecording(RecordOptions{Upstream: "https://api.example.com", PathFilters: []string{"/api/["}}); err == nil {
+		t.Error("Expected error for malformed filter")
+	}
+
+	recorder := &fixtureRecorder{filters: []string{"/api/*/orders", "/health"}}
+	for path, want := range map[string]bool{
+		"/api/v1/orders":    true,
+		"/api/v1/orders/42": true,
+		"/api/v1/users":     false,
+		"/health":           true,
+		"/healthz":          false,
+		"/":                 false,
+	} {
+		if got := recorder.matches(path); got != want {
+			t.Errorf("Expected %s recorded: %v, got %v", path, want, got)
+		}
+	}
+}
+
+func TestReplayFixture(t *testing.T) {
+	store := &fixtureStore{}
+	server := newAdminTestServer(t, store)
+	recorded := `{"query":"expand=items","status_code":201,"response_headers":{"Content-Type":"application/json","Content-Length":"9","Set-Cookie":"session=1"},"response_body":"{\"id\":42}"}`
+	id, err := server.UploadFixture(FixtureInfo{Method: "POST", Path: "/orders"}, strings.NewReader(recorded))
+	if err != nil {
+		t.Fatalf("Failed to upload fixture: %v", err)
+	}
+
+	if err := server.ReplayFixture(id); err != nil {
+		t.Fatalf("Failed to replay fixture: %v", err)
+	}
+	stub := server.stubs[0]
+	if stub.Method != "POST" || stub.Path != "/orders" || stub.Status != 201 || stub.Match == nil || stub.Match.QueryParams["expand"] != "items" {
+		t.Errorf("Expected the recorded request and status, got %+v", stub)
+	}
+	if body, ok := stub.Body.(map[string]interface{}); !ok || body["id"] != float64(42) {
+		t.Errorf("Expected the JSON body, got %#v", stub.Body)
+	}
+	if _, ok := stub.Headers["Content-Length"]; ok || stub.Headers["Content-Type"] != "application/json" || len(stub.SetCookies) != 1 {
+		t.Errorf("Expected content headers without connection headers, got %v and %v", stub.Headers, stub.SetCookies)
+	}
+
+	if err := server.ReplayFixture("fixture_missing"); err == nil {
+		t.Error("Expected error for unknown fixture")
+	}
+}
+
+func TestReplayAll(t *testing.T) {
+	store := &fixtureStore{}
+	server := newAdminTestServer(t, store)
+	for _, upload := range []struct {
+		meta FixtureInfo
+		data string
+	}{
+		{FixtureInfo{Method: "GET", Path: "/v1/users", Metadata: map[string]interface{}{"name": "users-old"}}, `[]`},
+		{FixtureInfo{Method: "GET", Path: "/v1/users", Metadata: map[string]interface{}{"name": "users-new"}}, `[{"id":1}]`},
+		{FixtureInfo{Method: "GET", Path: "/v1/health"}, `"ok"`},
+		{FixtureInfo{Method: "GET", Path: "/v2/users"}, `[]`},
+		{FixtureInfo{Protocol: "grpc", Method: "SayHello", Path: "/v1/Greeter"}, `{}`},
+	} {
+		if _, err := server.UploadFixture(upload.meta, strings.NewReader(upload.data)); err != nil {
+			t.Fatalf("Failed to upload fixture: %v", err)
+		}
+	}
+	// Make the second users fixture the newest
+	store.fixtures[0].SavedAt = "2024-01-01T00:00:00Z"
+
+	replayed, err := server.ReplayAll(FixtureFilter{PathPrefix: "/v1/"})
+	if err != nil {
+		t.Fatalf("Failed to replay fixtures: %v", err)
+	}
+	if len(replayed) != 2 || len(server.stubs) != 2 {
+		t.Fatalf("Expected the HTTP fixtures under /v1/ replayed once per request, got %+v", replayed)
+	}
+	for _, stub := range server.stubs {
+		if stub.Path == "/v1/users" {
+			if body, _ := stub.Body.([]interface{}); len(body) != 1 {
+				t.Errorf("Expected the newest users fixture, got %v", stub.Body)
+			}
+		} else if stub.Body != "ok" || stub.Status != 200 {
+			t.Errorf("Expected the health fixture as a 200 body, got %+v", stub)
+		}
+	}
+
+	if replayed, err := server.ReplayAll(FixtureFilter{PathPrefix: "/v3/"}); err != nil || replayed != nil {
+		t.Errorf("Expected nothing to replay, got %v, %v", replayed, err)
+	}
+}
+
+func TestFixtureStubServerRecording(t *testing.T) {
+	// A file written by the server's own recorder
+	stub, err := fixtureStub([]byte(`{"fingerprint":{"method":"get","path":"/search","query":"q=a","headers":{}},"timestamp":"2024-01-01T00:00:00Z","status_code":200,"response_headers":{"content-type":"text/plain"},"response_body":"hits","metadata":{}}`))
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if stub.Method != "GET" || stub.Path != "/search" || stub.Match.QueryParams["q"] != "a" || stub.Body != "hits" {
+		t.Errorf("Expected the fingerprinted request and text body, got %+v", stub)
+	}
+
+	if _, err := fixtureStub([]byte(`{"protocol":"grpc","request":{"method":"SayHello","path":"/Greeter"},"response":{}}`)); err == nil {
+		t.Error("Expected error for a gRPC fixture")
+	}
+}
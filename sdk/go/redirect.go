@@ -0,0 +1,74 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RedirectTo makes the stub answer with a redirect to location. Status must
+// be a redirect status such as 301, 302, 303, 307, or 308.
+func (b *StubBuilder) RedirectTo(location string, status int) *StubBuilder {
+	b.status = status
+	b.headers["Location"] = location
+	return b
+}
+
+// StubRedirect redirects GET requests for path to location
+func (m *MockServer) StubRedirect(path, location string, status int) error {
+	if err := validateRedirectStatus(status); err != nil {
+		return err
+	}
+	return m.AddStub(NewStubBuilder(http.MethodGet, path).RedirectTo(location, status).Build())
+}
+
+// StubRedirectChain redirects each hop to the next and the last hop to final,
+// which is registered too, so clients follow len(hops) redirects before
+// reaching the final response. Hops use final's method.
+func (m *MockServer) StubRedirectChain(status int, final ResponseStub, hops ...string) error {
+	if err := validateRedirectStatus(status); err != nil {
+		return err
+	}
+	if len(hops) == 0 {
+		return NewInvalidConfigError("a redirect chain needs at least one hop", nil)
+	}
+
+	targets := append(hops[1:len(hops):len(hops)], final.Path)
+	for i, hop := range hops {
+		if err := m.AddStub(NewStubBuilder(final.Method, hop).RedirectTo(targets[i], status).Build()); err != nil {
+			return err
+		}
+	}
+	return m.AddStub(final)
+}
+
+// StubRedirectLoop redirects each path to the next and the last back to the
+// first, to exercise redirect loop detection
+func (m *MockServer) StubRedirectLoop(status int, paths ...string) error {
+	if err := validateRedirectStatus(status); err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return NewInvalidConfigError("a redirect loop needs at least one path", nil)
+	}
+
+	for i, path := range paths {
+		next := paths[(i+1)%len(paths)]
+		if err := m.AddStub(NewStubBuilder(http.MethodGet, path).RedirectTo(next, status).Build()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRedirectStatus checks status is a 3xx redirect
+func validateRedirectStatus(status int) error {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return nil
+	default:
+		return NewInvalidConfigError(fmt.Sprintf("%d is not a redirect status", status), map[string]interface{}{
+			"status": status,
+		})
+	}
+}
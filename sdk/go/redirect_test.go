@@ -0,0 +1,42 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestStubRedirectChain(t *testing.T) {
+	var mocks []map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var mock map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&mock)
+		mocks = append(mocks, mock)
+	}))
+
+	final := NewStubBuilder("GET", "/landing").Body("welcome").Build()
+	if err := server.StubRedirectChain(http.StatusFound, final, "/start", "/hop"); err != nil {
+		t.Fatalf("Failed to stub redirect chain: %v", err)
+	}
+
+	expected := []struct{ path, location string }{
+		{"/start", "/hop"},
+		{"/hop", "/landing"},
+	}
+	if len(mocks) != 3 {
+		t.Fatalf("Expected 2 redirects and the final stub, got %d mocks", len(mocks))
+	}
+	for i, want := range expected {
+		headers := mocks[i]["response"].(map[string]interface{})["headers"].(map[string]interface{})
+		if mocks[i]["path"] != want.path || headers["Location"] != want.location || mocks[i]["status_code"] != float64(302) {
+			t.Errorf("Hop %d: expected %s -> %s, got %v", i, want.path, want.location, mocks[i])
+		}
+	}
+	if mocks[2]["path"] != "/landing" {
+		t.Errorf("Expected final stub last, got %v", mocks[2])
+	}
+
+	if err := server.StubRedirect("/old", "/new", http.StatusOK); err == nil {
+		t.Error("Expected error for non-redirect status")
+	}
+}
@@ -0,0 +1,246 @@
+package mockforge
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportFormat is the output format of WriteTrafficReport
+type ReportFormat string
+
+const (
+	// ReportFormatJUnit writes JUnit XML, with one test case per verification
+	ReportFormatJUnit ReportFormat = "junit"
+	// ReportFormatHTML writes a self-contained HTML page
+	ReportFormatHTML ReportFormat = "html"
+)
+
+// verificationRecord is the outcome of a verification, kept for reports
+type verificationRecord struct {
+	name   string
+	result *VerificationResult
+}
+
+// recordVerification keeps the outcome of a verification for reports
+func (m *MockServer) recordVerification(name string, result *VerificationResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifications = append(m.verifications, verificationRecord{name: name, result: result})
+}
+
+// trafficReport is the content of a traffic report
+type trafficReport struct {
+	Generated     time.Time
+	Stubs         []reportStub
+	Requests      []LoggedRequest
+	Unmatched     int
+	Verifications []reportVerification
+	Failures      int
+}
+
+// reportStub summarizes a registered stub
+type reportStub struct {
+	ID     string
+	Method string
+	Path   string
+	Status int
+}
+
+// reportVerification is a verification outcome in a report
+type reportVerification struct {
+	Name    string
+	Passed  bool
+	Count   int
+	Message string
+}
+
+// WriteTrafficReport writes a report of the registered stubs, the request
+// journal with matched and unmatched traffic, and the outcome of every
+// verification made through this MockServer, for attaching to CI
+// artifacts. In JUnit format each verification is a test case; with
+// StrictStubbing, unmatched requests are reported as a failed test case too.
+func (m *MockServer) WriteTrafficReport(w io.Writer, format ReportFormat) error {
+	if format != ReportFormatJUnit && format != ReportFormatHTML {
+		return NewInvalidConfigError("unknown report format", map[string]interface{}{"format": format})
+	}
+
+	report, err := m.trafficReport()
+	if err != nil {
+		return err
+	}
+	if format == ReportFormatJUnit {
+		return writeJUnitReport(w, report, m.config.StrictStubbing)
+	}
+	if err := htmlReportTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// trafficReport gathers the stubs, journal, and verification outcomes
+func (m *MockServer) trafficReport() (*trafficReport, error) {
+	var listed struct {
+		Mocks []mockConfigWire `json:"mocks"`
+	}
+	if err := m.adminJSON("list mocks", http.MethodGet, "/__mockforge/api/mocks", nil, &listed); err != nil {
+		return nil, err
+	}
+
+	all, err := m.verify(VerificationRequest{}, AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	requests, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server lists the most recent request first
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].Timestamp.Before(requests[j].Timestamp)
+	})
+	report := &trafficReport{Generated: time.Now(), Requests: requests}
+	for _, mock := range listed.Mocks {
+		report.Stubs = append(report.Stubs, reportStub{ID: mock.ID, Method: mock.Method, Path: mock.Path, Status: mock.StatusCode})
+	}
+	for _, request := range requests {
+		if request.Metadata["unmatched"] == "true" {
+			report.Unmatched++
+		}
+	}
+
+	m.mu.Lock()
+	records := append([]verificationRecord(nil), m.verifications...)
+	m.mu.Unlock()
+	for _, record := range records {
+		verification := reportVerification{Name: record.name, Passed: record.result.Matched, Count: record.result.Count}
+		if record.result.ErrorMessage != nil {
+			verification.Message = *record.result.ErrorMessage
+		} else if !verification.Passed {
+			verification.Message = fmt.Sprintf("expected %s, got %d", record.result.Expected, record.result.Count)
+		}
+		if !verification.Passed {
+			report.Failures++
+		}
+		report.Verifications = append(report.Verifications, verification)
+	}
+	return report, nil
+}
+
+// JUnit XML elements
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes report as JUnit XML, listing the traffic as the
+// suite's output
+func writeJUnitReport(w io.Writer, report *trafficReport, strict bool) error {
+	suite := junitTestSuite{Name: "mockforge", Timestamp: report.Generated.Format(time.RFC3339)}
+	for _, verification := range report.Verifications {
+		testCase := junitTestCase{Name: verification.Name, ClassName: "mockforge.verification"}
+		if !verification.Passed {
+			testCase.Failure = &junitFailure{Message: verification.Message, Text: verification.Message}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	if strict {
+		testCase := junitTestCase{Name: "no unmatched requests", ClassName: "mockforge.traffic"}
+		if report.Unmatched > 0 {
+			message := fmt.Sprintf("%d request(s) matched no stub", report.Unmatched)
+			testCase.Failure = &junitFailure{Message: message, Text: message}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	for _, testCase := range suite.Cases {
+		suite.Tests++
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%d stub(s), %d request(s), %d unmatched\n", len(report.Stubs), len(report.Requests), report.Unmatched)
+	for _, request := range report.Requests {
+		fmt.Fprintf(&out, "%s %s %s -> %d", request.Timestamp.Format(time.RFC3339Nano), request.Method, request.Path, request.StatusCode)
+		if request.Metadata["unmatched"] == "true" {
+			out.WriteString(" (unmatched)")
+		}
+		out.WriteString("\n")
+	}
+	suite.SystemOut = out.String()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// htmlReportTemplate renders a traffic report as a self-contained page
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>MockForge traffic report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.passed { color: #1a7f37; }
+.failed { color: #cf222e; }
+.unmatched { background: #fff1f0; }
+</style>
+</head>
+<body>
+<h1>MockForge traffic report</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}: {{len .Stubs}} stub(s), {{len .Requests}} request(s), {{.Unmatched}} unmatched, {{len .Verifications}} verification(s), {{.Failures}} failed.</p>
+<h2>Verifications</h2>
+<table>
+<tr><th>Result</th><th>Verification</th><th>Count</th><th>Message</th></tr>
+{{range .Verifications}}<tr><td class="{{if .Passed}}passed{{else}}failed{{end}}">{{if .Passed}}passed{{else}}failed{{end}}</td><td>{{.Name}}</td><td>{{.Count}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+<h2>Stubs</h2>
+<table>
+<tr><th>ID</th><th>Method</th><th>Path</th><th>Status</th></tr>
+{{range .Stubs}}<tr><td>{{.ID}}</td><td>{{.Method}}</td><td>{{.Path}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
+<h2>Traffic</h2>
+<table>
+<tr><th>Time</th><th>Method</th><th>Path</th><th>Status</th><th>Stub</th></tr>
+{{range .Requests}}<tr{{if eq (index .Metadata "unmatched") "true"}} class="unmatched"{{end}}><td>{{.Timestamp.Format "15:04:05.000"}}</td><td>{{.Method}}</td><td>{{.Path}}</td><td>{{.StatusCode}}</td><td>{{index .Metadata "stub_id"}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,72 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWriteTrafficReport(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/__mockforge/api/mocks" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"mocks": []map[string]interface{}{{"id": "users", "method": "GET", "path": "/users", "status_code": 200}},
+			})
+			return
+		}
+		var body struct {
+			Pattern VerificationRequest `json:"pattern"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		matches := []map[string]interface{}{
+			{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/userz", "status_code": 404, "metadata": map[string]string{"unmatched": "true"}},
+			{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/users", "status_code": 200, "metadata": map[string]string{"stub_id": "users"}},
+		}
+		if body.Pattern.Path == "/orders" {
+			matches = []map[string]interface{}{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"matched": len(matches) > 0, "count": len(matches), "matches": matches})
+	}))
+	server.config.StrictStubbing = true
+
+	server.Verify(VerificationRequest{Method: "GET", Path: "/users"}, AtLeastOnce())
+	server.Verify(VerificationRequest{Method: "POST", Path: "/orders"}, Exactly(1))
+
+	var out bytes.Buffer
+	if err := server.WriteTrafficReport(&out, ReportFormatJUnit); err != nil {
+		t.Fatalf("Failed to write JUnit report: %v", err)
+	}
+	var suites struct {
+		Suites []struct {
+			Tests     int    `xml:"tests,attr"`
+			Failures  int    `xml:"failures,attr"`
+			SystemOut string `xml:"system-out"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatalf("Failed to parse JUnit report: %v\n%s", err, out.String())
+	}
+	// Two verifications plus the strict stubbing check
+	if len(suites.Suites) != 1 || suites.Suites[0].Tests != 3 || suites.Suites[0].Failures != 2 {
+		t.Errorf("Expected 3 test cases with 2 failures, got %+v", suites.Suites)
+	} else if !strings.Contains(suites.Suites[0].SystemOut, "GET /userz -> 404 (unmatched)") {
+		t.Errorf("Expected the traffic in the output, got %q", suites.Suites[0].SystemOut)
+	}
+
+	out.Reset()
+	if err := server.WriteTrafficReport(&out, ReportFormatHTML); err != nil {
+		t.Fatalf("Failed to write HTML report: %v", err)
+	}
+	for _, expected := range []string{"1 stub(s), 2 request(s), 1 unmatched, 2 verification(s), 1 failed", "Verify POST /orders exactly 1", `class="unmatched"`} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected the HTML report to contain %q", expected)
+		}
+	}
+
+	if err := server.WriteTrafficReport(&out, "pdf"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
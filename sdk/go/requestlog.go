@@ -0,0 +1,191 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LoggedRequest is a request recorded in the server's request log
+type LoggedRequest struct {
+	ID                string            `json:"id"`
+	Timestamp         time.Time         `json:"timestamp"`
+	Method            string            `json:"method"`
+	Path              string            `json:"path"`
+	StatusCode        int               `json:"status_code"`
+	ResponseTimeMs    int64             `json:"response_time_ms"`
+	ClientIP          string            `json:"client_ip,omitempty"`
+	UserAgent         string            `json:"user_agent,omitempty"`
+	Headers           map[string]string `json:"headers"`
+	QueryParams       map[string]string `json:"query_params,omitempty"`
+	Body              string            `json:"body,omitempty"`
+	ResponseSizeBytes int64             `json:"response_size_bytes"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+}
+
+// decodeLoggedRequests converts the raw request log entries returned by the
+// verification API
+func decodeLoggedRequests(matches []map[string]interface{}) ([]LoggedRequest, error) {
+	data, err := json.Marshal(matches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request log: %w", err)
+	}
+
+	var requests []LoggedRequest
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return nil, fmt.Errorf("failed to decode request log: %w", err)
+	}
+	for i := range requests {
+		// The server records bodies in the entry metadata
+		if requests[i].Body == "" {
+			requests[i].Body = requests[i].Metadata["request_body"]
+		}
+	}
+	return requests, nil
+}
+
+// bodyBytes returns the recorded request body. The server records bodies
+// as base64 as well as text, and only the base64 form keeps binary bodies
+// intact.
+func (r LoggedRequest) bodyBytes() []byte {
+	if encoded, ok := r.Metadata["request_body_base64"]; ok {
+		if body, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			return body
+		}
+	}
+	if r.Body == "" {
+		return nil
+	}
+	return []byte(r.Body)
+}
+
+// RecordedRequest is a typed entry of the request journal
+type RecordedRequest struct {
+	ID        string
+	Timestamp time.Time
+	Method    string
+	Path      string
+	Headers   map[string]string
+	Query     map[string]string
+	// Body holds at most the first 64 KiB of the request body
+	Body []byte
+	// BodyTruncated reports whether the request body went on past Body
+	BodyTruncated bool
+	// StubID is the ID of the stub that served the request, if any
+	StubID       string
+	Status       int
+	ResponseTime time.Duration
+}
+
+// DecodeJSON unmarshals the request body into v
+func (r RecordedRequest) DecodeJSON(v interface{}) error {
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("failed to decode body of %s %s: %w", r.Method, r.Path, err)
+	}
+	return nil
+}
+
+// RequestFilter selects journal entries. Empty fields match everything;
+// they follow the matching rules of VerificationRequest.
+type RequestFilter struct {
+	Method         string
+	Path           string
+	QueryParams    map[string]string
+	Headers        map[string]string
+	HeaderPatterns map[string]string
+	HeadersAbsent  []string
+	HeadersPresent []string
+	BodyPattern    string
+	BodyJSONPath   map[string]interface{}
+	BodyJSONSchema json.RawMessage
+	Since          *time.Time
+	Until          *time.Time
+	SinceMark      JournalMark
+	// Limit keeps only the most recent Limit entries; 0 keeps all
+	Limit int
+}
+
+// pattern returns the verification pattern selecting the filter's entries
+func (f RequestFilter) pattern() VerificationRequest {
+	return VerificationRequest{
+		Method:         f.Method,
+		Path:           f.Path,
+		QueryParams:    f.QueryParams,
+		Headers:        f.Headers,
+		HeaderPatterns: f.HeaderPatterns,
+		HeadersAbsent:  f.HeadersAbsent,
+		HeadersPresent: f.HeadersPresent,
+		BodyPattern:    f.BodyPattern,
+		BodyJSONPath:   f.BodyJSONPath,
+		BodyJSONSchema: f.BodyJSONSchema,
+		Since:          f.Since,
+		Until:          f.Until,
+		SinceMark:      f.SinceMark,
+	}
+}
+
+// GetRequests returns the journal entries matching filter, oldest first
+func (m *MockServer) GetRequests(filter RequestFilter) ([]RecordedRequest, error) {
+	result, err := m.verify(filter.pattern(), AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	logged, err := decodeLoggedRequests(result.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := recordedRequests(logged)
+	if filter.Limit > 0 && len(requests) > filter.Limit {
+		requests = requests[len(requests)-filter.Limit:]
+	}
+	return requests, nil
+}
+
+// UnmatchedRequests returns the journal entries no stub or route matched,
+// oldest first. These are usually requests to mistyped paths that the
+// server answered with a 404.
+func (m *MockServer) UnmatchedRequests() ([]RecordedRequest, error) {
+	result, err := m.verify(VerificationRequest{}, AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	logged, err := decodeLoggedRequests(result.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	var unmatched []LoggedRequest
+	for _, entry := range logged {
+		if entry.Metadata["unmatched"] == "true" {
+			unmatched = append(unmatched, entry)
+		}
+	}
+	return recordedRequests(unmatched), nil
+}
+
+// recordedRequests converts logged requests to journal entries, oldest first
+func recordedRequests(logged []LoggedRequest) []RecordedRequest {
+	requests := make([]RecordedRequest, len(logged))
+	for i, entry := range logged {
+		requests[i] = RecordedRequest{
+			ID:            entry.ID,
+			Timestamp:     entry.Timestamp,
+			Method:        entry.Method,
+			Path:          entry.Path,
+			Headers:       entry.Headers,
+			Query:         entry.QueryParams,
+			StubID:        entry.Metadata["stub_id"],
+			Status:        entry.StatusCode,
+			ResponseTime:  time.Duration(entry.ResponseTimeMs) * time.Millisecond,
+			BodyTruncated: entry.Metadata["request_body_truncated"] == "true",
+		}
+		requests[i].Body = entry.bodyBytes()
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].Timestamp.Before(requests[j].Timestamp)
+	})
+	return requests
+}
@@ -0,0 +1,88 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetRequests(t *testing.T) {
+	var pattern VerificationRequest
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pattern VerificationRequest `json:"pattern"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		pattern = body.Pattern
+		// The server returns the most recent entry first
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   3,
+			"matches": []map[string]interface{}{
+				{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "POST", "path": "/orders", "status_code": 201, "metadata": map[string]string{"request_body": `{"sku":"c"}`, "stub_id": "create-order"}},
+				{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "POST", "path": "/orders", "status_code": 201, "metadata": map[string]string{"request_body": `{"sku":"b"}`}},
+				{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "POST", "path": "/orders", "status_code": 400, "response_time_ms": 12},
+			},
+		})
+	}))
+
+	requests, err := server.GetRequests(RequestFilter{Method: "POST", Path: "/orders"})
+	if err != nil {
+		t.Fatalf("Failed to get requests: %v", err)
+	}
+	if pattern.Method != "POST" || pattern.Path != "/orders" {
+		t.Errorf("Expected the filter to be sent as the pattern, got %+v", pattern)
+	}
+	if len(requests) != 3 || requests[0].ID != "1" || requests[2].ID != "3" {
+		t.Fatalf("Expected 3 requests oldest first, got %+v", requests)
+	}
+	if requests[0].Status != 400 || requests[0].ResponseTime != 12*time.Millisecond || requests[0].Body != nil {
+		t.Errorf("Expected status, response time, and no body, got %+v", requests[0])
+	}
+	if requests[2].StubID != "create-order" {
+		t.Errorf("Expected stub ID create-order, got %q", requests[2].StubID)
+	}
+	var order struct{ SKU string }
+	if err := requests[2].DecodeJSON(&order); err != nil || order.SKU != "c" {
+		t.Errorf("Expected body with sku c, got %+v (%v)", order, err)
+	}
+
+	requests, err = server.GetRequests(RequestFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("Failed to get requests: %v", err)
+	}
+	if len(requests) != 2 || requests[0].ID != "2" {
+		t.Errorf("Expected the 2 most recent requests, got %+v", requests)
+	}
+}
+
+func TestUnmatchedRequests(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": true,
+			"count":   3,
+			"matches": []map[string]interface{}{
+				{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "GET", "path": "/userz", "status_code": 404, "metadata": map[string]string{"unmatched": "true"}},
+				{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/users", "status_code": 200, "metadata": map[string]string{"stub_id": "users"}},
+				{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "GET", "path": "/helth", "status_code": 404, "metadata": map[string]string{"unmatched": "true"}},
+			},
+		})
+	}))
+
+	requests, err := server.UnmatchedRequests()
+	if err != nil {
+		t.Fatalf("Failed to get unmatched requests: %v", err)
+	}
+	if len(requests) != 2 || requests[0].Path != "/helth" || requests[1].Path != "/userz" {
+		t.Errorf("Expected the 2 unmatched requests oldest first, got %+v", requests)
+	}
+}
+
+func TestStrictStubbingRejectsPassthrough(t *testing.T) {
+	server := NewMockServer(MockServerConfig{StrictStubbing: true, PassthroughUpstream: "http://localhost:9000"})
+	err := server.Start()
+	if e, ok := err.(*MockServerError); !ok || e.Code != ErrorCodeInvalidConfig {
+		t.Errorf("Expected an invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resourceVersion is an item's state from a point in time. A nil value is a
+// deletion.
+type resourceVersion struct {
+	value     map[string]interface{}
+	visibleAt time.Time
+}
+
+// ResourceMock is an in-memory REST collection supporting create, list, get,
+// replace, patch, and delete:
+//
+//	POST   {path}       creates an item, assigning an "id" if none is given
+//	GET    {path}       lists the items
+//	GET    {path}/{id}  returns an item
+//	PUT    {path}/{id}  replaces an item
+//	PATCH  {path}/{id}  merges fields into an item
+//	DELETE {path}/{id}  deletes an item
+//
+// Writes are acknowledged immediately, but with a consistency delay reads
+// keep returning the previous state for that long, like S3 listings or
+// search indexes. Like the other stateful presets it is served by an
+// in-process listener; point the client at URL().
+type ResourceMock struct {
+	sidecar *httpSidecar
+	path    string
+
+	mu       sync.Mutex
+	delay    time.Duration
+	versions map[string][]resourceVersion
+	order    []string
+	nextID   int
+}
+
+// Resource returns the CRUD simulation of the collection at path, starting
+// it on first use
+func (m *MockServer) Resource(path string) (*ResourceMock, error) {
+	path = "/" + strings.Trim(path, "/")
+	if path == "/" {
+		return nil, NewInvalidConfigError("resource path must not be empty", nil)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if resource, ok := m.resources[path]; ok {
+		return resource, nil
+	}
+
+	resource := &ResourceMock{path: path, versions: make(map[string][]resourceVersion)}
+
+	sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(resource.serveHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start resource %s: %w", path, err)
+	}
+	resource.sidecar = sidecar
+
+	if m.resources == nil {
+		m.resources = make(map[string]*ResourceMock)
+	}
+	m.attached = append(m.attached, sidecar)
+	m.resources[path] = resource
+
+	return resource, nil
+}
+
+// URL returns the collection URL
+func (r *ResourceMock) URL() string {
+	return r.sidecar.URL() + r.path
+}
+
+// ConsistencyDelay hides each subsequent write from list and get requests
+// for d, so a freshly created item is not found, an update returns stale
+// data, and a deleted item is still listed until d has passed
+func (r *ResourceMock) ConsistencyDelay(d time.Duration) *ResourceMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delay = d
+	return r
+}
+
+// Seed stores items immediately visible, regardless of the consistency delay
+func (r *ResourceMock) Seed(items ...map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range items {
+		r.write(r.assignID(item), item, time.Time{})
+	}
+}
+
+// Items returns the latest state of every item, including writes that are
+// not visible to clients yet
+func (r *ResourceMock) Items() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []map[string]interface{}
+	for _, id := range r.order {
+		versions := r.versions[id]
+		if latest := versions[len(versions)-1].value; latest != nil {
+			items = append(items, latest)
+		}
+	}
+	return items
+}
+
+// assignID returns item's id, assigning the next free one if it has none.
+// Callers must hold r.mu.
+func (r *ResourceMock) assignID(item map[string]interface{}) string {
+	if id, ok := item["id"]; ok && id != nil {
+		return fmt.Sprint(id)
+	}
+	for {
+		r.nextID++
+		id := strconv.Itoa(r.nextID)
+		if _, taken := r.versions[id]; !taken {
+			item["id"] = id
+			return id
+		}
+	}
+}
+
+// write records a new version of an item. Callers must hold r.mu.
+func (r *ResourceMock) write(id string, value map[string]interface{}, visibleAt time.Time) {
+	if _, exists := r.versions[id]; !exists {
+		r.order = append(r.order, id)
+	}
+	r.versions[id] = append(r.versions[id], resourceVersion{value: value, visibleAt: visibleAt})
+}
+
+// visible returns the version of an item clients can currently read, or nil.
+// Callers must hold r.mu.
+func (r *ResourceMock) visible(id string, now time.Time) map[string]interface{} {
+	versions := r.versions[id]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !now.Before(versions[i].visibleAt) {
+			return versions[i].value
+		}
+	}
+	return nil
+}
+
+// latest returns the most recent version of an item, or nil if it does not
+// exist. Callers must hold r.mu.
+func (r *ResourceMock) latest(id string) map[string]interface{} {
+	versions := r.versions[id]
+	if len(versions) == 0 {
+		return nil
+	}
+	return versions[len(versions)-1].value
+}
+
+func (r *ResourceMock) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	rest, ok := strings.CutPrefix(req.URL.Path, r.path)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		http.NotFound(w, req)
+		return
+	}
+	id := strings.Trim(rest, "/")
+	if strings.Contains(id, "/") {
+		http.NotFound(w, req)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	visibleAt := now.Add(r.delay)
+
+	switch {
+	case id == "" && req.Method == http.MethodGet:
+		items := []map[string]interface{}{}
+		for _, itemID := range r.order {
+			if item := r.visible(itemID, now); item != nil {
+				items = append(items, item)
+			}
+		}
+		writeJSON(w, http.StatusOK, items)
+
+	case id == "" && req.Method == http.MethodPost:
+		var item map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&item); err != nil || item == nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "body must be a JSON object"})
+			return
+		}
+		itemID := r.assignID(item)
+		if r.latest(itemID) != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "item " + itemID + " already exists"})
+			return
+		}
+		r.write(itemID, item, visibleAt)
+		writeJSON(w, http.StatusCreated, item)
+
+	case id != "" && req.Method == http.MethodGet:
+		item := r.visible(id, now)
+		if item == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "item " + id + " not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+
+	case id != "" && (req.Method == http.MethodPut || req.Method == http.MethodPatch):
+		current := r.latest(id)
+		if current == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "item " + id + " not found"})
+			return
+		}
+		var fields map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&fields); err != nil || fields == nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "body must be a JSON object"})
+			return
+		}
+		item := fields
+		if req.Method == http.MethodPatch {
+			item = make(map[string]interface{}, len(current)+len(fields))
+			for key, value := range current {
+				item[key] = value
+			}
+			for key, value := range fields {
+				item[key] = value
+			}
+		}
+		item["id"] = current["id"]
+		r.write(id, item, visibleAt)
+		writeJSON(w, http.StatusOK, item)
+
+	case id != "" && req.Method == http.MethodDelete:
+		if r.latest(id) == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "item " + id + " not found"})
+			return
+		}
+		r.write(id, nil, visibleAt)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,68 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResourceConsistencyDelay(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	users, err := server.Resource("/users")
+	if err != nil {
+		t.Fatalf("Failed to start resource: %v", err)
+	}
+	users.Seed(map[string]interface{}{"id": "admin", "name": "Admin"})
+	users.ConsistencyDelay(200 * time.Millisecond)
+
+	list := func() []map[string]interface{} {
+		resp, err := http.Get(users.URL())
+		if err != nil {
+			t.Fatalf("Failed to list: %v", err)
+		}
+		defer resp.Body.Close()
+		var items []map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&items)
+		return items
+	}
+
+	resp, err := http.Post(users.URL(), "application/json", strings.NewReader(`{"name":"Alice"}`))
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	var created map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created["id"] != "1" {
+		t.Fatalf("Expected 201 with id 1, got %d %v", resp.StatusCode, created)
+	}
+
+	if items := list(); len(items) != 1 {
+		t.Errorf("Expected only the seeded item during the delay, got %v", items)
+	}
+	if resp, _ := http.Get(users.URL() + "/1"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 during the delay, got %d", resp.StatusCode)
+	}
+	if items := users.Items(); len(items) != 2 {
+		t.Errorf("Expected Items to include the pending write, got %v", items)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+
+	if items := list(); len(items) != 2 {
+		t.Errorf("Expected both items after the delay, got %v", items)
+	}
+	if resp, _ := http.Get(users.URL() + "/1"); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 after the delay, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, users.URL()+"/admin", nil)
+	http.DefaultClient.Do(req)
+	if items := list(); len(items) != 2 {
+		t.Errorf("Expected the deleted item to still be listed, got %v", items)
+	}
+}
@@ -0,0 +1,84 @@
+package mockforge
+
+import "fmt"
+
+// ResponseMatcher matches the response the server sent for a request.
+// Zero fields match anything.
+type ResponseMatcher struct {
+	// Status is the exact status code
+	Status int
+	// MinStatus and MaxStatus bound the status code, inclusively, e.g. 500
+	// and 599 for any server error
+	MinStatus int
+	MaxStatus int
+}
+
+// matches reports whether status satisfies the matcher
+func (r ResponseMatcher) matches(status int) bool {
+	return (r.Status == 0 || status == r.Status) &&
+		(r.MinStatus == 0 || status >= r.MinStatus) &&
+		(r.MaxStatus == 0 || status <= r.MaxStatus)
+}
+
+// String formats the matcher for failure messages
+func (r ResponseMatcher) String() string {
+	switch {
+	case r.Status != 0:
+		return fmt.Sprintf("status %d", r.Status)
+	case r.MinStatus != 0 && r.MaxStatus != 0:
+		return fmt.Sprintf("status %d-%d", r.MinStatus, r.MaxStatus)
+	case r.MinStatus != 0:
+		return fmt.Sprintf("status >= %d", r.MinStatus)
+	case r.MaxStatus != 0:
+		return fmt.Sprintf("status <= %d", r.MaxStatus)
+	default:
+		return "any status"
+	}
+}
+
+// VerifyResponses verifies that requests matching pattern were answered with
+// responses matching response the expected number of times, e.g. to check
+// that configured chaos faults produced the errors the client saw:
+//
+//	result, err := server.VerifyResponses(
+//	    mockforge.VerificationRequest{Path: "/payments"},
+//	    mockforge.ResponseMatcher{Status: 503},
+//	    mockforge.AtLeastOnce())
+//
+// When too few responses match, NearMisses lists requests matching pattern
+// that got a different response.
+func (m *MockServer) VerifyResponses(pattern VerificationRequest, response ResponseMatcher, expected VerificationCount) (*VerificationResult, error) {
+	all, err := m.verify(pattern, AtLeast(0))
+	if err != nil {
+		return nil, err
+	}
+	logged, err := decodeLoggedRequests(all.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{Expected: expected, Matches: []map[string]interface{}{}}
+	var misses []NearMiss
+	for i, entry := range logged {
+		if response.matches(entry.StatusCode) {
+			result.Count++
+			result.Matches = append(result.Matches, all.Matches[i])
+		} else if len(misses) < nearMissLimit {
+			misses = append(misses, NearMiss{
+				Request:     entry,
+				Differences: []string{fmt.Sprintf("response: expected %s, got %d", response, entry.StatusCode)},
+			})
+		}
+	}
+
+	result.Matched = expected.Satisfied(result.Count)
+	if !result.Matched {
+		message := fmt.Sprintf("expected %s to get %s %s time(s), got %d", describePattern(pattern), response, expected, result.Count)
+		result.ErrorMessage = &message
+		if expected.tooFew(result.Count) {
+			result.NearMisses = misses
+		}
+	}
+	m.recordVerification(fmt.Sprintf("VerifyResponses %s %s %s", describePattern(pattern), response, expected), result)
+	return result, nil
+}
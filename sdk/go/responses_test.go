@@ -0,0 +1,34 @@
+package mockforge
+
+import "testing"
+
+func TestVerifyResponses(t *testing.T) {
+	server := newJournalTestServer(t, []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "POST", "path": "/payments", "status_code": 503},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "POST", "path": "/payments", "status_code": 502},
+		{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "POST", "path": "/payments", "status_code": 201},
+	})
+	payments := VerificationRequest{Method: "POST", Path: "/payments"}
+
+	result, err := server.VerifyResponses(payments, ResponseMatcher{MinStatus: 500, MaxStatus: 599}, Exactly(2))
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if !result.Matched || result.Count != 2 || len(result.Matches) != 2 {
+		t.Errorf("Expected 2 server errors, got %+v", result)
+	}
+
+	result, err = server.VerifyResponses(payments, ResponseMatcher{Status: 503}, AtLeast(2))
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Matched || result.Count != 1 {
+		t.Fatalf("Expected 1 of 2 503 responses, got %+v", result)
+	}
+	if *result.ErrorMessage != "expected POST /payments to get status 503 at least 2 time(s), got 1" {
+		t.Errorf("Unexpected message %q", *result.ErrorMessage)
+	}
+	if len(result.NearMisses) != 2 || result.NearMisses[0].Differences[0] != "response: expected status 503, got 201" {
+		t.Errorf("Expected the other responses as near misses, got %+v", result.NearMisses)
+	}
+}
@@ -0,0 +1,431 @@
+package mockforge
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// s3Namespace is the XML namespace of S3 API responses
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// S3Object is an object stored in the S3 mock
+type S3Object struct {
+	Key         string
+	Data        []byte
+	ContentType string
+	// Metadata holds the x-amz-meta-* headers, keyed without the prefix
+	Metadata     map[string]string
+	ETag         string
+	LastModified time.Time
+}
+
+// s3Upload is a multipart upload in progress
+type s3Upload struct {
+	bucket string
+	key    string
+	object S3Object
+	parts  map[int][]byte
+}
+
+// S3Mock is an in-memory, S3-compatible object store supporting bucket
+// create and list, object PUT, GET, HEAD, and DELETE, and multipart
+// uploads. Requests are not authenticated; any credentials are accepted.
+//
+// It is served by an in-process listener. Configure the S3 client with
+// Endpoint() and path-style addressing, e.g. UsePathStyle in the AWS SDK.
+type S3Mock struct {
+	sidecar *httpSidecar
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*S3Object
+	uploads map[string]*s3Upload
+	nextID  int
+}
+
+// EnableS3Mock creates bucket in the S3 mock, starting the mock on first use
+func (m *MockServer) EnableS3Mock(bucket string) (*S3Mock, error) {
+	if bucket == "" || strings.Contains(bucket, "/") {
+		return nil, NewInvalidConfigError("S3 bucket name must be non-empty and free of slashes", map[string]interface{}{"bucket": bucket})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.s3 == nil {
+		s3 := &S3Mock{
+			buckets: make(map[string]map[string]*S3Object),
+			uploads: make(map[string]*s3Upload),
+		}
+
+		sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(s3.serveHTTP))
+		if err != nil {
+			return nil, fmt.Errorf("failed to start S3 mock: %w", err)
+		}
+		s3.sidecar = sidecar
+
+		m.attached = append(m.attached, sidecar)
+		m.s3 = s3
+	}
+
+	m.s3.mu.Lock()
+	if _, ok := m.s3.buckets[bucket]; !ok {
+		m.s3.buckets[bucket] = make(map[string]*S3Object)
+	}
+	m.s3.mu.Unlock()
+
+	return m.s3, nil
+}
+
+// Endpoint returns the URL to configure as the S3 client's endpoint
+func (s *S3Mock) Endpoint() string {
+	return s.sidecar.URL()
+}
+
+// PutObject stores an object, e.g. to seed data the code under test reads
+func (s *S3Mock) PutObject(bucket, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		return NewInvalidConfigError("S3 bucket does not exist", map[string]interface{}{"bucket": bucket})
+	}
+	objects[key] = newS3Object(key, data, "application/octet-stream", nil)
+	return nil
+}
+
+// Object returns a copy of an object
+func (s *S3Mock) Object(bucket, key string) (S3Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, ok := s.buckets[bucket][key]
+	if !ok {
+		return S3Object{}, false
+	}
+	copied := *object
+	copied.Data = append([]byte(nil), object.Data...)
+	return copied, true
+}
+
+// Keys returns the keys of the objects in bucket, sorted
+func (s *S3Mock) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newS3Object builds an object, computing its ETag
+func newS3Object(key string, data []byte, contentType string, metadata map[string]string) *S3Object {
+	sum := md5.Sum(data)
+	return &S3Object{
+		Key:          key,
+		Data:         data,
+		ContentType:  contentType,
+		Metadata:     metadata,
+		ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+		LastModified: time.Now().UTC(),
+	}
+}
+
+func (s *S3Mock) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket == "" {
+		s3Error(w, http.StatusNotImplemented, "NotImplemented", "Listing buckets is not supported")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects, exists := s.buckets[bucket]
+	if r.Method == http.MethodPut && key == "" {
+		if !exists {
+			s.buckets[bucket] = make(map[string]*S3Object)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !exists {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet:
+		s.listObjects(w, bucket, objects, query.Get("prefix"), query.Get("delimiter"))
+	case key == "":
+		s3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource")
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.nextID++
+		uploadID := fmt.Sprintf("upload-%d", s.nextID)
+		s.uploads[uploadID] = &s3Upload{bucket: bucket, key: key, object: *newS3Object(key, nil, s3ContentType(r), s3Metadata(r)), parts: make(map[int][]byte)}
+		writeS3XML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Xmlns    string   `xml:"xmlns,attr"`
+			Bucket   string
+			Key      string
+			UploadID string `xml:"UploadId"`
+		}{Xmlns: s3Namespace, Bucket: bucket, Key: key, UploadID: uploadID})
+	case query.Has("uploadId"):
+		s.serveMultipart(w, r, bucket, key, objects)
+	case r.Method == http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		object := newS3Object(key, data, s3ContentType(r), s3Metadata(r))
+		objects[key] = object
+		w.Header().Set("ETag", object.ETag)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := objects[key]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", object.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.Data)))
+		w.Header().Set("ETag", object.ETag)
+		w.Header().Set("Last-Modified", object.LastModified.Format(http.TimeFormat))
+		for name, value := range object.Metadata {
+			w.Header().Set("X-Amz-Meta-"+name, value)
+		}
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(object.Data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource")
+	}
+}
+
+// serveMultipart handles part uploads, completion, and abort of a
+// multipart upload. Callers must hold s.mu.
+func (s *S3Mock) serveMultipart(w http.ResponseWriter, r *http.Request, bucket, key string, objects map[string]*S3Object) {
+	uploadID := r.URL.Query().Get("uploadId")
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.bucket != bucket || upload.key != key {
+		s3Error(w, http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		if err != nil || partNumber < 1 || partNumber > 10000 {
+			s3Error(w, http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000")
+			return
+		}
+		data, err := readS3Body(r)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		upload.parts[partNumber] = data
+		sum := md5.Sum(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodPost:
+		var request struct {
+			Parts []struct {
+				PartNumber int
+			} `xml:"Part"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Parts) == 0 {
+			s3Error(w, http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed")
+			return
+		}
+		var data []byte
+		for i, part := range request.Parts {
+			partData, ok := upload.parts[part.PartNumber]
+			if !ok {
+				s3Error(w, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found.")
+				return
+			}
+			if i > 0 && part.PartNumber <= request.Parts[i-1].PartNumber {
+				s3Error(w, http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order.")
+				return
+			}
+			data = append(data, partData...)
+		}
+		object := newS3Object(key, data, upload.object.ContentType, upload.object.Metadata)
+		objects[key] = object
+		delete(s.uploads, uploadID)
+		writeS3XML(w, struct {
+			XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+			Xmlns    string   `xml:"xmlns,attr"`
+			Location string
+			Bucket   string
+			Key      string
+			ETag     string
+		}{Xmlns: s3Namespace, Location: s.Endpoint() + "/" + bucket + "/" + key, Bucket: bucket, Key: key, ETag: object.ETag})
+
+	case http.MethodDelete:
+		delete(s.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource")
+	}
+}
+
+// listObjects answers ListObjects and ListObjectsV2 with every matching key;
+// results are never truncated
+func (s *S3Mock) listObjects(w http.ResponseWriter, bucket string, objects map[string]*S3Object, prefix, delimiter string) {
+	type contents struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	result := struct {
+		XMLName        xml.Name `xml:"ListBucketResult"`
+		Xmlns          string   `xml:"xmlns,attr"`
+		Name           string
+		Prefix         string
+		Delimiter      string `xml:",omitempty"`
+		KeyCount       int
+		MaxKeys        int
+		IsTruncated    bool
+		Contents       []contents
+		CommonPrefixes []commonPrefix
+	}{Xmlns: s3Namespace, Name: bucket, Prefix: prefix, Delimiter: delimiter, MaxKeys: 1000}
+
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(rest, delimiter); i >= 0 {
+				common := prefix + rest[:i+len(delimiter)]
+				if !seen[common] {
+					seen[common] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: common})
+				}
+				continue
+			}
+		}
+		object := objects[key]
+		result.Contents = append(result.Contents, contents{
+			Key:          key,
+			LastModified: object.LastModified.Format(time.RFC3339),
+			ETag:         object.ETag,
+			Size:         len(object.Data),
+			StorageClass: "STANDARD",
+		})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+	writeS3XML(w, result)
+}
+
+// readS3Body reads an object body, decoding the aws-chunked encoding SDKs
+// use for streaming uploads
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") &&
+		!strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("malformed aws-chunked body: %w", err)
+		}
+		sizeField, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeField, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed aws-chunked size %q", sizeField)
+		}
+		if size == 0 {
+			// Trailing checksum headers follow the last chunk
+			return data, nil
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, fmt.Errorf("malformed aws-chunked body: %w", err)
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+// s3ContentType returns the request's content type, or S3's default
+func s3ContentType(r *http.Request) string {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	return "binary/octet-stream"
+}
+
+// s3Metadata collects the x-amz-meta-* headers of a request
+func s3Metadata(r *http.Request) map[string]string {
+	metadata := make(map[string]string)
+	for name := range r.Header {
+		if key, ok := strings.CutPrefix(strings.ToLower(name), "x-amz-meta-"); ok {
+			metadata[key] = r.Header.Get(name)
+		}
+	}
+	return metadata
+}
+
+// writeS3XML writes an XML response body
+func writeS3XML(w http.ResponseWriter, v interface{}) {
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	xml.NewEncoder(&body).Encode(v)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// s3Error writes an S3 error response
+func s3Error(w http.ResponseWriter, status int, code, message string) {
+	body, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: message})
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
@@ -0,0 +1,86 @@
+package mockforge
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestS3Mock(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	s3, err := server.EnableS3Mock("invoices")
+	if err != nil {
+		t.Fatalf("Failed to start S3 mock: %v", err)
+	}
+	if err := s3.PutObject("invoices", "2024/01.pdf", []byte("january")); err != nil {
+		t.Fatalf("Failed to seed object: %v", err)
+	}
+
+	do := func(method, path, body string, headers map[string]string) (*http.Response, string) {
+		t.Helper()
+		request, _ := http.NewRequest(method, s3.Endpoint()+path, strings.NewReader(body))
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Failed to %s %s: %v", method, path, err)
+		}
+		defer response.Body.Close()
+		data, _ := io.ReadAll(response.Body)
+		return response, string(data)
+	}
+
+	response, _ := do(http.MethodPut, "/invoices/2024/02.pdf", "february", map[string]string{"Content-Type": "application/pdf", "X-Amz-Meta-Customer": "acme"})
+	if response.StatusCode != http.StatusOK || response.Header.Get("ETag") == "" {
+		t.Errorf("Expected PUT to return 200 with an ETag, got %d", response.StatusCode)
+	}
+	object, ok := s3.Object("invoices", "2024/02.pdf")
+	if !ok || string(object.Data) != "february" || object.ContentType != "application/pdf" || object.Metadata["customer"] != "acme" {
+		t.Errorf("Unexpected stored object: %+v", object)
+	}
+
+	response, body := do(http.MethodGet, "/invoices/2024/01.pdf", "", nil)
+	if response.StatusCode != http.StatusOK || body != "january" {
+		t.Errorf("Expected seeded object, got %d %q", response.StatusCode, body)
+	}
+	response, _ = do(http.MethodHead, "/invoices/missing", "", nil)
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing key, got %d", response.StatusCode)
+	}
+
+	_, body = do(http.MethodGet, "/invoices?list-type=2&prefix=2024/", "", nil)
+	var listing struct {
+		Contents []struct{ Key string }
+	}
+	xml.Unmarshal([]byte(body), &listing)
+	if len(listing.Contents) != 2 || listing.Contents[0].Key != "2024/01.pdf" {
+		t.Errorf("Unexpected listing: %s", body)
+	}
+
+	_, body = do(http.MethodPost, "/invoices/report.csv?uploads", "", nil)
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	xml.Unmarshal([]byte(body), &initiated)
+	do(http.MethodPut, "/invoices/report.csv?partNumber=2&uploadId="+initiated.UploadID, "b,c\n", nil)
+	// A streaming upload encodes the part with aws-chunked
+	do(http.MethodPut, "/invoices/report.csv?partNumber=1&uploadId="+initiated.UploadID, "4;chunk-signature=abc\r\na,b\n\r\n0\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n", map[string]string{"Content-Encoding": "aws-chunked"})
+	response, _ = do(http.MethodPost, "/invoices/report.csv?uploadId="+initiated.UploadID,
+		"<CompleteMultipartUpload><Part><PartNumber>1</PartNumber></Part><Part><PartNumber>2</PartNumber></Part></CompleteMultipartUpload>", nil)
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected multipart completion to succeed, got %d", response.StatusCode)
+	}
+	if object, _ := s3.Object("invoices", "report.csv"); string(object.Data) != "a,b\nb,c\n" {
+		t.Errorf("Expected assembled parts, got %q", object.Data)
+	}
+
+	do(http.MethodDelete, "/invoices/2024/01.pdf", "", nil)
+	if keys := s3.Keys("invoices"); len(keys) != 2 {
+		t.Errorf("Expected 2 keys after delete, got %v", keys)
+	}
+}
@@ -0,0 +1,334 @@
+// Package scenario runs declarative integration test scenarios against a
+// MockForge server.
+//
+// A scenario is an ordered list of phases. Each phase can register stubs,
+// wait for the system under test to make calls, toggle chaos, and verify the
+// requests received. Scenarios are written in YAML so they can be authored
+// without writing Go:
+//
+//	name: checkout
+//	phases:
+//	  - name: load stubs
+//	    stubs:
+//	      - method: POST
+//	        path: /payments
+//	        status: 201
+//	        body: {id: pay_1}
+//	  - name: wait for payment
+//	    wait: {method: POST, path: /payments, calls: 1, timeout: 5s}
+//	  - name: payments provider degrades
+//	    chaos: {scenario: slow_backend}
+//	  - name: assert
+//	    verify:
+//	      - {method: POST, path: /payments, times: 1}
+//
+// and run from a test with
+//
+//	scenario.Run(t, server, "testdata/checkout.scenario.yaml")
+//
+// The same structures can be built in Go and run with Scenario.Run.
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults for wait phases
+const (
+	DefaultWaitTimeout = 5 * time.Second
+	waitPollInterval   = 50 * time.Millisecond
+)
+
+// Scenario is a named, ordered list of phases
+type Scenario struct {
+	Name   string  `yaml:"name"`
+	Phases []Phase `yaml:"phases"`
+}
+
+// Phase is one step of a scenario. Its actions run in field order: clear
+// stubs, load stubs, chaos, wait, verify.
+type Phase struct {
+	Name       string         `yaml:"name"`
+	ClearStubs bool           `yaml:"clear_stubs"`
+	Stubs      []Stub         `yaml:"stubs"`
+	Chaos      *Chaos         `yaml:"chaos"`
+	Wait       *Wait          `yaml:"wait"`
+	Verify     []Verification `yaml:"verify"`
+}
+
+// Stub is a stub registered by a phase
+type Stub struct {
+	Method        string            `yaml:"method"`
+	Path          string            `yaml:"path"`
+	Status        int               `yaml:"status"`
+	Headers       map[string]string `yaml:"headers"`
+	Body          interface{}       `yaml:"body"`
+	LatencyMs     *int              `yaml:"latency_ms"`
+	Priority      int               `yaml:"priority"`
+	Scenario      string            `yaml:"scenario"`
+	RequiredState string            `yaml:"required_state"`
+	NewState      string            `yaml:"new_state"`
+	Times         int               `yaml:"times"`
+	Fault         mockforge.Fault   `yaml:"fault"`
+}
+
+// Chaos toggles chaos engineering. Enabled switches chaos on or off, while
+// Scenario and StopScenario start and stop a named chaos scenario.
+type Chaos struct {
+	Enabled      *bool  `yaml:"enabled"`
+	Scenario     string `yaml:"scenario"`
+	StopScenario string `yaml:"stop_scenario"`
+}
+
+// Wait blocks until the server has received Calls matching requests
+type Wait struct {
+	Method  string        `yaml:"method"`
+	Path    string        `yaml:"path"`
+	Calls   int           `yaml:"calls"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Verification asserts how many matching requests the server received. Set
+// exactly one of Times, AtLeast, AtMost, or Never.
+type Verification struct {
+	Method      string            `yaml:"method"`
+	Path        string            `yaml:"path"`
+	Headers     map[string]string `yaml:"headers"`
+	QueryParams map[string]string `yaml:"query_params"`
+	BodyPattern string            `yaml:"body_pattern"`
+	Times       *int              `yaml:"times"`
+	AtLeast     *int              `yaml:"at_least"`
+	AtMost      *int              `yaml:"at_most"`
+	Never       bool              `yaml:"never"`
+}
+
+// Load reads a scenario from a YAML file
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse parses a YAML scenario
+func Parse(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Run loads the scenario file at path and runs it against server, failing
+// the test at the first phase that fails
+func Run(t testing.TB, server *mockforge.MockServer, path string) {
+	t.Helper()
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Run(t, server)
+}
+
+// Run runs the scenario against server, failing the test at the first phase
+// that fails
+func (s *Scenario) Run(t testing.TB, server *mockforge.MockServer) {
+	t.Helper()
+
+	if err := s.Execute(server); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Execute runs every phase against server in order and returns the first
+// failure
+func (s *Scenario) Execute(server *mockforge.MockServer) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	for i, phase := range s.Phases {
+		if err := phase.execute(server); err != nil {
+			return fmt.Errorf("scenario %q: phase %d (%s): %w", s.Name, i+1, phase.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the scenario is well formed before anything is executed
+func (s *Scenario) validate() error {
+	for i, phase := range s.Phases {
+		for _, v := range phase.Verify {
+			set := 0
+			for _, present := range []bool{v.Times != nil, v.AtLeast != nil, v.AtMost != nil, v.Never} {
+				if present {
+					set++
+				}
+			}
+			if set != 1 {
+				return fmt.Errorf("phase %d (%s): verification of %s %s must set exactly one of times, at_least, at_most, never",
+					i+1, phase.Name, v.Method, v.Path)
+			}
+		}
+		if phase.Wait != nil && phase.Wait.Calls < 1 {
+			return fmt.Errorf("phase %d (%s): wait must expect at least one call", i+1, phase.Name)
+		}
+	}
+	return nil
+}
+
+// execute runs the phase's actions in order
+func (p Phase) execute(server *mockforge.MockServer) error {
+	if p.ClearStubs {
+		if err := server.ClearStubs(); err != nil {
+			return err
+		}
+	}
+
+	for _, stub := range p.Stubs {
+		if err := server.AddStub(stub.responseStub()); err != nil {
+			return fmt.Errorf("failed to load stub %s %s: %w", stub.Method, stub.Path, err)
+		}
+	}
+
+	if p.Chaos != nil {
+		if err := p.Chaos.apply(server); err != nil {
+			return err
+		}
+	}
+
+	if p.Wait != nil {
+		if err := p.Wait.await(server); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range p.Verify {
+		if err := v.check(server); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// responseStub converts the stub to the SDK representation
+func (s Stub) responseStub() mockforge.ResponseStub {
+	status := s.Status
+	if status == 0 {
+		status = 200
+	}
+	headers := s.Headers
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+
+	stub := mockforge.ResponseStub{
+		Method:        s.Method,
+		Path:          s.Path,
+		Status:        status,
+		Headers:       headers,
+		Body:          s.Body,
+		Priority:      s.Priority,
+		Scenario:      s.Scenario,
+		RequiredState: s.RequiredState,
+		NewState:      s.NewState,
+		Times:         s.Times,
+		Fault:         s.Fault,
+	}
+	if s.LatencyMs != nil {
+		stub.Latency = mockforge.FixedLatency(time.Duration(*s.LatencyMs) * time.Millisecond)
+	}
+	return stub
+}
+
+// apply applies the chaos changes
+func (c Chaos) apply(server *mockforge.MockServer) error {
+	if c.StopScenario != "" {
+		if err := server.StopChaosScenario(c.StopScenario); err != nil {
+			return err
+		}
+	}
+	if c.Enabled != nil {
+		if err := server.SetChaosEnabled(*c.Enabled); err != nil {
+			return err
+		}
+	}
+	if c.Scenario != "" {
+		if err := server.StartChaosScenario(c.Scenario); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// await polls the server until enough matching calls have been received
+func (w Wait) await(server *mockforge.MockServer) error {
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = DefaultWaitTimeout
+	}
+	pattern := mockforge.VerificationRequest{Method: w.Method, Path: w.Path}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		count, err := server.CountRequests(pattern)
+		if err != nil {
+			return err
+		}
+		if count >= w.Calls {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for %d %s %s calls, got %d", timeout, w.Calls, w.Method, w.Path, count)
+		}
+		time.Sleep(waitPollInterval)
+	}
+}
+
+// check verifies the request count on the server
+func (v Verification) check(server *mockforge.MockServer) error {
+	var expected mockforge.VerificationCount
+	switch {
+	case v.Times != nil:
+		expected = mockforge.Exactly(*v.Times)
+	case v.AtLeast != nil:
+		expected = mockforge.AtLeast(*v.AtLeast)
+	case v.AtMost != nil:
+		expected = mockforge.AtMost(*v.AtMost)
+	default:
+		expected = mockforge.Never()
+	}
+
+	pattern := mockforge.VerificationRequest{
+		Method:      v.Method,
+		Path:        v.Path,
+		Headers:     v.Headers,
+		QueryParams: v.QueryParams,
+		BodyPattern: v.BodyPattern,
+	}
+	result, err := server.Verify(pattern, expected)
+	if err != nil {
+		return err
+	}
+	if !result.Matched {
+		return fmt.Errorf("expected %s %s to be called %s, got %d", v.Method, v.Path, expected, result.Count)
+	}
+	return nil
+}
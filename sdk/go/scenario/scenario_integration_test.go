@@ -0,0 +1,85 @@
+//go:build integration
+// +build integration
+
+// Integration tests that require the MockForge CLI to be installed and on PATH.
+// Run with: go test -tags=integration
+package scenario
+
+import (
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// startCLIServer starts a server with the MockForge CLI for the rest of the
+// test, skipping the test when the CLI is not on PATH
+func startCLIServer(t *testing.T) *mockforge.MockServer {
+	t.Helper()
+	if _, err := exec.LookPath("mockforge"); err != nil {
+		t.Skip("Requires MockForge CLI to be installed")
+	}
+	server := mockforge.NewMockServer(mockforge.MockServerConfig{})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+	return server
+}
+
+func TestRunAgainstServer(t *testing.T) {
+	server := startCLIServer(t)
+
+	// The system under test pays twice once the scenario has loaded the
+	// payments stub
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for paid, deadline := 0, time.Now().Add(5*time.Second); paid < 2 && time.Now().Before(deadline); {
+			resp, err := http.Post(server.URL()+"/payments", "application/json", strings.NewReader(`{}`))
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusCreated {
+				paid++
+			} else {
+				time.Sleep(20 * time.Millisecond)
+			}
+		}
+	}()
+	Run(t, server, "testdata/checkout.scenario.yaml")
+	<-done
+}
+
+func TestExecuteAgainstServerReportsFailingPhase(t *testing.T) {
+	server := startCLIServer(t)
+
+	once := 1
+	s := &Scenario{
+		Name: "go-dsl",
+		Phases: []Phase{
+			{Name: "load stubs", Stubs: []Stub{{Method: "GET", Path: "/slow", Body: "ok"}}},
+			{Name: "wait", Wait: &Wait{Method: "GET", Path: "/slow", Calls: 1, Timeout: 200 * time.Millisecond}},
+			{Name: "assert", Verify: []Verification{{Method: "GET", Path: "/slow", Times: &once}}},
+			{Name: "degrade", Chaos: &Chaos{Scenario: "slow_backend"}},
+			{Name: "recover", Chaos: &Chaos{StopScenario: "slow_backend"}},
+		},
+	}
+	err := s.Execute(server)
+	if err == nil || !strings.HasPrefix(err.Error(), `scenario "go-dsl": phase 2 (wait): timed out after 200ms`) {
+		t.Fatalf("Expected the wait phase to time out, got %v", err)
+	}
+
+	resp, err := http.Get(server.URL() + "/slow")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if err := s.Execute(server); err != nil {
+		t.Errorf("Expected the scenario to pass after the call, got %v", err)
+	}
+}
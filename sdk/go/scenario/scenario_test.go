@@ -0,0 +1,84 @@
+package scenario
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// newVerificationServer serves the verification API, reporting count
+// matching POST requests and none for other methods
+func newVerificationServer(t *testing.T, count *int32) *mockforge.MockServer {
+	t.Helper()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pattern  mockforge.VerificationRequest `json:"pattern"`
+			Expected mockforge.VerificationCount   `json:"expected"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		n := 0
+		if body.Pattern.Method == "POST" {
+			n = int(atomic.AddInt32(count, 1))
+		}
+
+		switch r.URL.Path {
+		case "/api/verification/count":
+			json.NewEncoder(w).Encode(map[string]int{"count": n})
+		case "/api/verification/verify":
+			json.NewEncoder(w).Encode(mockforge.VerificationResult{Matched: body.Expected.Satisfied(n), Count: n})
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	host, port, _ := net.SplitHostPort(api.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return mockforge.NewMockServer(mockforge.MockServerConfig{Host: host, Port: portNum})
+}
+
+func TestRun(t *testing.T) {
+	var count int32
+	server := newVerificationServer(t, &count)
+
+	Run(t, server, "testdata/checkout.scenario.yaml")
+}
+
+func TestParseRejectsAmbiguousVerification(t *testing.T) {
+	_, err := Parse([]byte(`
+phases:
+  - name: assert
+    verify:
+      - {method: GET, path: /, times: 1, never: true}
+`))
+	if err == nil {
+		t.Error("Expected error for verification with several counts")
+	}
+}
+
+func TestExecuteReportsFailingPhase(t *testing.T) {
+	var count int32
+	server := newVerificationServer(t, &count)
+
+	times := 0
+	s := &Scenario{
+		Name: "go-dsl",
+		Phases: []Phase{
+			{Name: "assert", Verify: []Verification{{Method: "POST", Path: "/payments", Times: &times}}},
+		},
+	}
+
+	err := s.Execute(server)
+	if err == nil {
+		t.Fatal("Expected verification failure")
+	}
+	if expected := `scenario "go-dsl": phase 1 (assert): expected POST /payments to be called exactly 0, got 1`; err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,56 @@
+package mockforge
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// ScenarioStarted is the state every scenario is in before any stub moves it
+const ScenarioStarted = "Started"
+
+// ScenarioState is the current state of a scenario on the server
+type ScenarioState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// ResetScenarios moves every scenario back to ScenarioStarted
+func (m *MockServer) ResetScenarios() error {
+	if m.isDryRun() {
+		states, err := m.ScenarioStates()
+		if err != nil {
+			return err
+		}
+		targets := make([]string, len(states))
+		for i, state := range states {
+			targets[i] = state.Name
+		}
+		m.recordDryRun("reset scenarios", targets)
+		return nil
+	}
+
+	return m.adminJSON("reset scenarios", http.MethodPost, "/__mockforge/api/scenario-states/reset", nil, nil)
+}
+
+// SetScenarioState forces a scenario into state, e.g. to start a test midway
+// through a workflow
+func (m *MockServer) SetScenarioState(name, state string) error {
+	return m.adminJSON(
+		"set scenario state",
+		http.MethodPut,
+		"/__mockforge/api/scenario-states/"+url.PathEscape(name),
+		map[string]string{"state": state},
+		nil,
+	)
+}
+
+// ScenarioStates lists the current state of every scenario
+func (m *MockServer) ScenarioStates() ([]ScenarioState, error) {
+	var result struct {
+		Scenarios []ScenarioState `json:"scenarios"`
+	}
+	if err := m.adminJSON("list scenario states", http.MethodGet, "/__mockforge/api/scenario-states", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Scenarios, nil
+}
@@ -0,0 +1,44 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestScenarioStubs(t *testing.T) {
+	var mocks []map[string]interface{}
+	var stateUpdate map[string]string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/__mockforge/api/mocks":
+			var mock map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&mock)
+			mocks = append(mocks, mock)
+		case "/__mockforge/api/scenario-states/checkout":
+			json.NewDecoder(r.Body).Decode(&stateUpdate)
+		}
+	}))
+
+	err := server.AddStub(NewStubBuilder("POST", "/pay").
+		Scenario("checkout").
+		RequiredState("cart-created").
+		NewState("paid").
+		Build())
+	if err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	if mocks[0]["scenario"] != "checkout" ||
+		mocks[0]["required_scenario_state"] != "cart-created" ||
+		mocks[0]["new_scenario_state"] != "paid" {
+		t.Errorf("Expected scenario fields in mock config, got %v", mocks[0])
+	}
+
+	if err := server.SetScenarioState("checkout", "paid"); err != nil {
+		t.Fatalf("Failed to set scenario state: %v", err)
+	}
+	if stateUpdate["state"] != "paid" {
+		t.Errorf("Expected state paid, got %v", stateUpdate)
+	}
+}
@@ -0,0 +1,709 @@
+package mockforge
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SearchDocument is a document stored in the search preset
+type SearchDocument struct {
+	ID     string
+	Source map[string]interface{}
+}
+
+// BulkOperation is a single action captured from a _bulk request
+type BulkOperation struct {
+	// Action is index, create, update, or delete
+	Action string
+	Index  string
+	ID     string
+	// Source is the document (or partial document for update), nil for delete
+	Source map[string]interface{}
+}
+
+// SearchMock is an Elasticsearch/OpenSearch-compatible endpoint backed by an
+// in-memory document set.
+//
+// It answers _search with match_all, match, match_phrase, term, terms, range,
+// exists, and bool queries plus from/size pagination, and captures every
+// _bulk operation (applying it to the document set). Scoring is a simple
+// count of matched terms, good enough to make relevance order deterministic.
+//
+// Like the Twilio preset it is served by an in-process listener; point the
+// search client at URL().
+type SearchMock struct {
+	sidecar *httpSidecar
+
+	mu      sync.Mutex
+	indices map[string][]SearchDocument
+	bulk    []BulkOperation
+	nextID  int
+}
+
+// Search returns the search engine preset, starting it on first use
+func (m *MockServer) Search() (*SearchMock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.search != nil {
+		return m.search, nil
+	}
+
+	search := &SearchMock{indices: make(map[string][]SearchDocument)}
+
+	sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(search.serveHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start search preset: %w", err)
+	}
+	search.sidecar = sidecar
+
+	m.attached = append(m.attached, sidecar)
+	m.search = search
+
+	return search, nil
+}
+
+// URL returns the cluster URL to configure in the search client
+func (s *SearchMock) URL() string {
+	return s.sidecar.URL()
+}
+
+// Seed adds documents to an index, replacing documents with the same ID
+func (s *SearchMock) Seed(index string, docs ...SearchDocument) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range docs {
+		s.put(index, doc)
+	}
+}
+
+// Documents returns the documents currently stored in an index
+func (s *SearchMock) Documents(index string) []SearchDocument {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SearchDocument(nil), s.indices[index]...)
+}
+
+// BulkOperations returns every operation received through _bulk in order
+func (s *SearchMock) BulkOperations() []BulkOperation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]BulkOperation(nil), s.bulk...)
+}
+
+// Reset removes all indices and captured bulk operations
+func (s *SearchMock) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indices = make(map[string][]SearchDocument)
+	s.bulk = nil
+}
+
+// put stores doc in index. Callers must hold s.mu.
+func (s *SearchMock) put(index string, doc SearchDocument) {
+	if doc.ID == "" {
+		s.nextID++
+		doc.ID = strconv.Itoa(s.nextID)
+	}
+	docs := s.indices[index]
+	for i := range docs {
+		if docs[i].ID == doc.ID {
+			docs[i] = doc
+			return
+		}
+	}
+	s.indices[index] = append(docs, doc)
+}
+
+// remove deletes a document from index. Callers must hold s.mu.
+func (s *SearchMock) remove(index, id string) bool {
+	docs := s.indices[index]
+	for i := range docs {
+		if docs[i].ID == id {
+			s.indices[index] = append(docs[:i], docs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// get returns a document from index. Callers must hold s.mu.
+func (s *SearchMock) get(index, id string) (SearchDocument, bool) {
+	for _, doc := range s.indices[index] {
+		if doc.ID == id {
+			return doc, true
+		}
+	}
+	return SearchDocument{}, false
+}
+
+func (s *SearchMock) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.URL.Path == "/" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"name":         "mockforge",
+			"cluster_name": "mockforge",
+			"version":      map[string]interface{}{"number": "8.11.0"},
+			"tagline":      "You Know, for Search",
+		})
+	case parts[len(parts)-1] == "_bulk":
+		defaultIndex := ""
+		if len(parts) == 2 {
+			defaultIndex = parts[0]
+		}
+		s.handleBulk(w, r, defaultIndex)
+	case parts[len(parts)-1] == "_search" || parts[len(parts)-1] == "_count":
+		index := ""
+		if len(parts) == 2 {
+			index = parts[0]
+		}
+		s.handleSearch(w, r, index, parts[len(parts)-1] == "_count")
+	case len(parts) == 3 && parts[1] == "_doc":
+		s.handleDoc(w, r, parts[0], parts[2])
+	case len(parts) == 2 && parts[1] == "_doc" && r.Method == http.MethodPost:
+		s.handleDoc(w, r, parts[0], "")
+	case len(parts) == 1 && r.Method == http.MethodHead:
+		s.mu.Lock()
+		_, exists := s.indices[parts[0]]
+		s.mu.Unlock()
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		s.mu.Lock()
+		if _, exists := s.indices[parts[0]]; !exists {
+			s.indices[parts[0]] = nil
+		}
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true, "index": parts[0]})
+	default:
+		searchError(w, http.StatusBadRequest, "illegal_argument_exception", "unsupported request "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (s *SearchMock) handleDoc(w http.ResponseWriter, r *http.Request, index, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		doc, found := s.get(index, id)
+		if !found {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"_index": index, "_id": id, "found": false})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"_index": index, "_id": id, "found": true, "_source": doc.Source})
+	case http.MethodPut, http.MethodPost:
+		var source map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
+			searchError(w, http.StatusBadRequest, "mapper_parsing_exception", err.Error())
+			return
+		}
+		doc := SearchDocument{ID: id, Source: source}
+		_, existed := s.get(index, id)
+		s.put(index, doc)
+		if id == "" {
+			doc = s.indices[index][len(s.indices[index])-1]
+		}
+		result, status := "created", http.StatusCreated
+		if existed {
+			result, status = "updated", http.StatusOK
+		}
+		writeJSON(w, status, map[string]interface{}{"_index": index, "_id": doc.ID, "result": result})
+	case http.MethodDelete:
+		if !s.remove(index, id) {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"_index": index, "_id": id, "result": "not_found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"_index": index, "_id": id, "result": "deleted"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *SearchMock) handleBulk(w http.ResponseWriter, r *http.Request, defaultIndex string) {
+	start := time.Now()
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var items []interface{}
+	failed := false
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var header map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &header); err != nil || len(header) != 1 {
+			searchError(w, http.StatusBadRequest, "illegal_argument_exception", "malformed action/metadata line")
+			return
+		}
+
+		for action, meta := range header {
+			op := BulkOperation{Action: action, Index: meta.Index, ID: meta.ID}
+			if op.Index == "" {
+				op.Index = defaultIndex
+			}
+
+			if action != "delete" {
+				if !scanner.Scan() {
+					searchError(w, http.StatusBadRequest, "illegal_argument_exception", "missing source line for "+action)
+					return
+				}
+				if err := json.Unmarshal(scanner.Bytes(), &op.Source); err != nil {
+					searchError(w, http.StatusBadRequest, "mapper_parsing_exception", err.Error())
+					return
+				}
+			}
+
+			status := http.StatusOK
+			result := "updated"
+			switch action {
+			case "index", "create":
+				_, existed := s.get(op.Index, op.ID)
+				if action == "create" && existed {
+					status, result = http.StatusConflict, "version_conflict"
+					break
+				}
+				s.put(op.Index, SearchDocument{ID: op.ID, Source: op.Source})
+				if op.ID == "" {
+					docs := s.indices[op.Index]
+					op.ID = docs[len(docs)-1].ID
+				}
+				if !existed {
+					status, result = http.StatusCreated, "created"
+				}
+			case "update":
+				doc, found := s.get(op.Index, op.ID)
+				if !found {
+					status, result = http.StatusNotFound, "not_found"
+					break
+				}
+				partial, _ := op.Source["doc"].(map[string]interface{})
+				merged := make(map[string]interface{})
+				for k, v := range doc.Source {
+					merged[k] = v
+				}
+				for k, v := range partial {
+					merged[k] = v
+				}
+				s.put(op.Index, SearchDocument{ID: op.ID, Source: merged})
+			case "delete":
+				result = "deleted"
+				if !s.remove(op.Index, op.ID) {
+					status, result = http.StatusNotFound, "not_found"
+				}
+			}
+
+			if status >= 300 {
+				failed = true
+			}
+			s.bulk = append(s.bulk, op)
+			items = append(items, map[string]interface{}{
+				action: map[string]interface{}{"_index": op.Index, "_id": op.ID, "status": status, "result": result},
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"took":   time.Since(start).Milliseconds(),
+		"errors": failed,
+		"items":  items,
+	})
+}
+
+type searchRequest struct {
+	Query map[string]interface{} `json:"query"`
+	From  int                    `json:"from"`
+	Size  *int                   `json:"size"`
+}
+
+type searchHit struct {
+	index string
+	doc   SearchDocument
+	score float64
+}
+
+func (s *SearchMock) handleSearch(w http.ResponseWriter, r *http.Request, index string, count bool) {
+	start := time.Now()
+
+	var req searchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		searchError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+		return
+	}
+	if v := r.URL.Query().Get("from"); v != "" {
+		req.From, _ = strconv.Atoi(v)
+	}
+	if v := r.URL.Query().Get("size"); v != "" {
+		size, _ := strconv.Atoi(v)
+		req.Size = &size
+	}
+
+	s.mu.Lock()
+	if index != "" {
+		if _, exists := s.indices[index]; !exists {
+			s.mu.Unlock()
+			searchError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+index+"]")
+			return
+		}
+	}
+	names := make([]string, 0, len(s.indices))
+	for name := range s.indices {
+		if index == "" || name == index {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var hits []searchHit
+	for _, name := range names {
+		for _, doc := range s.indices[name] {
+			matched, score, err := searchMatches(req.Query, doc.Source)
+			if err != nil {
+				s.mu.Unlock()
+				searchError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+				return
+			}
+			if matched {
+				hits = append(hits, searchHit{index: name, doc: doc, score: score})
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if count {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"count": len(hits)})
+		return
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+
+	total := len(hits)
+	size := 10
+	if req.Size != nil {
+		size = *req.Size
+	}
+	from := req.From
+	if from > total {
+		from = total
+	}
+	end := from + size
+	if end > total {
+		end = total
+	}
+
+	maxScore := 0.0
+	rendered := make([]interface{}, 0, end-from)
+	for _, hit := range hits[from:end] {
+		if hit.score > maxScore {
+			maxScore = hit.score
+		}
+		rendered = append(rendered, map[string]interface{}{
+			"_index":  hit.index,
+			"_id":     hit.doc.ID,
+			"_score":  hit.score,
+			"_source": hit.doc.Source,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"took":      time.Since(start).Milliseconds(),
+		"timed_out": false,
+		"hits": map[string]interface{}{
+			"total":     map[string]interface{}{"value": total, "relation": "eq"},
+			"max_score": maxScore,
+			"hits":      rendered,
+		},
+	})
+}
+
+// searchMatches evaluates a query DSL clause against a document source and
+// returns whether it matched and its score
+func searchMatches(query map[string]interface{}, source map[string]interface{}) (bool, float64, error) {
+	if len(query) == 0 {
+		return true, 1, nil
+	}
+
+	for kind, body := range query {
+		switch kind {
+		case "match_all":
+			return true, 1, nil
+		case "match", "match_phrase":
+			field, value, opts, err := searchFieldClause(kind, body)
+			if err != nil {
+				return false, 0, err
+			}
+			return searchMatchText(searchField(source, field), fmt.Sprint(value), opts["operator"] == "and" || kind == "match_phrase")
+		case "term":
+			field, value, _, err := searchFieldClause(kind, body)
+			if err != nil {
+				return false, 0, err
+			}
+			return searchTermMatches(searchField(source, field), value), 1, nil
+		case "terms":
+			clause, ok := body.(map[string]interface{})
+			if !ok {
+				return false, 0, fmt.Errorf("[terms] query malformed")
+			}
+			for field, values := range clause {
+				list, _ := values.([]interface{})
+				for _, value := range list {
+					if searchTermMatches(searchField(source, field), value) {
+						return true, 1, nil
+					}
+				}
+			}
+			return false, 0, nil
+		case "exists":
+			clause, _ := body.(map[string]interface{})
+			field, _ := clause["field"].(string)
+			return searchField(source, field) != nil, 1, nil
+		case "range":
+			field, _, opts, err := searchFieldClause(kind, body)
+			if err != nil {
+				return false, 0, err
+			}
+			return searchRangeMatches(searchField(source, field), opts), 1, nil
+		case "bool":
+			clause, ok := body.(map[string]interface{})
+			if !ok {
+				return false, 0, fmt.Errorf("[bool] query malformed")
+			}
+			return searchBoolMatches(clause, source)
+		default:
+			return false, 0, fmt.Errorf("unsupported query type [%s]", kind)
+		}
+	}
+	return false, 0, nil
+}
+
+func searchBoolMatches(clause map[string]interface{}, source map[string]interface{}) (bool, float64, error) {
+	score := 0.0
+
+	for _, occur := range []string{"must", "filter"} {
+		for _, sub := range searchClauses(clause[occur]) {
+			matched, subScore, err := searchMatches(sub, source)
+			if err != nil || !matched {
+				return false, 0, err
+			}
+			if occur == "must" {
+				score += subScore
+			}
+		}
+	}
+
+	for _, sub := range searchClauses(clause["must_not"]) {
+		matched, _, err := searchMatches(sub, source)
+		if err != nil || matched {
+			return false, 0, err
+		}
+	}
+
+	should := searchClauses(clause["should"])
+	shouldMatched := 0
+	for _, sub := range should {
+		matched, subScore, err := searchMatches(sub, source)
+		if err != nil {
+			return false, 0, err
+		}
+		if matched {
+			shouldMatched++
+			score += subScore
+		}
+	}
+
+	// should is optional when must/filter clauses are present
+	minimumShould := 0
+	if len(should) > 0 && clause["must"] == nil && clause["filter"] == nil {
+		minimumShould = 1
+	}
+	if v, ok := clause["minimum_should_match"].(float64); ok {
+		minimumShould = int(v)
+	}
+	if shouldMatched < minimumShould {
+		return false, 0, nil
+	}
+
+	if score == 0 {
+		score = 1
+	}
+	return true, score, nil
+}
+
+// searchClauses normalizes a bool occurrence, which may be an object or array
+func searchClauses(v interface{}) []map[string]interface{} {
+	switch clauses := v.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{clauses}
+	case []interface{}:
+		var out []map[string]interface{}
+		for _, clause := range clauses {
+			if m, ok := clause.(map[string]interface{}); ok {
+				out = append(out, m)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// searchFieldClause unpacks {"field": value} or {"field": {"query"|"value": v, ...}}
+func searchFieldClause(kind string, body interface{}) (string, interface{}, map[string]interface{}, error) {
+	clause, ok := body.(map[string]interface{})
+	if !ok || len(clause) != 1 {
+		return "", nil, nil, fmt.Errorf("[%s] query malformed, expected a single field", kind)
+	}
+	for field, value := range clause {
+		if opts, ok := value.(map[string]interface{}); ok {
+			if v, ok := opts["query"]; ok {
+				return field, v, opts, nil
+			}
+			return field, opts["value"], opts, nil
+		}
+		return field, value, map[string]interface{}{}, nil
+	}
+	return "", nil, nil, nil
+}
+
+// searchField resolves a dotted field path, ignoring a .keyword sub-field
+func searchField(source map[string]interface{}, field string) interface{} {
+	field = strings.TrimSuffix(field, ".keyword")
+	var current interface{} = source
+	for _, part := range strings.Split(field, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[part]
+	}
+	return current
+}
+
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	})
+}
+
+func searchMatchText(value interface{}, query string, all bool) (bool, float64, error) {
+	if value == nil {
+		return false, 0, nil
+	}
+
+	var text string
+	if list, ok := value.([]interface{}); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		text = strings.Join(parts, " ")
+	} else {
+		text = fmt.Sprint(value)
+	}
+
+	docTokens := make(map[string]int)
+	for _, token := range searchTokens(text) {
+		docTokens[token]++
+	}
+
+	matched := 0.0
+	queryTokens := searchTokens(query)
+	for _, token := range queryTokens {
+		if docTokens[token] > 0 {
+			matched++
+		} else if all {
+			return false, 0, nil
+		}
+	}
+	return matched > 0, matched, nil
+}
+
+func searchTermMatches(value, term interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if fmt.Sprint(item) == fmt.Sprint(term) {
+				return true
+			}
+		}
+		return false
+	}
+	return value != nil && fmt.Sprint(value) == fmt.Sprint(term)
+}
+
+func searchRangeMatches(value interface{}, bounds map[string]interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	compare := func(bound interface{}) int {
+		if a, ok := value.(float64); ok {
+			if b, ok := bound.(float64); ok {
+				switch {
+				case a < b:
+					return -1
+				case a > b:
+					return 1
+				}
+				return 0
+			}
+		}
+		// Fall back to lexical comparison, which orders ISO 8601 dates correctly
+		return strings.Compare(fmt.Sprint(value), fmt.Sprint(bound))
+	}
+
+	for op, bound := range bounds {
+		cmp := compare(bound)
+		switch op {
+		case "gt":
+			if cmp <= 0 {
+				return false
+			}
+		case "gte":
+			if cmp < 0 {
+				return false
+			}
+		case "lt":
+			if cmp >= 0 {
+				return false
+			}
+		case "lte":
+			if cmp > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// searchError writes an Elasticsearch error envelope
+func searchError(w http.ResponseWriter, status int, errorType, reason string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":       errorType,
+			"reason":     reason,
+			"root_cause": []interface{}{map[string]interface{}{"type": errorType, "reason": reason}},
+		},
+		"status": status,
+	})
+}
@@ -0,0 +1,80 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSearchMock(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	search, err := server.Search()
+	if err != nil {
+		t.Fatalf("Failed to start search preset: %v", err)
+	}
+
+	search.Seed("products",
+		SearchDocument{ID: "1", Source: map[string]interface{}{"name": "Red running shoes", "brand": "acme", "price": 80.0}},
+		SearchDocument{ID: "2", Source: map[string]interface{}{"name": "Blue running shorts", "brand": "acme", "price": 30.0}},
+		SearchDocument{ID: "3", Source: map[string]interface{}{"name": "Red dress", "brand": "globex", "price": 120.0}},
+	)
+
+	query := func(body string) []string {
+		resp, err := http.Post(search.URL()+"/products/_search", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Hits struct {
+				Hits []struct {
+					ID string `json:"_id"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		var ids []string
+		for _, hit := range result.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	t.Run("bool query with match, term, and range", func(t *testing.T) {
+		ids := query(`{"query":{"bool":{"must":[{"match":{"name":"running"}}],"filter":[{"term":{"brand.keyword":"acme"}},{"range":{"price":{"gte":50}}}]}}}`)
+		if len(ids) != 1 || ids[0] != "1" {
+			t.Errorf("Expected [1], got %v", ids)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		ids := query(`{"query":{"match_all":{}},"from":1,"size":1}`)
+		if len(ids) != 1 || ids[0] != "2" {
+			t.Errorf("Expected [2], got %v", ids)
+		}
+	})
+
+	t.Run("bulk indexing is captured and searchable", func(t *testing.T) {
+		body := "{\"index\":{\"_index\":\"products\",\"_id\":\"4\"}}\n{\"name\":\"Green running jacket\"}\n{\"delete\":{\"_index\":\"products\",\"_id\":\"3\"}}\n"
+		resp, err := http.Post(search.URL()+"/_bulk", "application/x-ndjson", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Bulk failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if ops := search.BulkOperations(); len(ops) != 2 || ops[0].Action != "index" || ops[1].Action != "delete" {
+			t.Errorf("Expected index and delete operations, got %+v", ops)
+		}
+		if ids := query(`{"query":{"match":{"name":"red"}}}`); len(ids) != 1 {
+			t.Errorf("Expected deleted document to be gone, got %v", ids)
+		}
+		if ids := query(`{"query":{"match":{"name":"jacket"}}}`); len(ids) != 1 || ids[0] != "4" {
+			t.Errorf("Expected [4], got %v", ids)
+		}
+	})
+}
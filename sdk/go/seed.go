@@ -0,0 +1,21 @@
+package mockforge
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// seedEnv returns the environment variable seeding the CLI's generators
+func seedEnv(seed int64) []string {
+	if seed == 0 {
+		return nil
+	}
+	return []string{"MOCKFORGE_RANDOM_SEED=" + strconv.FormatInt(seed, 10)}
+}
+
+// SetSeed reseeds the random source behind faker and template generated
+// values such as {{uuid}} and {{faker.email}}, so the values generated from
+// now on repeat across runs
+func (m *MockServer) SetSeed(seed int64) error {
+	return m.adminJSON("set random seed", http.MethodPut, "/__mockforge/api/config/random-seed", map[string]interface{}{"seed": seed}, nil)
+}
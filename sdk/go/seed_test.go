@@ -0,0 +1,31 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRandomSeed(t *testing.T) {
+	if env := seedEnv(42); len(env) != 1 || env[0] != "MOCKFORGE_RANDOM_SEED=42" {
+		t.Errorf("Expected seed env var, got %v", env)
+	}
+	if env := seedEnv(0); env != nil {
+		t.Errorf("Expected no env var for zero seed, got %v", env)
+	}
+
+	var update map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/__mockforge/api/config/random-seed" {
+			t.Errorf("Unexpected request to %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	if err := server.SetSeed(7); err != nil {
+		t.Fatalf("Failed to set seed: %v", err)
+	}
+	if update["seed"] != float64(7) {
+		t.Errorf("Expected random seed 7, got %+v", update)
+	}
+}
@@ -0,0 +1,194 @@
+package mockforge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SequenceOption configures VerifySequence
+type SequenceOption func(*sequenceConfig)
+
+type sequenceConfig struct {
+	counts []VerificationCount
+	strict bool
+}
+
+// StepCounts sets how many requests each step of the sequence must match,
+// in step order. Steps without a count must match at least once.
+func StepCounts(counts ...VerificationCount) SequenceOption {
+	return func(c *sequenceConfig) {
+		c.counts = append([]VerificationCount(nil), counts...)
+	}
+}
+
+// StrictOrder fails the sequence when a request matching one of its steps
+// arrives out of place. By default such requests are ignored, so other
+// matching calls may be interleaved.
+func StrictOrder() SequenceOption {
+	return func(c *sequenceConfig) {
+		c.strict = true
+	}
+}
+
+// VerifySequence verifies that requests matching patterns arrived in order.
+// Each pattern is a step that must match at least once, or as often as
+// StepCounts requires, after the requests of the previous step. When the
+// sequence does not hold, the result's FailedStep is the index of the first
+// step that failed.
+//
+// The result's Count is the number of satisfied steps and Matches holds the
+// requests assigned to steps.
+func (m *MockServer) VerifySequence(patterns []VerificationRequest, opts ...SequenceOption) (*VerificationResult, error) {
+	steps := make([]sequenceStep, len(patterns))
+	for i, pattern := range patterns {
+		steps[i] = sequenceStep{server: m, pattern: pattern}
+	}
+	result, err := verifySequence(steps, opts)
+	if err == nil {
+		m.recordVerification("VerifySequence "+describeSteps(steps), result)
+	}
+	return result, err
+}
+
+// sequenceStep is a step of a sequence, matched on one server. name
+// identifies the server in a VerificationGroup.
+type sequenceStep struct {
+	server  *MockServer
+	name    string
+	pattern VerificationRequest
+}
+
+// describe formats the step for failure messages
+func (s sequenceStep) describe() string {
+	if s.name == "" {
+		return describePattern(s.pattern)
+	}
+	return s.name + ": " + describePattern(s.pattern)
+}
+
+// verifySequence checks that requests matching steps arrived in order
+func verifySequence(steps []sequenceStep, opts []SequenceOption) (*VerificationResult, error) {
+	config := &sequenceConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	counts := make([]VerificationCount, len(steps))
+	for i := range counts {
+		counts[i] = AtLeastOnce()
+		if i < len(config.counts) && config.counts[i].Type != "" {
+			counts[i] = config.counts[i]
+		}
+	}
+
+	requests, stepMatches, err := sequenceRequests(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the requests in arrival order, advancing to the next step as soon
+	// as the current one is satisfied and the request belongs to the next
+	step, matched := 0, make([]int, len(steps))
+	result := &VerificationResult{Expected: Exactly(len(steps)), Matches: []map[string]interface{}{}}
+	fail := func(index int, message string) (*VerificationResult, error) {
+		result.FailedStep = &index
+		result.ErrorMessage = &message
+		return result, nil
+	}
+	for _, request := range requests {
+		matchesStep := func(i int) bool { return i < len(steps) && stepMatches[request.key][i] }
+		switch {
+		case matchesStep(step+1) && counts[step].Satisfied(matched[step]):
+			step++
+		case matchesStep(step):
+		default:
+			if config.strict {
+				return fail(step, fmt.Sprintf("sequence failed at step %d: unexpected %s%s %s out of order", step, request.origin, request.logged.Method, request.logged.Path))
+			}
+			continue
+		}
+		matched[step]++
+		result.Matches = append(result.Matches, request.raw)
+	}
+
+	for i, count := range counts {
+		if !count.Satisfied(matched[i]) {
+			return fail(i, fmt.Sprintf("sequence failed at step %d (%s): expected %s in order, got %d", i, steps[i].describe(), count, matched[i]))
+		}
+		result.Count++
+	}
+	result.Matched = true
+	return result, nil
+}
+
+// sequenceRequest is a logged request matching at least one sequence step.
+// origin names the server it was sent to, as a prefix for messages.
+type sequenceRequest struct {
+	key    string
+	origin string
+	raw    map[string]interface{}
+	logged LoggedRequest
+}
+
+// sequenceRequests returns the requests matching any step, oldest first,
+// and for each request key which steps it matches. Matching is done by the
+// servers, one query per step.
+func sequenceRequests(steps []sequenceStep) ([]sequenceRequest, map[string][]bool, error) {
+	var requests []sequenceRequest
+	stepMatches := make(map[string][]bool)
+	for i, step := range steps {
+		result, err := step.server.verify(step.pattern, AtLeast(0))
+		if err != nil {
+			return nil, nil, err
+		}
+		logged, err := decodeLoggedRequests(result.Matches)
+		if err != nil {
+			return nil, nil, err
+		}
+		origin := ""
+		if step.name != "" {
+			origin = step.name + ": "
+		}
+		// The server lists the most recent request first
+		for j := len(logged) - 1; j >= 0; j-- {
+			entry := logged[j]
+			key := entry.ID
+			if key == "" {
+				key = fmt.Sprintf("%s %s %s", entry.Timestamp.Format(time.RFC3339Nano), entry.Method, entry.Path)
+			}
+			key = origin + key
+			if _, seen := stepMatches[key]; !seen {
+				stepMatches[key] = make([]bool, len(steps))
+				requests = append(requests, sequenceRequest{key: key, origin: origin, raw: result.Matches[j], logged: entry})
+			}
+			stepMatches[key][i] = true
+		}
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].logged.Timestamp.Before(requests[j].logged.Timestamp)
+	})
+	return requests, stepMatches, nil
+}
+
+// describeSteps formats the steps of a sequence for reports
+func describeSteps(steps []sequenceStep) string {
+	described := make([]string, len(steps))
+	for i, step := range steps {
+		described[i] = step.describe()
+	}
+	return strings.Join(described, " -> ")
+}
+
+// describePattern formats a verification pattern for failure messages
+func describePattern(pattern VerificationRequest) string {
+	method, path := pattern.Method, pattern.Path
+	if method == "" {
+		method = "*"
+	}
+	if path == "" {
+		path = "*"
+	}
+	return strings.ToUpper(method) + " " + path
+}
@@ -0,0 +1,75 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestVerifySequence(t *testing.T) {
+	// Requests in arrival order; the server lists them most recent first
+	logged := []map[string]interface{}{
+		{"id": "1", "timestamp": "2024-01-01T00:00:00Z", "method": "POST", "path": "/login"},
+		{"id": "2", "timestamp": "2024-01-01T00:00:01Z", "method": "GET", "path": "/cart"},
+		{"id": "3", "timestamp": "2024-01-01T00:00:02Z", "method": "POST", "path": "/cart/items"},
+		{"id": "4", "timestamp": "2024-01-01T00:00:03Z", "method": "POST", "path": "/cart/items"},
+		{"id": "5", "timestamp": "2024-01-01T00:00:04Z", "method": "GET", "path": "/cart"},
+		{"id": "6", "timestamp": "2024-01-01T00:00:05Z", "method": "POST", "path": "/checkout"},
+	}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pattern VerificationRequest `json:"pattern"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		matches := []map[string]interface{}{}
+		for i := len(logged) - 1; i >= 0; i-- {
+			if logged[i]["method"] == body.Pattern.Method && logged[i]["path"] == body.Pattern.Path {
+				matches = append(matches, logged[i])
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"matched": true, "count": len(matches), "matches": matches})
+	}))
+
+	login := VerificationRequest{Method: "POST", Path: "/login"}
+	addItem := VerificationRequest{Method: "POST", Path: "/cart/items"}
+	viewCart := VerificationRequest{Method: "GET", Path: "/cart"}
+	checkout := VerificationRequest{Method: "POST", Path: "/checkout"}
+
+	t.Run("loose order ignores interleaved calls", func(t *testing.T) {
+		result, err := server.VerifySequence([]VerificationRequest{login, addItem, checkout}, StepCounts(Exactly(1), Exactly(2)))
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		if !result.Matched || result.Count != 3 || len(result.Matches) != 4 {
+			t.Errorf("Expected the sequence to hold with 4 requests, got %+v", result)
+		}
+	})
+
+	t.Run("per-step counts report the failed step", func(t *testing.T) {
+		result, err := server.VerifySequence([]VerificationRequest{login, addItem, checkout}, StepCounts(Exactly(1), Exactly(3)))
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		if result.Matched || result.FailedStep == nil || *result.FailedStep != 1 {
+			t.Errorf("Expected step 1 to fail, got %+v", result)
+		}
+	})
+
+	t.Run("strict order rejects out-of-place calls", func(t *testing.T) {
+		result, err := server.VerifySequence([]VerificationRequest{viewCart, addItem, checkout}, StrictOrder())
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		if result.Matched || result.FailedStep == nil || *result.FailedStep != 1 {
+			t.Errorf("Expected the second cart view to fail step 1, got %+v", result)
+		}
+
+		result, err = server.VerifySequence([]VerificationRequest{viewCart, addItem, checkout})
+		if err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+		if !result.Matched {
+			t.Errorf("Expected the loose sequence to hold, got %s", *result.ErrorMessage)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package mockforge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeServerConfig writes the config file the server starts with to a new
+// temporary directory: ConfigFile, if set, with the settings registered
+// before Start merged in. It returns ConfigFile unchanged when nothing was
+// registered, and the directory to remove on Stop otherwise.
+func (m *MockServer) writeServerConfig() (path, dir string, err error) {
+	m.mu.Lock()
+	descriptors := m.grpcDescriptors
+	overrides := append([]grpcOverride(nil), m.grpcOverrides...)
+	tcpFixtures := m.tcpFixtures
+	m.mu.Unlock()
+	smtp := m.config.SMTP || m.config.IMAP || m.config.POP3
+	if len(descriptors) == 0 && len(overrides) == 0 && len(tcpFixtures) == 0 && !smtp && !m.config.FTP {
+		return m.config.ConfigFile, "", nil
+	}
+
+	config, err := m.readConfigFile()
+	if err != nil {
+		return "", "", err
+	}
+	grpc := configSection(config, "grpc")
+	if _, ok := grpc["proto_dir"]; ok && len(descriptors) > 0 {
+		return "", "", NewInvalidConfigError("registered proto descriptors cannot be combined with the config file's grpc.proto_dir; register those protos with CompileProtos too", map[string]interface{}{"config_file": m.config.ConfigFile})
+	}
+	if tcp, _ := config["tcp"].(map[string]interface{}); tcp["fixtures_dir"] != nil && len(tcpFixtures) > 0 {
+		return "", "", NewInvalidConfigError("StubTCP stubs cannot be combined with the config file's tcp.fixtures_dir", map[string]interface{}{"config_file": m.config.ConfigFile})
+	}
+
+	dir, err = os.MkdirTemp("", "mockforge-config-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	if len(descriptors) > 0 {
+		protoDir := filepath.Join(dir, "proto")
+		if err := os.Mkdir(protoDir, 0o755); err != nil {
+			return "", "", fmt.Errorf("failed to create proto directory: %w", err)
+		}
+		for i, descriptorSet := range descriptors {
+			file := filepath.Join(protoDir, fmt.Sprintf("descriptors_%d.binpb", i+1))
+			if err := os.WriteFile(file, descriptorSet, 0o644); err != nil {
+				return "", "", fmt.Errorf("failed to write proto descriptors: %w", err)
+			}
+		}
+		grpc["proto_dir"] = protoDir
+	}
+
+	if len(overrides) > 0 {
+		// The server applies the first matching rule, so stubs with a request
+		// matcher go first, and all stubs before the config file's own rules
+		sort.SliceStable(overrides, func(i, j int) bool { return len(overrides[i].Match) > len(overrides[j].Match) })
+		rules := make([]interface{}, 0, len(overrides))
+		for _, override := range overrides {
+			rules = append(rules, override)
+		}
+		existing, _ := grpc["overrides"].([]interface{})
+		grpc["overrides"] = append(rules, existing...)
+	}
+
+	if smtp {
+		section := configSection(config, "smtp")
+		section["enabled"] = true
+		section["enable_mailbox"] = true
+		if m.config.SMTPPort != 0 {
+			section["port"] = m.config.SMTPPort
+		} else if _, ok := section["port"]; !ok {
+			section["port"] = 0
+		}
+		if _, ok := section["host"]; !ok {
+			section["host"] = m.host
+		}
+		// The readers always pick free ports, reported like the SMTP port
+		if m.config.IMAP {
+			section["imap_port"] = 0
+		}
+		if m.config.POP3 {
+			section["pop3_port"] = 0
+		}
+	}
+
+	if len(tcpFixtures) > 0 {
+		fixturesDir := filepath.Join(dir, "tcp-fixtures")
+		if err := os.Mkdir(fixturesDir, 0o755); err != nil {
+			return "", "", fmt.Errorf("failed to create TCP fixtures directory: %w", err)
+		}
+		data, err := yaml.Marshal(tcpFixtures)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to encode TCP stubs: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(fixturesDir, "stubs.yaml"), data, 0o644); err != nil {
+			return "", "", fmt.Errorf("failed to write TCP stubs: %w", err)
+		}
+		tcp := configSection(config, "tcp")
+		tcp["enabled"] = true
+		tcp["fixtures_dir"] = fixturesDir
+		if m.config.TCPPort != 0 {
+			tcp["port"] = m.config.TCPPort
+		} else if _, ok := tcp["port"]; !ok {
+			tcp["port"] = 0
+		}
+		if _, ok := tcp["host"]; !ok {
+			tcp["host"] = m.host
+		}
+		// Unmatched data is a test bug, so it disconnects instead of echoing
+		if _, ok := tcp["echo_mode"]; !ok {
+			tcp["echo_mode"] = false
+		}
+	}
+
+	if m.config.FTP {
+		section := configSection(config, "ftp")
+		section["enabled"] = true
+		if m.config.FTPPort != 0 {
+			section["port"] = m.config.FTPPort
+		} else if _, ok := section["port"]; !ok {
+			section["port"] = 0
+		}
+		if _, ok := section["host"]; !ok {
+			section["host"] = m.host
+		}
+		// The server only stores uploads an upload rule accepts; without
+		// fixtures of their own, tests get a rule storing every upload
+		if _, ok := section["fixtures_dir"]; !ok {
+			fixturesDir := filepath.Join(dir, "ftp-fixtures")
+			if err := os.Mkdir(fixturesDir, 0o755); err != nil {
+				return "", "", fmt.Errorf("failed to create FTP fixtures directory: %w", err)
+			}
+			if err := os.WriteFile(filepath.Join(fixturesDir, "uploads.yaml"), []byte(ftpUploadFixture), 0o644); err != nil {
+				return "", "", fmt.Errorf("failed to write FTP fixture: %w", err)
+			}
+			section["fixtures_dir"] = fixturesDir
+		}
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	path = filepath.Join(dir, "mockforge.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to write config: %w", err)
+	}
+	return path, dir, nil
+}
+
+// ftpUploadFixture is the FTP fixture accepting uploads to any path into
+// the server's in-memory file system
+const ftpUploadFixture = `identifier: mockforge-sdk-uploads
+name: Accept all uploads
+virtual_files: []
+upload_rules:
+  - path_pattern: ".*"
+    auto_accept: true
+    storage:
+      type: memory
+`
+
+// configSection returns the named section of config, adding it if missing
+func configSection(config map[string]interface{}, name string) map[string]interface{} {
+	section, _ := config[name].(map[string]interface{})
+	if section == nil {
+		section = map[string]interface{}{}
+		config[name] = section
+	}
+	return section
+}
+
+// readConfigFile parses ConfigFile for writeServerConfig to extend, or
+// returns an empty config without one
+func (m *MockServer) readConfigFile() (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if m.config.ConfigFile == "" {
+		return config, nil
+	}
+	details := map[string]interface{}{"config_file": m.config.ConfigFile}
+	switch strings.ToLower(filepath.Ext(m.config.ConfigFile)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, NewInvalidConfigError("gRPC, TCP, SMTP, and FTP settings require a YAML or JSON config file", details)
+	}
+	data, err := os.ReadFile(m.config.ConfigFile)
+	if err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to read config file: %v", err), details)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to parse config file: %v", err), details)
+	}
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	// The server also resolves a relative OpenAPI spec against the config
+	// file's directory, which the written config does not share
+	if http, ok := config["http"].(map[string]interface{}); ok {
+		if spec, ok := http["openapi_spec"].(string); ok && spec != "" && !filepath.IsAbs(spec) {
+			if _, err := os.Stat(spec); err != nil {
+				if configDir, err := filepath.Abs(filepath.Dir(m.config.ConfigFile)); err == nil {
+					http["openapi_spec"] = filepath.Join(configDir, spec)
+				}
+			}
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,117 @@
+package mockforge
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// httpSidecar is an in-process HTTP listener owned by a MockServer. Sidecars
+// receive traffic for protocols the MockForge CLI does not terminate itself
+// (OTLP exports, webhooks fired at the test, ...) and are closed on Stop.
+type httpSidecar struct {
+	listener net.Listener
+	server   *http.Server
+}
+
+// newHTTPSidecar starts serving handler on a random port of host
+func newHTTPSidecar(host string, handler http.Handler) (*httpSidecar, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start listener: %w", err)
+	}
+
+	s := &httpSidecar{
+		listener: listener,
+		server:   &http.Server{Handler: handler},
+	}
+	go s.server.Serve(listener)
+
+	return s, nil
+}
+
+// URL returns the base URL of the sidecar
+func (s *httpSidecar) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// Close stops the sidecar
+func (s *httpSidecar) Close() error {
+	return s.server.Close()
+}
+
+// udpSidecar is an in-process UDP listener owned by a MockServer. Every
+// datagram received is passed to the handler on the reader goroutine.
+type udpSidecar struct {
+	conn *net.UDPConn
+	done sync.WaitGroup
+}
+
+// newUDPSidecar starts reading datagrams on a random port of host
+func newUDPSidecar(host string, handle func([]byte)) (*udpSidecar, error) {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve listen address: %w", err)
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start listener: %w", err)
+	}
+
+	s := &udpSidecar{conn: conn}
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		buf := make([]byte, 64*1024)
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			datagram := make([]byte, n)
+			copy(datagram, buf[:n])
+			handle(datagram)
+		}
+	}()
+
+	return s, nil
+}
+
+// Addr returns the host:port the sidecar is listening on
+func (s *udpSidecar) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// Close stops the sidecar and waits for the reader goroutine to exit
+func (s *udpSidecar) Close() error {
+	err := s.conn.Close()
+	s.done.Wait()
+	return err
+}
+
+// closeAttached closes every sidecar owned by the server and forgets the sinks
+// and presets tied to this run
+func (m *MockServer) closeAttached() {
+	m.mu.Lock()
+	attached := m.attached
+	m.attached = nil
+	m.logSink = nil
+	m.metricsSink = nil
+	m.push = nil
+	m.twilio = nil
+	m.search = nil
+	m.uploads = nil
+	m.federation = nil
+	m.resources = nil
+	m.webhooks = nil
+	m.oidc = nil
+	m.s3 = nil
+	m.recording = nil
+	m.mu.Unlock()
+
+	for _, c := range attached {
+		c.Close()
+	}
+}
@@ -0,0 +1,324 @@
+package mockforge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SOAP envelope namespaces of SOAP 1.1 and 1.2
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAPFault returns a SOAP 1.1 envelope carrying a fault, for use as the
+// response of StubSOAP. code is a fault code such as "soap:Server".
+func SOAPFault(code, message string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(message))
+	return `<soap:Envelope xmlns:soap="` + soap11Namespace + `"><soap:Body><soap:Fault>` +
+		`<faultcode>` + code + `</faultcode><faultstring>` + escaped.String() + `</faultstring>` +
+		`</soap:Fault></soap:Body></soap:Envelope>`
+}
+
+// StubSOAP answers SOAP requests for action with responseEnvelope. The action
+// is recognized from the SOAPAction header (SOAP 1.1) or the action
+// parameter of the Content-Type (SOAP 1.2). requestXPathMatchers maps XPath
+// expressions to the text the request must have there, see
+// StubBuilder.WhenXPath; stubs with more matchers take precedence. Envelopes
+// containing a Fault are served with status 500, as SOAP requires. Stubs
+// match any path unless MockServerConfig.SOAPPath is set.
+//
+//	server.StubSOAP("urn:GetOrder", map[string]string{"//GetOrder/id": "42"},
+//	    `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">...</soap:Envelope>`)
+func (m *MockServer) StubSOAP(action string, requestXPathMatchers map[string]string, responseEnvelope string) error {
+	stubs, err := m.soapStubs(action, requestXPathMatchers, responseEnvelope)
+	if err != nil {
+		return err
+	}
+	for _, stub := range stubs {
+		if err := m.AddStub(stub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// soapStubs builds the HTTP stubs StubSOAP registers, one per way of
+// carrying the action
+func (m *MockServer) soapStubs(action string, requestXPathMatchers map[string]string, responseEnvelope string) ([]ResponseStub, error) {
+	if action == "" {
+		return nil, NewInvalidConfigError("SOAP action must not be empty", nil)
+	}
+	root, err := parseXMLTree([]byte(responseEnvelope))
+	if err != nil || root.name != "Envelope" {
+		return nil, NewInvalidConfigError("SOAP response must be an XML Envelope", map[string]interface{}{"action": action})
+	}
+	for xpath := range requestXPathMatchers {
+		if _, _, err := parseXPath(xpath); err != nil {
+			return nil, err
+		}
+	}
+
+	status := http.StatusOK
+	if root.find("Body", "Fault") {
+		status = http.StatusInternalServerError
+	}
+	contentType := "text/xml; charset=utf-8"
+	if root.space == soap12Namespace {
+		contentType = "application/soap+xml; charset=utf-8"
+	}
+
+	path := m.config.SOAPPath
+	if path == "" {
+		path = PathRegex("^/")
+	}
+	quoted := regexp.QuoteMeta(action)
+	actionHeaders := []struct{ name, pattern string }{
+		{"SOAPAction", `^"?` + quoted + `"?$`},
+		{"Content-Type", `;\s*action="?` + quoted + `"?(;|$)`},
+	}
+
+	stubs := make([]ResponseStub, len(actionHeaders))
+	for i, header := range actionHeaders {
+		builder := NewStubBuilder(http.MethodPost, path).
+			Status(status).
+			Header("Content-Type", contentType).
+			BodyBytes([]byte(responseEnvelope)).
+			WhenHeaderMatches(header.name, header.pattern).
+			Priority(len(requestXPathMatchers))
+		for xpath, value := range requestXPathMatchers {
+			builder.WhenXPath(xpath, value)
+		}
+		stubs[i] = builder.Build()
+	}
+	return stubs, nil
+}
+
+// VerifySOAP asserts how many SOAP requests for action were received whose
+// envelope has the text given by each of xpathMatchers
+func (m *MockServer) VerifySOAP(action string, xpathMatchers map[string]string, count VerificationCount) error {
+	for xpath := range xpathMatchers {
+		if _, _, err := parseXPath(xpath); err != nil {
+			return err
+		}
+	}
+
+	result, err := m.verify(VerificationRequest{Method: http.MethodPost, Path: m.config.SOAPPath}, AtLeast(0))
+	if err != nil {
+		return err
+	}
+	requests, err := decodeLoggedRequests(result.Matches)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, request := range requests {
+		if soapAction(request.Headers) != action {
+			continue
+		}
+		root, err := parseXMLTree([]byte(request.Body))
+		if err != nil {
+			continue
+		}
+		matched := true
+		for xpath, value := range xpathMatchers {
+			if !root.matchXPath(xpath, value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			n++
+		}
+	}
+	if !count.Satisfied(n) {
+		return fmt.Errorf("expected %s SOAP request(s) for %q matching %s, got %d", count, action, describeXPaths(xpathMatchers), n)
+	}
+	return nil
+}
+
+// soapAction returns the action of a logged request, from its SOAPAction
+// header or Content-Type action parameter
+func soapAction(headers map[string]string) string {
+	for name, value := range headers {
+		switch strings.ToLower(name) {
+		case "soapaction":
+			return strings.Trim(value, `"`)
+		case "content-type":
+			for _, param := range strings.Split(value, ";") {
+				if action, ok := strings.CutPrefix(strings.TrimSpace(param), "action="); ok {
+					return strings.Trim(action, `"`)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// describeXPaths formats XPath matchers for failure messages
+func describeXPaths(xpathMatchers map[string]string) string {
+	if len(xpathMatchers) == 0 {
+		return "any envelope"
+	}
+	parts := make([]string, 0, len(xpathMatchers))
+	for xpath, value := range xpathMatchers {
+		parts = append(parts, fmt.Sprintf("%s=%q", xpath, value))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// xmlNode is an element of a parsed XML document
+type xmlNode struct {
+	space    string
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+// parseXMLTree parses an XML document into its root element
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{space: t.Name.Space, name: t.Name.Local}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// find reports whether the path of child names exists below the node
+func (n *xmlNode) find(names ...string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, child := range n.children {
+		if child.name == names[0] && child.find(names[1:]...) {
+			return true
+		}
+	}
+	return false
+}
+
+// xpathSegment is a step of an XPath, optionally filtered by text
+type xpathSegment struct {
+	name    string
+	text    string
+	hasText bool
+}
+
+// xpathTextPredicate matches a segment with a [text()="..."] filter
+var xpathTextPredicate = regexp.MustCompile(`^([^\[\]]+)\[text\(\)=(?:"([^"]*)"|'([^']*)')\]$`)
+
+// parseXPath parses the XPath subset the server supports: absolute or
+// descendant (//) paths of element names, each optionally filtered with
+// [text()="..."]
+func parseXPath(xpath string) (descendant bool, segments []xpathSegment, err error) {
+	invalid := NewInvalidConfigError("XPath must be /a/b or //a/b, optionally filtered with [text()=\"...\"]", map[string]interface{}{"xpath": xpath})
+	rest, descendant := strings.CutPrefix(xpath, "//")
+	if !descendant {
+		var ok bool
+		if rest, ok = strings.CutPrefix(xpath, "/"); !ok {
+			return false, nil, invalid
+		}
+	}
+	for _, part := range strings.Split(rest, "/") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if match := xpathTextPredicate.FindStringSubmatch(part); match != nil {
+			segments = append(segments, xpathSegment{name: strings.TrimSpace(match[1]), text: match[2] + match[3], hasText: true})
+		} else if strings.ContainsAny(part, "[]()=@*") {
+			return false, nil, invalid
+		} else {
+			segments = append(segments, xpathSegment{name: part})
+		}
+	}
+	if len(segments) == 0 {
+		return false, nil, invalid
+	}
+	return descendant, segments, nil
+}
+
+// matchXPath reports whether xpath selects an element whose trimmed text is
+// value, or any element if value is empty, as the server evaluates it
+func (n *xmlNode) matchXPath(xpath, value string) bool {
+	descendant, segments, err := parseXPath(xpath)
+	if err != nil {
+		return false
+	}
+	if value != "" {
+		last := &segments[len(segments)-1]
+		last.text, last.hasText = value, true
+	}
+
+	var starts []*xmlNode
+	if descendant {
+		var walk func(node *xmlNode)
+		walk = func(node *xmlNode) {
+			if node.matchSegment(segments[0]) {
+				starts = append(starts, node)
+			}
+			for _, child := range node.children {
+				walk(child)
+			}
+		}
+		walk(n)
+	} else if n.matchSegment(segments[0]) {
+		starts = append(starts, n)
+	}
+
+	frontier := starts
+	for _, segment := range segments[1:] {
+		var next []*xmlNode
+		for _, node := range frontier {
+			for _, child := range node.children {
+				if child.matchSegment(segment) {
+					next = append(next, child)
+				}
+			}
+		}
+		frontier = next
+	}
+	return len(frontier) > 0
+}
+
+// matchSegment reports whether the node satisfies one XPath step
+func (n *xmlNode) matchSegment(segment xpathSegment) bool {
+	return n.name == segment.name && (!segment.hasText || strings.TrimSpace(n.text) == segment.text)
+}
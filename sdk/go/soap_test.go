@@ -0,0 +1,53 @@
+package mockforge
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSOAPStubs(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	envelope := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><GetOrderResponse><status>shipped</status></GetOrderResponse></soap:Body></soap:Envelope>`
+
+	stubs, err := server.soapStubs("urn:GetOrder", map[string]string{"//GetOrder/id": "42"}, envelope)
+	if err != nil {
+		t.Fatalf("Failed to build SOAP stubs: %v", err)
+	}
+	if len(stubs) != 2 || stubs[0].Status != http.StatusOK || stubs[0].Match.XPaths["//GetOrder/id"] != "42" {
+		t.Errorf("Unexpected SOAP stubs: %+v", stubs)
+	}
+	if stubs[0].Headers["Content-Type"] != "text/xml; charset=utf-8" {
+		t.Errorf("Expected SOAP 1.1 content type, got %q", stubs[0].Headers["Content-Type"])
+	}
+
+	fault, err := server.soapStubs("urn:GetOrder", nil, SOAPFault("soap:Server", "order <42> locked"))
+	if err != nil || fault[0].Status != http.StatusInternalServerError {
+		t.Errorf("Expected fault to be served with status 500, got %+v, %v", fault, err)
+	}
+
+	if _, err := server.soapStubs("urn:GetOrder", nil, "<html/>"); err == nil {
+		t.Error("Expected error for a response that is not an envelope")
+	}
+	if _, err := server.soapStubs("urn:GetOrder", map[string]string{"GetOrder/id": "42"}, envelope); err == nil {
+		t.Error("Expected error for a relative XPath")
+	}
+}
+
+func TestVerifySOAP(t *testing.T) {
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"matched":true,"count":3,"expected":{"type":"at_least","value":0},"matches":[
+			{"method":"POST","path":"/orders","headers":{"soapaction":"\"urn:GetOrder\""},"body":"<s:Envelope xmlns:s=\"urn:s\"><s:Body><GetOrder><id> 42 </id></GetOrder></s:Body></s:Envelope>"},
+			{"method":"POST","path":"/orders","headers":{"content-type":"application/soap+xml; action=urn:GetOrder"},"body":"<Envelope><Body><GetOrder><id>7</id></GetOrder></Body></Envelope>"},
+			{"method":"POST","path":"/orders","headers":{"soapaction":"urn:CancelOrder"},"body":"<Envelope><Body><CancelOrder><id>42</id></CancelOrder></Body></Envelope>"}]}`))
+	}))
+
+	if err := server.VerifySOAP("urn:GetOrder", map[string]string{"//GetOrder/id": "42"}, Exactly(1)); err != nil {
+		t.Errorf("Expected one matching request, got %v", err)
+	}
+	if err := server.VerifySOAP("urn:GetOrder", nil, Exactly(2)); err != nil {
+		t.Errorf("Expected both SOAP versions to count, got %v", err)
+	}
+	if err := server.VerifySOAP("urn:GetOrder", map[string]string{"/Envelope/Body/GetOrder/id": "8"}, AtLeastOnce()); err == nil {
+		t.Error("Expected error when no request matches")
+	}
+}
@@ -0,0 +1,207 @@
+package mockforge
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StubOverride adjusts the stub StubFromSpec generates
+type StubOverride func(*specStubConfig)
+
+// specStubConfig is the generation configuration of StubFromSpec
+type specStubConfig struct {
+	status  int
+	fields  []specField
+	headers map[string]string
+}
+
+// specField is a body field override
+type specField struct {
+	path  string
+	value interface{}
+}
+
+// WithStatus answers with the operation's response for status instead of
+// its first success response
+func WithStatus(status int) StubOverride {
+	return func(c *specStubConfig) {
+		c.status = status
+	}
+}
+
+// WithField sets a field of the generated body. The path is dot-separated,
+// with numeric segments indexing arrays, e.g. "items.0.name".
+func WithField(path string, value interface{}) StubOverride {
+	return func(c *specStubConfig) {
+		c.fields = append(c.fields, specField{path: path, value: value})
+	}
+}
+
+// WithResponseHeader adds a header to the generated response
+func WithResponseHeader(name, value string) StubOverride {
+	return func(c *specStubConfig) {
+		c.headers[name] = value
+	}
+}
+
+// StubFromSpec registers a stub for the operation with operationID in the
+// server's OpenAPI spec. The body is the response's example if the spec has
+// one, otherwise a value generated from the response schema, with any
+// WithField overrides applied on top.
+func (m *MockServer) StubFromSpec(operationID string, overrides ...StubOverride) error {
+	if m.config.OpenAPISpec == "" {
+		return NewInvalidConfigError("StubFromSpec requires an OpenAPI spec", nil)
+	}
+	stub, err := stubFromSpec(m.config.OpenAPISpec, operationID, overrides...)
+	if err != nil {
+		return err
+	}
+	return m.AddStub(*stub)
+}
+
+// stubFromSpec builds the stub StubFromSpec registers
+func stubFromSpec(specPath, operationID string, overrides ...StubOverride) (*ResponseStub, error) {
+	config := &specStubConfig{headers: make(map[string]string)}
+	for _, override := range overrides {
+		override(config)
+	}
+
+	doc, err := loadOpenAPIDocument(specPath)
+	if err != nil {
+		return nil, err
+	}
+	var op *openAPIOperation
+	for _, candidate := range doc.operations() {
+		if id, _ := candidate.Node["operationId"].(string); id == operationID {
+			op = &candidate
+			break
+		}
+	}
+	if op == nil {
+		return nil, NewInvalidConfigError("operation not found in spec", map[string]interface{}{"operation": operationID, "spec": specPath})
+	}
+
+	status, response := doc.selectResponse(op, config.status)
+	if response == nil {
+		details := map[string]interface{}{"operation": operationID}
+		if config.status != 0 {
+			details["status"] = config.status
+		}
+		return nil, NewInvalidConfigError("operation has no matching response", details)
+	}
+
+	stub := &ResponseStub{Method: op.Method, Path: op.Path, Status: status, Headers: config.headers}
+	mediaType, body, ok := doc.responseBody(response)
+	if ok {
+		stub.Headers["Content-Type"] = mediaType
+		for _, field := range config.fields {
+			if body, err = setField(body, strings.Split(field.path, "."), field.value); err != nil {
+				return nil, NewInvalidConfigError(fmt.Sprintf("cannot override %s: %v", field.path, err), map[string]interface{}{"operation": operationID})
+			}
+		}
+		// Non-JSON string bodies such as text/plain are served verbatim
+		if text, isText := body.(string); isText && !strings.Contains(mediaType, "json") {
+			stub.BodyBytes = []byte(text)
+		} else {
+			stub.Body = body
+		}
+	} else if len(config.fields) > 0 {
+		return nil, NewInvalidConfigError("response has no body to override", map[string]interface{}{"operation": operationID, "status": status})
+	}
+	return stub, nil
+}
+
+// selectResponse returns the response for status, or the first success
+// response if status is zero, falling back to the default response
+func (d *openAPIDocument) selectResponse(op *openAPIOperation, status int) (int, map[string]interface{}) {
+	responses, _ := d.resolve(op.Node["responses"]).(map[string]interface{})
+
+	if status == 0 {
+		var codes []int
+		for key := range responses {
+			if code, err := strconv.Atoi(key); err == nil && code >= 200 && code < 300 {
+				codes = append(codes, code)
+			}
+		}
+		sort.Ints(codes)
+		if len(codes) > 0 {
+			response, _ := d.resolve(responses[strconv.Itoa(codes[0])]).(map[string]interface{})
+			return codes[0], response
+		}
+		status = 200
+	} else if response, ok := d.resolve(responses[strconv.Itoa(status)]).(map[string]interface{}); ok {
+		return status, response
+	}
+
+	response, _ := d.resolve(responses["default"]).(map[string]interface{})
+	return status, response
+}
+
+// responseBody returns the media type and example body of a response,
+// preferring JSON content. ok is false if the response has no body.
+func (d *openAPIDocument) responseBody(response map[string]interface{}) (mediaType string, body interface{}, ok bool) {
+	// Swagger 2: schema and examples on the response itself
+	if schema, hasSchema := response["schema"]; hasSchema {
+		if examples, _ := response["examples"].(map[string]interface{}); examples["application/json"] != nil {
+			return "application/json", examples["application/json"], true
+		}
+		return "application/json", d.exampleFromSchema(schema, 0), true
+	}
+
+	content, _ := response["content"].(map[string]interface{})
+	if len(content) == 0 {
+		return "", nil, false
+	}
+	mediaType = sortedKeys(content)[0]
+	for _, candidate := range sortedKeys(content) {
+		if strings.Contains(candidate, "json") {
+			mediaType = candidate
+			break
+		}
+	}
+
+	media, _ := d.resolve(content[mediaType]).(map[string]interface{})
+	if value, hasExample := media["example"]; hasExample {
+		return mediaType, value, true
+	}
+	named, _ := media["examples"].(map[string]interface{})
+	for _, name := range sortedKeys(named) {
+		example, _ := d.resolve(named[name]).(map[string]interface{})
+		if value, hasValue := example["value"]; hasValue {
+			return mediaType, value, true
+		}
+	}
+	return mediaType, d.exampleFromSchema(media["schema"], 0), true
+}
+
+// setField sets the value at path inside body, creating objects for missing
+// segments, and returns the updated body
+func setField(body interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch node := body.(type) {
+	case []interface{}:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(node) {
+			return nil, fmt.Errorf("no array element %q", path[0])
+		}
+		if node[index], err = setField(node[index], path[1:], value); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case map[string]interface{}:
+		child, err := setField(node[path[0]], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[path[0]] = child
+		return node, nil
+	case nil:
+		return setField(map[string]interface{}{}, path, value)
+	default:
+		return nil, fmt.Errorf("%q is not an object or array", path[0])
+	}
+}
@@ -0,0 +1,44 @@
+package mockforge
+
+import (
+	"testing"
+)
+
+func TestStubFromSpec(t *testing.T) {
+	t.Run("generated from schema", func(t *testing.T) {
+		stub, err := stubFromSpec("testdata/users.yaml", "getUser", WithField("manager", nil), WithField("tags.0", "vip"))
+		if err != nil {
+			t.Fatalf("Failed to generate stub: %v", err)
+		}
+		if stub.Method != "GET" || stub.Path != "/users/{id}" || stub.Status != 200 {
+			t.Errorf("Expected GET /users/{id} 200, got %s %s %d", stub.Method, stub.Path, stub.Status)
+		}
+		body, _ := stub.Body.(map[string]interface{})
+		if body["id"] != 1 || body["email"] != "user@example.com" || body["role"] != "admin" {
+			t.Errorf("Expected schema-valid user, got %v", body)
+		}
+		if tags, _ := body["tags"].([]interface{}); len(tags) != 1 || tags[0] != "vip" {
+			t.Errorf("Expected overridden tags, got %v", body["tags"])
+		}
+		if body["manager"] != nil {
+			t.Errorf("Expected overridden manager, got %v", body["manager"])
+		}
+	})
+
+	t.Run("status override", func(t *testing.T) {
+		stub, err := stubFromSpec("testdata/users.yaml", "getUser", WithStatus(404))
+		if err != nil {
+			t.Fatalf("Failed to generate stub: %v", err)
+		}
+		body, _ := stub.Body.(map[string]interface{})
+		if stub.Status != 404 || body["error"] != "not found" {
+			t.Errorf("Expected 404 with example error, got %d %v", stub.Status, body)
+		}
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		if _, err := stubFromSpec("testdata/users.yaml", "deleteUser"); err == nil {
+			t.Error("Expected error for unknown operation")
+		}
+	})
+}
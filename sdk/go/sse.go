@@ -0,0 +1,52 @@
+package mockforge
+
+import (
+	"strings"
+	"time"
+)
+
+// SSEEvent is one Server-Sent Event emitted by an SSE stub
+type SSEEvent struct {
+	// Name is the event type; empty emits the default "message" event
+	Name string
+	// Data is the event payload; multi-line data is split across data fields
+	Data string
+	// ID sets the client's last event ID
+	ID string
+	// Delay is how long the server waits before emitting the event
+	Delay time.Duration
+}
+
+// encode formats the event in the text/event-stream format
+func (e SSEEvent) encode() []byte {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Name != "" {
+		b.WriteString("event: " + e.Name + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// SSE streams events as text/event-stream, each after its delay, and keeps
+// the connection open after the last one until the client disconnects
+func (b *StubBuilder) SSE(events ...SSEEvent) *StubBuilder {
+	for _, event := range events {
+		b.chunks = append(b.chunks, Chunk{Data: event.encode(), DelayMs: int(event.Delay / time.Millisecond)})
+	}
+	b.headers["Content-Type"] = "text/event-stream"
+	b.headers["Cache-Control"] = "no-cache"
+	b.keepOpen = true
+	return b
+}
+
+// StubSSE stubs GET path as a Server-Sent Events stream emitting events on
+// schedule
+func (m *MockServer) StubSSE(path string, events []SSEEvent) error {
+	return m.AddStub(NewStubBuilder("GET", path).SSE(events...).Build())
+}
@@ -0,0 +1,51 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStubSSE(t *testing.T) {
+	var received map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+
+	err := server.StubSSE("/events", []SSEEvent{
+		{Name: "price", Data: `{"sku":"A1","price":10}`, ID: "1"},
+		{Data: "line one\nline two", Delay: 250 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub SSE: %v", err)
+	}
+
+	if received["method"] != "GET" {
+		t.Errorf("Expected GET stub, got %v", received["method"])
+	}
+	response := received["response"].(map[string]interface{})
+	if response["keep_open"] != true {
+		t.Error("Expected the stream to be kept open")
+	}
+	if headers := response["headers"].(map[string]interface{}); headers["Content-Type"] != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %v", headers)
+	}
+
+	chunks := response["chunks"].([]interface{})
+	expected := []struct {
+		data    string
+		delayMs float64
+	}{
+		{"id: 1\nevent: price\ndata: {\"sku\":\"A1\",\"price\":10}\n\n", 0},
+		{"data: line one\ndata: line two\n\n", 250},
+	}
+	for i, want := range expected {
+		chunk := chunks[i].(map[string]interface{})
+		data, _ := base64.StdEncoding.DecodeString(chunk["data_base64"].(string))
+		if string(data) != want.data || chunk["delay_ms"] != want.delayMs {
+			t.Errorf("Event %d: expected %q after %vms, got %q after %v", i, want.data, want.delayMs, data, chunk["delay_ms"])
+		}
+	}
+}
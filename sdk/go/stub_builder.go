@@ -1,13 +1,43 @@
 package mockforge
 
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
 // StubBuilder provides a fluent interface for creating response stubs
 type StubBuilder struct {
-	method    string
-	path      string
-	status    int
-	headers   map[string]string
-	body      interface{}
-	latencyMs *int
+	method       string
+	path         string
+	status       int
+	headers      map[string]string
+	body         interface{}
+	latency      *LatencySpec
+	match        RequestMatch
+	priority     int
+	scenario     string
+	required     string
+	newState     string
+	sequence     []SequencedResponse
+	times        int
+	expiresIn    time.Duration
+	fault        Fault
+	proxy        *ProxyConfig
+	callbacks    []Callback
+	raw          []byte
+	bodyBytes    []byte
+	bodyFile     string
+	encoding     ContentEncoding
+	chunks       []Chunk
+	keepOpen     bool
+	cookies      []string
+	schema       json.RawMessage
+	rateLimit    *RateLimit
+	throttle     int
+	transformers []string
+	err          error
 }
 
 // NewStubBuilder creates a new StubBuilder
@@ -20,6 +50,45 @@ func NewStubBuilder(method, path string) *StubBuilder {
 	}
 }
 
+// StubJSON starts a stub answering with v marshaled as JSON and an
+// application/json Content-Type
+func StubJSON(method, path string, v interface{}) *StubBuilder {
+	b := NewStubBuilder(method, path).Header("Content-Type", "application/json")
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.err = NewInvalidConfigError(fmt.Sprintf("failed to marshal JSON body: %v", err), map[string]interface{}{"path": path})
+		return b
+	}
+	return b.Body(json.RawMessage(data))
+}
+
+// StubXML starts a stub answering with v marshaled as XML, with an XML
+// declaration, and an application/xml Content-Type. Strings and byte slices
+// are served verbatim.
+func StubXML(method, path string, v interface{}) *StubBuilder {
+	b := NewStubBuilder(method, path).Header("Content-Type", "application/xml")
+	switch body := v.(type) {
+	case string:
+		return b.BodyBytes([]byte(body))
+	case []byte:
+		return b.BodyBytes(body)
+	}
+	data, err := xml.Marshal(v)
+	if err != nil {
+		b.err = NewInvalidConfigError(fmt.Sprintf("failed to marshal XML body: %v", err), map[string]interface{}{"path": path})
+		return b
+	}
+	return b.BodyBytes(append([]byte(xml.Header), data...))
+}
+
+// StubText starts a stub answering with text and a UTF-8 text/plain
+// Content-Type
+func StubText(method, path, text string) *StubBuilder {
+	return NewStubBuilder(method, path).
+		Header("Content-Type", "text/plain; charset=utf-8").
+		BodyBytes([]byte(text))
+}
+
 // Status sets the HTTP status code
 func (b *StubBuilder) Status(code int) *StubBuilder {
 	b.status = code
@@ -46,20 +115,296 @@ func (b *StubBuilder) Body(body interface{}) *StubBuilder {
 	return b
 }
 
-// Latency sets the response latency in milliseconds
+// BodyBytes sets a binary response body, served verbatim
+func (b *StubBuilder) BodyBytes(body []byte) *StubBuilder {
+	b.bodyBytes = append([]byte(nil), body...)
+	return b
+}
+
+// BodyFile serves the file at path as the response body. The server reads it
+// from disk on each request, so edits show up without re-registering; the
+// Content-Type defaults to one matching the extension.
+func (b *StubBuilder) BodyFile(path string) *StubBuilder {
+	b.bodyFile = path
+	return b
+}
+
+// StreamChunks streams the body as chunks, each written after its delay, to
+// exercise streaming parsers and client read timeouts
+func (b *StubBuilder) StreamChunks(chunks []Chunk) *StubBuilder {
+	b.chunks = append(b.chunks, chunks...)
+	return b
+}
+
+// ThrottleBytesPerSecond writes the body at n bytes per second, to exercise
+// client read timeouts and download progress reporting
+func (b *StubBuilder) ThrottleBytesPerSecond(n int) *StubBuilder {
+	b.throttle = n
+	return b
+}
+
+// GzipBody serves the body gzip-compressed with Content-Encoding: gzip
+func (b *StubBuilder) GzipBody() *StubBuilder {
+	return b.Encoding(EncodingGzip)
+}
+
+// Encoding serves the body compressed with encoding ("gzip", "deflate", or
+// "br") and the matching Content-Encoding header
+func (b *StubBuilder) Encoding(encoding ContentEncoding) *StubBuilder {
+	b.encoding = encoding
+	return b
+}
+
+// Latency sets a fixed response latency in milliseconds
 func (b *StubBuilder) Latency(ms int) *StubBuilder {
-	b.latencyMs = &ms
+	b.latency = FixedLatency(time.Duration(ms) * time.Millisecond)
+	return b
+}
+
+// LatencyUniform delays responses by a random amount between min and max
+func (b *StubBuilder) LatencyUniform(min, max time.Duration) *StubBuilder {
+	b.latency = UniformLatency(min, max)
+	return b
+}
+
+// LatencyNormal delays responses by a normally distributed amount
+func (b *StubBuilder) LatencyNormal(mean, stddev time.Duration) *StubBuilder {
+	b.latency = NormalLatency(mean, stddev)
+	return b
+}
+
+// LatencyLogNormal delays responses by a log-normally distributed amount,
+// producing realistic tail latencies
+func (b *StubBuilder) LatencyLogNormal(mean, stddev time.Duration) *StubBuilder {
+	b.latency = LogNormalLatency(mean, stddev)
+	return b
+}
+
+// LatencyPercentiles delays responses so their P50, P95, and P99 match
+func (b *StubBuilder) LatencyPercentiles(p50, p95, p99 time.Duration) *StubBuilder {
+	b.latency = PercentileLatency(p50, p95, p99)
+	return b
+}
+
+// Priority sets the match priority; higher priorities are matched first
+func (b *StubBuilder) Priority(priority int) *StubBuilder {
+	b.priority = priority
+	return b
+}
+
+// Scenario makes the stub part of the named scenario state machine
+func (b *StubBuilder) Scenario(name string) *StubBuilder {
+	b.scenario = name
+	return b
+}
+
+// RequiredState only matches while the scenario is in state
+func (b *StubBuilder) RequiredState(state string) *StubBuilder {
+	b.required = state
+	return b
+}
+
+// NewState moves the scenario to state when the stub matches
+func (b *StubBuilder) NewState(state string) *StubBuilder {
+	b.newState = state
+	return b
+}
+
+// Times disables the stub after it has matched n requests, e.g. to model a
+// one-time token
+func (b *StubBuilder) Times(n int) *StubBuilder {
+	b.times = n
+	return b
+}
+
+// ExpiresAfter disables the stub once d has passed since it was registered
+func (b *StubBuilder) ExpiresAfter(d time.Duration) *StubBuilder {
+	b.expiresIn = d
+	return b
+}
+
+// ThenCallback makes the stub send an HTTP request to url delay after
+// responding, e.g. the webhook that follows a 202 Accepted. Use
+// ThenCallbackWith for custom headers.
+func (b *StubBuilder) ThenCallback(method, url string, body interface{}, delay time.Duration) *StubBuilder {
+	return b.ThenCallbackWith(Callback{Method: method, URL: url, Body: body, Delay: delay})
+}
+
+// ThenCallbackWith makes the stub send callback after responding
+func (b *StubBuilder) ThenCallbackWith(callback Callback) *StubBuilder {
+	b.callbacks = append(b.callbacks, callback)
+	return b
+}
+
+// RawHTTP serves response exactly as given, byte for byte, including
+// nonstandard status lines, header order, and framing, then closes the
+// connection. Status, headers, and body set on the builder are ignored.
+//
+//	builder.RawHTTP([]byte("HTTP/1.1 200 Okey-dokey\r\nX-B: 1\r\nX-A: 2\r\n\r\nhello"))
+func (b *StubBuilder) RawHTTP(response []byte) *StubBuilder {
+	b.raw = append([]byte(nil), response...)
+	return b
+}
+
+// ProxyTo forwards matching requests to upstreamURL instead of returning the
+// stub's response
+func (b *StubBuilder) ProxyTo(upstreamURL string) *StubBuilder {
+	if b.proxy == nil {
+		b.proxy = &ProxyConfig{}
+	}
+	b.proxy.UpstreamURL = upstreamURL
+	return b
+}
+
+// RecordProxied saves the upstream responses of a ProxyTo stub as new stubs,
+// which serve later requests for the same method and path
+func (b *StubBuilder) RecordProxied() *StubBuilder {
+	if b.proxy == nil {
+		b.proxy = &ProxyConfig{}
+	}
+	b.proxy.Record = true
+	return b
+}
+
+// Fault makes the stub simulate a network-level failure such as
+// FaultConnectionReset instead of returning its response
+func (b *StubBuilder) Fault(fault Fault) *StubBuilder {
+	b.fault = fault
+	return b
+}
+
+// RespondInSequence serves the responses in order, one per matching request,
+// then keeps repeating the last one. It takes precedence over Status, Header,
+// and Body.
+//
+//	builder.RespondInSequence(
+//	    mockforge.Respond(503, nil),
+//	    mockforge.Respond(200, map[string]interface{}{"ok": true}),
+//	)
+func (b *StubBuilder) RespondInSequence(responses ...SequencedResponse) *StubBuilder {
+	b.sequence = append([]SequencedResponse(nil), responses...)
+	return b
+}
+
+// WhenHeader only matches requests carrying the header with exactly value
+func (b *StubBuilder) WhenHeader(key, value string) *StubBuilder {
+	return b.WhenHeaderMatches(key, exactHeaderPattern(value))
+}
+
+// WhenHeaderMatches only matches requests carrying the header with a value
+// the regular expression pattern matches. The pattern is unanchored, so
+// "json" matches "application/json"; use ^ and $ to match the whole value.
+func (b *StubBuilder) WhenHeaderMatches(key, pattern string) *StubBuilder {
+	if b.match.Headers == nil {
+		b.match.Headers = make(map[string]string)
+	}
+	b.match.Headers[key] = pattern
+	return b
+}
+
+// WhenHeaderAbsent only matches requests that do not carry the header
+func (b *StubBuilder) WhenHeaderAbsent(key string) *StubBuilder {
+	b.match.HeadersAbsent = append(b.match.HeadersAbsent, key)
+	return b
+}
+
+// WhenHeaderPresent only matches requests carrying the header, with any value
+func (b *StubBuilder) WhenHeaderPresent(key string) *StubBuilder {
+	b.match.HeadersPresent = append(b.match.HeadersPresent, key)
+	return b
+}
+
+// WhenQuery only matches requests with the query parameter set to value
+func (b *StubBuilder) WhenQuery(key, value string) *StubBuilder {
+	if b.match.QueryParams == nil {
+		b.match.QueryParams = make(map[string]string)
+	}
+	b.match.QueryParams[key] = value
+	return b
+}
+
+// WhenJSONPath only matches requests whose JSON body has value at path
+func (b *StubBuilder) WhenJSONPath(path string, value interface{}) *StubBuilder {
+	if b.match.JSONPaths == nil {
+		b.match.JSONPaths = make(map[string]interface{})
+	}
+	b.match.JSONPaths[path] = value
+	return b
+}
+
+// WhenXPath only matches requests whose XML body has an element at xpath
+// with text value, or any such element if value is empty. Paths are
+// absolute (/Envelope/Body/GetOrder) or descendant (//GetOrder/id) and may
+// filter segments with [text()="..."].
+func (b *StubBuilder) WhenXPath(xpath, value string) *StubBuilder {
+	if b.match.XPaths == nil {
+		b.match.XPaths = make(map[string]string)
+	}
+	b.match.XPaths[xpath] = value
+	return b
+}
+
+// WhenBodyJSON only matches requests whose JSON body equals body
+func (b *StubBuilder) WhenBodyJSON(body interface{}) *StubBuilder {
+	b.match.BodyJSON = body
+	return b
+}
+
+// WhenBodyMatches only matches requests whose raw body matches the regex
+func (b *StubBuilder) WhenBodyMatches(pattern string) *StubBuilder {
+	b.match.BodyPattern = pattern
 	return b
 }
 
 // Build builds the ResponseStub
 func (b *StubBuilder) Build() ResponseStub {
 	return ResponseStub{
-		Method:    b.method,
-		Path:      b.path,
-		Status:    b.status,
-		Headers:   b.headers,
-		Body:      b.body,
-		LatencyMs: b.latencyMs,
+		Method:        b.method,
+		Path:          b.path,
+		Status:        b.status,
+		Headers:       b.headers,
+		Body:          b.body,
+		BodyBytes:     b.bodyBytes,
+		BodyFile:      b.bodyFile,
+		Chunks:        append([]Chunk(nil), b.chunks...),
+		KeepOpen:      b.keepOpen,
+		SetCookies:    append([]string(nil), b.cookies...),
+		Encoding:      b.encoding,
+		Latency:       b.latency,
+		Match:         b.buildMatch(),
+		Priority:      b.priority,
+		Scenario:      b.scenario,
+		RequiredState: b.required,
+		NewState:      b.newState,
+		Sequence:      b.sequence,
+		Times:         b.times,
+		ExpiresAfter:  b.expiresIn,
+		Fault:         b.fault,
+		Proxy:         b.buildProxy(),
+		RawResponse:   b.raw,
+		Callbacks:     append([]Callback(nil), b.callbacks...),
+		RequestSchema: b.schema,
+		RateLimit:     b.rateLimit,
+		Throttle:      b.throttle,
+		Transformers:  append([]string(nil), b.transformers...),
+		buildErr:      b.err,
+	}
+}
+
+// buildMatch returns a copy of the configured match criteria, or nil if none
+func (b *StubBuilder) buildMatch() *RequestMatch {
+	if b.match.IsEmpty() {
+		return nil
+	}
+	match := b.match
+	return &match
+}
+
+// buildProxy returns a copy of the proxy configuration, or nil if none
+func (b *StubBuilder) buildProxy() *ProxyConfig {
+	if b.proxy == nil {
+		return nil
 	}
+	proxy := *b.proxy
+	return &proxy
 }
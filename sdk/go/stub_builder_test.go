@@ -0,0 +1,289 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStubBuilderMatchers(t *testing.T) {
+	var received map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	stub := NewStubBuilder("POST", "/payments").
+		WhenHeader("X-Tenant", "a").
+		WhenQuery("page", "2").
+		WhenJSONPath("$.type", "refund").
+		WhenHeaderAbsent("Authorization").
+		WhenHeaderPresent("X-Request-Id").
+		Body(map[string]interface{}{"ok": true}).
+		Build()
+
+	if err := server.AddStub(stub); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+
+	match, ok := received["request_match"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected request_match in mock config, got %v", received)
+	}
+	if headers := match["headers"].(map[string]interface{}); headers["X-Tenant"] != "^a$" {
+		t.Errorf("Expected X-Tenant header matcher, got %v", headers)
+	}
+	if query := match["query_params"].(map[string]interface{}); query["page"] != "2" {
+		t.Errorf("Expected page query matcher, got %v", query)
+	}
+	if paths := match["json_paths"].(map[string]interface{}); paths["$.type"] != "refund" {
+		t.Errorf("Expected $.type JSONPath matcher, got %v", paths)
+	}
+	if absent := match["headers_absent"].([]interface{}); len(absent) != 1 || absent[0] != "Authorization" {
+		t.Errorf("Expected Authorization absence matcher, got %v", absent)
+	}
+	if present := match["headers_present"].([]interface{}); len(present) != 1 || present[0] != "X-Request-Id" {
+		t.Errorf("Expected X-Request-Id presence matcher, got %v", present)
+	}
+}
+
+func TestStubBuilderHeaderMatchers(t *testing.T) {
+	stub := NewStubBuilder("GET", "/env").
+		WhenHeader("X-Env", "a").
+		WhenHeader("Accept", "application/json+v1").
+		WhenHeaderMatches("Authorization", "^Bearer .+").
+		Build()
+
+	for _, tc := range []struct {
+		name, value string
+		want        bool
+	}{
+		{"X-Env", "a", true},
+		{"X-Env", "banana", false},
+		{"Accept", "application/json+v1", true},
+		{"Accept", "application/jsonnv1", false},
+		{"Authorization", "Bearer t0k3n", true},
+		{"Authorization", "Basic dXNlcg==", false},
+	} {
+		re := regexp.MustCompile(stub.Match.Headers[tc.name])
+		if got := re.MatchString(tc.value); got != tc.want {
+			t.Errorf("%s %q: expected match %v, got %v", tc.name, tc.value, tc.want, got)
+		}
+	}
+
+	if value, ok := literalHeaderValue(stub.Match.Headers["Accept"]); !ok || value != "application/json+v1" {
+		t.Errorf("Expected the exact Accept value back, got %q, %v", value, ok)
+	}
+	if _, ok := literalHeaderValue(stub.Match.Headers["Authorization"]); ok {
+		t.Error("Expected a regex header not to read as a literal value")
+	}
+}
+
+func TestStubBuilderWithoutMatchers(t *testing.T) {
+	stub := NewStubBuilder("GET", "/health").Build()
+	if stub.Match != nil {
+		t.Errorf("Expected no match block, got %+v", stub.Match)
+	}
+	if _, ok := stub.mockConfig()["request_match"]; ok {
+		t.Error("Expected no request_match in mock config")
+	}
+}
+
+func TestStubBuilderRespondInSequence(t *testing.T) {
+	stub := NewStubBuilder("GET", "/flaky").
+		RespondInSequence(Respond(500, nil), Respond(200, map[string]interface{}{"ok": true})).
+		Build()
+
+	sequence, ok := stub.mockConfig()["response_sequence"].([]map[string]interface{})
+	if !ok || len(sequence) != 2 {
+		t.Fatalf("Expected 2 sequenced responses, got %v", stub.mockConfig()["response_sequence"])
+	}
+	if sequence[0]["status_code"] != 500 || sequence[1]["status_code"] != 200 {
+		t.Errorf("Expected statuses 500 then 200, got %v", sequence)
+	}
+}
+
+func TestStubBuilderCallLimits(t *testing.T) {
+	config := NewStubBuilder("POST", "/tokens/redeem").
+		Times(1).
+		ExpiresAfter(2 * time.Second).
+		Build().
+		mockConfig()
+
+	if config["max_matches"] != 1 {
+		t.Errorf("Expected max_matches 1, got %v", config["max_matches"])
+	}
+	if config["expires_after_ms"] != int64(2000) {
+		t.Errorf("Expected expires_after_ms 2000, got %v", config["expires_after_ms"])
+	}
+
+	server := NewMockServer(MockServerConfig{})
+	if err := server.AddStub(NewStubBuilder("GET", "/").Times(-1).Build()); err == nil {
+		t.Error("Expected error for negative call limit")
+	}
+}
+
+func TestStubBuilderFault(t *testing.T) {
+	config := NewStubBuilder("GET", "/orders").Fault(FaultConnectionReset).Build().mockConfig()
+
+	response := config["response"].(map[string]interface{})
+	fault, ok := response["fault"].(map[string]interface{})
+	if !ok || fault["type"] != FaultConnectionReset {
+		t.Errorf("Expected connection_reset fault, got %v", response["fault"])
+	}
+
+	server := NewMockServer(MockServerConfig{})
+	if err := server.AddStub(NewStubBuilder("GET", "/").Fault("explode").Build()); err == nil {
+		t.Error("Expected error for unknown fault")
+	}
+}
+
+func TestStubBuilderRawHTTP(t *testing.T) {
+	raw := []byte("HTTP/1.1 299 Whatever\r\nX-B: 1\r\nX-A: 2\r\n\r\nhi")
+	config := NewStubBuilder("GET", "/legacy").RawHTTP(raw).Build().mockConfig()
+
+	response := config["response"].(map[string]interface{})
+	if response["raw_base64"] != "SFRUUC8xLjEgMjk5IFdoYXRldmVyDQpYLUI6IDENClgtQTogMg0KDQpoaQ==" {
+		t.Errorf("Expected base64 raw response, got %v", response["raw_base64"])
+	}
+
+	server := NewMockServer(MockServerConfig{})
+	if err := server.AddStub(NewStubBuilder("GET", "/").RawHTTP([]byte("200 OK\r\n\r\n")).Build()); err == nil {
+		t.Error("Expected error for raw response without status line")
+	}
+}
+
+func TestStubBuilderThenCallback(t *testing.T) {
+	config := NewStubBuilder("POST", "/jobs").
+		Status(202).
+		ThenCallback("POST", "http://localhost:9000/hooks", map[string]interface{}{"status": "done"}, 50*time.Millisecond).
+		Build().
+		mockConfig()
+
+	callbacks, ok := config["callbacks"].([]map[string]interface{})
+	if !ok || len(callbacks) != 1 {
+		t.Fatalf("Expected one callback, got %v", config["callbacks"])
+	}
+	if callbacks[0]["url"] != "http://localhost:9000/hooks" || callbacks[0]["delay_ms"] != int64(50) {
+		t.Errorf("Expected callback to hooks after 50ms, got %v", callbacks[0])
+	}
+
+	server := NewMockServer(MockServerConfig{})
+	if err := server.AddStub(NewStubBuilder("POST", "/").ThenCallback("POST", "/hooks", nil, 0).Build()); err == nil {
+		t.Error("Expected error for relative callback URL")
+	}
+}
+
+func TestStubBuilderCookies(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	stub := NewStubBuilder("POST", "/login").
+		WhenCookie("csrf", "abc").
+		SetCookie("session", "s3cr3t", CookieOptions{Path: "/", HTTPOnly: true, Secure: true, MaxAge: time.Hour}).
+		SetCookie("theme", "dark", CookieOptions{Domain: "example.com", Expires: expires}).
+		Build()
+
+	expected := []string{
+		"session=s3cr3t; Path=/; Max-Age=3600; HttpOnly; Secure",
+		"theme=dark; Domain=example.com; Expires=Wed, 02 Jan 2030 03:04:05 GMT",
+	}
+	for i, want := range expected {
+		if stub.SetCookies[i] != want {
+			t.Errorf("Expected Set-Cookie %q, got %q", want, stub.SetCookies[i])
+		}
+	}
+
+	if stub.Match == nil || stub.Match.Cookies["csrf"] != "abc" {
+		t.Errorf("Expected csrf cookie matcher, got %+v", stub.Match)
+	}
+	response := stub.mockConfig()["response"].(map[string]interface{})
+	if cookies := response["set_cookies"].([]string); len(cookies) != 2 {
+		t.Errorf("Expected 2 set_cookies, got %v", cookies)
+	}
+}
+
+func TestStubBuilderRequestSchema(t *testing.T) {
+	stub := NewStubBuilder("POST", "/users").
+		ValidateRequestAgainstSchema(`{"type": "object", "required": ["email"]}`).
+		Status(201).
+		Build()
+	if err := stub.validate(); err != nil {
+		t.Fatalf("Expected valid stub, got %v", err)
+	}
+	data, _ := json.Marshal(stub.mockConfig())
+	var config map[string]interface{}
+	json.Unmarshal(data, &config)
+	if schema, ok := config["request_schema"].(map[string]interface{}); !ok || schema["type"] != "object" {
+		t.Errorf("Expected request_schema object, got %v", config["request_schema"])
+	}
+
+	invalid := NewStubBuilder("POST", "/users").ValidateRequestAgainstSchema(`{"type":`).Build()
+	if err := invalid.validate(); err == nil {
+		t.Error("Expected error for malformed schema")
+	}
+
+	if env := ValidationWarn.env(); len(env) != 1 || env[0] != "MOCKFORGE_REQUEST_VALIDATION=warn" {
+		t.Errorf("Expected validation env var, got %v", env)
+	}
+	if err := ValidationMode("strict").validate(); err == nil {
+		t.Error("Expected error for unknown validation mode")
+	}
+}
+
+func TestTypedStubHelpers(t *testing.T) {
+	type user struct {
+		XMLName struct{} `xml:"user" json:"-"`
+		Name    string   `xml:"name" json:"name"`
+	}
+
+	jsonStub := StubJSON("GET", "/user", user{Name: "Alice"}).Status(201).Build()
+	data, _ := json.Marshal(jsonStub.mockConfig())
+	if !strings.Contains(string(data), `"body":{"name":"Alice"}`) || jsonStub.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected JSON body and Content-Type, got %s", data)
+	}
+	if jsonStub.Status != 201 {
+		t.Errorf("Expected chained status 201, got %d", jsonStub.Status)
+	}
+
+	xmlStub := StubXML("GET", "/user", user{Name: "Alice"}).Build()
+	if want := xml.Header + "<user><name>Alice</name></user>"; string(xmlStub.BodyBytes) != want {
+		t.Errorf("Expected XML body %q, got %q", want, xmlStub.BodyBytes)
+	}
+
+	textStub := StubText("GET", "/health", "ok").Build()
+	if string(textStub.BodyBytes) != "ok" || textStub.Headers["Content-Type"] != "text/plain; charset=utf-8" {
+		t.Errorf("Expected plain text body, got %q with %v", textStub.BodyBytes, textStub.Headers)
+	}
+
+	invalid := StubJSON("GET", "/bad", make(chan int)).Build()
+	if err := invalid.validate(); err == nil {
+		t.Error("Expected error for unmarshalable body")
+	}
+}
+
+func TestStubBuilderRateLimited(t *testing.T) {
+	stub := NewStubBuilder("GET", "/search").RateLimited(5, time.Minute).Build()
+	if err := stub.validate(); err != nil {
+		t.Fatalf("Expected valid stub, got %v", err)
+	}
+	limit, _ := stub.mockConfig()["rate_limit"].(map[string]interface{})
+	if limit["limit"] != 5 || limit["window_ms"] != int64(60000) {
+		t.Errorf("Expected 5 requests per 60000ms, got %v", limit)
+	}
+
+	data, _ := json.Marshal(stub.mockConfig())
+	var wire mockConfigWire
+	json.Unmarshal(data, &wire)
+	decoded, err := wire.stub()
+	if err != nil || decoded.RateLimit == nil || *decoded.RateLimit != *stub.RateLimit {
+		t.Errorf("Expected rate limit to round-trip, got %+v (%v)", decoded.RateLimit, err)
+	}
+
+	invalid := NewStubBuilder("GET", "/search").RateLimited(0, time.Second).Build()
+	if err := invalid.validate(); err == nil {
+		t.Error("Expected error for zero limit")
+	}
+}
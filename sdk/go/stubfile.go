@@ -0,0 +1,132 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StubFormat is a file format for exported stubs
+type StubFormat string
+
+const (
+	// StubFormatJSON writes stubs as an indented JSON array
+	StubFormatJSON StubFormat = "json"
+	// StubFormatYAML writes stubs as a YAML sequence
+	StubFormatYAML StubFormat = "yaml"
+)
+
+// ExportStubs writes every registered stub to w in the admin API's mock
+// format, the one the server's own export and import endpoints use. The
+// output can be committed as a mappings file and loaded with ImportStubs.
+func (m *MockServer) ExportStubs(w io.Writer, format StubFormat) error {
+	if format != StubFormatJSON && format != StubFormatYAML {
+		return NewInvalidConfigError("unknown stub format", map[string]interface{}{"format": format})
+	}
+
+	var mocks []interface{}
+	if m.adminPort != 0 {
+		var result struct {
+			Mocks []interface{} `json:"mocks"`
+		}
+		if err := m.adminJSON("list mocks", http.MethodGet, "/__mockforge/api/mocks", nil, &result); err != nil {
+			return err
+		}
+		mocks = result.Mocks
+	} else {
+		// Round-trip through JSON so nested structs carry their json names
+		// in YAML output too
+		configs := make([]map[string]interface{}, len(m.stubs))
+		for i := range m.stubs {
+			configs[i] = m.stubs[i].mockConfig()
+		}
+		data, err := json.Marshal(configs)
+		if err != nil {
+			return fmt.Errorf("failed to encode stubs: %w", err)
+		}
+		if err := json.Unmarshal(data, &mocks); err != nil {
+			return fmt.Errorf("failed to encode stubs: %w", err)
+		}
+	}
+	if mocks == nil {
+		mocks = []interface{}{}
+	}
+
+	var data []byte
+	var err error
+	if format == StubFormatYAML {
+		data, err = yaml.Marshal(mocks)
+	} else {
+		data, err = json.MarshalIndent(mocks, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode stubs: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ImportStubs reads a JSON or YAML file written by ExportStubs, or by the
+// server's export endpoint, and registers its stubs in a single admin call.
+// The stubs are added to those already registered. In dry-run mode nothing
+// is registered and the import is recorded instead.
+func (m *MockServer) ImportStubs(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read stubs: %w", err)
+	}
+	stubs, err := decodeStubFile(data)
+	if err != nil {
+		return err
+	}
+
+	if m.isDryRun() {
+		targets := make([]string, len(stubs))
+		for i, stub := range stubs {
+			targets[i] = fmt.Sprintf("%s %s", stub.Method, stub.Path)
+		}
+		m.recordDryRun("import stubs", targets)
+		return nil
+	}
+	return m.StubAll(stubs)
+}
+
+// decodeStubFile parses a list of mocks, or an object listing them under
+// "mocks", from JSON or YAML
+func decodeStubFile(data []byte) ([]ResponseStub, error) {
+	// JSON is valid YAML, so a single parser handles both. The result is
+	// re-encoded as JSON to decode it with the wire struct's json tags.
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to parse stubs: %v", err), nil)
+	}
+	if object, ok := doc.(map[string]interface{}); ok {
+		doc = object["mocks"]
+	}
+	if _, ok := doc.([]interface{}); !ok {
+		return nil, NewInvalidConfigError("stub file must contain a list of mocks", nil)
+	}
+
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("failed to parse stubs: %v", err), nil)
+	}
+	var configs []mockConfigWire
+	if err := json.Unmarshal(normalized, &configs); err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("invalid mock in stub file: %v", err), nil)
+	}
+
+	stubs := make([]ResponseStub, len(configs))
+	for i := range configs {
+		stub, err := configs[i].stub()
+		if err != nil {
+			return nil, err
+		}
+		stubs[i] = *stub
+	}
+	return stubs, nil
+}
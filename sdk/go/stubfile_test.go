@@ -0,0 +1,54 @@
+package mockforge
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportImportStubs(t *testing.T) {
+	source := NewMockServer(MockServerConfig{})
+	source.AddStub(NewStubBuilder("GET", "/users/{id}").
+		Body(map[string]string{"name": "Alice"}).
+		WhenHeader("X-Tenant", "acme").
+		Build())
+	source.AddStub(NewStubBuilder("POST", "/upload").Status(201).BodyBytes([]byte{0, 1, 2}).Build())
+
+	for _, format := range []StubFormat{StubFormatJSON, StubFormatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := source.ExportStubs(&buf, format); err != nil {
+				t.Fatalf("Failed to export stubs: %v", err)
+			}
+			if format == StubFormatYAML && !strings.Contains(buf.String(), "request_match:") {
+				t.Errorf("Expected wire keys in YAML output, got:\n%s", buf.String())
+			}
+
+			target := NewMockServer(MockServerConfig{})
+			if err := target.ImportStubs(&buf); err != nil {
+				t.Fatalf("Failed to import stubs: %v", err)
+			}
+			if len(target.stubs) != 2 {
+				t.Fatalf("Expected 2 stubs, got %d", len(target.stubs))
+			}
+			users, upload := target.stubs[0], target.stubs[1]
+			if users.Path != "/users/{id}" || users.Match == nil || users.Match.Headers["X-Tenant"] != "^acme$" {
+				t.Errorf("Expected header-matched users stub, got %+v", users)
+			}
+			if upload.Status != 201 || !bytes.Equal(upload.BodyBytes, []byte{0, 1, 2}) {
+				t.Errorf("Expected binary upload stub, got %+v", upload)
+			}
+		})
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		target := NewMockServer(MockServerConfig{})
+		target.WithDryRun(true)
+		if err := target.ImportStubs(strings.NewReader(`[{"method": "GET", "path": "/a"}]`)); err != nil {
+			t.Fatalf("Failed to import stubs: %v", err)
+		}
+		if len(target.stubs) != 0 || len(target.DryRunChanges()) != 1 {
+			t.Errorf("Expected the import to be recorded only, got %d stubs", len(target.stubs))
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package mockforge
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// StubGroup is a named set of stubs that can be cleared without touching
+// the server's other stubs, so a subtest can layer stubs on shared fixtures
+type StubGroup struct {
+	server *MockServer
+	name   string
+}
+
+// Group returns a stub group. Stubs added through it are tagged with name
+// and removed again by Clear.
+func (m *MockServer) Group(name string) *StubGroup {
+	return &StubGroup{server: m, name: name}
+}
+
+// Name returns the group name
+func (g *StubGroup) Name() string {
+	return g.name
+}
+
+// AddStub registers a stub as part of the group
+func (g *StubGroup) AddStub(stub ResponseStub) error {
+	stub.Group = g.name
+	return g.server.AddStub(stub)
+}
+
+// StubAll registers stubs as part of the group in a single admin call
+func (g *StubGroup) StubAll(stubs []ResponseStub) error {
+	tagged := make([]ResponseStub, len(stubs))
+	for i, stub := range stubs {
+		stub.Group = g.name
+		tagged[i] = stub
+	}
+	return g.server.StubAll(tagged)
+}
+
+// Clear removes every stub in the group, however it was registered, leaving
+// all others in place. In dry-run mode the removal is recorded instead.
+func (g *StubGroup) Clear() error {
+	m := g.server
+	if m.isDryRun() {
+		var targets []string
+		for _, stub := range m.stubs {
+			if stub.Group == g.name {
+				targets = append(targets, fmt.Sprintf("%s %s", stub.Method, stub.Path))
+			}
+		}
+		m.recordDryRun("clear group "+g.name, targets)
+		return nil
+	}
+
+	if m.adminPort != 0 {
+		if err := m.adminJSON("clear stub group", http.MethodDelete, "/__mockforge/api/mocks/groups/"+url.PathEscape(g.name), nil, nil); err != nil {
+			return err
+		}
+	}
+	remaining := m.stubs[:0]
+	for _, stub := range m.stubs {
+		if stub.Group != g.name {
+			remaining = append(remaining, stub)
+		}
+	}
+	m.stubs = remaining
+	return m.persist()
+}
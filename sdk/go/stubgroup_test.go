@@ -0,0 +1,69 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestStubGroupClear(t *testing.T) {
+	var mu sync.Mutex
+	mocks := make(map[string]map[string]interface{})
+	next := 0
+	create := func(mock map[string]interface{}) map[string]interface{} {
+		next++
+		mock["id"] = fmt.Sprintf("mock-%d", next)
+		mocks[mock["id"].(string)] = mock
+		return mock
+	}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/__mockforge/api/mocks/bulk":
+			var batch []map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&batch)
+			for i := range batch {
+				batch[i] = create(batch[i])
+			}
+			json.NewEncoder(w).Encode(batch)
+		case r.Method == http.MethodPost:
+			var mock map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&mock)
+			json.NewEncoder(w).Encode(create(mock))
+		case r.Method == http.MethodDelete && r.URL.Path == "/__mockforge/api/mocks/groups/checkout":
+			for id, mock := range mocks {
+				if mock["group"] == "checkout" {
+					delete(mocks, id)
+				}
+			}
+			w.Write([]byte(`{"group":"checkout","deleted":3}`))
+		}
+	}))
+
+	if err := server.AddStub(NewStubBuilder("GET", "/shared").Build()); err != nil {
+		t.Fatalf("Failed to add shared stub: %v", err)
+	}
+	group := server.Group("checkout")
+	if err := group.AddStub(NewStubBuilder("GET", "/cart").Build()); err != nil {
+		t.Fatalf("Failed to add group stub: %v", err)
+	}
+	if err := group.StubAll([]ResponseStub{NewStubBuilder("POST", "/pay").Build(), NewStubBuilder("GET", "/receipt").Build()}); err != nil {
+		t.Fatalf("Failed to add group stubs: %v", err)
+	}
+	if mocks["mock-2"]["group"] != "checkout" {
+		t.Errorf("Expected group stubs to be tagged, got %v", mocks["mock-2"])
+	}
+
+	if err := group.Clear(); err != nil {
+		t.Fatalf("Failed to clear group: %v", err)
+	}
+	if len(mocks) != 1 || mocks["mock-1"] == nil {
+		t.Errorf("Expected only the shared stub to remain, got %v", mocks)
+	}
+	if len(server.stubs) != 1 || server.stubs[0].Path != "/shared" {
+		t.Errorf("Expected only the shared stub locally, got %+v", server.stubs)
+	}
+}
@@ -0,0 +1,289 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// CreateStub registers a stub and returns the ID the server assigned to it,
+// for use with GetStub, UpdateStub, and DeleteStub. Stubs registered before
+// the server starts have no ID yet.
+func (m *MockServer) CreateStub(stub ResponseStub) (string, error) {
+	if err := stub.validate(); err != nil {
+		return "", err
+	}
+
+	m.stubs = append(m.stubs, stub)
+
+	// If admin API is available, use it to add the stub dynamically
+	if m.adminPort == 0 {
+		return "", m.persist()
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := m.adminJSON("create mock", http.MethodPost, "/__mockforge/api/mocks", stub.mockConfig(), &created); err != nil {
+		return "", err
+	}
+	return created.ID, m.persist()
+}
+
+// StubAll registers stubs in a single admin call. Every stub is validated
+// first, so an invalid stub registers none of them.
+func (m *MockServer) StubAll(stubs []ResponseStub) error {
+	configs := make([]map[string]interface{}, len(stubs))
+	for i := range stubs {
+		if err := stubs[i].validate(); err != nil {
+			return err
+		}
+		configs[i] = stubs[i].mockConfig()
+	}
+
+	if m.adminPort != 0 && len(configs) > 0 {
+		if err := m.adminJSON("create mocks", http.MethodPost, "/__mockforge/api/mocks/bulk", configs, nil); err != nil {
+			return err
+		}
+	}
+
+	m.stubs = append(m.stubs, stubs...)
+	return m.persist()
+}
+
+// GetStub returns the stub registered under id as the server currently
+// holds it
+func (m *MockServer) GetStub(id string) (*ResponseStub, error) {
+	var config mockConfigWire
+	if err := m.adminJSON("get mock", http.MethodGet, "/__mockforge/api/mocks/"+url.PathEscape(id), nil, &config); err != nil {
+		return nil, err
+	}
+	return config.stub()
+}
+
+// UpdateStub replaces the stub registered under id, keeping its ID
+func (m *MockServer) UpdateStub(id string, stub ResponseStub) error {
+	if err := stub.validate(); err != nil {
+		return err
+	}
+	config := stub.mockConfig()
+	config["id"] = id
+	if err := m.adminJSON("update mock", http.MethodPut, "/__mockforge/api/mocks/"+url.PathEscape(id), config, nil); err != nil {
+		return err
+	}
+	return m.persist()
+}
+
+// DeleteStub removes the stub registered under id. In dry-run mode the
+// deletion is recorded instead.
+func (m *MockServer) DeleteStub(id string) error {
+	if m.isDryRun() {
+		m.recordDryRun("delete stub", []string{id})
+		return nil
+	}
+	if err := m.adminJSON("delete mock", http.MethodDelete, "/__mockforge/api/mocks/"+url.PathEscape(id), nil, nil); err != nil {
+		return err
+	}
+	return m.persist()
+}
+
+// stubsByID returns the registered stubs keyed by the ID the server
+// assigned, which the journal records as stub_id
+func (m *MockServer) stubsByID() (map[string]*ResponseStub, error) {
+	var listed struct {
+		Mocks []mockConfigWire `json:"mocks"`
+	}
+	if err := m.adminJSON("list mocks", http.MethodGet, "/__mockforge/api/mocks", nil, &listed); err != nil {
+		return nil, err
+	}
+	stubs := make(map[string]*ResponseStub, len(listed.Mocks))
+	for i := range listed.Mocks {
+		stub, err := listed.Mocks[i].stub()
+		if err != nil {
+			return nil, err
+		}
+		stubs[listed.Mocks[i].ID] = stub
+	}
+	return stubs, nil
+}
+
+// registeredStubs returns the server's stubs, or the locally added ones
+// before it starts, ordered by path then method
+func (m *MockServer) registeredStubs() ([]ResponseStub, error) {
+	stubs := append([]ResponseStub(nil), m.stubs...)
+	if m.adminPort != 0 {
+		registered, err := m.stubsByID()
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(registered))
+		for id := range registered {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		stubs = stubs[:0]
+		for _, id := range ids {
+			stubs = append(stubs, *registered[id])
+		}
+	}
+	sort.SliceStable(stubs, func(i, j int) bool {
+		if stubs[i].Path != stubs[j].Path {
+			return stubs[i].Path < stubs[j].Path
+		}
+		return stubs[i].Method < stubs[j].Method
+	})
+	return stubs, nil
+}
+
+// mockConfigWire is a MockConfig as returned by the admin API, decoded into
+// the fields stubs are built from
+type mockConfigWire struct {
+	ID         string `json:"id"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	PathMatch  string `json:"path_match"`
+	StatusCode int    `json:"status_code"`
+	Response   struct {
+		Body            interface{}       `json:"body"`
+		BodyBase64      string            `json:"body_base64"`
+		BodyFile        string            `json:"body_file"`
+		Headers         map[string]string `json:"headers"`
+		SetCookies      []string          `json:"set_cookies"`
+		ContentEncoding ContentEncoding   `json:"content_encoding"`
+		KeepOpen        bool              `json:"keep_open"`
+		Throttle        int               `json:"throttle_bytes_per_second"`
+		Chunks          []struct {
+			DataBase64 string `json:"data_base64"`
+			DelayMs    int    `json:"delay_ms"`
+		} `json:"chunks"`
+		RawBase64 string `json:"raw_base64"`
+		Fault     *struct {
+			Type Fault `json:"type"`
+		} `json:"fault"`
+	} `json:"response"`
+	LatencyMs             *float64        `json:"latency_ms"`
+	Latency               *LatencySpec    `json:"latency"`
+	RequestMatch          *RequestMatch   `json:"request_match"`
+	RequestSchema         json.RawMessage `json:"request_schema"`
+	Priority              int             `json:"priority"`
+	Group                 string          `json:"group"`
+	Transformers          []string        `json:"transformers"`
+	Scenario              string          `json:"scenario"`
+	RequiredScenarioState string          `json:"required_scenario_state"`
+	NewScenarioState      string          `json:"new_scenario_state"`
+	ResponseSequence      []struct {
+		StatusCode int               `json:"status_code"`
+		Body       interface{}       `json:"body"`
+		Headers    map[string]string `json:"headers"`
+		LatencyMs  *float64          `json:"latency_ms"`
+		Latency    *LatencySpec      `json:"latency"`
+	} `json:"response_sequence"`
+	MaxMatches int `json:"max_matches"`
+	RateLimit  *struct {
+		Limit    int   `json:"limit"`
+		WindowMs int64 `json:"window_ms"`
+	} `json:"rate_limit"`
+	ExpiresAfterMs int64 `json:"expires_after_ms"`
+	Callbacks      []struct {
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Body    interface{}       `json:"body"`
+		DelayMs int64             `json:"delay_ms"`
+	} `json:"callbacks"`
+	Proxy *ProxyConfig `json:"proxy"`
+}
+
+// stub converts the MockConfig back into the stub that produces it
+func (c *mockConfigWire) stub() (*ResponseStub, error) {
+	stub := &ResponseStub{
+		Method:        c.Method,
+		Path:          c.Path,
+		Status:        c.StatusCode,
+		Headers:       c.Response.Headers,
+		Body:          c.Response.Body,
+		BodyFile:      c.Response.BodyFile,
+		SetCookies:    c.Response.SetCookies,
+		Encoding:      c.Response.ContentEncoding,
+		KeepOpen:      c.Response.KeepOpen,
+		Throttle:      c.Response.Throttle,
+		Latency:       wireLatency(c.LatencyMs, c.Latency),
+		Match:         c.RequestMatch,
+		RequestSchema: c.RequestSchema,
+		Priority:      c.Priority,
+		Group:         c.Group,
+		Transformers:  c.Transformers,
+		Scenario:      c.Scenario,
+		RequiredState: c.RequiredScenarioState,
+		NewState:      c.NewScenarioState,
+		Times:         c.MaxMatches,
+		ExpiresAfter:  time.Duration(c.ExpiresAfterMs) * time.Millisecond,
+		Proxy:         c.Proxy,
+	}
+	if stub.Status == 0 {
+		stub.Status = http.StatusOK
+	}
+	if c.PathMatch == "regex" {
+		stub.Path = regexPathPrefix + c.Path
+	}
+	if c.Response.Fault != nil {
+		stub.Fault = c.Response.Fault.Type
+	}
+	if c.RateLimit != nil {
+		stub.RateLimit = &RateLimit{Limit: c.RateLimit.Limit, Window: time.Duration(c.RateLimit.WindowMs) * time.Millisecond}
+	}
+
+	var err error
+	decode := func(encoded string) []byte {
+		data, decodeErr := base64.StdEncoding.DecodeString(encoded)
+		if decodeErr != nil && err == nil {
+			err = NewAdminAPIError("get mock", "invalid base64 in mock config", decodeErr)
+		}
+		return data
+	}
+	if c.Response.BodyBase64 != "" {
+		stub.BodyBytes = decode(c.Response.BodyBase64)
+	}
+	if c.Response.RawBase64 != "" {
+		stub.RawResponse = decode(c.Response.RawBase64)
+	}
+	for _, chunk := range c.Response.Chunks {
+		stub.Chunks = append(stub.Chunks, Chunk{Data: decode(chunk.DataBase64), DelayMs: chunk.DelayMs})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resp := range c.ResponseSequence {
+		stub.Sequence = append(stub.Sequence, SequencedResponse{
+			Status:  resp.StatusCode,
+			Headers: resp.Headers,
+			Body:    resp.Body,
+			Latency: wireLatency(resp.LatencyMs, resp.Latency),
+		})
+	}
+	for _, callback := range c.Callbacks {
+		stub.Callbacks = append(stub.Callbacks, Callback{
+			Method:  callback.Method,
+			URL:     callback.URL,
+			Headers: callback.Headers,
+			Body:    callback.Body,
+			Delay:   time.Duration(callback.DelayMs) * time.Millisecond,
+		})
+	}
+
+	return stub, nil
+}
+
+// wireLatency converts the latency keys written by setLatency back to a spec
+func wireLatency(fixedMs *float64, spec *LatencySpec) *LatencySpec {
+	if spec != nil {
+		return spec
+	}
+	if fixedMs != nil {
+		return &LatencySpec{Distribution: LatencyFixed, FixedMs: *fixedMs}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStubCRUD(t *testing.T) {
+	mocks := make(map[string]map[string]interface{})
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/__mockforge/api/mocks/")
+		switch r.Method {
+		case http.MethodPost:
+			var mock map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&mock)
+			mock["id"] = "mock-1"
+			mocks["mock-1"] = mock
+			json.NewEncoder(w).Encode(mock)
+		case http.MethodGet:
+			mock, ok := mocks[id]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(mock)
+		case http.MethodPut:
+			var mock map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&mock)
+			mocks[id] = mock
+		case http.MethodDelete:
+			delete(mocks, id)
+		}
+	}))
+
+	id, err := server.CreateStub(NewStubBuilder("GET", PathRegex(`^/users/\d+$`)).
+		Body(map[string]string{"name": "Alice"}).
+		Latency(25).
+		WhenHeader("X-Tenant", "acme").
+		Times(3).
+		Build())
+	if err != nil {
+		t.Fatalf("Failed to create stub: %v", err)
+	}
+	if id != "mock-1" {
+		t.Fatalf("Expected server-assigned ID, got %q", id)
+	}
+
+	stub, err := server.GetStub(id)
+	if err != nil {
+		t.Fatalf("Failed to get stub: %v", err)
+	}
+	if stub.Path != PathRegex(`^/users/\d+$`) || stub.Status != 200 || stub.Times != 3 {
+		t.Errorf("Expected regex path, status 200, and 3 matches, got %+v", stub)
+	}
+	if stub.Latency == nil || stub.Latency.Sample(nil) != 25*time.Millisecond {
+		t.Errorf("Expected fixed 25ms latency, got %+v", stub.Latency)
+	}
+	if stub.Match == nil || stub.Match.Headers["X-Tenant"] != "^acme$" {
+		t.Errorf("Expected header match, got %+v", stub.Match)
+	}
+
+	stub.Status = 503
+	if err := server.UpdateStub(id, *stub); err != nil {
+		t.Fatalf("Failed to update stub: %v", err)
+	}
+	if mocks[id]["status_code"] != float64(503) || mocks[id]["id"] != id {
+		t.Errorf("Expected updated status under the same ID, got %v", mocks[id])
+	}
+
+	if err := server.DeleteStub(id); err != nil {
+		t.Fatalf("Failed to delete stub: %v", err)
+	}
+	if _, err := server.GetStub(id); err == nil {
+		t.Error("Expected error getting a deleted stub")
+	}
+}
+
+func TestStubAll(t *testing.T) {
+	var requests int
+	var batch []map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPost || r.URL.Path != "/__mockforge/api/mocks/bulk" {
+			t.Errorf("Expected POST to the bulk endpoint, got %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&batch)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	stubs := make([]ResponseStub, 200)
+	for i := range stubs {
+		stubs[i] = NewStubBuilder("GET", "/items/"+strings.Repeat("x", i%5+1)).Status(200).Build()
+	}
+	if err := server.StubAll(stubs); err != nil {
+		t.Fatalf("Failed to register stubs: %v", err)
+	}
+	if requests != 1 || len(batch) != 200 {
+		t.Errorf("Expected 1 request carrying 200 mocks, got %d requests and %d mocks", requests, len(batch))
+	}
+
+	t.Run("invalid stub registers none", func(t *testing.T) {
+		requests = 0
+		err := server.StubAll([]ResponseStub{stubs[0], {Method: "GET", Path: PathRegex("("), Status: 200}})
+		if err == nil {
+			t.Error("Expected error for invalid stub")
+		}
+		if requests != 0 {
+			t.Errorf("Expected no admin request, got %d", requests)
+		}
+	})
+}
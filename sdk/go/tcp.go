@@ -0,0 +1,158 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TCPStub answers what a raw TCP client sends, so custom binary protocols
+// can be mocked. MockForge's TCP server matches each read from a client
+// against the stubs: Data and DataHex match a read of exactly those bytes,
+// TextPattern a read of UTF-8 text matching the regular expression. A stub
+// with none of them answers reads no other stub matches.
+type TCPStub struct {
+	Data        []byte
+	DataHex     string
+	TextPattern string
+	// Response is sent back, or ResponseHex given in hex, e.g. "06 41"
+	Response    []byte
+	ResponseHex string
+	// Delay is how long the server waits before responding
+	Delay time.Duration
+	// Close closes the connection after the response; otherwise it stays
+	// open for the client's next message
+	Close bool
+}
+
+// tcpFixture is a TCPStub as a mockforge-tcp fixture
+type tcpFixture struct {
+	Identifier    string           `yaml:"identifier"`
+	Name          string           `yaml:"name"`
+	MatchCriteria tcpMatchCriteria `yaml:"match_criteria"`
+	Response      tcpResponse      `yaml:"response"`
+}
+
+type tcpMatchCriteria struct {
+	ExactBytes  string `yaml:"exact_bytes,omitempty"`
+	TextPattern string `yaml:"text_pattern,omitempty"`
+	MatchAll    bool   `yaml:"match_all,omitempty"`
+}
+
+type tcpResponse struct {
+	Data               string `yaml:"data"`
+	Encoding           string `yaml:"encoding"`
+	DelayMS            int64  `yaml:"delay_ms,omitempty"`
+	CloseAfterResponse bool   `yaml:"close_after_response,omitempty"`
+	KeepAlive          bool   `yaml:"keep_alive"`
+}
+
+// StubTCP adds a stub to MockForge's TCP server, which runs when at least
+// one stub is registered; see TCPAddr. The TCP server loads stubs at
+// startup, so register them before Start. Clients that send something no
+// stub matches are disconnected.
+//
+//	server.StubTCP(mockforge.TCPStub{DataHex: "02 30 31 03", ResponseHex: "06"})
+//	server.StubTCP(mockforge.TCPStub{TextPattern: "^PING", Response: []byte("PONG\n")})
+func (m *MockServer) StubTCP(stub TCPStub) error {
+	fixture, err := stub.fixture()
+	if err != nil {
+		return err
+	}
+	if m.IsRunning() {
+		return NewInvalidConfigError("TCP stubs must be registered before Start; the TCP server loads them at startup", nil)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fixture.Identifier = fmt.Sprintf("sdk-stub-%d", len(m.tcpFixtures)+1)
+	fixture.Name = fmt.Sprintf("StubTCP #%d", len(m.tcpFixtures)+1)
+	m.tcpFixtures = append(m.tcpFixtures, fixture)
+	return nil
+}
+
+// TCPAddr returns the host:port of the TCP server StubTCP stubs are served
+// on
+func (m *MockServer) TCPAddr() (string, error) {
+	m.portMutex.RLock()
+	defer m.portMutex.RUnlock()
+	if m.tcpPort == 0 {
+		return "", NewInvalidConfigError("TCP server not running; register a StubTCP stub before Start", nil)
+	}
+	return net.JoinHostPort(m.host, strconv.Itoa(m.tcpPort)), nil
+}
+
+// fixture validates the stub and converts it to a fixture
+func (s TCPStub) fixture() (tcpFixture, error) {
+	matchers := 0
+	for _, set := range []bool{s.Data != nil, s.DataHex != "", s.TextPattern != ""} {
+		if set {
+			matchers++
+		}
+	}
+	if matchers > 1 {
+		return tcpFixture{}, NewInvalidConfigError("TCP stub must match on at most one of Data, DataHex, and TextPattern", nil)
+	}
+	if s.Response != nil && s.ResponseHex != "" {
+		return tcpFixture{}, NewInvalidConfigError("TCP stub must set at most one of Response and ResponseHex", nil)
+	}
+	if s.Delay < 0 {
+		return tcpFixture{}, NewInvalidConfigError("TCP stub delay must not be negative", nil)
+	}
+
+	data := s.Data
+	if s.DataHex != "" {
+		decoded, err := decodeTCPHex(s.DataHex)
+		if err != nil {
+			return tcpFixture{}, err
+		}
+		data = decoded
+	}
+	response := s.Response
+	if s.ResponseHex != "" {
+		decoded, err := decodeTCPHex(s.ResponseHex)
+		if err != nil {
+			return tcpFixture{}, err
+		}
+		response = decoded
+	}
+
+	fixture := tcpFixture{
+		Response: tcpResponse{
+			Data:               base64.StdEncoding.EncodeToString(response),
+			Encoding:           "base64",
+			DelayMS:            s.Delay.Milliseconds(),
+			CloseAfterResponse: s.Close,
+			KeepAlive:          !s.Close,
+		},
+	}
+	switch {
+	case data != nil:
+		if len(data) == 0 {
+			return tcpFixture{}, NewInvalidConfigError("TCP stub data must not be empty", nil)
+		}
+		fixture.MatchCriteria.ExactBytes = base64.StdEncoding.EncodeToString(data)
+	case s.TextPattern != "":
+		if _, err := regexp.Compile(s.TextPattern); err != nil {
+			return tcpFixture{}, NewInvalidConfigError("invalid TCP text pattern: "+err.Error(), map[string]interface{}{"pattern": s.TextPattern})
+		}
+		fixture.MatchCriteria.TextPattern = s.TextPattern
+	default:
+		fixture.MatchCriteria.MatchAll = true
+	}
+	return fixture, nil
+}
+
+// decodeTCPHex decodes hex bytes, ignoring whitespace between them
+func decodeTCPHex(pattern string) ([]byte, error) {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(pattern), ""))
+	if err != nil {
+		return nil, NewInvalidConfigError("invalid TCP hex pattern: "+err.Error(), map[string]interface{}{"pattern": pattern})
+	}
+	return data, nil
+}
@@ -0,0 +1,111 @@
+package mockforge
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStubTCPServerConfig(t *testing.T) {
+	server := NewMockServer(MockServerConfig{TCPPort: 7000})
+	stubs := []TCPStub{
+		{DataHex: "02 30 31 03", ResponseHex: "06", Delay: 10 * time.Millisecond},
+		{TextPattern: "^QUIT", Response: []byte("BYE\n"), Close: true},
+		{Response: []byte("ERR\n")},
+	}
+	for _, stub := range stubs {
+		if err := server.StubTCP(stub); err != nil {
+			t.Fatalf("Failed to stub TCP: %v", err)
+		}
+	}
+
+	path, dir, err := server.writeServerConfig()
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	var config struct {
+		TCP map[string]interface{} `yaml:"tcp"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	fixturesDir := filepath.Join(dir, "tcp-fixtures")
+	want := map[string]interface{}{"enabled": true, "port": 7000, "host": "127.0.0.1", "fixtures_dir": fixturesDir, "echo_mode": false}
+	if !reflect.DeepEqual(config.TCP, want) {
+		t.Errorf("Expected tcp section %v, got %v", want, config.TCP)
+	}
+
+	data, err = os.ReadFile(filepath.Join(fixturesDir, "stubs.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read TCP fixtures: %v", err)
+	}
+	var fixtures []map[string]interface{}
+	if err := yaml.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("Failed to parse TCP fixtures: %v", err)
+	}
+	wantFixtures := []map[string]interface{}{
+		{
+			"identifier":     "sdk-stub-1",
+			"name":           "StubTCP #1",
+			"match_criteria": map[string]interface{}{"exact_bytes": "AjAxAw=="},
+			"response":       map[string]interface{}{"data": "Bg==", "encoding": "base64", "delay_ms": 10, "keep_alive": true},
+		},
+		{
+			"identifier":     "sdk-stub-2",
+			"name":           "StubTCP #2",
+			"match_criteria": map[string]interface{}{"text_pattern": "^QUIT"},
+			"response":       map[string]interface{}{"data": "QllFCg==", "encoding": "base64", "close_after_response": true, "keep_alive": false},
+		},
+		{
+			"identifier":     "sdk-stub-3",
+			"name":           "StubTCP #3",
+			"match_criteria": map[string]interface{}{"match_all": true},
+			"response":       map[string]interface{}{"data": "RVJSCg==", "encoding": "base64", "keep_alive": true},
+		},
+	}
+	if !reflect.DeepEqual(fixtures, wantFixtures) {
+		t.Errorf("Expected fixtures %v, got %v", wantFixtures, fixtures)
+	}
+
+	// A config file's own fixtures cannot be merged with the stubs
+	configFile := filepath.Join(t.TempDir(), "mockforge.yaml")
+	os.WriteFile(configFile, []byte("tcp:\n  fixtures_dir: /srv/tcp-fixtures\n"), 0o644)
+	server = NewMockServer(MockServerConfig{ConfigFile: configFile})
+	server.StubTCP(TCPStub{Response: []byte("OK")})
+	if _, _, err := server.writeServerConfig(); err == nil {
+		t.Error("Expected an error for a config file with tcp.fixtures_dir")
+	}
+}
+
+func TestStubTCPValidation(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+
+	tests := []TCPStub{
+		{Data: []byte("a"), TextPattern: "a"},
+		{Data: []byte("a"), DataHex: "61"},
+		{Data: []byte{}},
+		{DataHex: "0g"},
+		{TextPattern: "("},
+		{Response: []byte("a"), ResponseHex: "61"},
+		{ResponseHex: "zz"},
+		{Delay: -time.Second},
+	}
+	for _, stub := range tests {
+		if err := server.StubTCP(stub); err == nil {
+			t.Errorf("Expected error for stub %+v", stub)
+		}
+	}
+	if _, err := server.TCPAddr(); err == nil {
+		t.Error("Expected an error before the TCP server is running")
+	}
+}
@@ -0,0 +1,95 @@
+package mockforge
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// Transformer is a named rewrite the server applies to responses before
+// they are sent, referenced by stubs with StubBuilder.Transform. Its steps
+// run in field order: status, header removals, header values, body patch,
+// then the signature of the resulting body.
+//
+// SetHeaders values and the string values in BodyPatch may use
+// {{request.path.<name>}}, {{request.header.<name>}}, and
+// {{request.body.<field>}} placeholders, filled from the matched request.
+type Transformer struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// StatusCode, if set, replaces the response status
+	StatusCode int `json:"status_code,omitempty"`
+	// SetHeaders are set on the response, replacing any of the same name
+	SetHeaders map[string]string `json:"set_headers,omitempty"`
+	// RemoveHeaders are removed from the response
+	RemoveHeaders []string `json:"remove_headers,omitempty"`
+	// BodyPatch is applied to JSON response bodies
+	BodyPatch []PatchOperation `json:"body_patch,omitempty"`
+	// Sign, if set, adds an HMAC-SHA256 signature of the body
+	Sign *ResponseSignature `json:"sign,omitempty"`
+}
+
+// PatchOperation is a JSON Patch (RFC 6902) operation
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ResponseSignature signs the response body with HMAC-SHA256 and sends the
+// hex-encoded signature in Header
+type ResponseSignature struct {
+	Header string `json:"header"`
+	Secret string `json:"secret"`
+}
+
+// transformerNamePattern restricts names to what is safe in admin API paths
+var transformerNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// validateTransformerName checks a transformer name can be referenced
+func validateTransformerName(name string) error {
+	if !transformerNamePattern.MatchString(name) {
+		return NewInvalidConfigError("transformer name must be letters, digits, '.', '_', or '-'", map[string]interface{}{"name": name})
+	}
+	return nil
+}
+
+// Transform applies the named transformers, in order, to the stub's
+// responses. Names no transformer is defined for are skipped.
+func (b *StubBuilder) Transform(names ...string) *StubBuilder {
+	b.transformers = append(b.transformers, names...)
+	return b
+}
+
+// DefineTransformer creates or replaces a transformer
+func (m *MockServer) DefineTransformer(transformer Transformer) error {
+	if err := validateTransformerName(transformer.Name); err != nil {
+		return err
+	}
+	if transformer.Sign != nil && transformer.Sign.Header == "" {
+		return NewInvalidConfigError("transformer signature needs a header", map[string]interface{}{"name": transformer.Name})
+	}
+	return m.adminJSON("define transformer", http.MethodPut, "/__mockforge/api/transformers/"+url.PathEscape(transformer.Name), transformer, nil)
+}
+
+// Transformers lists the defined transformers
+func (m *MockServer) Transformers() ([]Transformer, error) {
+	var result struct {
+		Transformers []Transformer `json:"transformers"`
+	}
+	if err := m.adminJSON("list transformers", http.MethodGet, "/__mockforge/api/transformers", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Transformers, nil
+}
+
+// DeleteTransformer removes a transformer. Stubs still referencing it
+// respond untransformed. In dry-run mode the deletion is recorded instead.
+func (m *MockServer) DeleteTransformer(name string) error {
+	if m.isDryRun() {
+		m.recordDryRun("delete transformer", []string{name})
+		return nil
+	}
+	return m.adminJSON("delete transformer", http.MethodDelete, "/__mockforge/api/transformers/"+url.PathEscape(name), nil, nil)
+}
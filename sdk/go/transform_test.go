@@ -0,0 +1,75 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTransformers(t *testing.T) {
+	defined := make(map[string]json.RawMessage)
+	var mock map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/__mockforge/api/transformers/")
+		switch {
+		case r.URL.Path == "/__mockforge/api/mocks":
+			json.NewDecoder(r.Body).Decode(&mock)
+		case r.Method == http.MethodPut:
+			var transformer json.RawMessage
+			json.NewDecoder(r.Body).Decode(&transformer)
+			defined[name] = transformer
+		case r.Method == http.MethodGet:
+			list := []json.RawMessage{}
+			for _, transformer := range defined {
+				list = append(list, transformer)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"transformers": list})
+		case r.Method == http.MethodDelete:
+			delete(defined, name)
+		}
+	}))
+
+	err := server.DefineTransformer(Transformer{
+		Name:          "sign-response",
+		StatusCode:    201,
+		SetHeaders:    map[string]string{"X-Request-Id": "{{request.header.x-request-id}}"},
+		RemoveHeaders: []string{"X-Internal"},
+		BodyPatch:     []PatchOperation{{Op: "add", Path: "/signed", Value: true}},
+		Sign:          &ResponseSignature{Header: "X-Signature", Secret: "key"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to define transformer: %v", err)
+	}
+	want := `{"name":"sign-response","status_code":201,"set_headers":{"X-Request-Id":"{{request.header.x-request-id}}"},` +
+		`"remove_headers":["X-Internal"],"body_patch":[{"op":"add","path":"/signed","value":true}],` +
+		`"sign":{"header":"X-Signature","secret":"key"}}`
+	if got := string(defined["sign-response"]); got != want {
+		t.Errorf("Expected transformer body %s, got %s", want, got)
+	}
+	list, err := server.Transformers()
+	if err != nil || len(list) != 1 || list[0].Sign == nil || list[0].Sign.Header != "X-Signature" {
+		t.Errorf("Expected the defined transformer, got %v (%v)", list, err)
+	}
+
+	if err := server.AddStub(NewStubBuilder("GET", "/orders").Transform("inject-request-id", "sign-response").Build()); err != nil {
+		t.Fatalf("Failed to add stub: %v", err)
+	}
+	if names, _ := mock["transformers"].([]interface{}); len(names) != 2 || names[1] != "sign-response" {
+		t.Errorf("Expected transformers in order, got %v", mock["transformers"])
+	}
+	if err := server.AddStub(NewStubBuilder("GET", "/orders").Transform("bad/name").Build()); err == nil {
+		t.Error("Expected error for an invalid transformer reference")
+	}
+
+	if err := server.DeleteTransformer("sign-response"); err != nil || len(defined) != 0 {
+		t.Errorf("Expected transformer to be deleted, got %v (%v)", defined, err)
+	}
+
+	if err := server.DefineTransformer(Transformer{Name: "bad/name"}); err == nil {
+		t.Error("Expected error for invalid name")
+	}
+	if err := server.DefineTransformer(Transformer{Name: "unsigned", Sign: &ResponseSignature{Secret: "key"}}); err == nil {
+		t.Error("Expected error for a signature without a header")
+	}
+}
@@ -0,0 +1,501 @@
+package mockforge
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TwilioConfig configures the Twilio-compatible preset
+type TwilioConfig struct {
+	// AccountSID and AuthToken are the credentials clients must present with
+	// HTTP basic auth. They default to a fixed test account.
+	AccountSID string
+	AuthToken  string
+	// CallbackDelay is the pause between status callbacks (default 10ms)
+	CallbackDelay time.Duration
+}
+
+// TwilioMessage is a message created through the Twilio preset
+type TwilioMessage struct {
+	SID            string
+	From           string
+	To             string
+	Body           string
+	Status         string
+	StatusCallback string
+	ErrorCode      int
+	CreatedAt      time.Time
+}
+
+// TwilioCall is a voice call created through the Twilio preset
+type TwilioCall struct {
+	SID            string
+	From           string
+	To             string
+	URL            string
+	Twiml          string
+	StatusCallback string
+	CreatedAt      time.Time
+}
+
+// TwilioCallback is a status callback delivered by the preset
+type TwilioCallback struct {
+	URL        string
+	Params     url.Values
+	Signature  string
+	StatusCode int
+	Err        error
+}
+
+// TwilioMock is a Twilio-compatible SMS and voice API with an inbox for
+// assertions. Status callbacks are signed with X-Twilio-Signature exactly as
+// Twilio does, so webhook signature validation in the application can be
+// exercised too.
+//
+// Unlike the stub-based presets, the Twilio API is served by an in-process
+// listener: it needs to inspect form bodies and drive status callbacks.
+// Configure the Twilio client to use URL() as its API base.
+type TwilioMock struct {
+	config  TwilioConfig
+	sidecar *httpSidecar
+	client  *http.Client
+
+	mu            sync.Mutex
+	messages      []TwilioMessage
+	calls         []TwilioCall
+	callbacks     []TwilioCallback
+	rejected      map[string]int
+	undeliverable map[string]int
+}
+
+// twilioE164 matches an E.164 phone number
+var twilioE164 = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// Twilio returns the Twilio-compatible preset, starting it on first use.
+// The config is only applied when the preset is first started.
+func (m *MockServer) Twilio(config TwilioConfig) (*TwilioMock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.twilio != nil {
+		return m.twilio, nil
+	}
+
+	if config.AccountSID == "" {
+		config.AccountSID = "AC00000000000000000000000000000000"
+	}
+	if config.AuthToken == "" {
+		config.AuthToken = "mockforge-auth-token"
+	}
+	if config.CallbackDelay == 0 {
+		config.CallbackDelay = 10 * time.Millisecond
+	}
+
+	twilio := &TwilioMock{
+		config:        config,
+		client:        &http.Client{Timeout: 5 * time.Second},
+		rejected:      make(map[string]int),
+		undeliverable: make(map[string]int),
+	}
+
+	sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(twilio.serveHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start Twilio preset: %w", err)
+	}
+	twilio.sidecar = sidecar
+
+	m.attached = append(m.attached, sidecar)
+	m.twilio = twilio
+
+	return twilio, nil
+}
+
+// URL returns the API base URL to configure in the Twilio client
+func (t *TwilioMock) URL() string {
+	return t.sidecar.URL()
+}
+
+// AccountSID returns the account SID clients must authenticate with
+func (t *TwilioMock) AccountSID() string {
+	return t.config.AccountSID
+}
+
+// AuthToken returns the auth token clients must authenticate with
+func (t *TwilioMock) AuthToken() string {
+	return t.config.AuthToken
+}
+
+// RejectNumber makes message and call creation to the number fail with the
+// given Twilio error code, e.g. 21610 (unsubscribed recipient). Invalid
+// numbers are rejected with 21211 without configuration.
+func (t *TwilioMock) RejectNumber(to string, code int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rejected[to] = code
+}
+
+// UndeliverableNumber accepts messages to the number but reports them as
+// undelivered with the given error code (e.g. 30003) via status callback
+func (t *TwilioMock) UndeliverableNumber(to string, code int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.undeliverable[to] = code
+}
+
+// Messages returns every accepted message in creation order
+func (t *TwilioMock) Messages() []TwilioMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TwilioMessage(nil), t.messages...)
+}
+
+// MessagesTo returns the accepted messages sent to a number
+func (t *TwilioMock) MessagesTo(to string) []TwilioMessage {
+	var found []TwilioMessage
+	for _, message := range t.Messages() {
+		if message.To == to {
+			found = append(found, message)
+		}
+	}
+	return found
+}
+
+// LastMessageTo returns the most recent message sent to a number, which is
+// typically what an OTP test needs
+func (t *TwilioMock) LastMessageTo(to string) (TwilioMessage, bool) {
+	messages := t.MessagesTo(to)
+	if len(messages) == 0 {
+		return TwilioMessage{}, false
+	}
+	return messages[len(messages)-1], true
+}
+
+// Calls returns every accepted voice call in creation order
+func (t *TwilioMock) Calls() []TwilioCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TwilioCall(nil), t.calls...)
+}
+
+// Callbacks returns the status callbacks delivered so far
+func (t *TwilioMock) Callbacks() []TwilioCallback {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TwilioCallback(nil), t.callbacks...)
+}
+
+// Reset clears the inbox, calls, and callbacks; number configuration is kept
+func (t *TwilioMock) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+	t.calls = nil
+	t.callbacks = nil
+}
+
+// TwilioSignature computes the X-Twilio-Signature header Twilio sends with a
+// webhook to callbackURL carrying the given form parameters
+func TwilioSignature(authToken, callbackURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(callbackURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (t *TwilioMock) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := "/2010-04-01/Accounts/" + t.config.AccountSID + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		twilioError(w, http.StatusNotFound, 20404, "The requested resource "+r.URL.Path+" was not found")
+		return
+	}
+
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != t.config.AccountSID || pass != t.config.AuthToken {
+		twilioError(w, http.StatusUnauthorized, 20003, "Authenticate")
+		return
+	}
+
+	resource := strings.TrimPrefix(r.URL.Path, prefix)
+	switch {
+	case resource == "Messages.json" && r.Method == http.MethodPost:
+		t.createMessage(w, r)
+	case strings.HasPrefix(resource, "Messages/") && r.Method == http.MethodGet:
+		t.getMessage(w, strings.TrimSuffix(strings.TrimPrefix(resource, "Messages/"), ".json"))
+	case resource == "Calls.json" && r.Method == http.MethodPost:
+		t.createCall(w, r)
+	default:
+		twilioError(w, http.StatusNotFound, 20404, "The requested resource "+r.URL.Path+" was not found")
+	}
+}
+
+func (t *TwilioMock) createMessage(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		twilioError(w, http.StatusBadRequest, 21100, "Invalid form body")
+		return
+	}
+
+	to := r.PostForm.Get("To")
+	if code, ok := t.rejection(to); ok {
+		twilioError(w, http.StatusBadRequest, code, twilioRejectionMessage(code, to))
+		return
+	}
+	if r.PostForm.Get("Body") == "" && r.PostForm.Get("MediaUrl") == "" {
+		twilioError(w, http.StatusBadRequest, 21602, "Message body is required.")
+		return
+	}
+
+	message := TwilioMessage{
+		SID:            twilioSID("SM"),
+		From:           r.PostForm.Get("From"),
+		To:             to,
+		Body:           r.PostForm.Get("Body"),
+		Status:         "queued",
+		StatusCallback: r.PostForm.Get("StatusCallback"),
+		CreatedAt:      time.Now(),
+	}
+
+	t.mu.Lock()
+	t.messages = append(t.messages, message)
+	undeliverableCode, undeliverable := t.undeliverable[to]
+	t.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, t.messageResource(message))
+
+	statuses := []string{"sent", "delivered"}
+	if undeliverable {
+		statuses = []string{"sent", "undelivered"}
+	}
+	go t.advanceMessage(message, statuses, undeliverableCode)
+}
+
+func (t *TwilioMock) getMessage(w http.ResponseWriter, sid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, message := range t.messages {
+		if message.SID == sid {
+			writeJSON(w, http.StatusOK, t.messageResource(message))
+			return
+		}
+	}
+	twilioError(w, http.StatusNotFound, 20404, "The requested resource was not found")
+}
+
+func (t *TwilioMock) createCall(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		twilioError(w, http.StatusBadRequest, 21100, "Invalid form body")
+		return
+	}
+
+	to := r.PostForm.Get("To")
+	if code, ok := t.rejection(to); ok {
+		twilioError(w, http.StatusBadRequest, code, twilioRejectionMessage(code, to))
+		return
+	}
+	if r.PostForm.Get("Url") == "" && r.PostForm.Get("Twiml") == "" {
+		twilioError(w, http.StatusBadRequest, 21205, "Url parameter is required.")
+		return
+	}
+
+	call := TwilioCall{
+		SID:            twilioSID("CA"),
+		From:           r.PostForm.Get("From"),
+		To:             to,
+		URL:            r.PostForm.Get("Url"),
+		Twiml:          r.PostForm.Get("Twiml"),
+		StatusCallback: r.PostForm.Get("StatusCallback"),
+		CreatedAt:      time.Now(),
+	}
+
+	t.mu.Lock()
+	t.calls = append(t.calls, call)
+	t.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"sid":         call.SID,
+		"account_sid": t.config.AccountSID,
+		"from":        call.From,
+		"to":          call.To,
+		"status":      "queued",
+		"uri":         fmt.Sprintf("/2010-04-01/Accounts/%s/Calls/%s.json", t.config.AccountSID, call.SID),
+	})
+
+	if call.StatusCallback != "" {
+		go func() {
+			time.Sleep(t.config.CallbackDelay)
+			t.sendCallback(call.StatusCallback, url.Values{
+				"AccountSid": {t.config.AccountSID},
+				"CallSid":    {call.SID},
+				"From":       {call.From},
+				"To":         {call.To},
+				"CallStatus": {"completed"},
+			})
+		}()
+	}
+}
+
+// rejection returns the error code creation to the number must fail with
+func (t *TwilioMock) rejection(to string) (int, bool) {
+	t.mu.Lock()
+	code, ok := t.rejected[to]
+	t.mu.Unlock()
+
+	if ok {
+		return code, true
+	}
+	if !twilioE164.MatchString(to) {
+		return 21211, true
+	}
+	return 0, false
+}
+
+// advanceMessage moves a message through its lifecycle, firing a status
+// callback at each step
+func (t *TwilioMock) advanceMessage(message TwilioMessage, statuses []string, errorCode int) {
+	for _, status := range statuses {
+		time.Sleep(t.config.CallbackDelay)
+
+		t.mu.Lock()
+		for i := range t.messages {
+			if t.messages[i].SID == message.SID {
+				t.messages[i].Status = status
+				if status == "undelivered" {
+					t.messages[i].ErrorCode = errorCode
+				}
+			}
+		}
+		t.mu.Unlock()
+
+		if message.StatusCallback == "" {
+			continue
+		}
+
+		params := url.Values{
+			"AccountSid":    {t.config.AccountSID},
+			"MessageSid":    {message.SID},
+			"SmsSid":        {message.SID},
+			"From":          {message.From},
+			"To":            {message.To},
+			"MessageStatus": {status},
+			"SmsStatus":     {status},
+		}
+		if status == "undelivered" {
+			params.Set("ErrorCode", fmt.Sprintf("%d", errorCode))
+		}
+		t.sendCallback(message.StatusCallback, params)
+	}
+}
+
+// sendCallback POSTs a signed status callback and records the outcome
+func (t *TwilioMock) sendCallback(callbackURL string, params url.Values) {
+	callback := TwilioCallback{
+		URL:       callbackURL,
+		Params:    params,
+		Signature: TwilioSignature(t.config.AuthToken, callbackURL, params),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, callbackURL, strings.NewReader(params.Encode()))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Twilio-Signature", callback.Signature)
+		req.Header.Set("User-Agent", "TwilioProxy/1.1")
+
+		var resp *http.Response
+		resp, err = t.client.Do(req)
+		if err == nil {
+			callback.StatusCode = resp.StatusCode
+			resp.Body.Close()
+		}
+	}
+	callback.Err = err
+
+	t.mu.Lock()
+	t.callbacks = append(t.callbacks, callback)
+	t.mu.Unlock()
+}
+
+func (t *TwilioMock) messageResource(message TwilioMessage) map[string]interface{} {
+	resource := map[string]interface{}{
+		"sid":          message.SID,
+		"account_sid":  t.config.AccountSID,
+		"from":         message.From,
+		"to":           message.To,
+		"body":         message.Body,
+		"status":       message.Status,
+		"direction":    "outbound-api",
+		"num_segments": "1",
+		"date_created": message.CreatedAt.UTC().Format(time.RFC1123Z),
+		"uri":          fmt.Sprintf("/2010-04-01/Accounts/%s/Messages/%s.json", t.config.AccountSID, message.SID),
+		"error_code":   nil,
+	}
+	if message.ErrorCode != 0 {
+		resource["error_code"] = message.ErrorCode
+	}
+	return resource
+}
+
+// twilioRejectionMessages holds the messages Twilio returns for common
+// recipient errors
+var twilioRejectionMessages = map[int]string{
+	21211: "The 'To' number %s is not a valid phone number.",
+	21408: "Permission to send an SMS has not been enabled for the region indicated by the 'To' number: %s.",
+	21610: "Attempt to send to unsubscribed recipient %s.",
+	21614: "'To' number %s is not a valid mobile number.",
+}
+
+func twilioRejectionMessage(code int, to string) string {
+	if format, ok := twilioRejectionMessages[code]; ok {
+		return fmt.Sprintf(format, to)
+	}
+	return fmt.Sprintf("The request to %s was rejected.", to)
+}
+
+// twilioError writes a Twilio REST error envelope
+func twilioError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"code":      code,
+		"message":   message,
+		"more_info": fmt.Sprintf("https://www.twilio.com/docs/errors/%d", code),
+		"status":    status,
+	})
+}
+
+// twilioSID returns a random 34 character SID with the given prefix
+func twilioSID(prefix string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
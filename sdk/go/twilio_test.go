@@ -0,0 +1,70 @@
+package mockforge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTwilioMock(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	twilio, err := server.Twilio(TwilioConfig{AuthToken: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to start Twilio preset: %v", err)
+	}
+
+	callbacks := make(chan *http.Request, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		callbacks <- r
+	}))
+	defer receiver.Close()
+
+	send := func(to string) *http.Response {
+		form := url.Values{"To": {to}, "From": {"+15005550006"}, "Body": {"Your code is 123456"}, "StatusCallback": {receiver.URL}}
+		req, _ := http.NewRequest("POST", twilio.URL()+"/2010-04-01/Accounts/"+twilio.AccountSID()+"/Messages.json", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(twilio.AccountSID(), twilio.AuthToken())
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("rejects invalid numbers with 21211", func(t *testing.T) {
+		if resp := send("555-1234"); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("records messages and sends signed callbacks", func(t *testing.T) {
+		if resp := send("+14155550100"); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d", resp.StatusCode)
+		}
+
+		message, ok := twilio.LastMessageTo("+14155550100")
+		if !ok || message.Body != "Your code is 123456" {
+			t.Fatalf("Expected message in inbox, got %+v", message)
+		}
+
+		select {
+		case r := <-callbacks:
+			expected := TwilioSignature("secret", receiver.URL, r.PostForm)
+			if r.Header.Get("X-Twilio-Signature") != expected {
+				t.Errorf("Expected signature %s, got %s", expected, r.Header.Get("X-Twilio-Signature"))
+			}
+			if r.PostForm.Get("MessageSid") != message.SID {
+				t.Errorf("Expected callback for %s, got %s", message.SID, r.PostForm.Get("MessageSid"))
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected a status callback")
+		}
+	})
+}
@@ -0,0 +1,475 @@
+package mockforge
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UploadProtocol identifies a resumable upload protocol
+type UploadProtocol string
+
+const (
+	// UploadTus is the tus.io resumable upload protocol (v1.0.0)
+	UploadTus UploadProtocol = "tus"
+	// UploadGoogleResumable is the Google Cloud Storage / Drive resumable protocol
+	UploadGoogleResumable UploadProtocol = "google-resumable"
+)
+
+// UploadFault is a failure injected while receiving a chunk
+type UploadFault string
+
+const (
+	// UploadFaultServerError discards the chunk and responds 500
+	UploadFaultServerError UploadFault = "server_error"
+	// UploadFaultDropConnection discards the chunk and closes the connection
+	// without a response
+	UploadFaultDropConnection UploadFault = "drop_connection"
+	// UploadFaultPartialWrite persists only the first half of the chunk and
+	// then closes the connection, so the client must query the offset to resume
+	UploadFaultPartialWrite UploadFault = "partial_write"
+)
+
+// UploadChunk records one chunk request received for an upload
+type UploadChunk struct {
+	// ClaimedOffset is the offset the client said the chunk starts at
+	ClaimedOffset int64
+	// ServerOffset is the upload's offset when the chunk arrived
+	ServerOffset int64
+	// Size is the number of bytes the client sent
+	Size int64
+	// Accepted is the number of bytes persisted
+	Accepted int64
+	// Status is the HTTP status returned, 0 if the connection was dropped
+	Status int
+	Fault  UploadFault
+	At     time.Time
+}
+
+// Upload is the state of one resumable upload
+type Upload struct {
+	ID       string
+	Protocol UploadProtocol
+	// Length is the declared total size, -1 if not yet known
+	Length   int64
+	Offset   int64
+	Metadata map[string]string
+	Data     []byte
+	Chunks   []UploadChunk
+}
+
+// Complete reports whether every declared byte was received
+func (u Upload) Complete() bool {
+	return u.Length >= 0 && u.Offset == u.Length
+}
+
+// OffsetErrors returns the chunks the client sent at the wrong offset
+func (u Upload) OffsetErrors() []UploadChunk {
+	var mismatched []UploadChunk
+	for _, chunk := range u.Chunks {
+		if chunk.ClaimedOffset != chunk.ServerOffset {
+			mismatched = append(mismatched, chunk)
+		}
+	}
+	return mismatched
+}
+
+// UploadMock serves the tus and Google resumable upload protocols and lets
+// tests inject faults between chunks.
+//
+// tus uploads are created at URL() + "/files"; Google-style uploads are
+// started with POST URL() + "/upload?uploadType=resumable". Chunks are
+// numbered from 1 in arrival order across all uploads.
+type UploadMock struct {
+	sidecar *httpSidecar
+
+	mu      sync.Mutex
+	uploads map[string]*Upload
+	order   []string
+	chunks  int
+	faults  map[int]UploadFault
+	nextID  int
+}
+
+// ResumableUploads returns the resumable upload preset, starting it on first use
+func (m *MockServer) ResumableUploads() (*UploadMock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.uploads != nil {
+		return m.uploads, nil
+	}
+
+	uploads := &UploadMock{
+		uploads: make(map[string]*Upload),
+		faults:  make(map[int]UploadFault),
+	}
+
+	sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(uploads.serveHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start upload preset: %w", err)
+	}
+	uploads.sidecar = sidecar
+
+	m.attached = append(m.attached, sidecar)
+	m.uploads = uploads
+
+	return uploads, nil
+}
+
+// URL returns the base URL of the upload endpoints
+func (u *UploadMock) URL() string {
+	return u.sidecar.URL()
+}
+
+// TusEndpoint returns the tus creation URL
+func (u *UploadMock) TusEndpoint() string {
+	return u.sidecar.URL() + "/files"
+}
+
+// FailChunk injects fault into the n-th chunk received (1-based)
+func (u *UploadMock) FailChunk(n int, fault UploadFault) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.faults[n] = fault
+}
+
+// FailNextChunk injects fault into the next chunk received
+func (u *UploadMock) FailNextChunk(fault UploadFault) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.faults[u.chunks+1] = fault
+}
+
+// Uploads returns a snapshot of every live (not terminated) upload in
+// creation order
+func (u *UploadMock) Uploads() []Upload {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	uploads := make([]Upload, 0, len(u.order))
+	for _, id := range u.order {
+		if upload, ok := u.uploads[id]; ok {
+			uploads = append(uploads, u.snapshot(upload))
+		}
+	}
+	return uploads
+}
+
+// Upload returns a snapshot of one upload
+func (u *UploadMock) Upload(id string) (Upload, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	upload, ok := u.uploads[id]
+	if !ok {
+		return Upload{}, false
+	}
+	return u.snapshot(upload), true
+}
+
+// Reset removes all uploads and pending faults
+func (u *UploadMock) Reset() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.uploads = make(map[string]*Upload)
+	u.order = nil
+	u.chunks = 0
+	u.faults = make(map[int]UploadFault)
+}
+
+func (u *UploadMock) snapshot(upload *Upload) Upload {
+	copied := *upload
+	copied.Data = append([]byte(nil), upload.Data...)
+	copied.Chunks = append([]UploadChunk(nil), upload.Chunks...)
+	return copied
+}
+
+// create registers a new upload. Callers must hold u.mu.
+func (u *UploadMock) create(protocol UploadProtocol, length int64, metadata map[string]string) *Upload {
+	u.nextID++
+	upload := &Upload{
+		ID:       fmt.Sprintf("upload-%d", u.nextID),
+		Protocol: protocol,
+		Length:   length,
+		Metadata: metadata,
+	}
+	u.uploads[upload.ID] = upload
+	u.order = append(u.order, upload.ID)
+	return upload
+}
+
+func (u *UploadMock) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/files" || strings.HasPrefix(r.URL.Path, "/files/"):
+		u.serveTus(w, r)
+	case r.URL.Query().Get("uploadType") == "resumable" && r.Method == http.MethodPost:
+		u.startGoogle(w, r)
+	case r.URL.Query().Get("upload_id") != "":
+		u.serveGoogle(w, r, r.URL.Query().Get("upload_id"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+const tusVersion = "1.0.0"
+
+func (u *UploadMock) serveTus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Tus-Version", tusVersion)
+		w.Header().Set("Tus-Extension", "creation,termination")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	if r.URL.Path == "/files" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+			return
+		}
+
+		u.mu.Lock()
+		upload := u.create(UploadTus, length, tusMetadata(r.Header.Get("Upload-Metadata")))
+		u.mu.Unlock()
+
+		w.Header().Set("Location", "/files/"+upload.ID)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/files/")
+	u.mu.Lock()
+	upload, ok := u.uploads[id]
+	u.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead:
+		u.mu.Lock()
+		w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+		u.mu.Unlock()
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		claimed, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+			return
+		}
+		offset, status := u.receiveChunk(w, r, upload, claimed)
+		if status == 0 {
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+		w.WriteHeader(status)
+	case http.MethodDelete:
+		u.mu.Lock()
+		delete(u.uploads, id)
+		u.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// tusMetadata decodes an Upload-Metadata header: key base64value,key2 ...
+func tusMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, _ := base64.StdEncoding.DecodeString(encoded)
+		metadata[key] = string(value)
+	}
+	return metadata
+}
+
+func (u *UploadMock) startGoogle(w http.ResponseWriter, r *http.Request) {
+	length := int64(-1)
+	if v := r.Header.Get("X-Upload-Content-Length"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid X-Upload-Content-Length", http.StatusBadRequest)
+			return
+		}
+		length = parsed
+	}
+
+	metadata := map[string]string{}
+	if v := r.Header.Get("X-Upload-Content-Type"); v != "" {
+		metadata["content_type"] = v
+	}
+
+	u.mu.Lock()
+	upload := u.create(UploadGoogleResumable, length, metadata)
+	u.mu.Unlock()
+
+	w.Header().Set("Location", fmt.Sprintf("%s?upload_id=%s", r.URL.Path, upload.ID))
+	w.WriteHeader(http.StatusOK)
+}
+
+// googleContentRange matches "bytes 0-99/200", "bytes 0-99/*", and "bytes */200"
+var googleContentRange = regexp.MustCompile(`^bytes (?:(\d+)-(\d+)|\*)/(\d+|\*)$`)
+
+func (u *UploadMock) serveGoogle(w http.ResponseWriter, r *http.Request, id string) {
+	u.mu.Lock()
+	upload, ok := u.uploads[id]
+	u.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		u.mu.Lock()
+		delete(u.uploads, id)
+		u.mu.Unlock()
+		w.WriteHeader(499)
+		return
+	}
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	claimed := int64(0)
+	contentRange := r.Header.Get("Content-Range")
+	if contentRange != "" {
+		matches := googleContentRange.FindStringSubmatch(contentRange)
+		if matches == nil {
+			http.Error(w, "invalid Content-Range", http.StatusBadRequest)
+			return
+		}
+		if matches[3] != "*" {
+			total, _ := strconv.ParseInt(matches[3], 10, 64)
+			u.mu.Lock()
+			upload.Length = total
+			u.mu.Unlock()
+		}
+		if matches[1] == "" {
+			// Status query: report progress without a body
+			u.writeGoogleProgress(w, upload)
+			return
+		}
+		claimed, _ = strconv.ParseInt(matches[1], 10, 64)
+	}
+
+	_, status := u.receiveChunk(w, r, upload, claimed)
+	switch status {
+	case 0:
+		return
+	case http.StatusInternalServerError, http.StatusRequestEntityTooLarge:
+		w.WriteHeader(status)
+	default:
+		// Google reports both accepted and mismatched chunks as progress
+		u.writeGoogleProgress(w, upload)
+	}
+}
+
+func (u *UploadMock) writeGoogleProgress(w http.ResponseWriter, upload *Upload) {
+	u.mu.Lock()
+	id, offset, complete := upload.ID, upload.Offset, upload.Complete()
+	u.mu.Unlock()
+
+	if complete {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "size": strconv.FormatInt(offset, 10)})
+		return
+	}
+	if offset > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", offset-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect) // 308 Resume Incomplete
+}
+
+// receiveChunk reads a chunk body, applying any injected fault. It returns
+// the new offset and the status to respond with, or status 0 if the
+// connection was dropped.
+func (u *UploadMock) receiveChunk(w http.ResponseWriter, r *http.Request, upload *Upload, claimed int64) (int64, int) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return 0, http.StatusBadRequest
+	}
+
+	u.mu.Lock()
+	u.chunks++
+	fault := u.faults[u.chunks]
+	delete(u.faults, u.chunks)
+
+	chunk := UploadChunk{
+		ClaimedOffset: claimed,
+		ServerOffset:  upload.Offset,
+		Size:          int64(len(data)),
+		Fault:         fault,
+		At:            time.Now(),
+	}
+
+	status := http.StatusNoContent
+	switch {
+	case claimed != upload.Offset:
+		status = http.StatusConflict
+	case upload.Length >= 0 && upload.Offset+int64(len(data)) > upload.Length:
+		status = http.StatusRequestEntityTooLarge
+	case fault == UploadFaultServerError:
+		status = http.StatusInternalServerError
+	case fault == UploadFaultDropConnection:
+		status = 0
+	case fault == UploadFaultPartialWrite:
+		chunk.Accepted = int64(len(data) / 2)
+		status = 0
+	default:
+		chunk.Accepted = int64(len(data))
+	}
+
+	upload.Data = append(upload.Data, data[:chunk.Accepted]...)
+	upload.Offset += chunk.Accepted
+	chunk.Status = status
+	upload.Chunks = append(upload.Chunks, chunk)
+	offset := upload.Offset
+	u.mu.Unlock()
+
+	if status == 0 {
+		dropConnection(w)
+	}
+	return offset, status
+}
+
+// dropConnection closes the client connection without writing a response
+func dropConnection(w http.ResponseWriter) {
+	if hijacker, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	// Aborting the handler makes net/http close the connection
+	panic(http.ErrAbortHandler)
+}
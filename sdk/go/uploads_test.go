@@ -0,0 +1,76 @@
+package mockforge
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestUploadMockTusFaults(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	uploads, err := server.ResumableUploads()
+	if err != nil {
+		t.Fatalf("Failed to start upload preset: %v", err)
+	}
+
+	tus := func(method, url string, headers map[string]string, body []byte) (*http.Response, error) {
+		req, _ := http.NewRequest(method, url, bytes.NewReader(body))
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	resp, err := tus("POST", uploads.TusEndpoint(), map[string]string{"Upload-Length": "10"}, nil)
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	location := uploads.URL() + resp.Header.Get("Location")
+
+	patch := func(offset int, data string) (*http.Response, error) {
+		return tus("PATCH", location, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": strconv.Itoa(offset),
+		}, []byte(data))
+	}
+
+	uploads.FailChunk(2, UploadFaultPartialWrite)
+
+	if resp, err := patch(0, "abcd"); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected first chunk to be accepted, got %v", err)
+	}
+	if _, err := patch(4, "efgh"); err == nil {
+		t.Fatal("Expected the partial write fault to drop the connection")
+	}
+
+	resp, err = tus("HEAD", location, nil, nil)
+	if err != nil {
+		t.Fatalf("HEAD failed: %v", err)
+	}
+	if offset := resp.Header.Get("Upload-Offset"); offset != "6" {
+		t.Fatalf("Expected offset 6 after partial write, got %s", offset)
+	}
+
+	if resp, _ := patch(4, "efghij"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a stale offset, got %d", resp.StatusCode)
+	}
+	if resp, _ := patch(6, "ghij"); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected resumed chunk to be accepted, got %d", resp.StatusCode)
+	}
+
+	upload := uploads.Uploads()[0]
+	if !upload.Complete() || string(upload.Data) != "abcdefghij" {
+		t.Errorf("Expected complete upload abcdefghij, got %q", upload.Data)
+	}
+	if errs := upload.OffsetErrors(); len(errs) != 1 || errs[0].ClaimedOffset != 4 {
+		t.Errorf("Expected one offset error at 4, got %+v", errs)
+	}
+}
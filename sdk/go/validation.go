@@ -0,0 +1,59 @@
+package mockforge
+
+import (
+	"encoding/json"
+)
+
+// ValidationMode controls what the server does with requests that do not
+// conform to the OpenAPI spec or to a stub's request schema
+type ValidationMode string
+
+const (
+	// ValidationOff skips request validation
+	ValidationOff ValidationMode = "off"
+	// ValidationWarn logs validation failures but serves the response
+	ValidationWarn ValidationMode = "warn"
+	// ValidationEnforce answers non-conforming requests with 400 and a
+	// structured validation error
+	ValidationEnforce ValidationMode = "enforce"
+)
+
+// validate checks the mode is known; the empty mode keeps the server default
+func (mode ValidationMode) validate() error {
+	switch mode {
+	case "", ValidationOff, ValidationWarn, ValidationEnforce:
+		return nil
+	}
+	return NewInvalidConfigError("unknown validation mode", map[string]interface{}{"mode": string(mode)})
+}
+
+// env returns the environment variable selecting the mode for the CLI
+func (mode ValidationMode) env() []string {
+	if mode == "" {
+		return nil
+	}
+	return []string{"MOCKFORGE_REQUEST_VALIDATION=" + string(mode)}
+}
+
+// ValidateRequestAgainstSchema validates request bodies against a JSON
+// Schema. Depending on the server's ValidationMode, requests that do not
+// conform are rejected with 400, logged, or served normally.
+func (b *StubBuilder) ValidateRequestAgainstSchema(schemaJSON string) *StubBuilder {
+	b.schema = json.RawMessage(schemaJSON)
+	return b
+}
+
+// validateRequestSchema checks a stub's request schema is a JSON object
+func validateRequestSchema(stub *ResponseStub) error {
+	if len(stub.RequestSchema) == 0 {
+		return nil
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(stub.RequestSchema, &schema); err != nil {
+		return NewInvalidConfigError("request schema must be a JSON object", map[string]interface{}{
+			"path":  stub.Path,
+			"error": err.Error(),
+		})
+	}
+	return nil
+}
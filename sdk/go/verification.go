@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // VerificationRequest represents a pattern for matching requests during verification
@@ -18,10 +19,29 @@ type VerificationRequest struct {
 	QueryParams map[string]string `json:"query_params,omitempty"`
 	// Headers to match (all must be present and match). Case-insensitive header names. If empty, headers are not checked.
 	Headers map[string]string `json:"headers,omitempty"`
+	// Headers whose values must match these regular expressions. Case-insensitive header names. If empty, not checked.
+	HeaderPatterns map[string]string `json:"header_patterns,omitempty"`
+	// Headers that must not have been sent, e.g. to assert credentials were not forwarded. Case-insensitive names.
+	HeadersAbsent []string `json:"headers_absent,omitempty"`
+	// Headers that must have been sent, with any value. Case-insensitive names.
+	HeadersPresent []string `json:"headers_present,omitempty"`
 	// Request body pattern to match. Supports exact match or regex. If empty, body is not checked.
 	BodyPattern string `json:"body_pattern,omitempty"`
+	// JSONPath expressions (e.g. "$.items[0].sku") and the values the JSON body must have there. If empty, not checked.
+	BodyJSONPath map[string]interface{} `json:"body_json_path,omitempty"`
+	// JSON Schema the JSON body must validate against. If empty, not checked.
+	BodyJSONSchema json.RawMessage `json:"body_json_schema,omitempty"`
+	// Only match requests logged at or after this time. If nil, not checked.
+	Since *time.Time `json:"since,omitempty"`
+	// Only match requests logged before this time. If nil, not checked.
+	Until *time.Time `json:"until,omitempty"`
+	// Only match requests logged after a mark from MarkJournal. Unlike Since, it does not depend on the client's clock.
+	SinceMark JournalMark `json:"since_mark,omitempty"`
 }
 
+// JournalMark is an opaque token for a point in a server's request journal
+type JournalMark string
+
 // VerificationCount represents a count assertion for verification
 type VerificationCount struct {
 	Type  string `json:"type"`
@@ -49,6 +69,70 @@ func AtLeastOnce() VerificationCount {
 	return VerificationCount{Type: "at_least_once"}
 }
 
+// Satisfied reports whether n observed occurrences satisfy the count assertion.
+// It is used for checks the SDK evaluates locally rather than on the server.
+func (c VerificationCount) Satisfied(n int) bool {
+	value := 0
+	if c.Value != nil {
+		value = *c.Value
+	}
+
+	switch c.Type {
+	case "exactly":
+		return n == value
+	case "at_least":
+		return n >= value
+	case "at_most":
+		return n <= value
+	case "never":
+		return n == 0
+	case "at_least_once":
+		return n >= 1
+	default:
+		return false
+	}
+}
+
+// tooFew reports whether n occurrences fall short of the count assertion
+func (c VerificationCount) tooFew(n int) bool {
+	value := 0
+	if c.Value != nil {
+		value = *c.Value
+	}
+
+	switch c.Type {
+	case "exactly", "at_least":
+		return n < value
+	case "at_least_once":
+		return n < 1
+	default:
+		return false
+	}
+}
+
+// String describes the count assertion, e.g. "at least 2"
+func (c VerificationCount) String() string {
+	value := 0
+	if c.Value != nil {
+		value = *c.Value
+	}
+
+	switch c.Type {
+	case "exactly":
+		return fmt.Sprintf("exactly %d", value)
+	case "at_least":
+		return fmt.Sprintf("at least %d", value)
+	case "at_most":
+		return fmt.Sprintf("at most %d", value)
+	case "never":
+		return "never"
+	case "at_least_once":
+		return "at least once"
+	default:
+		return c.Type
+	}
+}
+
 // VerificationResult represents the result of a verification operation
 type VerificationResult struct {
 	// Whether the verification passed
@@ -61,10 +145,29 @@ type VerificationResult struct {
 	Matches []map[string]interface{} `json:"matches"`
 	// Error message if verification failed
 	ErrorMessage *string `json:"error_message,omitempty"`
+	// Index of the first failed step, for sequence verifications
+	FailedStep *int `json:"failed_step,omitempty"`
+	// Closest non-matching requests, when too few requests matched
+	NearMisses []NearMiss `json:"near_misses,omitempty"`
 }
 
-// Verify verifies requests against a pattern and count assertion
+// Verify verifies requests against a pattern and count assertion. When too
+// few requests match, the result lists the closest non-matching requests
+// and why they did not match.
 func (m *MockServer) Verify(pattern VerificationRequest, expected VerificationCount) (*VerificationResult, error) {
+	result, err := m.verify(pattern, expected)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.addNearMisses(result, pattern, expected); err != nil {
+		return nil, err
+	}
+	m.recordVerification(fmt.Sprintf("Verify %s %s", describePattern(pattern), expected), result)
+	return result, nil
+}
+
+// verify runs a verification on the server
+func (m *MockServer) verify(pattern VerificationRequest, expected VerificationCount) (*VerificationResult, error) {
 	requestBody := map[string]interface{}{
 		"pattern":  pattern,
 		"expected": expected,
@@ -97,6 +200,32 @@ func (m *MockServer) Verify(pattern VerificationRequest, expected VerificationCo
 	return &result, nil
 }
 
+// VerifyEventually polls Verify every interval until the count assertion
+// holds or timeout elapses, for requests the system under test sends
+// asynchronously. It returns the last result, which is unmatched on
+// timeout; interval defaults to 100ms.
+func (m *MockServer) VerifyEventually(pattern VerificationRequest, expected VerificationCount, timeout, interval time.Duration) (*VerificationResult, error) {
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		result, err := m.verify(pattern, expected)
+		if err != nil {
+			return nil, err
+		}
+		if result.Matched || !time.Now().Add(interval).Before(deadline) {
+			if err := m.addNearMisses(result, pattern, expected); err != nil {
+				return nil, err
+			}
+			m.recordVerification(fmt.Sprintf("VerifyEventually %s %s", describePattern(pattern), expected), result)
+			return result, nil
+		}
+		time.Sleep(interval)
+	}
+}
+
 // VerifyNever verifies that a request was never made
 func (m *MockServer) VerifyNever(pattern VerificationRequest) (*VerificationResult, error) {
 	jsonData, err := json.Marshal(pattern)
@@ -123,6 +252,7 @@ func (m *MockServer) VerifyNever(pattern VerificationRequest) (*VerificationResu
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	m.recordVerification(fmt.Sprintf("VerifyNever %s", describePattern(pattern)), &result)
 	return &result, nil
 }
 
@@ -157,73 +287,84 @@ func (m *MockServer) VerifyAtLeast(pattern VerificationRequest, min int) (*Verif
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	m.recordVerification(fmt.Sprintf("VerifyAtLeast %s %d", describePattern(pattern), min), &result)
 	return &result, nil
 }
 
-// VerifySequence verifies that requests occurred in a specific sequence
-func (m *MockServer) VerifySequence(patterns []VerificationRequest) (*VerificationResult, error) {
+// CountRequests gets the count of matching requests
+func (m *MockServer) CountRequests(pattern VerificationRequest) (int, error) {
 	requestBody := map[string]interface{}{
-		"patterns": patterns,
+		"pattern": pattern,
 	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	resp, err := http.Post(
-		fmt.Sprintf("%s/api/verification/sequence", m.URL()),
+		fmt.Sprintf("%s/api/verification/count", m.URL()),
 		"application/json",
 		bytes.NewBuffer(jsonData),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("verification request failed: %w", err)
+		return 0, fmt.Errorf("verification request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusExpectationFailed {
-		return nil, fmt.Errorf("verification request failed with status: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("verification request failed with status: %d", resp.StatusCode)
 	}
 
-	var result VerificationResult
+	var result struct {
+		Count int `json:"count"`
+	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return 0, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &result, nil
+	return result.Count, nil
 }
 
-// CountRequests gets the count of matching requests
-func (m *MockServer) CountRequests(pattern VerificationRequest) (int, error) {
-	requestBody := map[string]interface{}{
-		"pattern": pattern,
+// ResetRequestJournal discards the recorded requests without touching stubs,
+// so each test can verify only its own traffic
+func (m *MockServer) ResetRequestJournal() error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/verification/journal", m.URL()), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
 	}
 
-	jsonData, err := json.Marshal(requestBody)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("journal reset request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	resp, err := http.Post(
-		fmt.Sprintf("%s/api/verification/count", m.URL()),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("journal reset request failed with status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MarkJournal marks the current point of the request journal, so later
+// verifications can set SinceMark to only consider requests made after it,
+// e.g. after triggering an action on a shared server
+func (m *MockServer) MarkJournal() (JournalMark, error) {
+	resp, err := http.Post(fmt.Sprintf("%s/api/verification/journal/mark", m.URL()), "application/json", nil)
 	if err != nil {
-		return 0, fmt.Errorf("verification request failed: %w", err)
+		return "", fmt.Errorf("journal mark request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("verification request failed with status: %d", resp.StatusCode)
+		return "", fmt.Errorf("journal mark request failed with status: %d", resp.StatusCode)
 	}
 
-	var result struct {
-		Count int `json:"count"`
+	var body struct {
+		Mark JournalMark `json:"mark"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	return result.Count, nil
+	return body.Mark, nil
 }
@@ -0,0 +1,100 @@
+package mockforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVerifyEventually(t *testing.T) {
+	var polls int32
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The request under verification arrives on the third poll
+		count := 0
+		if atomic.AddInt32(&polls, 1) >= 3 {
+			count = 1
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"matched": count == 1, "count": count})
+	}))
+	pattern := VerificationRequest{Method: "POST", Path: "/callbacks"}
+
+	result, err := server.VerifyEventually(pattern, Exactly(1), time.Second, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if n := atomic.LoadInt32(&polls); !result.Matched || n != 3 {
+		t.Errorf("Expected a match on the third poll, got matched=%v after %d polls", result.Matched, n)
+	}
+
+	atomic.StoreInt32(&polls, -100)
+	start := time.Now()
+	result, err = server.VerifyEventually(pattern, Exactly(1), 50*time.Millisecond, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Matched {
+		t.Error("Expected the last result to be unmatched after the timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected polling to stop at the timeout, took %v", elapsed)
+	}
+}
+
+func TestResetRequestJournal(t *testing.T) {
+	var method, path string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	if err := server.ResetRequestJournal(); err != nil {
+		t.Fatalf("Failed to reset journal: %v", err)
+	}
+	if method != http.MethodDelete || path != "/api/verification/journal" {
+		t.Errorf("Expected DELETE /api/verification/journal, got %s %s", method, path)
+	}
+}
+
+func TestMarkJournal(t *testing.T) {
+	const mark = "2024-01-01T00:00:01.5Z"
+	var sent map[string]interface{}
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/verification/journal/mark" {
+			json.NewEncoder(w).Encode(map[string]string{"mark": mark})
+			return
+		}
+		var body struct {
+			Pattern map[string]interface{} `json:"pattern"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := body.Pattern["since_mark"]; ok {
+			sent = body.Pattern
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched": false,
+			"count":   0,
+			"matches": []map[string]interface{}{
+				{"id": "1", "timestamp": "2024-01-01T00:00:01Z", "method": "POST", "path": "/orders"},
+			},
+		})
+	}))
+
+	marked, err := server.MarkJournal()
+	if err != nil || marked != mark {
+		t.Fatalf("Expected mark %q, got %q (%v)", mark, marked, err)
+	}
+
+	until := time.Date(2024, 1, 1, 0, 0, 2, 0, time.UTC)
+	result, err := server.Verify(VerificationRequest{Path: "/orders", SinceMark: marked, Until: &until}, AtLeastOnce())
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if sent["since_mark"] != mark || sent["until"] != "2024-01-01T00:00:02Z" {
+		t.Errorf("Expected the window to be sent, got %v", sent)
+	}
+	if len(result.NearMisses) != 1 || result.NearMisses[0].Differences[0] != "logged before the journal mark" {
+		t.Errorf("Expected the earlier request as a near miss, got %+v", result.NearMisses)
+	}
+}
@@ -0,0 +1,158 @@
+package mockforge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CapturedRequest is a request received by a WebhookReceiver
+type CapturedRequest struct {
+	Method     string
+	Path       string
+	Query      url.Values
+	Headers    http.Header
+	Body       []byte
+	ReceivedAt time.Time
+}
+
+// WebhookMatcher selects captured requests. Empty fields match everything.
+type WebhookMatcher struct {
+	Method string
+	// Path matches requests to exactly this path
+	Path string
+	// Headers must all be present with these values
+	Headers map[string]string
+	// BodyContains matches requests whose body contains this text
+	BodyContains string
+}
+
+// matches reports whether request passes the matcher
+func (w WebhookMatcher) matches(request CapturedRequest) bool {
+	if w.Method != "" && !strings.EqualFold(request.Method, w.Method) {
+		return false
+	}
+	if w.Path != "" && request.Path != w.Path {
+		return false
+	}
+	for name, value := range w.Headers {
+		if request.Headers.Get(name) != value {
+			return false
+		}
+	}
+	if w.BodyContains != "" && !bytes.Contains(request.Body, []byte(w.BodyContains)) {
+		return false
+	}
+	return true
+}
+
+// WebhookReceiver accepts the webhooks the system under test sends,
+// answering each with 200 OK, so tests can wait for a call instead of
+// sleeping. It is served by an in-process listener; configure the
+// application to call URL().
+type WebhookReceiver struct {
+	sidecar *httpSidecar
+	path    string
+
+	mu       sync.Mutex
+	received []CapturedRequest
+	arrived  chan struct{} // Closed and replaced on every request
+}
+
+// WebhookReceiver returns the receiver for requests to path and below,
+// starting it on first use
+func (m *MockServer) WebhookReceiver(path string) (*WebhookReceiver, error) {
+	path = "/" + strings.Trim(path, "/")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if receiver, ok := m.webhooks[path]; ok {
+		return receiver, nil
+	}
+
+	receiver := &WebhookReceiver{path: path, arrived: make(chan struct{})}
+
+	sidecar, err := newHTTPSidecar(m.host, http.HandlerFunc(receiver.serveHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start webhook receiver %s: %w", path, err)
+	}
+	receiver.sidecar = sidecar
+
+	if m.webhooks == nil {
+		m.webhooks = make(map[string]*WebhookReceiver)
+	}
+	m.attached = append(m.attached, sidecar)
+	m.webhooks[path] = receiver
+
+	return receiver, nil
+}
+
+// URL returns the URL webhooks should be sent to
+func (r *WebhookReceiver) URL() string {
+	return strings.TrimSuffix(r.sidecar.URL()+r.path, "/")
+}
+
+// Received returns the requests received so far, oldest first
+func (r *WebhookReceiver) Received() []CapturedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CapturedRequest(nil), r.received...)
+}
+
+// WaitForRequest returns the first request matching matcher, waiting for
+// one to arrive until ctx is done. Requests received before the call count.
+func (r *WebhookReceiver) WaitForRequest(ctx context.Context, matcher WebhookMatcher) (CapturedRequest, error) {
+	for {
+		r.mu.Lock()
+		for _, request := range r.received {
+			if matcher.matches(request) {
+				r.mu.Unlock()
+				return request, nil
+			}
+		}
+		arrived, n := r.arrived, len(r.received)
+		r.mu.Unlock()
+
+		select {
+		case <-arrived:
+		case <-ctx.Done():
+			return CapturedRequest{}, fmt.Errorf("no matching webhook at %s after %d request(s): %w", r.path, n, ctx.Err())
+		}
+	}
+}
+
+func (r *WebhookReceiver) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	rest, ok := strings.CutPrefix(req.URL.Path, r.path)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && r.path != "/") {
+		http.NotFound(w, req)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	r.received = append(r.received, CapturedRequest{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      req.URL.Query(),
+		Headers:    req.Header.Clone(),
+		Body:       body,
+		ReceivedAt: time.Now(),
+	})
+	close(r.arrived)
+	r.arrived = make(chan struct{})
+	r.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,57 @@
+package mockforge
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookReceiver(t *testing.T) {
+	server := NewMockServer(MockServerConfig{})
+	defer server.Stop()
+
+	receiver, err := server.WebhookReceiver("/hooks/payments")
+	if err != nil {
+		t.Fatalf("Failed to start webhook receiver: %v", err)
+	}
+
+	send := func(path, body string) {
+		request, _ := http.NewRequest(http.MethodPost, receiver.URL()+path, strings.NewReader(body))
+		request.Header.Set("X-Event", "payment")
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Errorf("Failed to send webhook: %v", err)
+			return
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", response.StatusCode)
+		}
+	}
+	send("", `{"status":"pending"}`)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		send("/v2", `{"status":"succeeded"}`)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request, err := receiver.WaitForRequest(ctx, WebhookMatcher{Method: "POST", Headers: map[string]string{"X-Event": "payment"}, BodyContains: "succeeded"})
+	if err != nil {
+		t.Fatalf("Failed to wait for webhook: %v", err)
+	}
+	if request.Path != "/hooks/payments/v2" {
+		t.Errorf("Expected path /hooks/payments/v2, got %q", request.Path)
+	}
+	if got := len(receiver.Received()); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if _, err := receiver.WaitForRequest(short, WebhookMatcher{BodyContains: "refunded"}); err == nil {
+		t.Error("Expected error when no webhook matches")
+	}
+}
@@ -0,0 +1,204 @@
+package mockforge
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WSScript is a scripted WebSocket conversation, run step by step for each
+// client that connects
+type WSScript []WSStep
+
+// WSStep is one step of a WSScript: waiting for an inbound message,
+// sending a text or binary frame, or closing the connection. Delay is how
+// long the server waits before sending or closing.
+type WSStep struct {
+	// Expect is text an inbound text message must contain before the script
+	// continues
+	Expect string
+	// ExpectBinary is bytes an inbound binary message must contain before
+	// the script continues
+	ExpectBinary []byte
+	Text         string
+	Binary       []byte
+	Close        *WSClose
+	Delay        time.Duration
+}
+
+// WSClose is the close frame ending a scripted conversation
+type WSClose struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// WSExpect waits for an inbound text message containing text
+func WSExpect(text string) WSStep {
+	return WSStep{Expect: text}
+}
+
+// WSExpectBinary waits for an inbound binary message containing data
+func WSExpectBinary(data []byte) WSStep {
+	return WSStep{ExpectBinary: data}
+}
+
+// WSSendText sends a text frame after delay
+func WSSendText(text string, delay time.Duration) WSStep {
+	return WSStep{Text: text, Delay: delay}
+}
+
+// WSSendBinary sends a binary frame after delay
+func WSSendBinary(data []byte, delay time.Duration) WSStep {
+	return WSStep{Binary: data, Delay: delay}
+}
+
+// WSCloseWith closes the connection with code and reason after delay
+func WSCloseWith(code int, reason string, delay time.Duration) WSStep {
+	return WSStep{Close: &WSClose{Code: code, Reason: reason}, Delay: delay}
+}
+
+// validate checks the step is a single action with valid arguments
+func (s WSStep) validate(index int) error {
+	details := map[string]interface{}{"step": index}
+	actions := 0
+	for _, set := range []bool{s.Expect != "", s.ExpectBinary != nil, s.Text != "", s.Binary != nil, s.Close != nil} {
+		if set {
+			actions++
+		}
+	}
+	if actions > 1 {
+		return NewInvalidConfigError("WebSocket step must do exactly one thing", details)
+	}
+	if s.Delay < 0 {
+		return NewInvalidConfigError("WebSocket step delay must not be negative", details)
+	}
+	if s.Close != nil && !validCloseCode(s.Close.Code) {
+		details["code"] = s.Close.Code
+		return NewInvalidConfigError("WebSocket close code must be 1000-1003, 1007-1014, or 3000-4999", details)
+	}
+	if s.Close != nil && len(s.Close.Reason) > 123 {
+		return NewInvalidConfigError("WebSocket close reason must be at most 123 bytes", details)
+	}
+	return nil
+}
+
+// validCloseCode reports whether a server may send code in a close frame;
+// 1004-1006 and 1015 are reserved for signaling its absence
+func validCloseCode(code int) bool {
+	return (code >= 1000 && code <= 1003) || (code >= 1007 && code <= 1014) || (code >= 3000 && code <= 4999)
+}
+
+// mockConfig converts the step to the server's replay entry format. Binary
+// payloads marshal as base64, the format the server reads.
+func (s WSStep) mockConfig() map[string]interface{} {
+	entry := map[string]interface{}{}
+	switch {
+	case s.Expect != "":
+		entry["dir"] = "in"
+		entry["waitFor"] = s.Expect
+	case s.ExpectBinary != nil:
+		entry["dir"] = "in"
+		entry["waitForBinary"] = s.ExpectBinary
+	case s.Close != nil:
+		entry["dir"] = "out"
+		entry["close"] = s.Close
+	case s.Binary != nil:
+		entry["dir"] = "out"
+		entry["binary"] = s.Binary
+	default:
+		entry["dir"] = "out"
+		entry["text"] = s.Text
+	}
+	if s.Delay > 0 {
+		entry["delay_ms"] = s.Delay.Milliseconds()
+	}
+	return entry
+}
+
+// wsScript is a script registered with StubWebSocket
+type wsScript struct {
+	Path    string                   `json:"path"`
+	Entries []map[string]interface{} `json:"entries"`
+}
+
+// StubWebSocket runs script for each client of the server's WebSocket
+// endpoint at path, "/ws" or below it, so clients can be tested against a
+// deterministic conversation. Without a close step the connection stays
+// open once the script ends. Each path runs one script; paths without one
+// fall back to the config file's websocket.replay_file, or echo.
+//
+// Scripts registered before Start are loaded when the server starts;
+// later ones apply to the connections opened after registration.
+//
+//	server.StubWebSocket("/ws/orders", mockforge.WSScript{
+//	    mockforge.WSExpect(`"type":"subscribe"`),
+//	    mockforge.WSSendText(`{"type":"subscribed"}`, 0),
+//	    mockforge.WSCloseWith(1001, "going away", time.Second),
+//	})
+func (m *MockServer) StubWebSocket(path string, script WSScript) error {
+	if path != "/ws" && !strings.HasPrefix(path, "/ws/") {
+		return NewInvalidConfigError("WebSocket script path must be /ws or start with /ws/", map[string]interface{}{"path": path})
+	}
+	entries, err := webSocketReplay(script)
+	if err != nil {
+		return err
+	}
+	registered := wsScript{Path: path, Entries: entries}
+
+	m.mu.Lock()
+	for _, existing := range m.wsScripts {
+		if existing.Path == path {
+			m.mu.Unlock()
+			return NewInvalidConfigError("a WebSocket script is already registered for this path", map[string]interface{}{"path": path})
+		}
+	}
+	m.mu.Unlock()
+
+	if m.IsRunning() {
+		if err := m.registerWebSocketScript(registered); err != nil {
+			return err
+		}
+	}
+	m.mu.Lock()
+	m.wsScripts = append(m.wsScripts, registered)
+	m.mu.Unlock()
+	return nil
+}
+
+// registerWebSocketScript sends a script to the running server
+func (m *MockServer) registerWebSocketScript(script wsScript) error {
+	return m.adminJSON("register websocket script", http.MethodPost, "/__mockforge/api/ws/scripts", script, nil)
+}
+
+// registerWebSocketScripts sends the scripts registered before Start to
+// the server
+func (m *MockServer) registerWebSocketScripts() error {
+	m.mu.Lock()
+	scripts := append([]wsScript(nil), m.wsScripts...)
+	m.mu.Unlock()
+	for _, script := range scripts {
+		if err := m.registerWebSocketScript(script); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webSocketReplay builds the server's replay entries for StubWebSocket
+func webSocketReplay(script WSScript) ([]map[string]interface{}, error) {
+	if len(script) == 0 {
+		return nil, NewInvalidConfigError("WebSocket script must have at least one step", nil)
+	}
+
+	entries := make([]map[string]interface{}, len(script))
+	for i, step := range script {
+		if err := step.validate(i); err != nil {
+			return nil, err
+		}
+		if step.Close != nil && i != len(script)-1 {
+			return nil, NewInvalidConfigError("WebSocket close must be the last step", map[string]interface{}{"step": i})
+		}
+		entries[i] = step.mockConfig()
+	}
+	return entries, nil
+}
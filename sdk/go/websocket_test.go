@@ -0,0 +1,87 @@
+package mockforge
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStubWebSocket(t *testing.T) {
+	var bodies []string
+	server := newAdminTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/__mockforge/api/ws/scripts" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	}))
+
+	err := server.StubWebSocket("/ws/orders", WSScript{
+		WSExpect(`"type":"subscribe"`),
+		WSSendText(`{"type":"subscribed"}`, 100*time.Millisecond),
+		WSCloseWith(1001, "going away", time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub WebSocket: %v", err)
+	}
+	err = server.StubWebSocket("/ws/frames", WSScript{
+		WSExpectBinary([]byte{0x01, 0x02}),
+		WSSendBinary([]byte{0x06}, 0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to stub binary WebSocket: %v", err)
+	}
+	if err := server.StubWebSocket("/ws/orders", WSScript{WSSendText("hi", 0)}); err == nil {
+		t.Error("Expected error for a second script on a path")
+	}
+	if err := server.StubWebSocket("/orders", WSScript{WSSendText("hi", 0)}); err == nil {
+		t.Error("Expected error for a path outside /ws")
+	}
+	if len(bodies) != 0 {
+		t.Fatalf("Expected scripts held until Start, got %v", bodies)
+	}
+
+	// Start sends the scripts registered before it
+	if err := server.registerWebSocketScripts(); err != nil {
+		t.Fatalf("Failed to register scripts: %v", err)
+	}
+	expected := []string{
+		`{"path":"/ws/orders","entries":[` +
+			`{"dir":"in","waitFor":"\"type\":\"subscribe\""},` +
+			`{"delay_ms":100,"dir":"out","text":"{\"type\":\"subscribed\"}"},` +
+			`{"close":{"code":1001,"reason":"going away"},"delay_ms":1000,"dir":"out"}]}`,
+		`{"path":"/ws/frames","entries":[` +
+			`{"dir":"in","waitForBinary":"AQI="},` +
+			`{"binary":"Bg==","dir":"out"}]}`,
+	}
+	if !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %v, got %v", expected, bodies)
+	}
+
+	tests := []struct {
+		name   string
+		script WSScript
+	}{
+		{"empty script", nil},
+		{"close not last", WSScript{WSCloseWith(1000, "", 0), WSSendText("hi", 0)}},
+		{"reserved close code", WSScript{WSCloseWith(1006, "", 0)}},
+		{"long reason", WSScript{WSCloseWith(1000, strings.Repeat("x", 124), 0)}},
+		{"two actions", WSScript{{Expect: "hi", Text: "hello"}}},
+		{"text and binary", WSScript{{Text: "hi", Binary: []byte{0x01}}}},
+		{"two expectations", WSScript{{Expect: "hi", ExpectBinary: []byte{0x01}}}},
+		{"negative delay", WSScript{WSSendText("hi", -time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := webSocketReplay(tt.script); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
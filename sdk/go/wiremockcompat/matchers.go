@@ -0,0 +1,191 @@
+package wiremockcompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// URLMatchingStrategy is how a URLMatcher compares request URLs
+type URLMatchingStrategy string
+
+const (
+	URLEqualToRule      URLMatchingStrategy = "url"
+	URLPathEqualToRule  URLMatchingStrategy = "urlPath"
+	URLPathMatchingRule URLMatchingStrategy = "urlPathPattern"
+	URLMatchingRule     URLMatchingStrategy = "urlPattern"
+)
+
+// URLMatcher matches the URL of a request
+type URLMatcher struct {
+	strategy URLMatchingStrategy
+	value    string
+}
+
+// URLEqualTo matches the path and query string exactly
+func URLEqualTo(url string) URLMatcher {
+	return URLMatcher{strategy: URLEqualToRule, value: url}
+}
+
+// URLPathEqualTo matches the path exactly, with any query string
+func URLPathEqualTo(path string) URLMatcher {
+	return URLMatcher{strategy: URLPathEqualToRule, value: path}
+}
+
+// URLPathMatching matches the whole path against a regular expression
+func URLPathMatching(pattern string) URLMatcher {
+	return URLMatcher{strategy: URLPathMatchingRule, value: pattern}
+}
+
+// URLMatching matches the whole URL against a regular expression. MockForge
+// matches the expression against the path only.
+func URLMatching(pattern string) URLMatcher {
+	return URLMatcher{strategy: URLMatchingRule, value: pattern}
+}
+
+// Strategy returns how the matcher compares URLs
+func (m URLMatcher) Strategy() URLMatchingStrategy {
+	return m.strategy
+}
+
+// Value returns the URL, path, or pattern the matcher compares with
+func (m URLMatcher) Value() string {
+	return m.value
+}
+
+// stubPath returns the MockForge stub path and exact query parameters
+func (m URLMatcher) stubPath() (string, map[string]string, error) {
+	switch m.strategy {
+	case URLEqualToRule:
+		path, rawQuery, _ := strings.Cut(m.value, "?")
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return "", nil, mockforge.NewInvalidConfigError(fmt.Sprintf("invalid query in URL %q: %v", m.value, err), nil)
+		}
+		params := make(map[string]string, len(query))
+		for name, values := range query {
+			params[name] = values[0]
+		}
+		return path, params, nil
+	case URLPathEqualToRule:
+		return m.value, nil, nil
+	case URLPathMatchingRule, URLMatchingRule:
+		return mockforge.PathRegex(anchored(m.value)), nil, nil
+	}
+	return "", nil, mockforge.NewInvalidConfigError("a URL matcher is required", nil)
+}
+
+// verificationPath returns the path pattern of a journal query, which the
+// server compares literally or as a regular expression
+func (m URLMatcher) verificationPath() (string, map[string]string, error) {
+	if m.strategy == URLPathMatchingRule || m.strategy == URLMatchingRule {
+		return anchored(m.value), nil, nil
+	}
+	return m.stubPath()
+}
+
+// MatcherStrategy is how a StringValueMatcher compares values
+type MatcherStrategy string
+
+const (
+	ParamEqualTo           MatcherStrategy = "equalTo"
+	ParamEqualToIgnoreCase MatcherStrategy = "equalToIgnoreCase"
+	ParamMatches           MatcherStrategy = "matches"
+	ParamDoesNotMatch      MatcherStrategy = "doesNotMatch"
+	ParamContains          MatcherStrategy = "contains"
+	ParamEqualToJson       MatcherStrategy = "equalToJson"
+	ParamAbsent            MatcherStrategy = "absent"
+)
+
+// StringValueMatcher matches a header, query parameter, cookie, or body
+type StringValueMatcher struct {
+	strategy MatcherStrategy
+	value    string
+}
+
+// EqualTo matches the exact value
+func EqualTo(value string) StringValueMatcher {
+	return StringValueMatcher{strategy: ParamEqualTo, value: value}
+}
+
+// EqualToIgnoreCase matches the value regardless of case
+func EqualToIgnoreCase(value string) StringValueMatcher {
+	return StringValueMatcher{strategy: ParamEqualToIgnoreCase, value: value}
+}
+
+// Matching matches a regular expression against the whole value
+func Matching(pattern string) StringValueMatcher {
+	return StringValueMatcher{strategy: ParamMatches, value: pattern}
+}
+
+// NotMatching matches values the regular expression does not match. MockForge
+// has no negated matching, so StubFor rejects stubs that use it.
+func NotMatching(pattern string) StringValueMatcher {
+	return StringValueMatcher{strategy: ParamDoesNotMatch, value: pattern}
+}
+
+// Contains matches values containing the substring
+func Contains(value string) StringValueMatcher {
+	return StringValueMatcher{strategy: ParamContains, value: value}
+}
+
+// EqualToJson matches a body that is JSON equal to value
+func EqualToJson(value string) StringValueMatcher {
+	return StringValueMatcher{strategy: ParamEqualToJson, value: value}
+}
+
+// Absent matches a header that is not sent
+func Absent() StringValueMatcher {
+	return StringValueMatcher{strategy: ParamAbsent}
+}
+
+// Strategy returns how the matcher compares values
+func (m StringValueMatcher) Strategy() MatcherStrategy {
+	return m.strategy
+}
+
+// Value returns the value or pattern the matcher compares with
+func (m StringValueMatcher) Value() string {
+	return m.value
+}
+
+// pattern returns the matcher as a regular expression
+func (m StringValueMatcher) pattern() (string, error) {
+	switch m.strategy {
+	case ParamEqualTo:
+		return "^" + regexp.QuoteMeta(m.value) + "$", nil
+	case ParamEqualToIgnoreCase:
+		return "(?i)^" + regexp.QuoteMeta(m.value) + "$", nil
+	case ParamMatches:
+		return anchored(m.value), nil
+	case ParamContains:
+		return regexp.QuoteMeta(m.value), nil
+	}
+	return "", m.unsupported()
+}
+
+// json decodes the value of an EqualToJson matcher
+func (m StringValueMatcher) json() (interface{}, error) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(m.value), &decoded); err != nil {
+		return nil, mockforge.NewInvalidConfigError(fmt.Sprintf("invalid JSON in EqualToJson: %v", err), nil)
+	}
+	return decoded, nil
+}
+
+func (m StringValueMatcher) unsupported() error {
+	return mockforge.NewInvalidConfigError(
+		fmt.Sprintf("%s matching is not supported here", m.strategy),
+		map[string]interface{}{"value": m.value},
+	)
+}
+
+// anchored makes a WireMock pattern, which must match the whole value,
+// match the whole value in MockForge too
+func anchored(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
@@ -0,0 +1,506 @@
+// Package wiremockcompat mirrors the go-wiremock client API on top of a
+// MockForge server, so suites written against go-wiremock can switch by
+// changing their import and client construction:
+//
+//	import wiremock "github.com/SaaSy-Solutions/mockforge/sdk/go/wiremockcompat"
+//
+//	client := wiremock.NewClient(server)
+//	client.StubFor(wiremock.Get(wiremock.URLPathEqualTo("/orders/42")).
+//	    WithHeader("Accept", wiremock.EqualTo("application/json")).
+//	    WillReturnResponse(wiremock.NewResponse().
+//	        WithStatus(http.StatusOK).
+//	        WithJSONBody(map[string]string{"id": "42"})))
+//
+// Matchers MockForge cannot express, such as NotMatching or non-exact query
+// parameters, make StubFor and Verify return an InvalidConfigError rather
+// than silently matching more requests.
+package wiremockcompat
+
+import (
+	"net/http"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// AnyMethod matches requests of every method. Only verifications may use
+// it; MockForge stubs match a single method.
+const AnyMethod = "ANY"
+
+// wiremockDefaultPriority is the priority WireMock gives stubs without one
+const wiremockDefaultPriority = 5
+
+// Client is a go-wiremock style client for a MockForge server
+type Client struct {
+	server *mockforge.MockServer
+}
+
+// NewClient returns a client that stubs and verifies on server
+func NewClient(server *mockforge.MockServer) *Client {
+	return &Client{server: server}
+}
+
+// StubFor registers the stub rule
+func (c *Client) StubFor(stubRule *StubRule) error {
+	stub, err := stubRule.stub()
+	if err != nil {
+		return err
+	}
+	id, err := c.server.CreateStub(stub)
+	if err != nil {
+		return err
+	}
+	stubRule.uuid = id
+	return nil
+}
+
+// DeleteStub removes a stub rule registered with StubFor
+func (c *Client) DeleteStub(stubRule *StubRule) error {
+	return c.DeleteStubByID(stubRule.UUID())
+}
+
+// DeleteStubByID removes the stub registered under id
+func (c *Client) DeleteStubByID(id string) error {
+	if id == "" {
+		return mockforge.NewInvalidConfigError("stub rule was not registered", nil)
+	}
+	return c.server.DeleteStub(id)
+}
+
+// Clear removes every stub
+func (c *Client) Clear() error {
+	return c.server.ClearStubs()
+}
+
+// Reset removes every stub, discards the request journal, and resets
+// scenarios
+func (c *Client) Reset() error {
+	if err := c.server.ClearStubs(); err != nil {
+		return err
+	}
+	if err := c.server.ResetRequestJournal(); err != nil {
+		return err
+	}
+	return c.server.ResetScenarios()
+}
+
+// ResetAllScenarios moves every scenario back to the Started state
+func (c *Client) ResetAllScenarios() error {
+	return c.server.ResetScenarios()
+}
+
+// GetCountRequests returns how many received requests match r
+func (c *Client) GetCountRequests(r *Request) (int64, error) {
+	pattern, err := r.verificationRequest()
+	if err != nil {
+		return 0, err
+	}
+	count, err := c.server.CountRequests(pattern)
+	return int64(count), err
+}
+
+// Verify reports whether exactly expectedCount received requests match r
+func (c *Client) Verify(r *Request, expectedCount int64) (bool, error) {
+	pattern, err := r.verificationRequest()
+	if err != nil {
+		return false, err
+	}
+	result, err := c.server.Verify(pattern, mockforge.Exactly(int(expectedCount)))
+	if err != nil {
+		return false, err
+	}
+	return result.Matched, nil
+}
+
+// Request describes the requests a stub rule or verification matches
+type Request struct {
+	method       string
+	urlMatcher   URLMatcher
+	headers      map[string]StringValueMatcher
+	queryParams  map[string]StringValueMatcher
+	cookies      map[string]StringValueMatcher
+	bodyPatterns []StringValueMatcher
+}
+
+// NewRequest returns a request pattern for method and URL
+func NewRequest(method string, urlMatcher URLMatcher) *Request {
+	return &Request{method: method, urlMatcher: urlMatcher}
+}
+
+// WithHeader requires a header to match
+func (r *Request) WithHeader(header string, matcher StringValueMatcher) *Request {
+	if r.headers == nil {
+		r.headers = make(map[string]StringValueMatcher)
+	}
+	r.headers[header] = matcher
+	return r
+}
+
+// WithQueryParam requires a query parameter to match
+func (r *Request) WithQueryParam(param string, matcher StringValueMatcher) *Request {
+	if r.queryParams == nil {
+		r.queryParams = make(map[string]StringValueMatcher)
+	}
+	r.queryParams[param] = matcher
+	return r
+}
+
+// WithCookie requires a cookie to match
+func (r *Request) WithCookie(cookie string, matcher StringValueMatcher) *Request {
+	if r.cookies == nil {
+		r.cookies = make(map[string]StringValueMatcher)
+	}
+	r.cookies[cookie] = matcher
+	return r
+}
+
+// WithBodyPattern requires the body to match
+func (r *Request) WithBodyPattern(matcher StringValueMatcher) *Request {
+	r.bodyPatterns = append(r.bodyPatterns, matcher)
+	return r
+}
+
+// match returns the request as a stub path and request match
+func (r *Request) match() (string, *mockforge.RequestMatch, error) {
+	path, query, err := r.urlMatcher.stubPath()
+	if err != nil {
+		return "", nil, err
+	}
+	match := &mockforge.RequestMatch{}
+	if query, err = exactValues(query, r.queryParams); err != nil {
+		return "", nil, err
+	}
+	if len(query) > 0 {
+		match.QueryParams = query
+	}
+	if match.Cookies, err = exactValues(nil, r.cookies); err != nil {
+		return "", nil, err
+	}
+
+	for name, matcher := range r.headers {
+		if matcher.strategy == ParamAbsent {
+			match.HeadersAbsent = append(match.HeadersAbsent, name)
+			continue
+		}
+		pattern, err := matcher.pattern()
+		if err != nil {
+			return "", nil, err
+		}
+		if match.Headers == nil {
+			match.Headers = make(map[string]string)
+		}
+		match.Headers[name] = pattern
+	}
+
+	for _, matcher := range r.bodyPatterns {
+		if matcher.strategy == ParamEqualToJson {
+			if match.BodyJSON, err = matcher.json(); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+		if match.BodyPattern != "" {
+			return "", nil, mockforge.NewInvalidConfigError("only one non-JSON body pattern is supported", nil)
+		}
+		if match.BodyPattern, err = matcher.pattern(); err != nil {
+			return "", nil, err
+		}
+	}
+	return path, match, nil
+}
+
+// verificationRequest returns the request as a journal query
+func (r *Request) verificationRequest() (mockforge.VerificationRequest, error) {
+	path, query, err := r.urlMatcher.verificationPath()
+	if err != nil {
+		return mockforge.VerificationRequest{}, err
+	}
+	pattern := mockforge.VerificationRequest{Path: path}
+	if r.method != AnyMethod {
+		pattern.Method = r.method
+	}
+	if query, err = exactValues(query, r.queryParams); err != nil {
+		return pattern, err
+	}
+	if len(query) > 0 {
+		pattern.QueryParams = query
+	}
+	if len(r.cookies) > 0 {
+		return pattern, mockforge.NewInvalidConfigError("cookies cannot be verified", nil)
+	}
+
+	for name, matcher := range r.headers {
+		switch matcher.strategy {
+		case ParamAbsent:
+			pattern.HeadersAbsent = append(pattern.HeadersAbsent, name)
+		case ParamEqualTo:
+			if pattern.Headers == nil {
+				pattern.Headers = make(map[string]string)
+			}
+			pattern.Headers[name] = matcher.value
+		default:
+			regex, err := matcher.pattern()
+			if err != nil {
+				return pattern, err
+			}
+			if pattern.HeaderPatterns == nil {
+				pattern.HeaderPatterns = make(map[string]string)
+			}
+			pattern.HeaderPatterns[name] = regex
+		}
+	}
+
+	for _, matcher := range r.bodyPatterns {
+		if matcher.strategy == ParamEqualToJson {
+			body, err := matcher.json()
+			if err != nil {
+				return pattern, err
+			}
+			// "$" selects the whole body
+			pattern.BodyJSONPath = map[string]interface{}{"$": body}
+			continue
+		}
+		if pattern.BodyPattern != "" {
+			return pattern, mockforge.NewInvalidConfigError("only one non-JSON body pattern is supported", nil)
+		}
+		if pattern.BodyPattern, err = matcher.pattern(); err != nil {
+			return pattern, err
+		}
+	}
+	return pattern, nil
+}
+
+// exactValues merges EqualTo matchers into values, rejecting other matchers
+func exactValues(values map[string]string, matchers map[string]StringValueMatcher) (map[string]string, error) {
+	for name, matcher := range matchers {
+		if matcher.strategy != ParamEqualTo {
+			return nil, matcher.unsupported()
+		}
+		if values == nil {
+			values = make(map[string]string)
+		}
+		values[name] = matcher.value
+	}
+	return values, nil
+}
+
+// StubRule is a stub under construction
+type StubRule struct {
+	request       *Request
+	response      *Response
+	priority      *int64
+	scenarioName  string
+	requiredState string
+	newState      string
+	uuid          string
+}
+
+// NewStubRule returns a stub rule for method and URL
+func NewStubRule(method string, urlMatcher URLMatcher) *StubRule {
+	return &StubRule{request: NewRequest(method, urlMatcher), response: NewResponse()}
+}
+
+// Get returns a stub rule for GET requests
+func Get(urlMatcher URLMatcher) *StubRule {
+	return NewStubRule(http.MethodGet, urlMatcher)
+}
+
+// Post returns a stub rule for POST requests
+func Post(urlMatcher URLMatcher) *StubRule {
+	return NewStubRule(http.MethodPost, urlMatcher)
+}
+
+// Put returns a stub rule for PUT requests
+func Put(urlMatcher URLMatcher) *StubRule {
+	return NewStubRule(http.MethodPut, urlMatcher)
+}
+
+// Patch returns a stub rule for PATCH requests
+func Patch(urlMatcher URLMatcher) *StubRule {
+	return NewStubRule(http.MethodPatch, urlMatcher)
+}
+
+// Delete returns a stub rule for DELETE requests
+func Delete(urlMatcher URLMatcher) *StubRule {
+	return NewStubRule(http.MethodDelete, urlMatcher)
+}
+
+// Head returns a stub rule for HEAD requests
+func Head(urlMatcher URLMatcher) *StubRule {
+	return NewStubRule(http.MethodHead, urlMatcher)
+}
+
+// Options returns a stub rule for OPTIONS requests
+func Options(urlMatcher URLMatcher) *StubRule {
+	return NewStubRule(http.MethodOptions, urlMatcher)
+}
+
+// WithHeader requires a header to match
+func (s *StubRule) WithHeader(header string, matcher StringValueMatcher) *StubRule {
+	s.request.WithHeader(header, matcher)
+	return s
+}
+
+// WithQueryParam requires a query parameter to match
+func (s *StubRule) WithQueryParam(param string, matcher StringValueMatcher) *StubRule {
+	s.request.WithQueryParam(param, matcher)
+	return s
+}
+
+// WithCookie requires a cookie to match
+func (s *StubRule) WithCookie(cookie string, matcher StringValueMatcher) *StubRule {
+	s.request.WithCookie(cookie, matcher)
+	return s
+}
+
+// WithBodyPattern requires the body to match
+func (s *StubRule) WithBodyPattern(matcher StringValueMatcher) *StubRule {
+	s.request.WithBodyPattern(matcher)
+	return s
+}
+
+// WillReturnResponse sets the response
+func (s *StubRule) WillReturnResponse(response *Response) *StubRule {
+	s.response = response
+	return s
+}
+
+// WillReturn sets a text response
+func (s *StubRule) WillReturn(body string, headers map[string]string, status int64) *StubRule {
+	s.response = NewResponse().WithBody(body).WithHeaders(headers).WithStatus(status)
+	return s
+}
+
+// WillReturnJSON sets a JSON response
+func (s *StubRule) WillReturnJSON(json interface{}, headers map[string]string, status int64) *StubRule {
+	s.response = NewResponse().WithJSONBody(json).WithHeaders(headers).WithStatus(status)
+	return s
+}
+
+// WithFixedDelayMilliseconds delays the response
+func (s *StubRule) WithFixedDelayMilliseconds(delay time.Duration) *StubRule {
+	s.response.WithFixedDelay(delay)
+	return s
+}
+
+// AtPriority sets the WireMock priority; 1 is matched first
+func (s *StubRule) AtPriority(priority int64) *StubRule {
+	s.priority = &priority
+	return s
+}
+
+// InScenario makes the stub part of a scenario
+func (s *StubRule) InScenario(scenarioName string) *StubRule {
+	s.scenarioName = scenarioName
+	return s
+}
+
+// WhenScenarioStateIs restricts the stub to a scenario state
+func (s *StubRule) WhenScenarioStateIs(scenarioState string) *StubRule {
+	s.requiredState = scenarioState
+	return s
+}
+
+// WillSetStateTo moves the scenario to a state when the stub matches
+func (s *StubRule) WillSetStateTo(scenarioState string) *StubRule {
+	s.newState = scenarioState
+	return s
+}
+
+// Request returns the stub's request pattern, for use with Verify
+func (s *StubRule) Request() *Request {
+	return s.request
+}
+
+// UUID returns the ID the server assigned once StubFor registered the rule
+func (s *StubRule) UUID() string {
+	return s.uuid
+}
+
+// stub converts the rule to a MockForge stub
+func (s *StubRule) stub() (mockforge.ResponseStub, error) {
+	if s.request.method == AnyMethod {
+		return mockforge.ResponseStub{}, mockforge.NewInvalidConfigError("stubs must match a single method", nil)
+	}
+	path, match, err := s.request.match()
+	if err != nil {
+		return mockforge.ResponseStub{}, err
+	}
+
+	stub := mockforge.ResponseStub{
+		Method:        s.request.method,
+		Path:          path,
+		Status:        int(s.response.status),
+		Headers:       s.response.headers,
+		Body:          s.response.body,
+		Scenario:      s.scenarioName,
+		RequiredState: s.requiredState,
+		NewState:      s.newState,
+	}
+	if match.Headers != nil || match.HeadersAbsent != nil || match.QueryParams != nil ||
+		match.Cookies != nil || match.BodyJSON != nil || match.BodyPattern != "" {
+		stub.Match = match
+	}
+	// WireMock matches lower priorities first, MockForge higher ones
+	if s.priority != nil {
+		stub.Priority = wiremockDefaultPriority - int(*s.priority)
+	}
+	if s.response.delay > 0 {
+		stub.Latency = mockforge.FixedLatency(s.response.delay)
+	}
+	return stub, nil
+}
+
+// Response is a stub's response under construction
+type Response struct {
+	status  int64
+	headers map[string]string
+	body    interface{}
+	delay   time.Duration
+}
+
+// NewResponse returns an empty 200 response
+func NewResponse() *Response {
+	return &Response{status: http.StatusOK}
+}
+
+// WithStatus sets the status code
+func (r *Response) WithStatus(status int64) *Response {
+	r.status = status
+	return r
+}
+
+// WithBody sets a text body
+func (r *Response) WithBody(body string) *Response {
+	r.body = body
+	return r
+}
+
+// WithJSONBody sets a JSON body and content type
+func (r *Response) WithJSONBody(body interface{}) *Response {
+	r.body = body
+	return r.WithHeader("Content-Type", "application/json")
+}
+
+// WithHeader sets a header
+func (r *Response) WithHeader(key, value string) *Response {
+	if r.headers == nil {
+		r.headers = make(map[string]string)
+	}
+	r.headers[key] = value
+	return r
+}
+
+// WithHeaders sets several headers
+func (r *Response) WithHeaders(headers map[string]string) *Response {
+	for key, value := range headers {
+		r.WithHeader(key, value)
+	}
+	return r
+}
+
+// WithFixedDelay delays the response
+func (r *Response) WithFixedDelay(delay time.Duration) *Response {
+	r.delay = delay
+	return r
+}
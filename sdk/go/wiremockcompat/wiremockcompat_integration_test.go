@@ -0,0 +1,153 @@
+//go:build integration
+// +build integration
+
+// Integration tests that require the MockForge CLI to be installed and on PATH.
+// Run with: go test -tags=integration
+package wiremockcompat
+
+import (
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+// startCLIServer starts a server with the MockForge CLI for the rest of the
+// test, skipping the test when the CLI is not on PATH
+func startCLIServer(t *testing.T) *mockforge.MockServer {
+	t.Helper()
+	if _, err := exec.LookPath("mockforge"); err != nil {
+		t.Skip("Requires MockForge CLI to be installed")
+	}
+	server := mockforge.NewMockServer(mockforge.MockServerConfig{})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+	return server
+}
+
+// send sends a request to the server and returns the response status and body
+func send(t *testing.T, server *mockforge.MockServer, method, url string, headers map[string]string, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL()+url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestClientAgainstServer(t *testing.T) {
+	server := startCLIServer(t)
+	client := NewClient(server)
+
+	order := Post(URLEqualTo("/orders?region=eu")).
+		WithHeader("Authorization", Matching("Bearer .+")).
+		WithHeader("X-Debug", Absent()).
+		WithBodyPattern(EqualToJson(`{"sku":"a"}`)).
+		WillReturnResponse(NewResponse().
+			WithStatus(http.StatusCreated).
+			WithJSONBody(map[string]string{"id": "42"}).
+			WithFixedDelay(20 * time.Millisecond))
+	lookup := Get(URLPathMatching("/orders/[0-9]+")).WillReturn("found", nil, 200)
+	for _, rule := range []*StubRule{order, lookup} {
+		if err := client.StubFor(rule); err != nil {
+			t.Fatalf("Failed to register stub rule: %v", err)
+		}
+	}
+	if order.UUID() == "" {
+		t.Error("Expected the server to assign the stub an ID")
+	}
+
+	auth := map[string]string{"Authorization": "Bearer t", "Content-Type": "application/json"}
+	if status, body := send(t, server, "POST", "/orders?region=eu", auth, `{"sku":"a"}`); status != 201 || !strings.Contains(body, `"42"`) {
+		t.Errorf("Expected the order created, got %d %s", status, body)
+	}
+	for _, tc := range []struct {
+		name    string
+		url     string
+		headers map[string]string
+		body    string
+	}{
+		{"other region", "/orders?region=us", auth, `{"sku":"a"}`},
+		{"no credentials", "/orders?region=eu", map[string]string{"Content-Type": "application/json"}, `{"sku":"a"}`},
+		{"debug header", "/orders?region=eu", map[string]string{"Authorization": "Bearer t", "X-Debug": "1"}, `{"sku":"a"}`},
+		{"other body", "/orders?region=eu", auth, `{"sku":"b"}`},
+	} {
+		if status, _ := send(t, server, "POST", tc.url, tc.headers, tc.body); status != 404 {
+			t.Errorf("%s: expected 404, got %d", tc.name, status)
+		}
+	}
+	if status, body := send(t, server, "GET", "/orders/7", nil, ""); status != 200 || body != "found" {
+		t.Errorf("Expected the regex stub, got %d %s", status, body)
+	}
+	if status, _ := send(t, server, "GET", "/orders/x", nil, ""); status != 404 {
+		t.Errorf("Expected 404 for a non-numeric ID, got %d", status)
+	}
+
+	// Credentials are journaled by name only, so verify on another header
+	ok, err := client.Verify(NewRequest(AnyMethod, URLPathEqualTo("/orders")).WithHeader("Content-Type", Contains("json")), 4)
+	if err != nil || !ok {
+		t.Errorf("Expected four JSON order requests, got %v (%v)", ok, err)
+	}
+	if count, err := client.GetCountRequests(lookup.Request()); err != nil || count != 2 {
+		t.Errorf("Expected two lookups, got %d (%v)", count, err)
+	}
+
+	if err := client.DeleteStub(lookup); err != nil {
+		t.Fatalf("Failed to delete stub rule: %v", err)
+	}
+	if status, _ := send(t, server, "GET", "/orders/7", nil, ""); status != 404 {
+		t.Errorf("Expected 404 after deleting the stub, got %d", status)
+	}
+	if err := client.Reset(); err != nil {
+		t.Fatalf("Failed to reset: %v", err)
+	}
+	if count, err := client.GetCountRequests(NewRequest(AnyMethod, URLPathMatching(".*"))); err != nil || count != 0 {
+		t.Errorf("Expected an empty journal after reset, got %d (%v)", count, err)
+	}
+}
+
+func TestScenariosAgainstServer(t *testing.T) {
+	server := startCLIServer(t)
+	client := NewClient(server)
+
+	rules := []*StubRule{
+		Get(URLPathEqualTo("/cart")).InScenario("cart").WhenScenarioStateIs(mockforge.ScenarioStarted).WillReturn("empty", nil, 200),
+		Post(URLPathEqualTo("/cart")).InScenario("cart").WhenScenarioStateIs(mockforge.ScenarioStarted).WillSetStateTo("filled").WillReturn("added", nil, 201),
+		Get(URLPathEqualTo("/cart")).InScenario("cart").WhenScenarioStateIs("filled").WillReturn("one item", nil, 200),
+	}
+	for _, rule := range rules {
+		if err := client.StubFor(rule); err != nil {
+			t.Fatalf("Failed to register stub rule: %v", err)
+		}
+	}
+
+	for i, want := range []struct {
+		method string
+		body   string
+	}{{"GET", "empty"}, {"POST", "added"}, {"GET", "one item"}} {
+		if _, body := send(t, server, want.method, "/cart", nil, ""); body != want.body {
+			t.Errorf("Step %d: expected %q, got %q", i+1, want.body, body)
+		}
+	}
+	if err := client.ResetAllScenarios(); err != nil {
+		t.Fatalf("Failed to reset scenarios: %v", err)
+	}
+	if _, body := send(t, server, "GET", "/cart", nil, ""); body != "empty" {
+		t.Errorf("Expected the started state after reset, got %q", body)
+	}
+}
@@ -0,0 +1,96 @@
+package wiremockcompat
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mockforge "github.com/SaaSy-Solutions/mockforge/sdk/go"
+)
+
+func TestStubRule(t *testing.T) {
+	stub, err := Post(URLEqualTo("/orders?region=eu")).
+		WithHeader("Authorization", Matching("Bearer .+")).
+		WithHeader("X-Debug", Absent()).
+		WithBodyPattern(EqualToJson(`{"sku":"a"}`)).
+		WillReturnResponse(NewResponse().
+			WithStatus(http.StatusCreated).
+			WithJSONBody(map[string]string{"id": "42"}).
+			WithFixedDelay(20 * time.Millisecond)).
+		AtPriority(1).
+		InScenario("checkout").
+		WillSetStateTo("ordered").
+		stub()
+	if err != nil {
+		t.Fatalf("Failed to convert stub rule: %v", err)
+	}
+	if stub.Method != "POST" || stub.Path != "/orders" || stub.Status != 201 {
+		t.Errorf("Unexpected stub %+v", stub)
+	}
+	if stub.Match == nil || stub.Match.QueryParams["region"] != "eu" || stub.Match.Headers["Authorization"] != "^(?:Bearer .+)$" {
+		t.Fatalf("Unexpected request match %+v", stub.Match)
+	}
+	if len(stub.Match.HeadersAbsent) != 1 || stub.Match.BodyJSON.(map[string]interface{})["sku"] != "a" {
+		t.Errorf("Unexpected request match %+v", stub.Match)
+	}
+	if stub.Headers["Content-Type"] != "application/json" || stub.Latency == nil {
+		t.Errorf("Expected a JSON response with latency, got %+v", stub)
+	}
+	// WireMock priority 1 beats the default priority 5
+	if stub.Priority != 4 || stub.Scenario != "checkout" || stub.NewState != "ordered" {
+		t.Errorf("Unexpected priority or scenario %+v", stub)
+	}
+
+	stub, err = Get(URLPathMatching("/orders/[0-9]+")).WillReturn("ok", nil, 200).stub()
+	if err != nil || stub.Path != mockforge.PathRegex("^(?:/orders/[0-9]+)$") || stub.Match != nil {
+		t.Errorf("Expected an unrestricted regex stub, got %+v (%v)", stub, err)
+	}
+
+	if _, err := Get(URLPathEqualTo("/orders")).WithHeader("Accept", NotMatching("xml")).stub(); err == nil {
+		t.Error("Expected an error for NotMatching")
+	}
+	if _, err := Get(URLPathEqualTo("/orders")).WithQueryParam("page", Matching("[0-9]+")).stub(); err == nil {
+		t.Error("Expected an error for a non-exact query parameter")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var patterns []mockforge.VerificationRequest
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pattern  mockforge.VerificationRequest `json:"pattern"`
+			Expected mockforge.VerificationCount   `json:"expected"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		patterns = append(patterns, body.Pattern)
+		json.NewEncoder(w).Encode(mockforge.VerificationResult{Matched: body.Expected.Satisfied(2), Count: 2})
+	}))
+	defer api.Close()
+	host, port, _ := net.SplitHostPort(api.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	client := NewClient(mockforge.NewMockServer(mockforge.MockServerConfig{Host: host, Port: portNum}))
+
+	request := NewRequest(AnyMethod, URLPathEqualTo("/orders")).
+		WithHeader("Accept", EqualTo("application/json")).
+		WithHeader("Authorization", Contains("Bearer"))
+	ok, err := client.Verify(request, 2)
+	if err != nil || !ok {
+		t.Fatalf("Expected verification to pass, got %v (%v)", ok, err)
+	}
+	pattern := patterns[0]
+	if pattern.Method != "" || pattern.Path != "/orders" || pattern.Headers["Accept"] != "application/json" || pattern.HeaderPatterns["Authorization"] != "Bearer" {
+		t.Errorf("Unexpected verification pattern %+v", pattern)
+	}
+
+	count, err := client.GetCountRequests(Get(URLPathEqualTo("/orders")).Request())
+	if err != nil || count != 2 {
+		t.Errorf("Expected a count of 2, got %d (%v)", count, err)
+	}
+	if patterns[1].Method != "GET" {
+		t.Errorf("Expected the stub rule's method, got %+v", patterns[1])
+	}
+}